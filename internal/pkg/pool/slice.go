@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"math/bits"
+	"sync"
+)
+
+const (
+	minSliceClass = 3  // 8 elements
+	maxSliceClass = 20 // 1M elements
+)
+
+// SlicePool recycles slices in power of two size classes.  Slices larger
+// than the largest class are allocated and left to the garbage collector.
+// The zero value is ready to use.
+type SlicePool[T any] struct {
+	classes [maxSliceClass - minSliceClass + 1]sync.Pool
+}
+
+// Get returns an empty slice with capacity at least n.
+func (p *SlicePool[T]) Get(n int) []T {
+	c := sliceClass(n)
+	if c > maxSliceClass {
+		return make([]T, 0, n)
+	}
+
+	if v := p.classes[c-minSliceClass].Get(); v != nil {
+		return (*v.(*[]T))[:0]
+	}
+	return make([]T, 0, 1<<c)
+}
+
+// Put recycles s, which must no longer be referenced.  Elements are zeroed
+// so that they do not hold on to memory while pooled.
+func (p *SlicePool[T]) Put(s []T) {
+	// Round down, as s may be a tail of a pooled slice.
+	c := bits.Len(uint(cap(s))) - 1
+	if c < minSliceClass {
+		return
+	}
+	c = min(c, maxSliceClass)
+
+	s = s[:cap(s)]
+	clear(s)
+	s = s[: 0 : 1<<c]
+	p.classes[c-minSliceClass].Put(&s)
+}
+
+// Smallest class holding n elements.
+func sliceClass(n int) int {
+	if n <= 1<<minSliceClass {
+		return minSliceClass
+	}
+	return bits.Len(uint(n - 1))
+}
@@ -9,8 +9,35 @@ type LogEntry struct {
 	Stream    string  `msg:"s" json:"s"`
 	Timestamp int64   `msg:"t" json:"t"`
 	Matches   [][]int `msg:"m,omitempty" json:"m,omitempty"`
+
+	// IngestTime is an optional secondary timestamp for logs that carry both an
+	// event and an ingestion clock.  Timestamp remains the primary used for windowing.
+	IngestTime int64 `msg:"i,omitempty" json:"i,omitempty"`
+
+	// Props are values extracted from the line by the terms that matched it,
+	// for example the object emitted by a jq term.  Treat as immutable; the map
+	// may be shared between copies of the entry.
+	Props map[string]any `msg:"p,omitempty" json:"p,omitempty"`
+
+	// Offset and LineNo locate the line in its source; LineNo is one based.
+	// Both are zero if unknown.
+	Offset int64 `msg:"o,omitempty" json:"o,omitempty"`
+	LineNo int64 `msg:"n,omitempty" json:"n,omitempty"`
+
+	// Labels are optional metadata about the source, for example the pod or
+	// host that emitted the line.  Treat as immutable, as with Props.
+	Labels map[string]string `msg:"b,omitempty" json:"b,omitempty"`
+
+	// Severity is the level of the line, if the parser could extract one;
+	// SevUnknown otherwise.  See SeverityT.
+	Severity SeverityT `msg:"v,omitempty" json:"v,omitempty"`
 }
 
+// SeverityT is the level of a line.  Values follow the severity numbers of the
+// OpenTelemetry log data model: each level spans four numbers, from the named
+// constant up, so that finer grades order within their level.
+type SeverityT int32
+
 // Uses msgpack size as an estimate;  not exactly right.
 // Cannot use e.MsgSize() because it doesn't properly account for omitted matches
 
@@ -24,6 +51,30 @@ func (z LogEntry) UpperBound() (s int) {
 			s += msgp.ArrayHeaderSize + (len(z.Matches[za0001]) * (msgp.IntSize))
 		}
 	}
+	if z.IngestTime != 0 {
+		s += 2 + msgp.Int64Size
+	}
+	if z.Props != nil {
+		s += 2 + msgp.MapHeaderSize
+		for k, v := range z.Props {
+			s += msgp.StringPrefixSize + len(k) + msgp.GuessSize(v)
+		}
+	}
+	if z.Offset != 0 {
+		s += 2 + msgp.Int64Size
+	}
+	if z.LineNo != 0 {
+		s += 2 + msgp.Int64Size
+	}
+	if z.Labels != nil {
+		s += 2 + msgp.MapHeaderSize
+		for k, v := range z.Labels {
+			s += msgp.StringPrefixSize + len(k) + msgp.StringPrefixSize + len(v)
+		}
+	}
+	if z.Severity != 0 {
+		s += 2 + msgp.Int32Size
+	}
 	return
 
 	//return e.Msgsize()
@@ -74,6 +74,90 @@ func (z *LogEntry) DecodeMsg(dc *msgp.Reader) (err error) {
 					}
 				}
 			}
+		case "i":
+			z.IngestTime, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "IngestTime")
+				return
+			}
+		case "p":
+			var zb0004 uint32
+			zb0004, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+			if z.Props == nil {
+				z.Props = make(map[string]interface{}, zb0004)
+			} else if len(z.Props) > 0 {
+				clear(z.Props)
+			}
+			for zb0004 > 0 {
+				zb0004--
+				var za0003 string
+				za0003, err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Props")
+					return
+				}
+				var za0004 interface{}
+				za0004, err = dc.ReadIntf()
+				if err != nil {
+					err = msgp.WrapError(err, "Props", za0003)
+					return
+				}
+				z.Props[za0003] = za0004
+			}
+		case "o":
+			z.Offset, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "n":
+			z.LineNo, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "LineNo")
+				return
+			}
+		case "b":
+			var zb0005 uint32
+			zb0005, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Labels")
+				return
+			}
+			if z.Labels == nil {
+				z.Labels = make(map[string]string, zb0005)
+			} else if len(z.Labels) > 0 {
+				clear(z.Labels)
+			}
+			for zb0005 > 0 {
+				zb0005--
+				var za0005 string
+				za0005, err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Labels")
+					return
+				}
+				var za0006 string
+				za0006, err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Labels", za0005)
+					return
+				}
+				z.Labels[za0005] = za0006
+			}
+		case "v":
+			{
+				var zb0006 int32
+				zb0006, err = dc.ReadInt32()
+				if err != nil {
+					err = msgp.WrapError(err, "Severity")
+					return
+				}
+				z.Severity = SeverityT(zb0006)
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -88,13 +172,37 @@ func (z *LogEntry) DecodeMsg(dc *msgp.Reader) (err error) {
 // EncodeMsg implements msgp.Encodable
 func (z *LogEntry) EncodeMsg(en *msgp.Writer) (err error) {
 	// check for omitted fields
-	zb0001Len := uint32(4)
-	var zb0001Mask uint8 /* 4 bits */
+	zb0001Len := uint32(10)
+	var zb0001Mask uint16 /* 10 bits */
 	_ = zb0001Mask
 	if z.Matches == nil {
 		zb0001Len--
 		zb0001Mask |= 0x8
 	}
+	if z.IngestTime == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	if z.Props == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	if z.Offset == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x40
+	}
+	if z.LineNo == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x80
+	}
+	if z.Labels == nil {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.Severity == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
 	// variable map header, size zb0001Len
 	err = en.Append(0x80 | uint8(zb0001Len))
 	if err != nil {
@@ -159,6 +267,102 @@ func (z *LogEntry) EncodeMsg(en *msgp.Writer) (err error) {
 				}
 			}
 		}
+		if (zb0001Mask & 0x10) == 0 { // if not omitted
+			// write "i"
+			err = en.Append(0xa1, 0x69)
+			if err != nil {
+				return
+			}
+			err = en.WriteInt64(z.IngestTime)
+			if err != nil {
+				err = msgp.WrapError(err, "IngestTime")
+				return
+			}
+		}
+		if (zb0001Mask & 0x20) == 0 { // if not omitted
+			// write "p"
+			err = en.Append(0xa1, 0x70)
+			if err != nil {
+				return
+			}
+			err = en.WriteMapHeader(uint32(len(z.Props)))
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+			for za0003, za0004 := range z.Props {
+				err = en.WriteString(za0003)
+				if err != nil {
+					err = msgp.WrapError(err, "Props")
+					return
+				}
+				err = en.WriteIntf(za0004)
+				if err != nil {
+					err = msgp.WrapError(err, "Props", za0003)
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x40) == 0 { // if not omitted
+			// write "o"
+			err = en.Append(0xa1, 0x6f)
+			if err != nil {
+				return
+			}
+			err = en.WriteInt64(z.Offset)
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		}
+		if (zb0001Mask & 0x80) == 0 { // if not omitted
+			// write "n"
+			err = en.Append(0xa1, 0x6e)
+			if err != nil {
+				return
+			}
+			err = en.WriteInt64(z.LineNo)
+			if err != nil {
+				err = msgp.WrapError(err, "LineNo")
+				return
+			}
+		}
+		if (zb0001Mask & 0x100) == 0 { // if not omitted
+			// write "b"
+			err = en.Append(0xa1, 0x62)
+			if err != nil {
+				return
+			}
+			err = en.WriteMapHeader(uint32(len(z.Labels)))
+			if err != nil {
+				err = msgp.WrapError(err, "Labels")
+				return
+			}
+			for za0005, za0006 := range z.Labels {
+				err = en.WriteString(za0005)
+				if err != nil {
+					err = msgp.WrapError(err, "Labels")
+					return
+				}
+				err = en.WriteString(za0006)
+				if err != nil {
+					err = msgp.WrapError(err, "Labels", za0005)
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x200) == 0 { // if not omitted
+			// write "v"
+			err = en.Append(0xa1, 0x76)
+			if err != nil {
+				return
+			}
+			err = en.WriteInt32(int32(z.Severity))
+			if err != nil {
+				err = msgp.WrapError(err, "Severity")
+				return
+			}
+		}
 	}
 	return
 }
@@ -167,13 +371,37 @@ func (z *LogEntry) EncodeMsg(en *msgp.Writer) (err error) {
 func (z *LogEntry) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
 	// check for omitted fields
-	zb0001Len := uint32(4)
-	var zb0001Mask uint8 /* 4 bits */
+	zb0001Len := uint32(10)
+	var zb0001Mask uint16 /* 10 bits */
 	_ = zb0001Mask
 	if z.Matches == nil {
 		zb0001Len--
 		zb0001Mask |= 0x8
 	}
+	if z.IngestTime == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	if z.Props == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	if z.Offset == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x40
+	}
+	if z.LineNo == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x80
+	}
+	if z.Labels == nil {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.Severity == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
 	// variable map header, size zb0001Len
 	o = append(o, 0x80|uint8(zb0001Len))
 
@@ -199,6 +427,48 @@ func (z *LogEntry) MarshalMsg(b []byte) (o []byte, err error) {
 				}
 			}
 		}
+		if (zb0001Mask & 0x10) == 0 { // if not omitted
+			// string "i"
+			o = append(o, 0xa1, 0x69)
+			o = msgp.AppendInt64(o, z.IngestTime)
+		}
+		if (zb0001Mask & 0x20) == 0 { // if not omitted
+			// string "p"
+			o = append(o, 0xa1, 0x70)
+			o = msgp.AppendMapHeader(o, uint32(len(z.Props)))
+			for za0003, za0004 := range z.Props {
+				o = msgp.AppendString(o, za0003)
+				o, err = msgp.AppendIntf(o, za0004)
+				if err != nil {
+					err = msgp.WrapError(err, "Props", za0003)
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x40) == 0 { // if not omitted
+			// string "o"
+			o = append(o, 0xa1, 0x6f)
+			o = msgp.AppendInt64(o, z.Offset)
+		}
+		if (zb0001Mask & 0x80) == 0 { // if not omitted
+			// string "n"
+			o = append(o, 0xa1, 0x6e)
+			o = msgp.AppendInt64(o, z.LineNo)
+		}
+		if (zb0001Mask & 0x100) == 0 { // if not omitted
+			// string "b"
+			o = append(o, 0xa1, 0x62)
+			o = msgp.AppendMapHeader(o, uint32(len(z.Labels)))
+			for za0005, za0006 := range z.Labels {
+				o = msgp.AppendString(o, za0005)
+				o = msgp.AppendString(o, za0006)
+			}
+		}
+		if (zb0001Mask & 0x200) == 0 { // if not omitted
+			// string "v"
+			o = append(o, 0xa1, 0x76)
+			o = msgp.AppendInt32(o, int32(z.Severity))
+		}
 	}
 	return
 }
@@ -271,6 +541,90 @@ func (z *LogEntry) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					}
 				}
 			}
+		case "i":
+			z.IngestTime, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "IngestTime")
+				return
+			}
+		case "p":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Props")
+				return
+			}
+			if z.Props == nil {
+				z.Props = make(map[string]interface{}, zb0004)
+			} else if len(z.Props) > 0 {
+				clear(z.Props)
+			}
+			for zb0004 > 0 {
+				var za0004 interface{}
+				zb0004--
+				var za0003 string
+				za0003, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Props")
+					return
+				}
+				za0004, bts, err = msgp.ReadIntfBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Props", za0003)
+					return
+				}
+				z.Props[za0003] = za0004
+			}
+		case "o":
+			z.Offset, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "n":
+			z.LineNo, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "LineNo")
+				return
+			}
+		case "b":
+			var zb0005 uint32
+			zb0005, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Labels")
+				return
+			}
+			if z.Labels == nil {
+				z.Labels = make(map[string]string, zb0005)
+			} else if len(z.Labels) > 0 {
+				clear(z.Labels)
+			}
+			for zb0005 > 0 {
+				var za0006 string
+				zb0005--
+				var za0005 string
+				za0005, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Labels")
+					return
+				}
+				za0006, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Labels", za0005)
+					return
+				}
+				z.Labels[za0005] = za0006
+			}
+		case "v":
+			{
+				var zb0006 int32
+				zb0006, bts, err = msgp.ReadInt32Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Severity")
+					return
+				}
+				z.Severity = SeverityT(zb0006)
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -289,6 +643,21 @@ func (z *LogEntry) Msgsize() (s int) {
 	for za0001 := range z.Matches {
 		s += msgp.ArrayHeaderSize + (len(z.Matches[za0001]) * (msgp.IntSize))
 	}
+	s += 2 + msgp.Int64Size + 2 + msgp.MapHeaderSize
+	if z.Props != nil {
+		for za0003, za0004 := range z.Props {
+			_ = za0004
+			s += msgp.StringPrefixSize + len(za0003) + msgp.GuessSize(za0004)
+		}
+	}
+	s += 2 + msgp.Int64Size + 2 + msgp.Int64Size + 2 + msgp.MapHeaderSize
+	if z.Labels != nil {
+		for za0005, za0006 := range z.Labels {
+			_ = za0006
+			s += msgp.StringPrefixSize + len(za0005) + msgp.StringPrefixSize + len(za0006)
+		}
+	}
+	s += 2 + msgp.Int32Size
 	return
 }
 
@@ -378,3 +747,55 @@ func (z LogList) Msgsize() (s int) {
 	}
 	return
 }
+
+// DecodeMsg implements msgp.Decodable
+func (z *SeverityT) DecodeMsg(dc *msgp.Reader) (err error) {
+	{
+		var zb0001 int32
+		zb0001, err = dc.ReadInt32()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = SeverityT(zb0001)
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z SeverityT) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteInt32(int32(z))
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z SeverityT) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendInt32(o, int32(z))
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SeverityT) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	{
+		var zb0001 int32
+		zb0001, bts, err = msgp.ReadInt32Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = SeverityT(zb0001)
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z SeverityT) Msgsize() (s int) {
+	s = msgp.Int32Size
+	return
+}
@@ -0,0 +1,86 @@
+package entry
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrSeverity = errors.New("unknown severity")
+
+const (
+	SevUnknown SeverityT = 0
+	SevTrace   SeverityT = 1
+	SevDebug   SeverityT = 5
+	SevInfo    SeverityT = 9
+	SevWarn    SeverityT = 13
+	SevError   SeverityT = 17
+	SevFatal   SeverityT = 21
+)
+
+// Level returns the named level s falls in; SevUnknown if out of range.
+func (s SeverityT) Level() SeverityT {
+	if s < SevTrace || s > SevFatal+3 {
+		return SevUnknown
+	}
+	return (s-1)/4*4 + 1
+}
+
+func (s SeverityT) String() string {
+	switch s.Level() {
+	case SevTrace:
+		return "TRACE"
+	case SevDebug:
+		return "DEBUG"
+	case SevInfo:
+		return "INFO"
+	case SevWarn:
+		return "WARN"
+	case SevError:
+		return "ERROR"
+	case SevFatal:
+		return "FATAL"
+	}
+	return ""
+}
+
+// Names of severities as written by common loggers and by syslog.
+var severityNames = map[string]SeverityT{
+	"trace":       SevTrace,
+	"debug":       SevDebug,
+	"info":        SevInfo,
+	"information": SevInfo,
+	"notice":      SevInfo + 1,
+	"warn":        SevWarn,
+	"warning":     SevWarn,
+	"error":       SevError,
+	"err":         SevError,
+	"crit":        SevError + 1,
+	"critical":    SevError + 1,
+	"alert":       SevFatal,
+	"fatal":       SevFatal,
+	"panic":       SevFatal,
+	"emerg":       SevFatal + 1,
+	"emergency":   SevFatal + 1,
+}
+
+// ParseSeverity returns the severity named, in any case; for example "WARN",
+// "warning" or "err".  Fails with ErrSeverity on an unknown name.
+func ParseSeverity(name string) (SeverityT, error) {
+	if s, ok := severityNames[strings.ToLower(name)]; ok {
+		return s, nil
+	}
+	return SevUnknown, ErrSeverity
+}
+
+// Syslog severity codes, from emergency to debug.
+var syslogSeverities = [8]SeverityT{SevFatal + 1, SevFatal, SevError + 1, SevError, SevWarn, SevInfo + 1, SevInfo, SevDebug}
+
+// SyslogSeverity maps a syslog severity code, 0 (emergency) to 7 (debug), as
+// carried by a syslog priority or a journal PRIORITY field; SevUnknown if out
+// of range.
+func SyslogSeverity(code int) SeverityT {
+	if code < 0 || code >= len(syslogSeverities) {
+		return SevUnknown
+	}
+	return syslogSeverities[code]
+}
@@ -0,0 +1,51 @@
+package entry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := map[string]SeverityT{
+		"trace":   SevTrace,
+		"DEBUG":   SevDebug,
+		"Info":    SevInfo,
+		"notice":  SevInfo + 1,
+		"warning": SevWarn,
+		"err":     SevError,
+		"CRIT":    SevError + 1,
+		"panic":   SevFatal,
+		"emerg":   SevFatal + 1,
+	}
+	for name, want := range tests {
+		if got, err := ParseSeverity(name); err != nil || got != want {
+			t.Errorf("%s: Expected %d, got %d, %v", name, want, got, err)
+		}
+	}
+
+	if _, err := ParseSeverity("loud"); !errors.Is(err, ErrSeverity) {
+		t.Errorf("Expected %v, got %v", ErrSeverity, err)
+	}
+}
+
+func TestSeverityLevel(t *testing.T) {
+	tests := map[SeverityT]string{0: "", 1: "TRACE", 8: "DEBUG", 12: "INFO", 13: "WARN", 18: "ERROR", 24: "FATAL", 25: "", -1: ""}
+	for sev, want := range tests {
+		if got := sev.String(); got != want {
+			t.Errorf("%d: Expected %q, got %q", sev, want, got)
+		}
+	}
+
+	if SevError+2 < SevError || (SevError+2).Level() != SevError {
+		t.Errorf("Expected grades to order within their level")
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := map[int]SeverityT{-1: SevUnknown, 0: SevFatal + 1, 3: SevError, 4: SevWarn, 6: SevInfo, 7: SevDebug, 8: SevUnknown}
+	for code, want := range tests {
+		if got := SyslogSeverity(code); got != want {
+			t.Errorf("%d: Expected %d, got %d", code, want, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package format
+
+import (
+	"time"
+)
+
+type clockOptsT struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+type ClockOptT func(*clockOptsT)
+
+// WithLocation sets the time zone of timestamps that carry none, such as
+// RFC 3164 and klog.  Defaults to UTC.
+func WithLocation(loc *time.Location) ClockOptT {
+	return func(o *clockOptsT) {
+		o.loc = loc
+	}
+}
+
+// WithClock sets the clock used to infer the year of timestamps that carry
+// none.  Defaults to time.Now.
+func WithClock(now func() time.Time) ClockOptT {
+	return func(o *clockOptsT) {
+		o.now = now
+	}
+}
+
+func parseClockOpts(opts []ClockOptT) clockOptsT {
+	o := clockOptsT{loc: time.UTC, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Like mungeYear, but keeps the location of t.
+func inferYear(now, t time.Time, futureSlop time.Duration) int64 {
+	var (
+		loc         = t.Location()
+		nowWithSlop = now.Add(futureSlop).In(loc)
+		candidate   = time.Date(nowWithSlop.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	)
+
+	if candidate.After(nowWithSlop) {
+		candidate = candidate.AddDate(-1, 0, 0)
+	}
+
+	return candidate.UnixNano()
+}
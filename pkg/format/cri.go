@@ -17,6 +17,7 @@ const (
 	avgLogSize  = 256
 	tokenStdout = "stdout"
 	tokenStderr = "stderr"
+	tagPartial  = 'P'
 )
 
 var (
@@ -25,13 +26,39 @@ var (
 )
 
 type criFmtT struct {
+	join bool
+	partialT
 }
 
 type criFactoryT struct {
+	join bool
+}
+
+type criOptsT struct {
+	join bool
+}
+
+type CriOptT func(*criOptsT)
+
+// WithJoinPartial joins lines tagged 'P' with their continuation, up to and
+// including the line tagged 'F'.  The parser returns ErrPartial while it holds
+// a partial line, and so suits forward scans only.
+func WithJoinPartial() CriOptT {
+	return func(o *criOptsT) {
+		o.join = true
+	}
+}
+
+func NewCriFactory(opts ...CriOptT) FactoryI {
+	var o criOptsT
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &criFactoryT{join: o.join}
 }
 
 func (f *criFactoryT) New() ParserI {
-	return &criFmtT{}
+	return &criFmtT{join: f.join}
 }
 
 func (f *criFactoryT) String() string {
@@ -103,7 +130,7 @@ func (f *criFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
 		return
 	}
 
-	// Search past tag; we are ignoring for now
+	// Search past tag; only the partial flag is of interest
 	line = line[idx+1:]
 	idx = bytes.IndexByte(line, delimiter)
 	if idx < 0 {
@@ -112,12 +139,24 @@ func (f *criFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
 		return
 	}
 
-	entry.Line = string(line[idx+1:])
+	if !f.join {
+		entry.Line = string(line[idx+1:])
+		return
+	}
+
+	final := idx == 0 || line[0] != tagPartial
+	if !f.merge(&entry, string(line[idx+1:]), final) {
+		return LogEntry{}, ErrPartial
+	}
 	return
 }
 
 func scanCriTimestamp(buf []byte) (int64, error) {
-	offset := bytes.IndexByte(buf, delimiter)
+	return scanDelimTimestamp(buf, delimiter)
+}
+
+func scanDelimTimestamp(buf []byte, delim byte) (int64, error) {
+	offset := bytes.IndexByte(buf, delim)
 
 	if offset < 0 {
 		return -1, ErrNoTimestamp
@@ -63,3 +63,40 @@ func TestReadCriEntry(t *testing.T) {
 	}
 
 }
+
+func TestCriJoinPartial(t *testing.T) {
+
+	const (
+		ts1S = "2016-10-06T00:17:09.000000001Z"
+		ts2S = "2016-10-06T00:17:09.000000002Z"
+	)
+
+	var (
+		ts1, _ = time.Parse(time.RFC3339Nano, ts1S)
+		f      = NewCriFactory(WithJoinPartial()).New()
+	)
+
+	tests := []struct {
+		data string
+		want LogEntry
+	}{
+		{data: ts1S + " stdout P hello "},
+		{data: ts1S + " stderr F oops", want: LogEntry{Timestamp: ts1.UnixNano(), Stream: tokenStderr, Line: "oops"}},
+		{data: ts2S + " stdout P big "},
+		{data: ts2S + " stdout F world", want: LogEntry{Timestamp: ts1.UnixNano(), Stream: tokenStdout, Line: "hello big world"}},
+	}
+
+	for i, tc := range tests {
+		var werr error
+		if tc.want.Timestamp == 0 {
+			werr = ErrPartial
+		}
+		got, err := f.ReadEntry([]byte(tc.data))
+		if !errors.Is(err, werr) {
+			t.Fatalf("%d: expected err: %v, got: %v", i, werr, err)
+		}
+		if got.Line != tc.want.Line || got.Stream != tc.want.Stream || got.Timestamp != tc.want.Timestamp {
+			t.Fatalf("%d: expected %v, got: %v", i, tc.want, got)
+		}
+	}
+}
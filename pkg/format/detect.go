@@ -2,6 +2,7 @@ package format
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"io"
 
@@ -20,18 +21,18 @@ type ParserI interface {
 
 type DetectFormatFunc func(line []byte) (FactoryI, int64, error)
 
-var supportedFormats = []DetectFormatFunc{
-	detectJSON,
-	detectCri,
-	detectRFC3339Nano, // must come after detectCri since they both start with RFC3339Nano
-}
-
 const (
 	FactoryJSON        = "json"
 	FactoryRegex       = "regex"
 	FactoryRfc3339Nano = "rfc3339Nano"
 	FactoryJSONCustom  = "json_custom"
 	FactoryCRI         = "cri"
+	FactoryRfc3164     = "rfc3164"
+	FactoryRfc5424     = "rfc5424"
+	FactoryDocker      = "docker"
+	FactoryKlog        = "klog"
+	FactoryEpoch       = "epoch"
+	FactoryLayout      = "layout"
 )
 
 const (
@@ -52,7 +53,8 @@ func Detect(rdr io.Reader) (FactoryI, int64, error) {
 		return nil, -1, err
 	}
 
-	for _, try := range supportedFormats {
+	// Formats are tried in registry priority order.
+	for _, try := range detectors() {
 		fmt, ts, err := try(line)
 		if err == nil {
 			return fmt, ts, err
@@ -62,3 +64,127 @@ func Detect(rdr io.Reader) (FactoryI, int64, error) {
 
 	return nil, -1, errors.Join(elist...)
 }
+
+const DefSampleLines = 16
+
+// DetectResultT is the best format for a sample of lines.  Confidence is the
+// fraction of the non-empty sample lines its parser reads, and Timestamp is
+// that of the first line read.
+type DetectResultT struct {
+	Factory    FactoryI
+	Timestamp  int64
+	Confidence float64
+	Lines      int
+}
+
+type detectOptsT struct {
+	lines int
+}
+
+type DetectOptT func(*detectOptsT)
+
+// WithSampleLines sets the number of lines DetectSample reads; defaults to DefSampleLines.
+func WithSampleLines(n int) DetectOptT {
+	return func(o *detectOptsT) {
+		if n > 0 {
+			o.lines = n
+		}
+	}
+}
+
+// DetectSample reads up to a sample of lines and scores every registered
+// format against them.  A format is scored by the first factory its detector
+// returns in the sample, so lines the format reads differently, such as with
+// another delimiter, count against it.  The highest score wins, ties going to
+// the higher priority.  Unlike Detect, a sample that starts with continuation
+// lines, such as a stack trace, can still be detected.
+func DetectSample(rdr io.Reader, opts ...DetectOptT) (DetectResultT, error) {
+
+	o := detectOptsT{lines: DefSampleLines}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sample, err := readSample(rdr, o.lines)
+	switch {
+	case err != nil:
+		return DetectResultT{}, err
+	case len(sample) == 0:
+		return DetectResultT{}, ErrFormatDetect
+	}
+
+	var (
+		best  DetectResultT
+		elist = []error{ErrFormatDetect}
+	)
+
+	for _, try := range detectors() {
+		res, err := scoreSample(try, sample)
+		if err != nil {
+			elist = append(elist, err)
+			continue
+		}
+		if res.Confidence > best.Confidence {
+			best = res
+		}
+	}
+
+	if best.Factory == nil {
+		return DetectResultT{}, errors.Join(elist...)
+	}
+
+	return best, nil
+}
+
+// Read up to n non-empty lines.
+func readSample(rdr io.Reader, n int) ([][]byte, error) {
+
+	var (
+		sample  [][]byte
+		scanner = bufio.NewScanner(rdr)
+	)
+
+	scanner.Buffer(make([]byte, DefBufferSize), MaxRecordSize)
+
+	for len(sample) < n && scanner.Scan() {
+		if line := scanner.Bytes(); len(bytes.TrimSpace(line)) > 0 {
+			sample = append(sample, bytes.Clone(line))
+		}
+	}
+
+	return sample, scanner.Err()
+}
+
+func scoreSample(try DetectFormatFunc, sample [][]byte) (DetectResultT, error) {
+
+	var (
+		res  DetectResultT
+		err  error
+		hits int
+		p    ParserI
+	)
+
+	for _, line := range sample {
+		if p == nil {
+			// Find the first line the detector accepts.
+			if res.Factory, res.Timestamp, err = try(line); err != nil {
+				continue
+			}
+			p = res.Factory.New()
+			hits += 1
+			continue
+		}
+
+		if _, perr := p.ReadEntry(line); perr == nil || errors.Is(perr, ErrPartial) {
+			hits += 1
+		}
+	}
+
+	if p == nil {
+		return DetectResultT{}, err
+	}
+
+	res.Lines = len(sample)
+	res.Confidence = float64(hits) / float64(len(sample))
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package format
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDetectSample(t *testing.T) {
+
+	tests := map[string]struct {
+		data       string
+		factory    string
+		confidence float64
+		opts       []DetectOptT
+	}{
+		"cri": {
+			data:       "2016-10-06T00:17:09.669794202Z stdout F one\n2016-10-06T00:17:09.669794203Z stderr F two\n",
+			factory:    FactoryCRI,
+			confidence: 1,
+		},
+		"trace_first": {
+			data:       "\tat Foo.bar(Foo.java:10)\n\n2016-10-06T00:17:09.669794202Z boom\n2016-10-06T00:17:09.669794203Z ok\n",
+			factory:    FactoryRfc3339Nano,
+			confidence: 2.0 / 3,
+		},
+		"mixed": {
+			// Mostly RFC 3339 with a CRI line; CRI reads only its own line.
+			data:       "2016-10-06T00:17:09.669794202Z stdout F one\n2016-10-06T00:17:09.669794203Z two\n2016-10-06T00:17:09.669794204Z three\n",
+			factory:    FactoryRfc3339Nano,
+			confidence: 1,
+		},
+		"window": {
+			data:       "junk\nI0102 15:04:05.000000 1 a.go:1] one\n2016-10-06T00:17:09.669794202Z x\n2016-10-06T00:17:09.669794202Z y\n",
+			factory:    FactoryKlog,
+			confidence: 0.5,
+			opts:       []DetectOptT{WithSampleLines(2)},
+		},
+		"no_newline": {
+			data:       "<13>1 2018-10-06T00:17:09.669794202Z host app - - - hello",
+			factory:    FactoryRfc5424,
+			confidence: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := DetectSample(strings.NewReader(tc.data), tc.opts...)
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+			if res.Factory.String() != tc.factory {
+				t.Errorf("Expected %s got %s", tc.factory, res.Factory.String())
+			}
+			if res.Confidence != tc.confidence {
+				t.Errorf("Expected confidence %v got %v", tc.confidence, res.Confidence)
+			}
+			if res.Timestamp <= 0 {
+				t.Errorf("Expected timestamp, got %d", res.Timestamp)
+			}
+		})
+	}
+}
+
+func TestDetectSampleFail(t *testing.T) {
+	for _, data := range []string{"", "\n\n", "hello\nworld\n"} {
+		if _, err := DetectSample(strings.NewReader(data)); !errors.Is(err, ErrFormatDetect) {
+			t.Errorf("%q: Expected %v got %v", data, ErrFormatDetect, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package format
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// Docker splits long lines into chunks; every chunk but the last lacks the
+// trailing newline.  The parser holds chunks until the last one arrives and
+// returns ErrPartial meanwhile.
+//
+// Joining relies on lines arriving in order, so the parser suits forward
+// scans only.  Detect returns the plain JSON parser, which neither strips the
+// newline nor joins chunks.
+
+type dockerFmtT struct {
+	partialT
+}
+
+type dockerFactoryT struct {
+}
+
+// NewDockerFactory parses the Docker json-file log driver format.  The line
+// is the log field without its trailing newline.
+func NewDockerFactory() FactoryI {
+	return &dockerFactoryT{}
+}
+
+func (f *dockerFactoryT) New() ParserI {
+	return &dockerFmtT{}
+}
+
+func (f *dockerFactoryT) String() string {
+	return FactoryDocker
+}
+
+func (f *dockerFmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
+	var jf jsonFmtT
+	return jf.ReadTimestamp(rdr)
+}
+
+// Read Docker json-file entry
+// Expect:
+//	{"log":"content 1\n","stream":"stdout","time":"2016-10-20T18:39:20.57606443Z"}
+//	{"log":"long con","stream":"stderr","time":"2016-10-20T18:39:20.57606444Z"}
+//	{"log":"tent 2\n","stream":"stderr","time":"2016-10-20T18:39:20.57606445Z"}
+
+func (f *dockerFmtT) ReadEntry(data []byte) (entry LogEntry, err error) {
+	var line jsonLogT
+
+	if err = json.Unmarshal(data, &line); err != nil {
+		err = errors.Join(ErrJsonUnmarshal, err)
+		return
+	}
+
+	if line.Time.IsZero() {
+		err = ErrJsonTimeField
+		return
+	}
+
+	entry.Stream = line.Stream
+	entry.Timestamp = line.Time.UnixNano()
+
+	chunk, final := strings.CutSuffix(line.Log, "\n")
+	if final {
+		chunk = strings.TrimSuffix(chunk, "\r")
+	}
+
+	if !f.merge(&entry, chunk, final) {
+		return LogEntry{}, ErrPartial
+	}
+
+	return
+}
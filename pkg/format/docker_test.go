@@ -0,0 +1,90 @@
+package format
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadDockerEntry(t *testing.T) {
+
+	var (
+		ts1 = time.Date(2016, time.October, 20, 18, 39, 20, 1, time.UTC).UnixNano()
+		ts2 = time.Date(2016, time.October, 20, 18, 39, 20, 2, time.UTC).UnixNano()
+	)
+
+	tests := map[string]struct {
+		data []string
+		want []LogEntry
+		werr []error
+	}{
+		"corrupt": {
+			data: []string{`{"log":"x`},
+			want: []LogEntry{{}},
+			werr: []error{ErrJsonUnmarshal},
+		},
+		"no_time": {
+			data: []string{`{"log":"x\n","stream":"stdout"}`},
+			want: []LogEntry{{}},
+			werr: []error{ErrJsonTimeField},
+		},
+		"newline": {
+			data: []string{`{"log":"content 1\n","stream":"stdout","time":"2016-10-20T18:39:20.000000001Z"}`},
+			want: []LogEntry{{Timestamp: ts1, Stream: tokenStdout, Line: "content 1"}},
+			werr: []error{nil},
+		},
+		"crlf": {
+			data: []string{`{"log":"content 1\r\n","stream":"stdout","time":"2016-10-20T18:39:20.000000001Z"}`},
+			want: []LogEntry{{Timestamp: ts1, Stream: tokenStdout, Line: "content 1"}},
+			werr: []error{nil},
+		},
+		"partial": {
+			data: []string{
+				`{"log":"long con","stream":"stderr","time":"2016-10-20T18:39:20.000000001Z"}`,
+				`{"log":"tent 2\n","stream":"stderr","time":"2016-10-20T18:39:20.000000002Z"}`,
+			},
+			want: []LogEntry{{}, {Timestamp: ts1, Stream: tokenStderr, Line: "long content 2"}},
+			werr: []error{ErrPartial, nil},
+		},
+		"interleaved": {
+			data: []string{
+				`{"log":"err ","stream":"stderr","time":"2016-10-20T18:39:20.000000001Z"}`,
+				`{"log":"out\n","stream":"stdout","time":"2016-10-20T18:39:20.000000002Z"}`,
+				`{"log":"done\n","stream":"stderr","time":"2016-10-20T18:39:20.000000002Z"}`,
+			},
+			want: []LogEntry{{}, {Timestamp: ts2, Stream: tokenStdout, Line: "out"}, {Timestamp: ts1, Stream: tokenStderr, Line: "err done"}},
+			werr: []error{ErrPartial, nil, nil},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := NewDockerFactory().New()
+			for i, data := range tc.data {
+				entry, err := f.ReadEntry([]byte(data))
+				if !errors.Is(err, tc.werr[i]) {
+					t.Fatalf("%d: Expected %v got %v", i, tc.werr[i], err)
+				}
+				if !reflect.DeepEqual(entry, tc.want[i]) {
+					t.Errorf("%d: Expected %v got %v", i, tc.want[i], entry)
+				}
+			}
+		})
+	}
+}
+
+func TestDockerTimestamp(t *testing.T) {
+
+	f := NewDockerFactory().New()
+
+	ts, err := f.ReadTimestamp(strings.NewReader(`{"log":"long con","stream":"stderr","time":"2016-10-20T18:39:20.000000001Z"}`))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if exp := time.Date(2016, time.October, 20, 18, 39, 20, 1, time.UTC).UnixNano(); ts != exp {
+		t.Errorf("Expected %d got %d", exp, ts)
+	}
+}
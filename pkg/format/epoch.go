@@ -0,0 +1,235 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+)
+
+// EpochUnitT is the unit of a numeric epoch timestamp.  EpochAuto infers the
+// unit from the magnitude: up to 10 integer digits are seconds, up to 13
+// millis, up to 16 micros, and beyond that nanos.
+type EpochUnitT int
+
+const (
+	EpochAuto EpochUnitT = iota
+	EpochSeconds
+	EpochMillis
+	EpochMicros
+	EpochNanos
+)
+
+// Time formats, by EpochUnitT.String, that NewJsonCustomFactory accepts for
+// epoch time fields, whether numbers or strings.
+var epochFormats = map[string]EpochUnitT{
+	EpochAuto.String():    EpochAuto,
+	EpochSeconds.String(): EpochSeconds,
+	EpochMillis.String():  EpochMillis,
+	EpochMicros.String():  EpochMicros,
+	EpochNanos.String():   EpochNanos,
+}
+
+// Fewer integer digits are unlikely to be a timestamp; seconds before 1973.
+const epochDetectDigits = 9
+
+func (u EpochUnitT) String() string {
+	switch u {
+	case EpochAuto:
+		return "epoch"
+	case EpochSeconds:
+		return "epoch_s"
+	case EpochMillis:
+		return "epoch_ms"
+	case EpochMicros:
+		return "epoch_us"
+	case EpochNanos:
+		return "epoch_ns"
+	default:
+		return "unknown"
+	}
+}
+
+// Nanoseconds per unit, and the number of fraction digits it can carry.
+func (u EpochUnitT) scale() (int64, int) {
+	switch u {
+	case EpochSeconds:
+		return 1e9, 9
+	case EpochMillis:
+		return 1e6, 6
+	case EpochMicros:
+		return 1e3, 3
+	default:
+		return 1, 0
+	}
+}
+
+func autoUnit(digits int) EpochUnitT {
+	switch {
+	case digits <= 10:
+		return EpochSeconds
+	case digits <= 13:
+		return EpochMillis
+	case digits <= 16:
+		return EpochMicros
+	default:
+		return EpochNanos
+	}
+}
+
+// Parse a decimal epoch with an optional fraction, such as 1707837164.380,
+// into nanoseconds.  Fraction digits finer than a nanosecond are dropped.
+func parseEpoch(b []byte, unit EpochUnitT) (int64, error) {
+
+	ipart, fpart, _ := bytes.Cut(b, []byte{'.'})
+	if len(ipart) == 0 || len(ipart) > 19 {
+		return -1, ErrParseTimestamp
+	}
+
+	var v int64
+	for _, c := range ipart {
+		if !isDigit(c) {
+			return -1, ErrParseTimestamp
+		}
+		if v > (math.MaxInt64-int64(c-'0'))/10 {
+			return -1, ErrEpochRange
+		}
+		v = v*10 + int64(c-'0')
+	}
+
+	if unit == EpochAuto {
+		unit = autoUnit(len(ipart))
+	}
+
+	scale, places := unit.scale()
+	if v > math.MaxInt64/scale {
+		return -1, ErrEpochRange
+	}
+	v *= scale
+
+	var frac int64
+	for i, c := range fpart {
+		if !isDigit(c) {
+			return -1, ErrParseTimestamp
+		}
+		if i < places {
+			frac = frac*10 + int64(c-'0')
+		}
+	}
+	for i := len(fpart); i < places; i++ {
+		frac *= 10
+	}
+
+	if v > math.MaxInt64-frac {
+		return -1, ErrEpochRange
+	}
+
+	return v + frac, nil
+}
+
+type epochFmtT struct {
+	unit  EpochUnitT
+	delim byte
+}
+
+type epochFactoryT struct {
+	unit  EpochUnitT
+	delim byte
+}
+
+// NewEpochFactory parses lines that lead with a numeric epoch timestamp in
+// unit, followed by a space and the log content.  The line excludes the
+// timestamp.
+func NewEpochFactory(unit EpochUnitT) (FactoryI, error) {
+	if unit < EpochAuto || unit > EpochNanos {
+		return nil, ErrEpochUnit
+	}
+	return &epochFactoryT{unit: unit, delim: delimiter}, nil
+}
+
+func (f *epochFactoryT) New() ParserI {
+	return &epochFmtT{unit: f.unit, delim: f.delim}
+}
+
+func (f *epochFactoryT) String() string {
+	return FactoryEpoch
+}
+
+func (f *epochFmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
+
+	ptr := pool.PoolAlloc()
+	defer pool.PoolFree(ptr)
+	buf := (*ptr)[:tsBufSize]
+
+	n, err := io.ReadFull(rdr, buf)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if n == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	idx := bytes.IndexByte(buf[:n], f.delim)
+	if idx < 0 {
+		return -1, ErrNoTimestamp
+	}
+
+	return parseEpoch(buf[:idx], f.unit)
+}
+
+// Expects format, where the delimiter is one of rfc3339Delimiters:
+//	1707837164 log content 1
+//	1707837164380 log content 2
+//	1707837164.380212573 log content 3
+
+func (f *epochFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
+
+	idx := bytes.IndexByte(line, f.delim)
+	if idx < 0 {
+		err = ErrNoTimestamp
+		return
+	}
+
+	ts, err := parseEpoch(line[:idx], f.unit)
+	if err != nil {
+		return
+	}
+
+	entry.Timestamp = ts
+	entry.Line = string(line[idx+1:])
+	return
+}
+
+// Try each candidate delimiter in turn; the timestamp must have enough digits
+// to be plausible, lest any line leading with a number be taken as an epoch.
+func detectEpoch(line []byte) (FactoryI, int64, error) {
+
+	var elist []error
+
+	for _, delim := range rfc3339Delimiters {
+		idx := bytes.IndexByte(line, delim)
+		if idx < 0 {
+			elist = append(elist, ErrNoTimestamp)
+			continue
+		}
+
+		ipart, _, _ := bytes.Cut(line[:idx], []byte{'.'})
+		if len(ipart) < epochDetectDigits {
+			elist = append(elist, ErrParseTimestamp)
+			continue
+		}
+
+		ts, err := parseEpoch(line[:idx], EpochAuto)
+		if err == nil {
+			return &epochFactoryT{unit: EpochAuto, delim: delim}, ts, nil
+		}
+		elist = append(elist, err)
+	}
+
+	return nil, -1, errors.Join(elist...)
+}
@@ -0,0 +1,164 @@
+package format
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEpoch(t *testing.T) {
+
+	tests := map[string]struct {
+		data string
+		unit EpochUnitT
+		want int64
+		werr error
+	}{
+		"empty":          {data: "", werr: ErrParseTimestamp},
+		"alpha":          {data: "17078x7164", werr: ErrParseTimestamp},
+		"bad_frac":       {data: "1707837164.3x", werr: ErrParseTimestamp},
+		"auto_s":         {data: "1707837164", want: 1707837164000000000},
+		"auto_s_frac":    {data: "1707837164.38", want: 1707837164380000000},
+		"auto_ms":        {data: "1707837164380", want: 1707837164380000000},
+		"auto_ms_frac":   {data: "1707837164380.5", want: 1707837164380500000},
+		"auto_us":        {data: "1707837164380212", want: 1707837164380212000},
+		"auto_ns":        {data: "1707837164380212573", want: 1707837164380212573},
+		"auto_ns_frac":   {data: "1707837164380212573.9", want: 1707837164380212573},
+		"s_frac_trunc":   {data: "1707837164.3802125739", unit: EpochSeconds, want: 1707837164380212573},
+		"explicit_ms":    {data: "1707837164", unit: EpochMillis, want: 1707837164000000},
+		"explicit_ns":    {data: "42", unit: EpochNanos, want: 42},
+		"range_s":        {data: "17078371640", unit: EpochSeconds, werr: ErrEpochRange},
+		"range_digits":   {data: "99999999999999999999", werr: ErrParseTimestamp},
+		"range_overflow": {data: "9999999999999999999", werr: ErrEpochRange},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseEpoch([]byte(tc.data), tc.unit)
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if err == nil && got != tc.want {
+				t.Errorf("Expected %d got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReadEpochEntry(t *testing.T) {
+
+	factory, err := NewEpochFactory(EpochMillis)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	f := factory.New()
+
+	entry, err := f.ReadEntry([]byte("1707837164380 hello world"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if entry.Timestamp != 1707837164380000000 || entry.Line != "hello world" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+
+	if _, err := f.ReadEntry([]byte("1707837164380")); !errors.Is(err, ErrNoTimestamp) {
+		t.Errorf("Expected %v got %v", ErrNoTimestamp, err)
+	}
+
+	if _, err := NewEpochFactory(EpochNanos + 1); !errors.Is(err, ErrEpochUnit) {
+		t.Errorf("Expected %v got %v", ErrEpochUnit, err)
+	}
+}
+
+func TestDetectEpoch(t *testing.T) {
+
+	tests := map[string]struct {
+		data string
+		want int64
+		line string
+	}{
+		"seconds": {data: "1707837164 hello world\n", want: 1707837164000000000, line: "hello world\n"},
+		"millis":  {data: "1707837164380\thello world\n", want: 1707837164380000000, line: "hello world\n"},
+		"frac":    {data: "1707837164.380212|hello world\n", want: 1707837164380212000, line: "hello world\n"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory, ts, err := Detect(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+			if factory.String() != FactoryEpoch {
+				t.Errorf("Expected %s got %s", FactoryEpoch, factory.String())
+			}
+			if ts != tc.want {
+				t.Errorf("Expected %d got %d", tc.want, ts)
+			}
+
+			f := factory.New()
+
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+			if entry.Line != tc.line {
+				t.Errorf("Expected %q got %q", tc.line, entry.Line)
+			}
+
+			if ts, err = f.ReadTimestamp(strings.NewReader(tc.data)); err != nil || ts != tc.want {
+				t.Errorf("Expected %d got %d, %v", tc.want, ts, err)
+			}
+		})
+	}
+
+	// Too few digits to be plausible.
+	if _, _, err := Detect(strings.NewReader("404 not found\n")); !errors.Is(err, ErrFormatDetect) {
+		t.Errorf("Expected %v got %v", ErrFormatDetect, err)
+	}
+}
+
+func TestJsonCustomEpoch(t *testing.T) {
+
+	tests := map[string]struct {
+		format string
+		data   string
+		want   int64
+		werr   error
+	}{
+		"number_ms":  {format: "epoch_ms", data: `{"ts": 1707837164380}`, want: 1707837164380000000},
+		"number_ns":  {format: "epoch", data: `{"ts": 1707837164380212573}`, want: 1707837164380212573},
+		"number_s":   {format: "epoch", data: `{"ts": 1707837164.5}`, want: 1707837164500000000},
+		"string_ms":  {format: "epoch_ms", data: `{"ts": "1707837164380"}`, want: 1707837164380000000},
+		"not_epoch":  {format: "2006", data: `{"ts": 1707837164380}`, werr: ErrJsonTimeField},
+		"no_field":   {format: "epoch", data: `{"x": 1}`, werr: ErrJsonTimeField},
+		"bad_string": {format: "epoch", data: `{"ts": "soon"}`, werr: ErrParseTimestamp},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory, err := NewJsonCustomFactory("$.ts", tc.format)
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			f := factory.New()
+
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if entry.Timestamp != tc.want {
+				t.Errorf("Expected %d got %d", tc.want, entry.Timestamp)
+			}
+
+			ts, err := f.ReadTimestamp(strings.NewReader(tc.data))
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if err == nil && ts != tc.want {
+				t.Errorf("Expected %d got %d", tc.want, ts)
+			}
+		})
+	}
+}
@@ -14,4 +14,16 @@ var (
 	ErrJsonTimeField  = errors.New("fail to extract time field")
 	ErrJsonUnmarshal  = errors.New("fail JSON unmarshal")
 	ErrMatchTimestamp = errors.New("fail match timestamp")
+	ErrNoPriority     = errors.New("no syslog priority")
+	ErrNoVersion      = errors.New("no syslog version")
+	ErrNoHeader       = errors.New("truncated syslog header")
+	ErrNoStructured   = errors.New("malformed syslog structured data")
+	ErrEpochUnit      = errors.New("unknown epoch unit")
+	ErrEpochRange     = errors.New("epoch out of range")
+	ErrFormatDupe     = errors.New("format already registered")
+	ErrFormatName     = errors.New("format name and factory required")
+	ErrLayout         = errors.New("invalid time layout")
+
+	// Returned while a parser holds a partial line; not a parse failure.
+	ErrPartial = errors.New("partial line held")
 )
@@ -4,34 +4,110 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
 )
 
+// TimeFormatAuto, or an empty layout, has the custom JSON parser try each of
+// autoLayouts in turn, and numeric epochs of any unit.  The layout that last
+// succeeded is tried first.
+const TimeFormatAuto = "auto"
+
+var autoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RubyDate,
+	time.UnixDate,
+}
+
 type jsonCustomFmtT struct {
 	path    *json.Path
+	msgPath *json.Path
+	loc     *time.Location
 	fmtTime string
+	epoch   bool
+	auto    bool
+	unit    EpochUnitT
 }
 
 type jsonCustomFactoryT struct {
 	pathTime string
 	fmtTime  string
+	pathMsg  string
+	loc      *time.Location
 }
 
-func NewJsonCustomFactory(pathTime, fmtTime string) (FactoryI, error) {
+type jsonCustomOptsT struct {
+	pathMsg string
+	loc     *time.Location
+}
+
+type JsonCustomOptT func(*jsonCustomOptsT)
+
+// WithMessageField sets the entry line to the string field at pathMsg rather
+// than the raw line.  Lines without the field keep the raw line.
+func WithMessageField(pathMsg string) JsonCustomOptT {
+	return func(o *jsonCustomOptsT) {
+		o.pathMsg = pathMsg
+	}
+}
+
+// WithJsonLocation sets the zone of time fields without zone information.
+// Defaults to UTC.
+func WithJsonLocation(loc *time.Location) JsonCustomOptT {
+	return func(o *jsonCustomOptsT) {
+		o.loc = loc
+	}
+}
+
+// NewJsonCustomFactory extracts the time field at pathTime and parses it with
+// the time layout fmtTime.  If fmtTime names an EpochUnitT, such as "epoch_ms",
+// the field is instead a numeric epoch, either a number or a string.  Paths
+// are JSONPath, such as "$.ts"; the jq style ".ts" is also accepted.
+func NewJsonCustomFactory(pathTime, fmtTime string, opts ...JsonCustomOptT) (FactoryI, error) {
+
+	o := jsonCustomOptsT{loc: time.UTC}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	pathTime = normPath(pathTime)
+
 	// Validate that the path is a valid JSON path
 	_, err := json.CreatePath(pathTime)
 	if err != nil {
 		return nil, err
 	}
 
+	if o.pathMsg != "" {
+		o.pathMsg = normPath(o.pathMsg)
+		if _, err = json.CreatePath(o.pathMsg); err != nil {
+			return nil, err
+		}
+	}
+
 	return &jsonCustomFactoryT{
 		pathTime: pathTime,
 		fmtTime:  fmtTime,
+		pathMsg:  o.pathMsg,
+		loc:      o.loc,
 	}, nil
 }
 
+// Accept jq style paths.
+func normPath(path string) string {
+	if strings.HasPrefix(path, ".") {
+		return "$" + path
+	}
+	return path
+}
+
 func (f *jsonCustomFactoryT) New() ParserI {
 	// Path seems to have state so cannot reuse it; validate this.
 	path, err := json.CreatePath(f.pathTime)
@@ -39,7 +115,27 @@ func (f *jsonCustomFactoryT) New() ParserI {
 		panic(err) // This should never happen
 	}
 
-	return &jsonCustomFmtT{path: path, fmtTime: f.fmtTime}
+	var msgPath *json.Path
+	if f.pathMsg != "" {
+		if msgPath, err = json.CreatePath(f.pathMsg); err != nil {
+			panic(err) // This should never happen
+		}
+	}
+
+	var (
+		unit, epoch = epochFormats[f.fmtTime]
+		auto        = f.fmtTime == "" || f.fmtTime == TimeFormatAuto
+	)
+
+	return &jsonCustomFmtT{
+		path:    path,
+		msgPath: msgPath,
+		loc:     f.loc,
+		fmtTime: f.fmtTime,
+		epoch:   epoch || auto,
+		auto:    auto,
+		unit:    unit,
+	}
 }
 
 func (f *jsonCustomFactoryT) String() string {
@@ -49,25 +145,47 @@ func (f *jsonCustomFactoryT) String() string {
 func (f *jsonCustomFmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
 
 	var (
-		line    json.RawMessage
+		line    any
 		decoder = json.NewDecoder(rdr)
 	)
 
+	decoder.UseNumber()
 	if err = decoder.Decode(&line); err != nil {
 		return
 	}
 
-	var stime string
-	if err = f.path.Get(line, &stime); err != nil {
-		err = errors.Join(ErrJsonTimeField, err)
-		return
+	return f.readTime(line)
+}
+
+// Extract and parse the time field from the decoded line.
+func (f *jsonCustomFmtT) readTime(line any) (int64, error) {
+	var v any
+	if err := f.path.Get(line, &v); err != nil {
+		return -1, errors.Join(ErrJsonTimeField, err)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return f.parseTime(v)
+	case json.Number:
+		if f.epoch {
+			return parseEpoch([]byte(v), f.unit)
+		}
 	}
 
-	return f.parseTime(stime)
+	return -1, ErrJsonTimeField
 }
 
 func (f *jsonCustomFmtT) parseTime(stime string) (ts int64, err error) {
-	t, err := time.Parse(f.fmtTime, stime)
+	if f.auto {
+		return f.parseAuto(stime)
+	}
+
+	if f.epoch {
+		return parseEpoch([]byte(stime), f.unit)
+	}
+
+	t, err := time.ParseInLocation(f.fmtTime, stime, f.loc)
 	if err != nil {
 		err = errors.Join(ErrParseTimestamp, err)
 		return
@@ -76,6 +194,27 @@ func (f *jsonCustomFmtT) parseTime(stime string) (ts int64, err error) {
 	return t.UTC().UnixNano(), nil
 }
 
+func (f *jsonCustomFmtT) parseAuto(stime string) (int64, error) {
+	if f.fmtTime != "" && f.fmtTime != TimeFormatAuto {
+		if t, err := time.ParseInLocation(f.fmtTime, stime, f.loc); err == nil {
+			return t.UTC().UnixNano(), nil
+		}
+	}
+
+	for _, layout := range autoLayouts {
+		if t, err := time.ParseInLocation(layout, stime, f.loc); err == nil {
+			f.fmtTime = layout
+			return t.UTC().UnixNano(), nil
+		}
+	}
+
+	if ts, err := parseEpoch([]byte(stime), EpochAuto); err == nil {
+		return ts, nil
+	}
+
+	return -1, ErrParseTimestamp
+}
+
 // Read custom JSON Format
 // TODO: Optimize this.  This is decoding the entire line when
 // we only need the timestamp plus validation.
@@ -87,23 +226,31 @@ func (f *jsonCustomFmtT) ReadEntry(data []byte) (entry LogEntry, err error) {
 		decoder = json.NewDecoder(bytes.NewReader(data))
 	)
 
+	decoder.UseNumber()
 	if err = decoder.Decode(&line); err != nil {
 		err = errors.Join(ErrJsonUnmarshal, err)
 		return
 	}
 
-	var stime string
-	if err = f.path.Get(line, &stime); err != nil {
-		err = errors.Join(ErrJsonTimeField, err)
-		return
-	}
-
-	ts, err := f.parseTime(stime)
+	ts, err := f.readTime(line)
 	if err != nil {
 		return
 	}
 
-	entry.Line = string(data)
+	entry.Line = f.readMessage(line, data)
 	entry.Timestamp = ts
 	return
 }
+
+// Returns the message field if selected and present, otherwise the raw line.
+func (f *jsonCustomFmtT) readMessage(line any, data []byte) string {
+	if f.msgPath != nil {
+		var v any
+		if err := f.msgPath.Get(line, &v); err == nil {
+			if msg, ok := v.(string); ok {
+				return msg
+			}
+		}
+	}
+	return string(data)
+}
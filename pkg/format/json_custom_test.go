@@ -1,6 +1,7 @@
 package format
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -29,3 +30,106 @@ func TestJsonCustomFunkyField(t *testing.T) {
 		t.Errorf("Expected %s got %s", string(line), entry.Line)
 	}
 }
+
+func TestJsonCustomAutoLayout(t *testing.T) {
+
+	want := time.Date(2024, time.February, 13, 15, 12, 44, 355965799, time.UTC).UnixNano()
+
+	tests := map[string]struct {
+		path string
+		data string
+		want int64
+		werr error
+	}{
+		"rfc3339":    {path: ".ts", data: `{"ts":"2024-02-13T15:12:44.355965799Z"}`, want: want},
+		"offset":     {path: ".ts", data: `{"ts":"2024-02-13T17:12:44.355965799+02:00"}`, want: want},
+		"no_zone":    {path: ".ts", data: `{"ts":"2024-02-13T15:12:44.355965799"}`, want: want},
+		"space":      {path: ".time", data: `{"time":"2024-02-13 15:12:44.355965799"}`, want: want},
+		"nested":     {path: ".meta.timestamp", data: `{"meta":{"timestamp":"2024-02-13 15:12:44.355965799Z"}}`, want: want},
+		"jsonpath":   {path: "$.ts", data: `{"ts":"2024-02-13T15:12:44.355965799Z"}`, want: want},
+		"epoch_ns":   {path: ".ts", data: `{"ts":1707837164355965799}`, want: want},
+		"epoch_ms":   {path: ".ts", data: `{"ts":"1707837164355"}`, want: 1707837164355000000},
+		"unknown":    {path: ".ts", data: `{"ts":"yesterday"}`, werr: ErrParseTimestamp},
+		"wrong_type": {path: ".ts", data: `{"ts":true}`, werr: ErrJsonTimeField},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory, err := NewJsonCustomFactory(tc.path, TimeFormatAuto)
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			entry, err := factory.New().ReadEntry([]byte(tc.data))
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if entry.Timestamp != tc.want {
+				t.Errorf("Expected %d got %d", tc.want, entry.Timestamp)
+			}
+		})
+	}
+}
+
+func TestJsonCustomMessageField(t *testing.T) {
+
+	factory, err := NewJsonCustomFactory(".ts", "", WithMessageField(".msg"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	f := factory.New()
+
+	tests := map[string]string{
+		`{"ts":"2024-02-13T15:12:44Z","msg":"hello world"}`: "hello world",
+		`{"ts":"2024-02-13T15:12:44Z","msg":42}`:            `{"ts":"2024-02-13T15:12:44Z","msg":42}`,
+		`{"ts":"2024-02-13T15:12:44Z"}`:                     `{"ts":"2024-02-13T15:12:44Z"}`,
+	}
+
+	for data, want := range tests {
+		entry, err := f.ReadEntry([]byte(data))
+		if err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+		if entry.Line != want {
+			t.Errorf("Expected %q got %q", want, entry.Line)
+		}
+	}
+
+	if _, err := NewJsonCustomFactory(".ts", "", WithMessageField("$[")); err == nil {
+		t.Errorf("Expected error on bad message path")
+	}
+}
+
+func TestJsonCustomLocation(t *testing.T) {
+
+	loc := time.FixedZone("UTC-5", -5*3600)
+
+	for _, fmtTime := range []string{time.DateTime, TimeFormatAuto} {
+		factory, err := NewJsonCustomFactory(".ts", fmtTime, WithJsonLocation(loc))
+		if err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+
+		f := factory.New()
+
+		entry, err := f.ReadEntry([]byte(`{"ts":"2020-01-09 10:04:05"}`))
+		if err != nil {
+			t.Fatalf("%s: expected nil error got %v", fmtTime, err)
+		}
+		if entry.Timestamp != 1578582245000000000 {
+			t.Errorf("%s: expected %d got %d", fmtTime, 1578582245000000000, entry.Timestamp)
+		}
+
+		// An explicit zone is kept.
+		entry, err = f.ReadEntry([]byte(`{"ts":"2020-01-09T15:04:05Z"}`))
+		if fmtTime == TimeFormatAuto {
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+			if entry.Timestamp != 1578582245000000000 {
+				t.Errorf("Expected %d got %d", 1578582245000000000, entry.Timestamp)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+const (
+	klogLayout    = "0102 15:04:05"
+	LabelSeverity = "severity"
+)
+
+var (
+	klogHeaderEnd = []byte("] ")
+
+	klogSeverity = map[byte]string{
+		'I': "INFO",
+		'W': "WARNING",
+		'E': "ERROR",
+		'F': "FATAL",
+	}
+
+	klogLevel = map[byte]entry.SeverityT{
+		'I': entry.SevInfo,
+		'W': entry.SevWarn,
+		'E': entry.SevError,
+		'F': entry.SevFatal,
+	}
+)
+
+type klogFmtT struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+type klogFactoryT struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+// NewKlogFactory parses the klog and glog header used by Kubernetes components.
+// The timestamp has no year; it is inferred as for RFC 3164.
+func NewKlogFactory(opts ...ClockOptT) FactoryI {
+	o := parseClockOpts(opts)
+	return &klogFactoryT{loc: o.loc, now: o.now}
+}
+
+func (f *klogFactoryT) New() ParserI {
+	return &klogFmtT{loc: f.loc, now: f.now}
+}
+
+func (f *klogFactoryT) String() string {
+	return FactoryKlog
+}
+
+func (f *klogFmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
+
+	ptr := pool.PoolAlloc()
+	defer pool.PoolFree(ptr)
+	buf := (*ptr)[:tsBufSize]
+
+	n, err := io.ReadFull(rdr, buf)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if n == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	ts, _, err = f.parse(buf[:n])
+	return
+}
+
+// Read klog entry
+// (see https://github.com/kubernetes/klog/blob/v2.130.1/klog.go#L605)
+// Expects format:
+//	I0102 15:04:05.000000   123 file.go:42] log content 1
+//	E0102 15:04:05.000001       1 server.go:7] log content 2
+//
+// The line is the message after the header.  The severity is parsed into
+// Severity, and recorded under the LabelSeverity label as well.
+
+func (f *klogFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
+
+	ts, rest, err := f.parse(line)
+	if err != nil {
+		return
+	}
+
+	// Skip the thread id and source location.
+	idx := bytes.Index(rest, klogHeaderEnd)
+	if idx < 0 {
+		err = ErrNoHeader
+		return
+	}
+
+	entry.Timestamp = ts
+	entry.Line = string(rest[idx+len(klogHeaderEnd):])
+	entry.Labels = map[string]string{LabelSeverity: klogSeverity[line[0]]}
+	entry.Severity = klogLevel[line[0]]
+	return
+}
+
+// Parse the severity and timestamp; returns the remainder after the timestamp delimiter.
+func (f *klogFmtT) parse(line []byte) (int64, []byte, error) {
+	const hdr = len("I0102 15:04:05")
+
+	if len(line) < hdr+1 || klogSeverity[line[0]] == "" {
+		return -1, nil, ErrNoTimestamp
+	}
+
+	n := hdr
+	if line[n] == '.' {
+		n += 1
+		for n < len(line) && isDigit(line[n]) {
+			n += 1
+		}
+	}
+
+	if n >= len(line) || line[n] != delimiter {
+		return -1, nil, ErrNoTimestamp
+	}
+
+	t, err := time.ParseInLocation(klogLayout, string(line[1:n]), f.loc)
+	if err != nil {
+		return -1, nil, errors.Join(ErrParseTimestamp, err)
+	}
+
+	return inferYear(f.now(), t, defaultMungeSlop), line[n+1:], nil
+}
+
+func detectKlog(line []byte) (FactoryI, int64, error) {
+
+	f := klogFmtT{loc: time.UTC, now: time.Now}
+	entry, err := f.ReadEntry(line)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return NewKlogFactory(), entry.Timestamp, nil
+}
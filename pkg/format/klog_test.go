@@ -0,0 +1,70 @@
+package format
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+func TestReadKlogEntry(t *testing.T) {
+
+	var (
+		now = time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+		f   = NewKlogFactory(WithClock(func() time.Time { return now })).New()
+	)
+
+	tests := map[string]struct {
+		data string
+		want LogEntry
+		werr error
+	}{
+		"empty":      {data: "", werr: ErrNoTimestamp},
+		"severity":   {data: "X0102 15:04:05.000000   123 file.go:42] msg", werr: ErrNoTimestamp},
+		"short":      {data: "I0102 15:04", werr: ErrNoTimestamp},
+		"bad_date":   {data: "I1302 15:04:05.000000   123 file.go:42] msg", werr: ErrParseTimestamp},
+		"no_header":  {data: "I0102 15:04:05.000000   123 file.go:42 msg", werr: ErrNoHeader},
+		"info":       {data: "I0102 15:04:05.000001   123 file.go:42] hello world", want: LogEntry{Timestamp: time.Date(2026, time.January, 2, 15, 4, 5, 1000, time.UTC).UnixNano(), Line: "hello world", Labels: map[string]string{LabelSeverity: "INFO"}, Severity: entry.SevInfo}},
+		"error":      {data: "E1231 23:59:59.500000       1 server.go:7] boom\n", want: LogEntry{Timestamp: time.Date(2025, time.December, 31, 23, 59, 59, 500000000, time.UTC).UnixNano(), Line: "boom\n", Labels: map[string]string{LabelSeverity: "ERROR"}, Severity: entry.SevError}},
+		"no_frac":    {data: "W0310 11:00:00 7 a.go:1] careful", want: LogEntry{Timestamp: time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC).UnixNano(), Line: "careful", Labels: map[string]string{LabelSeverity: "WARNING"}, Severity: entry.SevWarn}},
+		"empty_line": {data: "F0310 11:00:00.000000 7 a.go:1] ", want: LogEntry{Timestamp: time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC).UnixNano(), Labels: map[string]string{LabelSeverity: "FATAL"}, Severity: entry.SevFatal}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if !reflect.DeepEqual(entry, tc.want) {
+				t.Errorf("Expected %v got %v", tc.want, entry)
+			}
+		})
+	}
+}
+
+func TestDetectKlog(t *testing.T) {
+
+	const data = "I0102 15:04:05.000000   123 file.go:42] hello world\n"
+
+	factory, ts, err := Detect(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if factory.String() != FactoryKlog {
+		t.Errorf("Expected %s got %s", FactoryKlog, factory.String())
+	}
+
+	rts, err := factory.New().ReadTimestamp(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if rts != ts {
+		t.Errorf("Expected %d got %d", ts, rts)
+	}
+}
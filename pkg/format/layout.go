@@ -0,0 +1,191 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+)
+
+// Go layouts of the strptime directives accepted by NewLayout.
+var strptimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'L': "000",
+	'N': "000000000",
+	'p': "PM",
+	'z': "-0700",
+	'Z': "MST",
+	'T': "15:04:05",
+	'F': "2006-01-02",
+	'%': "%",
+}
+
+// Slack over the layout length when searching for the end of the timestamp,
+// for padded or spelled out fields.
+const layoutSlop = 16
+
+type layoutFmtT struct {
+	layout string
+	loc    *time.Location
+	now    func() time.Time
+	width  int
+}
+
+type layoutFactoryT struct {
+	layout string
+	loc    *time.Location
+	now    func() time.Time
+}
+
+// NewLayout parses lines that lead with a timestamp in layout, followed by a
+// space or tab and the log content.  The layout is a Go reference layout, such
+// as "2006-01-02 15:04:05,000", or uses strptime directives, such as
+// "%Y-%m-%d %H:%M:%S".  A layout without a year has the year inferred as for
+// RFC 3164; one without a zone is read in the WithLocation zone.  The line
+// excludes the timestamp.
+func NewLayout(layout string, opts ...ClockOptT) (FactoryI, error) {
+
+	if strings.ContainsRune(layout, '%') {
+		var err error
+		if layout, err = fromStrptime(layout); err != nil {
+			return nil, err
+		}
+	}
+
+	if layout == "" {
+		return nil, ErrLayout
+	}
+
+	o := parseClockOpts(opts)
+	return &layoutFactoryT{layout: layout, loc: o.loc, now: o.now}, nil
+}
+
+func fromStrptime(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", ErrLayout
+		}
+		i += 1
+		v, ok := strptimeDirectives[s[i]]
+		if !ok {
+			return "", fmt.Errorf("%w: unsupported directive %%%c", ErrLayout, s[i])
+		}
+		sb.WriteString(v)
+	}
+	return sb.String(), nil
+}
+
+func (f *layoutFactoryT) New() ParserI {
+	return &layoutFmtT{layout: f.layout, loc: f.loc, now: f.now}
+}
+
+func (f *layoutFactoryT) String() string {
+	return FactoryLayout
+}
+
+func (f *layoutFmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
+
+	ptr := pool.PoolAlloc()
+	defer pool.PoolFree(ptr)
+	buf := (*ptr)[:len(f.layout)+layoutSlop+1]
+
+	n, err := io.ReadFull(rdr, buf)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if n == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	ts, _, err = f.parse(buf[:n])
+	return
+}
+
+// Expects format, for the layout "2006-01-02 15:04:05,000":
+//	2006-01-02 15:04:05,000 log content 1
+//	2006-01-02 15:04:05,001	log content 2
+
+func (f *layoutFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
+
+	ts, n, err := f.parse(line)
+	if err != nil {
+		return
+	}
+
+	entry.Timestamp = ts
+	entry.Line = string(line[n:])
+	return
+}
+
+// Parse the leading timestamp; returns the offset of the content.  The
+// timestamp ends at a space or tab; the width that last parsed is tried
+// first, then each candidate end in turn.
+func (f *layoutFmtT) parse(line []byte) (int64, int, error) {
+
+	if f.width > 0 && f.width < len(line) && isLayoutDelim(line[f.width]) {
+		if ts, err := f.parseAt(line, f.width); err == nil {
+			return ts, f.width + 1, nil
+		}
+	}
+
+	var (
+		lerr  error = ErrNoTimestamp
+		limit       = min(len(line)-1, len(f.layout)+layoutSlop)
+	)
+
+	for i := 1; i <= limit; i++ {
+		if !isLayoutDelim(line[i]) {
+			continue
+		}
+		ts, err := f.parseAt(line, i)
+		if err == nil {
+			f.width = i
+			return ts, i + 1, nil
+		}
+		lerr = errors.Join(ErrParseTimestamp, err)
+	}
+
+	return -1, 0, lerr
+}
+
+func (f *layoutFmtT) parseAt(line []byte, n int) (int64, error) {
+	t, err := time.ParseInLocation(f.layout, string(line[:n]), f.loc)
+	if err != nil {
+		return -1, err
+	}
+
+	if t.Year() == 0 {
+		return inferYear(f.now(), t, defaultMungeSlop), nil
+	}
+	return t.UnixNano(), nil
+}
+
+func isLayoutDelim(c byte) bool {
+	return c == delimiter || c == '\t'
+}
@@ -0,0 +1,94 @@
+package format
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadLayoutEntry(t *testing.T) {
+
+	now := func() time.Time { return time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		layout string
+		data   string
+		want   time.Time
+		line   string
+		werr   error
+	}{
+		"log4j":      {layout: "2006-01-02 15:04:05,000", data: "2024-02-13 15:12:44,355 INFO hello", want: time.Date(2024, 2, 13, 15, 12, 44, 355000000, time.UTC), line: "INFO hello"},
+		"tab":        {layout: "2006-01-02 15:04:05", data: "2024-02-13 15:12:44\thello", want: time.Date(2024, 2, 13, 15, 12, 44, 0, time.UTC), line: "hello"},
+		"strptime":   {layout: "%Y-%m-%d %H:%M:%S.%f", data: "2024-02-13 15:12:44.000001 hello", want: time.Date(2024, 2, 13, 15, 12, 44, 1000, time.UTC), line: "hello"},
+		"no_year":    {layout: "%b %e %T", data: "Dec  1 10:00:00 hello", want: time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC), line: "hello"},
+		"zone":       {layout: "02/Jan/2006:15:04:05 -0700", data: "13/Feb/2024:17:12:44 +0200 GET /", want: time.Date(2024, 2, 13, 15, 12, 44, 0, time.UTC), line: "GET /"},
+		"day_name":   {layout: "Monday 2006-01-02", data: "Wednesday 2024-02-14 hump day", want: time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), line: "hump day"},
+		"no_content": {layout: "2006-01-02", data: "2024-02-13", werr: ErrNoTimestamp},
+		"mismatch":   {layout: "2006-01-02", data: "13/02/2024 hello", werr: ErrParseTimestamp},
+		"empty_line": {layout: "2006-01-02", data: "2024-02-13 ", want: time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory, err := NewLayout(tc.layout, WithClock(now))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			f := factory.New()
+
+			// Twice, to exercise the cached width.
+			for range 2 {
+				entry, err := f.ReadEntry([]byte(tc.data))
+				if !errors.Is(err, tc.werr) {
+					t.Fatalf("Expected %v got %v", tc.werr, err)
+				}
+				if err != nil {
+					return
+				}
+				if entry.Timestamp != tc.want.UnixNano() {
+					t.Errorf("Expected %v got %v", tc.want, time.Unix(0, entry.Timestamp).UTC())
+				}
+				if entry.Line != tc.line {
+					t.Errorf("Expected %q got %q", tc.line, entry.Line)
+				}
+			}
+
+			ts, err := f.ReadTimestamp(strings.NewReader(tc.data + " and a long tail of content"))
+			if err != nil || ts != tc.want.UnixNano() {
+				t.Errorf("Expected %v got %d, %v", tc.want, ts, err)
+			}
+		})
+	}
+}
+
+func TestLayoutStrptime(t *testing.T) {
+
+	tests := map[string]struct {
+		layout string
+		want   string
+		werr   error
+	}{
+		"iso":      {layout: "%F %T", want: "2006-01-02 15:04:05"},
+		"percent":  {layout: "%Y%%%m", want: "2006%01"},
+		"dangling": {layout: "%Y %", werr: ErrLayout},
+		"unknown":  {layout: "%Q", werr: ErrLayout},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := fromStrptime(tc.layout)
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %q got %q", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := NewLayout(""); !errors.Is(err, ErrLayout) {
+		t.Errorf("Expected %v got %v", ErrLayout, err)
+	}
+}
@@ -0,0 +1,41 @@
+package format
+
+// Holds partial lines per stream until the final chunk.
+type partialT struct {
+	slots [2]struct {
+		held  bool
+		stamp int64
+		buf   []byte
+	}
+}
+
+// Merge chunk onto any held partial of the entry's stream.  Returns true when
+// entry is complete, with the line and the timestamp of its first chunk.  A
+// held line reaching MaxRecordSize is emitted as is.
+func (p *partialT) merge(entry *LogEntry, chunk string, final bool) bool {
+	slot := &p.slots[0]
+	if entry.Stream == tokenStderr {
+		slot = &p.slots[1]
+	}
+
+	if !slot.held {
+		if final {
+			entry.Line = chunk
+			return true
+		}
+		slot.held = true
+		slot.stamp = entry.Timestamp
+	}
+
+	slot.buf = append(slot.buf, chunk...)
+	if !final && len(slot.buf) < MaxRecordSize {
+		return false
+	}
+
+	entry.Timestamp = slot.stamp
+	entry.Line = string(slot.buf)
+
+	slot.held = false
+	slot.buf = slot.buf[:0]
+	return true
+}
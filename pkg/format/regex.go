@@ -17,13 +17,36 @@ const (
 type TimeFormatCbT func(m []byte) (int64, error)
 
 type regexFmtT struct {
-	expTime *regexp.Regexp
-	cb      TimeFormatCbT
+	expTime   *regexp.Regexp
+	cb        TimeFormatCbT
+	expIngest *regexp.Regexp
+	cbIngest  TimeFormatCbT
 }
 
 type regexFactoryT struct {
-	expTime *regexp.Regexp
-	cb      TimeFormatCbT
+	expTime   *regexp.Regexp
+	cb        TimeFormatCbT
+	expIngest *regexp.Regexp
+	cbIngest  TimeFormatCbT
+}
+
+type regexOptsT struct {
+	expIngest string
+	cbIngest  TimeFormatCbT
+}
+
+type RegexOptT func(*regexOptsT)
+
+// WithIngestTime extracts a secondary timestamp into LogEntry.IngestTime.
+// The first capture group of expIngest is passed to cb; a nil cb parses it as
+// the primary timestamp is parsed.  The primary timestamp is still used for
+// windowing.  The secondary is best effort; if it does not
+// match or fails to parse, IngestTime is left zero and the entry is still returned.
+func WithIngestTime(expIngest string, cb TimeFormatCbT) RegexOptT {
+	return func(o *regexOptsT) {
+		o.expIngest = expIngest
+		o.cbIngest = cb
+	}
 }
 
 func WithTimeFormat(fmtTime string) TimeFormatCbT {
@@ -48,26 +71,65 @@ func WithTimeFormat(fmtTime string) TimeFormatCbT {
 	}
 }
 
-func NewRegexFactory(expTime string, cb TimeFormatCbT) (FactoryI, error) {
+// WithTimeFormatIn is WithTimeFormat for timestamps without zone information
+// that are logged in loc rather than UTC.
+func WithTimeFormatIn(fmtTime string, loc *time.Location) TimeFormatCbT {
+	return func(m []byte) (int64, error) {
+		t, err := time.ParseInLocation(fmtTime, string(m), loc)
+		if err != nil {
+			return 0, err
+		}
+
+		if t.Year() == 0 {
+			return inferYear(time.Now(), t, defaultMungeSlop), nil
+		}
+
+		return t.UnixNano(), nil
+	}
+}
+
+func NewRegexFactory(expTime string, cb TimeFormatCbT, opts ...RegexOptT) (FactoryI, error) {
 
 	var (
 		exp *regexp.Regexp
 		err error
+		o   regexOptsT
 	)
 
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Expression must compile
 	if exp, err = regexp.Compile(expTime); err != nil {
 		return nil, err
 	}
 
-	return &regexFactoryT{
+	f := &regexFactoryT{
 		expTime: exp,
 		cb:      cb,
-	}, nil
+	}
+
+	if o.expIngest != "" {
+		if f.expIngest, err = regexp.Compile(o.expIngest); err != nil {
+			return nil, err
+		}
+		f.cbIngest = o.cbIngest
+		if f.cbIngest == nil {
+			f.cbIngest = cb
+		}
+	}
+
+	return f, nil
 }
 
 func (f *regexFactoryT) New() ParserI {
-	return &regexFmtT{expTime: f.expTime, cb: f.cb}
+	return &regexFmtT{
+		expTime:   f.expTime,
+		cb:        f.cb,
+		expIngest: f.expIngest,
+		cbIngest:  f.cbIngest,
+	}
 }
 
 func (f *regexFactoryT) String() string {
@@ -122,6 +184,14 @@ func (f *regexFmtT) ReadEntry(data []byte) (entry LogEntry, err error) {
 
 	entry.Line = string(data)
 	entry.Timestamp = ts
+
+	if f.expIngest != nil {
+		if m := f.expIngest.FindSubmatch(data); len(m) > 1 {
+			if its, ierr := f.cbIngest(m[1]); ierr == nil {
+				entry.IngestTime = its
+			}
+		}
+	}
 	return
 }
 
@@ -104,6 +104,65 @@ func TestRegexCustomCb(t *testing.T) {
 	}
 }
 
+func TestRegexIngestTime(t *testing.T) {
+
+	var (
+		expEvent  = `event=(\S+)`
+		expIngest = `ingest=(\S+)`
+	)
+
+	factory, err := NewRegexFactory(expEvent, WithTimeFormat(time.RFC3339), WithIngestTime(expIngest, WithTimeFormat(time.RFC3339)))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	f := factory.New()
+
+	entry, err := f.ReadEntry([]byte(`ingest=2024-01-01T00:00:05Z event=2024-01-01T00:00:01Z msg=hello`))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if exp := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC).UnixNano(); entry.Timestamp != exp {
+		t.Errorf("Expected %d got %d", exp, entry.Timestamp)
+	}
+
+	if exp := time.Date(2024, 1, 1, 0, 0, 5, 0, time.UTC).UnixNano(); entry.IngestTime != exp {
+		t.Errorf("Expected %d got %d", exp, entry.IngestTime)
+	}
+
+	// Missing secondary is not an error.
+	entry, err = f.ReadEntry([]byte(`event=2024-01-01T00:00:01Z msg=hello`))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if entry.IngestTime != 0 {
+		t.Errorf("Expected zero ingest time, got %d", entry.IngestTime)
+	}
+
+	if _, err := NewRegexFactory(expEvent, WithTimeFormat(time.RFC3339), WithIngestTime(`(`, nil)); err == nil {
+		t.Errorf("Expected compile error on ingest expression")
+	}
+}
+
+func TestRegexIngestTimeNilCb(t *testing.T) {
+	factory, err := NewRegexFactory(`event=(\S+)`, WithTimeFormat(time.RFC3339), WithIngestTime(`ingest=(\S+)`, nil))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	entry, err := factory.New().ReadEntry([]byte(`ingest=2024-01-01T00:00:05Z event=2024-01-01T00:00:01Z msg=hello`))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	// Parsed as the primary timestamp is.
+	if exp := time.Date(2024, 1, 1, 0, 0, 5, 0, time.UTC).UnixNano(); entry.IngestTime != exp {
+		t.Errorf("Expected %d got %d", exp, entry.IngestTime)
+	}
+}
+
 func TestMungeYearWithSlop(t *testing.T) {
 	t.Run("PanicOnInvalidDuration", func(t *testing.T) {
 		defer func() {
@@ -269,3 +328,23 @@ func TestMungeYearWithSlop(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexTimeFormatIn(t *testing.T) {
+
+	loc := time.FixedZone("UTC-5", -5*3600)
+
+	exp := `^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) `
+	factory, err := NewRegexFactory(exp, WithTimeFormatIn(time.DateTime, loc))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	entry, err := factory.New().ReadEntry([]byte("2020-01-09 10:04:05 local time"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if entry.Timestamp != 1578582245000000000 {
+		t.Errorf("Expected %d got %d", 1578582245000000000, entry.Timestamp)
+	}
+}
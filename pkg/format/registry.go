@@ -0,0 +1,190 @@
+package format
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// Registered formats are consulted by Detect from the highest priority down,
+// ties in order of registration.  The built in formats take priorities at and
+// just below PriorityBuiltin, so a format registered with the default priority
+// of 0 is tried after all of them.
+const PriorityBuiltin = 100
+
+type registryEntryT struct {
+	name     string
+	priority int
+	seq      int
+	factory  FactoryI
+	detect   DetectFormatFunc
+}
+
+type registryT struct {
+	mux     sync.RWMutex
+	seq     int
+	entries []registryEntryT
+}
+
+var registry = &registryT{}
+
+func init() {
+	// Detection order matters: CRI must come before RFC3339Nano since they
+	// both start with RFC3339Nano, and epoch before RFC3164.
+	builtins := []struct {
+		name    string
+		factory FactoryI
+		detect  DetectFormatFunc
+	}{
+		{FactoryJSON, NewJsonFactory(), detectJSON},
+		{FactoryCRI, NewCriFactory(), detectCri},
+		{FactoryRfc3339Nano, &rfc3339NanoFactoryT{delim: delimiter}, detectRFC3339Nano},
+		{FactoryEpoch, &epochFactoryT{unit: EpochAuto, delim: delimiter}, detectEpoch},
+		{FactoryRfc5424, NewRfc5424Factory(), detectRfc5424},
+		{FactoryKlog, NewKlogFactory(), detectKlog},
+		{FactoryRfc3164, NewRfc3164Factory(), detectRfc3164},
+		{FactoryDocker, NewDockerFactory(), nil}, // Detected as JSON
+	}
+
+	for i, b := range builtins {
+		registry.add(registryEntryT{
+			name:     b.name,
+			priority: PriorityBuiltin - i,
+			factory:  b.factory,
+			detect:   b.detect,
+		})
+	}
+}
+
+type registerOptsT struct {
+	priority  int
+	detect    DetectFormatFunc
+	detectSet bool
+}
+
+type RegisterOptT func(*registerOptsT)
+
+// WithPriority sets the detection priority; higher is tried first.
+func WithPriority(priority int) RegisterOptT {
+	return func(o *registerOptsT) {
+		o.priority = priority
+	}
+}
+
+// WithDetect replaces the default detection, which accepts a line if the
+// factory's parser reads it.  A nil detect registers the format for Lookup only.
+func WithDetect(detect DetectFormatFunc) RegisterOptT {
+	return func(o *registerOptsT) {
+		o.detect = detect
+		o.detectSet = true
+	}
+}
+
+// Register adds a format under name for Lookup and Detect.
+func Register(name string, factory FactoryI, opts ...RegisterOptT) error {
+	if name == "" || factory == nil {
+		return ErrFormatName
+	}
+
+	var o registerOptsT
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !o.detectSet {
+		o.detect = detectFactory(factory)
+	}
+
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+
+	if registry.find(name) >= 0 {
+		return ErrFormatDupe
+	}
+
+	registry.add(registryEntryT{
+		name:     name,
+		priority: o.priority,
+		factory:  factory,
+		detect:   o.detect,
+	})
+	return nil
+}
+
+// Unregister removes the format registered under name, if any.
+func Unregister(name string) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+
+	if idx := registry.find(name); idx >= 0 {
+		registry.entries = slices.Delete(registry.entries, idx, idx+1)
+	}
+}
+
+// Lookup returns the factory registered under name.
+func Lookup(name string) (FactoryI, bool) {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+
+	if idx := registry.find(name); idx >= 0 {
+		return registry.entries[idx].factory, true
+	}
+	return nil, false
+}
+
+// Formats returns the registered names in detection order.
+func Formats() []string {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+
+	names := make([]string, 0, len(registry.entries))
+	for _, entry := range registry.entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+// Detection functions in priority order.
+func detectors() []DetectFormatFunc {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+
+	funcs := make([]DetectFormatFunc, 0, len(registry.entries))
+	for _, entry := range registry.entries {
+		if entry.detect != nil {
+			funcs = append(funcs, entry.detect)
+		}
+	}
+	return funcs
+}
+
+// Caller must hold the lock.
+func (r *registryT) find(name string) int {
+	return slices.IndexFunc(r.entries, func(e registryEntryT) bool {
+		return e.name == name
+	})
+}
+
+// Caller must hold the lock; keeps the entries in detection order.
+func (r *registryT) add(entry registryEntryT) {
+	r.seq += 1
+	entry.seq = r.seq
+
+	idx, _ := slices.BinarySearchFunc(r.entries, entry, func(a, b registryEntryT) int {
+		if c := cmp.Compare(b.priority, a.priority); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	r.entries = slices.Insert(r.entries, idx, entry)
+}
+
+func detectFactory(factory FactoryI) DetectFormatFunc {
+	return func(line []byte) (FactoryI, int64, error) {
+		entry, err := factory.New().ReadEntry(line)
+		if err != nil {
+			return nil, -1, err
+		}
+		return factory, entry.Timestamp, nil
+	}
+}
@@ -0,0 +1,103 @@
+package format
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryBuiltins(t *testing.T) {
+
+	names := Formats()
+	for _, name := range []string{FactoryJSON, FactoryCRI, FactoryRfc3339Nano, FactoryRfc3164, FactoryDocker} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s registered", name)
+		}
+		factory, ok := Lookup(name)
+		if !ok || factory.String() != name {
+			t.Errorf("Expected lookup of %s", name)
+		}
+	}
+
+	if i, j := slices.Index(names, FactoryCRI), slices.Index(names, FactoryRfc3339Nano); i > j {
+		t.Errorf("Expected %s before %s: %v", FactoryCRI, FactoryRfc3339Nano, names)
+	}
+
+	if err := Register(FactoryJSON, NewJsonFactory()); !errors.Is(err, ErrFormatDupe) {
+		t.Errorf("Expected %v got %v", ErrFormatDupe, err)
+	}
+	if err := Register("", NewJsonFactory()); !errors.Is(err, ErrFormatName) {
+		t.Errorf("Expected %v got %v", ErrFormatName, err)
+	}
+}
+
+func TestRegistryDetect(t *testing.T) {
+
+	const data = "2024-02-13 15:12:44,355 [main] INFO hello\n"
+
+	if _, _, err := Detect(strings.NewReader(data)); !errors.Is(err, ErrFormatDetect) {
+		t.Fatalf("Expected %v got %v", ErrFormatDetect, err)
+	}
+
+	factory, err := NewLayout("2006-01-02 15:04:05,000")
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if err := Register("log4j", factory); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	defer Unregister("log4j")
+
+	got, ts, err := Detect(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if got != factory {
+		t.Errorf("Expected registered factory, got %s", got.String())
+	}
+	if exp := time.Date(2024, time.February, 13, 15, 12, 44, 355000000, time.UTC).UnixNano(); ts != exp {
+		t.Errorf("Expected %d got %d", exp, ts)
+	}
+}
+
+func TestRegistryPriority(t *testing.T) {
+
+	const data = "2016-10-06T00:17:09.669794202Z stdout F hello\n"
+
+	shadow, err := NewLayout(time.RFC3339Nano)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	// Default priority is tried after the built in CRI format.
+	if err := Register("low", shadow); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	defer Unregister("low")
+
+	if got, _, _ := Detect(strings.NewReader(data)); got.String() != FactoryCRI {
+		t.Errorf("Expected %s got %s", FactoryCRI, got.String())
+	}
+
+	if err := Register("high", shadow, WithPriority(PriorityBuiltin+1)); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	defer Unregister("high")
+
+	if got, _, _ := Detect(strings.NewReader(data)); got != shadow {
+		t.Errorf("Expected layout got %s", got.String())
+	}
+
+	// Lookup only.
+	if err := Register("hidden", shadow, WithPriority(PriorityBuiltin+2), WithDetect(nil)); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	defer Unregister("hidden")
+
+	if names := Formats(); names[0] != "hidden" || names[1] != "high" {
+		t.Errorf("Unexpected order %v", names)
+	}
+}
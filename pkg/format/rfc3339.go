@@ -9,14 +9,19 @@ import (
 	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
 )
 
+// Candidate delimiters between the timestamp and the log content, in order of preference.
+var rfc3339Delimiters = []byte{delimiter, '\t', '|'}
+
 type rfc3339NanoFmtT struct {
+	delim byte
 }
 
 type rfc3339NanoFactoryT struct {
+	delim byte
 }
 
 func (f *rfc3339NanoFactoryT) New() ParserI {
-	return &rfc3339NanoFmtT{}
+	return &rfc3339NanoFmtT{delim: f.delim}
 }
 
 func (f *rfc3339NanoFactoryT) String() string {
@@ -40,16 +45,16 @@ func (f *rfc3339NanoFmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
 		return
 	}
 
-	return scanCriTimestamp(buf[:n])
+	return scanDelimTimestamp(buf[:n], f.delim)
 }
 
-// Expects format:
+// Expects format, where the delimiter is one of rfc3339Delimiters:
 //	2016-10-06T00:17:09.669794202Z log content 1
 //	2016-10-06T00:17:09.669794203Z log content 2
 
 func (f *rfc3339NanoFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
 
-	idx := bytes.IndexByte(line, delimiter)
+	idx := bytes.IndexByte(line, f.delim)
 	if idx < 0 {
 		entry = LogEntry{}
 		err = ErrNoTimestamp
@@ -69,14 +74,19 @@ func (f *rfc3339NanoFmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
 	return
 }
 
+// Try each candidate delimiter in turn; the first that yields a parseable timestamp wins.
 func detectRFC3339Nano(line []byte) (FactoryI, int64, error) {
 
-	var cf rfc3339NanoFmtT
-	entry, err := cf.ReadEntry(line)
+	var elist []error
 
-	if err != nil {
-		return nil, -1, err
+	for _, delim := range rfc3339Delimiters {
+		cf := rfc3339NanoFmtT{delim: delim}
+		entry, err := cf.ReadEntry(line)
+		if err == nil {
+			return &rfc3339NanoFactoryT{delim: delim}, entry.Timestamp, nil
+		}
+		elist = append(elist, err)
 	}
 
-	return &rfc3339NanoFactoryT{}, entry.Timestamp, nil
+	return nil, -1, errors.Join(elist...)
 }
@@ -0,0 +1,70 @@
+package format
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectRFC3339Delimiters(t *testing.T) {
+
+	const stampS = "2018-10-06T00:17:09.669794202Z"
+
+	stamp, _ := time.Parse(time.RFC3339Nano, stampS)
+
+	tests := map[string]struct {
+		data string
+		line string
+	}{
+		"space":           {data: stampS + " hello world\n", line: "hello world\n"},
+		"tab":             {data: stampS + "\thello world\n", line: "hello world\n"},
+		"pipe":            {data: stampS + "|hello world\n", line: "hello world\n"},
+		"space_preferred": {data: stampS + " a|b\tc\n", line: "a|b\tc\n"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory, ts, err := Detect(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if factory.String() != FactoryRfc3339Nano {
+				t.Errorf("Expected %s got %s", FactoryRfc3339Nano, factory.String())
+			}
+
+			if ts != stamp.UnixNano() {
+				t.Errorf("Expected %d got %d", stamp.UnixNano(), ts)
+			}
+
+			f := factory.New()
+
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if entry.Line != tc.line {
+				t.Errorf("Expected %q got %q", tc.line, entry.Line)
+			}
+
+			ts, err = f.ReadTimestamp(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if ts != stamp.UnixNano() {
+				t.Errorf("Expected %d got %d", stamp.UnixNano(), ts)
+			}
+		})
+	}
+}
+
+func TestDetectRFC3339Fail(t *testing.T) {
+
+	_, _, err := Detect(strings.NewReader("Jan  9 host hello world\n"))
+	if !errors.Is(err, ErrFormatDetect) {
+		t.Errorf("Expected %v got %v", ErrFormatDetect, err)
+	}
+}
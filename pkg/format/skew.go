@@ -0,0 +1,47 @@
+package format
+
+import (
+	"io"
+	"time"
+)
+
+type skewFmtT struct {
+	ParserI
+	skew int64
+}
+
+type skewFactoryT struct {
+	FactoryI
+	skew int64
+}
+
+// NewSkewFactory corrects a source whose clock is off by skew; skew is added
+// to every timestamp the parser reads, so a source running 2s behind takes a
+// skew of 2s.  The factory keeps the name of the one it wraps.
+func NewSkewFactory(factory FactoryI, skew time.Duration) FactoryI {
+	if skew == 0 {
+		return factory
+	}
+	return &skewFactoryT{FactoryI: factory, skew: int64(skew)}
+}
+
+func (f *skewFactoryT) New() ParserI {
+	return &skewFmtT{ParserI: f.FactoryI.New(), skew: f.skew}
+}
+
+func (f *skewFmtT) ReadTimestamp(rdr io.Reader) (int64, error) {
+	ts, err := f.ParserI.ReadTimestamp(rdr)
+	if err != nil {
+		return ts, err
+	}
+	return ts + f.skew, nil
+}
+
+func (f *skewFmtT) ReadEntry(line []byte) (LogEntry, error) {
+	entry, err := f.ParserI.ReadEntry(line)
+	if err != nil {
+		return entry, err
+	}
+	entry.Timestamp += f.skew
+	return entry, nil
+}
@@ -0,0 +1,52 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSkewFactory(t *testing.T) {
+
+	const (
+		line = "2024-02-13T15:12:44.380212573Z hello world"
+		ts   = int64(1707837164380212573)
+		skew = 2 * time.Second
+	)
+
+	factory := NewSkewFactory(&rfc3339NanoFactoryT{delim: delimiter}, skew)
+
+	if factory.String() != FactoryRfc3339Nano {
+		t.Errorf("Expected name %s got %s", FactoryRfc3339Nano, factory.String())
+	}
+
+	f := factory.New()
+
+	entry, err := f.ReadEntry([]byte(line))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if entry.Timestamp != ts+int64(skew) {
+		t.Errorf("Expected %d got %d", ts+int64(skew), entry.Timestamp)
+	}
+	if entry.Line != "hello world" {
+		t.Errorf("Expected line 'hello world' got '%s'", entry.Line)
+	}
+
+	got, err := f.ReadTimestamp(bytes.NewReader([]byte(line)))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if got != ts+int64(skew) {
+		t.Errorf("Expected %d got %d", ts+int64(skew), got)
+	}
+
+	if _, err = f.ReadEntry([]byte("no timestamp")); err == nil {
+		t.Errorf("Expected error on bad line")
+	}
+
+	base := NewKlogFactory()
+	if NewSkewFactory(base, 0) != base {
+		t.Errorf("Expected zero skew to return the factory unwrapped")
+	}
+}
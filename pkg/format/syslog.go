@@ -0,0 +1,394 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+const (
+	rfc3164Layout = "Jan _2 15:04:05"
+	rfc5424Nil    = '-'
+)
+
+// Byte order mark that may prefix an RFC 5424 message.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ----------
+
+type rfc3164FmtT struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+type rfc3164FactoryT struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+// NewRfc3164Factory parses classic BSD syslog.  The timestamp has no year;
+// it is taken to be the closest to now that is not more than a week ahead.
+func NewRfc3164Factory(opts ...ClockOptT) FactoryI {
+	o := parseClockOpts(opts)
+	return &rfc3164FactoryT{loc: o.loc, now: o.now}
+}
+
+func (f *rfc3164FactoryT) New() ParserI {
+	return &rfc3164FmtT{loc: f.loc, now: f.now}
+}
+
+func (f *rfc3164FactoryT) String() string {
+	return FactoryRfc3164
+}
+
+func (f *rfc3164FmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
+
+	ptr := pool.PoolAlloc()
+	defer pool.PoolFree(ptr)
+	buf := (*ptr)[:tsBufSize]
+
+	n, err := io.ReadFull(rdr, buf)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if n == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	ts, _, err = f.parse(buf[:n])
+	return
+}
+
+// Read RFC 3164 entry; the priority is optional and fractional seconds are accepted.
+// Expects format:
+//	<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8
+//	Jan  2 15:04:05.123 host kernel: eth0: link up
+//
+// The line is the message, the tag and content after the hostname.  The
+// severity is parsed from the priority, if any.
+
+func (f *rfc3164FmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
+
+	ts, rest, err := f.parse(line)
+	if err != nil {
+		return
+	}
+
+	// Skip the hostname.
+	idx := bytes.IndexByte(rest, delimiter)
+	if idx < 0 {
+		err = ErrNoHeader
+		return
+	}
+
+	entry.Timestamp = ts
+	entry.Line = string(rest[idx+1:])
+	entry.Severity = prioritySeverity(line)
+	return
+}
+
+// Parse the priority and timestamp; returns the remainder after the timestamp delimiter.
+func (f *rfc3164FmtT) parse(line []byte) (int64, []byte, error) {
+
+	line, err := skipPriority(line, false)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	n := scan3164Timestamp(line)
+	if n < 0 || n >= len(line) || line[n] != delimiter {
+		return -1, nil, ErrNoTimestamp
+	}
+
+	t, err := time.ParseInLocation(rfc3164Layout, string(line[:n]), f.loc)
+	if err != nil {
+		return -1, nil, errors.Join(ErrParseTimestamp, err)
+	}
+
+	return inferYear(f.now(), t, defaultMungeSlop), line[n+1:], nil
+}
+
+// Returns the length of a 'Mmm dd hh:mm:ss[.fff]' timestamp, or -1.
+// The day may be padded with a space or a zero.
+func scan3164Timestamp(b []byte) int {
+	const hms = len("15:04:05")
+
+	if len(b) < 4 || !isAlpha(b[0]) || !isAlpha(b[1]) || !isAlpha(b[2]) || b[3] != ' ' {
+		return -1
+	}
+
+	i := 4
+	if i < len(b) && b[i] == ' ' {
+		i += 1
+	}
+
+	day := i
+	for i < len(b) && i-day < 2 && isDigit(b[i]) {
+		i += 1
+	}
+	if i == day || i >= len(b) || b[i] != ' ' {
+		return -1
+	}
+	i += 1
+
+	if len(b)-i < hms || b[i+2] != ':' || b[i+5] != ':' {
+		return -1
+	}
+	i += hms
+
+	if i < len(b) && b[i] == '.' {
+		i += 1
+		for i < len(b) && isDigit(b[i]) {
+			i += 1
+		}
+	}
+
+	return i
+}
+
+func detectRfc3164(line []byte) (FactoryI, int64, error) {
+
+	f := rfc3164FmtT{loc: time.UTC, now: time.Now}
+	entry, err := f.ReadEntry(line)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return NewRfc3164Factory(), entry.Timestamp, nil
+}
+
+// ----------
+
+type rfc5424FmtT struct {
+}
+
+type rfc5424FactoryT struct {
+}
+
+// NewRfc5424Factory parses structured syslog.
+func NewRfc5424Factory() FactoryI {
+	return &rfc5424FactoryT{}
+}
+
+func (f *rfc5424FactoryT) New() ParserI {
+	return &rfc5424FmtT{}
+}
+
+func (f *rfc5424FactoryT) String() string {
+	return FactoryRfc5424
+}
+
+func (f *rfc5424FmtT) ReadTimestamp(rdr io.Reader) (ts int64, err error) {
+
+	ptr := pool.PoolAlloc()
+	defer pool.PoolFree(ptr)
+	buf := (*ptr)[:tsBufSize]
+
+	n, err := io.ReadFull(rdr, buf)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if n == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	ts, _, err = parse5424Timestamp(buf[:n])
+	return
+}
+
+// Read RFC 5424 entry
+// (see https://datatracker.ietf.org/doc/html/rfc5424#section-6)
+// Expects format:
+//	<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event
+//	<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed
+//
+// The line is the message after the structured data, without a byte order mark.
+// The severity is parsed from the priority.
+
+func (f *rfc5424FmtT) ReadEntry(line []byte) (entry LogEntry, err error) {
+
+	ts, rest, err := parse5424Timestamp(line)
+	if err != nil {
+		return
+	}
+
+	// Skip HOSTNAME, APP-NAME, PROCID and MSGID.
+	for range 4 {
+		idx := bytes.IndexByte(rest, delimiter)
+		if idx < 0 {
+			err = ErrNoHeader
+			return
+		}
+		rest = rest[idx+1:]
+	}
+
+	n := scanStructured(rest)
+	if n < 0 {
+		err = ErrNoStructured
+		return
+	}
+	rest = rest[n:]
+
+	// The message is optional.
+	if len(rest) > 0 && rest[0] == delimiter {
+		rest = bytes.TrimPrefix(rest[1:], utf8BOM)
+	}
+
+	entry.Timestamp = ts
+	entry.Line = string(rest)
+	entry.Severity = prioritySeverity(line)
+	return
+}
+
+// Parse the priority, version and timestamp; returns the remainder after the timestamp delimiter.
+func parse5424Timestamp(line []byte) (int64, []byte, error) {
+
+	line, err := skipPriority(line, true)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	// VERSION is a non zero number of up to three digits.
+	var i int
+	for i < len(line) && i < 3 && isDigit(line[i]) {
+		i += 1
+	}
+	if i == 0 || line[0] == '0' || i >= len(line) || line[i] != delimiter {
+		return -1, nil, ErrNoVersion
+	}
+	line = line[i+1:]
+
+	idx := bytes.IndexByte(line, delimiter)
+	if idx < 0 {
+		return -1, nil, ErrNoTimestamp
+	}
+
+	// A nil timestamp cannot be placed in a window.
+	if idx == 1 && line[0] == rfc5424Nil {
+		return -1, nil, ErrNoTimestamp
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, string(line[:idx]))
+	if err != nil {
+		return -1, nil, errors.Join(ErrParseTimestamp, err)
+	}
+
+	return t.UnixNano(), line[idx+1:], nil
+}
+
+// Returns the length of the structured data, either nil or one or more
+// bracketed elements, or -1 if malformed.  Within quoted parameter values,
+// '"', '\' and ']' may be escaped with a backslash.
+func scanStructured(b []byte) int {
+	if len(b) > 0 && b[0] == rfc5424Nil {
+		if len(b) == 1 || isBreak(b[1]) {
+			return 1
+		}
+		return -1
+	}
+
+	var i int
+	for i < len(b) && b[i] == '[' {
+		var quoted bool
+		for i += 1; i < len(b); i += 1 {
+			c := b[i]
+			if quoted {
+				if c == '\\' {
+					i += 1
+				} else if c == '"' {
+					quoted = false
+				}
+				continue
+			}
+			if c == '"' {
+				quoted = true
+			} else if c == ']' {
+				break
+			}
+		}
+		if i >= len(b) {
+			return -1
+		}
+		i += 1
+	}
+
+	if i == 0 || (i < len(b) && !isBreak(b[i])) {
+		return -1
+	}
+	return i
+}
+
+func detectRfc5424(line []byte) (FactoryI, int64, error) {
+
+	var f rfc5424FmtT
+	entry, err := f.ReadEntry(line)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return &rfc5424FactoryT{}, entry.Timestamp, nil
+}
+
+// ----------
+
+// Skip the '<PRI>' prefix, a number of up to three digits.
+func skipPriority(line []byte, required bool) ([]byte, error) {
+	if len(line) == 0 || line[0] != '<' {
+		if required {
+			return nil, ErrNoPriority
+		}
+		return line, nil
+	}
+
+	idx := bytes.IndexByte(line, '>')
+	if idx < 2 || idx > 4 {
+		return nil, ErrNoPriority
+	}
+	for _, c := range line[1:idx] {
+		if !isDigit(c) {
+			return nil, ErrNoPriority
+		}
+	}
+
+	return line[idx+1:], nil
+}
+
+// The severity of a '<PRI>' prefix, the priority modulo 8; unknown if absent.
+func prioritySeverity(line []byte) entry.SeverityT {
+	rest, err := skipPriority(line, true)
+	if err != nil {
+		return entry.SevUnknown
+	}
+
+	pri, err := strconv.Atoi(string(line[1 : len(line)-len(rest)-1]))
+	if err != nil {
+		return entry.SevUnknown
+	}
+	return entry.SyslogSeverity(pri % 8)
+}
+
+// The structured data ends the line or is followed by the message.
+func isBreak(c byte) bool {
+	return c == delimiter || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
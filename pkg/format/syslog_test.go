@@ -0,0 +1,174 @@
+package format
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+func TestReadRfc3164Entry(t *testing.T) {
+
+	var (
+		now   = time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+		clock = func() time.Time { return now }
+		f     = NewRfc3164Factory(WithClock(clock)).New()
+	)
+
+	tests := map[string]struct {
+		data string
+		want LogEntry
+		werr error
+	}{
+		"empty":       {data: "", werr: ErrNoTimestamp},
+		"no_host":     {data: "Oct 11 22:14:15 ", werr: ErrNoHeader},
+		"bad_pri":     {data: "<1x>Oct 11 22:14:15 host msg", werr: ErrNoPriority},
+		"bad_month":   {data: "Foo 11 22:14:15 host msg", werr: ErrParseTimestamp},
+		"bad_clock":   {data: "Oct 11 22-14-15 host msg", werr: ErrNoTimestamp},
+		"rfc3339":     {data: "2016-10-06T00:17:09Z host msg", werr: ErrNoTimestamp},
+		"last_year":   {data: "<34>Oct 11 22:14:15 mymachine su: 'su root' failed\n", want: LogEntry{Timestamp: time.Date(2025, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(), Line: "su: 'su root' failed\n", Severity: entry.SevError + 1}},
+		"this_year":   {data: "Mar  2 15:04:05 host kernel: eth0: link up", want: LogEntry{Timestamp: time.Date(2026, time.March, 2, 15, 4, 5, 0, time.UTC).UnixNano(), Line: "kernel: eth0: link up"}},
+		"within_slop": {data: "Mar 15 15:04:05 host kernel: up", want: LogEntry{Timestamp: time.Date(2026, time.March, 15, 15, 4, 5, 0, time.UTC).UnixNano(), Line: "kernel: up"}},
+		"fraction":    {data: "Apr 30 23:36:47.715984 host app: ok", want: LogEntry{Timestamp: time.Date(2025, time.April, 30, 23, 36, 47, 715984000, time.UTC).UnixNano(), Line: "app: ok"}},
+		"single_day":  {data: "Jan 2 15:04:05 host app: ok", want: LogEntry{Timestamp: time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC).UnixNano(), Line: "app: ok"}},
+		"empty_msg":   {data: "Jan 02 15:04:05 host ", want: LogEntry{Timestamp: time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC).UnixNano()}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if !reflect.DeepEqual(entry, tc.want) {
+				t.Errorf("Expected %v got %v", tc.want, entry)
+			}
+		})
+	}
+}
+
+func TestRfc3164Location(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No tz database: %v", err)
+	}
+
+	var (
+		now = time.Date(2026, time.January, 2, 12, 0, 0, 0, time.UTC)
+		f   = NewRfc3164Factory(WithLocation(loc), WithClock(func() time.Time { return now })).New()
+		exp = time.Date(2026, time.January, 1, 23, 30, 0, 0, loc).UnixNano()
+	)
+
+	entry, err := f.ReadEntry([]byte("Jan  1 23:30:00 host msg"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if entry.Timestamp != exp {
+		t.Errorf("Expected %d got %d", exp, entry.Timestamp)
+	}
+
+	ts, err := f.ReadTimestamp(strings.NewReader("Jan  1 23:30:00 host msg"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if ts != exp {
+		t.Errorf("Expected %d got %d", exp, ts)
+	}
+}
+
+func TestReadRfc5424Entry(t *testing.T) {
+
+	var (
+		f     = NewRfc5424Factory().New()
+		stamp = time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC).UnixNano()
+	)
+
+	tests := map[string]struct {
+		data string
+		want LogEntry
+		werr error
+	}{
+		"empty":        {data: "", werr: ErrNoPriority},
+		"no_version":   {data: "<34> 2003-10-11T22:14:15.003Z host su - ID47 - msg", werr: ErrNoVersion},
+		"zero_version": {data: "<34>0 2003-10-11T22:14:15.003Z host su - ID47 - msg", werr: ErrNoVersion},
+		"nil_stamp":    {data: "<34>1 - host su - ID47 - msg", werr: ErrNoTimestamp},
+		"bad_stamp":    {data: "<34>1 2003-10-11 host su - ID47 - msg", werr: ErrParseTimestamp},
+		"short":        {data: "<34>1 2003-10-11T22:14:15.003Z host su -", werr: ErrNoHeader},
+		"bad_sd":       {data: "<34>1 2003-10-11T22:14:15.003Z host su - ID47 [a x=\"1\" msg", werr: ErrNoStructured},
+		"junk_sd":      {data: "<34>1 2003-10-11T22:14:15.003Z host su - ID47 -x msg", werr: ErrNoStructured},
+		"nil_sd":       {data: "<34>1 2003-10-11T22:14:15.003Z host su - ID47 - 'su root' failed\n", want: LogEntry{Timestamp: stamp, Line: "'su root' failed\n", Severity: entry.SevError + 1}},
+		"no_msg":       {data: "<34>1 2003-10-11T22:14:15.003Z host su - ID47 -", want: LogEntry{Timestamp: stamp, Severity: entry.SevError + 1}},
+		"no_msg_eol":   {data: "<34>1 2003-10-11T22:14:15.003Z host su - ID47 -\n", want: LogEntry{Timestamp: stamp, Line: "\n", Severity: entry.SevError + 1}},
+		"sd":           {data: "<165>1 2003-10-11T22:14:15.003Z host evntslog - ID47 [exampleSDID@32473 iut=\"3\"] An event", want: LogEntry{Timestamp: stamp, Line: "An event", Severity: entry.SevInfo + 1}},
+		"sd_multi":     {data: "<165>1 2003-10-11T22:14:15.003Z host evntslog - ID47 [a@1 x=\"1\"][b@1 y=\"2\"] An event", want: LogEntry{Timestamp: stamp, Line: "An event", Severity: entry.SevInfo + 1}},
+		"sd_escaped":   {data: "<165>1 2003-10-11T22:14:15.003Z host evntslog - ID47 [a@1 x=\"[\\\"]\\]\"] An event", want: LogEntry{Timestamp: stamp, Line: "An event", Severity: entry.SevInfo + 1}},
+		"bom":          {data: "<165>1 2003-10-11T22:14:15.003Z host evntslog - ID47 - \xEF\xBB\xBFAn event", want: LogEntry{Timestamp: stamp, Line: "An event", Severity: entry.SevInfo + 1}},
+		"offset":       {data: "<165>1 2003-10-12T00:14:15.003+02:00 host app 1234 - - An event", want: LogEntry{Timestamp: stamp, Line: "An event", Severity: entry.SevInfo + 1}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if !errors.Is(err, tc.werr) {
+				t.Fatalf("Expected %v got %v", tc.werr, err)
+			}
+			if !reflect.DeepEqual(entry, tc.want) {
+				t.Errorf("Expected %v got %v", tc.want, entry)
+			}
+		})
+	}
+}
+
+func TestDetectSyslog(t *testing.T) {
+
+	tests := map[string]struct {
+		data    string
+		factory string
+		line    string
+	}{
+		"rfc3164":     {data: "Jan  9 15:04:05 host app: hello world\n", factory: FactoryRfc3164, line: "app: hello world\n"},
+		"rfc3164_pri": {data: "<13>Jan  9 15:04:05 host app: hello world\n", factory: FactoryRfc3164, line: "app: hello world\n"},
+		"rfc5424":     {data: "<13>1 2018-10-06T00:17:09.669794202Z host app - - - hello world\n", factory: FactoryRfc5424, line: "hello world\n"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory, ts, err := Detect(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if factory.String() != tc.factory {
+				t.Errorf("Expected %s got %s", tc.factory, factory.String())
+			}
+
+			f := factory.New()
+
+			entry, err := f.ReadEntry([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if entry.Line != tc.line {
+				t.Errorf("Expected %q got %q", tc.line, entry.Line)
+			}
+
+			if entry.Timestamp != ts {
+				t.Errorf("Expected %d got %d", ts, entry.Timestamp)
+			}
+
+			rts, err := f.ReadTimestamp(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if rts != ts {
+				t.Errorf("Expected %d got %d", ts, rts)
+			}
+		})
+	}
+}
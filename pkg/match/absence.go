@@ -0,0 +1,161 @@
+package match
+
+import (
+	"errors"
+)
+
+var ErrHeartbeat = errors.New("heartbeat anchor requires a heartbeat term")
+
+// MatchAbsence fires when a term does not appear within window of an anchor.
+//
+// The anchor is selected with WithAbsence:
+//
+//   - AbsenceStart: the first line scanned.  The matcher fires at most once;
+//     any occurrence of the term disarms it for good.
+//   - AbsencePrevious: each occurrence of the term.  The matcher is not armed
+//     until the term first appears, and re-arms on every occurrence.
+//   - AbsenceHeartbeat: a heartbeat term supplied with WithHeartbeat.  An
+//     occurrence of the term disarms the matcher until the next heartbeat.  While
+//     armed, further heartbeats do not move the anchor.  A line matching both
+//     the heartbeat and the term is a heartbeat that is immediately satisfied.
+//
+// The hit carries the anchor line and fires once the clock advances more than
+// window past the anchor.  Like MatchFallingEdge this is time driven; it is
+// detected on Eval or on Scan of any line that advances the clock past the window.
+
+type MatchAbsence struct {
+	matcher   MatchFunc
+	heartbeat MatchFunc
+	mode      AbsenceT
+	window    int64
+	clock     int64
+	started   bool
+	armed     bool
+	anchor    LogEntry
+
+	statsT
+	lateT
+}
+
+func NewMatchAbsence(window int64, term TermT, opts ...OptT) (*MatchAbsence, error) {
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optAbsence | optHeartbeat); err != nil {
+		return nil, err
+	}
+
+	m, err := term.NewMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &MatchAbsence{matcher: m, mode: o.absence, window: window, statsT: newStats(o)}
+
+	if o.absence == AbsenceHeartbeat {
+		if o.heartbeat == nil {
+			return nil, ErrHeartbeat
+		}
+		if r.heartbeat, err = o.heartbeat.NewMatcher(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *MatchAbsence) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
+
+	if e.Timestamp < r.clock {
+		if e = r.late(&r.identT, "MatchAbsence", e, r.clock); e == nil {
+			return
+		}
+	}
+
+	// The window may have elapsed before this line arrived; fire on the old anchor first.
+	hits = r._eval(e.Timestamp)
+
+	switch r.mode {
+	case AbsenceStart:
+		if !r.started {
+			r.started = true
+			r.arm(e)
+		}
+		if r.matcher(e) {
+			r.nMatched += 1
+			r.armed = false
+		}
+
+	case AbsencePrevious:
+		if r.matcher(e) {
+			r.nMatched += 1
+			r.arm(e)
+		}
+
+	case AbsenceHeartbeat:
+		if !r.armed && r.heartbeat(e) {
+			r.arm(e)
+		}
+		if r.matcher(e) {
+			r.nMatched += 1
+			r.armed = false
+		}
+	}
+
+	return
+}
+
+func (r *MatchAbsence) arm(e *ScanLine) {
+	r.armed = true
+	r.anchor = e.Entry()
+}
+
+func (r *MatchAbsence) Eval(clock int64) (hits Hits) {
+	if clock <= r.clock {
+		return
+	}
+	return r._eval(clock)
+}
+
+func (r *MatchAbsence) _eval(clock int64) (hits Hits) {
+	r.clock = clock
+
+	if !r.armed || clock-r.anchor.Timestamp <= r.window {
+		return
+	}
+
+	r.nHits += 1
+	hits.Cnt = 1
+	hits.FireStamp = clock
+	hits.Logs = []LogEntry{r.anchor}
+	SetMeta(&hits, 0, HitMetaT{
+		Start: r.anchor.Timestamp,
+		End:   r.anchor.Timestamp + r.window,
+	})
+
+	r.armed = false
+	r.anchor = LogEntry{}
+	r.tag(&hits)
+	return
+}
+
+// Coverage returns the range from the anchor to the clock while armed.
+func (r *MatchAbsence) Coverage() (oldest, newest int64) {
+	if !r.armed {
+		return NoCoverage, NoCoverage
+	}
+	return r.anchor.Timestamp, r.clock
+}
+
+// State is a single entry; nothing to collect.
+func (r *MatchAbsence) GarbageCollect(clock int64) {
+	r.gcClock = clock
+}
+
+// Stats returns the matcher counters.
+func (r *MatchAbsence) Stats() StatsT {
+	s := r.stats(nil, &r.lateT)
+	if r.armed {
+		s.Buffered = 1
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package match
+
+import (
+	"testing"
+)
+
+func NewCasesAbsence() casesT {
+
+	return casesT{
+		"Start": {
+			// N----- (window 5; alpha never seen)
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "noop"},
+				{postF: checkEval(6, checkNoFire)},
+				{postF: checkEval(7, checkFireStamp(7, matchStamps(1)))},
+				{postF: checkEval(20, checkNoFire)},
+			},
+		},
+		"StartSatisfied": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "noop"},
+				{line: "alpha"},
+				{postF: checkEval(20, checkNoFire)},
+			},
+		},
+		"StartFireOnScan": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "noop"},
+				{stamp: 10, line: "alpha", cb: checkFireStamp(10, matchStamps(1))},
+			},
+		},
+		"Previous": {
+			window: 5,
+			terms:  []string{"alpha"},
+			opts:   []OptT{WithAbsence(AbsencePrevious)},
+			steps: []stepT{
+				{line: "noop"},
+				{postF: checkEval(10, checkNoFire)}, // Not armed until alpha
+				{stamp: 11, line: "alpha"},
+				{stamp: 15, line: "alpha"},
+				{postF: checkEval(20, checkNoFire)},
+				{postF: checkEval(21, matchStamps(15))},
+				{postF: checkEval(40, checkNoFire)},
+				{stamp: 41, line: "alpha"},
+				{postF: checkEval(47, matchStamps(41))},
+			},
+		},
+		"Heartbeat": {
+			window: 5,
+			terms:  []string{"alpha"},
+			opts:   []OptT{WithHeartbeat(makeRaw("ping"))},
+			steps: []stepT{
+				{line: "noop"},
+				{postF: checkEval(10, checkNoFire)}, // Not armed until ping
+				{stamp: 11, line: "ping"},
+				{stamp: 12, line: "alpha"},
+				{postF: checkEval(20, checkNoFire)},
+				{stamp: 21, line: "ping"},
+				{stamp: 23, line: "ping"}, // Does not move the anchor
+				{postF: checkEval(26, checkNoFire)},
+				{postF: checkEval(27, matchStamps(21))},
+			},
+		},
+		"HeartbeatAndTerm": {
+			window: 5,
+			terms:  []string{"alpha"},
+			opts:   []OptT{WithHeartbeat(makeRaw("ping"))},
+			steps: []stepT{
+				{line: "ping alpha"},
+				{postF: checkEval(20, checkNoFire)},
+			},
+		},
+		"OutOfOrder": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{stamp: 10, line: "noop"},
+				{stamp: 5, line: "alpha"},
+				{postF: checkEval(16, matchStamps(10))},
+			},
+		},
+	}
+}
+
+func TestAbsence(t *testing.T) {
+	defer disableLogs()()
+
+	NewCasesAbsence().run(t, func(tc caseT) (Matcher, error) {
+		return NewMatchAbsence(tc.window, makeTerms(tc.terms)[0], tc.opts...)
+	})
+}
+
+func TestAbsenceInitFail(t *testing.T) {
+
+	cases := map[string]struct {
+		err  error
+		term TermT
+		opts []OptT
+	}{
+		"EmptyTerm": {
+			err:  ErrTermEmpty,
+			term: TermT{Type: TermRaw, Value: ""},
+		},
+		"NoHeartbeat": {
+			err:  ErrHeartbeat,
+			term: makeRaw("alpha"),
+			opts: []OptT{WithAbsence(AbsenceHeartbeat)},
+		},
+		"EmptyHeartbeat": {
+			err:  ErrTermEmpty,
+			term: makeRaw("alpha"),
+			opts: []OptT{WithHeartbeat(TermT{Type: TermRaw})},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewMatchAbsence(10, tc.term, tc.opts...)
+			if err != tc.err {
+				t.Fatalf("Expected err == %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package match
+
+import (
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+)
+
+// Backing arrays of term asserts, recycled as terms grow and are reset.
+var assertPool pool.SlicePool[LogEntry]
+
+// Below this capacity asserts grow by plain append, so that the many terms
+// holding a few asserts do not each take a pooled array.
+const pooledCap = 8
+
+// Append e to the asserts.  Asserts are dropped from the front as they age
+// out of the window, so a term that runs away walks its view along the
+// backing array; on reaching the end the view is moved back to the front if
+// it fills at most half the array, and otherwise moved to a pooled array of
+// twice the size.
+func (t *termT) push(e LogEntry) {
+	if n := len(t.asserts); n == cap(t.asserts) && n >= pooledCap {
+		t.grow()
+	}
+	t.asserts = append(t.asserts, e)
+}
+
+func (t *termT) grow() {
+	n := len(t.asserts)
+
+	if t.base != nil && n <= cap(t.base)/2 {
+		base := t.base[:cap(t.base)]
+		copy(base, t.asserts)
+		clear(base[n:])
+		t.asserts = base[:n]
+		return
+	}
+
+	base := append(assertPool.Get(2*n), t.asserts...)
+	if t.base != nil {
+		assertPool.Put(t.base)
+	}
+	t.base, t.asserts = base, base
+}
+
+// Drop every assert.  A large backing array is recycled.
+func (t *termT) release() {
+	if cap(t.asserts) <= capThreshold {
+		t.asserts = t.asserts[:0]
+		return
+	}
+	if t.base != nil {
+		assertPool.Put(t.base)
+		t.base = nil
+	}
+	t.asserts = nil
+}
@@ -0,0 +1,66 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestAssertsCompact(t *testing.T) {
+	var (
+		terms = make([]termT, 1)
+		next  int64
+	)
+
+	// Hold a sliding window of 100 asserts, as a runaway term does.
+	for range 10000 {
+		next += 1
+		terms[0].push(LogEntry{Timestamp: next})
+		if len(terms[0].asserts) > 100 {
+			shiftLeft(terms, 0, 1)
+		}
+	}
+
+	m := terms[0].asserts
+	if len(m) != 100 {
+		t.Fatalf("Expected 100 asserts, got %d", len(m))
+	}
+	for i, e := range m {
+		if exp := next - 99 + int64(i); e.Timestamp != exp {
+			t.Fatalf("Assert %d: expected %d, got %d", i, exp, e.Timestamp)
+		}
+	}
+
+	// Compaction keeps the array at twice the window, or the next class.
+	if c := cap(terms[0].base); c > 256 {
+		t.Errorf("Expected the backing array reused, got capacity %d", c)
+	}
+
+	terms[0].release()
+	if terms[0].asserts != nil || terms[0].base != nil {
+		t.Errorf("Expected asserts released")
+	}
+}
+
+func TestAssertsSmall(t *testing.T) {
+	var term termT
+	for i := range pooledCap {
+		term.push(LogEntry{Timestamp: int64(i)})
+	}
+	if term.base != nil {
+		t.Errorf("Expected no pooled array below %d asserts", pooledCap)
+	}
+
+	// Append may round the capacity up with the size of an entry; the array
+	// is pooled once that fills.
+	for len(term.asserts) < cap(term.asserts) {
+		term.push(LogEntry{Timestamp: int64(len(term.asserts))})
+	}
+	if term.base != nil {
+		t.Errorf("Expected no pooled array until capacity %d fills", cap(term.asserts))
+	}
+
+	n := len(term.asserts)
+	term.push(LogEntry{Timestamp: int64(n)})
+	if term.base == nil || len(term.asserts) != n+1 || term.asserts[n].Timestamp != int64(n) {
+		t.Errorf("Expected a pooled array, got %v", term.asserts)
+	}
+}
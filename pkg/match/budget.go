@@ -0,0 +1,62 @@
+package match
+
+// WithMaxBuffered bounds the memory held by MatchSeq, MatchSet, InverseSeq and
+// InverseSet, so that a runaway match cannot accumulate without limit.  Each
+// term buffers at most n asserts, and each reset term records at most n reset
+// lines; the bound is applied as each line is scanned.
+//
+// The policy is to evict the oldest first.  An evicted assert can no longer
+// start a match, and an evicted reset line can no longer suppress one.  The
+// bound of a term is raised to its occurrences where needed, so that a counted
+// term can still complete.  Evictions are reported in StatsT.Evicted.  A bound
+// of zero or less, the default, is unbounded.
+func WithMaxBuffered(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optMaxBuffered
+		o.maxBuffered = max(n, 0)
+	}
+}
+
+// Embedded by matchers that honour WithMaxBuffered.
+type budgetT struct {
+	maxBuffered int
+}
+
+// Evict the oldest asserts of each term over budget.  The budget never drops
+// a term below its dupe count, so a hot term remains hot.  Returns the number
+// of asserts evicted.
+func (b budgetT) trimTerms(terms []termT, dupeMap map[int]int) (n int) {
+	if b.maxBuffered == 0 {
+		return
+	}
+
+	for i := range terms {
+		limit := max(b.maxBuffered, dupeMap[i]+1)
+		if cnt := len(terms[i].asserts) - limit; cnt > 0 {
+			shiftLeft(terms, i, cnt)
+			n += cnt
+		}
+	}
+	return
+}
+
+// Evict the oldest reset lines of each reset term over budget.
+// Returns the number of reset lines evicted.
+func (b budgetT) trimResets(resets []resetT) (n int) {
+	if b.maxBuffered == 0 {
+		return
+	}
+
+	for i := range resets {
+		cnt := len(resets[i].resets) - b.maxBuffered
+		if cnt <= 0 {
+			continue
+		}
+		resets[i].resets = resets[i].resets[cnt:]
+		if c := resets[i].corr; c != nil {
+			c.keys = c.keys[cnt:]
+		}
+		n += cnt
+	}
+	return
+}
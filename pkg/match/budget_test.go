@@ -0,0 +1,102 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestBudgetSeqRunaway(t *testing.T) {
+	sm, err := NewMatchSeqOpts(1000, makeTermsA("frank", "burns"), WithMaxBuffered(3))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i := range 100 {
+		sm.Scan(sl.ResetLine(int64(i+1), "Let's be frank"))
+	}
+
+	if s := sm.Stats(); s.Buffered != 3 || s.Evicted != 97 {
+		t.Errorf("Expected 3 buffered and 97 evicted, got %d and %d", s.Buffered, s.Evicted)
+	}
+
+	// The oldest were evicted; the match starts at a retained assert.
+	hits := sm.Scan(sl.ResetLine(101, "burns"))
+	if hits.Cnt != 1 || hits.Logs[0].Timestamp != 98 {
+		t.Errorf("Expected hit from 98, got %v", hits.Logs)
+	}
+}
+
+func TestBudgetSeqDupes(t *testing.T) {
+	// The bound is raised to the occurrences of a counted term.
+	sm, err := NewMatchSeqOpts(1000, []TermT{{Type: TermRaw, Value: "alpha", Count: 3}, makeRaw("beta")}, WithMaxBuffered(1))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		sl   = NewScanLine()
+		hits Hits
+	)
+	for i, line := range []string{"alpha", "alpha", "alpha", "alpha", "beta"} {
+		hits = sm.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	if hits.Cnt != 1 || !checkFrame(hits.Logs, 2, 3, 4, 5) {
+		t.Errorf("Expected hit on 2,3,4,5; got %v", hits.Logs)
+	}
+}
+
+func TestBudgetInverseSeqResets(t *testing.T) {
+	iseq, err := NewInverseSeq(
+		10,
+		makeTermsA("alpha", "beta"),
+		[]ResetT{{Term: makeRaw("reset"), Window: 100, Slide: -100}},
+		WithMaxBuffered(2),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i := range 5 {
+		iseq.Scan(sl.ResetLine(int64(i+1), "reset"))
+	}
+
+	if s := iseq.Stats(); s.Evicted != 3 {
+		t.Errorf("Expected 3 evicted, got %d", s.Evicted)
+	}
+}
+
+func TestBudgetSet(t *testing.T) {
+	ms, err := NewMatchSetOpts(1000, makeTermsA("alpha", "beta"), WithMaxBuffered(2))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		sl   = NewScanLine()
+		hits Hits
+	)
+	for i, line := range []string{"alpha", "alpha", "alpha", "alpha", "beta"} {
+		hits = ms.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	if hits.Cnt != 1 || !checkFrame(hits.Logs, 3, 5) {
+		t.Errorf("Expected hit on 3,5; got %v", hits.Logs)
+	}
+	if s := ms.Stats(); s.Evicted != 2 || s.Buffered != 1 {
+		t.Errorf("Expected 2 evicted and 1 buffered, got %d and %d", s.Evicted, s.Buffered)
+	}
+}
+
+func checkFrame(logs []LogEntry, stamps ...int64) bool {
+	if len(logs) != len(stamps) {
+		return false
+	}
+	for i, e := range logs {
+		if e.Timestamp != stamps[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package match
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+// MatchBytesFunc matches a raw line, for filtering lines held in a buffer
+// before an entry is built.
+type MatchBytesFunc func(line []byte) bool
+
+// NewBytesMatcher returns the term matcher over raw lines.  Raw and regex
+// terms match the bytes directly; other terms, including jq, view the line
+// through a ScanLine without copying.  As with MatchFunc, the result is not
+// safe for concurrent use.
+func (tt TermT) NewBytesMatcher() (MatchBytesFunc, error) {
+
+	m, err := tt.NewMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Raw lines carry no severity; those terms view the line as an entry.
+	switch {
+	case tt.MinSeverity != entry.SevUnknown:
+	case tt.Type == TermRaw && tt.Options == 0:
+		return makeRawBytesMatch(tt.Value), nil
+	case tt.Type == TermRegex:
+		// Validated by NewMatcher above.
+		return makeRegexBytesMatch(regexp.MustCompile(tt.Value), requiredLiteral(tt.Value)), nil
+	}
+
+	sl := NewScanLine()
+	return func(line []byte) bool {
+		return m(sl.ResetBytes(0, line))
+	}, nil
+}
+
+func makeRawBytesMatch(s string) MatchBytesFunc {
+	b := []byte(s)
+	return func(line []byte) bool {
+		return bytes.Contains(line, b)
+	}
+}
+
+func makeRegexBytesMatch(exp *regexp.Regexp, lit string) MatchBytesFunc {
+	if lit == "" {
+		return exp.Match
+	}
+	b := []byte(lit)
+	return func(line []byte) bool {
+		return bytes.Contains(line, b) && exp.Match(line)
+	}
+}
@@ -0,0 +1,83 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestBytesMatcher(t *testing.T) {
+	cases := map[string]struct {
+		term  TermT
+		line  string
+		match bool
+	}{
+		"Raw":       {term: TermT{Type: TermRaw, Value: "alpha"}, line: "an alpha line", match: true},
+		"RawMiss":   {term: TermT{Type: TermRaw, Value: "alpha"}, line: "a beta line"},
+		"RawOpts":   {term: TermT{Type: TermRaw, Value: "ALPHA", Options: TermOptNoCase}, line: "an alpha line", match: true},
+		"Regex":     {term: TermT{Type: TermRegex, Value: `code [1-9]`}, line: "exit code 2", match: true},
+		"RegexMiss": {term: TermT{Type: TermRegex, Value: `code [1-9]`}, line: "exit code 0"},
+		"Jq":        {term: TermT{Type: TermJqJson, Value: `.level == "error"`}, line: `{"level":"error"}`, match: true},
+		"JqMiss":    {term: TermT{Type: TermJqJson, Value: `.level == "error"`}, line: `{"level":"info"}`},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m, err := tc.term.NewBytesMatcher()
+			if err != nil {
+				t.Fatalf("Expected nil error, got: %v", err)
+			}
+			if got := m([]byte(tc.line)); got != tc.match {
+				t.Errorf("Expected %v, got %v", tc.match, got)
+			}
+		})
+	}
+
+	if _, err := (TermT{Type: TermRegex, Value: "("}).NewBytesMatcher(); err == nil {
+		t.Errorf("Expected compile error")
+	}
+}
+
+func TestScanLineBorrowed(t *testing.T) {
+	sm, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		sl   = NewScanLine()
+		buf  = make([]byte, 0, 64)
+		hits Hits
+	)
+
+	// The buffer is reused for every line; retained asserts must not alias it.
+	for i, line := range []string{"alpha", "gamma", "beta"} {
+		buf = append(buf[:0], line...)
+		hits = sm.Scan(sl.ResetBytes(int64(i+1), buf))
+	}
+	copy(buf, "XXXX")
+
+	if hits.Cnt != 1 || hits.Logs[0].Line != "alpha" || hits.Logs[1].Line != "beta" {
+		t.Errorf("Expected alpha, beta; got %v", hits.Logs)
+	}
+}
+
+func TestScanLineBytesNoAlloc(t *testing.T) {
+	sm, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		sl  = NewScanLine()
+		buf = []byte("a line that matches no term")
+		ts  int64
+	)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ts += 1
+		sm.Scan(sl.ResetBytes(ts, buf))
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
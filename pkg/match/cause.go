@@ -0,0 +1,127 @@
+package match
+
+import (
+	"cmp"
+	"slices"
+)
+
+// PropCause is the Props key holding the causal chain for a hit.
+const PropCause = "cause"
+
+// CauseT is a node in the causal chain of a hit.  A leaf names the matcher that
+// fired and the timestamps of the logs it contributed.  A combinator node names
+// the combinator and lists the sub-matchers that contributed, ordered by their
+// earliest contributing timestamp.
+type CauseT struct {
+	Name   string   `json:"n"`
+	Stamps []int64  `json:"t,omitempty"`
+	Causes []CauseT `json:"c,omitempty"`
+}
+
+// JoinCauses returns a combinator node over the children.  Children are sorted
+// by their first contributing timestamp so the chain reads in causal order.
+func JoinCauses(name string, children ...CauseT) CauseT {
+	causes := slices.Clone(children)
+	slices.SortStableFunc(causes, func(a, b CauseT) int {
+		return cmp.Compare(a.first(), b.first())
+	})
+	return CauseT{Name: name, Causes: causes}
+}
+
+// Flatten returns the leaves of the chain in causal order.
+func (c CauseT) Flatten() []CauseT {
+	if len(c.Causes) == 0 {
+		return []CauseT{c}
+	}
+
+	var out []CauseT
+	for _, child := range c.Causes {
+		out = append(out, child.Flatten()...)
+	}
+	return out
+}
+
+func (c CauseT) first() int64 {
+	if len(c.Stamps) > 0 {
+		return c.Stamps[0]
+	}
+	if len(c.Causes) > 0 {
+		return c.Causes[0].first()
+	}
+	return 0
+}
+
+// CauseOf returns the causal chain of hit i, if present.
+func CauseOf(h Hits, i int) (CauseT, bool) {
+	if h.Props == nil {
+		return CauseT{}, false
+	}
+	c, ok := h.Props[PropKey{Idx: i, Key: PropCause}].(CauseT)
+	return c, ok
+}
+
+// SetCause records c as the causal chain of hit i.
+func SetCause(h *Hits, i int, c CauseT) {
+	if h.Props == nil {
+		h.Props = make(map[PropKey]any)
+	}
+	h.Props[PropKey{Idx: i, Key: PropCause}] = c
+}
+
+// MatchNamed wraps a Matcher so that every hit it emits carries a CauseT under
+// its name, and names the rule in its metadata.  The cause is a leaf of the
+// hit's timestamps, or where the wrapped matcher is a combinator such as
+// MatchChain, the chain it built renamed.  Combinators use the causes of their
+// sub-matchers to build the causal chain.
+
+type MatchNamed struct {
+	m    Matcher
+	name string
+}
+
+func NewMatchNamed(name string, m Matcher) *MatchNamed {
+	return &MatchNamed{m: m, name: name}
+}
+
+func (r *MatchNamed) Scan(e *ScanLine) Hits {
+	return r.annotate(r.m.Scan(e))
+}
+
+func (r *MatchNamed) Eval(clock int64) Hits {
+	return r.annotate(r.m.Eval(clock))
+}
+
+func (r *MatchNamed) GarbageCollect(clock int64) {
+	r.m.GarbageCollect(clock)
+}
+
+// Stats returns the counters of the wrapped matcher.
+func (r *MatchNamed) Stats() StatsT {
+	return statsOf(r.m)
+}
+
+// Oldest forwards to the wrapped matcher; see OldestI.
+func (r *MatchNamed) Oldest() (int64, bool) {
+	return Oldest(r.m)
+}
+
+func (r *MatchNamed) annotate(hits Hits) Hits {
+	for i := range hits.Cnt {
+		if c, ok := CauseOf(hits, i); ok && len(c.Causes) > 0 {
+			c.Name = r.name
+			SetCause(&hits, i, c)
+		} else {
+			logs := hits.group(i)
+			stamps := make([]int64, 0, len(logs))
+			for _, e := range logs {
+				stamps = append(stamps, e.Timestamp)
+			}
+			SetCause(&hits, i, CauseT{Name: r.name, Stamps: stamps})
+		}
+
+		meta, _ := MetaOf(hits, i)
+		meta.Rule = r.name
+		SetMeta(&hits, i, meta)
+	}
+	return hits
+}
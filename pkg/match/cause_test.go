@@ -0,0 +1,151 @@
+package match
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCauseChain(t *testing.T) {
+
+	oomSingle, err := NewMatchSingle(makeRaw("OOMKilled"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	restartSeq, err := NewMatchSeq(10, makeTermsA("Back-off", "restarting")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		oom     = NewMatchNamed("oom", oomSingle)
+		restart = NewMatchNamed("restart", restartSeq)
+		subs    = []Matcher{restart, oom}
+		leaves  = make(map[int]CauseT)
+		sl      = NewScanLine()
+	)
+
+	lines := []string{
+		"container OOMKilled",
+		"Back-off pulling image",
+		"noop",
+		"restarting container",
+	}
+
+	for i, line := range lines {
+		sl.ResetLine(int64(i+1), line)
+		for j, sm := range subs {
+			hits := sm.Scan(sl)
+			if hits.Cnt == 0 {
+				continue
+			}
+			c, ok := CauseOf(hits, 0)
+			if !ok {
+				t.Fatalf("Expected cause on hit from %d", j)
+			}
+			leaves[j] = c
+		}
+	}
+
+	if len(leaves) != 2 {
+		t.Fatalf("Expected both sub-matchers to fire, got %v", leaves)
+	}
+
+	// Two levels: a combinator over the two sub-matchers.
+	var hits Hits
+	hits.Cnt = 1
+	SetCause(&hits, 0, JoinCauses("crashloop", leaves[0], leaves[1]))
+
+	chain, ok := CauseOf(hits, 0)
+	if !ok {
+		t.Fatalf("Expected cause on combinator hit")
+	}
+
+	exp := CauseT{
+		Name: "crashloop",
+		Causes: []CauseT{
+			{Name: "oom", Stamps: []int64{1}},
+			{Name: "restart", Stamps: []int64{2, 4}},
+		},
+	}
+
+	if !reflect.DeepEqual(chain, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, chain)
+	}
+
+	if flat := chain.Flatten(); len(flat) != 2 || flat[0].Name != "oom" || flat[1].Name != "restart" {
+		t.Errorf("Expected flattened [oom restart], got %+v", flat)
+	}
+
+	if props := hits.IndexProps(0); props[PropCause] == nil {
+		t.Errorf("Expected cause in index props")
+	}
+}
+
+func TestCauseOfMissing(t *testing.T) {
+	if _, ok := CauseOf(Hits{Cnt: 1}, 0); ok {
+		t.Errorf("Expected no cause")
+	}
+}
+
+func TestCauseChainMatch(t *testing.T) {
+	oomSingle, err := NewMatchSingle(makeRaw("OOMKilled"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	restartCount, err := NewMatchCount(10, 3, makeRaw("restart"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	seq, err := NewMatchSeq(20, ChainTerm("oom"), ChainTerm("restarts"), makeRaw("NotReady"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	chain, err := NewMatchChain(NewMatchNamed("crashloop", seq),
+		ChainInputT{Name: "oom", M: NewMatchNamed("oom", oomSingle)},
+		ChainInputT{Name: "restarts", M: restartCount},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	m := NewMatchNamed("incident", chain)
+
+	var (
+		hits Hits
+		sl   = NewScanLine()
+	)
+	for _, step := range []struct {
+		stamp int64
+		line  string
+	}{
+		{1, "container OOMKilled"},
+		{2, "pod restart"},
+		{3, "pod restart"},
+		{5, "pod restart"},
+		{8, "node NotReady"},
+	} {
+		hits = m.Scan(sl.ResetLine(step.stamp, step.line))
+	}
+
+	if hits.Cnt != 1 {
+		t.Fatalf("Expected 1 hit, got %d", hits.Cnt)
+	}
+
+	// The named upstream keeps its name; the unnamed is named for its input.
+	exp := CauseT{
+		Name: "incident",
+		Causes: []CauseT{
+			{Name: "oom", Stamps: []int64{1}},
+			{Name: "restarts", Stamps: []int64{2, 3, 5}},
+			{Name: "crashloop", Stamps: []int64{8}},
+		},
+	}
+	if c, ok := CauseOf(hits, 0); !ok || !reflect.DeepEqual(c, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, c)
+	}
+
+	if len(chain.causes) != 0 {
+		t.Errorf("Expected the upstream causes consumed, got %v", chain.causes)
+	}
+}
@@ -0,0 +1,191 @@
+package match
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+var ErrChainInput = errors.New("chain input requires a unique name and a matcher")
+
+// ChainInputT is an upstream matcher of a MatchChain.  Its hits are fed to the
+// downstream matcher as lines that ChainTerm(Name) matches.
+type ChainInputT struct {
+	Name string
+	M    Matcher
+}
+
+// chainLineT is the synthetic line for an upstream hit.
+type chainLineT struct {
+	Hit   string `json:"hit"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+	Logs  int    `json:"logs"`
+}
+
+// MatchChain composes matchers, so that the hits of one become input terms of
+// another; for example, a hit on three pod restarts followed by node NotReady.
+// Each line is scanned by the upstream matchers, and each upstream hit is fed
+// to the downstream matcher as a synthetic JSON line:
+//
+//	{"hit":"restarts","start":1,"end":3,"logs":3}
+//
+// The window spans the hit logs, or the HitMetaT of the hit where set, such as
+// for MatchAbsence.  Each synthetic line is stamped with the end of the hit
+// window, then the line itself is scanned by the downstream matcher.  A hit
+// emitted by a timed upstream matcher after the downstream clock has passed its
+// window end is stamped with the clock instead, so that it is not dropped as
+// out of order.  Only downstream hits are emitted.
+//
+// Each hit carries its causal chain; see CauseOf.  The root is named for the
+// downstream cause, as set by MatchNamed, or "chain"; under it are the chains
+// of the upstream hits fed, or leaves named for their inputs, and a leaf of
+// the lines the downstream matched directly, in causal order.
+
+type MatchChain struct {
+	m      Matcher
+	inputs []ChainInputT
+	clock  int64
+	sl     *ScanLine
+	causes map[chainFedT]CauseT // Causes of the upstream hits fed.
+}
+
+// A synthetic line fed downstream.
+type chainFedT struct {
+	line  string
+	stamp int64
+}
+
+// Name of the root of a chain's causes, where the downstream is unnamed.
+const chainCause = "chain"
+
+func NewMatchChain(m Matcher, inputs ...ChainInputT) (*MatchChain, error) {
+	for i, in := range inputs {
+		if in.Name == "" || in.M == nil || slices.ContainsFunc(inputs[:i], func(o ChainInputT) bool {
+			return o.Name == in.Name
+		}) {
+			return nil, ErrChainInput
+		}
+	}
+
+	return &MatchChain{m: m, inputs: inputs, sl: NewScanLine(), causes: make(map[chainFedT]CauseT)}, nil
+}
+
+// ChainTerm matches the synthetic lines of hits from the chain input name.
+func ChainTerm(name string) TermT {
+	v, _ := json.Marshal(name)
+	return TermT{Type: TermRaw, Value: `{"hit":` + string(v) + `,`}
+}
+
+func (r *MatchChain) Scan(e *ScanLine) (hits Hits) {
+	for _, in := range r.inputs {
+		hits.Append(r.feed(in.Name, in.M.Scan(e)))
+	}
+
+	r.clock = max(r.clock, e.Timestamp)
+	hits.Append(r.cause(r.m.Scan(e)))
+	return
+}
+
+func (r *MatchChain) Eval(clock int64) (hits Hits) {
+	for _, in := range r.inputs {
+		hits.Append(r.feed(in.Name, in.M.Eval(clock)))
+	}
+
+	r.clock = max(r.clock, clock)
+	hits.Append(r.cause(r.m.Eval(clock)))
+	r.prune()
+	return
+}
+
+func (r *MatchChain) GarbageCollect(clock int64) {
+	for _, in := range r.inputs {
+		in.M.GarbageCollect(clock)
+	}
+	r.m.GarbageCollect(clock)
+	r.prune()
+}
+
+// Drop the causes of upstream hits stamped before any line the downstream
+// holds.
+func (r *MatchChain) prune() {
+	oldest, held := Oldest(r.m)
+	for fed := range r.causes {
+		if !held || fed.stamp < oldest {
+			delete(r.causes, fed)
+		}
+	}
+}
+
+// Record the causal chain of each downstream hit, from the upstream hits it
+// consumed and the lines it matched directly.
+func (r *MatchChain) cause(h Hits) Hits {
+	for i := range h.Cnt {
+		var (
+			name     = chainCause
+			children []CauseT
+			direct   []int64
+		)
+		if c, ok := CauseOf(h, i); ok {
+			name = c.Name
+		}
+
+		for _, e := range h.group(i) {
+			fed := chainFedT{line: e.Line, stamp: e.Timestamp}
+			if c, ok := r.causes[fed]; ok {
+				children = append(children, c)
+				delete(r.causes, fed)
+			} else {
+				direct = append(direct, e.Timestamp)
+			}
+		}
+		if len(direct) > 0 {
+			children = append(children, CauseT{Name: name, Stamps: direct})
+		}
+
+		SetCause(&h, i, JoinCauses(name, children...))
+	}
+	return h
+}
+
+// Stats returns the counters of the downstream matcher.
+func (r *MatchChain) Stats() StatsT {
+	return statsOf(r.m)
+}
+
+// Feed the upstream hits to the downstream matcher.
+func (r *MatchChain) feed(name string, h Hits) (hits Hits) {
+	for i := range h.Cnt {
+		logs := h.Index(i)
+		if len(logs) == 0 {
+			continue
+		}
+
+		line := chainLineT{Hit: name, Start: logs[0].Timestamp, End: logs[0].Timestamp, Logs: len(logs)}
+		for _, l := range logs[1:] {
+			line.Start = min(line.Start, l.Timestamp)
+			line.End = max(line.End, l.Timestamp)
+		}
+		if meta, ok := MetaOf(h, i); ok {
+			line.Start, line.End = meta.Start, meta.End
+		}
+
+		data, err := json.Marshal(line)
+		if err != nil {
+			continue
+		}
+
+		c, ok := CauseOf(h, i)
+		if !ok {
+			c = CauseT{Name: name, Stamps: make([]int64, 0, len(logs))}
+			for _, l := range logs {
+				c.Stamps = append(c.Stamps, l.Timestamp)
+			}
+		}
+
+		r.clock = max(r.clock, line.End)
+		r.causes[chainFedT{line: string(data), stamp: r.clock}] = c
+		hits.Append(r.cause(r.m.Scan(r.sl.ResetLine(r.clock, string(data)))))
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package match
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	restarts, err := NewMatchCount(10, 3, makeRaw("restart"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	seq, err := NewMatchSeq(20, ChainTerm("restarts"), makeRaw("NotReady"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	chain, err := NewMatchChain(seq, ChainInputT{Name: "restarts", M: restarts})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+
+	// NotReady before the restarts does not count.
+	steps := []struct {
+		stamp int64
+		line  string
+		cnt   int
+	}{
+		{1, "node NotReady", 0},
+		{2, "pod restart", 0},
+		{3, "pod restart", 0},
+		{5, "pod restart", 0},
+		{8, "node NotReady", 1},
+	}
+
+	var hits Hits
+	for i, step := range steps {
+		hits = chain.Scan(sl.ResetLine(step.stamp, step.line))
+		if hits.Cnt != step.cnt {
+			t.Fatalf("Step %d: expected %d hits, got %d", i, step.cnt, hits.Cnt)
+		}
+	}
+
+	matchStamps(5, 8)(t, 5, hits)
+
+	var line chainLineT
+	if err := json.Unmarshal([]byte(hits.Logs[0].Line), &line); err != nil {
+		t.Fatalf("Expected synthetic JSON line, got %q: %v", hits.Logs[0].Line, err)
+	}
+	if line != (chainLineT{Hit: "restarts", Start: 2, End: 5, Logs: 3}) {
+		t.Errorf("Unexpected synthetic line %+v", line)
+	}
+
+	if s := chain.Stats(); s.Hits != 1 {
+		t.Errorf("Expected downstream stats, got %+v", s)
+	}
+}
+
+func TestChainTimed(t *testing.T) {
+	// The absence hit fires on eval, after the downstream clock has moved on.
+	absence, _ := NewMatchAbsence(5, makeRaw("heartbeat"), WithAbsence(AbsencePrevious))
+	set, _ := NewMatchSet(10, ChainTerm("silent"), makeRaw("error"))
+
+	chain, err := NewMatchChain(set, ChainInputT{Name: "silent", M: absence})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	chain.Scan(sl.ResetLine(1, "heartbeat"))
+	chain.Scan(sl.ResetLine(3, "error"))
+
+	// Stamped with the end of the absence window.
+	hits := chain.Eval(10)
+	if hits.Cnt != 1 {
+		t.Fatalf("Expected 1 hit on eval, got %d", hits.Cnt)
+	}
+	matchStamps(6, 3)(t, 1, hits)
+}
+
+func TestChainFail(t *testing.T) {
+	m, _ := NewMatchSingle(makeRaw("alpha"))
+
+	cases := map[string][]ChainInputT{
+		"NoName":    {{M: m}},
+		"NoMatcher": {{Name: "a"}},
+		"Dupe":      {{Name: "a", M: m}, {Name: "a", M: m}},
+	}
+
+	for name, inputs := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewMatchChain(m, inputs...); err != ErrChainInput {
+				t.Errorf("Expected %v, got %v", ErrChainInput, err)
+			}
+		})
+	}
+}
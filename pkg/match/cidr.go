@@ -0,0 +1,145 @@
+package match
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+)
+
+var ErrCIDRSpec = errors.New("invalid cidr term")
+
+// A TermCIDR value is a list of CIDR prefixes, separated by spaces or commas,
+// optionally preceded by a field and the keyword "in":
+//
+//	10.0.0.0/8, 192.168.0.0/16
+//	client.ip in 10.0.0.0/8 fd00::/8
+//
+// Without a field, the line is scanned for IPv4 and IPv6 addresses; an IPv4
+// address followed by a port is recognized.  With a field, the field is read
+// as with TermNumeric.  The term matches if any address falls within any prefix.
+// A bare address in the list is taken as a single host prefix.
+
+type cidrT struct {
+	key      string
+	path     []string
+	prefixes []netip.Prefix
+}
+
+func makeCIDRMatch(term string) (MatchFunc, error) {
+	c, err := parseCIDR(term)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.key == "" {
+		return func(e *ScanLine) bool {
+			return scanAddrs(e.Line, c.contains)
+		}, nil
+	}
+
+	return func(e *ScanLine) bool {
+		v, ok := extractField(e, c.key, c.path)
+		if !ok {
+			return false
+		}
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		addr, ok := parseAddr(s)
+		return ok && c.contains(addr)
+	}, nil
+}
+
+func parseCIDR(term string) (c cidrT, err error) {
+	list := term
+	if field, rest, found := strings.Cut(term, " in "); found {
+		var ok bool
+		if c.key, c.path, ok = parseFieldPath(field); !ok {
+			return c, ErrCIDRSpec
+		}
+		list = rest
+	}
+
+	for _, tok := range strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		var p netip.Prefix
+		if strings.Contains(tok, "/") {
+			if p, err = netip.ParsePrefix(tok); err != nil {
+				return c, ErrCIDRSpec
+			}
+		} else {
+			addr, perr := netip.ParseAddr(tok)
+			if perr != nil {
+				return c, ErrCIDRSpec
+			}
+			p = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		c.prefixes = append(c.prefixes, p.Masked())
+	}
+
+	if len(c.prefixes) == 0 {
+		return c, ErrCIDRSpec
+	}
+	return c, nil
+}
+
+func (c cidrT) contains(addr netip.Addr) bool {
+	for _, p := range c.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse an address, allowing an IPv4 address with a trailing port.
+func parseAddr(s string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap(), true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// Call fn on each address found in line until fn returns true.  Candidates are
+// runs of hex digits, '.' and ':' that contain a '.' or ':'.
+func scanAddrs(line string, fn func(netip.Addr) bool) bool {
+	start := -1
+	for i := 0; i <= len(line); i++ {
+		if i < len(line) && isAddrByte(line[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+
+		// Shed trailing punctuation; a leading ':' may belong to an IPv6 address.
+		tok := strings.TrimLeft(strings.TrimRight(line[start:i], ".:"), ".")
+		start = -1
+
+		if len(tok) < 2 || !strings.ContainsAny(tok, ".:") {
+			continue
+		}
+		if addr, ok := parseAddr(tok); ok && fn(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAddrByte(c byte) bool {
+	switch {
+	case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		return true
+	case c == '.' || c == ':':
+		return true
+	}
+	return false
+}
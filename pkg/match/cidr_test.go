@@ -0,0 +1,52 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCIDRMatch(t *testing.T) {
+
+	tests := map[string]struct {
+		term  string
+		line  string
+		match bool
+	}{
+		"V4":           {term: "10.0.0.0/8", line: "connection from 10.1.2.3 accepted", match: true},
+		"V4Miss":       {term: "10.0.0.0/8", line: "connection from 11.1.2.3 accepted", match: false},
+		"V4Port":       {term: "10.0.0.0/8", line: "peer=10.1.2.3:8443 closed", match: true},
+		"V4Punct":      {term: "10.0.0.0/8", line: "denied 10.1.2.3.", match: true},
+		"V4Second":     {term: "10.0.0.0/8", line: "192.168.1.1 -> 10.9.9.9", match: true},
+		"Set":          {term: "172.16.0.0/12, 192.168.0.0/16", line: "src 192.168.4.20", match: true},
+		"Host":         {term: "203.0.113.7", line: "ban 203.0.113.7", match: true},
+		"V6":           {term: "fd00::/8", line: "from [fd12:3456::1]:22", match: true},
+		"V6Loopback":   {term: "::1/128", line: "bind ::1 port 80", match: true},
+		"V6Mapped":     {term: "10.0.0.0/8", line: "from ::ffff:10.0.0.5", match: true},
+		"NotAddr":      {term: "10.0.0.0/8", line: "at 10:00:01 version 10.2", match: false},
+		"Field":        {term: "client.ip in 10.0.0.0/8", line: `{"client":{"ip":"10.1.1.1"},"server":"8.8.8.8"}`, match: true},
+		"FieldOther":   {term: "client.ip in 8.8.8.0/24", line: `{"client":{"ip":"10.1.1.1"},"server":"8.8.8.8"}`, match: false},
+		"FieldLogfmt":  {term: "src in 10.0.0.0/8", line: `src=10.1.1.1:5000 dst=8.8.8.8`, match: true},
+		"FieldMissing": {term: "dst in 10.0.0.0/8", line: `{"src":"10.1.1.1"}`, match: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := TermT{Type: TermCIDR, Value: tc.term}.NewMatcher()
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+			if got := m(NewScanLine().ResetLine(1, tc.line)); got != tc.match {
+				t.Errorf("Expected %v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestCIDRBadTerm(t *testing.T) {
+	for _, term := range []string{"10.0.0.0/33", "nope", " in 10.0.0.0/8", "ip in ", ","} {
+		_, err := TermT{Type: TermCIDR, Value: term}.NewMatcher()
+		if !errors.Is(err, ErrTermCompile) || !errors.Is(err, ErrCIDRSpec) {
+			t.Errorf("%q: Expected %v, got %v", term, ErrCIDRSpec, err)
+		}
+	}
+}
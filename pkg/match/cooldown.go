@@ -0,0 +1,65 @@
+package match
+
+import (
+	"math"
+)
+
+// MatchCooldown wraps a Matcher to suppress hits for a cooldown period after
+// each hit, for example to alert at most once every five minutes.  A hit is
+// suppressed if it fires less than cooldown after the last hit let through.
+// Hits that fire together in one batch share a fire stamp, so only the first
+// of a batch is let through.  The wrapped matcher runs unchanged; suppression
+// applies only to what is emitted.
+
+type MatchCooldown struct {
+	m          Matcher
+	cooldown   int64
+	next       int64
+	suppressed uint64
+}
+
+func NewMatchCooldown(cooldown int64, m Matcher) *MatchCooldown {
+	return &MatchCooldown{m: m, cooldown: cooldown, next: math.MinInt64}
+}
+
+func (r *MatchCooldown) Scan(e *ScanLine) Hits {
+	return r.filter(r.m.Scan(e))
+}
+
+func (r *MatchCooldown) Eval(clock int64) Hits {
+	return r.filter(r.m.Eval(clock))
+}
+
+func (r *MatchCooldown) GarbageCollect(clock int64) {
+	r.m.GarbageCollect(clock)
+}
+
+// Suppressed returns the number of hits suppressed so far.
+func (r *MatchCooldown) Suppressed() uint64 {
+	return r.suppressed
+}
+
+// Stats returns the counters of the wrapped matcher; Hits includes those suppressed.
+func (r *MatchCooldown) Stats() StatsT {
+	return statsOf(r.m)
+}
+
+func (r *MatchCooldown) filter(h Hits) Hits {
+	switch {
+	case h.Cnt == 0, r.cooldown <= 0:
+		return h
+	case h.FireStamp < r.next:
+		r.suppressed += uint64(h.Cnt)
+		return Hits{}
+	}
+
+	r.next = h.FireStamp + r.cooldown
+
+	if h.Cnt == 1 {
+		return h
+	}
+
+	r.suppressed += uint64(h.Cnt - 1)
+
+	return h.Head(1)
+}
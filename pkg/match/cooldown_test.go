@@ -0,0 +1,73 @@
+package match
+
+import (
+	"testing"
+)
+
+func NewCasesCooldown() casesT {
+
+	return casesT{
+		"Suppress": {
+			// At most one hit per 5.
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha", cb: matchStamps(1)},
+				{line: "alpha"},
+				{line: "alpha"},
+				{stamp: 5, line: "alpha"},
+				{stamp: 6, line: "alpha", cb: matchStamps(6)},
+				{stamp: 7, line: "alpha"},
+				{stamp: 20, line: "alpha", cb: matchStamps(20)},
+			},
+		},
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	NewCasesCooldown().run(t, func(tc caseT) (Matcher, error) {
+		m, err := NewMatchSingle(makeTerms(tc.terms)[0])
+		if err != nil {
+			return nil, err
+		}
+		return NewMatchCooldown(tc.window, m), nil
+	})
+}
+
+func TestCooldownBatch(t *testing.T) {
+	iseq, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset"), Window: 2, Absolute: true, Anchor: 1}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		cd = NewMatchCooldown(100, iseq)
+		sl = NewScanLine()
+	)
+
+	// Two hits pending on the reset window fire together on Eval.
+	for i, line := range []string{"alpha", "beta", "alpha", "beta"} {
+		if hits := cd.Scan(sl.ResetLine(int64(i+1), line)); hits.Cnt != 0 {
+			t.Fatalf("Expected no hits, got %v", hits.Cnt)
+		}
+	}
+
+	hits := cd.Eval(20)
+	matchStamps(1, 2)(t, 1, hits)
+
+	if cd.Suppressed() != 1 {
+		t.Errorf("Expected 1 suppressed, got %v", cd.Suppressed())
+	}
+}
+
+func TestCooldownDisabled(t *testing.T) {
+	m, _ := NewMatchSingle(makeRaw("alpha"))
+	cd := NewMatchCooldown(0, m)
+
+	sl := NewScanLine()
+	for i := range 3 {
+		if hits := cd.Scan(sl.ResetLine(1, "alpha")); hits.Cnt != 1 {
+			t.Errorf("Step %d: Expected 1 hit, got %v", i, hits.Cnt)
+		}
+	}
+}
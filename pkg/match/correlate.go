@@ -0,0 +1,92 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrCorrelate = errors.New("invalid correlation field")
+
+// correlateT restricts a reset to lines that share a field value with the
+// anchor entry of the match, such as a request ID.  The field is a dotted
+// path into a JSON line, a logfmt key, or a prop extracted by a jq term; or
+// the value is derived by a KeyFn.
+type correlateT struct {
+	key   string
+	path  []string
+	keyFn KeyFn
+	keys  []string // Field value of each recorded reset, parallel to resets.
+}
+
+func newCorrelate(field string, keyFn KeyFn) (*correlateT, error) {
+	switch {
+	case keyFn != nil && field != "":
+		return nil, fmt.Errorf("%w: '%s' with a key function", ErrCorrelate, field)
+	case keyFn != nil:
+		return &correlateT{keyFn: keyFn}, nil
+	case field == "":
+		return nil, nil
+	}
+	key, path, ok := parseFieldPath(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrCorrelate, field)
+	}
+	return &correlateT{key: key, path: path}, nil
+}
+
+// Extract the correlation value from the line; props take precedence, those
+// extracted by the term just evaluated over those of the entry.
+func (c *correlateT) value(e *ScanLine) (string, bool) {
+	if c.keyFn != nil {
+		v := c.keyFn(e)
+		return v, v != ""
+	}
+	if v, ok := e.extract[c.key]; ok {
+		return fieldString(v), true
+	}
+	if v, ok := e.Props[c.key]; ok {
+		return fieldString(v), true
+	}
+	v, ok := extractField(e, c.key, c.path)
+	if !ok {
+		return "", false
+	}
+	return fieldString(v), true
+}
+
+// Extract the correlation value from a stored entry.
+func (c *correlateT) entryValue(e LogEntry) (string, bool) {
+	var sl ScanLine
+	return c.value(sl.Reset(e))
+}
+
+func fieldString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// Record the line if it matches the reset term.  A correlated reset is only
+// recorded if the line carries the correlation field.
+func (r *resetT) record(e *ScanLine) bool {
+	if !r.matcher(e) {
+		return false
+	}
+
+	if r.corr != nil {
+		v, ok := r.corr.value(e)
+		if !ok {
+			return false
+		}
+		r.corr.keys = append(r.corr.keys, e.own(v))
+	}
+
+	r.resets = append(r.resets, e.Timestamp)
+	return true
+}
@@ -0,0 +1,238 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func NewCasesCorrelate() casesT {
+	reset := []ResetT{{
+		Term:      makeRaw("cancel"),
+		Window:    5,
+		Absolute:  true,
+		Correlate: "req",
+	}}
+
+	return casesT{
+
+		"SameKey": {
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a"}`},
+				{line: `{"msg":"cancel","req":"a"}`},
+				{line: `{"msg":"retry","req":"a"}`},
+				{line: "NOOP", stamp: 20},
+			},
+		},
+
+		"OtherKey": {
+			// A reset for another request does not apply.
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a"}`},
+				{line: `{"msg":"cancel","req":"b"}`},
+				{line: `{"msg":"retry","req":"a"}`},
+				{line: "NOOP", stamp: 20, cb: matchStamps(1, 3)},
+			},
+		},
+
+		"MissingKey": {
+			// A reset without the field cannot correlate.
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a"}`},
+				{line: `{"msg":"cancel"}`},
+				{line: `{"msg":"retry","req":"a"}`},
+				{line: "NOOP", stamp: 20, cb: matchStamps(1, 3)},
+			},
+		},
+
+		"Logfmt": {
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: "msg=start req=a"},
+				{line: "msg=cancel req=b"},
+				{line: "msg=retry req=a"},
+				{line: "msg=start req=c"},
+				{line: "msg=cancel req=c"},
+				{line: "msg=retry req=c"},
+				{line: "NOOP", stamp: 20, cb: matchStamps(1, 3)},
+			},
+		},
+
+		"Numeric": {
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":7}`},
+				{line: "msg=cancel req=7"},
+				{line: `{"msg":"retry","req":7}`},
+				{line: "NOOP", stamp: 20},
+			},
+		},
+
+		"JqProp": {
+			// The reset term extracts the field under the correlated name.
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset: []ResetT{{
+				Term:      TermT{Type: TermJqJson, Value: `select(.msg == "cancel") | {req: .id}`},
+				Window:    5,
+				Absolute:  true,
+				Correlate: "req",
+			}},
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a"}`},
+				{line: `{"msg":"cancel","id":"b"}`},
+				{line: `{"msg":"retry","req":"a"}`},
+				{line: `{"msg":"start","req":"c"}`},
+				{line: `{"msg":"cancel","id":"c"}`},
+				{line: `{"msg":"retry","req":"c"}`},
+				{line: "NOOP", stamp: 20, cb: matchStamps(1, 3)},
+			},
+		},
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	cases := NewCasesCorrelate()
+
+	t.Run("Seq", func(t *testing.T) {
+		cases.run(t, func(tc caseT) (Matcher, error) {
+			return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+		})
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		cases.run(t, func(tc caseT) (Matcher, error) {
+			return NewInverseSet(tc.window, makeTerms(tc.terms), tc.reset)
+		})
+	})
+}
+
+func TestCorrelateInit(t *testing.T) {
+	reset := []ResetT{{Term: makeRaw("cancel"), Correlate: "req..id"}}
+	if _, err := NewInverseSeq(10, makeTermsA("start"), reset); !errors.Is(err, ErrCorrelate) {
+		t.Errorf("Expected ErrCorrelate, got %v", err)
+	}
+}
+
+func TestCorrelateState(t *testing.T) {
+	reset := []ResetT{{Term: makeRaw("cancel"), Window: 5, Absolute: true, Correlate: "req"}}
+
+	newMatcher := func() *InverseSeq {
+		m, err := NewInverseSeq(10, makeTermsA("start", "retry"), reset)
+		if err != nil {
+			t.Fatalf("Expected nil error, got: %v", err)
+		}
+		return m
+	}
+
+	var (
+		m  = newMatcher()
+		sl = NewScanLine()
+	)
+	m.Scan(sl.ResetLine(1, "msg=cancel req=b"))
+	m.Scan(sl.ResetLine(2, "msg=start req=a"))
+
+	data, err := m.MarshalState()
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	r := newMatcher()
+	if err := r.RestoreState(data); err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	r.Scan(sl.ResetLine(3, "msg=cancel req=a"))
+	r.Scan(sl.ResetLine(4, "msg=retry req=a"))
+	if hits := r.Eval(20); hits.Cnt != 0 {
+		t.Errorf("Expected correlated reset, got %d hits", hits.Cnt)
+	}
+}
+
+func TestCorrelateKey(t *testing.T) {
+	req, err := KeyJq(`.req`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	user, err := KeyJq(`.user`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	reset := []ResetT{{
+		Term:         makeRaw("cancel"),
+		Window:       5,
+		Absolute:     true,
+		CorrelateKey: KeyComposite(req, user),
+	}}
+
+	cases := casesT{
+		"SameKey": {
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a","user":"x"}`},
+				{line: `{"msg":"cancel","req":"a","user":"x"}`},
+				{line: `{"msg":"retry","req":"a","user":"x"}`},
+				{line: "NOOP", stamp: 20},
+			},
+		},
+
+		"OtherPart": {
+			// Either part differing is another identity.
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a","user":"x"}`},
+				{line: `{"msg":"cancel","req":"a","user":"y"}`},
+				{line: `{"msg":"cancel","req":"b","user":"x"}`},
+				{line: `{"msg":"retry","req":"a","user":"x"}`},
+				{line: "NOOP", stamp: 20, cb: matchStamps(1, 4)},
+			},
+		},
+
+		"PartialKey": {
+			// A reset missing a part cannot correlate.
+			window: 10,
+			terms:  []string{"start", "retry"},
+			reset:  reset,
+			steps: []stepT{
+				{line: `{"msg":"start","req":"a","user":"x"}`},
+				{line: `{"msg":"cancel","req":"a"}`},
+				{line: `{"msg":"retry","req":"a","user":"x"}`},
+				{line: "NOOP", stamp: 20, cb: matchStamps(1, 3)},
+			},
+		},
+	}
+
+	t.Run("Seq", func(t *testing.T) {
+		cases.run(t, func(tc caseT) (Matcher, error) {
+			return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+		})
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		cases.run(t, func(tc caseT) (Matcher, error) {
+			return NewInverseSet(tc.window, makeTerms(tc.terms), tc.reset)
+		})
+	})
+
+	both := []ResetT{{Term: makeRaw("cancel"), Correlate: "req", CorrelateKey: req}}
+	if _, err := NewInverseSeq(10, makeTermsA("start"), both); !errors.Is(err, ErrCorrelate) {
+		t.Errorf("Expected ErrCorrelate, got %v", err)
+	}
+}
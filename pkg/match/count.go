@@ -0,0 +1,140 @@
+package match
+
+import (
+	"errors"
+	"slices"
+)
+
+var ErrThreshold = errors.New("threshold must be positive")
+
+// MatchCount fires when a term matches at least threshold times within the
+// window.  The hit carries the threshold contributing entries.
+//
+// By default the matcher is tumbling; on fire the contributing entries are
+// consumed and the count starts anew.  WithRefire(RefireRolling) instead drops
+// only the oldest entry, so each subsequent match that keeps the count at the
+// threshold within the window fires again.
+//
+// Like MatchSeq, the matcher is edge triggered and a span of exactly window
+// between the first and last entry is considered within the window.
+
+type MatchCount struct {
+	matcher   MatchFunc
+	clock     int64
+	window    int64
+	threshold int
+	refire    RefireT
+	asserts   []LogEntry
+
+	statsT
+	lateT
+}
+
+func NewMatchCount(window int64, threshold int, term TermT, opts ...OptT) (*MatchCount, error) {
+	if threshold <= 0 {
+		return nil, ErrThreshold
+	}
+
+	m, err := term.NewMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optRefire); err != nil {
+		return nil, err
+	}
+
+	return &MatchCount{
+		matcher:   m,
+		window:    window,
+		threshold: threshold,
+		refire:    o.refire,
+		statsT:    newStats(o),
+	}, nil
+}
+
+func (r *MatchCount) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
+	if e.Timestamp < r.clock {
+		if e = r.late(&r.identT, "MatchCount", e, r.clock); e == nil {
+			return
+		}
+	}
+	r.clock = e.Timestamp
+
+	if !r.matcher(e) {
+		return
+	}
+	r.nMatched += 1
+
+	r.GarbageCollect(e.Timestamp)
+	r.asserts = append(r.asserts, e.Entry())
+
+	if len(r.asserts) < r.threshold {
+		return
+	}
+
+	r.nHits += 1
+	hits.Cnt = 1
+	hits.FireStamp = e.Timestamp
+
+	switch r.refire {
+	case RefireRolling:
+		hits.Logs = slices.Clone(r.asserts)
+		r.asserts = r.asserts[1:]
+	default:
+		hits.Logs = r.asserts
+		r.asserts = nil
+	}
+
+	r.tag(&hits)
+	return
+}
+
+// Count is edge triggered; the clock alone cannot cause a fire.  Advances the
+// clock and drops entries that have left the window.
+func (r *MatchCount) Eval(clock int64) (hits Hits) {
+	if clock <= r.clock {
+		return
+	}
+	r.clock = clock
+	if !r.gcDue(clock, len(r.asserts)) {
+		return
+	}
+	r.GarbageCollect(clock)
+	return
+}
+
+// Remove all entries that are older than the window.
+func (r *MatchCount) GarbageCollect(clock int64) {
+	var (
+		deadline = clock - r.window
+		cnt      = countBefore(r.asserts, deadline)
+	)
+
+	r.gcClock = clock
+
+	if cnt == len(r.asserts) {
+		r.asserts = r.asserts[:0]
+	} else if cnt > 0 {
+		r.asserts = r.asserts[cnt:]
+	}
+}
+
+// Coverage returns the range from the oldest retained entry to the clock.
+func (r *MatchCount) Coverage() (oldest, newest int64) {
+	if len(r.asserts) == 0 {
+		return NoCoverage, NoCoverage
+	}
+	return r.asserts[0].Timestamp, r.clock
+}
+
+func (r *MatchCount) edgeTriggered() {}
+
+// Stats returns the matcher counters.
+func (r *MatchCount) Stats() StatsT {
+	s := r.stats(nil, &r.lateT)
+	s.Buffered = len(r.asserts)
+	return s
+}
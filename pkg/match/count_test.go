@@ -0,0 +1,125 @@
+package match
+
+import (
+	"testing"
+)
+
+func NewCasesCount() casesT {
+
+	return casesT{
+		"Threshold": {
+			// AAA (threshold 3)
+			window: 10,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "alpha"},
+				{line: "alpha", cb: matchStamps(1, 3, 4)},
+				{line: "alpha"},
+			},
+		},
+		"Window": {
+			// A---------AA (first ages out)
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{stamp: 7, line: "alpha"},
+				{stamp: 8, line: "alpha"},
+				{stamp: 12, line: "alpha", cb: matchStamps(7, 8, 12)},
+			},
+		},
+		"WindowInclusive": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{stamp: 6, line: "alpha", cb: matchStamps(1, 2, 6)},
+			},
+		},
+		"Tumbling": {
+			window: 10,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "alpha", cb: matchStamps(1, 2, 3)},
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "alpha", cb: matchStamps(4, 5, 6)},
+			},
+		},
+		"Rolling": {
+			window: 10,
+			terms:  []string{"alpha"},
+			opts:   []OptT{WithRefire(RefireRolling)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "alpha", cb: matchStamps(1, 2, 3)},
+				{line: "alpha", cb: matchStamps(2, 3, 4)},
+				{stamp: 13, line: "alpha", cb: matchStamps(3, 4, 13)},
+				{stamp: 15, line: "alpha"}, // 4 ages out
+			},
+		},
+		"GarbageCollect": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{postF: garbageCollect(10)},
+				{stamp: 10, line: "alpha"},
+				{postF: checkEval(11, checkNoFire)},
+			},
+		},
+		"OutOfOrder": {
+			window: 10,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{stamp: 5, line: "alpha"},
+				{stamp: 6, line: "alpha"},
+				{stamp: 4, line: "alpha"},
+				{stamp: 7, line: "alpha", cb: matchStamps(5, 6, 7)},
+			},
+		},
+	}
+}
+
+func TestCount(t *testing.T) {
+	defer disableLogs()()
+
+	NewCasesCount().run(t, func(tc caseT) (Matcher, error) {
+		return NewMatchCount(tc.window, 3, makeTerms(tc.terms)[0], tc.opts...)
+	})
+}
+
+func TestCountInitFail(t *testing.T) {
+
+	cases := map[string]struct {
+		err       error
+		threshold int
+		term      TermT
+	}{
+		"EmptyTerm": {
+			err:       ErrTermEmpty,
+			threshold: 1,
+			term:      TermT{Type: TermRaw, Value: ""},
+		},
+		"ZeroThreshold": {
+			err:  ErrThreshold,
+			term: makeRaw("alpha"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewMatchCount(10, tc.threshold, tc.term)
+			if err != tc.err {
+				t.Fatalf("Expected err == %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
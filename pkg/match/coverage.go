@@ -0,0 +1,36 @@
+package match
+
+import "math"
+
+// NoCoverage is returned by Coverage when a matcher retains no state.
+const NoCoverage int64 = math.MinInt64
+
+// CoverageI is implemented by matchers that can report the timestamp range of
+// the state they currently retain.  The range runs from the oldest retained
+// assert (or reset) to the matcher clock.  If nothing is retained, both values
+// are NoCoverage.
+type CoverageI interface {
+	Coverage() (oldest, newest int64)
+}
+
+func calcCoverage(clock int64, terms []termT, resets []resetT) (oldest, newest int64) {
+	oldest = math.MaxInt64
+
+	for _, term := range terms {
+		if len(term.asserts) > 0 {
+			oldest = min(oldest, term.asserts[0].Timestamp)
+		}
+	}
+
+	for _, reset := range resets {
+		if len(reset.resets) > 0 {
+			oldest = min(oldest, reset.resets[0])
+		}
+	}
+
+	if oldest == math.MaxInt64 {
+		return NoCoverage, NoCoverage
+	}
+
+	return oldest, max(oldest, clock)
+}
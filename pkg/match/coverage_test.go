@@ -0,0 +1,106 @@
+package match
+
+import (
+	"testing"
+)
+
+func checkCoverage(oldest, newest int64) func(*testing.T, int, Matcher) {
+	return func(t *testing.T, step int, sm Matcher) {
+		t.Helper()
+		o, n := sm.(CoverageI).Coverage()
+		if o != oldest || n != newest {
+			t.Errorf("Step %v: Expected coverage [%v, %v], got [%v, %v]", step, oldest, newest, o, n)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+
+	cases := map[string]struct {
+		factory func(caseT) (Matcher, error)
+		tc      caseT
+	}{
+		"Seq": {
+			factory: func(tc caseT) (Matcher, error) {
+				return NewMatchSeq(tc.window, makeTerms(tc.terms)...)
+			},
+			tc: caseT{
+				window: 10,
+				terms:  []string{"alpha", "beta", "gamma"},
+				steps: []stepT{
+					{postF: checkCoverage(NoCoverage, NoCoverage)},
+					{stamp: 1, line: "alpha", postF: checkCoverage(1, 1)},
+					{stamp: 5, line: "alpha", postF: checkCoverage(1, 5)},
+					{stamp: 6, line: "beta", postF: checkCoverage(1, 6)},
+					{postF: garbageCollect(12)},
+					{postF: checkCoverage(5, 6)},
+					{postF: garbageCollect(100)},
+					{postF: checkCoverage(NoCoverage, NoCoverage)},
+				},
+			},
+		},
+		"Set": {
+			factory: func(tc caseT) (Matcher, error) {
+				return NewMatchSet(tc.window, makeTerms(tc.terms)...)
+			},
+			tc: caseT{
+				window: 10,
+				terms:  []string{"alpha", "beta"},
+				steps: []stepT{
+					{postF: checkCoverage(NoCoverage, NoCoverage)},
+					{stamp: 2, line: "beta", postF: checkCoverage(2, 2)},
+					{stamp: 4, line: "beta", postF: checkCoverage(2, 4)},
+					{stamp: 13, line: "noop", postF: checkCoverage(4, 13)},
+				},
+			},
+		},
+		"InverseSeq": {
+			// Resets are retained history as well.
+			factory: func(tc caseT) (Matcher, error) {
+				return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+			},
+			tc: caseT{
+				window: 10,
+				terms:  []string{"alpha", "beta"},
+				reset:  []ResetT{{Term: makeRaw("boom"), Window: 20}},
+				steps: []stepT{
+					{postF: checkCoverage(NoCoverage, NoCoverage)},
+					{stamp: 1, line: "alpha", postF: checkCoverage(1, 1)},
+					{stamp: 3, line: "boom", postF: checkCoverage(1, 3)},
+					{stamp: 4, line: "beta", postF: checkCoverage(3, 4)}, // Reset drops alpha; reset retained.
+				},
+			},
+		},
+		"InverseSet": {
+			factory: func(tc caseT) (Matcher, error) {
+				return NewInverseSet(tc.window, makeTerms(tc.terms), tc.reset)
+			},
+			tc: caseT{
+				window: 10,
+				terms:  []string{"alpha", "beta"},
+				steps: []stepT{
+					{stamp: 3, line: "alpha", postF: checkCoverage(3, 3)},
+					{stamp: 7, line: "noop", postF: checkCoverage(3, 7)},
+				},
+			},
+		},
+		"FallingEdge": {
+			factory: func(tc caseT) (Matcher, error) {
+				return NewMatchFallingEdge(makeTerms(tc.terms)[0], tc.window)
+			},
+			tc: caseT{
+				window: 5,
+				terms:  []string{"alpha"},
+				steps: []stepT{
+					{postF: checkCoverage(NoCoverage, NoCoverage)},
+					{stamp: 3, line: "alpha", postF: checkCoverage(3, 3)},
+					{stamp: 9, line: "noop", cb: matchStamps(3), postF: checkCoverage(NoCoverage, NoCoverage)},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		casesT{name: c.tc}.run(t, c.factory)
+	}
+}
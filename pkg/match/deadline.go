@@ -0,0 +1,40 @@
+package match
+
+// Implemented by time driven matchers that can tell when Eval may next emit
+// hits, so that KeyedMatcher need only evaluate partitions that are due.
+type deadlineI interface {
+	// Earliest clock at which Eval may emit hits; false if the matcher must
+	// first scan a line.
+	deadline() (int64, bool)
+}
+
+// Clock at which a complete frame of an inverse matcher, waiting at clock on
+// the reset windows, may resolve; see evalResets.
+func resolveAt(clock int64, wait anchorT) (int64, bool) {
+	if !wait.ValidTerm() && wait.clock > 0 {
+		return clock + wait.clock, true
+	}
+	return clock + 1, true
+}
+
+func (r *InverseSeq) deadline() (int64, bool) {
+	if r.nActive < len(r.terms) {
+		return 0, false
+	}
+	return resolveAt(r.clock, r.checkReset(r.clock))
+}
+
+func (r *InverseSet) deadline() (int64, bool) {
+	if !r.hotMask.FirstN(len(r.terms)) {
+		return 0, false
+	}
+	return resolveAt(r.clock, r.checkReset(r.clock))
+}
+
+func (r *MatchAbsence) deadline() (int64, bool) {
+	return r.anchor.Timestamp + r.window + 1, r.armed
+}
+
+func (r *MatchFallingEdge) deadline() (int64, bool) {
+	return r.last.Timestamp + r.gap + 1, r.active
+}
@@ -0,0 +1,80 @@
+package match
+
+import "math"
+
+// DualClock tracks both the event time of entries, Timestamp, and their ingest
+// time, IngestTime, for the wrapped matcher.  An entry without an ingest time
+// is taken to be ingested as stamped.
+//
+// Eval and GarbageCollect take an ingest clock, such as that of a wall clock
+// ticker, and are forwarded at the event time watermark: the greatest event
+// time scanned, extrapolated by ingest time passed since the last entry was
+// ingested.  So a burst of backfilled entries, stamped far behind the ingest
+// clock, does not collect partial matches as though their windows had closed.
+//
+// Only a quiet stream extrapolates.  Entries that are ingested without moving
+// the event time forward, as during a backfill, hold the watermark in place
+// rather than let it race ahead with the ingest clock.  The clock forwarded
+// never runs backward; a clock of math.MaxInt64 is forwarded as is.
+type DualClock struct {
+	m         Matcher
+	watermark int64 // Greatest event time scanned.
+	ingest    int64 // Greatest ingest time scanned.
+	clock     int64 // Event clock last forwarded.
+}
+
+func NewDualClock(m Matcher) *DualClock {
+	return &DualClock{
+		m:         m,
+		watermark: math.MinInt64,
+		ingest:    math.MinInt64,
+		clock:     math.MinInt64,
+	}
+}
+
+func (d *DualClock) Scan(e *ScanLine) Hits {
+	ingest := e.IngestTime
+	if ingest == 0 {
+		ingest = e.Timestamp
+	}
+
+	d.watermark = max(d.watermark, e.Timestamp)
+	d.ingest = max(d.ingest, ingest)
+	return d.m.Scan(e)
+}
+
+func (d *DualClock) Eval(clock int64) Hits {
+	return d.m.Eval(d.eventClock(clock))
+}
+
+func (d *DualClock) GarbageCollect(clock int64) {
+	d.m.GarbageCollect(d.eventClock(clock))
+}
+
+// Clocks returns the event time watermark and the greatest ingest time
+// scanned; both are math.MinInt64 until an entry is scanned.
+func (d *DualClock) Clocks() (event, ingest int64) {
+	return d.watermark, d.ingest
+}
+
+// Translate an ingest clock to event time.
+func (d *DualClock) eventClock(clock int64) int64 {
+	if d.ingest == math.MinInt64 {
+		// Nothing scanned; there is no event time to go by.
+		d.clock = max(d.clock, clock)
+		return d.clock
+	}
+
+	event := d.watermark
+	switch idle := clock - d.ingest; {
+	case idle <= 0:
+	case clock == math.MaxInt64, idle > math.MaxInt64-event:
+		// A drain, as at the end of a stream, passes through.
+		event = math.MaxInt64
+	default:
+		event += idle
+	}
+
+	d.clock = max(d.clock, event)
+	return d.clock
+}
@@ -0,0 +1,97 @@
+package match
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// Records the clocks forwarded by Eval and GarbageCollect.
+type clockRecT struct {
+	Matcher
+	clocks []int64
+}
+
+func (c *clockRecT) Eval(clock int64) Hits {
+	c.clocks = append(c.clocks, clock)
+	return c.Matcher.Eval(clock)
+}
+
+func (c *clockRecT) GarbageCollect(clock int64) {
+	c.clocks = append(c.clocks, clock)
+	c.Matcher.GarbageCollect(clock)
+}
+
+func dualEntry(stamp, ingest int64, line string) LogEntry {
+	return LogEntry{Timestamp: stamp, IngestTime: ingest, Line: line}
+}
+
+func TestDualClockBackfill(t *testing.T) {
+	defer disableLogs()()
+
+	var (
+		sl   = NewScanLine()
+		plan = []LogEntry{dualEntry(100, 100, "start")}
+	)
+
+	// A backfill burst; ingested over 50 ticks, stamped in the past.
+	for i := range int64(10) {
+		plan = append(plan, dualEntry(40+i, 101+5*i, "noise"))
+	}
+
+	for _, dual := range []bool{false, true} {
+		seq, err := NewMatchSeq(10, makeTermsA("start", "fail")...)
+		if err != nil {
+			t.Fatalf("Expected nil error, got: %v", err)
+		}
+
+		var m Matcher = seq
+		if dual {
+			m = NewDualClock(seq)
+		}
+
+		for _, e := range plan {
+			m.Scan(sl.Reset(e))
+		}
+		m.GarbageCollect(150)
+
+		hits := m.Scan(sl.Reset(dualEntry(105, 151, "fail")))
+		if want := map[bool]int{false: 0, true: 1}[dual]; hits.Cnt != want {
+			t.Errorf("Expected %d hits with dual %v, got %d", want, dual, hits.Cnt)
+		}
+	}
+}
+
+func TestDualClockForward(t *testing.T) {
+	defer disableLogs()()
+
+	seq, err := NewMatchSeq(10, makeTermsA("start", "fail")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		rec = &clockRecT{Matcher: seq}
+		d   = NewDualClock(rec)
+		sl  = NewScanLine()
+	)
+
+	d.Eval(5) // Nothing scanned; forwarded as is.
+	d.Scan(sl.Reset(dualEntry(100, 120, "start")))
+	d.Scan(sl.Reset(dualEntry(90, 130, "noise")))
+	d.Scan(sl.ResetLine(101, "noise")) // No ingest time; ingested as stamped.
+
+	if ev, in := d.Clocks(); ev != 101 || in != 130 {
+		t.Errorf("Expected clocks 101, 130; got %d, %d", ev, in)
+	}
+
+	d.Eval(130)           // Not idle; the watermark.
+	d.GarbageCollect(140) // Idle for 10.
+	d.Eval(135)           // Never backward.
+	d.Eval(math.MaxInt64)
+
+	want := []int64{5, 101, 111, 111, math.MaxInt64}
+	if !slices.Equal(rec.clocks, want) {
+		t.Errorf("Expected clocks %v, got %v", want, rec.clocks)
+	}
+}
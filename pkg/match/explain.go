@@ -0,0 +1,99 @@
+package match
+
+import (
+	"fmt"
+)
+
+// TraceKindT is the kind of decision recorded by a matcher in explain mode.
+type TraceKindT int
+
+const (
+	TraceFire   TraceKindT = iota // Hit emitted.
+	TraceWindow                   // Frame matched, but spans more than the window; the term assert is dropped.
+	TraceReset                    // Frame suppressed by a reset line; the anchor assert is dropped.
+	TraceWait                     // Frame pending until the reset window has passed.
+	TraceExpire                   // Term asserts aged out of the window by garbage collection.
+	TraceOrder                    // Frame matched, but an ordered term precedes its predecessor; the term assert is dropped.
+)
+
+// TraceT is a single matcher decision.  Fields that do not apply to the
+// kind are -1 (Term, Reset) or zero.
+type TraceT struct {
+	Kind  TraceKindT
+	Clock int64 // Clock at which the decision was made.
+	Term  int   // Term whose assert was dropped or expired.
+	Reset int   // Reset term that suppressed the frame.
+	Stamp int64 // Timestamp of the dropped assert, or of the reset line.
+	Until int64 // Clock after which a pending frame is evaluated.
+	Count int   // Number of asserts expired.
+}
+
+func (t TraceT) String() string {
+	switch t.Kind {
+	case TraceFire:
+		return fmt.Sprintf("%d: fired", t.Clock)
+	case TraceWindow:
+		return fmt.Sprintf("%d: term %d at %d outside window", t.Clock, t.Term, t.Stamp)
+	case TraceReset:
+		return fmt.Sprintf("%d: term %d suppressed by reset %d at %d", t.Clock, t.Term, t.Reset, t.Stamp)
+	case TraceWait:
+		return fmt.Sprintf("%d: waiting on reset window until %d", t.Clock, t.Until)
+	case TraceExpire:
+		return fmt.Sprintf("%d: %d asserts on term %d expired, oldest at %d", t.Clock, t.Count, t.Term, t.Stamp)
+	case TraceOrder:
+		return fmt.Sprintf("%d: term %d at %d out of order", t.Clock, t.Term, t.Stamp)
+	}
+	return fmt.Sprintf("%d: unknown trace %d", t.Clock, t.Kind)
+}
+
+// ExplainI is implemented by matchers that record decision traces.
+type ExplainI interface {
+	Explain() []TraceT
+}
+
+// Embedded by matchers to keep a ring of the most recent traces.
+// Disabled, and free, unless a limit is set by WithExplain.
+type explainT struct {
+	traces []TraceT
+	limit  int
+	next   int
+}
+
+func (x *explainT) trace(t TraceT) {
+	switch {
+	case x.limit == 0:
+		return
+	case len(x.traces) < x.limit:
+		x.traces = append(x.traces, t)
+	default:
+		x.traces[x.next] = t
+		x.next = (x.next + 1) % x.limit
+	}
+}
+
+// Trace a pending frame; a wait is re-evaluated on every line, so repeats are elided.
+func (x *explainT) traceWait(clock, until int64) {
+	if n := len(x.traces); n > 0 {
+		last := x.traces[(x.next+n-1)%n]
+		if last.Kind == TraceWait && last.Until == until {
+			return
+		}
+	}
+	x.trace(TraceT{Kind: TraceWait, Clock: clock, Term: -1, Reset: -1, Until: until})
+}
+
+// Explain returns the recorded traces, oldest first.
+func (x *explainT) Explain() []TraceT {
+	out := make([]TraceT, 0, len(x.traces))
+	out = append(out, x.traces[x.next:]...)
+	return append(out, x.traces[:x.next]...)
+}
+
+// WithExplain records the last n decisions of InverseSeq, InverseSet and
+// MatchSeq, retrievable via Explain.  Disabled by default.
+func WithExplain(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optExplain
+		o.explain = max(n, 0)
+	}
+}
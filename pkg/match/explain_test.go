@@ -0,0 +1,87 @@
+package match
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExplainInverseSeq(t *testing.T) {
+	iseq, err := NewInverseSeq(
+		5,
+		makeTermsA("alpha", "beta"),
+		[]ResetT{{Term: makeRaw("reset"), Window: 10, Absolute: true}},
+		WithExplain(10),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for _, step := range []struct {
+		stamp int64
+		line  string
+	}{
+		{1, "alpha"},
+		{2, "beta"},
+		{3, "reset"},
+		{4, "alpha"},
+		{12, "beta"},
+		{20, "alpha"},
+		{21, "beta"},
+	} {
+		iseq.Scan(sl.ResetLine(step.stamp, step.line))
+	}
+	iseq.Eval(40)
+
+	exp := []TraceT{
+		{Kind: TraceWait, Clock: 2, Term: -1, Reset: -1, Until: 12},
+		{Kind: TraceReset, Clock: 3, Term: 0, Reset: 0, Stamp: 3},
+		{Kind: TraceWindow, Clock: 12, Term: 0, Reset: -1, Stamp: 4},
+		{Kind: TraceWait, Clock: 21, Term: -1, Reset: -1, Until: 31},
+		{Kind: TraceFire, Clock: 40, Term: -1, Reset: -1},
+	}
+
+	if got := iseq.Explain(); !slices.Equal(got, exp) {
+		t.Errorf("Expected traces:\n%v\ngot:\n%v", exp, got)
+	}
+}
+
+func TestExplainRing(t *testing.T) {
+	sm, err := NewMatchSeqOpts(1, makeTermsA("alpha", "beta"), WithExplain(2))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i := range 4 {
+		sm.Scan(sl.ResetLine(int64(i*10+1), "alpha"))
+		sm.Scan(sl.ResetLine(int64(i*10+2), "beta"))
+	}
+
+	got := sm.Explain()
+	if len(got) != 2 || got[0].Clock != 32-10 || got[1].Clock != 32 || got[1].Kind != TraceFire {
+		t.Errorf("Expected last two fires, got %v", got)
+	}
+}
+
+func TestExplainDisabled(t *testing.T) {
+	iset, err := NewInverseSet(5, makeTermsA("alpha", "beta"), nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	iset.Scan(sl.ResetLine(1, "alpha"))
+	iset.Scan(sl.ResetLine(2, "beta"))
+
+	if got := iset.Explain(); len(got) != 0 {
+		t.Errorf("Expected no traces, got %v", got)
+	}
+}
+
+func TestTraceString(t *testing.T) {
+	tr := TraceT{Kind: TraceReset, Clock: 12, Term: 0, Reset: 1, Stamp: 3}
+	if got, exp := tr.String(), "12: term 0 suppressed by reset 1 at 3"; got != exp {
+		t.Errorf("Expected %q, got %q", exp, got)
+	}
+}
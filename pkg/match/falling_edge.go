@@ -0,0 +1,104 @@
+package match
+
+// MatchFallingEdge fires on the transition of a term from active to inactive.
+//
+// The term is considered active once it has matched at least one line; there is
+// no requirement for a minimum burst.  When the clock advances more than gap past
+// the most recent match, a single hit is emitted carrying that last matching line.
+// The matcher then re-arms and will not fire again until the term matches anew.
+//
+// The transition is time driven; it is detected either on Eval, or on Scan of any
+// line whose timestamp has advanced the clock past the gap.
+
+type MatchFallingEdge struct {
+	matcher MatchFunc
+	gap     int64
+	clock   int64
+	active  bool
+	last    LogEntry
+
+	statsT
+	lateT
+}
+
+func NewMatchFallingEdge(term TermT, gap int64, opts ...OptT) (*MatchFallingEdge, error) {
+	o := parseOpts(opts)
+	if err := o.check(optsStats); err != nil {
+		return nil, err
+	}
+
+	m, err := term.NewMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MatchFallingEdge{matcher: m, gap: gap, statsT: newStats(o)}, nil
+}
+
+func (r *MatchFallingEdge) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
+
+	if e.Timestamp < r.clock {
+		if e = r.late(&r.identT, "MatchFallingEdge", e, r.clock); e == nil {
+			return
+		}
+	}
+
+	// The gap may have elapsed before this line arrived; fire on the old edge first.
+	hits = r._eval(e.Timestamp)
+
+	if r.matcher(e) {
+		r.nMatched += 1
+		r.active = true
+		r.last = e.Entry()
+	}
+
+	return
+}
+
+func (r *MatchFallingEdge) Eval(clock int64) (hits Hits) {
+	if clock <= r.clock {
+		return
+	}
+	return r._eval(clock)
+}
+
+func (r *MatchFallingEdge) _eval(clock int64) (hits Hits) {
+	r.clock = clock
+
+	if !r.active || clock-r.last.Timestamp <= r.gap {
+		return
+	}
+
+	r.nHits += 1
+	hits.Cnt = 1
+	hits.FireStamp = clock
+	hits.Logs = []LogEntry{r.last}
+
+	r.active = false
+	r.last = LogEntry{}
+	r.tag(&hits)
+	return
+}
+
+// Coverage returns the range from the last match to the clock while active.
+func (r *MatchFallingEdge) Coverage() (oldest, newest int64) {
+	if !r.active {
+		return NoCoverage, NoCoverage
+	}
+	return r.last.Timestamp, r.clock
+}
+
+// State is a single entry; nothing to collect.
+func (r *MatchFallingEdge) GarbageCollect(clock int64) {
+	r.gcClock = clock
+}
+
+// Stats returns the matcher counters.
+func (r *MatchFallingEdge) Stats() StatsT {
+	s := r.stats(nil, &r.lateT)
+	if r.active {
+		s.Buffered = 1
+	}
+	return s
+}
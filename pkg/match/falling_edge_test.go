@@ -0,0 +1,79 @@
+package match
+
+import (
+	"testing"
+)
+
+func NewCasesFallingEdge() casesT {
+
+	return casesT{
+		"BurstThenSilence": {
+			// AAA------------ (gap 5; fire once at the transition)
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "noop"},
+				{postF: checkEval(8, checkNoFire)},
+				{postF: checkEval(9, checkFireStamp(9, matchStamps(3)))},
+				{postF: checkEval(20, checkNoFire)},
+			},
+		},
+		"Rearm": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{postF: checkEval(10, matchStamps(1))},
+				{stamp: 11, line: "noop"},
+				{stamp: 12, line: "alpha"},
+				{postF: checkEval(17, checkNoFire)},
+				{postF: checkEval(18, matchStamps(12))},
+			},
+		},
+		"FireOnScan": {
+			// Edge is detected on the next scanned line, not just Eval.
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "alpha"},
+				{stamp: 10, line: "alpha", cb: checkFireStamp(10, matchStamps(1))},
+				{postF: checkEval(16, matchStamps(10))},
+			},
+		},
+		"NeverActive": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{line: "noop"},
+				{postF: checkEval(100, checkNoFire)},
+			},
+		},
+		"OutOfOrder": {
+			window: 5,
+			terms:  []string{"alpha"},
+			steps: []stepT{
+				{stamp: 10, line: "alpha"},
+				{stamp: 5, line: "alpha"},
+				{postF: checkEval(16, matchStamps(10))},
+			},
+		},
+	}
+}
+
+func TestFallingEdge(t *testing.T) {
+	defer disableLogs()()
+
+	NewCasesFallingEdge().run(t, func(tc caseT) (Matcher, error) {
+		return NewMatchFallingEdge(makeTerms(tc.terms)[0], tc.window)
+	})
+}
+
+func TestFallingEdgeInitFail(t *testing.T) {
+	_, err := NewMatchFallingEdge(TermT{Type: TermRaw}, 10)
+	if err != ErrTermEmpty {
+		t.Fatalf("Expected err == %v, got %v", ErrTermEmpty, err)
+	}
+}
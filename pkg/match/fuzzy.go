@@ -0,0 +1,75 @@
+package match
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Fuzzy matching is O(len(needle) * len(line)); cap both axes of cost.
+const (
+	maxFuzzyNeedle   = 64
+	maxFuzzyDistance = 4
+)
+
+var (
+	ErrFuzzyNeedle   = errors.New("fuzzy term too long")
+	ErrFuzzyDistance = errors.New("fuzzy distance out of range")
+)
+
+// Match if the line contains a substring within dist edits (insert, delete or
+// substitute) of the needle.  This is Sellers' variant of Levenshtein, where a
+// match may begin at any position in the line.
+func makeFuzzyMatch(needle string, dist int) (MatchFunc, error) {
+
+	var (
+		pat = []rune(needle)
+		m   = len(pat)
+	)
+
+	switch {
+	case m > maxFuzzyNeedle:
+		return nil, ErrFuzzyNeedle
+	case dist < 0, dist > maxFuzzyDistance:
+		return nil, ErrFuzzyDistance
+	case dist == 0:
+		return makeRawMatch(needle), nil
+	case dist >= m:
+		// Every line is within m edits of the needle.
+		return func(*ScanLine) bool { return true }, nil
+	}
+
+	return func(e *ScanLine) bool {
+		if utf8.RuneCountInString(e.Line) < m-dist {
+			return false
+		}
+
+		if strings.Contains(e.Line, needle) {
+			return true
+		}
+
+		// Single column of the DP table; col[i] is the best distance of pat[:i]
+		// against a substring of the line ending at the current position.
+		var col [maxFuzzyNeedle + 1]int
+		for i := range m + 1 {
+			col[i] = i
+		}
+
+		for _, c := range e.Line {
+			diag := col[0] // col[0] stays zero; a match may start anywhere.
+			for i := 1; i <= m; i++ {
+				cost := 1
+				if pat[i-1] == c {
+					cost = 0
+				}
+				next := min(col[i]+1, col[i-1]+1, diag+cost)
+				diag, col[i] = col[i], next
+			}
+			if col[m] <= dist {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
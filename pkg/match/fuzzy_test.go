@@ -0,0 +1,102 @@
+package match
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+
+	const needle = "connection refused"
+
+	cases := map[string]struct {
+		line  string
+		dist  int
+		match bool
+	}{
+		"ExactDist0":        {line: "dial tcp: connection refused", dist: 0, match: true},
+		"TrailingDist0":     {line: "dial tcp: connection refused!", dist: 0, match: true},
+		"SubstituteDist0":   {line: "dial tcp: connection refuzed", dist: 0, match: false},
+		"SubstituteDist1":   {line: "dial tcp: connection refuzed", dist: 1, match: true},
+		"DeleteDist1":       {line: "dial tcp: conection refused", dist: 1, match: true},
+		"InsertDist1":       {line: "dial tcp: connecttion refused", dist: 1, match: true},
+		"PunctuationDist1":  {line: "dial tcp: connection-refused", dist: 1, match: true},
+		"TwoOffDist1":       {line: "dial tcp: conection refuzed", dist: 1, match: false},
+		"TwoOffDist2":       {line: "dial tcp: conection refuzed", dist: 2, match: true},
+		"Unrelated":         {line: "all systems nominal", dist: 2, match: false},
+		"ShortLine":         {line: "conn", dist: 2, match: false},
+		"MultiByteNeighbor": {line: "dial tcp: cönnection refused", dist: 1, match: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m, err := TermT{Type: TermFuzzy, Value: needle, Distance: tc.dist}.NewMatcher()
+			if err != nil {
+				t.Fatalf("Expected nil error, got: %v", err)
+			}
+			if got := m(NewScanLine().ResetLine(1, tc.line)); got != tc.match {
+				t.Errorf("Expected match == %v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestFuzzyCaps(t *testing.T) {
+
+	cases := map[string]struct {
+		term TermT
+		err  error
+	}{
+		"NeedleTooLong": {
+			term: TermT{Type: TermFuzzy, Value: strings.Repeat("a", maxFuzzyNeedle+1), Distance: 1},
+			err:  ErrFuzzyNeedle,
+		},
+		"DistanceTooLarge": {
+			term: TermT{Type: TermFuzzy, Value: "alpha", Distance: maxFuzzyDistance + 1},
+			err:  ErrFuzzyDistance,
+		},
+		"DistanceNegative": {
+			term: TermT{Type: TermFuzzy, Value: "alpha", Distance: -1},
+			err:  ErrFuzzyDistance,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.term.NewMatcher()
+			if !errors.Is(err, ErrTermCompile) || !errors.Is(err, tc.err) {
+				t.Errorf("Expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFuzzySeq(t *testing.T) {
+	sm, err := NewMatchSeq(10,
+		TermT{Type: TermFuzzy, Value: "disk full", Distance: 1},
+		TermT{Type: TermRaw, Value: "shutdown"},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sm.Scan(NewScanLine().ResetLine(1, "warn: disk ful on /var"))
+	if hits := sm.Scan(NewScanLine().ResetLine(2, "shutdown")); hits.Cnt != 1 {
+		t.Errorf("Expected 1 hit, got %d", hits.Cnt)
+	}
+}
+
+func BenchmarkFuzzyMiss(b *testing.B) {
+	m, err := TermT{Type: TermFuzzy, Value: "connection refused", Distance: 2}.NewMatcher()
+	if err != nil {
+		b.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine().ResetLine(1, "2024-01-01T00:00:00Z INFO request served in 12ms path=/api/v1/users status=200")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m(sl)
+	}
+}
@@ -0,0 +1,49 @@
+package match
+
+import (
+	"errors"
+)
+
+var ErrGapRange = errors.New("gap out of range")
+
+// Validate per step gaps against the number of sequence terms.
+func validateGaps(gaps []int64, nTerms int) error {
+	if len(gaps) > nTerms-1 {
+		return ErrGapRange
+	}
+	for _, g := range gaps {
+		if g < 0 {
+			return ErrGapRange
+		}
+	}
+	return nil
+}
+
+// True if delta satisfies maximum gap i; a zero or missing gap is unbounded.
+func gapOK(gaps []int64, i int, delta int64) bool {
+	if i >= len(gaps) || gaps[i] == 0 {
+		return true
+	}
+	return delta <= gaps[i]
+}
+
+// True if delta satisfies minimum gap i; a missing gap is zero.
+func minGapOK(gaps []int64, i int, delta int64) bool {
+	return i >= len(gaps) || delta >= gaps[i]
+}
+
+// Raise every minimum gap to at least one so that timestamps strictly increase.
+func strictGaps(gaps []int64, nTerms int) []int64 {
+	if nTerms < 2 {
+		return gaps
+	}
+
+	strict := make([]int64, nTerms-1)
+	for i := range strict {
+		strict[i] = 1
+		if i < len(gaps) {
+			strict[i] = max(gaps[i], 1)
+		}
+	}
+	return strict
+}
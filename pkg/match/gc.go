@@ -0,0 +1,145 @@
+package match
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// GCPolicyT determines when a matcher runs the garbage collection it may
+// defer.  Collection that a match depends on, such as dropping asserts that
+// have left the window before a sequence advances, always runs with Scan;
+// the policy governs the rest:
+//
+//   - MatchSeq, MatchSet, MatchCount and MatchRate collect on Eval.
+//   - KeyedMatcher evaluates its time driven partitions that are due, and
+//     expires idle ones, on every Scan.
+//   - MultiMatcher evaluates its time driven matchers that were not
+//     dispatched the line on every Scan.
+//
+// An explicit GarbageCollect always collects, as does Eval on KeyedMatcher
+// and MultiMatcher.  Deferred hits from time driven matchers fire late by
+// up to the deferral, but are not lost.
+type GCPolicyT int
+
+const (
+	GCInline   GCPolicyT = iota // Collect whenever the clock advances; the default.
+	GCInterval                  // At most once per WithGCInterval of clock.
+	GCSize                      // Once more than WithGCSize entries, or KeyedMatcher partitions, are held.
+	GCManual                    // Only when asked; see GCGroup.
+)
+
+// Deferred collection schedule, embedded by matchers through statsT.
+type gcT struct {
+	gcPolicy GCPolicyT
+	gcEvery  int64
+	gcSize   int
+	gcClock  int64
+}
+
+func newGC(o optsT) gcT {
+	return gcT{gcPolicy: o.gcPolicy, gcEvery: o.gcEvery, gcSize: o.gcSize}
+}
+
+// Whether a deferred collection is due at clock, with held entries.
+func (g *gcT) gcDue(clock int64, held int) bool {
+	switch g.gcPolicy {
+	case GCInterval:
+		return clock-g.gcClock >= g.gcEvery
+	case GCSize:
+		return held > g.gcSize
+	case GCManual:
+		return false
+	}
+	return true
+}
+
+// Number of asserts held by terms.
+func heldAsserts(terms []termT) (n int) {
+	for _, term := range terms {
+		n += len(term.asserts)
+	}
+	return
+}
+
+// Implemented by groups that defer evaluating their members; sweep runs the
+// deferred evaluation and collection at the group clock.
+type sweeperI interface {
+	sweep() Hits
+}
+
+// GCGroup runs deferred collection of a group of matchers off the hot path.
+//
+// Every call into the group is serialized by a mutex, so Run may collect from
+// a background goroutine while another scans.  Give the members GCManual, or
+// a lax policy, so that Scan does not pay for collection inline.  Hits are
+// passed to the callback with the index of the member that emitted them,
+// under the mutex; it may be called from the Run goroutine, and must not call
+// back into the group.
+type GCGroup struct {
+	mux     sync.Mutex
+	members []Matcher
+	cb      MultiHitFuncT
+	clock   int64
+}
+
+func NewGCGroup(cb MultiHitFuncT, members ...Matcher) *GCGroup {
+	return &GCGroup{members: members, cb: cb}
+}
+
+// Scan passes the line to every member.
+func (g *GCGroup) Scan(e *ScanLine) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	g.clock = max(g.clock, e.Timestamp)
+	for i, m := range g.members {
+		if hits := m.Scan(e); hits.Cnt > 0 {
+			g.cb(i, hits)
+		}
+	}
+}
+
+// Eval evaluates every member at clock.
+func (g *GCGroup) Eval(clock int64) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	g.clock = max(g.clock, clock)
+	for i, m := range g.members {
+		if hits := m.Eval(clock); hits.Cnt > 0 {
+			g.cb(i, hits)
+		}
+	}
+}
+
+// Collect runs the deferred work of every member at the latest clock seen:
+// groups evaluate their time driven members, then every member collects.
+func (g *GCGroup) Collect() {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	for i, m := range g.members {
+		if s, ok := m.(sweeperI); ok {
+			if hits := s.sweep(); hits.Cnt > 0 {
+				g.cb(i, hits)
+			}
+		}
+		m.GarbageCollect(g.clock)
+	}
+}
+
+// Run collects every period until ctx is done.
+func (g *GCGroup) Run(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Collect()
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package match
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func gcKeyed(t *testing.T, opts ...OptT) *KeyedMatcher {
+	keyFn, err := KeyRegex(`pod=(\w+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, func() Matcher {
+		m, err := NewInverseSeq(10, makeTermsA("start", "fail"), []ResetT{{Term: makeRaw("ok"), Window: 5}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return km
+}
+
+var gcLines = []LogEntry{
+	{Timestamp: 1, Line: "pod=a start"},
+	{Timestamp: 2, Line: "pod=a fail"},
+	{Timestamp: 3, Line: "pod=b start"},
+	{Timestamp: 20, Line: "pod=b noise"},
+}
+
+func TestGCPolicyKeyed(t *testing.T) {
+	tests := map[string]struct {
+		opts []OptT
+		want []string // Hits from the scan, then from the sweep.
+	}{
+		"inline":   {want: []string{"a:1,2"}},
+		"interval": {opts: []OptT{WithGCInterval(10)}, want: []string{"a:1,2"}},
+		"lax":      {opts: []OptT{WithGCInterval(100)}, want: []string{"|", "a:1,2"}},
+		"size":     {opts: []OptT{WithGCSize(2)}, want: []string{"|", "a:1,2"}},
+		"small":    {opts: []OptT{WithGCSize(1)}, want: []string{"a:1,2"}},
+		"manual":   {opts: []OptT{WithGCPolicy(GCManual)}, want: []string{"|", "a:1,2"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				got  []string
+				km   = gcKeyed(t, tc.opts...)
+				cb   = renderHits(&got)
+				line = NewScanLine()
+			)
+
+			for _, e := range gcLines {
+				cb(km.Scan(line.Reset(e)))
+			}
+			if len(got) == 0 {
+				got = append(got, "|")
+			}
+			cb(km.sweep())
+
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGCPolicyDue(t *testing.T) {
+	g := newGC(parseOpts([]OptT{WithGCSize(4)}))
+	if g.gcDue(100, 4) || !g.gcDue(0, 5) {
+		t.Errorf("Expected size policy due past 4 entries")
+	}
+
+	g = newGC(parseOpts([]OptT{WithGCPolicy(GCManual), WithGCInterval(5)}))
+	if g.gcPolicy != GCManual || g.gcDue(100, 100) {
+		t.Errorf("Expected an explicit policy kept by WithGCInterval")
+	}
+
+	g = newGC(parseOpts([]OptT{WithGCInterval(5)}))
+	g.gcClock = 10
+	if g.gcDue(14, 0) || !g.gcDue(15, 0) {
+		t.Errorf("Expected interval policy due every 5")
+	}
+}
+
+func TestGCPolicyMulti(t *testing.T) {
+	var (
+		got  []int
+		line = NewScanLine()
+		cb   = func(idx int, hits Hits) { got = append(got, hits.Cnt) }
+	)
+
+	inv, err := NewInverseSeq(10, makeTermsA("start", "fail"), []ResetT{{Term: makeRaw("ok"), Window: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mm, _ := NewMultiMatcher(WithGCPolicy(GCManual))
+	mm.Add(inv, makeTermsA("start", "fail", "ok")...)
+	mm.Add(mustSingle(t, "noise"), makeRaw("noise"))
+
+	for _, e := range gcLines[:3] {
+		mm.Scan(line.Reset(e), cb)
+	}
+	mm.Scan(line.Reset(LogEntry{Timestamp: 20, Line: "other"}), cb)
+	if len(got) != 0 {
+		t.Fatalf("Expected evaluation deferred, got %v", got)
+	}
+
+	mm.Eval(20, cb)
+	if !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected the held hit on Eval, got %v", got)
+	}
+}
+
+func mustSingle(t *testing.T, value string) Matcher {
+	m, err := NewMatchSingle(makeRaw(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestGCGroupRun(t *testing.T) {
+	var (
+		hits = make(chan int, 4)
+		line = NewScanLine()
+	)
+
+	g := NewGCGroup(func(idx int, h Hits) { hits <- idx }, gcKeyed(t, WithGCPolicy(GCManual)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		g.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	for _, e := range gcLines {
+		g.Scan(line.Reset(e))
+	}
+
+	select {
+	case idx := <-hits:
+		if idx != 0 {
+			t.Errorf("Expected hit from member 0, got %d", idx)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the background collection to emit the held hit")
+	}
+
+	cancel()
+	<-done
+
+	g.Collect()
+	if len(hits) != 0 {
+		t.Errorf("Expected no further hits, got %d", len(hits))
+	}
+}
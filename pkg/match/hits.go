@@ -1,5 +1,11 @@
 package match
 
+import (
+	"iter"
+	"maps"
+	"slices"
+)
+
 type PropKey struct {
 	Idx int
 	Key string
@@ -9,50 +15,294 @@ type Hits struct {
 	Cnt   int
 	Logs  []LogEntry
 	Props map[PropKey]any
+
+	// FireStamp is the matcher clock when the most recent hit fired.  For edge
+	// triggered matchers this is the timestamp of the triggering event; for
+	// matchers that delay on a reset window it is the clock passed to Eval.
+	FireStamp int64
+
+	// Groups holds the number of logs in each hit where hits differ in size,
+	// such as after appending hits from matchers of different arity.  If nil,
+	// the logs are split evenly across Cnt hits.
+	Groups []int
+}
+
+// Hit is a single match, as yielded by Hits.Iter.
+type Hit struct {
+	Logs    []LogEntry
+	Props   map[string]any
+	Window  [2]int64 // Effective match window; see HitMetaT.
+	Rule    string   // Rule name, if any; see HitMetaT.
+	Delayed bool     // Held back on a reset window; see HitMetaT.
+}
+
+// Iter returns an iterator over the hits in h.
+func (h Hits) Iter() iter.Seq[Hit] {
+	return func(yield func(Hit) bool) {
+		var off int
+		for i := range h.Cnt {
+			var (
+				sz      = h.groupSize(i)
+				meta, _ = MetaOf(h, i)
+			)
+			hit := Hit{
+				Logs:    h.Logs[off : off+sz],
+				Props:   h.IndexProps(i),
+				Window:  [2]int64{meta.Start, meta.End},
+				Rule:    meta.Rule,
+				Delayed: meta.Delayed,
+			}
+			if !yield(hit) {
+				return
+			}
+			off += sz
+		}
+	}
 }
 
+// Size of group i; assumes i is in range.
+func (h Hits) groupSize(i int) int {
+	if h.Groups != nil {
+		return h.Groups[i]
+	}
+	return len(h.Logs) / h.Cnt
+}
+
+// Explicit size of each group.
+func (h Hits) groupSizes() []int {
+	if h.Groups != nil {
+		return h.Groups
+	}
+	sizes := make([]int, h.Cnt)
+	for i := range sizes {
+		sizes[i] = len(h.Logs) / h.Cnt
+	}
+	return sizes
+}
+
+// Append adds the hits in o to h.  Property indices in o are shifted
+// so that they continue to refer to the same hit after the merge.
+func (h *Hits) Append(o Hits) {
+	if o.Cnt <= 0 {
+		return
+	}
+
+	if o.Props != nil {
+		if h.Props == nil {
+			h.Props = make(map[PropKey]any, len(o.Props))
+		}
+		for k, v := range o.Props {
+			h.Props[PropKey{Idx: k.Idx + h.Cnt, Key: k.Key}] = v
+		}
+	}
+
+	switch {
+	case h.Cnt <= 0:
+		h.Groups = slices.Clone(o.Groups)
+	case h.Groups != nil || o.Groups != nil || h.groupSize(0) != o.groupSize(0):
+		h.Groups = append(slices.Clone(h.groupSizes()), o.groupSizes()...)
+	}
+
+	h.Cnt += o.Cnt
+	h.Logs = append(h.Logs, o.Logs...)
+	h.FireStamp = max(h.FireStamp, o.FireStamp)
+}
+
+// PopFront removes and returns the logs of the first hit.  Props are not
+// reindexed.
+//
+// Deprecated: Use Iter.
 func (h *Hits) PopFront() []LogEntry {
 	if h.Cnt <= 0 {
 		return nil
 	}
 
 	var (
-		sz   = len(h.Logs) / h.Cnt
+		sz   = h.groupSize(0)
 		logs = h.Logs[:sz]
 	)
 
 	h.Cnt -= 1
 	h.Logs = h.Logs[sz:]
+	if h.Groups != nil {
+		h.Groups = h.Groups[1:]
+	}
 	return logs
 }
 
+// Head returns the first n hits in h, with properties for those hits only.
+func (h Hits) Head(n int) Hits {
+	if n >= h.Cnt {
+		return h
+	}
+	if n <= 0 {
+		return Hits{}
+	}
+
+	out := Hits{
+		Cnt:       n,
+		FireStamp: h.FireStamp,
+	}
+	if h.Groups != nil {
+		out.Groups = h.Groups[:n]
+		var sz int
+		for _, g := range out.Groups {
+			sz += g
+		}
+		out.Logs = h.Logs[:sz]
+	} else {
+		out.Logs = h.Logs[:n*h.groupSize(0)]
+	}
+	for k, v := range h.Props {
+		if k.Idx < n {
+			if out.Props == nil {
+				out.Props = make(map[PropKey]any)
+			}
+			out.Props[k] = v
+		}
+	}
+	return out
+}
+
+// Tail returns the last n hits in h, with properties for those hits only,
+// reindexed.
+func (h Hits) Tail(n int) Hits {
+	if n >= h.Cnt {
+		return h
+	}
+	if n <= 0 {
+		return Hits{}
+	}
+
+	var (
+		skip = h.Cnt - n
+		out  = Hits{
+			Cnt:       n,
+			FireStamp: h.FireStamp,
+		}
+	)
+	if h.Groups != nil {
+		out.Groups = h.Groups[skip:]
+		var sz int
+		for _, g := range h.Groups[:skip] {
+			sz += g
+		}
+		out.Logs = h.Logs[sz:]
+	} else {
+		out.Logs = h.Logs[skip*h.groupSize(0):]
+	}
+	for k, v := range h.Props {
+		if k.Idx >= skip {
+			if out.Props == nil {
+				out.Props = make(map[PropKey]any)
+			}
+			out.Props[PropKey{Idx: k.Idx - skip, Key: k.Key}] = v
+		}
+	}
+	return out
+}
+
+// Filter returns the hits in h for which keep is true, with properties
+// reindexed.
+func (h Hits) Filter(keep func(logs []LogEntry) bool) Hits {
+	var (
+		out Hits
+		off int
+	)
+
+	for i := range h.Cnt {
+		sz := h.groupSize(i)
+		logs := h.Logs[off : off+sz]
+		off += sz
+
+		if !keep(logs) {
+			continue
+		}
+
+		for k, v := range h.Props {
+			if k.Idx == i {
+				if out.Props == nil {
+					out.Props = make(map[PropKey]any)
+				}
+				out.Props[PropKey{Idx: out.Cnt, Key: k.Key}] = v
+			}
+		}
+		if h.Groups != nil {
+			out.Groups = append(out.Groups, sz)
+		}
+		out.Cnt += 1
+		out.Logs = append(out.Logs, logs...)
+	}
+
+	if out.Cnt > 0 {
+		out.FireStamp = h.FireStamp
+	}
+	return out
+}
+
+// All returns an iterator over the log groups in h, one group per hit.
+func (h Hits) All() iter.Seq[[]LogEntry] {
+	return func(yield func([]LogEntry) bool) {
+		for i := range h.Cnt {
+			if !yield(h.group(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Last returns the logs of the last hit.
+//
+// Deprecated: Use Iter.
 func (h Hits) Last() []LogEntry {
-	return h.Index(h.Cnt - 1)
+	return h.group(h.Cnt - 1)
 }
 
+// Index returns the logs of hit i.
+//
+// Deprecated: Use Iter.
 func (h Hits) Index(i int) []LogEntry {
+	return h.group(i)
+}
+
+func (h Hits) group(i int) []LogEntry {
 	if i < 0 || i >= h.Cnt {
 		return nil
 	}
-	var (
-		nLogs = len(h.Logs)
-		sz    = nLogs / h.Cnt
-		off   = i * sz
-	)
-	return h.Logs[off : off+sz]
+
+	var off int
+	if h.Groups != nil {
+		for _, g := range h.Groups[:i] {
+			off += g
+		}
+	} else {
+		off = i * h.groupSize(0)
+	}
+	return h.Logs[off : off+h.groupSize(i)]
 }
 
 // IndexProps returns a map of properties for the given index i, aggregating all entries in h.Props
-// where PropKey.Idx == i. If h.Props is nil or no properties match, it returns nil (not an empty map).
-// Out-of-range indices are not explicitly checked; if no properties match, nil is returned.
+// where PropKey.Idx == i, along with the props extracted into the log entries of that hit.  Where
+// keys collide, h.Props takes precedence over entries, and later entries over earlier ones.
+// If no properties match, it returns nil (not an empty map).
 
 func (h Hits) IndexProps(i int) map[string]any {
-	if h.Props == nil || i < 0 || i >= h.Cnt {
+	if i < 0 || i >= h.Cnt {
 		return nil
 	}
 
 	var m map[string]any
 
+	for _, e := range h.group(i) {
+		if len(e.Props) == 0 {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]any)
+		}
+		maps.Copy(m, e.Props)
+	}
+
 	for k, v := range h.Props {
 		if k.Idx == i {
 			if m == nil {
@@ -0,0 +1,88 @@
+package match
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// hitJsonT is the serialized form of a Hit.  The schema is stable; fields may
+// be added but not renamed.  Timestamps are RFC3339 in UTC with nanoseconds.
+type hitJsonT struct {
+	Rule    string         `json:"rule,omitempty"`
+	Start   string         `json:"start"`
+	End     string         `json:"end"`
+	Delayed bool           `json:"delayed,omitempty"`
+	Logs    []logJsonT     `json:"logs"`
+	Props   map[string]any `json:"props,omitempty"`
+}
+
+type logJsonT struct {
+	Timestamp string            `json:"ts"`
+	Line      string            `json:"line"`
+	Stream    string            `json:"stream,omitempty"`
+	Offset    int64             `json:"offset,omitempty"`
+	LineNo    int64             `json:"lineNo,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Severity  string            `json:"severity,omitempty"` // Level name; see entry.SeverityT.
+}
+
+func formatStamp(ts int64) string {
+	return time.Unix(0, ts).UTC().Format(time.RFC3339Nano)
+}
+
+// MarshalJSON encodes the hit in the stable hit schema.  The metadata is
+// promoted to top level fields rather than repeated in props.
+func (h Hit) MarshalJSON() ([]byte, error) {
+	out := hitJsonT{
+		Rule:    h.Rule,
+		Start:   formatStamp(h.Window[0]),
+		End:     formatStamp(h.Window[1]),
+		Delayed: h.Delayed,
+		Logs:    make([]logJsonT, len(h.Logs)),
+	}
+
+	for i, e := range h.Logs {
+		out.Logs[i] = logJsonT{
+			Timestamp: formatStamp(e.Timestamp),
+			Line:      e.Line,
+			Stream:    e.Stream,
+			Offset:    e.Offset,
+			LineNo:    e.LineNo,
+			Labels:    e.Labels,
+			Severity:  e.Severity.String(),
+		}
+	}
+
+	for k, v := range h.Props {
+		if k == PropMeta {
+			continue
+		}
+		if out.Props == nil {
+			out.Props = make(map[string]any, len(h.Props))
+		}
+		out.Props[k] = v
+	}
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the hits as an array of hits; see Hit.MarshalJSON.
+func (h Hits) MarshalJSON() ([]byte, error) {
+	out := make([]Hit, 0, max(h.Cnt, 0))
+	for hit := range h.Iter() {
+		out = append(out, hit)
+	}
+	return json.Marshal(out)
+}
+
+// WriteNDJSON writes the hits to w as newline delimited JSON, one hit per line.
+func (h Hits) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for hit := range h.Iter() {
+		if err := enc.Encode(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package match
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+func TestHitJson(t *testing.T) {
+	var (
+		t0 = time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC).UnixNano()
+		t1 = t0 + int64(time.Second)
+	)
+
+	h := Hits{
+		Cnt: 1,
+		Logs: []LogEntry{
+			{Timestamp: t0, Line: "alpha"},
+			{Timestamp: t1, Line: "beta"},
+		},
+	}
+	SetMeta(&h, 0, HitMetaT{Start: t0, End: t1, Rule: "r1", Delayed: true})
+	h.Props[PropKey{Idx: 0, Key: "pod"}] = "p"
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	exp := `[{"rule":"r1","start":"2025-01-02T03:04:05.000000006Z","end":"2025-01-02T03:04:06.000000006Z","delayed":true,` +
+		`"logs":[{"ts":"2025-01-02T03:04:05.000000006Z","line":"alpha"},{"ts":"2025-01-02T03:04:06.000000006Z","line":"beta"}],` +
+		`"props":{"pod":"p"}}]`
+
+	if string(data) != exp {
+		t.Errorf("Expected:\n%s\ngot:\n%s", exp, data)
+	}
+}
+
+func TestHitsJsonEmpty(t *testing.T) {
+	data, err := json.Marshal(Hits{})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("Expected [], got %s", data)
+	}
+}
+
+func TestHitsNDJSON(t *testing.T) {
+	h := Hits{Cnt: 2, Logs: []LogEntry{{Timestamp: 1, Line: "a"}, {Timestamp: 2, Line: "b"}}}
+
+	var buf bytes.Buffer
+	if err := h.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+
+	var hit struct {
+		Start string `json:"start"`
+		Logs  []struct {
+			Line string `json:"line"`
+		} `json:"logs"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &hit); err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	if hit.Start != "1970-01-01T00:00:00.000000002Z" || len(hit.Logs) != 1 || hit.Logs[0].Line != "b" {
+		t.Errorf("Unexpected hit: %+v", hit)
+	}
+}
+
+func TestHitJsonLocation(t *testing.T) {
+	sm, err := NewMatchSingle(makeRaw("alpha"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	// Source fields carry through the matcher into the hit.
+	sl := NewScanLine().Reset(LogEntry{
+		Timestamp: 0,
+		Line:      "alpha",
+		Stream:    "stderr",
+		Offset:    128,
+		LineNo:    9,
+		Labels:    map[string]string{"pod": "p"},
+		Severity:  entry.SevError + 1,
+	})
+
+	data, err := json.Marshal(sm.Scan(sl))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	exp := `"logs":[{"ts":"1970-01-01T00:00:00Z","line":"alpha","stream":"stderr","offset":128,"lineNo":9,"labels":{"pod":"p"},"severity":"ERROR"}]`
+	if !strings.Contains(string(data), exp) {
+		t.Errorf("Expected %s in:\n%s", exp, data)
+	}
+}
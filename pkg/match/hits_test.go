@@ -124,6 +124,33 @@ func TestHitsIndexProps(t *testing.T) {
 	}
 }
 
+func TestHitsIndexPropsEntryProps(t *testing.T) {
+	logs := makeTestLogs(4)
+	logs[0].Props = map[string]any{"pod": "a", "code": 500}
+	logs[1].Props = map[string]any{"pod": "b"}
+	logs[3].Props = map[string]any{"pod": "c"}
+
+	h := Hits{
+		Cnt:  2,
+		Logs: logs,
+		Props: map[PropKey]any{
+			{Idx: 1, Key: "pod"}: "explicit",
+		},
+	}
+
+	// Later entries win over earlier entries.
+	props0 := h.IndexProps(0)
+	if len(props0) != 2 || props0["pod"] != "b" || props0["code"] != 500 {
+		t.Errorf("Expected pod:b code:500 for index 0, got %v", props0)
+	}
+
+	// Hit props win over entries.
+	props1 := h.IndexProps(1)
+	if len(props1) != 1 || props1["pod"] != "explicit" {
+		t.Errorf("Expected pod:explicit for index 1, got %v", props1)
+	}
+}
+
 func TestHitsIndexPropsNilProps(t *testing.T) {
 	h := Hits{
 		Cnt:   2,
@@ -252,3 +279,211 @@ func TestPropKeyStruct(t *testing.T) {
 		t.Errorf("Expected key1 to be overwritten with 'value3', got %v", props[key1])
 	}
 }
+
+func TestHitsFireStamp(t *testing.T) {
+
+	cases := casesT{
+		"Seq": {
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 5, line: "beta", cb: checkFireStamp(5, matchStamps(1, 5))},
+			},
+		},
+		"InverseSeqResetDelay": {
+			// Fire is delayed until the reset window has passed; stamped on the Eval clock.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:   makeRaw("boom"),
+				Window: 5,
+			}},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 2, line: "beta", postF: checkEval(20, checkFireStamp(20, matchStamps(1, 2)))},
+			},
+		},
+	}
+
+	cases.run(t, func(tc caseT) (Matcher, error) {
+		if tc.reset == nil {
+			return NewMatchSeq(tc.window, makeTerms(tc.terms)...)
+		}
+		return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+	})
+}
+
+func TestHitsHead(t *testing.T) {
+	h := Hits{
+		Cnt:       3,
+		Logs:      makeTestLogs(6),
+		FireStamp: 7,
+		Props: map[PropKey]any{
+			{Idx: 0, Key: "a"}: 1,
+			{Idx: 2, Key: "a"}: 3,
+		},
+	}
+
+	head := h.Head(2)
+	if head.Cnt != 2 || len(head.Logs) != 4 || head.FireStamp != 7 {
+		t.Errorf("Expected 2 hits with 4 logs at 7, got %v %v %v", head.Cnt, len(head.Logs), head.FireStamp)
+	}
+	if len(head.Props) != 1 || head.Props[PropKey{Idx: 0, Key: "a"}] != 1 {
+		t.Errorf("Expected props for first hit only, got %v", head.Props)
+	}
+
+	if all := h.Head(5); all.Cnt != 3 {
+		t.Errorf("Expected all 3 hits, got %v", all.Cnt)
+	}
+	if none := h.Head(0); none.Cnt != 0 || none.Logs != nil {
+		t.Errorf("Expected no hits, got %v", none)
+	}
+}
+
+func TestHitsTail(t *testing.T) {
+	h := Hits{
+		Cnt:       3,
+		Logs:      makeStampedLogs(1, 2, 3, 4, 5, 6),
+		FireStamp: 7,
+		Props: map[PropKey]any{
+			{Idx: 0, Key: "a"}: 1,
+			{Idx: 2, Key: "a"}: 3,
+		},
+	}
+
+	tail := h.Tail(2)
+	if tail.Cnt != 2 || len(tail.Logs) != 4 || tail.FireStamp != 7 || tail.Logs[0].Timestamp != 3 {
+		t.Errorf("Expected last 2 hits with 4 logs at 7, got %v %v %v", tail.Cnt, len(tail.Logs), tail.FireStamp)
+	}
+	if len(tail.Props) != 1 || tail.Props[PropKey{Idx: 1, Key: "a"}] != 3 {
+		t.Errorf("Expected props for last hit reindexed, got %v", tail.Props)
+	}
+
+	var uneven Hits
+	uneven.Append(Hits{Cnt: 1, Logs: makeStampedLogs(1, 2)})
+	uneven.Append(Hits{Cnt: 1, Logs: makeStampedLogs(3)})
+	if tail := uneven.Tail(1); tail.Cnt != 1 || len(tail.Logs) != 1 || tail.Logs[0].Timestamp != 3 || len(tail.Groups) != 1 {
+		t.Errorf("Expected the last uneven hit, got %v", tail)
+	}
+
+	if all := h.Tail(5); all.Cnt != 3 {
+		t.Errorf("Expected all 3 hits, got %v", all.Cnt)
+	}
+	if none := h.Tail(0); none.Cnt != 0 || none.Logs != nil {
+		t.Errorf("Expected no hits, got %v", none)
+	}
+}
+
+func makeStampedLogs(stamps ...int64) []LogEntry {
+	logs := make([]LogEntry, len(stamps))
+	for i, ts := range stamps {
+		logs[i] = LogEntry{Timestamp: ts}
+	}
+	return logs
+}
+
+func TestHitsAppendUneven(t *testing.T) {
+	var h Hits
+	h.Append(Hits{Cnt: 1, Logs: makeStampedLogs(1, 2)})
+	h.Append(Hits{Cnt: 2, Logs: makeStampedLogs(3, 4, 5, 6, 7, 8)})
+	h.Append(Hits{Cnt: 1, Logs: makeStampedLogs(9)})
+
+	if h.Cnt != 4 || len(h.Logs) != 9 {
+		t.Fatalf("Expected 4 hits of 9 logs, got %d of %d", h.Cnt, len(h.Logs))
+	}
+
+	exp := [][]int64{{1, 2}, {3, 4, 5}, {6, 7, 8}, {9}}
+	for i, stamps := range exp {
+		group := h.Index(i)
+		if len(group) != len(stamps) || group[0].Timestamp != stamps[0] {
+			t.Errorf("Hit %d: Expected %v, got %v", i, stamps, group)
+		}
+	}
+
+	if last := h.Last(); len(last) != 1 || last[0].Timestamp != 9 {
+		t.Errorf("Expected last hit {9}, got %v", last)
+	}
+
+	head := h.Head(2)
+	if head.Cnt != 2 || len(head.Logs) != 5 {
+		t.Errorf("Expected head of 2 hits and 5 logs, got %d of %d", head.Cnt, len(head.Logs))
+	}
+
+	if first := h.PopFront(); len(first) != 2 {
+		t.Errorf("Expected 2 logs, got %d", len(first))
+	}
+	if second := h.PopFront(); len(second) != 3 || second[0].Timestamp != 3 {
+		t.Errorf("Expected {3, 4, 5}, got %v", second)
+	}
+}
+
+func TestHitsAppendEven(t *testing.T) {
+	var h Hits
+	h.Append(Hits{Cnt: 1, Logs: makeTestLogs(2)})
+	h.Append(Hits{Cnt: 1, Logs: makeTestLogs(2)})
+
+	if h.Groups != nil {
+		t.Errorf("Expected no groups for even hits, got %v", h.Groups)
+	}
+}
+
+func TestHitsIter(t *testing.T) {
+	h := Hits{Cnt: 1, Logs: makeStampedLogs(1, 4)}
+	h.Append(Hits{
+		Cnt:   1,
+		Logs:  makeStampedLogs(5, 6, 9),
+		Props: map[PropKey]any{{Idx: 0, Key: "k"}: "v"},
+	})
+
+	var hits []Hit
+	for hit := range h.Iter() {
+		hits = append(hits, hit)
+	}
+
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %d", len(hits))
+	}
+	if len(hits[0].Logs) != 2 || hits[0].Window != [2]int64{1, 4} || hits[0].Props != nil {
+		t.Errorf("Unexpected first hit: %+v", hits[0])
+	}
+	if len(hits[1].Logs) != 3 || hits[1].Window != [2]int64{5, 9} || hits[1].Props["k"] != "v" {
+		t.Errorf("Unexpected second hit: %+v", hits[1])
+	}
+
+	// Early exit.
+	for range h.Iter() {
+		break
+	}
+}
+
+func TestHitsFilter(t *testing.T) {
+
+	h := Hits{
+		Cnt:       3,
+		Logs:      []LogEntry{{Line: "a"}, {Line: "b"}, {Line: "c"}, {Line: "d"}},
+		Groups:    []int{1, 2, 1},
+		FireStamp: 9,
+		Props: map[PropKey]any{
+			{Idx: 1, Key: "k"}: "one",
+			{Idx: 2, Key: "k"}: "two",
+		},
+	}
+
+	out := h.Filter(func(logs []LogEntry) bool { return logs[0].Line != "a" })
+
+	switch {
+	case out.Cnt != 2, len(out.Logs) != 3, out.Logs[0].Line != "b":
+		t.Errorf("Expected hits b,c and d, got %v", out)
+	case out.Groups[0] != 2 || out.Groups[1] != 1:
+		t.Errorf("Expected groups [2 1], got %v", out.Groups)
+	case out.Props[PropKey{Idx: 0, Key: "k"}] != "one" || out.Props[PropKey{Idx: 1, Key: "k"}] != "two":
+		t.Errorf("Expected reindexed props, got %v", out.Props)
+	case out.FireStamp != 9:
+		t.Errorf("Expected fire stamp 9, got %d", out.FireStamp)
+	}
+
+	if out = h.Filter(func([]LogEntry) bool { return false }); out.Cnt != 0 || out.FireStamp != 0 {
+		t.Errorf("Expected no hits, got %v", out)
+	}
+}
@@ -0,0 +1,64 @@
+package match
+
+import (
+	"github.com/rs/zerolog"
+)
+
+// Props keys holding the ID and labels of the matcher that emitted a hit, as
+// set by WithID and WithLabels.
+const (
+	PropID     = "id"
+	PropLabels = "labels"
+)
+
+// Embedded by matchers, through statsT, to carry the WithID and WithLabels
+// identity into their hits, stats and warning logs.
+type identT struct {
+	id     string
+	labels map[string]string
+}
+
+func newIdent(o optsT) identT {
+	return identT{id: o.id, labels: o.labels}
+}
+
+// Record the identity on every hit in h.
+func (t *identT) tag(h *Hits) {
+	if h.Cnt == 0 || (t.id == "" && t.labels == nil) {
+		return
+	}
+
+	if h.Props == nil {
+		h.Props = make(map[PropKey]any, h.Cnt)
+	}
+	for i := range h.Cnt {
+		if t.id != "" {
+			h.Props[PropKey{Idx: i, Key: PropID}] = t.id
+		}
+		if t.labels != nil {
+			h.Props[PropKey{Idx: i, Key: PropLabels}] = t.labels
+		}
+	}
+}
+
+// Add the identity to a log event.
+func (t *identT) log(ev *zerolog.Event) *zerolog.Event {
+	if t.id != "" {
+		ev = ev.Str(PropID, t.id)
+	}
+	if t.labels != nil {
+		ev = ev.Interface(PropLabels, t.labels)
+	}
+	return ev
+}
+
+// IdentOf returns the ID and labels of the matcher that emitted hit i, if
+// set with WithID or WithLabels.
+func IdentOf(h Hits, i int) (id string, labels map[string]string) {
+	if i < 0 || i >= h.Cnt || h.Props == nil {
+		return
+	}
+	id, _ = h.Props[PropKey{Idx: i, Key: PropID}].(string)
+	labels, _ = h.Props[PropKey{Idx: i, Key: PropLabels}].(map[string]string)
+	return
+}
@@ -0,0 +1,120 @@
+package match
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestIdentHits(t *testing.T) {
+	var (
+		sl     = NewScanLine()
+		labels = map[string]string{"team": "infra"}
+		opts   = []OptT{WithID("rule-1"), WithLabels(labels)}
+	)
+
+	seq, _ := NewMatchSeqOpts(10, makeTermsA("alpha", "beta"), opts...)
+	absence, _ := NewMatchAbsence(10, makeRaw("alpha"), opts...)
+	edge, _ := NewMatchFallingEdge(makeRaw("alpha"), 10, opts...)
+
+	seq.Scan(sl.ResetLine(1, "alpha"))
+	hits := seq.Scan(sl.ResetLine(2, "beta"))
+	checkIdent(t, "seq", hits, labels)
+
+	absence.Scan(sl.ResetLine(1, "noop"))
+	checkIdent(t, "absence", absence.Eval(20), labels)
+
+	edge.Scan(sl.ResetLine(1, "alpha"))
+	checkIdent(t, "edge", edge.Eval(20), labels)
+
+	dst := Hits{Logs: make([]LogEntry, 0, seq.GroupSize())}
+	seq.Scan(sl.ResetLine(30, "alpha"))
+	if !seq.ScanInto(sl.ResetLine(31, "beta"), &dst) {
+		t.Fatalf("Expected ScanInto fire")
+	}
+	checkIdent(t, "scanInto", dst, labels)
+
+	single, _ := NewMatchSingleOpts(makeRaw("alpha"), opts...)
+	checkIdent(t, "single", single.Scan(sl.ResetLine(40, "alpha")), labels)
+
+	set, _ := NewMatchSetOpts(10, makeTermsA("alpha", "beta"), opts...)
+	set.Scan(sl.ResetLine(40, "beta"))
+	checkIdent(t, "set", set.Scan(sl.ResetLine(41, "alpha")), labels)
+
+	count, _ := NewMatchCount(10, 2, makeRaw("alpha"), opts...)
+	count.Scan(sl.ResetLine(40, "alpha"))
+	checkIdent(t, "count", count.Scan(sl.ResetLine(41, "alpha")), labels)
+
+	rate, _ := NewMatchRate(2, 1, makeRaw("alpha"), opts...)
+	rate.Scan(sl.ResetLine(40, "alpha"))
+	checkIdent(t, "rate", rate.Scan(sl.ResetLine(41, "alpha")), labels)
+
+	invSeq, _ := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset")}}, opts...)
+	invSeq.Scan(sl.ResetLine(40, "alpha"))
+	invSeq.Scan(sl.ResetLine(41, "beta"))
+	checkIdent(t, "inverseSeq", invSeq.Eval(50), labels)
+
+	invSet, _ := NewInverseSet(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset"), Window: 5, Absolute: true}}, opts...)
+	invSet.Scan(sl.ResetLine(40, "alpha"))
+	invSet.Scan(sl.ResetLine(41, "beta"))
+	checkIdent(t, "inverseSet", invSet.Eval(50), labels)
+
+	keyed, _ := NewKeyedMatcher(func(*ScanLine) string { return "k" }, func() Matcher {
+		m, _ := NewMatchSingle(makeRaw("alpha"))
+		return m
+	}, opts...)
+	checkIdent(t, "keyed", keyed.Scan(sl.ResetLine(40, "alpha")), labels)
+
+	if st := seq.Stats(); st.ID != "rule-1" || st.Labels["team"] != "infra" {
+		t.Errorf("Expected stats identity, got %q %v", st.ID, st.Labels)
+	}
+}
+
+func checkIdent(t *testing.T, name string, hits Hits, labels map[string]string) {
+	t.Helper()
+	if hits.Cnt != 1 {
+		t.Fatalf("%s: Expected 1 hit, got %d", name, hits.Cnt)
+	}
+	id, got := IdentOf(hits, 0)
+	if id != "rule-1" || got["team"] != labels["team"] {
+		t.Errorf("%s: Expected identity, got %q %v", name, id, got)
+	}
+}
+
+func TestIdentNone(t *testing.T) {
+	sl := NewScanLine()
+	seq, _ := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+
+	seq.Scan(sl.ResetLine(1, "alpha"))
+	hits := seq.Scan(sl.ResetLine(2, "beta"))
+	if hits.Props != nil {
+		t.Errorf("Expected no props, got %v", hits.Props)
+	}
+	if id, labels := IdentOf(hits, 0); id != "" || labels != nil {
+		t.Errorf("Expected no identity, got %q %v", id, labels)
+	}
+}
+
+func TestIdentLateLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	saved, level := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	defer func() {
+		log.Logger = saved
+		zerolog.SetGlobalLevel(level)
+	}()
+
+	sl := NewScanLine()
+	count, _ := NewMatchCount(10, 2, makeRaw("alpha"), WithID("rule-2"))
+	count.Scan(sl.ResetLine(5, "alpha"))
+	count.Scan(sl.ResetLine(3, "alpha"))
+
+	if !strings.Contains(buf.String(), `"id":"rule-2"`) {
+		t.Errorf("Expected id in warning, got %q", buf.String())
+	}
+}
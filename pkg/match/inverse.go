@@ -1,6 +1,7 @@
 package match
 
 import (
+	"cmp"
 	"errors"
 	"slices"
 )
@@ -9,20 +10,38 @@ var (
 	ErrNoTerms       = errors.New("no terms")
 	ErrTooManyTerms  = errors.New("too many terms")
 	ErrAnchorRange   = errors.New("anchor out of range")
-	ErrAnchorNoDupes = errors.New("non zero anchors unsupported with duplicate terms")
+	ErrAnchorNoDupes = errors.New("non zero anchors unsupported with duplicate terms") // Deprecated: no longer returned.
+	ErrBetweenRange  = errors.New("between position out of range")
 )
 
-const (
-	maxTerms     = 64
-	capThreshold = 4
-)
+// MaxTerms is the most distinct terms a sequence or set may have; repeats of
+// a term are not counted.
+const MaxTerms = 1024
+
+const capThreshold = 4
 
 type ResetT struct {
 	Term     TermT // Inverse term
 	Window   int64 // Window size; defaults to 0 which in combination with !Absolute means the window is the range of the matched sequence.
 	Slide    int64 // Slide the anchor, +/- relative to the anchor term
-	Anchor   uint8 // Anchor term; defaults to first event in match sequence
+	Anchor   int   // Anchor term; defaults to first event in match sequence
 	Absolute bool  // Absolute window time or relative to the range of the matched sequence.
+
+	// AnchorEnd, if greater than Anchor, closes an anchor range.  The window
+	// then spans from Anchor, shifted by Slide, to AnchorEnd, extended by
+	// Window; Absolute is ignored.
+	AnchorEnd int
+
+	// Correlate, if set, names a field that a reset line must share with the
+	// anchor entry of the match for the reset to count; eg. a request ID.
+	// The field is a dotted JSON path, a logfmt key, or a jq extracted prop.
+	Correlate string
+
+	// CorrelateKey, if set, derives the key that a reset line must share with
+	// the anchor entry in place of a Correlate field; eg. a KeyComposite of
+	// several fields.  A line with an empty key does not correlate.  Setting
+	// both is an error.
+	CorrelateKey KeyFn
 }
 
 type resetT struct {
@@ -30,13 +49,19 @@ type resetT struct {
 	resets   []int64
 	window   int64
 	slide    int64
-	anchor   uint8
+	anchor   int
 	absolute bool
+	between  bool // Window is the open gap between anchors stop-1 and stop.
+	stop     int  // Anchor closing the gap; only valid if between.
+	end      int  // Anchor closing the range; zero if no range.
+	corr     *correlateT
 }
 
 type termT struct {
 	matcher MatchFunc
 	asserts []LogEntry
+	base    []LogEntry // Pooled array backing asserts, if any; see push.
+	matched uint64     // Lines matched; see StatsT.
 }
 
 func (r resetT) calcWindowA(anchors []anchorT) (int64, int64) {
@@ -44,6 +69,11 @@ func (r resetT) calcWindowA(anchors []anchorT) (int64, int64) {
 		return 0, 0
 	}
 
+	if r.between {
+		// Open interval; a line sharing a stamp with either end is not between.
+		return anchors[r.stop-1].clock + 1, anchors[r.stop].clock - 1
+	}
+
 	var (
 		width  = r.window
 		anchor = anchors[r.anchor].clock
@@ -52,6 +82,10 @@ func (r resetT) calcWindowA(anchors []anchorT) (int64, int64) {
 	// Slide the anchor if necessary
 	anchor += r.slide
 
+	if r.end > 0 {
+		return anchor, anchors[r.end].clock + max(width, 0)
+	}
+
 	// Determine the width of the window
 	if !r.absolute {
 		width += anchors[len(anchors)-1].clock - anchors[0].clock
@@ -66,6 +100,188 @@ func (r resetT) calcWindowA(anchors []anchorT) (int64, int64) {
 	return anchor, anchor + width
 }
 
+// Build reset terms shared by the inverse matchers.  The anchor, and the end of
+// any anchor range, must fall within nAnchors, which includes dupes.
+
+func buildResets(resetTerms []ResetT, nAnchors int) ([]resetT, error) {
+	if len(resetTerms) == 0 {
+		return nil, nil
+	}
+
+	resets := make([]resetT, 0, len(resetTerms))
+
+	for _, term := range resetTerms {
+		m, err := term.Term.NewMatcher()
+		switch {
+		case err != nil:
+			return nil, err
+		case term.Anchor < 0, term.Anchor >= nAnchors, term.AnchorEnd >= nAnchors:
+			return nil, ErrAnchorRange
+		case term.AnchorEnd > 0 && term.AnchorEnd <= term.Anchor:
+			return nil, ErrAnchorRange
+		}
+
+		corr, err := newCorrelate(term.Correlate, term.CorrelateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		resets = append(resets, resetT{
+			matcher:  m,
+			window:   term.Window,
+			slide:    term.Slide,
+			anchor:   term.Anchor,
+			absolute: term.Absolute,
+			end:      term.AnchorEnd,
+			corr:     corr,
+		})
+	}
+
+	return resets, nil
+}
+
+// Build the negative terms for each gap in the sequence.  Positions index the
+// sequence as supplied, including dupes, so match the anchor list.  The reset
+// is anchored on the first assert of the term preceding the gap, which is
+// dropped should the gap be interrupted.
+
+func buildBetween(between map[int][]TermT, nAnchors int, dupeMap map[int]int) ([]resetT, error) {
+	if len(between) == 0 {
+		return nil, nil
+	}
+
+	for pos := range between {
+		if pos < 1 || pos >= nAnchors {
+			return nil, ErrBetweenRange
+		}
+	}
+
+	// Map each anchor to the first anchor of its term.
+	var (
+		first = make([]int, 0, nAnchors)
+		off   int
+	)
+	for i := 0; len(first) < nAnchors; i++ {
+		for range dupeMap[i] + 1 {
+			first = append(first, off)
+		}
+		off = len(first)
+	}
+
+	var resets []resetT
+
+	for pos := 1; pos < nAnchors; pos++ {
+		for _, term := range between[pos] {
+			m, err := term.NewMatcher()
+			if err != nil {
+				return nil, err
+			}
+
+			resets = append(resets, resetT{
+				matcher: m,
+				anchor:  first[pos-1],
+				between: true,
+				stop:    pos,
+			})
+		}
+	}
+
+	return resets, nil
+}
+
+// Gather the timestamps of a hot frame, in term order, including dupes.
+
+func gatherAnchors(terms []termT, dupeMap map[int]int) []anchorT {
+	anchors := make([]anchorT, 0, len(terms)+dupeMap[-1])
+
+	for i, term := range terms {
+		cnt := dupeMap[i] + 1
+		for j := range cnt {
+			anchors = append(anchors, anchorT{
+				clock:  term.asserts[j].Timestamp,
+				term:   i,
+				offset: j,
+				entry:  &term.asserts[j],
+			})
+		}
+	}
+
+	return anchors
+}
+
+// Iterate across the resets; determine if we have a negative match.
+// Returns the anchor to drop on a negative match.  If the outcome cannot yet
+// be determined, returns an invalid term with clock set to the ticks to wait.
+
+func evalResets(resets []resetT, anchors []anchorT, clock int64) anchorT {
+
+	for ri, reset := range resets {
+		start, stop := reset.calcWindowA(anchors)
+
+		var want string
+		if reset.corr != nil {
+			v, ok := reset.corr.entryValue(*anchors[reset.anchor].entry)
+			if !ok {
+				// No correlation field on the match; the reset cannot apply.
+				continue
+			}
+			want = v
+		}
+
+		// Check if we have a negative term in the reset window.
+		// Reset stamps are in order; search for the start of the window.
+		i, _ := slices.BinarySearch(reset.resets, start)
+		for ; i < len(reset.resets) && reset.resets[i] <= stop; i++ {
+			if reset.corr == nil || reset.corr.keys[i] == want {
+				hit := anchors[reset.anchor]
+				hit.reset, hit.stamp = ri, reset.resets[i]
+				return hit
+			}
+		}
+
+		// If the reset window is in the future, we cannot come to a conclusion.
+		// We must wait until the reset window is in the past due to events with
+		// duplicate timestamps.  Thus must wait until one tick past the reset window.
+		if stop >= clock {
+			return anchorT{
+				term:  -1,
+				clock: stop - clock + 1,
+			}
+		}
+	}
+
+	return anchorT{term: -1}
+}
+
+// Clean up reset stamps older than deadline.  Returns the lesser of nMark and
+// the earliest GC mark implied by the remaining resets.
+
+func gcResets(resets []resetT, deadline, gcSpan, nMark int64) int64 {
+
+	for i, reset := range resets {
+
+		m := reset.resets
+		if len(m) == 0 {
+			continue
+		}
+
+		cnt, _ := slices.BinarySearch(m, deadline)
+
+		if cnt > 0 {
+			resets[i].resets = m[cnt:]
+			if c := reset.corr; c != nil {
+				c.keys = c.keys[cnt:]
+			}
+		}
+
+		if len(resets[i].resets) > 0 {
+			nMark = min(nMark, resets[i].resets[0]+gcSpan)
+		}
+	}
+
+	return nMark
+}
+
 // Calculate GC windows for term and reset terms.
 
 func calcGCWindow(window int64, resets []resetT) (int64, int64) {
@@ -86,6 +302,10 @@ func calcGCWindow(window int64, resets []resetT) (int64, int64) {
 		// relative case: window + reset.Window (positive or negative)
 		// absolute case: reset.Window with last item anchor
 		rRight := window + reset.window + reset.slide
+		if reset.end > 0 {
+			// The range ends on an anchor within the match.
+			rRight = window + max(reset.window, 0)
+		}
 
 		if rRight > right {
 			right = rRight
@@ -98,32 +318,32 @@ func calcGCWindow(window int64, resets []resetT) (int64, int64) {
 	return (-1 * left), right
 }
 
+// Number of asserts older than stamp.  Asserts are appended in clock order,
+// and late entries are clamped to the clock, so a binary search suffices.
+
+func countBefore(asserts []LogEntry, stamp int64) int {
+	n, _ := slices.BinarySearchFunc(asserts, stamp, func(e LogEntry, t int64) int {
+		return cmp.Compare(e.Timestamp, t)
+	})
+	return n
+}
+
 // Be wary; this has a side effect of changing terms[i].asserts slice.
 
 func shiftLeft(terms []termT, idx, cnt int) int {
 	m := terms[idx].asserts
 
-	switch {
-	case cnt < len(m):
-		m = m[cnt:]
-	case cap(m) <= capThreshold:
-		m = m[:0]
-	default:
-		m = nil
+	if cnt >= len(m) {
+		terms[idx].release()
+		return 0
 	}
 
-	terms[idx].asserts = m
-	return len(m)
+	terms[idx].asserts = m[cnt:]
+	return len(m) - cnt
 }
 
 func resetTerm(terms []termT, idx int) {
-	m := terms[idx].asserts
-
-	if cap(m) <= capThreshold {
-		terms[idx].asserts = m[:0]
-	} else {
-		terms[idx].asserts = nil
-	}
+	terms[idx].release()
 }
 
 // Be wary; this has a side effect of changing terms[i].asserts slice.
@@ -147,6 +367,9 @@ type anchorT struct {
 	clock  int64
 	term   int
 	offset int
+	entry  *LogEntry // Asserted entry; only set by gatherAnchors.
+	reset  int       // Reset that suppressed the anchor; only set by evalResets.
+	stamp  int64     // Timestamp of the reset line; only set by evalResets.
 }
 
 func (a anchorT) ValidTerm() bool {
@@ -1,16 +1,13 @@
 package match
 
-import (
-	"slices"
-
-	"github.com/rs/zerolog/log"
-)
+import "slices"
 
 // InverseSeq matches a sequence of terms in order, within a time window,
 // with optional reset terms that can invalidate a match.
 //
-// Duplicate terms are supported.  However, reset terms with non-zero anchors
-// on duplicate terms are not supported.
+// Duplicate terms are supported, and reset terms may anchor on any of them.
+// A reset anchored on a dupe drops that assert; the next match of the term
+// takes its place in the frame.
 //
 // The implementation uses a state machine approach, where each term in the
 // sequence is represented by a state.  As log entries are processed, the
@@ -29,103 +26,88 @@ import (
 // The Eval method can be called to force evaluation of the current state,
 // and return any matches that may activate due to clock progression.
 //
+// Reset terms and sequence terms are evaluated against every line, so a
+// single line may match both.  By default such a line is recorded as a
+// reset and also advances the sequence; since resets are recorded before
+// the match is evaluated, the line will typically invalidate the very match
+// it completes.  WithPrecedence changes this so that the line counts only
+// as a reset (PrecedenceReset) or only as a term (PrecedenceTerm).  Only
+// terms that are currently active are considered for precedence.
+//
+// WithBetween adds negative terms to a single gap in the sequence.  Unlike a
+// reset, which is relative to the whole match, a between term only invalidates
+// the partial match whose gap it interrupts.
+//
 // Note: This implementation assumes that log entries are processed in
 // chronological order. Out-of-order entries will be logged as warnings
 // and ignored.
 
 type InverseSeq struct {
-	clock   int64
-	window  int64
-	gcMark  int64
-	gcLeft  int64
-	gcRight int64
-	nActive int
-	terms   []termT
-	resets  []resetT
-	dupeMap map[int]int
+	clock      int64
+	window     int64
+	gcMark     int64
+	gcLeft     int64
+	gcRight    int64
+	nActive    int
+	terms      []termT
+	resets     []resetT
+	dupeMap    map[int]int
+	precedence PrecedenceT
+
+	statsT
+	lateT
+	explainT
+	budgetT
 }
 
-func NewInverseSeq(window int64, seqTerms []TermT, resetTerms []ResetT) (*InverseSeq, error) {
+func NewInverseSeq(window int64, seqTerms []TermT, resetTerms []ResetT, opts ...OptT) (*InverseSeq, error) {
+
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optBetween | optExplain | optMaxBuffered | optPrecedence | optResets); err != nil {
+		return nil, err
+	}
 
 	terms, dupeMap, err := buildSeqTerms(seqTerms...)
 	if err != nil {
 		return nil, err
 	}
 
-	var resets []resetT
-	if len(resetTerms) > 0 {
-		resets = make([]resetT, 0, len(resetTerms))
+	nAnchors := countAnchors(seqTerms)
 
-		for _, term := range resetTerms {
-			m, err := term.Term.NewMatcher()
-			switch {
-			case err != nil:
-				return nil, err
-			case int(term.Anchor) >= len(seqTerms):
-				return nil, ErrAnchorRange
-			case !maybeAnchor(len(terms), dupeMap, term.Anchor):
-				return nil, ErrAnchorNoDupes
-			}
+	resets, err := buildResets(append(slices.Clip(resetTerms), o.resets...), nAnchors)
+	if err != nil {
+		return nil, err
+	}
 
-			resets = append(resets, resetT{
-				matcher:  m,
-				window:   term.Window,
-				slide:    term.Slide,
-				anchor:   term.Anchor,
-				absolute: term.Absolute,
-			})
-		}
+	between, err := buildBetween(o.between, nAnchors, dupeMap)
+	if err != nil {
+		return nil, err
 	}
+	resets = append(resets, between...)
 
 	gcLeft, gcRight := calcGCWindow(window, resets)
 
 	return &InverseSeq{
-		window:  window,
-		gcLeft:  gcLeft,
-		gcRight: gcRight,
-		gcMark:  disableGC,
-		terms:   terms,
-		resets:  resets,
-		dupeMap: dupeMap,
+		window:     window,
+		gcLeft:     gcLeft,
+		gcRight:    gcRight,
+		gcMark:     disableGC,
+		terms:      terms,
+		resets:     resets,
+		dupeMap:    dupeMap,
+		precedence: o.precedence,
+		explainT:   explainT{limit: o.explain},
+		budgetT:    budgetT{maxBuffered: o.maxBuffered},
+		statsT:     newStats(o),
 	}, nil
 }
 
-func maybeAnchor(nTerms int, dupeMap map[int]int, anchor uint8) bool {
-	if dupeMap == nil || anchor == 0 {
-		return true
-	}
-
-	// The anchor is non-zero so refers to a term that may be a dupe.
-	// Due to the way this is implemented, we cannot support non-zero anchors on dupe terms.
-	// See prequel-machine for this support.
-
-	curOff := 0
-	for i := range nTerms {
-		curOff += 1
-
-		// If anchor is on the first term before dupes, OK.
-		if int(anchor) < curOff {
-			return true
-		}
-
-		// If anchor is within the dupes of this term, not OK.
-		curOff += dupeMap[i]
-		if int(anchor) < curOff {
-			return false
-		}
-	}
-
-	return false
-}
-
 func (r *InverseSeq) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
 	if e.Timestamp < r.clock {
-		log.Warn().
-			Str("line", e.Line).
-			Int64("stamp", e.Timestamp).
-			Int64("clock", r.clock).
-			Msg("InverseSeq: Out of order event.")
-		return
+		if e = r.late(&r.identT, "InverseSeq", e, r.clock); e == nil {
+			return
+		}
 	}
 	r.clock = e.Timestamp
 
@@ -142,50 +124,84 @@ func (r *InverseSeq) Scan(e *ScanLine) (hits Hits) {
 		zeroMatch = true
 	}
 
-	// Run resets
-	for i, reset := range r.resets {
-		if reset.matcher(e) {
-			r.resets[i].resets = append(reset.resets, e.Timestamp)
+	// Run resets first unless terms take precedence,
+	// in which case resets must wait on the term results.
+	var isReset bool
+	if r.precedence != PrecedenceTerm {
+		isReset = r.scanResets(e)
+	}
+
+	var isTerm bool
+	if !isReset || r.precedence != PrecedenceReset {
+		isTerm = r.scanTerms(e, zeroMatch)
+	}
+
+	if !isTerm && r.precedence == PrecedenceTerm {
+		r.scanResets(e)
+	}
+
+	// Evict over the WithMaxBuffered bound.
+	if n := r.trimTerms(r.terms, r.dupeMap); n > 0 {
+		r.nEvicted += uint64(n)
+		r.miniGC()
+	}
+	r.nEvicted += uint64(r.trimResets(r.resets))
+
+	if r.nActive < len(r.terms) {
+		return
+	}
+
+	if hits = r._eval(e.Timestamp); hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
+}
+
+// Record the line against any matching reset terms.
+// Returns true if any reset term matched.
+func (r *InverseSeq) scanResets(e *ScanLine) (match bool) {
+	for i := range r.resets {
+		if r.resets[i].record(e) {
+			r.nResets += 1
 			r.resetGcMark(e.Timestamp + r.gcLeft + r.gcRight)
+			match = true
 		}
 	}
+	return
+}
+
+// Run the line against the active terms, advancing the sequence as appropriate.
+// Returns true if any active term matched.
+func (r *InverseSeq) scanTerms(e *ScanLine, zeroMatch bool) (match bool) {
 
-	// Run the active terms
 	for i := range r.nActive {
 		if r.terms[i].matcher(e) {
-			r.terms[i].asserts = append(r.terms[i].asserts, e.LogEntry)
+			r.terms[i].assert(e.Entry())
+			match = true
 		}
 	}
 
-	if r.nActive < len(r.terms) {
-
-		switch {
-		case zeroMatch:
-		case !r.terms[r.nActive].matcher(e):
-			// No match on active term; NOOP.
-			return
-		}
-
-		r.terms[r.nActive].asserts = append(r.terms[r.nActive].asserts, e.LogEntry)
-		r.resetGcMark(e.Timestamp + r.gcRight)
+	if r.nActive == len(r.terms) {
+		return
+	}
 
-		// We have matched the active term; check if there are dupes before advancing.
-		dupeCnt := r.dupeMap[r.nActive]
+	switch {
+	case zeroMatch:
+	case !r.terms[r.nActive].matcher(e):
+		// No match on active term; NOOP.
+		return
+	}
 
-		if len(r.terms[r.nActive].asserts) <= dupeCnt {
-			// Not enough dupes yet; append current for later.
-			return
-		}
+	r.terms[r.nActive].assert(e.Entry())
+	r.resetGcMark(e.Timestamp + r.gcRight)
 
+	// We have matched the active term; check if there are dupes before advancing.
+	if dupeCnt := r.dupeMap[r.nActive]; len(r.terms[r.nActive].asserts) > dupeCnt {
 		// We've matched the active term; advance.
 		r.nActive += 1
-
-		if r.nActive < len(r.terms) {
-			return
-		}
 	}
 
-	return r._eval(e.Timestamp)
+	return true
 }
 
 func (r *InverseSeq) Eval(clock int64) (hits Hits) {
@@ -195,7 +211,10 @@ func (r *InverseSeq) Eval(clock int64) (hits Hits) {
 		return
 	}
 	r.clock = clock
-	return r._eval(clock)
+	if hits = r._eval(clock); hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
 }
 
 func (r *InverseSeq) _eval(clock int64) (hits Hits) {
@@ -212,14 +231,17 @@ func (r *InverseSeq) _eval(clock int64) (hits Hits) {
 
 		if tStop-tStart > r.window {
 			drop.term = 0
+			r.trace(TraceT{Kind: TraceWindow, Clock: clock, Term: 0, Reset: -1, Stamp: tStart})
 		} else if r.resets != nil {
 			anchor := r.checkReset(clock)
 
 			switch {
 			case anchor.ValidTerm():
 				drop = anchor
+				r.trace(TraceT{Kind: TraceReset, Clock: clock, Term: anchor.term, Reset: anchor.reset, Stamp: anchor.stamp})
 			case anchor.clock > 0:
 				// We have a match that is too recent; we must wait.
+				r.traceWait(clock, clock+anchor.clock)
 				return
 			}
 		}
@@ -230,7 +252,14 @@ func (r *InverseSeq) _eval(clock int64) (hits Hits) {
 			shiftAnchor(r.terms, drop)
 		} else {
 			// Fire hit and prune asserts
+			if r.resets != nil {
+				SetMeta(&hits, hits.Cnt, resetMeta(r.resets, gatherAnchors(r.terms, r.dupeMap), clock))
+			}
+
+			r.nHits += 1
+			r.trace(TraceT{Kind: TraceFire, Clock: clock, Term: -1, Reset: -1})
 			hits.Cnt += 1
+			hits.FireStamp = clock
 			if hits.Logs == nil {
 				hits.Logs = make([]LogEntry, 0, nTerms+r.dupeMap[-1])
 			}
@@ -252,6 +281,16 @@ func (r *InverseSeq) _eval(clock int64) (hits Hits) {
 	return
 }
 
+// Stats returns the matcher counters.
+func (r *InverseSeq) Stats() StatsT {
+	return r.stats(r.terms, &r.lateT)
+}
+
+// Coverage returns the range from the oldest retained assert or reset to the clock.
+func (r *InverseSeq) Coverage() (oldest, newest int64) {
+	return calcCoverage(r.clock, r.terms, r.resets)
+}
+
 func (r *InverseSeq) maybeGC(clock int64) {
 
 	if clock < r.gcMark {
@@ -264,6 +303,8 @@ func (r *InverseSeq) maybeGC(clock int64) {
 // Remove all terms that are older than the window.
 func (r *InverseSeq) GarbageCollect(clock int64) {
 
+	r.gcClock = clock
+
 	// Special case;
 	// If all the terms are hot and we have resets,
 	// allow the GC to be handled on the next evaluation.
@@ -274,22 +315,14 @@ func (r *InverseSeq) GarbageCollect(clock int64) {
 	}
 
 	var (
-		cnt      int
 		nMark    = disableGC
 		m        = r.terms[0].asserts
 		deadline = clock - r.gcRight
+		cnt      = countBefore(m, deadline)
 	)
 
-	// Find the first term that is not older than the window.
-	// Binary search?
-	for _, term := range m {
-		if term.Timestamp >= deadline {
-			break
-		}
-		cnt += 1
-	}
-
 	if cnt > 0 {
+		r.trace(TraceT{Kind: TraceExpire, Clock: clock, Term: 0, Reset: -1, Stamp: m[0].Timestamp, Count: cnt})
 		shiftLeft(r.terms, 0, cnt)
 	}
 
@@ -300,34 +333,7 @@ func (r *InverseSeq) GarbageCollect(clock int64) {
 	}
 
 	// Adjust the deadline for the reset terms
-	deadline -= r.gcLeft
-
-	// Clean up the reset terms
-	for i, reset := range r.resets {
-
-		var (
-			m = reset.resets
-		)
-
-		if len(m) == 0 {
-			continue
-		}
-
-		cnt, _ := slices.BinarySearch(m, deadline)
-
-		if cnt > 0 {
-			r.resets[i].resets = m[cnt:]
-		}
-
-		if len(r.resets[i].resets) > 0 {
-			v := r.resets[i].resets[0] + r.gcLeft + r.gcRight
-			if v < nMark {
-				nMark = v
-			}
-		}
-	}
-
-	r.gcMark = nMark
+	r.gcMark = gcResets(r.resets, deadline-r.gcLeft, r.gcLeft+r.gcRight, nMark)
 }
 
 // Find the first term in the sequence.
@@ -345,41 +351,37 @@ func (r *InverseSeq) miniGC() {
 	var (
 		nActive    int
 		dupeCnt    = r.dupeMap[0]
-		zeroMatch  = r.terms[0].asserts[0].Timestamp
+		zeroMatch  int64
 		forceClear bool
 	)
 
+	// Each term must follow the last dupe of the previous term in the frame.
+	// Asserts on dupes may be dropped out of order by a reset anchored on a
+	// dupe, so a later dupe may follow asserts already made on the next term.
 	if nZeroAsserts > dupeCnt {
 		nActive = 1
+		zeroMatch = r.terms[0].asserts[dupeCnt].Timestamp
 	} else {
 		forceClear = true
 	}
 
-	// For remaining active terms, find the first term that is not older than the window.
-	for i := 1; i < r.nActive; i++ {
+	// For remaining active terms, and any partial dupes of the next, find the
+	// first term that is not older than the window.
+	for i := 1; i < min(r.nActive+1, len(r.terms)); i++ {
 
 		if forceClear {
 			resetTerm(r.terms, i)
 			continue
 		}
 
-		cnt := 0
-		for _, t := range r.terms[i].asserts {
-			if t.Timestamp < zeroMatch {
-				cnt += 1
-			} else {
-				zeroMatch = t.Timestamp
-				break
-			}
-		}
-
-		if cnt > 0 {
+		if cnt := countBefore(r.terms[i].asserts, zeroMatch); cnt > 0 {
 			shiftLeft(r.terms, i, cnt)
 		}
 
 		dupeCnt := r.dupeMap[i]
 		if len(r.terms[i].asserts) > dupeCnt {
 			nActive++
+			zeroMatch = r.terms[i].asserts[dupeCnt].Timestamp
 		} else {
 			forceClear = true
 		}
@@ -397,49 +399,7 @@ func (r *InverseSeq) reset() {
 }
 
 func (r *InverseSeq) checkReset(clock int64) anchorT {
-
-	var (
-		nTerms  = len(r.terms)
-		nDupes  = r.dupeMap[-1]
-		anchors = make([]anchorT, 0, nTerms+nDupes)
-	)
-
-	// Gather timestamps from match
-	for i, term := range r.terms {
-		cnt := r.dupeMap[i] + 1
-		for j := range cnt {
-			anchors = append(anchors, anchorT{
-				clock:  term.asserts[j].Timestamp,
-				term:   i,
-				offset: j,
-			})
-		}
-	}
-
-	// Iterate across the resets; determine if we have a negative match.
-	for i, reset := range r.resets {
-		start, stop := reset.calcWindowA(anchors)
-
-		// Check if we have a negative term in the reset window.
-		// TODO: Binary search?
-		for _, ts := range r.resets[i].resets {
-			if ts >= start && ts <= stop {
-				return anchors[reset.anchor]
-			}
-		}
-
-		// If the reset window is in the future, we cannot come to a conclusion.
-		// We must wait until the reset window is in the past due to events with
-		// duplicate timestamps.  Thus must wait until one tick past the reset window.
-		if stop >= clock {
-			return anchorT{
-				term:  -1,
-				clock: stop - clock + 1,
-			}
-		}
-	}
-
-	return anchorT{term: -1}
+	return evalResets(r.resets, gatherAnchors(r.terms, r.dupeMap), clock)
 }
 
 func (r *InverseSeq) resetGcMark(nMark int64) {
@@ -1,6 +1,7 @@
 package match
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -584,6 +585,165 @@ func NewCasesSeqResets() casesT {
 	}
 }
 
+func NewCasesSeqDupeAnchors() casesT {
+	return casesT{
+
+		"Hit": {
+			// -12-------------- alpha
+			// ---3------------- reset
+			// ----4------------ beta
+			// The reset is in the window of the second alpha, which is dropped.
+			window: 10,
+			terms:  []string{"alpha", "alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("reset"),
+				Window:   3,
+				Absolute: true,
+				Anchor:   1,
+			}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "reset"},
+				{line: "beta"},
+				{line: "alpha", stamp: 6},
+				{line: "beta", stamp: 7},
+				{line: "NOOP", stamp: 10, cb: matchStamps(1, 6, 7)},
+			},
+		},
+
+		"Miss": {
+			// -1-3------------- alpha
+			// --2-------------- reset
+			// ----4------------ beta
+			// The reset precedes the window of the second alpha.
+			window: 10,
+			terms:  []string{"alpha", "alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("reset"),
+				Window:   3,
+				Absolute: true,
+				Anchor:   1,
+			}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "reset"},
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "NOOP", stamp: 10, cb: matchStamps(1, 3, 4)},
+			},
+		},
+
+		"LaterDupe": {
+			// -12-4------------ alpha
+			// ---3-6----------- beta
+			// -----5----------- reset
+			// Dropping the second alpha promotes the fourth, which follows
+			// the first beta; that beta is out of order and also dropped.
+			window: 10,
+			terms:  []string{"alpha", "alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("reset"),
+				Window:   1,
+				Slide:    3,
+				Absolute: true,
+				Anchor:   1,
+			}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "alpha"},
+				{line: "reset"},
+				{line: "beta"},
+				{line: "NOOP", stamp: 10, cb: matchStamps(1, 4, 6)},
+			},
+		},
+
+		"LastTerm": {
+			// -1--------------- alpha
+			// --23-5----------- beta
+			// ----4------------ reset
+			window: 10,
+			terms:  []string{"alpha", "beta", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("reset"),
+				Window:   2,
+				Absolute: true,
+				Anchor:   2,
+			}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "beta"},
+				{line: "reset"},
+				{line: "beta"},
+				{line: "NOOP", stamp: 10, cb: matchStamps(1, 2, 5)},
+			},
+		},
+	}
+}
+
+func NewCasesSeqAnchorRange() casesT {
+	// The reset window spans the second through third terms.
+	reset := []ResetT{{
+		Term:      makeRaw("reset"),
+		Anchor:    1,
+		AnchorEnd: 2,
+	}}
+
+	return casesT{
+
+		"Inside": {
+			// -1---------- alpha
+			// --2--------- beta
+			// ---3-------- reset
+			// ----4------- gamma
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma", "delta"},
+			reset:  reset,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "reset"},
+				{line: "gamma"},
+				{line: "delta"},
+				{line: "NOOP", stamp: 20},
+			},
+		},
+
+		"Before": {
+			// -1---------- alpha
+			// --2--------- reset
+			// ---3-------- beta
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma", "delta"},
+			reset:  reset,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "reset"},
+				{line: "beta"},
+				{line: "gamma"},
+				{line: "delta", cb: matchStamps(1, 3, 4, 5)},
+			},
+		},
+
+		"After": {
+			// The range ends on gamma; a reset before delta is fine.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma", "delta"},
+			reset:  reset,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "gamma"},
+				{line: "reset"},
+				{line: "delta", cb: matchStamps(1, 2, 3, 5)},
+			},
+		},
+	}
+}
+
 func TestInverseSeq(t *testing.T) {
 
 	cases := map[string]struct {
@@ -601,19 +761,224 @@ func TestInverseSeq(t *testing.T) {
 		"Resets": {
 			cases: NewCasesSeqResets(),
 		},
+		"DupeAnchors": {
+			cases: NewCasesSeqDupeAnchors(),
+		},
+		"AnchorRange": {
+			cases: NewCasesSeqAnchorRange(),
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 
 			tc.cases.run(t, func(tc caseT) (Matcher, error) {
-				return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+				return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset, tc.opts...)
 			})
 
 		})
 	}
 }
 
+func NewCasesSeqPrecedence() casesT {
+	// The line "beta boom" matches both the final term and the reset term.
+	resets := []ResetT{{Term: makeRaw("boom")}}
+
+	return casesT{
+		"Both": {
+			// Line is recorded as a reset and completes the sequence.
+			// The reset lands in the match window and invalidates it.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  resets,
+			opts:   []OptT{WithPrecedence(PrecedenceBoth)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta boom", postF: checkResets(0, 1)},
+				{postF: checkActive(0)},
+			},
+		},
+		"Default": {
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  resets,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta boom", postF: checkResets(0, 1)},
+				{postF: checkActive(0)},
+			},
+		},
+		"Reset": {
+			// Line is only a reset; the sequence does not advance.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  resets,
+			opts:   []OptT{WithPrecedence(PrecedenceReset)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta boom", postF: checkResets(0, 1)},
+				{postF: checkActive(1)},
+				{line: "beta"}, // Reset at 2 lands in window [1,4]
+				{postF: checkActive(0)},
+			},
+		},
+		"Term": {
+			// Line is only a term; the reset is not recorded and the sequence fires.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  resets,
+			opts:   []OptT{WithPrecedence(PrecedenceTerm)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta boom", postF: checkResets(0, 0)},
+				{line: "boom", cb: matchStamps(1, 2), postF: checkResets(0, 1)}, // Reset after window.
+			},
+		},
+		"TermInactive": {
+			// Line matches an inactive term; it counts as a reset.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  resets,
+			opts:   []OptT{WithPrecedence(PrecedenceTerm)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha boom", postF: checkResets(0, 0)}, // Active term 0 matched
+				{line: "noop"},
+			},
+		},
+	}
+}
+
+func TestInverseSeqPrecedence(t *testing.T) {
+	NewCasesSeqPrecedence().run(t, func(tc caseT) (Matcher, error) {
+		return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset, tc.opts...)
+	})
+}
+
+func NewCasesSeqBetween() casesT {
+	// alpha, then beta with no "boom" between them, then gamma.
+	between := []OptT{WithBetween(1, makeRaw("boom"))}
+
+	return casesT{
+		"Clean": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   between,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(1, 2, 3)},
+			},
+		},
+		"Interrupted": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   between,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "boom"},
+				{line: "beta"},
+				{line: "gamma"},
+				{postF: checkActive(0)},
+			},
+		},
+		"OtherGap": {
+			// Only the alpha..beta gap is guarded.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   between,
+			steps: []stepT{
+				{line: "boom"},
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "boom"},
+				{line: "gamma", cb: matchStamps(2, 3, 5)},
+			},
+		},
+		"OnlyPartialDropped": {
+			// The interrupted alpha is dropped; a later alpha completes.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   between,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "boom"},
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(3, 4, 5)},
+			},
+		},
+		"LaterBeta": {
+			// beta at 2 is interrupted; beta at 4 follows the boom so is clean.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   []OptT{WithBetween(2, makeRaw("boom"))},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "boom"},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(1, 4, 5)},
+			},
+		},
+		"SameStamp": {
+			// A negative term sharing a stamp with a gap end is not between.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			opts:   between,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 1, line: "boom"},
+				{stamp: 2, line: "boom"},
+				{stamp: 2, line: "beta", cb: matchStamps(1, 2)},
+			},
+		},
+		"Dupes": {
+			window: 10,
+			terms:  []string{"alpha", "alpha", "beta"},
+			opts:   []OptT{WithBetween(2, makeRaw("boom"))},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "boom"},
+				{line: "alpha"},
+				{line: "beta", cb: matchStamps(2, 4, 5)},
+			},
+		},
+		"WithinDupes": {
+			window: 10,
+			terms:  []string{"alpha", "alpha", "beta"},
+			opts:   between,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "boom"},
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "beta", cb: matchStamps(3, 4, 5)},
+			},
+		},
+		"WithReset": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			reset:  []ResetT{{Term: makeRaw("fatal")}},
+			opts:   between,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "fatal"},
+				{line: "gamma"},
+				{postF: checkActive(0)},
+			},
+		},
+	}
+}
+
+func TestInverseSeqBetween(t *testing.T) {
+	NewCasesSeqBetween().run(t, func(tc caseT) (Matcher, error) {
+		return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset, tc.opts...)
+	})
+}
+
 func TestInverseSeqInitFail(t *testing.T) {
 
 	cases := map[string]struct {
@@ -621,6 +986,7 @@ func TestInverseSeqInitFail(t *testing.T) {
 		window int64
 		terms  []TermT
 		reset  []ResetT
+		opts   []OptT
 	}{
 		"NoTerms": {
 			err:    ErrNoTerms,
@@ -656,19 +1022,19 @@ func TestInverseSeqInitFail(t *testing.T) {
 		"AlmostTooManyTerms": {
 			err:    nil,
 			window: 10,
-			terms:  makeTermsN(maxTerms),
+			terms:  makeTermsN(MaxTerms),
 		},
 
 		"DupeShouldNotPushOverMax": {
 			err:    nil,
 			window: 10,
-			terms:  makeDupesN(maxTerms * 2),
+			terms:  makeDupesN(MaxTerms * 2),
 		},
 
 		"TooManyTerms": {
 			err:    ErrTooManyTerms,
 			window: 10,
-			terms:  makeTermsN(maxTerms + 1),
+			terms:  makeTermsN(MaxTerms + 1),
 		},
 
 		"ZeroAnchorOnDupeTerm": {
@@ -683,8 +1049,22 @@ func TestInverseSeqInitFail(t *testing.T) {
 			},
 		},
 
+		"AnchorEndRange": {
+			err:    ErrAnchorRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			reset:  []ResetT{{Term: makeRaw("reset"), AnchorEnd: 2}},
+		},
+
+		"AnchorEndBeforeAnchor": {
+			err:    ErrAnchorRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta", "gamma"),
+			reset:  []ResetT{{Term: makeRaw("reset"), Anchor: 2, AnchorEnd: 1}},
+		},
+
 		"NonZeroAnchorOnDupeTerm": {
-			err:    ErrAnchorNoDupes,
+			err:    nil,
 			window: 10,
 			terms:  makeTermsA("shrubbery", "alpha", "alpha"),
 			reset: []ResetT{
@@ -694,118 +1074,34 @@ func TestInverseSeqInitFail(t *testing.T) {
 				},
 			},
 		},
-	}
 
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			_, err := NewInverseSeq(tc.window, tc.terms, tc.reset)
-			if err != tc.err {
-				t.Fatalf("Expected err == %v, got %v", tc.err, err)
-			}
-		})
-	}
-}
-
-func TestMaybeAnchor(t *testing.T) {
-	tests := []struct {
-		name     string
-		nTerms   int
-		dupeMap  map[int]int
-		anchor   uint8
-		expected bool
-	}{
-		{
-			name:     "NilDupeMapShouldReturnTrue",
-			nTerms:   3,
-			dupeMap:  nil,
-			anchor:   1,
-			expected: true,
-		},
-		{
-			name:     "ZeroAnchorShouldReturnTrue",
-			nTerms:   3,
-			dupeMap:  map[int]int{0: 2, 1: 1},
-			anchor:   0,
-			expected: true,
-		},
-		{
-			name:     "AnchorBeforeDupesShouldReturnTrue",
-			nTerms:   4,
-			dupeMap:  map[int]int{2: 2}, // term 2 has 2 dupes
-			anchor:   1,                 // anchor on term 1, before dupes
-			expected: true,
-		},
-		{
-			name:     "AnchorOnFirstTermBeforeDupesShouldReturnTrue",
-			nTerms:   3,
-			dupeMap:  map[int]int{1: 2}, // term 1 has 2 dupes
-			anchor:   0,                 // anchor on term 0, before dupes
-			expected: true,
-		},
-		{
-			name:     "AnchorWithinDupesShouldReturnFalse",
-			nTerms:   4,
-			dupeMap:  map[int]int{1: 3}, // term 1 has 3 dupes (positions 1,2,3,4)
-			anchor:   2,                 // anchor within the dupe range
-			expected: false,
-		},
-		{
-			name:     "AnchorOnLastDupePositionShouldReturnFalse",
-			nTerms:   5,
-			dupeMap:  map[int]int{1: 2}, // term 1 has 2 dupes (positions 1,2,3)
-			anchor:   3,                 // anchor on last dupe position
-			expected: false,
-		},
-		{
-			name:     "AnchorAfterAllDupesShouldReturnTrue",
-			nTerms:   5,
-			dupeMap:  map[int]int{1: 2}, // term 1 has 2 dupes (positions 1,2,3)
-			anchor:   4,                 // anchor after all dupes
-			expected: true,
-		},
-		{
-			name:     "MultipleDupeGroupsAnchorBeforeAllShouldReturnTrue",
-			nTerms:   7,
-			dupeMap:  map[int]int{1: 2, 4: 1}, // term 1 has 2 dupes, term 4 has 1 dupe
-			anchor:   1,                       // anchor front first dupes
-			expected: true,
-		},
-		{
-			name:     "MultipleDupeGroupsAnchorInFirstGroupShouldReturnFalse",
-			nTerms:   7,
-			dupeMap:  map[int]int{1: 2, 5: 1}, // term 1 has 2 dupes (1,2,3), term 5 has 1 dupe (7,8)
-			anchor:   2,                       // anchor within first dupe group
-			expected: false,
-		},
-		{
-			name:     "MultipleDupeGroupsAnchorInSecondGroupShouldReturnFalse",
-			nTerms:   7,
-			dupeMap:  map[int]int{1: 2, 5: 1}, // term 1 has 2 dupes (1,2,3), term 5 has 1 dupe (7,8)
-			anchor:   8,                       // anchor within second dupe group
-			expected: false,
+		"BetweenFirstTerm": {
+			err:    ErrBetweenRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithBetween(0, makeRaw("boom"))},
 		},
-		{
-			name:     "MultipleDupeGroupsAnchorBetweenGroupsShouldReturnTrue",
-			nTerms:   7,
-			dupeMap:  map[int]int{1: 2, 5: 1}, // term 1 has 2 dupes (1,2,3), term 5 has 1 dupe (7,8)
-			anchor:   4,                       // anchor between dupe groups
-			expected: true,
+
+		"BetweenPastLastTerm": {
+			err:    ErrBetweenRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithBetween(2, makeRaw("boom"))},
 		},
-		{
-			name:     "LargeAnchorAfterAllTermsShouldReturnFalse",
-			nTerms:   3,
-			dupeMap:  map[int]int{1: 1},
-			anchor:   10, // anchor way beyond all terms
-			expected: false,
+
+		"EmptyBetweenTerm": {
+			err:    ErrTermEmpty,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithBetween(1, TermT{Type: TermRaw})},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := maybeAnchor(tt.nTerms, tt.dupeMap, tt.anchor)
-			if result != tt.expected {
-				t.Errorf("maybeAnchor(%d, %v, %d) = %v, expected %v",
-					tt.nTerms, tt.dupeMap, tt.anchor, result, tt.expected)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewInverseSeq(tc.window, tc.terms, tc.reset, tc.opts...)
+			if err != tc.err {
+				t.Fatalf("Expected err == %v, got %v", tc.err, err)
 			}
 		})
 	}
@@ -1034,3 +1330,64 @@ func BenchmarkSeqInverseDupesWithResets(b *testing.B) {
 
 	}
 }
+
+func TestInverseSeqCountAnchors(t *testing.T) {
+	terms := []TermT{{Type: TermRaw, Value: "alpha", Count: 2}, makeRaw("beta")}
+
+	// Anchors include counts; beta is anchor 2.
+	if _, err := NewInverseSeq(10, terms, []ResetT{{Term: makeRaw("reset"), Anchor: 2}}); err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+	if _, err := NewInverseSeq(10, terms, []ResetT{{Term: makeRaw("reset"), Anchor: 3}}); err != ErrAnchorRange {
+		t.Errorf("Expected ErrAnchorRange, got %v", err)
+	}
+}
+
+func TestInverseSeqManyTerms(t *testing.T) {
+	// Term positions past 255 must not wrap.
+	const nTerms = 300
+
+	terms := make([]string, nTerms)
+	for i := range terms {
+		terms[i] = fmt.Sprintf("t%03d", i)
+	}
+
+	// Scan the terms, with extra after term pos.
+	run := func(pos int, extra string, opts ...OptT) int {
+		m, err := NewInverseSeq(1000, makeTermsA(terms...), []ResetT{{Term: makeRaw("late"), Anchor: 290, Window: 5, Absolute: true}}, opts...)
+		if err != nil {
+			t.Fatalf("Expected nil error, got: %v", err)
+		}
+
+		var (
+			sl    = NewScanLine()
+			hits  Hits
+			clock int64
+		)
+		for i, line := range terms {
+			clock++
+			hits.Append(m.Scan(sl.ResetLine(clock, line)))
+			if i == pos {
+				clock++
+				hits.Append(m.Scan(sl.ResetLine(clock, extra)))
+			}
+		}
+		hits.Append(m.Eval(clock + 10))
+		return hits.Cnt
+	}
+
+	between := WithBetween(280, makeRaw("bad"))
+
+	if cnt := run(23, "bad", between); cnt != 1 {
+		t.Errorf("Expected 1 hit on a clean gap, got %d", cnt)
+	}
+	if cnt := run(279, "bad", between); cnt != 0 {
+		t.Errorf("Expected no hits on an interrupted gap, got %d", cnt)
+	}
+	if cnt := run(34, "late"); cnt != 1 {
+		t.Errorf("Expected 1 hit on a reset outside the window, got %d", cnt)
+	}
+	if cnt := run(291, "late"); cnt != 0 {
+		t.Errorf("Expected no hits on a reset within the window, got %d", cnt)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"cmp"
 	"math"
 	"slices"
-
-	"github.com/rs/zerolog/log"
 )
 
 type InverseSet struct {
@@ -18,60 +16,61 @@ type InverseSet struct {
 	terms   []termT
 	resets  []resetT
 	dupeMap map[int]int
+	ordered []int // Term indices that must match in order; see WithOrdered.
+
+	statsT
+	lateT
+	explainT
+	budgetT
 }
 
-func NewInverseSet(window int64, setTerms []TermT, resetTerms []ResetT) (*InverseSet, error) {
+func NewInverseSet(window int64, setTerms []TermT, resetTerms []ResetT, opts ...OptT) (*InverseSet, error) {
+
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optExplain | optMaxBuffered | optOrdered | optResets); err != nil {
+		return nil, err
+	}
 
 	terms, dupeMap, err := buildSetTerms(setTerms...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Init reset terms
-	var resets []resetT
-	if len(resetTerms) > 0 {
-		resets = make([]resetT, 0, len(resetTerms))
-
-		for _, term := range resetTerms {
-			m, err := term.Term.NewMatcher()
-			switch {
-			case err != nil:
-				return nil, err
-			case int(term.Anchor) >= len(setTerms): // This includes dupes.
-				return nil, ErrAnchorRange
-			}
+	ordered, err := buildOrdered(setTerms, o.ordered, dupeMap)
+	if err != nil {
+		return nil, err
+	}
 
-			resets = append(resets, resetT{
-				matcher:  m,
-				window:   term.Window,
-				slide:    term.Slide,
-				anchor:   term.Anchor,
-				absolute: term.Absolute,
-			})
-		}
+	// Init reset terms; anchor range includes dupes.
+	resets, err := buildResets(append(slices.Clip(resetTerms), o.resets...), countAnchors(setTerms))
+	if err != nil {
+		return nil, err
 	}
+
 	// Calculate GC windows
 	gcLeft, gcRight := calcGCWindow(window, resets)
 
 	return &InverseSet{
-		window:  window,
-		gcLeft:  gcLeft,
-		gcRight: gcRight,
-		gcMark:  disableGC,
-		terms:   terms,
-		resets:  resets,
-		dupeMap: dupeMap,
+		window:   window,
+		gcLeft:   gcLeft,
+		gcRight:  gcRight,
+		gcMark:   disableGC,
+		terms:    terms,
+		resets:   resets,
+		dupeMap:  dupeMap,
+		ordered:  ordered,
+		explainT: explainT{limit: o.explain},
+		budgetT:  budgetT{maxBuffered: o.maxBuffered},
+		statsT:   newStats(o),
 	}, nil
 }
 
 func (r *InverseSet) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
 	if e.Timestamp < r.clock {
-		log.Warn().
-			Str("line", e.Line).
-			Int64("stamp", e.Timestamp).
-			Int64("clock", r.clock).
-			Msg("InverseSet: Out of order event.")
-		return
+		if e = r.late(&r.identT, "InverseSet", e, r.clock); e == nil {
+			return
+		}
 	}
 	r.clock = e.Timestamp
 
@@ -82,7 +81,7 @@ func (r *InverseSet) Scan(e *ScanLine) (hits Hits) {
 	for i, term := range r.terms {
 		if term.matcher(e) {
 			// Append the match to the assert list
-			r.terms[i].asserts = append(r.terms[i].asserts, e.LogEntry)
+			r.terms[i].assert(e.Entry())
 
 			// If not a dupe or we've hit the dupe count, set the hot mask
 			if dupeCnt := r.dupeMap[i]; len(r.terms[i].asserts) > dupeCnt {
@@ -94,24 +93,32 @@ func (r *InverseSet) Scan(e *ScanLine) (hits Hits) {
 		}
 	}
 
+	// Evict over the WithMaxBuffered bound.  Eviction leaves a term at or
+	// above its dupe count; the hot mask holds.
+	r.nEvicted += uint64(r.trimTerms(r.terms, r.dupeMap))
+
 	if r.hotMask.Zeros() && r.gcLeft == 0 {
 		// Nothing HOT and no point running resets.
 		return
 	}
 
 	// Run resets
-	for i, reset := range r.resets {
-		if reset.matcher(e) {
-			r.resets[i].resets = append(reset.resets, e.Timestamp)
+	for i := range r.resets {
+		if r.resets[i].record(e) {
+			r.nResets += 1
 			r.resetGcMark(e.Timestamp + r.gcLeft + r.gcRight)
 		}
 	}
+	r.nEvicted += uint64(r.trimResets(r.resets))
 
 	if !r.hotMask.FirstN(len(r.terms)) {
 		return // no match
 	}
 
-	return r._eval(e.Timestamp)
+	if hits = r._eval(e.Timestamp); hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
 }
 
 // Assert clock, may used to close out matcher
@@ -122,7 +129,10 @@ func (r *InverseSet) Eval(clock int64) (hits Hits) {
 		return
 	}
 	r.clock = clock
-	return r._eval(clock)
+	if hits = r._eval(clock); hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
 }
 
 func (r *InverseSet) _eval(clock int64) (hits Hits) {
@@ -138,14 +148,20 @@ func (r *InverseSet) _eval(clock int64) (hits Hits) {
 
 		if tStop-tStart > r.window {
 			drop.term = mIdx
+			r.trace(TraceT{Kind: TraceWindow, Clock: clock, Term: mIdx, Reset: -1, Stamp: tStart})
+		} else if oIdx := r.checkOrder(); oIdx >= 0 {
+			drop.term = oIdx
+			r.trace(TraceT{Kind: TraceOrder, Clock: clock, Term: oIdx, Reset: -1, Stamp: r.terms[oIdx].asserts[0].Timestamp})
 		} else if r.resets != nil {
 			anchor := r.checkReset(clock)
 
 			switch {
 			case anchor.ValidTerm():
 				drop = anchor
+				r.trace(TraceT{Kind: TraceReset, Clock: clock, Term: anchor.term, Reset: anchor.reset, Stamp: anchor.stamp})
 			case anchor.clock > 0:
 				// We have a match that is too recent; we must wait.
+				r.traceWait(clock, clock+anchor.clock)
 				return
 			}
 		}
@@ -163,7 +179,14 @@ func (r *InverseSet) _eval(clock int64) (hits Hits) {
 
 		} else {
 			// Fire hit and prune first assert from each term.
+			if r.resets != nil {
+				SetMeta(&hits, hits.Cnt, resetMeta(r.resets, r.sortedAnchors(), clock))
+			}
+
+			r.nHits += 1
+			r.trace(TraceT{Kind: TraceFire, Clock: clock, Term: -1, Reset: -1})
 			hits.Cnt += 1
+			hits.FireStamp = clock
 			if hits.Logs == nil {
 				hits.Logs = make([]LogEntry, 0, nTerms)
 			}
@@ -181,57 +204,31 @@ func (r *InverseSet) _eval(clock int64) (hits Hits) {
 	return
 }
 
-func (r *InverseSet) checkReset(clock int64) anchorT {
+// Stats returns the matcher counters.
+func (r *InverseSet) Stats() StatsT {
+	return r.stats(r.terms, &r.lateT)
+}
 
-	var (
-		nTerms  = len(r.terms)
-		nDupes  = r.dupeMap[-1]
-		anchors = make([]anchorT, 0, nTerms+nDupes)
-	)
+// Coverage returns the range from the oldest retained assert or reset to the clock.
+func (r *InverseSet) Coverage() (oldest, newest int64) {
+	return calcCoverage(r.clock, r.terms, r.resets)
+}
 
-	// Gather timestamps from match
-	for i, term := range r.terms {
-		cnt := r.dupeMap[i] + 1
-		for j := range cnt {
-			anchors = append(anchors, anchorT{
-				clock:  term.asserts[j].Timestamp,
-				term:   i,
-				offset: j,
-			})
-		}
-	}
+func (r *InverseSet) checkReset(clock int64) anchorT {
+	return evalResets(r.resets, r.sortedAnchors(), clock)
+}
+
+// Sort the anchors so that the anchors are relative to the sorted sequence.
+// If we do not sort, the anchor is relative to the original term, which
+// may be desirable, but is not the usual intent for an anchor.
+func (r *InverseSet) sortedAnchors() []anchorT {
+	anchors := gatherAnchors(r.terms, r.dupeMap)
 
-	// Sort the anchors so that the anchors are relative to the sorted sequence.
-	// If we do not sort, the anchor is relative to the original term, which
-	// may be desirable, but is not the usual intent for an anchor.
 	slices.SortFunc(anchors, func(a, b anchorT) int {
 		return cmp.Compare(a.clock, b.clock)
 	})
 
-	// Iterate across the resets; determine if we have a negative match.
-	for _, reset := range r.resets {
-		start, stop := reset.calcWindowA(anchors)
-
-		// Check if we have a negative term in the reset window.
-		// TODO: Binary search?
-		for _, ts := range reset.resets {
-			if ts >= start && ts <= stop {
-				return anchors[reset.anchor]
-			}
-		}
-
-		// If the reset window is in the future, we cannot come to a conclusion.
-		// We must wait until the reset window is in the past due to events with
-		// duplicate timestamps.  Thus must wait until one tick past the reset window.
-		if stop >= clock {
-			return anchorT{
-				term:  -1,
-				clock: stop - clock + 1,
-			}
-		}
-	}
-
-	return anchorT{term: -1}
+	return anchors
 }
 
 // Assumes we are hot; determine the start, stop time of the match.
@@ -294,6 +291,8 @@ func (r *InverseSet) maybeGC(clock int64) {
 // Remove all terms that are older than the window.
 func (r *InverseSet) GarbageCollect(clock int64) {
 
+	r.gcClock = clock
+
 	// Special case;
 	// If all the terms are hot and we have resets,
 	// allow the GC to be handled on the next evaluation.
@@ -310,20 +309,9 @@ func (r *InverseSet) GarbageCollect(clock int64) {
 
 	for i, term := range r.terms {
 
-		var (
-			cnt int
-		)
-
 		// Find the first term that is not older than the window.
-		// Binary search?
-		for _, term := range term.asserts {
-			if term.Timestamp >= deadline {
-				break
-			}
-			cnt += 1
-		}
-
-		if cnt > 0 {
+		if cnt := countBefore(term.asserts, deadline); cnt > 0 {
+			r.trace(TraceT{Kind: TraceExpire, Clock: clock, Term: i, Reset: -1, Stamp: term.asserts[0].Timestamp, Count: cnt})
 			if shiftLeft(r.terms, i, cnt) == 0 {
 				r.hotMask.Clr(i)
 			}
@@ -337,34 +325,7 @@ func (r *InverseSet) GarbageCollect(clock int64) {
 	}
 
 	// Adjust the deadline for the reset terms
-	deadline -= r.gcLeft
-
-	// Clean up the reset terms
-	for i, reset := range r.resets {
-
-		var (
-			m = reset.resets
-		)
-
-		if len(m) == 0 {
-			continue
-		}
-
-		cnt, _ := slices.BinarySearch(m, deadline)
-
-		if cnt > 0 {
-			r.resets[i].resets = m[cnt:]
-		}
-
-		if len(r.resets[i].resets) > 0 {
-			v := r.resets[i].resets[0] + r.gcLeft + r.gcRight
-			if v < nMark {
-				nMark = v
-			}
-		}
-	}
-
-	r.gcMark = nMark
+	r.gcMark = gcResets(r.resets, deadline-r.gcLeft, r.gcLeft+r.gcRight, nMark)
 }
 
 func (r *InverseSet) resetGcMark(nMark int64) {
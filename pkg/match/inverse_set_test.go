@@ -673,6 +673,99 @@ func TestInverseSets(t *testing.T) {
 	}
 }
 
+func NewCasesSetOrdered() casesT {
+	opts := []OptT{WithOrdered(0, 1)}
+
+	return casesT{
+		"InOrder": {
+			window: 10,
+			terms:  []string{"cause", "effect", "signal"},
+			opts:   opts,
+			steps: []stepT{
+				{line: "cause"},
+				{line: "signal"},
+				{line: "effect", cb: matchStamps(1, 3, 2)},
+			},
+		},
+		"OutOfOrder": {
+			window: 10,
+			terms:  []string{"cause", "effect", "signal"},
+			opts:   opts,
+			steps: []stepT{
+				{line: "effect"},
+				{line: "signal"},
+				{line: "cause"},
+				{line: "effect", cb: matchStamps(3, 4, 2)},
+			},
+		},
+		"SameStamp": {
+			window: 10,
+			terms:  []string{"cause", "effect", "signal"},
+			opts:   opts,
+			steps: []stepT{
+				{line: "effect", stamp: 5},
+				{line: "cause", stamp: 5},
+				{line: "signal", cb: matchStamps(5, 5, 6)},
+			},
+		},
+		"Unordered": {
+			window: 10,
+			terms:  []string{"cause", "effect", "signal"},
+			opts:   opts,
+			steps: []stepT{
+				{line: "signal"},
+				{line: "cause"},
+				{line: "effect", cb: matchStamps(2, 3, 1)},
+			},
+		},
+		"Chain": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   []OptT{WithOrdered(2, 0)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "gamma"},
+				{line: "alpha", cb: matchStamps(4, 2, 3)},
+			},
+		},
+		"Window": {
+			window: 5,
+			terms:  []string{"cause", "effect", "signal"},
+			opts:   opts,
+			steps: []stepT{
+				{line: "cause", stamp: 1},
+				{line: "effect", stamp: 2},
+				{line: "signal", stamp: 10},
+				{line: "cause", stamp: 11},
+				{line: "effect", stamp: 12, cb: matchStamps(11, 12, 10)},
+			},
+		},
+	}
+}
+
+func TestInverseSetOrdered(t *testing.T) {
+	NewCasesSetOrdered().run(t, func(tc caseT) (Matcher, error) {
+		return NewInverseSet(tc.window, makeTerms(tc.terms), tc.reset, tc.opts...)
+	})
+}
+
+func TestInverseSetOrderedTrace(t *testing.T) {
+	sm, err := NewInverseSet(10, makeTermsA("cause", "effect"), nil, WithOrdered(0, 1), WithExplain(4))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	sm.Scan(sl.ResetLine(1, "effect"))
+	sm.Scan(sl.ResetLine(2, "cause"))
+
+	traces := sm.Explain()
+	if len(traces) != 1 || traces[0].Kind != TraceOrder || traces[0].Term != 1 || traces[0].Stamp != 1 {
+		t.Errorf("Expected order trace on term 1 at 1, got %v", traces)
+	}
+}
+
 func TestInverseSetInitFail(t *testing.T) {
 
 	cases := map[string]struct {
@@ -680,6 +773,7 @@ func TestInverseSetInitFail(t *testing.T) {
 		window int64
 		terms  []TermT
 		reset  []ResetT
+		opts   []OptT
 	}{
 		"NoTerms": {
 			err:    ErrNoTerms,
@@ -715,25 +809,46 @@ func TestInverseSetInitFail(t *testing.T) {
 		"AlmostTooManyTerms": {
 			err:    nil,
 			window: 10,
-			terms:  makeTermsN(maxTerms),
+			terms:  makeTermsN(MaxTerms),
 		},
 
 		"DupeShouldNotPushOverMax": {
 			err:    nil,
 			window: 10,
-			terms:  makeDupesN(maxTerms * 2),
+			terms:  makeDupesN(MaxTerms * 2),
 		},
 
 		"TooManyTerms": {
 			err:    ErrTooManyTerms,
 			window: 10,
-			terms:  makeTermsN(maxTerms + 1),
+			terms:  makeTermsN(MaxTerms + 1),
+		},
+
+		"OrderedRange": {
+			err:    ErrOrdered,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithOrdered(0, 2)},
+		},
+
+		"OrderedDupe": {
+			err:    ErrOrdered,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta", "alpha"),
+			opts:   []OptT{WithOrdered(0, 1)},
+		},
+
+		"OrderedRepeat": {
+			err:    ErrOrdered,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithOrdered(0, 1, 0)},
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			_, err := NewInverseSet(tc.window, tc.terms, tc.reset)
+			_, err := NewInverseSet(tc.window, tc.terms, tc.reset, tc.opts...)
 			if err != tc.err {
 				t.Fatalf("Expected err == %v, got %v", tc.err, err)
 			}
@@ -17,6 +17,7 @@ type caseT struct {
 	window int64
 	terms  []string
 	reset  []ResetT
+	opts   []OptT
 	steps  []stepT
 }
 
@@ -106,6 +107,16 @@ func matchLinesN(cnt int, lines ...string) func(*testing.T, int, Hits) {
 	}
 }
 
+func checkFireStamp(stamp int64, cb func(*testing.T, int, Hits)) func(*testing.T, int, Hits) {
+	return func(t *testing.T, step int, hits Hits) {
+		t.Helper()
+		cb(t, step, hits)
+		if hits.FireStamp != stamp {
+			t.Errorf("Step %v: Expected fire stamp %v, got %v", step, stamp, hits.FireStamp)
+		}
+	}
+}
+
 func checkActive(nActive int) func(*testing.T, int, Matcher) {
 	return func(t *testing.T, step int, sm Matcher) {
 		t.Helper()
@@ -138,8 +149,8 @@ func checkHotMask(mask int64) func(*testing.T, int, Matcher) {
 			panic("Invalid type")
 		}
 
-		if hotMask != bitMaskT(mask) {
-			t.Errorf("Step %v: Expected hotMask == %b, got %b", step, mask, hotMask)
+		if hotMask.lo != uint64(mask) || !(bitMaskT{hi: hotMask.hi}).Zeros() {
+			t.Errorf("Step %v: Expected hotMask == %b, got %b %b", step, mask, hotMask.lo, hotMask.hi)
 		}
 	}
 }
@@ -418,6 +429,38 @@ func TestResetTCalcWindowA(t *testing.T) {
 			wantFrom: 10,
 			wantTo:   10, // negative window becomes 0
 		},
+		{
+			name: "AnchorRange",
+			reset: resetT{
+				window: 2,
+				slide:  -1,
+				anchor: 1,
+				end:    3,
+			},
+			anchors: []anchorT{
+				{clock: 1, term: 0, offset: 0},
+				{clock: 4, term: 1, offset: 0},
+				{clock: 6, term: 2, offset: 0},
+				{clock: 9, term: 3, offset: 0},
+			},
+			wantFrom: 3,  // 4 - 1
+			wantTo:   11, // 9 + 2
+		},
+		{
+			name: "AnchorRangeRelative",
+			reset: resetT{
+				anchor:   0,
+				end:      1,
+				absolute: false,
+			},
+			anchors: []anchorT{
+				{clock: 1, term: 0, offset: 0},
+				{clock: 4, term: 1, offset: 0},
+				{clock: 9, term: 2, offset: 0},
+			},
+			wantFrom: 1,
+			wantTo:   4, // Not extended by the match range.
+		},
 	}
 
 	for _, tt := range tests {
@@ -460,3 +503,216 @@ func TestResetTCalcWindowEdgeCases(t *testing.T) {
 		reset.calcWindowA(anchors)
 	})
 }
+
+// Reset cases with in-order input, where sequence and set semantics agree.
+func NewCasesResetShared() casesT {
+
+	return casesT{
+		"ResetInFrame": {
+			// A-R-B
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  []ResetT{{Term: makeRaw("boom")}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "boom"},
+				{line: "beta"},
+				{postF: checkEval(100, checkNoFire)},
+			},
+		},
+		"ResetAfterFrame": {
+			// A-B-N-R; relative window closes at B, fire on next tick.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset:  []ResetT{{Term: makeRaw("boom")}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "noop", cb: matchStamps(1, 2)},
+				{line: "boom"},
+			},
+		},
+		"AbsoluteLookback": {
+			// R--A-B; reset in the 5 ticks prior to A.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("boom"),
+				Window:   5,
+				Slide:    -5,
+				Absolute: true,
+			}},
+			steps: []stepT{
+				{line: "boom"},
+				{stamp: 4, line: "alpha"},
+				{stamp: 6, line: "beta"},
+				{postF: checkEval(100, checkNoFire)},
+			},
+		},
+		"AbsoluteLookbackMiss": {
+			// R------A-B; reset too early.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("boom"),
+				Window:   5,
+				Slide:    -5,
+				Absolute: true,
+			}},
+			steps: []stepT{
+				{line: "boom"},
+				{stamp: 8, line: "alpha"},
+				{stamp: 9, line: "beta", cb: matchStamps(8, 9)},
+			},
+		},
+		"AnchorOne": {
+			// A-B-R; reset within 5 ticks of B.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("boom"),
+				Window:   5,
+				Anchor:   1,
+				Absolute: true,
+			}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{stamp: 4, line: "boom"},
+				{postF: checkEval(100, checkNoFire)},
+			},
+		},
+		"AnchorOneExpired": {
+			// A-B-----N; reset window [2,7] passes without a reset.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("boom"),
+				Window:   5,
+				Anchor:   1,
+				Absolute: true,
+			}},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{stamp: 7, line: "noop"},
+				{stamp: 8, line: "noop", cb: matchStamps(1, 2)},
+			},
+		},
+	}
+}
+
+func TestResetShared(t *testing.T) {
+
+	factories := map[string]func(caseT) (Matcher, error){
+		"Seq": func(tc caseT) (Matcher, error) {
+			return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+		},
+		"Set": func(tc caseT) (Matcher, error) {
+			return NewInverseSet(tc.window, makeTerms(tc.terms), tc.reset)
+		},
+		"SeqOpt": func(tc caseT) (Matcher, error) {
+			return NewInverseSeq(tc.window, makeTerms(tc.terms), nil, WithResets(tc.reset...))
+		},
+		"SetOpt": func(tc caseT) (Matcher, error) {
+			return NewInverseSet(tc.window, makeTerms(tc.terms), nil, WithResets(tc.reset...))
+		},
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			NewCasesResetShared().run(t, factory)
+		})
+	}
+}
+
+func TestResetSharedInitFail(t *testing.T) {
+
+	cases := map[string]struct {
+		err   error
+		reset []ResetT
+	}{
+		"AnchorRange": {
+			err:   ErrAnchorRange,
+			reset: []ResetT{{Term: makeRaw("boom"), Anchor: 2}},
+		},
+		"EmptyReset": {
+			err:   ErrTermEmpty,
+			reset: []ResetT{{Term: makeRaw("")}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			terms := makeTermsA("alpha", "beta")
+
+			if _, err := NewInverseSeq(10, terms, tc.reset); err != tc.err {
+				t.Errorf("Seq: Expected err == %v, got %v", tc.err, err)
+			}
+			if _, err := NewInverseSet(10, terms, tc.reset); err != tc.err {
+				t.Errorf("Set: Expected err == %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCountBefore(t *testing.T) {
+	asserts := makeStampedLogs(1, 2, 2, 3, 5)
+
+	for stamp, exp := range map[int64]int{0: 0, 1: 0, 2: 1, 3: 3, 4: 4, 5: 4, 6: 5} {
+		if got := countBefore(asserts, stamp); got != exp {
+			t.Errorf("Stamp %d: expected %d, got %d", stamp, exp, got)
+		}
+	}
+}
+
+const wideN = 1 << 20
+
+// GC over a wide window; half the buffered asserts are expired.
+func BenchmarkCountBeforeWide(b *testing.B) {
+	asserts := make([]LogEntry, wideN)
+	for i := range asserts {
+		asserts[i].Timestamp = int64(i)
+	}
+	deadline := int64(wideN / 2)
+
+	b.Run("Linear", func(b *testing.B) {
+		for b.Loop() {
+			var cnt int
+			for _, e := range asserts {
+				if e.Timestamp >= deadline {
+					break
+				}
+				cnt += 1
+			}
+		}
+	})
+
+	b.Run("Binary", func(b *testing.B) {
+		for b.Loop() {
+			countBefore(asserts, deadline)
+		}
+	})
+}
+
+// Reset evaluation with the reset window past millions of buffered reset lines.
+func BenchmarkEvalResetsWide(b *testing.B) {
+	resets, err := buildResets([]ResetT{{Term: makeRaw("reset"), Window: 10}}, 1)
+	if err != nil {
+		b.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	for i := range wideN {
+		resets[0].resets = append(resets[0].resets, int64(i))
+	}
+
+	var (
+		clock   = int64(2 * wideN)
+		anchors = []anchorT{{clock: clock - 100}}
+	)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		evalResets(resets, anchors, clock)
+	}
+}
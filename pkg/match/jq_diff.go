@@ -0,0 +1,104 @@
+package match
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/itchyny/gojq"
+	"github.com/rs/zerolog/log"
+)
+
+// makeJqDiffMatch builds a stateful term that extracts a subtree from a JSON line
+// with a jq program and fires when the subtree differs from the one extracted
+// from the previous participating line.
+//
+// A line participates when the program yields a non-null value.  The first
+// participating line primes the term and does not fire.  The extracted value is
+// serialized with sorted map keys, so a change in key order alone is not a change.
+//
+// Terms may be evaluated more than once against the same line (eg. zero match
+// optimizations), so the result for the current ScanLine is memoized.
+
+func makeJqDiffMatch(term string) (MatchFunc, error) {
+
+	query, err := gojq.Parse(term)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		primed  bool
+		last    []byte
+		lastPtr *ScanLine
+		lastGen uint64
+		lastRes bool
+	)
+
+	return func(e *ScanLine) bool {
+		if e == lastPtr && e.gen == lastGen {
+			return lastRes
+		}
+		lastPtr, lastGen, lastRes = e, e.gen, false
+
+		cur, ok := extractJqSubtree(term, code, e)
+		if !ok {
+			return false
+		}
+
+		switch {
+		case !primed:
+			primed = true
+		case !bytes.Equal(last, cur):
+			lastRes = true
+		}
+
+		last = cur
+		return lastRes
+	}, nil
+}
+
+// Return the normalized serialization of the first non-null result of code on e.
+func extractJqSubtree(term string, code *gojq.Code, e *ScanLine) ([]byte, bool) {
+
+	v, err := e.DecodeJson()
+	if err != nil {
+		log.Debug().Err(err).Str("line", e.Line).Msg("Fail parse log line")
+		return nil, false
+	}
+
+	iter := code.Run(v)
+	for {
+		res, ok := iter.Next()
+		if !ok {
+			return nil, false
+		}
+
+		if err, ok := res.(error); ok {
+			if herr, ok := err.(*gojq.HaltError); ok && herr.Value() == nil {
+				return nil, false
+			}
+			log.Debug().Err(err).
+				Str("line", e.Line).
+				Str("term", term).
+				Msg("Fail jq query")
+			return nil, false
+		}
+
+		if res == nil {
+			continue
+		}
+
+		// encoding/json sorts map keys, which normalizes the subtree.
+		data, err := json.Marshal(res)
+		if err != nil {
+			log.Debug().Err(err).Str("term", term).Msg("Fail serialize jq result")
+			return nil, false
+		}
+		return data, true
+	}
+}
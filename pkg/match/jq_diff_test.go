@@ -0,0 +1,74 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJqDiffConfigChange(t *testing.T) {
+
+	tt := TermT{Type: TermJqJsonDiff, Value: `.config`}
+
+	sm, err := NewMatchSingle(tt)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	lines := []string{
+		`{"msg":"boot","config":{"a":1,"b":2}}`,   // First occurrence; primes, no fire.
+		`{"msg":"tick","config":{"a":1,"b":2}}`,   // Same subtree.
+		`{"msg":"noise"}`,                         // No subtree; ignored.
+		`{"msg":"tick","config":{"b":2,"a":1}}`,   // Key order change only.
+		`not json`,                                // Ignored.
+		`{"msg":"reload","config":{"a":1,"b":3}}`, // Change; fire.
+		`{"msg":"tick","config":{"b":3,"a":1}}`,   // Same as new subtree.
+	}
+
+	var (
+		fired []int64
+		sl    = NewScanLine()
+	)
+
+	for i, line := range lines {
+		if hits := sm.Scan(sl.ResetLine(int64(i+1), line)); hits.Cnt > 0 {
+			fired = append(fired, hits.Logs[0].Timestamp)
+		}
+	}
+
+	if len(fired) != 1 || fired[0] != 6 {
+		t.Fatalf("Expected single fire on stamp 6, got %v", fired)
+	}
+}
+
+func TestJqDiffRepeatEval(t *testing.T) {
+
+	m, err := TermT{Type: TermJqJsonDiff, Value: `.v`}.NewMatcher()
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	m(sl.ResetLine(1, `{"v":1}`))
+
+	// Evaluating the same line twice must return the same answer.
+	sl.ResetLine(2, `{"v":2}`)
+	if !m(sl) || !m(sl) {
+		t.Errorf("Expected repeat evaluation to match")
+	}
+
+	// An identical line scanned again is not a change.
+	if m(sl.ResetLine(3, `{"v":2}`)) {
+		t.Errorf("Expected no match on unchanged subtree")
+	}
+}
+
+func TestJqDiffBadTerm(t *testing.T) {
+	_, err := TermT{Type: TermJqJsonDiff, Value: `invalid jq`}.NewMatcher()
+	if !errors.Is(err, ErrTermCompile) {
+		t.Fatalf("Expected ErrTermCompile, got %v", err)
+	}
+
+	if TermJqJsonDiff.String() != termNameJqDiff {
+		t.Errorf("Expected %q, got %q", termNameJqDiff, TermJqJsonDiff.String())
+	}
+}
@@ -0,0 +1,106 @@
+package match
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// KeyFn derives a correlation key from a line.  An empty key means the line
+// carries no identity and should not be correlated.  Like a MatchFunc, it is
+// passed the ScanLine, so that a key may share the line's decoded forms with
+// the terms.
+//
+// KeyedMatcher partitions events on a KeyFn, and a reset correlates with the
+// anchor of a match on one through ResetT.CorrelateKey, so that the identity
+// may be any composite of the entry, not just a single field.
+type KeyFn func(*ScanLine) string
+
+// KeyComposite joins the keys produced by fns into a single key.  Each part is
+// quoted before joining so that the result is unambiguous; ("a|b", "c") and
+// ("a", "b|c") produce distinct keys.  If any part is empty, the composite is
+// empty; a partial identity is not an identity.
+func KeyComposite(fns ...KeyFn) KeyFn {
+	return func(e *ScanLine) string {
+		var sb strings.Builder
+		for i, fn := range fns {
+			part := fn(e)
+			if part == "" {
+				return ""
+			}
+			if i > 0 {
+				sb.WriteByte('|')
+			}
+			sb.WriteString(strconv.Quote(part))
+		}
+		return sb.String()
+	}
+}
+
+// KeyRegex returns a KeyFn that extracts the first capture group of expr from
+// the line, or the entire match if expr has no capture groups.
+func KeyRegex(expr string) (KeyFn, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key regex: %w", ErrTermCompile, err)
+	}
+
+	grp := 0
+	if re.NumSubexp() > 0 {
+		grp = 1
+	}
+
+	return func(e *ScanLine) string {
+		m := re.FindStringSubmatch(e.Line)
+		if m == nil {
+			return ""
+		}
+		return m[grp]
+	}, nil
+}
+
+// KeyJq returns a KeyFn that evaluates the jq expression against the line decoded
+// as JSON, as cached by the ScanLine.  The first non-null result is the key;
+// non-string results are rendered as JSON.
+func KeyJq(expr string) (KeyFn, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key parse fail: %w", ErrTermCompile, err)
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key compile fail: %w", ErrTermCompile, err)
+	}
+
+	return func(e *ScanLine) string {
+		v, err := e.DecodeJson()
+		if err != nil {
+			return ""
+		}
+
+		iter := code.Run(v)
+		for {
+			r, ok := iter.Next()
+			if !ok {
+				return ""
+			}
+			switch r := r.(type) {
+			case nil, error:
+				continue
+			case string:
+				return r
+			default:
+				b, err := json.Marshal(r)
+				if err != nil {
+					continue
+				}
+				return string(b)
+			}
+		}
+	}, nil
+}
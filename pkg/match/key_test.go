@@ -0,0 +1,111 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyCompositeCorrelation(t *testing.T) {
+
+	tenant, err := KeyJq(".tenant")
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	reqId, err := KeyJq(".request_id")
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	keyFn := KeyComposite(tenant, reqId)
+
+	// Identical message and request id; only the tenant differs.
+	entries := []LogEntry{
+		{Timestamp: 1, Line: `{"tenant":"acme","request_id":"r1","msg":"timeout"}`},
+		{Timestamp: 2, Line: `{"tenant":"initech","request_id":"r1","msg":"timeout"}`},
+		{Timestamp: 3, Line: `{"request_id":"r1","tenant":"acme","msg":"timeout"}`},
+		{Timestamp: 4, Line: `{"request_id":"r1","msg":"timeout"}`}, // Partial identity
+	}
+
+	var (
+		groups = make(map[string][]int64)
+		line   = func(i int) *ScanLine { return NewScanLine().Reset(entries[i]) }
+	)
+	for i, e := range entries {
+		if key := keyFn(line(i)); key != "" {
+			groups[key] = append(groups[key], e.Timestamp)
+		}
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %v", groups)
+	}
+
+	if g := groups[keyFn(line(0))]; len(g) != 2 || g[0] != 1 || g[1] != 3 {
+		t.Errorf("Expected acme group [1 3], got %v", g)
+	}
+
+	if g := groups[keyFn(line(1))]; len(g) != 1 || g[0] != 2 {
+		t.Errorf("Expected initech group [2], got %v", g)
+	}
+
+	// Single field would conflate the tenants.
+	if reqId(line(0)) != reqId(line(1)) {
+		t.Errorf("Expected single field keys to collide")
+	}
+}
+
+func TestKeyCompositeUnambiguous(t *testing.T) {
+	var (
+		a  = func(s string) KeyFn { return func(*ScanLine) string { return s } }
+		k1 = KeyComposite(a("a|b"), a("c"))(NewScanLine())
+		k2 = KeyComposite(a("a"), a("b|c"))(NewScanLine())
+	)
+
+	if k1 == k2 {
+		t.Errorf("Expected distinct keys, got %q", k1)
+	}
+}
+
+func TestKeyRegex(t *testing.T) {
+
+	keyFn, err := KeyRegex(`user=(\w+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	if key := keyFn(NewScanLine().ResetLine(0, "login user=bob ok")); key != "bob" {
+		t.Errorf("Expected bob, got %q", key)
+	}
+
+	if key := keyFn(NewScanLine().ResetLine(0, "login ok")); key != "" {
+		t.Errorf("Expected empty key, got %q", key)
+	}
+
+	if _, err := KeyRegex(`(`); !errors.Is(err, ErrTermCompile) {
+		t.Errorf("Expected ErrTermCompile, got %v", err)
+	}
+
+	if _, err := KeyJq(`.[`); !errors.Is(err, ErrTermCompile) {
+		t.Errorf("Expected ErrTermCompile, got %v", err)
+	}
+}
+
+func TestKeyJqCached(t *testing.T) {
+	keyFn, err := KeyJq(".tenant")
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine().ResetLine(1, `{"tenant":"acme"}`)
+	if key := keyFn(sl); key != "acme" {
+		t.Fatalf("Expected acme, got %q", key)
+	}
+
+	// The decode is left on the line for the terms.
+	if !sl._cached(decodeJson) {
+		t.Fatalf("Expected a cached decode")
+	}
+	if doc, _ := sl.cache.ptr.(map[string]any); doc["tenant"] != "acme" {
+		t.Errorf("Expected the decoded line, got %v", sl.cache.ptr)
+	}
+}
@@ -0,0 +1,304 @@
+package match
+
+import (
+	"container/heap"
+	"container/list"
+	"errors"
+	"strings"
+)
+
+var ErrKeyedArgs = errors.New("keyed matcher requires a key function and a factory")
+
+// PropKeyed is the Props key holding the correlation key of the partition
+// that emitted a hit.
+const PropKeyed = "key"
+
+// KeyedMatcher maintains an independent matcher per correlation key, so that
+// sequences from interleaved sources (pods, requests, addresses) are matched
+// in isolation.  Each line is dispatched to the partition for its key, which is
+// created on demand by the factory.  Lines with an empty key are not dispatched,
+// nor are lines for which the factory returns nil; a factory that can fail
+// reports the error out of band.
+//
+// Partitions are evicted least recently used first once WithMaxKeys is reached,
+// and after WithKeyTTL without a line.  An evicted partition is evaluated at the
+// current clock before it is dropped, so that hits pending on time are not lost.
+//
+// As with MultiMatcher, time driven partitions that are not dispatched a line
+// are evaluated at the line timestamp, and idle partitions expired, as the
+// GC policy allows; see GCPolicyT.  Time driven partitions are queued by the
+// clock at which they may next fire, so only those due are evaluated; those
+// that cannot tell are evaluated whenever the clock advances.  Every hit
+// carries its key in Props.
+
+type KeyedMatcher struct {
+	keyFn   KeyFn
+	factory func() Matcher
+	maxKeys int
+	ttl     int64
+	clock   int64
+	parts   map[string]*list.Element
+	lru     *list.List // Front is most recently used.
+	timed   deadlineHeapT
+
+	statsT
+	lateT
+}
+
+type partT struct {
+	key  string
+	m    Matcher
+	last int64
+	due  int64 // Clock at which the partition may next fire.
+	idx  int   // Index in the deadline heap; -1 if not queued.
+}
+
+func NewKeyedMatcher(keyFn KeyFn, factory func() Matcher, opts ...OptT) (*KeyedMatcher, error) {
+	if keyFn == nil || factory == nil {
+		return nil, ErrKeyedArgs
+	}
+
+	o := parseOpts(opts)
+	if err := o.check(optsKeyed); err != nil {
+		return nil, err
+	}
+
+	return newKeyed(keyFn, factory, o), nil
+}
+
+// Options of KeyedMatcher.
+const optsKeyed = optsStats | optMaxKeys | optKeyTTL
+
+func newKeyed(keyFn KeyFn, factory func() Matcher, o optsT) *KeyedMatcher {
+	return &KeyedMatcher{
+		keyFn:   keyFn,
+		factory: factory,
+		maxKeys: o.maxKeys,
+		ttl:     o.keyTTL,
+		statsT:  newStats(o),
+		parts:   make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Len returns the number of live partitions.
+func (r *KeyedMatcher) Len() int {
+	return len(r.parts)
+}
+
+func (r *KeyedMatcher) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
+	if e.Timestamp < r.clock {
+		if e = r.late(&r.identT, "KeyedMatcher", e, r.clock); e == nil {
+			return
+		}
+	}
+	r.clock = e.Timestamp
+
+	sweep := r.gcDue(r.clock, len(r.parts))
+	if sweep {
+		r.expire(&hits)
+		r.gcClock = r.clock
+	}
+
+	if key := r.keyFn(e); key != "" {
+		if elem := r.touch(&hits, key); elem != nil {
+			p := elem.Value.(*partT)
+			r.collect(&hits, p.key, p.m.Scan(e))
+			r.schedule(p)
+		}
+	}
+
+	if sweep {
+		r.evalDue(&hits)
+	}
+
+	if hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
+}
+
+func (r *KeyedMatcher) Eval(clock int64) (hits Hits) {
+	if clock <= r.clock {
+		return
+	}
+	r.clock = clock
+	r.sweepAt(&hits)
+	if hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
+}
+
+// Run the evaluation and expiry deferred by the GC policy at the clock.
+func (r *KeyedMatcher) sweep() (hits Hits) {
+	r.sweepAt(&hits)
+	if hits.Cnt > 0 {
+		r.tag(&hits)
+	}
+	return
+}
+
+func (r *KeyedMatcher) sweepAt(hits *Hits) {
+	r.expire(hits)
+	r.gcClock = r.clock
+	r.evalDue(hits)
+}
+
+// Evaluate the partitions due at the clock, earliest deadline first.
+func (r *KeyedMatcher) evalDue(hits *Hits) {
+	var due []*partT
+	for len(r.timed) > 0 && r.timed[0].due <= r.clock {
+		due = append(due, heap.Pop(&r.timed).(*partT))
+	}
+
+	for _, p := range due {
+		r.collect(hits, p.key, p.m.Eval(r.clock))
+		r.schedule(p)
+	}
+}
+
+// Queue a time driven partition by the clock at which it may next fire, or
+// dequeue it if it cannot fire before it scans another line.
+func (r *KeyedMatcher) schedule(p *partT) {
+	if !isTimed(p.m) {
+		return
+	}
+
+	due, ok := r.clock+1, true
+	if d, isDl := p.m.(deadlineI); isDl {
+		due, ok = d.deadline()
+	}
+
+	switch {
+	case !ok:
+		if p.idx >= 0 {
+			heap.Remove(&r.timed, p.idx)
+		}
+	case p.idx >= 0:
+		p.due = due
+		heap.Fix(&r.timed, p.idx)
+	default:
+		p.due = due
+		heap.Push(&r.timed, p)
+	}
+}
+
+func (r *KeyedMatcher) GarbageCollect(clock int64) {
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		el.Value.(*partT).m.GarbageCollect(clock)
+	}
+}
+
+// Stats returns the lines scanned and dropped by the keyed matcher, and the
+// remaining counters summed over the live partitions.
+func (r *KeyedMatcher) Stats() StatsT {
+	s := StatsT{
+		Scanned: r.nScanned,
+		Dropped: r.nDropped,
+	}
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		s.add(statsOf(el.Value.(*partT).m))
+	}
+	return s
+}
+
+// Find or create the partition for key and mark it most recently used; nil
+// if the factory fails.
+func (r *KeyedMatcher) touch(hits *Hits, key string) *list.Element {
+	if elem, ok := r.parts[key]; ok {
+		elem.Value.(*partT).last = r.clock
+		r.lru.MoveToFront(elem)
+		return elem
+	}
+
+	m := r.factory()
+	if m == nil {
+		return nil
+	}
+
+	if r.maxKeys > 0 && len(r.parts) >= r.maxKeys {
+		r.evict(hits, r.lru.Back())
+	}
+
+	// The key may view a borrowed line; copy before retaining.
+	key = strings.Clone(key)
+
+	elem := r.lru.PushFront(&partT{key: key, m: m, last: r.clock, idx: -1})
+	r.parts[key] = elem
+	return elem
+}
+
+// Evict partitions idle past the TTL; the least recently used are at the back.
+func (r *KeyedMatcher) expire(hits *Hits) {
+	if r.ttl <= 0 {
+		return
+	}
+
+	for el := r.lru.Back(); el != nil; el = r.lru.Back() {
+		if r.clock-el.Value.(*partT).last <= r.ttl {
+			break
+		}
+		r.evict(hits, el)
+	}
+}
+
+func (r *KeyedMatcher) evict(hits *Hits, el *list.Element) {
+	p := el.Value.(*partT)
+	r.collect(hits, p.key, p.m.Eval(r.clock))
+	if p.idx >= 0 {
+		heap.Remove(&r.timed, p.idx)
+	}
+	r.lru.Remove(el)
+	delete(r.parts, p.key)
+}
+
+func (r *KeyedMatcher) collect(hits *Hits, key string, h Hits) {
+	if h.Cnt == 0 {
+		return
+	}
+
+	base := hits.Cnt
+	hits.Append(h)
+
+	if hits.Props == nil {
+		hits.Props = make(map[PropKey]any, h.Cnt)
+	}
+	for i := range h.Cnt {
+		hits.Props[PropKey{Idx: base + i, Key: PropKeyed}] = key
+	}
+}
+
+func isTimed(m Matcher) bool {
+	_, ok := m.(edgeTriggeredI)
+	return !ok
+}
+
+// Min-heap of time driven partitions by deadline.
+type deadlineHeapT []*partT
+
+func (h deadlineHeapT) Len() int { return len(h) }
+
+func (h deadlineHeapT) Less(i, j int) bool { return h[i].due < h[j].due }
+
+func (h deadlineHeapT) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].idx, h[j].idx = i, j
+}
+
+func (h *deadlineHeapT) Push(x any) {
+	p := x.(*partT)
+	p.idx = len(*h)
+	*h = append(*h, p)
+}
+
+func (h *deadlineHeapT) Pop() any {
+	old := *h
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	p.idx = -1
+	*h = old[:n-1]
+	return p
+}
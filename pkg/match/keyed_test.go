@@ -0,0 +1,240 @@
+package match
+
+import (
+	"fmt"
+	"testing"
+)
+
+func checkKeyed(key string, stamps ...int64) func(*testing.T, int, Hits) {
+	return func(t *testing.T, step int, hits Hits) {
+		t.Helper()
+		matchStamps(stamps...)(t, step, hits)
+		if v := hits.IndexProps(0)[PropKeyed]; v != key {
+			t.Errorf("Step %v: Expected key %q, got %v", step, key, v)
+		}
+	}
+}
+
+func checkKeys(n int) func(*testing.T, int, Matcher) {
+	return func(t *testing.T, step int, m Matcher) {
+		t.Helper()
+		if got := m.(*KeyedMatcher).Len(); got != n {
+			t.Errorf("Step %v: Expected %v keys, got %v", step, n, got)
+		}
+	}
+}
+
+func NewCasesKeyed() casesT {
+
+	return casesT{
+		"Interleaved": {
+			// Unkeyed, pod=a start and pod=b fail would match.
+			window: 10,
+			terms:  []string{"start", "fail"},
+			steps: []stepT{
+				{line: "pod=a start"},
+				{line: "pod=b start"},
+				{line: "pod=b fail", cb: checkKeyed("b", 2, 3)},
+				{line: "pod=c fail"},
+				{line: "pod=a fail", cb: checkKeyed("a", 1, 5)},
+				{postF: checkKeys(3)},
+			},
+		},
+		"NoKey": {
+			window: 10,
+			terms:  []string{"start", "fail"},
+			steps: []stepT{
+				{line: "start"},
+				{line: "fail"},
+				{postF: checkKeys(0)},
+			},
+		},
+		"MaxKeys": {
+			window: 10,
+			terms:  []string{"start", "fail"},
+			opts:   []OptT{WithMaxKeys(2)},
+			steps: []stepT{
+				{line: "pod=a start"},
+				{line: "pod=b start"},
+				{line: "pod=a noop"},  // a is most recent
+				{line: "pod=c start"}, // evicts b
+				{postF: checkKeys(2)},
+				{line: "pod=c fail", cb: checkKeyed("c", 4, 6)},
+				{line: "pod=b fail"}, // b start was forgotten; evicts a
+				{postF: checkKeys(2)},
+			},
+		},
+		"TTL": {
+			window: 10,
+			terms:  []string{"start", "fail"},
+			opts:   []OptT{WithKeyTTL(3)},
+			steps: []stepT{
+				{line: "pod=a start"},
+				{line: "pod=b start"},
+				{postF: checkKeys(2)},
+				{stamp: 5, line: "pod=b noop"}, // a expired
+				{postF: checkKeys(1)},
+				{stamp: 6, line: "pod=a fail"},
+				{stamp: 7, line: "pod=b fail", cb: checkKeyed("b", 2, 7)},
+			},
+		},
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	defer disableLogs()()
+
+	keyFn, err := KeyRegex(`pod=(\S+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	NewCasesKeyed().run(t, func(tc caseT) (Matcher, error) {
+		return NewKeyedMatcher(keyFn, func() Matcher {
+			m, _ := NewMatchSeq(tc.window, makeTerms(tc.terms)...)
+			return m
+		}, tc.opts...)
+	})
+}
+
+func TestKeyedTimed(t *testing.T) {
+	keyFn, err := KeyRegex(`pod=(\S+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, func() Matcher {
+		m, _ := NewMatchAbsence(5, makeRaw("ping"), WithAbsence(AbsencePrevious))
+		return m
+	}, WithKeyTTL(20))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+
+	km.Scan(sl.ResetLine(1, "pod=a ping"))
+	km.Scan(sl.ResetLine(2, "pod=b ping"))
+
+	// pod=b keeps pinging; a goes silent and fires on b's line.
+	if hits := km.Scan(sl.ResetLine(6, "pod=b ping")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+	hits := km.Scan(sl.ResetLine(7, "pod=b ping"))
+	checkKeyed("a", 1)(t, 4, hits)
+
+	// Expiry evaluates the partition before dropping it.
+	hits = km.Eval(100)
+	checkKeyed("b", 7)(t, 5, hits)
+	if km.Len() != 0 {
+		t.Errorf("Expected no keys, got %v", km.Len())
+	}
+}
+
+func TestKeyedInitFail(t *testing.T) {
+	if _, err := NewKeyedMatcher(nil, func() Matcher { return nil }); err != ErrKeyedArgs {
+		t.Errorf("Expected err == %v, got %v", ErrKeyedArgs, err)
+	}
+	if _, err := NewKeyedMatcher(func(*ScanLine) string { return "" }, nil); err != ErrKeyedArgs {
+		t.Errorf("Expected err == %v, got %v", ErrKeyedArgs, err)
+	}
+}
+
+// Counts the evaluations of a time driven matcher.
+type evalCountT struct {
+	*MatchAbsence
+	evals *int
+}
+
+func (c evalCountT) Eval(clock int64) Hits {
+	*c.evals += 1
+	return c.MatchAbsence.Eval(clock)
+}
+
+func TestKeyedDeadline(t *testing.T) {
+	var evals int
+
+	keyFn, err := KeyRegex(`pod=(\S+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, func() Matcher {
+		m, _ := NewMatchAbsence(100, makeRaw("ping"), WithAbsence(AbsencePrevious))
+		return evalCountT{MatchAbsence: m, evals: &evals}
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+
+	// Partition p0 is due at 102, the rest later; one line arms each.
+	for i := range 50 {
+		km.Scan(sl.ResetLine(int64(i+1), fmt.Sprintf("pod=p%d ping", i)))
+	}
+	if evals != 0 {
+		t.Errorf("Expected no evaluations before a deadline, got %v", evals)
+	}
+
+	hits := km.Scan(sl.ResetLine(102, "pod=p49 noop"))
+	checkKeyed("p0", 1)(t, 1, hits)
+	if evals != 1 {
+		t.Errorf("Expected only the due partition evaluated, got %v", evals)
+	}
+
+	// Disarmed, p0 is no longer queued.
+	evals = 0
+	if hits = km.Eval(104); hits.Cnt != 2 {
+		t.Errorf("Expected 2 hits, got %v", hits.Cnt)
+	}
+	if evals != 2 {
+		t.Errorf("Expected 2 evaluations, got %v", evals)
+	}
+}
+
+func TestKeyedDeadlineInverse(t *testing.T) {
+	keyFn, err := KeyRegex(`pod=(\S+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, func() Matcher {
+		m, _ := NewInverseSeq(10, makeTermsA("start", "fail"), []ResetT{{Term: makeRaw("ok"), Window: 5}})
+		return m
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	km.Scan(sl.ResetLine(1, "pod=a start"))
+	km.Scan(sl.ResetLine(2, "pod=a fail"))
+
+	// The reset window closes at 7; the hit is held until 8.
+	if hits := km.Scan(sl.ResetLine(7, "pod=b noop")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+	hits := km.Scan(sl.ResetLine(8, "pod=b noop"))
+	checkKeyed("a", 1, 2)(t, 1, hits)
+}
+
+func TestKeyedFactoryNil(t *testing.T) {
+	keyFn, err := KeyRegex(`pod=(\S+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, func() Matcher { return nil })
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	if hits := km.Scan(sl.ResetLine(1, "pod=a start")); hits.Cnt != 0 {
+		t.Errorf("Expected no hits, got %v", hits.Cnt)
+	}
+	if km.Len() != 0 {
+		t.Errorf("Expected no keys, got %v", km.Len())
+	}
+}
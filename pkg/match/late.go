@@ -0,0 +1,57 @@
+package match
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// LateActionT is returned by a LateFuncT to decide the fate of an out of order entry.
+type LateActionT int
+
+const (
+	LateDrop   LateActionT = iota // Drop the entry with a warning; the default.
+	LateSkip                      // Drop the entry silently; the callback has dealt with it.
+	LateAccept                    // Process the entry as though it arrived at the matcher clock.
+)
+
+// LateFuncT is called with an entry older than the matcher clock.  It may
+// count or divert the entry, and returns the action the matcher should take.
+type LateFuncT func(e LogEntry, clock int64) LateActionT
+
+// Embedded by matchers that reject out of order entries.
+type lateT struct {
+	onLate   LateFuncT
+	nDropped uint64
+}
+
+// OnOutOfOrder installs fn to be called on each out of order entry.
+func (l *lateT) OnOutOfOrder(fn LateFuncT) {
+	l.onLate = fn
+}
+
+// Apply the late policy to e.  Returns the line to process, with the timestamp
+// clamped to clock on accept, or nil if the entry is dropped.  The caller's line
+// is not modified; it may be shared with other matchers.
+func (l *lateT) late(id *identT, name string, e *ScanLine, clock int64) *ScanLine {
+	action := LateDrop
+	if l.onLate != nil {
+		action = l.onLate(e.LogEntry, clock)
+	}
+
+	switch action {
+	case LateAccept:
+		accepted := *e
+		accepted.Timestamp = clock
+		return &accepted
+	case LateSkip:
+		l.nDropped += 1
+		return nil
+	default:
+		l.nDropped += 1
+		id.log(log.Warn()).
+			Str("line", e.Line).
+			Int64("stamp", e.Timestamp).
+			Int64("clock", clock).
+			Msg(name + ": Out of order event.")
+		return nil
+	}
+}
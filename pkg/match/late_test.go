@@ -0,0 +1,100 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestLateAccept(t *testing.T) {
+	seq, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var late []LogEntry
+	seq.OnOutOfOrder(func(e LogEntry, clock int64) LateActionT {
+		late = append(late, e)
+		return LateAccept
+	})
+
+	sl := NewScanLine()
+	seq.Scan(sl.ResetLine(5, "alpha"))
+
+	// Accepted at the clock, the caller's line is untouched.
+	hits := seq.Scan(sl.ResetLine(3, "beta"))
+	matchStamps(5, 5)(t, 2, hits)
+
+	if sl.Timestamp != 3 {
+		t.Errorf("Expected caller line unchanged, got %v", sl.Timestamp)
+	}
+	if len(late) != 1 || late[0].Timestamp != 3 {
+		t.Errorf("Expected one late entry at 3, got %v", late)
+	}
+}
+
+func TestLateSkip(t *testing.T) {
+	defer disableLogs()()
+
+	var (
+		cnt      int
+		diverted []LogEntry
+		sl       = NewScanLine()
+	)
+
+	matchers := map[string]interface {
+		Matcher
+		OnOutOfOrder(LateFuncT)
+	}{}
+
+	seq, _ := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	set, _ := NewMatchSet(10, makeTermsA("alpha", "beta")...)
+	iseq, _ := NewInverseSeq(10, makeTermsA("alpha", "beta"), nil)
+	iset, _ := NewInverseSet(10, makeTermsA("alpha", "beta"), nil)
+	edge, _ := NewMatchFallingEdge(makeRaw("alpha"), 10)
+	count, _ := NewMatchCount(10, 2, makeRaw("alpha"))
+	rate, _ := NewMatchRate(1, 1, makeRaw("alpha"))
+	absence, _ := NewMatchAbsence(10, makeRaw("alpha"))
+	keyed, _ := NewKeyedMatcher(func(*ScanLine) string { return "k" }, func() Matcher {
+		m, _ := NewMatchSingle(makeRaw("alpha"))
+		return m
+	})
+
+	matchers["seq"] = seq
+	matchers["set"] = set
+	matchers["iseq"] = iseq
+	matchers["iset"] = iset
+	matchers["edge"] = edge
+	matchers["count"] = count
+	matchers["rate"] = rate
+	matchers["absence"] = absence
+	matchers["keyed"] = keyed
+
+	for name, m := range matchers {
+		m.OnOutOfOrder(func(e LogEntry, clock int64) LateActionT {
+			cnt += 1
+			diverted = append(diverted, e)
+			return LateSkip
+		})
+
+		m.Scan(sl.ResetLine(10, "noop"))
+		if hits := m.Scan(sl.ResetLine(5, "alpha")); hits.Cnt != 0 {
+			t.Errorf("%s: Expected no hits, got %v", name, hits.Cnt)
+		}
+	}
+
+	if cnt != len(matchers) || len(diverted) != len(matchers) {
+		t.Errorf("Expected %d late entries, got %d", len(matchers), cnt)
+	}
+}
+
+func TestLateDefault(t *testing.T) {
+	defer disableLogs()()
+
+	seq, _ := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	seq.OnOutOfOrder(func(LogEntry, int64) LateActionT { return LateDrop })
+
+	sl := NewScanLine()
+	seq.Scan(sl.ResetLine(5, "alpha"))
+	if hits := seq.Scan(sl.ResetLine(3, "beta")); hits.Cnt != 0 {
+		t.Errorf("Expected no hits, got %v", hits.Cnt)
+	}
+}
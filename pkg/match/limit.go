@@ -0,0 +1,80 @@
+package match
+
+import (
+	"errors"
+)
+
+var ErrLimit = errors.New("limit must be positive")
+
+// MatchLimit wraps a Matcher so that it disarms after emitting limit hits;
+// a limit of one gives a one-shot rule.  Once done, the wrapped matcher is
+// released along with its buffers, and subsequent calls return immediately.
+// Any hits beyond the limit in the final batch are dropped.
+
+type MatchLimit struct {
+	m     Matcher
+	limit int
+	cnt   int
+}
+
+func NewMatchLimit(limit int, m Matcher) (*MatchLimit, error) {
+	if limit <= 0 {
+		return nil, ErrLimit
+	}
+	return &MatchLimit{m: m, limit: limit}, nil
+}
+
+func (r *MatchLimit) Scan(e *ScanLine) Hits {
+	if r.m == nil {
+		return Hits{}
+	}
+	return r.count(r.m.Scan(e))
+}
+
+func (r *MatchLimit) Eval(clock int64) Hits {
+	if r.m == nil {
+		return Hits{}
+	}
+	return r.count(r.m.Eval(clock))
+}
+
+func (r *MatchLimit) GarbageCollect(clock int64) {
+	if r.m == nil {
+		return
+	}
+	r.m.GarbageCollect(clock)
+}
+
+// Done reports whether the limit has been reached.
+func (r *MatchLimit) Done() bool {
+	return r.m == nil
+}
+
+// Hits returns the number of hits emitted so far.
+func (r *MatchLimit) Hits() int {
+	return r.cnt
+}
+
+// Stats returns the counters of the wrapped matcher.  Once done, the
+// wrapped matcher is released and only the hits emitted are reported.
+func (r *MatchLimit) Stats() StatsT {
+	if r.m == nil {
+		return StatsT{Hits: uint64(r.cnt)}
+	}
+	return statsOf(r.m)
+}
+
+func (r *MatchLimit) count(h Hits) Hits {
+	if h.Cnt == 0 {
+		return h
+	}
+
+	h = h.Head(r.limit - r.cnt)
+	r.cnt += h.Cnt
+
+	if r.cnt >= r.limit {
+		// Release the wrapped matcher and its buffers.
+		r.m = nil
+	}
+	return h
+}
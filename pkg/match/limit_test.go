@@ -0,0 +1,97 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+// The case window is used as the hit limit.
+func NewCasesLimit() casesT {
+
+	return casesT{
+		"OneShot": {
+			window: 1,
+			terms:  []string{"alpha", "beta"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta", cb: matchStamps(1, 2)},
+				{line: "alpha"},
+				{line: "beta"},
+			},
+		},
+		"Two": {
+			window: 2,
+			terms:  []string{"alpha", "beta"},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta", cb: matchStamps(1, 2)},
+				{line: "alpha"},
+				{line: "beta", cb: matchStamps(3, 4)},
+				{line: "alpha"},
+				{line: "beta"},
+			},
+		},
+	}
+}
+
+func TestLimit(t *testing.T) {
+	NewCasesLimit().run(t, func(tc caseT) (Matcher, error) {
+		m, err := NewMatchSeq(10, makeTerms(tc.terms)...)
+		if err != nil {
+			return nil, err
+		}
+		return NewMatchLimit(int(tc.window), m)
+	})
+}
+
+func TestLimitBatch(t *testing.T) {
+	iseq, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset"), Window: 2, Absolute: true, Anchor: 1}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	lm, err := NewMatchLimit(1, iseq)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i, line := range []string{"alpha", "beta", "alpha", "beta"} {
+		lm.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	if lm.Done() {
+		t.Fatalf("Expected not done")
+	}
+
+	// Two hits fire together; only the first is kept.
+	matchStamps(1, 2)(t, 1, lm.Eval(20))
+
+	if !lm.Done() || lm.Hits() != 1 {
+		t.Errorf("Expected done after 1 hit, got %v %v", lm.Done(), lm.Hits())
+	}
+	if hits := lm.Eval(30); hits.Cnt != 0 {
+		t.Errorf("Expected no hits once done, got %v", hits.Cnt)
+	}
+}
+
+func TestLimitInit(t *testing.T) {
+	m, _ := NewMatchSingle(makeRaw("alpha"))
+	if _, err := NewMatchLimit(0, m); !errors.Is(err, ErrLimit) {
+		t.Errorf("Expected ErrLimit, got %v", err)
+	}
+}
+
+func BenchmarkLimitDone(b *testing.B) {
+	m, _ := NewMatchSingle(makeRaw("alpha"))
+	lm, _ := NewMatchLimit(1, m)
+
+	sl := NewScanLine()
+	lm.Scan(sl.ResetLine(1, "alpha"))
+
+	sl.ResetLine(2, "alpha")
+	b.ResetTimer()
+	for range b.N {
+		lm.Scan(sl)
+	}
+}
@@ -0,0 +1,132 @@
+package match
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Counts the lines scanned by a matcher whose window is bounded in lines.  A
+// counted line is scanned with its count in place of a field, its timestamp
+// for WithLineWindow or its line number for WithMaxLines, and the field is
+// saved for the last size lines to restore those of a hit, which spans at most
+// size lines.
+type lineRingT struct {
+	count int64
+	size  int64
+	saved []int64 // Ring by count; grows to size.
+}
+
+// lineStateT is the checkpoint of a lineRingT.
+type lineStateT struct {
+	Count int64   `json:"count"`
+	Saved []int64 `json:"saved"`
+}
+
+// The line counters for WithLineWindow and WithMaxLines, if set.
+func lineRings(window int64, o optsT) (lines, limit *lineRingT) {
+	switch {
+	case o.lineWindow:
+		lines = newLineRing(window)
+	case o.maxLines > 0:
+		limit = newLineRing(o.maxLines)
+	}
+	return
+}
+
+func newLineRing(lines int64) *lineRingT {
+	size := max(lines, 0) + 1
+	return &lineRingT{
+		size:  size,
+		saved: make([]int64, 0, min(size, 1024)),
+	}
+}
+
+// Save v under the next line count; returns the count.
+func (l *lineRingT) push(v int64) int64 {
+	if idx := l.count % l.size; idx < int64(len(l.saved)) {
+		l.saved[idx] = v
+	} else {
+		l.saved = append(l.saved, v)
+	}
+	l.count += 1
+	return l.count - 1
+}
+
+// The value saved under count, which must be one of the last size lines.
+func (l *lineRingT) get(count int64) int64 {
+	return l.saved[count%l.size]
+}
+
+// The value saved under count; false if count is no longer within the bound.
+func (l *lineRingT) lookup(count int64) (int64, bool) {
+	if count < l.oldest() || count >= l.count {
+		return 0, false
+	}
+	return l.get(count), true
+}
+
+// The oldest line count still within the bound.
+func (l *lineRingT) oldest() int64 {
+	return l.count - l.size
+}
+
+// Returns a copy of e stamped with its line count.  The caller's line is not
+// modified; it may be shared with other matchers.
+func (l *lineRingT) stamp(e *ScanLine) *ScanLine {
+	counted := *e
+	counted.Timestamp = l.push(e.Timestamp)
+	return &counted
+}
+
+// Returns a copy of e numbered with its line count.
+func (l *lineRingT) number(e *ScanLine) *ScanLine {
+	counted := *e
+	counted.LineNo = l.push(e.LineNo)
+	return &counted
+}
+
+// Restore the timestamps of a hit stamped with line counts.
+func (l *lineRingT) unstamp(hits *Hits) {
+	hits.FireStamp = l.get(hits.FireStamp)
+	for i := range hits.Logs {
+		hits.Logs[i].Timestamp = l.get(hits.Logs[i].Timestamp)
+	}
+}
+
+// Restore the line numbers of a hit numbered with line counts.
+func (l *lineRingT) unnumber(hits *Hits) {
+	for i := range hits.Logs {
+		hits.Logs[i].LineNo = l.get(hits.Logs[i].LineNo)
+	}
+}
+
+func (l *lineRingT) state() *lineStateT {
+	if l == nil {
+		return nil
+	}
+	return &lineStateT{Count: l.count, Saved: l.saved}
+}
+
+func (l *lineRingT) restore(s *lineStateT) error {
+	switch {
+	case l == nil && s == nil:
+		return nil
+	case l == nil || s == nil:
+		return fmt.Errorf("%w: line count", ErrStateMismatch)
+	case int64(len(s.Saved)) > l.size || int64(len(s.Saved)) < min(s.Count, l.size):
+		return fmt.Errorf("%w: %d lines saved", ErrStateMismatch, len(s.Saved))
+	}
+
+	l.count = s.Count
+	l.saved = slices.Clone(s.Saved)
+	return nil
+}
+
+// Number of asserts numbered before count.  Asserts are appended in line order.
+func countBeforeLine(asserts []LogEntry, count int64) int {
+	n, _ := slices.BinarySearchFunc(asserts, count, func(e LogEntry, c int64) int {
+		return cmp.Compare(e.LineNo, c)
+	})
+	return n
+}
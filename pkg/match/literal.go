@@ -0,0 +1,144 @@
+package match
+
+import (
+	"regexp/syntax"
+)
+
+// requiredLiteral returns a literal substring that must appear in any line
+// matched by the regular expression, or "" if none can be determined.  When
+// several literals are required, the longest is returned since it is the most
+// selective.  Case folded literals are ignored.
+func requiredLiteral(expr string) string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	return literalOf(re.Simplify())
+}
+
+func literalOf(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return ""
+		}
+		return string(re.Rune)
+	case syntax.OpCapture:
+		return literalOf(re.Sub[0])
+	case syntax.OpPlus:
+		// At least one occurrence is required.
+		return literalOf(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return literalOf(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var (
+			best string
+			run  []rune
+		)
+		// Adjacent literals in a concat form a longer literal.
+		flush := func() {
+			if len(run) > len([]rune(best)) {
+				best = string(run)
+			}
+			run = run[:0]
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && sub.Flags&syntax.FoldCase == 0 {
+				run = append(run, sub.Rune...)
+				continue
+			}
+			flush()
+			if lit := literalOf(sub); len(lit) > len(best) {
+				best = lit
+			}
+		}
+		flush()
+		return best
+	}
+	return ""
+}
+
+// termLiteral returns a literal required by any line the term matches, or ""
+// if the term cannot be prefiltered.
+func termLiteral(term TermT) string {
+	switch term.Type {
+	case TermRaw:
+		if term.Options&TermOptNoCase != 0 {
+			// The prefilter is case sensitive.
+			return ""
+		}
+		return term.Value
+	case TermRegex:
+		return requiredLiteral(term.Value)
+	default:
+		return ""
+	}
+}
+
+// Cap on the literals in a set; past it, the set is too broad to be selective.
+const maxLiteralSet = 16
+
+// requiredLiterals returns a set of literals of which at least one must appear
+// in any line matched by the regular expression, or nil if none can be
+// determined.  It generalizes requiredLiteral to alternations, so that
+// `(fail|err)` yields {fail, err}.
+func requiredLiterals(expr string) []string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return literalSetOf(re.Simplify())
+}
+
+func literalSetOf(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if lit := literalOf(re); lit != "" {
+			return []string{lit}
+		}
+	case syntax.OpCapture, syntax.OpPlus:
+		return literalSetOf(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return literalSetOf(re.Sub[0])
+		}
+	case syntax.OpAlternate:
+		var set []string
+		for _, sub := range re.Sub {
+			lits := literalSetOf(sub)
+			if lits == nil {
+				// A branch without a literal lets any line through.
+				return nil
+			}
+			set = append(set, lits...)
+		}
+		if len(set) <= maxLiteralSet {
+			return set
+		}
+	case syntax.OpConcat:
+		// Any required part will do; prefer the one whose shortest literal
+		// is longest, as it is the most selective.
+		var best []string
+		if lit := literalOf(re); lit != "" {
+			best = []string{lit}
+		}
+		for _, sub := range re.Sub {
+			if lits := literalSetOf(sub); shortest(lits) > shortest(best) {
+				best = lits
+			}
+		}
+		return best
+	}
+	return nil
+}
+
+func shortest(lits []string) (n int) {
+	for i, lit := range lits {
+		if i == 0 || len(lit) < n {
+			n = len(lit)
+		}
+	}
+	return
+}
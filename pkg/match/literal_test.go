@@ -0,0 +1,115 @@
+package match
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRequiredLiteral(t *testing.T) {
+
+	cases := map[string]string{
+		`error`:                 "error",
+		`^error: \d+`:           "error: ",
+		`conn(ection)? refused`: " refused",
+		`(timeout)+`:            "timeout",
+		`x{2,}`:                 "x",
+		`x*`:                    "",
+		`\d+`:                   "",
+		`(?i)error`:             "",
+		`foo|bar`:               "",
+		`a.*longer`:             "longer",
+		`(`:                     "",
+	}
+
+	for expr, exp := range cases {
+		if got := requiredLiteral(expr); got != exp {
+			t.Errorf("%s: Expected %q, got %q", expr, exp, got)
+		}
+	}
+}
+
+func TestRequiredLiterals(t *testing.T) {
+
+	cases := map[string][]string{
+		`error`:                      {"error"},
+		`foo|bar`:                    {"foo", "bar"},
+		`(fail|err)\w*`:              {"fail", "err"},
+		`[a-z]+-7\s+(fail|err)`:      {"fail", "err"},
+		`(timeout|refused)\s+port\d`: {"timeout", "refused"},
+		`(foo|\d+)`:                  nil,
+		`(?i)foo|bar`:                nil,
+		`(foo|bar)?x`:                {"x"},
+		`\d+`:                        nil,
+		`(`:                          nil,
+	}
+
+	for expr, exp := range cases {
+		got := requiredLiterals(expr)
+		slices.Sort(got)
+		slices.Sort(exp)
+		if !slices.Equal(got, exp) {
+			t.Errorf("%s: Expected %q, got %q", expr, exp, got)
+		}
+	}
+}
+
+func TestTermLiteralNoCase(t *testing.T) {
+	if got := termLiteral(TermT{Type: TermRaw, Value: "Error", Options: TermOptWord}); got != "Error" {
+		t.Errorf("Expected %q, got %q", "Error", got)
+	}
+	if got := termLiteral(TermT{Type: TermRaw, Value: "Error", Options: TermOptNoCase}); got != "" {
+		t.Errorf("Expected no literal, got %q", got)
+	}
+}
+
+func TestRegexLiteralGuard(t *testing.T) {
+
+	var (
+		exprs = []string{`Failed to pull image .*`, `^error: \d+`, `conn(ection)? refused`, `\d{3}`, `(?i)error`, `a.*longer`}
+		lines = []string{"", "Failed to pull image nginx", "Failed to pull", "error: 42", "xerror: 42", "connection refused", "conn refused", "ERROR 500", "a then longer", "longer a"}
+	)
+
+	for _, expr := range exprs {
+		var (
+			exp    = regexp.MustCompile(expr)
+			m, err = TermT{Type: TermRegex, Value: expr}.NewMatcher()
+		)
+		if err != nil {
+			t.Fatalf("Expected err == nil, got %v", err)
+		}
+		bm, err := TermT{Type: TermRegex, Value: expr}.NewBytesMatcher()
+		if err != nil {
+			t.Fatalf("Expected err == nil, got %v", err)
+		}
+
+		for _, line := range lines {
+			want := exp.MatchString(line)
+			if got := m(NewScanLine().ResetLine(0, line)); got != want {
+				t.Errorf("%s on %q: Expected %v, got %v", expr, line, want, got)
+			}
+			if got := bm([]byte(line)); got != want {
+				t.Errorf("%s on %q: Expected bytes %v, got %v", expr, line, want, got)
+			}
+		}
+	}
+}
+
+func BenchmarkSequenceMissesRegex(b *testing.B) {
+	sm, err := NewMatchSeq(int64(time.Second),
+		TermT{Type: TermRegex, Value: `Failed to pull image .*`},
+		TermT{Type: TermRegex, Value: `Back-off pulling image "[^"]+"`},
+	)
+	if err != nil {
+		b.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	noop := NewScanLine().ResetLine(time.Now().UnixNano(), "I0101 00:00:00.000000 1 kubelet.go:100] Pod sandbox status is ready")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		noop.Timestamp += 1
+		sm.Scan(noop)
+	}
+}
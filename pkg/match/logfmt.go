@@ -0,0 +1,223 @@
+package match
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrLogfmtSpec = errors.New("invalid logfmt predicate")
+
+// A TermLogfmt value is a space separated list of predicates, all of which must
+// hold for the line to match:
+//
+//	key          key is present
+//	key=value    value equals
+//	key!=value   key is present and value differs
+//	key~value    value contains
+//	key>n        numeric comparison; also >=, < and <=
+//
+// Values may be double quoted, as in msg="connection refused".  A predicate on
+// a key absent from the line is false.  The parsed line is cached on the
+// ScanLine, so several logfmt terms on the same line parse it once.
+
+type logfmtOpT int
+
+const (
+	logfmtExists logfmtOpT = iota
+	logfmtEq
+	logfmtNe
+	logfmtContains
+	logfmtGt
+	logfmtGe
+	logfmtLt
+	logfmtLe
+)
+
+type logfmtPredT struct {
+	key string
+	op  logfmtOpT
+	val string
+	num float64
+}
+
+// Longest operators first so that ">=" is not read as ">".
+var logfmtOps = []struct {
+	tok string
+	op  logfmtOpT
+}{
+	{"!=", logfmtNe},
+	{">=", logfmtGe},
+	{"<=", logfmtLe},
+	{"=", logfmtEq},
+	{"~", logfmtContains},
+	{">", logfmtGt},
+	{"<", logfmtLt},
+}
+
+func makeLogfmtMatch(term string) (MatchFunc, error) {
+	preds, err := parseLogfmtPreds(term)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(e *ScanLine) bool {
+		kv := e.DecodeLogfmt()
+		for _, p := range preds {
+			if !p.eval(kv) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (p logfmtPredT) eval(kv map[string]string) bool {
+	v, ok := kv[p.key]
+	if !ok {
+		return false
+	}
+
+	switch p.op {
+	case logfmtExists:
+		return true
+	case logfmtEq:
+		return v == p.val
+	case logfmtNe:
+		return v != p.val
+	case logfmtContains:
+		return strings.Contains(v, p.val)
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return false
+	}
+
+	switch p.op {
+	case logfmtGt:
+		return n > p.num
+	case logfmtGe:
+		return n >= p.num
+	case logfmtLt:
+		return n < p.num
+	default:
+		return n <= p.num
+	}
+}
+
+func parseLogfmtPreds(term string) ([]logfmtPredT, error) {
+	var preds []logfmtPredT
+
+	for _, clause := range splitLogfmt(term) {
+		p, err := parseLogfmtPred(clause)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, p)
+	}
+
+	if len(preds) == 0 {
+		return nil, ErrLogfmtSpec
+	}
+	return preds, nil
+}
+
+func parseLogfmtPred(clause string) (p logfmtPredT, err error) {
+	idx := strings.IndexAny(clause, "!=~<>")
+	if idx < 0 {
+		p.key = clause
+		return
+	}
+
+	p.key = clause[:idx]
+	rest := clause[idx:]
+
+	for _, o := range logfmtOps {
+		if strings.HasPrefix(rest, o.tok) {
+			p.op = o.op
+			rest = rest[len(o.tok):]
+			break
+		}
+	}
+
+	switch {
+	case p.key == "":
+		return p, ErrLogfmtSpec
+	case p.op == logfmtExists:
+		// A lone '!' that is not part of "!=".
+		return p, ErrLogfmtSpec
+	}
+
+	if p.val, err = unquoteLogfmt(rest); err != nil {
+		return p, ErrLogfmtSpec
+	}
+
+	switch p.op {
+	case logfmtGt, logfmtGe, logfmtLt, logfmtLe:
+		if p.num, err = strconv.ParseFloat(p.val, 64); err != nil {
+			return p, ErrLogfmtSpec
+		}
+	}
+
+	return p, nil
+}
+
+// Split on spaces outside of double quotes.
+func splitLogfmt(s string) []string {
+	var (
+		out     []string
+		start   = -1
+		inQuote bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inQuote && c == '\\':
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case !inQuote && (c == ' ' || c == '\t'):
+			if start >= 0 {
+				out = append(out, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+func unquoteLogfmt(v string) (string, error) {
+	if len(v) == 0 || v[0] != '"' {
+		return v, nil
+	}
+	return strconv.Unquote(v)
+}
+
+// Parse the key=value pairs of a logfmt line.  A key without '=' is recorded
+// with an empty value.  An unterminated or malformed quoted value is kept as is.
+func parseLogfmt(line string) map[string]string {
+	kv := make(map[string]string)
+
+	for _, tok := range splitLogfmt(strings.TrimRight(line, "\r\n")) {
+		key, val, _ := strings.Cut(tok, "=")
+		if key == "" {
+			continue
+		}
+		if uq, err := unquoteLogfmt(val); err == nil {
+			val = uq
+		}
+		kv[key] = val
+	}
+
+	return kv
+}
@@ -0,0 +1,98 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLogfmtMatch(t *testing.T) {
+
+	const line = `level=error msg="connection timeout" svc=api latency=312.5 retry`
+
+	tests := map[string]struct {
+		term  string
+		match bool
+	}{
+		"Exists":          {term: "retry", match: true},
+		"ExistsMissing":   {term: "user", match: false},
+		"Eq":              {term: "level=error", match: true},
+		"EqMiss":          {term: "level=warn", match: false},
+		"EqQuoted":        {term: `msg="connection timeout"`, match: true},
+		"Ne":              {term: "svc!=web", match: true},
+		"NeMissing":       {term: "user!=root", match: false},
+		"Contains":        {term: "msg~timeout", match: true},
+		"ContainsQuoted":  {term: `msg~"tion tim"`, match: true},
+		"Gt":              {term: "latency>300", match: true},
+		"Ge":              {term: "latency>=312.5", match: true},
+		"Lt":              {term: "latency<300", match: false},
+		"Le":              {term: "latency<=312.5", match: true},
+		"NumericNotANum":  {term: "svc>1", match: false},
+		"And":             {term: "level=error svc=api latency>250", match: true},
+		"AndMiss":         {term: "level=error svc=web", match: false},
+		"EmptyValue":      {term: "retry=", match: true},
+		"ValueWithEquals": {term: "level=error=x", match: false},
+	}
+
+	sl := NewScanLine().ResetLine(1, line)
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := TermT{Type: TermLogfmt, Value: tc.term}.NewMatcher()
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+			if got := m(sl); got != tc.match {
+				t.Errorf("Expected %v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestLogfmtBadTerm(t *testing.T) {
+	for _, term := range []string{"=x", "a!x", "latency>fast", `msg="open`, "  "} {
+		_, err := TermT{Type: TermLogfmt, Value: term}.NewMatcher()
+		if !errors.Is(err, ErrTermCompile) || !errors.Is(err, ErrLogfmtSpec) {
+			t.Errorf("%q: Expected %v, got %v", term, ErrLogfmtSpec, err)
+		}
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	kv := parseLogfmt(`a=1 b="x \"y\" z" c= d e="unterminated` + "\n")
+
+	exp := map[string]string{
+		"a": "1",
+		"b": `x "y" z`,
+		"c": "",
+		"d": "",
+		"e": `"unterminated`,
+	}
+
+	if len(kv) != len(exp) {
+		t.Fatalf("Expected %v, got %v", exp, kv)
+	}
+	for k, v := range exp {
+		if kv[k] != v {
+			t.Errorf("Key %q: expected %q, got %q", k, v, kv[k])
+		}
+	}
+}
+
+func TestLogfmtCache(t *testing.T) {
+	sl := NewScanLine().ResetLine(1, "level=error svc=api")
+
+	kv := sl.DecodeLogfmt()
+	kv["sentinel"] = "x"
+
+	// JSON decode does not evict the logfmt cache.
+	_, _ = sl.DecodeJson()
+	if sl.DecodeLogfmt()["sentinel"] != "x" {
+		t.Errorf("Expected cached logfmt map")
+	}
+
+	// A new line does.
+	sl.ResetLine(2, "level=warn")
+	if kv := sl.DecodeLogfmt(); kv["sentinel"] != "" || kv["level"] != "warn" {
+		t.Errorf("Expected fresh logfmt map, got %v", kv)
+	}
+}
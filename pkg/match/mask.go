@@ -1,28 +1,101 @@
 package match
 
-type bitMaskT uint64
+import (
+	"math/bits"
+	"slices"
+)
+
+// bitMaskT is a set of term slots.  The first 64 slots are held inline; sets
+// with more terms spill into hi, a word per 64 slots.
+type bitMaskT struct {
+	lo uint64
+	hi []uint64
+}
+
+func makeBitMask(lo uint64, hi []uint64) bitMaskT {
+	return bitMaskT{lo: lo, hi: slices.Clone(hi)}
+}
 
 func (m *bitMaskT) Set(slot int) {
-	*m |= (1 << uint64(slot))
+	if slot < 64 {
+		m.lo |= 1 << uint(slot)
+		return
+	}
+
+	w := slot/64 - 1
+	if w >= len(m.hi) {
+		m.hi = append(m.hi, make([]uint64, w-len(m.hi)+1)...)
+	}
+	m.hi[w] |= 1 << uint(slot%64)
 }
 
 func (m *bitMaskT) Clr(slot int) {
-	*m &= ^(1 << uint64(slot))
+	if slot < 64 {
+		m.lo &^= 1 << uint(slot)
+		return
+	}
+
+	if w := slot/64 - 1; w < len(m.hi) {
+		m.hi[w] &^= 1 << uint(slot%64)
+	}
 }
 
 func (m *bitMaskT) Reset() {
-	*m = 0
+	m.lo = 0
+	clear(m.hi)
 }
 
 func (m bitMaskT) Zeros() bool {
-	return m == 0
+	if m.lo != 0 {
+		return false
+	}
+	for _, w := range m.hi {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
 }
 
+// FirstN returns true if slots 0 through n-1 are all set.
 func (m bitMaskT) FirstN(n int) bool {
-	mask := bitMaskT(1)<<n - 1
-	return m&mask == mask
+	if n <= 64 {
+		mask := uint64(1)<<uint(n) - 1
+		return m.lo&mask == mask
+	}
+
+	if m.lo != ^uint64(0) {
+		return false
+	}
+
+	n -= 64
+	for _, w := range m.hi {
+		if n <= 64 {
+			mask := uint64(1)<<uint(n) - 1
+			return w&mask == mask
+		}
+		if w != ^uint64(0) {
+			return false
+		}
+		n -= 64
+	}
+	return false
 }
 
 func (m bitMaskT) IsSet(slot int) bool {
-	return (m & bitMaskT(1<<slot)) != 0
+	if slot < 64 {
+		return m.lo&(1<<uint(slot)) != 0
+	}
+
+	w := slot/64 - 1
+	return w < len(m.hi) && m.hi[w]&(1<<uint(slot%64)) != 0
+}
+
+// Count returns the number of slots set.
+func (m bitMaskT) Count() int {
+	n := bits.OnesCount64(m.lo)
+	for _, w := range m.hi {
+		n += bits.OnesCount64(w)
+	}
+	return n
 }
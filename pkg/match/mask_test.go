@@ -56,3 +56,45 @@ func TestBitMaskT(t *testing.T) {
 		t.Errorf("Expected Zeros to return false when a bit is set")
 	}
 }
+
+func TestBitMaskWide(t *testing.T) {
+	var m bitMaskT
+
+	for _, slot := range []int{0, 63, 64, 130} {
+		m.Set(slot)
+		if !m.IsSet(slot) {
+			t.Errorf("Expected slot %d to be set", slot)
+		}
+	}
+	if m.IsSet(65) || m.IsSet(500) {
+		t.Errorf("Expected slots 65 and 500 to be unset")
+	}
+
+	m.Clr(130)
+	m.Clr(500)
+	if m.IsSet(130) {
+		t.Errorf("Expected slot 130 to be cleared")
+	}
+
+	m.Reset()
+	if !m.Zeros() {
+		t.Errorf("Expected mask to be zero after reset")
+	}
+
+	for i := range 130 {
+		m.Set(i)
+	}
+	for _, n := range []int{1, 64, 65, 128, 130} {
+		if !m.FirstN(n) {
+			t.Errorf("Expected first %d bits to be set", n)
+		}
+	}
+	if m.FirstN(131) || m.FirstN(200) {
+		t.Errorf("Expected first 131 bits not to be set")
+	}
+
+	m.Clr(70)
+	if m.FirstN(130) || !m.FirstN(70) {
+		t.Errorf("Expected only the first 70 bits to be set")
+	}
+}
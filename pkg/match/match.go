@@ -16,8 +16,25 @@ var (
 	ErrTermType    = errors.New("unknown term type")
 	ErrTermEmpty   = errors.New("empty term")
 	ErrTermCompile = errors.New("term compile error")
+	ErrTermOptions = errors.New("term options unsupported for term type")
+	ErrTermCount   = errors.New("negative term count")
 )
 
+// Matcher is implemented by every matcher, so a runtime can drive any of
+// them the same way.
+//
+// Scan processes an entry and returns the hits it completes.  Entries are
+// expected in timestamp order; one older than the matcher clock is late.
+//
+// Eval advances the matcher clock to clock without an entry, asserting that
+// no older entry will follow.  It returns the hits that were waiting only on
+// time, such as a sequence whose reset window has closed, and drops state that
+// can no longer match.  Eval at or behind the matcher clock does nothing, so
+// it may be called freely, for example on a timer.  Edge triggered matchers
+// fire only on Scan and never return hits from Eval.
+//
+// GarbageCollect drops state that has left the window ending at clock,
+// without advancing the matcher clock.
 type Matcher interface {
 	Eval(int64) Hits
 	Scan(*ScanLine) Hits
@@ -33,6 +50,11 @@ const (
 	TermRegex
 	TermJqJson
 	TermJqYaml
+	TermJqJsonDiff
+	TermFuzzy
+	TermLogfmt
+	TermNumeric
+	TermCIDR
 )
 
 const (
@@ -40,6 +62,11 @@ const (
 	termNameRegex   = "regex"
 	termNameJqJson  = "jqJson"
 	termNameJqYaml  = "jqYaml"
+	termNameJqDiff  = "jqJsonDiff"
+	termNameFuzzy   = "fuzzy"
+	termNameLogfmt  = "logfmt"
+	termNameNumeric = "numeric"
+	termNameCIDR    = "cidr"
 	termNameUnknown = "unknown"
 )
 
@@ -51,25 +78,85 @@ func (t TermTypeT) String() string {
 		return termNameJqJson
 	case TermJqYaml:
 		return termNameJqYaml
+	case TermJqJsonDiff:
+		return termNameJqDiff
 	case TermRegex:
 		return termNameRegex
+	case TermFuzzy:
+		return termNameFuzzy
+	case TermLogfmt:
+		return termNameLogfmt
+	case TermNumeric:
+		return termNameNumeric
+	case TermCIDR:
+		return termNameCIDR
 	default:
 		return termNameUnknown
 	}
 }
 
+// TermOptT is a bitmask of matching options; TermRaw only.
+type TermOptT uint8
+
+const (
+	TermOptNoCase TermOptT = 1 << iota // Case insensitive match.
+	TermOptWord                        // Match must be bounded by non-word characters.
+)
+
 type TermT struct {
-	Type  TermTypeT
-	Value string
+	Type     TermTypeT
+	Value    string
+	Distance int      // Maximum edit distance; TermFuzzy only.
+	Options  TermOptT // Matching options; TermRaw only.
+	Count    int      // Consecutive occurrences required; sequences and sets only.
+
+	// MinSeverity, if set, requires a line of at least this severity, so that
+	// a line of unknown severity never matches; see LogEntry.Severity.
+	MinSeverity entry.SeverityT
+}
+
+// Occurrences required of the term; a zero count is one.
+func (tt TermT) occurs() int {
+	return max(tt.Count, 1)
+}
+
+// The term without its count, for comparison of repeated terms.
+func (tt TermT) uncounted() TermT {
+	tt.Count = 0
+	return tt
+}
+
+// Number of anchors in a sequence, including repeats and counts.
+func countAnchors(terms []TermT) (n int) {
+	for _, tt := range terms {
+		n += tt.occurs()
+	}
+	return
 }
 
 type MatchFunc func(*ScanLine) bool
 
-func (tt TermT) NewMatcher() (m MatchFunc, err error) {
+// NewMatcher compiles the term, sharing the compiled term through the
+// TermCache unless disabled with SetTermCache.
+func (tt TermT) NewMatcher() (MatchFunc, error) {
+	if c := termCache.Load(); c != nil {
+		return c.Get(tt)
+	}
+	return tt.compile()
+}
+
+func (tt TermT) compile() (m MatchFunc, err error) {
 
-	if tt.Value == "" {
+	switch {
+	case tt.Value == "":
 		err = ErrTermEmpty
 		return
+	case tt.Options != 0 && tt.Type != TermRaw:
+		err = ErrTermOptions
+		return
+	case tt.Count < 0:
+		err = ErrTermCount
+		return
 	}
 
 	switch tt.Type {
@@ -77,19 +164,68 @@ func (tt TermT) NewMatcher() (m MatchFunc, err error) {
 		if m, err = makeJqMatch(tt); err != nil {
 			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
 		}
+	case TermJqJsonDiff:
+		if m, err = makeJqDiffMatch(tt.Value); err != nil {
+			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
+		}
 	case TermRegex:
 		if m, err = makeRegexMatch(tt.Value); err != nil {
 			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
 		}
+	case TermFuzzy:
+		if m, err = makeFuzzyMatch(tt.Value, tt.Distance); err != nil {
+			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
+		}
+	case TermLogfmt:
+		if m, err = makeLogfmtMatch(tt.Value); err != nil {
+			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
+		}
+	case TermNumeric:
+		if m, err = makeNumericMatch(tt.Value); err != nil {
+			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
+		}
+	case TermCIDR:
+		if m, err = makeCIDRMatch(tt.Value); err != nil {
+			err = fmt.Errorf("%w type:'%s' value:'%s': %w", ErrTermCompile, tt.Type.String(), tt.Value, err)
+		}
 	case TermRaw:
-		m = makeRawMatch(tt.Value)
+		if tt.Options != 0 {
+			m = makeRawOptMatch(tt.Value, tt.Options)
+		} else {
+			m = makeRawMatch(tt.Value)
+		}
 	default:
 		err = ErrTermType
 	}
 
+	switch {
+	case err != nil:
+	case tt.Type == TermJqJson, tt.Type == TermJqYaml:
+	default:
+		// Only jq terms extract props; drop those of a previous term.
+		m = makeScopedMatch(m)
+	}
+
+	if err == nil && tt.MinSeverity != entry.SevUnknown {
+		m = makeSeverityMatch(tt.MinSeverity, m)
+	}
+
 	return
 }
 
+func makeScopedMatch(m MatchFunc) MatchFunc {
+	return func(e *ScanLine) bool {
+		e.extract = nil
+		return m(e)
+	}
+}
+
+func makeSeverityMatch(sev entry.SeverityT, m MatchFunc) MatchFunc {
+	return func(e *ScanLine) bool {
+		return e.Severity >= sev && m(e)
+	}
+}
+
 func IsRegex(v string) bool {
 	return regexp.QuoteMeta(v) != v
 }
@@ -106,6 +242,13 @@ func makeRegexMatch(term string) (MatchFunc, error) {
 		return nil, err
 	}
 
+	// Like grep, skip the regex engine when a required literal is absent.
+	if lit := requiredLiteral(term); lit != "" {
+		return func(e *ScanLine) bool {
+			return strings.Contains(e.Line, lit) && exp.MatchString(e.Line)
+		}, nil
+	}
+
 	return func(e *ScanLine) bool {
 		return exp.MatchString(e.Line)
 	}, nil
@@ -169,6 +312,9 @@ func _makeJqMatch(term string, code *gojq.Code, unmarshal unmarshalFuncT) MatchF
 			v   any
 		)
 
+		// Props extracted by a previous term are not ours.
+		e.extract = nil
+
 		// Unmarshal the line (JSON or YAML) into an interface{} for gojq to consume.
 		// The ScanLine will cache the result, so this is only expensive on the first call for a given line.
 		if v, err = unmarshal(e); err != nil {
@@ -193,14 +339,18 @@ func _makeJqMatch(term string, code *gojq.Code, unmarshal unmarshalFuncT) MatchF
 				break
 			}
 
-			if res != nil {
-				if v, ok := res.(bool); ok {
-					if v {
-						match = true
-					}
-				} else {
+			switch v := res.(type) {
+			case nil:
+			case bool:
+				if v {
 					match = true
 				}
+			case map[string]any:
+				// An object is truthy; its keys are extracted as props.
+				e.extractProps(v)
+				match = true
+			default:
+				match = true
 			}
 		}
 
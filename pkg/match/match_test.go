@@ -3,6 +3,8 @@ package match
 import (
 	"errors"
 	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
 )
 
 func TestMatchJson(t *testing.T) {
@@ -227,6 +229,11 @@ func TestTermString(t *testing.T) {
 		{TermRegex, termNameRegex},
 		{TermJqJson, termNameJqJson},
 		{TermJqYaml, termNameJqYaml},
+		{TermJqJsonDiff, termNameJqDiff},
+		{TermFuzzy, termNameFuzzy},
+		{TermLogfmt, termNameLogfmt},
+		{TermNumeric, termNameNumeric},
+		{TermCIDR, termNameCIDR},
 		{TermTypeT(999), termNameUnknown},
 	}
 
@@ -655,3 +662,241 @@ func TestJqMatchErrorHandling(t *testing.T) {
 func scanLineFromLine(line string) *ScanLine {
 	return NewScanLine().ResetLine(0, line)
 }
+
+func TestJqExtractProps(t *testing.T) {
+
+	seq, err := NewMatchSeq(10,
+		TermT{Type: TermJqJson, Value: `select(.status >= 500) | {pod: .kubernetes.pod_name, code: .status}`},
+		TermT{Type: TermJqJson, Value: `select(.msg == "restart") | {restarts: .count}`},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+
+	if hits := seq.Scan(sl.ResetLine(1, `{"status":503,"kubernetes":{"pod_name":"api-0"}}`)); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+
+	hits := seq.Scan(sl.ResetLine(2, `{"msg":"restart","count":3}`))
+	if hits.Cnt != 1 {
+		t.Fatalf("Expected 1 hit, got %v", hits.Cnt)
+	}
+
+	props := hits.IndexProps(0)
+	if len(props) != 3 || props["pod"] != "api-0" || props["code"] != 503.0 || props["restarts"] != 3.0 {
+		t.Errorf("Expected pod, code and restarts props, got %v", props)
+	}
+
+	// Props are per line; the next line starts clean.
+	if sl.ResetLine(3, `{}`); sl.Entry().Props != nil {
+		t.Errorf("Expected nil props on reset, got %v", sl.Entry().Props)
+	}
+}
+
+func TestJqExtractPropsScoped(t *testing.T) {
+
+	m1, _ := TermT{Type: TermJqJson, Value: `{a: .a}`}.NewMatcher()
+	m2, _ := TermT{Type: TermJqJson, Value: `{b: .b}`}.NewMatcher()
+	m3, _ := TermT{Type: TermRaw, Value: `"a"`}.NewMatcher()
+
+	sl := NewScanLine().ResetLine(1, `{"a":1,"b":2}`)
+
+	if !m1(sl) {
+		t.Fatalf("Expected match")
+	}
+	held := sl.Entry()
+
+	if !m2(sl) {
+		t.Fatalf("Expected match")
+	}
+	if props := sl.Entry().Props; len(props) != 1 || props["b"] != 2.0 {
+		t.Errorf("Expected only b, got %v", props)
+	}
+	if len(held.Props) != 1 || held.Props["a"] != 1.0 {
+		t.Errorf("Expected held entry unchanged, got %v", held.Props)
+	}
+
+	if !m3(sl) {
+		t.Fatalf("Expected match")
+	}
+	if props := sl.Entry().Props; props != nil {
+		t.Errorf("Expected no props on raw term, got %v", props)
+	}
+	if sl.Props != nil {
+		t.Errorf("Expected line props untouched, got %v", sl.Props)
+	}
+}
+
+func TestJqExtractPropsMulti(t *testing.T) {
+
+	var (
+		termA = TermT{Type: TermJqJson, Value: `{pod: .pod}`}
+		termB = TermT{Type: TermRaw, Value: `boom`}
+	)
+
+	a, err := NewMatchSingle(termA)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	b, err := NewMatchSingle(termB)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	mm, _ := NewMultiMatcher()
+	mm.Add(a, termA)
+	mm.Add(b, termB)
+
+	got := make(map[int]map[string]any)
+	mm.Scan(NewScanLine().ResetLine(1, `{"pod":"p1","msg":"boom"}`), func(idx int, hits Hits) {
+		got[idx] = hits.IndexProps(0)
+	})
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 hits, got %v", got)
+	}
+	if got[0]["pod"] != "p1" {
+		t.Errorf("Expected pod:p1 on jq hit, got %v", got[0])
+	}
+	if _, ok := got[1]["pod"]; ok {
+		t.Errorf("Expected no pod on raw hit, got %v", got[1])
+	}
+}
+
+func TestEvalEdgeTriggered(t *testing.T) {
+
+	tests := map[string]func() (Matcher, error){
+		"set": func() (Matcher, error) {
+			return NewMatchSet(10, makeTermsA("alpha", "beta")...)
+		},
+		"seq": func() (Matcher, error) {
+			return NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+		},
+		"count": func() (Matcher, error) {
+			return NewMatchCount(10, 2, makeRaw("alpha"))
+		},
+		"rate": func() (Matcher, error) {
+			return NewMatchRate(2, 1, makeRaw("alpha"))
+		},
+	}
+
+	for name, newF := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := newF()
+			if err != nil {
+				t.Fatalf("Expected err == nil, got %v", err)
+			}
+
+			sl := NewScanLine()
+
+			if hits := m.Scan(sl.ResetLine(1, "alpha")); hits.Cnt != 0 {
+				t.Fatalf("Expected no hits, got %v", hits)
+			}
+			if hits := m.Eval(20); hits.Cnt != 0 {
+				t.Errorf("Expected no hits on eval, got %v", hits)
+			}
+
+			// Behind the clock; late and dropped.
+			if hits := m.Scan(sl.ResetLine(15, "alpha")); hits.Cnt != 0 {
+				t.Errorf("Expected no hits on late entry, got %v", hits)
+			}
+			if s := statsOf(m); s.Dropped != 1 {
+				t.Errorf("Expected 1 dropped, got %d", s.Dropped)
+			}
+
+			// The first entry left the window on eval.
+			if s := statsOf(m); s.Buffered != 0 || s.LastGC != 20 {
+				t.Errorf("Expected nothing buffered after GC at 20, got %d at %d", s.Buffered, s.LastGC)
+			}
+
+			// Eval behind the clock does nothing.
+			m.Eval(5)
+			if s := statsOf(m); s.LastGC != 20 {
+				t.Errorf("Expected no GC behind the clock, got %d", s.LastGC)
+			}
+		})
+	}
+}
+
+func TestEvalGCInterval(t *testing.T) {
+
+	tests := map[string]func() (Matcher, error){
+		"set": func() (Matcher, error) {
+			return NewMatchSetOpts(10, makeTermsA("alpha", "beta"), WithGCInterval(100))
+		},
+		"seq": func() (Matcher, error) {
+			return NewMatchSeqOpts(10, makeTermsA("alpha", "beta"), WithGCInterval(100))
+		},
+		"count": func() (Matcher, error) {
+			return NewMatchCount(10, 2, makeRaw("alpha"), WithGCInterval(100))
+		},
+		"rate": func() (Matcher, error) {
+			return NewMatchRate(2, 1, makeRaw("alpha"), WithGCInterval(100))
+		},
+	}
+
+	for name, newF := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := newF()
+			if err != nil {
+				t.Fatalf("Expected err == nil, got %v", err)
+			}
+
+			sl := NewScanLine()
+			m.Scan(sl.ResetLine(1, "alpha"))
+
+			// Within the interval; the entry is held past the window.
+			m.Eval(20)
+			if s := statsOf(m); s.LastGC == 20 {
+				t.Errorf("Expected no GC at 20")
+			}
+
+			m.Eval(200)
+			if s := statsOf(m); s.Buffered != 0 || s.LastGC != 200 {
+				t.Errorf("Expected GC at 200, got %d buffered at %d", s.Buffered, s.LastGC)
+			}
+
+			m.Eval(250)
+			if s := statsOf(m); s.LastGC != 200 {
+				t.Errorf("Expected no GC at 250, got %d", s.LastGC)
+			}
+		})
+	}
+}
+
+func TestTermMinSeverity(t *testing.T) {
+	tt := TermT{Type: TermRegex, Value: `time(out|d out)`, MinSeverity: entry.SevWarn}
+
+	m, err := tt.NewMatcher()
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	tests := map[entry.SeverityT]bool{
+		entry.SevUnknown:   false,
+		entry.SevInfo:      false,
+		entry.SevInfo + 3:  false,
+		entry.SevWarn:      true,
+		entry.SevFatal + 1: true,
+	}
+	for sev, want := range tests {
+		if got := m(sl.Reset(LogEntry{Line: "request timed out", Severity: sev})); got != want {
+			t.Errorf("%v: Expected %v, got %v", sev, want, got)
+		}
+	}
+	if m(sl.Reset(LogEntry{Line: "ok", Severity: entry.SevError})) {
+		t.Errorf("Expected the term still required")
+	}
+
+	// Raw lines carry no severity.
+	bm, err := TermT{Type: TermRaw, Value: "timeout", MinSeverity: entry.SevWarn}.NewBytesMatcher()
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	if bm([]byte("timeout")) {
+		t.Errorf("Expected no match on a raw line")
+	}
+}
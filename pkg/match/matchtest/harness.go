@@ -0,0 +1,167 @@
+package matchtest
+
+import (
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+// CheckFuncT checks the hits returned at a step, numbered from one.
+type CheckFuncT func(t testing.TB, step int, hits match.Hits)
+
+// PostFuncT runs after a step, with the matcher under test.
+type PostFuncT func(t testing.TB, step int, m match.Matcher)
+
+// StepT is a line scanned in a simulation.
+type StepT struct {
+	Stamp int64      // Line timestamp; zero for one past the previous step.
+	Line  string     // Line to scan; empty to skip the scan and only run Post.
+	Check CheckFuncT // Checks the hits from the scan; nil expects none.
+	Post  PostFuncT  // Optional; see Eval and GarbageCollect.
+}
+
+// CaseT is a simulation of a rule over a sequence of steps.
+type CaseT struct {
+	Clock int64                         // Clock before the first step.
+	New   func() (match.Matcher, error) // Builds the matcher under test.
+	Steps []StepT
+}
+
+// CasesT is a table of named simulations, each run as a subtest.
+type CasesT map[string]CaseT
+
+// Run runs each case as a subtest on a fresh matcher.
+func (c CasesT) Run(t *testing.T) {
+	t.Helper()
+
+	for name, tc := range c {
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+			tc.Run(t)
+		})
+	}
+}
+
+// Run scans the steps in order, checking the hits of each.
+func (tc CaseT) Run(t testing.TB) {
+	t.Helper()
+
+	m, err := tc.New()
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var (
+		clock = tc.Clock
+		line  = match.NewScanLine()
+	)
+
+	for idx, step := range tc.Steps {
+
+		clock += 1
+		if step.Stamp != 0 {
+			clock = step.Stamp
+		}
+
+		if step.Line != "" {
+			hits := m.Scan(line.ResetLine(clock, step.Line))
+			if step.Check == nil {
+				NoFire(t, idx+1, hits)
+			} else {
+				step.Check(t, idx+1, hits)
+			}
+		}
+
+		if step.Post != nil {
+			step.Post(t, idx+1, m)
+		}
+	}
+}
+
+// NoFire expects no hits.
+func NoFire(t testing.TB, step int, hits match.Hits) {
+	t.Helper()
+	if hits.Cnt != 0 {
+		t.Errorf("Step %v: Expected 0 hits, got %v", step, hits.Cnt)
+	}
+}
+
+// Stamps expects a single hit on lines with the stamps, in order.
+func Stamps(stamps ...int64) CheckFuncT {
+	return StampsN(1, stamps...)
+}
+
+// StampsN expects cnt hits, whose lines start with the stamps, in order.
+func StampsN(cnt int, stamps ...int64) CheckFuncT {
+	return func(t testing.TB, step int, hits match.Hits) {
+		t.Helper()
+		if !checkCnt(t, step, cnt, len(stamps), hits) {
+			return
+		}
+		for i, stamp := range stamps {
+			if hits.Logs[i].Timestamp != stamp {
+				t.Errorf("Step %v: Expected %v, got %v on index %v", step, stamp, hits.Logs[i].Timestamp, i)
+			}
+		}
+	}
+}
+
+// Lines expects a single hit on the lines, in order.
+func Lines(lines ...string) CheckFuncT {
+	return LinesN(1, lines...)
+}
+
+// LinesN expects cnt hits, whose lines start with lines, in order.
+func LinesN(cnt int, lines ...string) CheckFuncT {
+	return func(t testing.TB, step int, hits match.Hits) {
+		t.Helper()
+		if !checkCnt(t, step, cnt, len(lines), hits) {
+			return
+		}
+		for i, line := range lines {
+			if hits.Logs[i].Line != line {
+				t.Errorf("Step %v: Expected %v, got %v on index %v", step, line, hits.Logs[i].Line, i)
+			}
+		}
+	}
+}
+
+// FireStamp runs check and expects the hits to fire at stamp.
+func FireStamp(stamp int64, check CheckFuncT) CheckFuncT {
+	return func(t testing.TB, step int, hits match.Hits) {
+		t.Helper()
+		check(t, step, hits)
+		if hits.FireStamp != stamp {
+			t.Errorf("Step %v: Expected fire stamp %v, got %v", step, stamp, hits.FireStamp)
+		}
+	}
+}
+
+// Eval evaluates the matcher at clock and checks the hits.
+func Eval(clock int64, check CheckFuncT) PostFuncT {
+	return func(t testing.TB, step int, m match.Matcher) {
+		t.Helper()
+		check(t, step, m.Eval(clock))
+	}
+}
+
+// GarbageCollect collects the matcher at clock.
+func GarbageCollect(clock int64) PostFuncT {
+	return func(t testing.TB, step int, m match.Matcher) {
+		t.Helper()
+		m.GarbageCollect(clock)
+	}
+}
+
+func checkCnt(t testing.TB, step, cnt, nLogs int, hits match.Hits) bool {
+	t.Helper()
+	if hits.Cnt != cnt {
+		t.Errorf("Step %v: Expected %v hits, got %v", step, cnt, hits.Cnt)
+		return false
+	}
+	if len(hits.Logs) < nLogs {
+		t.Errorf("Step %v: Expected at least %v lines, got %v", step, nLogs, len(hits.Logs))
+		return false
+	}
+	return true
+}
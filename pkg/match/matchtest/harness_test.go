@@ -0,0 +1,103 @@
+package matchtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+func newInverse() (match.Matcher, error) {
+	return match.NewInverseSeq(10,
+		[]match.TermT{{Value: "start"}, {Value: "fail"}},
+		[]match.ResetT{{Term: match.TermT{Value: "ok"}, Window: 5}},
+	)
+}
+
+func TestHarness(t *testing.T) {
+	CasesT{
+		"scan": {
+			New: newInverse,
+			Steps: []StepT{
+				{Line: "start"},
+				{Line: "fail"},
+				{Stamp: 20, Line: "noise", Check: FireStamp(20, Stamps(1, 2))},
+			},
+		},
+		"eval": {
+			Clock: 100,
+			New:   newInverse,
+			Steps: []StepT{
+				{Line: "start"},
+				{Line: "fail", Post: Eval(105, NoFire)},
+				{Post: Eval(120, Lines("start", "fail"))},
+			},
+		},
+		"reset": {
+			New: newInverse,
+			Steps: []StepT{
+				{Line: "start"},
+				{Line: "fail"},
+				{Line: "ok", Post: Eval(50, NoFire)},
+			},
+		},
+		"collect": {
+			New: func() (match.Matcher, error) {
+				return match.NewMatchSeq(5, match.TermT{Value: "start"}, match.TermT{Value: "fail"})
+			},
+			Steps: []StepT{
+				{Line: "start"},
+				{Stamp: 20, Post: GarbageCollect(20)},
+				{Line: "fail"},
+			},
+		},
+		"count": {
+			New: func() (match.Matcher, error) {
+				return match.NewMatchSingle(match.TermT{Value: "fail"})
+			},
+			Steps: []StepT{
+				{Line: "fail", Check: LinesN(1, "fail")},
+				{Line: "fail", Check: StampsN(1, 2)},
+			},
+		},
+	}.Run(t)
+}
+
+// Records failures rather than failing the test.
+type recordT struct {
+	testing.TB
+	errs []string
+}
+
+func (r *recordT) Helper() {}
+
+func (r *recordT) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func (r *recordT) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func TestHarnessFails(t *testing.T) {
+	tests := map[string]struct {
+		steps []StepT
+		cnt   int
+	}{
+		"unexpected": {steps: []StepT{{Line: "start"}, {Line: "fail"}, {Stamp: 20, Line: "noise"}}, cnt: 1},
+		"stamps":     {steps: []StepT{{Line: "start"}, {Line: "fail"}, {Stamp: 20, Line: "noise", Check: Stamps(1, 3)}}, cnt: 1},
+		"lines":      {steps: []StepT{{Line: "start"}, {Line: "fail", Post: Eval(20, Lines("fail", "start"))}}, cnt: 2},
+		"missing":    {steps: []StepT{{Line: "start"}, {Line: "noise", Check: Stamps(1)}}, cnt: 1},
+		"fire":       {steps: []StepT{{Line: "start"}, {Line: "fail", Post: Eval(20, FireStamp(21, Stamps(1, 2)))}}, cnt: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := &recordT{TB: t}
+			CaseT{New: newInverse, Steps: tc.steps}.Run(rec)
+			if len(rec.errs) != tc.cnt {
+				t.Errorf("Expected %d failures, got %v", tc.cnt, rec.errs)
+			}
+		})
+	}
+}
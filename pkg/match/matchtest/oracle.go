@@ -0,0 +1,277 @@
+// Package matchtest helps rule authors test their rules.
+//
+// CasesT runs table driven simulations of a rule: given lines with stamps,
+// expect these hits.  For example:
+//
+//	matchtest.CasesT{
+//		"reset": {
+//			New: func() (match.Matcher, error) {
+//				return match.NewInverseSeq(10, terms, resets)
+//			},
+//			Steps: []matchtest.StepT{
+//				{Line: "start"},
+//				{Line: "fail"},
+//				{Stamp: 20, Line: "noise", Check: matchtest.Stamps(1, 2)},
+//			},
+//		},
+//	}.Run(t)
+//
+// InverseSeq and InverseSet are brute force reference implementations of the
+// inverse matchers, for validating rules and the matchers themselves.  The
+// references work over a complete stream rather than line by line, and
+// keep every line, so they are slow but short enough to check by eye.  Each
+// hit is found by the greedy rule the matchers implement:
+//
+//   - A frame is formed from the earliest unused line of each term; for a
+//     sequence, each term's line must follow the previous term's.
+//   - If the frame spans more than the window, its earliest line is dropped.
+//   - Otherwise, if a reset line falls in a reset window, the anchor of that
+//     reset is dropped.
+//   - Otherwise the frame is a hit, and its lines are used.
+//
+// The references cover a subset of the matchers' options: no repeated terms
+// or counts, resets without AnchorEnd or Correlate, and no match options.  The
+// stream must be in strictly increasing time order, and no line may match
+// more than one term; lines may match any number of resets.  Inputs outside
+// the subset are rejected rather than answered.
+package matchtest
+
+import (
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+var (
+	ErrUnsupported = errors.New("rule not supported by the reference")
+	ErrOrder       = errors.New("stream not in strictly increasing time order")
+	ErrOverlap     = errors.New("line matches more than one term")
+)
+
+// A rule compiled against a stream.
+type refT struct {
+	window int64
+	resets []match.ResetT
+	lines  []match.LogEntry
+	termOf []int     // Term matched by each line, or -1.
+	stamps [][]int64 // Stamps of the lines matching each reset.
+	used   []bool    // Lines dropped or used by a hit.
+}
+
+// InverseSeq returns the hits match.NewInverseSeq reports for the rule over
+// lines, once the clock has passed every window.
+func InverseSeq(window int64, terms []match.TermT, resets []match.ResetT, lines []match.LogEntry) ([][]match.LogEntry, error) {
+	r, err := compile(window, terms, resets, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits [][]match.LogEntry
+	for {
+		frame, ok := r.seqFrame(len(terms))
+		if !ok {
+			return hits, nil
+		}
+
+		// Lines follow in time, so anchors in term order are in time order.
+		if hit := r.eval(frame, frame); hit {
+			hits = append(hits, r.use(frame))
+		}
+	}
+}
+
+// InverseSet returns the hits match.NewInverseSet reports for the rule over
+// lines, once the clock has passed every window.
+func InverseSet(window int64, terms []match.TermT, resets []match.ResetT, lines []match.LogEntry) ([][]match.LogEntry, error) {
+	r, err := compile(window, terms, resets, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits [][]match.LogEntry
+	for {
+		frame, ok := r.setFrame(len(terms))
+		if !ok {
+			return hits, nil
+		}
+
+		// Set anchors are relative to the frame sorted in time.
+		if hit := r.eval(frame, slices.Sorted(slices.Values(frame))); hit {
+			hits = append(hits, r.use(frame))
+		}
+	}
+}
+
+// Run scans lines with m, then evaluates it at a clock past any window, and
+// returns the lines of each hit in order.
+func Run(m match.Matcher, lines []match.LogEntry) [][]match.LogEntry {
+	var (
+		out  [][]match.LogEntry
+		line = match.NewScanLine()
+	)
+
+	add := func(hits match.Hits) {
+		for hit := range hits.Iter() {
+			out = append(out, slices.Clone(hit.Logs))
+		}
+	}
+
+	for _, e := range lines {
+		add(m.Scan(line.Reset(e)))
+	}
+	add(m.Eval(math.MaxInt64 / 2))
+	return out
+}
+
+func compile(window int64, terms []match.TermT, resets []match.ResetT, lines []match.LogEntry) (*refT, error) {
+	if len(terms) == 0 {
+		return nil, match.ErrNoTerms
+	}
+
+	matchers := make([]match.MatchFunc, 0, len(terms))
+	for i, term := range terms {
+		if term.Count > 1 || term.Options != 0 || slices.Contains(terms[:i], term) {
+			return nil, ErrUnsupported
+		}
+		m, err := term.NewMatcher()
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	resetFns := make([]match.MatchFunc, 0, len(resets))
+	for _, reset := range resets {
+		switch {
+		case reset.AnchorEnd > 0, reset.Correlate != "", reset.CorrelateKey != nil:
+			return nil, ErrUnsupported
+		case int(reset.Anchor) >= len(terms):
+			return nil, match.ErrAnchorRange
+		}
+		m, err := reset.Term.NewMatcher()
+		if err != nil {
+			return nil, err
+		}
+		resetFns = append(resetFns, m)
+	}
+
+	r := &refT{
+		window: window,
+		resets: resets,
+		lines:  lines,
+		termOf: make([]int, len(lines)),
+		stamps: make([][]int64, len(resets)),
+		used:   make([]bool, len(lines)),
+	}
+
+	line := match.NewScanLine()
+	for i, e := range lines {
+		if i > 0 && e.Timestamp <= lines[i-1].Timestamp {
+			return nil, ErrOrder
+		}
+		line.Reset(e)
+
+		r.termOf[i] = -1
+		for j, m := range matchers {
+			if !m(line) {
+				continue
+			}
+			if r.termOf[i] >= 0 {
+				return nil, ErrOverlap
+			}
+			r.termOf[i] = j
+		}
+
+		for j, m := range resetFns {
+			if m(line) {
+				r.stamps[j] = append(r.stamps[j], e.Timestamp)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// The earliest unused line of each term, each following the last.
+func (r *refT) seqFrame(nTerms int) ([]int, bool) {
+	var (
+		frame = make([]int, 0, nTerms)
+		next  = 0
+	)
+	for term := range nTerms {
+		i := r.first(term, next)
+		if i < 0 {
+			return nil, false
+		}
+		frame = append(frame, i)
+		next = i + 1
+	}
+	return frame, true
+}
+
+// The earliest unused line of each term.
+func (r *refT) setFrame(nTerms int) ([]int, bool) {
+	frame := make([]int, 0, nTerms)
+	for term := range nTerms {
+		i := r.first(term, 0)
+		if i < 0 {
+			return nil, false
+		}
+		frame = append(frame, i)
+	}
+	return frame, true
+}
+
+func (r *refT) first(term, from int) int {
+	for i := from; i < len(r.lines); i++ {
+		if r.termOf[i] == term && !r.used[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// Check the frame against the window and resets; anchors are the frame lines
+// in the order resets count them.  Drops a line and returns false if the frame
+// is not a hit.
+func (r *refT) eval(frame, anchors []int) bool {
+	var (
+		start = r.lines[slices.Min(frame)].Timestamp
+		stop  = r.lines[slices.Max(frame)].Timestamp
+	)
+
+	if stop-start > r.window {
+		r.used[slices.Min(frame)] = true
+		return false
+	}
+
+	for i, reset := range r.resets {
+		anchor := r.lines[anchors[reset.Anchor]].Timestamp + reset.Slide
+
+		width := reset.Window
+		if !reset.Absolute {
+			width += stop - start
+		}
+		width = max(width, 0)
+
+		for _, stamp := range r.stamps[i] {
+			if stamp >= anchor && stamp <= anchor+width {
+				r.used[anchors[reset.Anchor]] = true
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *refT) use(frame []int) []match.LogEntry {
+	hit := make([]match.LogEntry, 0, len(frame))
+	for _, i := range frame {
+		r.used[i] = true
+		hit = append(hit, r.lines[i])
+	}
+	return hit
+}
@@ -0,0 +1,246 @@
+package matchtest
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+const alphabet = "abcdef"
+
+// A rule and stream decoded from fuzz input.
+type caseT struct {
+	window int64
+	terms  []match.TermT
+	resets []match.ResetT
+	lines  []match.LogEntry
+}
+
+func (c caseT) String() string {
+	var lines []string
+	for _, e := range c.lines {
+		lines = append(lines, fmt.Sprintf("%d:%s", e.Timestamp, e.Line))
+	}
+	return fmt.Sprintf("window=%d terms=%v resets=%+v lines=%v", c.window, c.terms, c.resets, lines)
+}
+
+// Bytes consumed in order; zero once exhausted.
+type bytesT []byte
+
+func (b *bytesT) next(n int) int {
+	if len(*b) == 0 {
+		return 0
+	}
+	v := (*b)[0]
+	*b = (*b)[1:]
+	return int(v) % n
+}
+
+// Decode a case: up to four distinct terms, up to three resets, and a stream
+// of single letter lines a few ticks apart.  Letters are drawn from a small
+// alphabet so that streams are dense in terms and resets.
+func decodeCase(data []byte) caseT {
+	var (
+		b     = bytesT(data)
+		c     = caseT{window: int64(b.next(30))}
+		perm  = []byte(alphabet)
+		nTerm = 1 + b.next(4)
+	)
+
+	for i := range perm {
+		j := i + b.next(len(perm)-i)
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	for _, l := range perm[:nTerm] {
+		c.terms = append(c.terms, match.TermT{Type: match.TermRaw, Value: string(l)})
+	}
+
+	for range b.next(4) {
+		c.resets = append(c.resets, match.ResetT{
+			Term:     match.TermT{Type: match.TermRaw, Value: string(alphabet[b.next(len(alphabet))])},
+			Window:   int64(b.next(25) - 5),
+			Slide:    int64(b.next(11) - 5),
+			Anchor:   b.next(nTerm),
+			Absolute: b.next(2) == 1,
+		})
+	}
+
+	var clock int64
+	for len(b) > 0 {
+		clock += 1 + int64(b.next(5))
+		c.lines = append(c.lines, match.LogEntry{Timestamp: clock, Line: string(alphabet[b.next(len(alphabet))])})
+	}
+
+	return c
+}
+
+func randCase(rng *rand.Rand) caseT {
+	data := make([]byte, 8+rng.Intn(120))
+	rng.Read(data)
+	return decodeCase(data)
+}
+
+func checkSeq(t *testing.T, c caseT) {
+	t.Helper()
+
+	want, err := InverseSeq(c.window, c.terms, c.resets, c.lines)
+	if err != nil {
+		t.Fatalf("Reference failed on %v: %v", c, err)
+	}
+
+	m, err := match.NewInverseSeq(c.window, c.terms, c.resets)
+	if err != nil {
+		t.Fatalf("Matcher failed on %v: %v", c, err)
+	}
+
+	if got := Run(m, c.lines); !sameHits(got, want) {
+		t.Fatalf("Mismatch on %v:\nwant %v\ngot  %v", c, want, got)
+	}
+}
+
+func checkSet(t *testing.T, c caseT) {
+	t.Helper()
+
+	want, err := InverseSet(c.window, c.terms, c.resets, c.lines)
+	if err != nil {
+		t.Fatalf("Reference failed on %v: %v", c, err)
+	}
+
+	m, err := match.NewInverseSet(c.window, c.terms, c.resets)
+	if err != nil {
+		t.Fatalf("Matcher failed on %v: %v", c, err)
+	}
+
+	if got := Run(m, c.lines); !sameHits(got, want) {
+		t.Fatalf("Mismatch on %v:\nwant %v\ngot  %v", c, want, got)
+	}
+}
+
+// Hits agree on the stamp and line of every entry.
+func sameHits(a, b [][]match.LogEntry) bool {
+	return slices.EqualFunc(a, b, func(x, y []match.LogEntry) bool {
+		return slices.EqualFunc(x, y, func(l, r match.LogEntry) bool {
+			return l.Timestamp == r.Timestamp && l.Line == r.Line
+		})
+	})
+}
+
+var fuzzSeeds = [][]byte{
+	{10, 0, 2, 0},
+	{5, 1, 0, 0, 1, 1, 3, 0, 2, 1, 0, 1, 4, 1, 2, 0, 0},
+	{20, 2, 3, 1, 2, 4, 12, 2, 0, 1, 0, 0, 1, 1, 1, 2, 2, 0, 3, 1, 0, 0, 1},
+	{0, 1, 0, 0, 2, 0, 5, 10, 1, 0, 0, 0, 1, 0, 0, 1, 0, 1},
+	{15, 3, 1, 2, 0, 3, 1, 0, 0, 3, 1, 2, 2, 3, 9, 7, 2, 0, 4, 0, 1, 1, 2, 2, 3, 0, 0},
+}
+
+func FuzzInverseSeq(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkSeq(t, decodeCase(data))
+	})
+}
+
+func FuzzInverseSet(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkSet(t, decodeCase(data))
+	})
+}
+
+// Random cases from a fixed seed, so that plain test runs compare widely.
+func TestReferenceRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 2000 {
+		c := randCase(rng)
+		checkSeq(t, c)
+		checkSet(t, c)
+	}
+}
+
+func TestReferenceSeq(t *testing.T) {
+	var (
+		terms  = []match.TermT{{Value: "a"}, {Value: "b"}}
+		resets = []match.ResetT{{Term: match.TermT{Value: "x"}, Window: 5, Absolute: true}}
+		lines  = []match.LogEntry{
+			{Timestamp: 1, Line: "a"},
+			{Timestamp: 2, Line: "b"},
+			{Timestamp: 3, Line: "x"},
+			{Timestamp: 10, Line: "a"},
+			{Timestamp: 12, Line: "b"},
+			{Timestamp: 30, Line: "a"},
+			{Timestamp: 50, Line: "b"},
+		}
+	)
+
+	hits, err := InverseSeq(10, terms, resets, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first frame is reset, the last exceeds the window.
+	if len(hits) != 1 || hits[0][0].Timestamp != 10 || hits[0][1].Timestamp != 12 {
+		t.Errorf("Expected a single hit at 10,12, got %v", hits)
+	}
+}
+
+func TestReferenceSet(t *testing.T) {
+	var (
+		terms = []match.TermT{{Value: "a"}, {Value: "b"}}
+		lines = []match.LogEntry{
+			{Timestamp: 1, Line: "b"},
+			{Timestamp: 2, Line: "b"},
+			{Timestamp: 6, Line: "a"},
+		}
+	)
+
+	hits, err := InverseSet(4, terms, nil, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hits are in term order; the earliest b is dropped by the window.
+	if len(hits) != 1 || hits[0][0].Timestamp != 6 || hits[0][1].Timestamp != 2 {
+		t.Errorf("Expected a single hit at 6,2, got %v", hits)
+	}
+}
+
+func TestReferenceReject(t *testing.T) {
+	var (
+		ab    = []match.TermT{{Value: "a"}, {Value: "b"}}
+		lines = []match.LogEntry{{Timestamp: 1, Line: "a"}}
+	)
+
+	tests := map[string]struct {
+		terms  []match.TermT
+		resets []match.ResetT
+		lines  []match.LogEntry
+		err    error
+	}{
+		"dupes":     {terms: []match.TermT{{Value: "a"}, {Value: "a"}}, lines: lines, err: ErrUnsupported},
+		"count":     {terms: []match.TermT{{Value: "a", Count: 2}}, lines: lines, err: ErrUnsupported},
+		"correlate": {terms: ab, resets: []match.ResetT{{Term: match.TermT{Value: "x"}, Correlate: "id"}}, err: ErrUnsupported},
+		"range":     {terms: ab, resets: []match.ResetT{{Term: match.TermT{Value: "x"}, AnchorEnd: 1}}, err: ErrUnsupported},
+		"anchor":    {terms: ab, resets: []match.ResetT{{Term: match.TermT{Value: "x"}, Anchor: 2}}, err: match.ErrAnchorRange},
+		"order":     {terms: ab, lines: []match.LogEntry{{Timestamp: 2, Line: "a"}, {Timestamp: 2, Line: "b"}}, err: ErrOrder},
+		"overlap":   {terms: ab, lines: []match.LogEntry{{Timestamp: 1, Line: "ab"}}, err: ErrOverlap},
+		"none":      {err: match.ErrNoTerms},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := InverseSeq(10, tc.terms, tc.resets, tc.lines); err != tc.err {
+				t.Errorf("Expected %v, got %v", tc.err, err)
+			}
+			if _, err := InverseSet(10, tc.terms, tc.resets, tc.lines); err != tc.err {
+				t.Errorf("Expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package match
+
+// PropMeta is the Props key holding the metadata for a hit.
+const PropMeta = "meta"
+
+// HitMetaT describes a single hit.  Start and End bound the effective match
+// window: the logs of the hit along with any reset windows evaluated against
+// them.  Rule names the matcher, if wrapped in MatchNamed.  Delayed is true
+// if the hit was held back waiting on a reset window to close.
+type HitMetaT struct {
+	Start   int64  `json:"start"`
+	End     int64  `json:"end"`
+	Rule    string `json:"rule,omitempty"`
+	Delayed bool   `json:"delayed,omitempty"`
+}
+
+// MetaOf returns the metadata of hit i.  Where a matcher has not recorded
+// metadata, the window is derived from the timestamps of the hit's logs.
+func MetaOf(h Hits, i int) (HitMetaT, bool) {
+	if i < 0 || i >= h.Cnt {
+		return HitMetaT{}, false
+	}
+
+	if m, ok := h.Props[PropKey{Idx: i, Key: PropMeta}].(HitMetaT); ok {
+		return m, true
+	}
+
+	var m HitMetaT
+	for j, e := range h.group(i) {
+		if j == 0 || e.Timestamp < m.Start {
+			m.Start = e.Timestamp
+		}
+		if j == 0 || e.Timestamp > m.End {
+			m.End = e.Timestamp
+		}
+	}
+	return m, true
+}
+
+// SetMeta records m as the metadata of hit i.
+func SetMeta(h *Hits, i int, m HitMetaT) {
+	if h.Props == nil {
+		h.Props = make(map[PropKey]any)
+	}
+	h.Props[PropKey{Idx: i, Key: PropMeta}] = m
+}
+
+// Metadata for a hit on anchors, widened by the reset windows evaluated
+// against them, and fired at clock.
+func resetMeta(resets []resetT, anchors []anchorT, clock int64) HitMetaT {
+	var (
+		last = anchors[len(anchors)-1].clock
+		m    = HitMetaT{Start: anchors[0].clock, End: last}
+	)
+
+	for _, reset := range resets {
+		start, stop := reset.calcWindowA(anchors)
+		m.Start = min(m.Start, start)
+		m.End = max(m.End, stop)
+	}
+
+	m.Delayed = clock > last
+	return m
+}
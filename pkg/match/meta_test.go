@@ -0,0 +1,111 @@
+package match
+
+import (
+	"testing"
+)
+
+func checkMeta(i int, exp HitMetaT) func(*testing.T, int, Hits) {
+	return func(t *testing.T, step int, hits Hits) {
+		t.Helper()
+		m, ok := MetaOf(hits, i)
+		if !ok {
+			t.Fatalf("Step %v: Expected meta for hit %v", step, i)
+		}
+		if m != exp {
+			t.Errorf("Step %v: Expected meta %+v, got %+v", step, exp, m)
+		}
+	}
+}
+
+func TestMeta(t *testing.T) {
+
+	cases := casesT{
+		"Seq": {
+			// Derived from the logs.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			steps: []stepT{
+				{stamp: 2, line: "alpha"},
+				{stamp: 5, line: "beta", cb: checkMeta(0, HitMetaT{Start: 2, End: 5})},
+			},
+		},
+		"InverseSeq": {
+			// Widened by the reset window and delayed until it closes.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("reset"),
+				Window:   8,
+				Slide:    -1,
+				Absolute: true,
+			}},
+			steps: []stepT{
+				{stamp: 2, line: "alpha"},
+				{stamp: 5, line: "beta", postF: checkEval(20, checkMeta(0, HitMetaT{Start: 1, End: 9, Delayed: true}))},
+			},
+		},
+		"InverseSeqNotDelayed": {
+			// The reset window closed before the last term.
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			reset: []ResetT{{
+				Term:     makeRaw("reset"),
+				Window:   1,
+				Absolute: true,
+			}},
+			steps: []stepT{
+				{stamp: 2, line: "alpha"},
+				{stamp: 5, line: "beta", cb: checkMeta(0, HitMetaT{Start: 2, End: 5})},
+			},
+		},
+	}
+
+	cases.run(t, func(tc caseT) (Matcher, error) {
+		if tc.reset == nil {
+			return NewMatchSeq(tc.window, makeTerms(tc.terms)...)
+		}
+		return NewInverseSeq(tc.window, makeTerms(tc.terms), tc.reset)
+	})
+}
+
+func TestMetaInverseSet(t *testing.T) {
+	m, err := NewInverseSet(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset"), Window: 10, Absolute: true}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	m.Scan(sl.ResetLine(3, "beta"))
+	m.Scan(sl.ResetLine(4, "alpha"))
+
+	// Anchored on the earliest log, regardless of term order.
+	checkMeta(0, HitMetaT{Start: 3, End: 13, Delayed: true})(t, 0, m.Eval(20))
+}
+
+func TestMetaRule(t *testing.T) {
+	seq, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	m := NewMatchNamed("rule-1", seq)
+
+	sl := NewScanLine()
+	m.Scan(sl.ResetLine(1, "alpha"))
+	checkMeta(0, HitMetaT{Start: 1, End: 2, Rule: "rule-1"})(t, 0, m.Scan(sl.ResetLine(2, "beta")))
+}
+
+func TestMetaAbsence(t *testing.T) {
+	m, err := NewMatchAbsence(5, makeRaw("alpha"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	m.Scan(NewScanLine().ResetLine(1, "noise"))
+	checkMeta(0, HitMetaT{Start: 1, End: 6})(t, 0, m.Eval(10))
+}
+
+func TestMetaOfRange(t *testing.T) {
+	if _, ok := MetaOf(Hits{}, 0); ok {
+		t.Errorf("Expected no meta for empty hits")
+	}
+}
@@ -0,0 +1,352 @@
+package match
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// MultiMatcher runs many matchers over the same stream, dispatching each line
+// only to the matchers that could possibly match it.
+//
+// An Aho-Corasick automaton is built over a required literal of every term:
+// raw terms contribute their value, regex terms a literal that any match must
+// contain.  A matcher is dispatched a line if any of its literals occur in the
+// line.  If any of its terms has no required literal (jq, fuzzy, or a regex
+// such as `\d+`), the matcher receives every line.
+//
+// WithRegexDb narrows the latter: regex terms without a required literal are
+// compiled into a single RegexDbI, and the matcher is dispatched a line if
+// any of them match it.  Should the backend fail to compile the patterns,
+// their matchers fall back to receiving every line.
+//
+// A time driven matcher (for example InverseSeq) that is not dispatched a line
+// is evaluated at the line timestamp instead, so it still fires as the clock
+// advances, as the GC policy allows; see GCPolicyT.  GCSize evaluates on
+// every line, as the multi matcher holds no entries.  Hits are reported for
+// dispatched matchers first, then for those evaluated.
+
+type MultiMatcher struct {
+	entries []multiEntryT
+	always  []int
+	timed   []int
+	ac      *acT
+	dirty   bool
+	regexDb RegexDbFactoryT
+	db      RegexDbI
+	dbIds   []int32 // Matcher index of each pattern in db.
+
+	// Per scan dispatch marks; gen avoids clearing between lines.
+	marks    []uint32
+	gen      uint32
+	dispatch []int
+	nScanned uint64
+
+	gcT
+}
+
+// Implemented by matchers whose Eval never emits hits; these need not be
+// evaluated as the clock advances.
+type edgeTriggeredI interface {
+	edgeTriggered()
+}
+
+type multiEntryT struct {
+	m       Matcher
+	lits    []string
+	regexes []string // Regex terms without a literal; only WithRegexDb.
+}
+
+// MultiHitFuncT receives hits from the matcher at index idx.
+type MultiHitFuncT func(idx int, hits Hits)
+
+func NewMultiMatcher(opts ...OptT) (*MultiMatcher, error) {
+	o := parseOpts(opts)
+	if err := o.check(optRegexDb | optGCInterval | optGCPolicy | optGCSize); err != nil {
+		return nil, err
+	}
+
+	mm := &MultiMatcher{regexDb: o.regexDb, gcT: newGC(o)}
+	if mm.gcPolicy == GCSize {
+		mm.gcPolicy = GCInline
+	}
+	return mm, nil
+}
+
+// Add registers m with the terms it was built from.  The terms must include
+// every term the matcher can match, including reset terms.  Returns the index
+// of the matcher used in callbacks.
+func (mm *MultiMatcher) Add(m Matcher, terms ...TermT) int {
+
+	var (
+		idx     = len(mm.entries)
+		lits    = make([]string, 0, len(terms))
+		regexes []string
+		never   = len(terms) == 0
+	)
+
+	for _, term := range terms {
+		lit := termLiteral(term)
+		switch {
+		case lit != "":
+			lits = append(lits, lit)
+			continue
+		case term.Type == TermRegex && mm.regexDb != nil:
+			regexes = append(regexes, term.Value)
+			continue
+		}
+		never = true
+		break
+	}
+
+	if never {
+		mm.always = append(mm.always, idx)
+		lits, regexes = nil, nil
+	}
+
+	if _, ok := m.(edgeTriggeredI); !ok {
+		mm.timed = append(mm.timed, idx)
+	}
+
+	mm.entries = append(mm.entries, multiEntryT{m: m, lits: lits, regexes: regexes})
+	mm.marks = append(mm.marks, 0)
+	mm.dirty = true
+	return idx
+}
+
+func (mm *MultiMatcher) build() {
+	var (
+		pats []string
+		ids  []int32
+	)
+
+	for i, entry := range mm.entries {
+		for _, lit := range entry.lits {
+			pats = append(pats, lit)
+			ids = append(ids, int32(i))
+		}
+	}
+
+	mm.ac = newAC(pats, ids)
+	mm.buildDb()
+	mm.dirty = false
+}
+
+func (mm *MultiMatcher) buildDb() {
+	var (
+		pats []string
+		ids  []int32
+	)
+
+	for i, entry := range mm.entries {
+		for _, expr := range entry.regexes {
+			pats = append(pats, expr)
+			ids = append(ids, int32(i))
+		}
+	}
+
+	mm.db, mm.dbIds = nil, nil
+	if len(pats) == 0 {
+		return
+	}
+
+	db, err := mm.regexDb(pats)
+	if err != nil {
+		log.Warn().Err(err).Int("patterns", len(pats)).Msg("MultiMatcher: Regex db failed; dispatching every line.")
+
+		// Fall back permanently, so a later build does not retry.
+		for i := range mm.entries {
+			if mm.entries[i].regexes != nil {
+				mm.entries[i].regexes = nil
+				mm.always = append(mm.always, i)
+			}
+		}
+		return
+	}
+
+	mm.db, mm.dbIds = db, ids
+}
+
+// Scan dispatches the line to candidate matchers and evaluates the rest.
+func (mm *MultiMatcher) Scan(e *ScanLine, cb MultiHitFuncT) {
+	if mm.dirty {
+		mm.build()
+	}
+
+	mm.nScanned += 1
+	mm.gen += 1
+	if mm.gen == 0 {
+		// Wrapped; clear stale marks.
+		clear(mm.marks)
+		mm.gen = 1
+	}
+
+	mm.dispatch = append(mm.dispatch[:0], mm.always...)
+	for _, idx := range mm.always {
+		mm.marks[idx] = mm.gen
+	}
+
+	mark := func(idx int32) {
+		if mm.marks[idx] != mm.gen {
+			mm.marks[idx] = mm.gen
+			mm.dispatch = append(mm.dispatch, int(idx))
+		}
+	}
+
+	mm.ac.scan(e.Line, mark)
+	if mm.db != nil {
+		mm.db.Match(e.Line, func(i int) { mark(mm.dbIds[i]) })
+	}
+
+	for _, idx := range mm.dispatch {
+		if hits := mm.entries[idx].m.Scan(e); hits.Cnt > 0 {
+			cb(idx, hits)
+		}
+	}
+
+	if !mm.gcDue(e.Timestamp, 0) {
+		return
+	}
+	mm.gcClock = e.Timestamp
+
+	for _, idx := range mm.timed {
+		if mm.marks[idx] == mm.gen {
+			continue
+		}
+		if hits := mm.entries[idx].m.Eval(e.Timestamp); hits.Cnt > 0 {
+			cb(idx, hits)
+		}
+	}
+}
+
+func (mm *MultiMatcher) Eval(clock int64, cb MultiHitFuncT) {
+	mm.gcClock = clock
+	for i, entry := range mm.entries {
+		if hits := entry.m.Eval(clock); hits.Cnt > 0 {
+			cb(i, hits)
+		}
+	}
+}
+
+func (mm *MultiMatcher) GarbageCollect(clock int64) {
+	for _, entry := range mm.entries {
+		entry.m.GarbageCollect(clock)
+	}
+}
+
+// Stats returns the lines scanned by the multi matcher, and the remaining
+// counters summed over its matchers.  Matched is not reported, as the terms
+// of the matchers differ.
+func (mm *MultiMatcher) Stats() StatsT {
+	s := StatsT{Scanned: mm.nScanned}
+	for _, entry := range mm.entries {
+		s.add(statsOf(entry.m))
+	}
+	s.Matched = nil
+	return s
+}
+
+// Candidates returns the indices of matchers that would be dispatched line.
+func (mm *MultiMatcher) Candidates(line string) []int {
+	if mm.dirty {
+		mm.build()
+	}
+
+	var (
+		out  []int
+		seen = make(map[int32]struct{})
+	)
+
+	mark := func(idx int32) {
+		if _, ok := seen[idx]; !ok {
+			seen[idx] = struct{}{}
+			out = append(out, int(idx))
+		}
+	}
+
+	out = append(out, mm.always...)
+	mm.ac.scan(line, mark)
+	if mm.db != nil {
+		mm.db.Match(line, func(i int) { mark(mm.dbIds[i]) })
+	}
+	return out
+}
+
+// acT is a byte oriented Aho-Corasick automaton with a dense transition table.
+type acT struct {
+	delta [][256]int32
+	out   [][]int32 // Matcher ids emitted on entering a state, including via suffix links.
+}
+
+func newAC(pats []string, ids []int32) *acT {
+
+	ac := &acT{
+		delta: make([][256]int32, 1),
+		out:   make([][]int32, 1),
+	}
+
+	// Build the trie; zero is both the root and "no edge" since the root has no parent.
+	for i, pat := range pats {
+		var s int32
+		for j := range len(pat) {
+			c := pat[j]
+			if ac.delta[s][c] == 0 {
+				ac.delta = append(ac.delta, [256]int32{})
+				ac.out = append(ac.out, nil)
+				ac.delta[s][c] = int32(len(ac.delta) - 1)
+			}
+			s = ac.delta[s][c]
+		}
+		ac.out[s] = appendId(ac.out[s], ids[i])
+	}
+
+	// BFS to compute failure links, folding them into a complete transition table.
+	var (
+		fail  = make([]int32, len(ac.delta))
+		queue = make([]int32, 0, len(ac.delta))
+	)
+
+	for c := range 256 {
+		if s := ac.delta[0][c]; s != 0 {
+			queue = append(queue, s)
+		}
+	}
+
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		for _, id := range ac.out[fail[s]] {
+			ac.out[s] = appendId(ac.out[s], id)
+		}
+
+		for c := range 256 {
+			next := ac.delta[s][c]
+			if next == 0 {
+				ac.delta[s][c] = ac.delta[fail[s]][c]
+				continue
+			}
+			fail[next] = ac.delta[fail[s]][c]
+			queue = append(queue, next)
+		}
+	}
+
+	return ac
+}
+
+func appendId(ids []int32, id int32) []int32 {
+	for _, v := range ids {
+		if v == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+func (ac *acT) scan(line string, emit func(int32)) {
+	var s int32
+	for i := range len(line) {
+		s = ac.delta[s][line[i]]
+		for _, id := range ac.out[s] {
+			emit(id)
+		}
+	}
+}
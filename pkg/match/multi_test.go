@@ -0,0 +1,159 @@
+package match
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type multiRuleT struct {
+	terms  []TermT
+	resets []ResetT
+}
+
+func (r multiRuleT) all() []TermT {
+	out := slices.Clone(r.terms)
+	for _, reset := range r.resets {
+		out = append(out, reset.Term)
+	}
+	return out
+}
+
+func (r multiRuleT) build(t testing.TB) Matcher {
+	var (
+		m   Matcher
+		err error
+	)
+	if len(r.resets) > 0 {
+		m, err = NewInverseSeq(10, r.terms, r.resets)
+	} else {
+		m, err = NewMatchSeq(10, r.terms...)
+	}
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+	return m
+}
+
+func makeMultiRules() []multiRuleT {
+	return []multiRuleT{
+		{terms: makeTermsA("alpha", "beta")},
+		{terms: []TermT{{Type: TermRegex, Value: `ze+ta-\d+`}, makeRaw("delta")}},
+		{terms: makeTermsA("beta"), resets: []ResetT{{Term: makeRaw("omega"), Window: 3}}},
+		{terms: []TermT{{Type: TermRegex, Value: `\d{3}`}}}, // No literal; always dispatched.
+		{terms: []TermT{makeRaw("alpha"), {Type: TermJqJson, Value: `.x == 1`}}},
+	}
+}
+
+func TestMultiMatcherEquivalence(t *testing.T) {
+
+	var (
+		rules = makeMultiRules()
+		mm, _ = NewMultiMatcher()
+		ref   = make([]Matcher, 0, len(rules))
+		words = []string{"alpha", "beta", "zeeta-7", "delta", "omega", "123", `{"x":1}`, "noise", "alp", "bet"}
+		rnd   = rand.New(rand.NewSource(1))
+	)
+
+	for _, rule := range rules {
+		mm.Add(rule.build(t), rule.all()...)
+		ref = append(ref, rule.build(t))
+	}
+
+	var (
+		got = make(map[int][]LogEntry)
+		exp = make(map[int][]LogEntry)
+	)
+
+	for i := range 2000 {
+		line := words[rnd.Intn(len(words))]
+		if rnd.Intn(2) == 0 {
+			line = fmt.Sprintf("%s %s", line, words[rnd.Intn(len(words))])
+		}
+		e := NewScanLine().ResetLine(int64(i+1), line)
+
+		mm.Scan(e, func(idx int, hits Hits) {
+			got[idx] = append(got[idx], hits.Logs...)
+		})
+
+		for idx, m := range ref {
+			if hits := m.Scan(e); hits.Cnt > 0 {
+				exp[idx] = append(exp[idx], hits.Logs...)
+			}
+		}
+	}
+
+	mm.Eval(1<<62, func(idx int, hits Hits) {
+		got[idx] = append(got[idx], hits.Logs...)
+	})
+	for idx, m := range ref {
+		if hits := m.Eval(1 << 62); hits.Cnt > 0 {
+			exp[idx] = append(exp[idx], hits.Logs...)
+		}
+	}
+
+	for idx := range rules {
+		if len(exp[idx]) == 0 {
+			t.Errorf("Rule %d: Expected some hits", idx)
+		}
+		if !reflect.DeepEqual(got[idx], exp[idx]) {
+			t.Errorf("Rule %d: Expected %d logs, got %d", idx, len(exp[idx]), len(got[idx]))
+		}
+	}
+}
+
+func TestMultiMatcherCandidates(t *testing.T) {
+
+	mm, _ := NewMultiMatcher()
+	for _, rule := range makeMultiRules() {
+		mm.Add(rule.build(t), rule.all()...)
+	}
+
+	cases := map[string][]int{
+		"nothing here":     {3, 4},
+		"alpha":            {0, 3, 4},
+		"the omega":        {2, 3, 4},
+		"zeta-9 delta":     {1, 3, 4},
+		"alphabeta gam":    {0, 2, 3, 4},
+		"xxalphaxxomegaxx": {0, 2, 3, 4},
+	}
+
+	for line, exp := range cases {
+		got := mm.Candidates(line)
+		slices.Sort(got)
+		if !slices.Equal(got, exp) {
+			t.Errorf("%q: Expected %v, got %v", line, exp, got)
+		}
+	}
+}
+
+func TestAhoCorasickOverlap(t *testing.T) {
+	ac := newAC([]string{"he", "she", "his", "hers"}, []int32{0, 1, 2, 3})
+
+	var got []int32
+	ac.scan("ushers", func(id int32) { got = append(got, id) })
+	slices.Sort(got)
+
+	if !slices.Equal(got, []int32{0, 1, 3}) {
+		t.Errorf("Expected [0 1 3], got %v", got)
+	}
+}
+
+func BenchmarkMultiMatcherMiss(b *testing.B) {
+	mm, _ := NewMultiMatcher()
+	for i := range 500 {
+		rule := multiRuleT{terms: makeTermsA(fmt.Sprintf("event-%d-start", i), fmt.Sprintf("event-%d-stop", i))}
+		mm.Add(rule.build(b), rule.all()...)
+	}
+
+	e := NewScanLine().ResetLine(1, "2024-01-01T00:00:00Z INFO request served in 12ms path=/api/v1/users status=200")
+	cb := func(int, Hits) {}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e.Timestamp += 1
+		mm.Scan(e, cb)
+	}
+}
@@ -0,0 +1,181 @@
+package match
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrNumericSpec = errors.New("invalid numeric comparison")
+
+// A TermNumeric value compares a numeric field against a threshold:
+//
+//	status >= 500
+//	.http.latency_ms > 250
+//	items.0.size < 1e6
+//
+// The field is a dotted path into a JSON line; numeric segments index arrays.
+// Lines that are not JSON are read as logfmt, with the path taken as the key.
+// Supported operators are ==, !=, >, >=, < and <=.  A field that is missing or
+// not numeric does not match; numeric strings such as "500" are accepted.
+
+type numericOpT int
+
+const (
+	numericEq numericOpT = iota
+	numericNe
+	numericGt
+	numericGe
+	numericLt
+	numericLe
+)
+
+// Longest operators first so that ">=" is not read as ">".
+var numericOps = []struct {
+	tok string
+	op  numericOpT
+}{
+	{"==", numericEq},
+	{"!=", numericNe},
+	{">=", numericGe},
+	{"<=", numericLe},
+	{">", numericGt},
+	{"<", numericLt},
+}
+
+type numericT struct {
+	key  string
+	path []string
+	op   numericOpT
+	num  float64
+}
+
+func makeNumericMatch(term string) (MatchFunc, error) {
+	n, err := parseNumeric(term)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(e *ScanLine) bool {
+		v, ok := n.extract(e)
+		return ok && n.compare(v)
+	}, nil
+}
+
+func parseNumeric(term string) (n numericT, err error) {
+	idx := strings.IndexAny(term, "=!<>")
+	if idx < 0 {
+		return n, ErrNumericSpec
+	}
+
+	var (
+		rest = term[idx:]
+		ok   bool
+	)
+
+	for _, o := range numericOps {
+		if strings.HasPrefix(rest, o.tok) {
+			n.op = o.op
+			rest = rest[len(o.tok):]
+			ok = true
+			break
+		}
+	}
+
+	if !ok {
+		return n, ErrNumericSpec
+	}
+
+	if n.key, n.path, ok = parseFieldPath(term[:idx]); !ok {
+		return n, ErrNumericSpec
+	}
+
+	if n.num, err = strconv.ParseFloat(strings.TrimSpace(rest), 64); err != nil {
+		return n, ErrNumericSpec
+	}
+
+	return n, nil
+}
+
+func (n numericT) extract(e *ScanLine) (float64, bool) {
+	v, ok := extractField(e, n.key, n.path)
+	if !ok {
+		return 0, false
+	}
+	return toNumber(v)
+}
+
+// Parse a dotted field path; a leading '.' is optional.
+func parseFieldPath(s string) (key string, path []string, ok bool) {
+	key = strings.TrimPrefix(strings.TrimSpace(s), ".")
+	if key == "" {
+		return
+	}
+
+	path = strings.Split(key, ".")
+	for _, seg := range path {
+		if seg == "" {
+			return
+		}
+	}
+
+	return key, path, true
+}
+
+// Extract a field by path from a JSON line, or by key from a logfmt line.
+func extractField(e *ScanLine, key string, path []string) (any, bool) {
+	doc, err := e.DecodeJson()
+	if err != nil {
+		v, ok := e.DecodeLogfmt()[key]
+		return v, ok
+	}
+
+	for _, seg := range path {
+		switch d := doc.(type) {
+		case map[string]any:
+			var ok bool
+			if doc, ok = d[seg]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(d) {
+				return nil, false
+			}
+			doc = d[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func (n numericT) compare(v float64) bool {
+	switch n.op {
+	case numericEq:
+		return v == n.num
+	case numericNe:
+		return v != n.num
+	case numericGt:
+		return v > n.num
+	case numericGe:
+		return v >= n.num
+	case numericLt:
+		return v < n.num
+	default:
+		return v <= n.num
+	}
+}
@@ -0,0 +1,57 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNumericMatch(t *testing.T) {
+
+	const (
+		jsonLine   = `{"status":503,"http":{"latency_ms":312.5},"items":[{"size":10}],"code":"404","name":"api"}`
+		logfmtLine = `level=error status=503 latency=1.5s`
+	)
+
+	tests := map[string]struct {
+		term  string
+		line  string
+		match bool
+	}{
+		"Ge":           {term: "status >= 500", line: jsonLine, match: true},
+		"GeMiss":       {term: "status >= 504", line: jsonLine, match: false},
+		"Gt":           {term: ".http.latency_ms > 250", line: jsonLine, match: true},
+		"Lt":           {term: "http.latency_ms<250", line: jsonLine, match: false},
+		"Le":           {term: "status <= 503", line: jsonLine, match: true},
+		"Eq":           {term: "status == 503", line: jsonLine, match: true},
+		"Ne":           {term: "status != 503", line: jsonLine, match: false},
+		"Index":        {term: "items.0.size < 1e6", line: jsonLine, match: true},
+		"IndexRange":   {term: "items.1.size < 1e6", line: jsonLine, match: false},
+		"StringNumber": {term: "code == 404", line: jsonLine, match: true},
+		"NotNumber":    {term: "name > 0", line: jsonLine, match: false},
+		"Missing":      {term: "nope > 0", line: jsonLine, match: false},
+		"NotObject":    {term: "status.x > 0", line: jsonLine, match: false},
+		"Logfmt":       {term: "status >= 500", line: logfmtLine, match: true},
+		"LogfmtUnits":  {term: "latency > 1", line: logfmtLine, match: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := TermT{Type: TermNumeric, Value: tc.term}.NewMatcher()
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+			if got := m(NewScanLine().ResetLine(1, tc.line)); got != tc.match {
+				t.Errorf("Expected %v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestNumericBadTerm(t *testing.T) {
+	for _, term := range []string{"status", "status = 5", "> 5", "status > fast", "a..b > 1", "status =! 5"} {
+		_, err := TermT{Type: TermNumeric, Value: term}.NewMatcher()
+		if !errors.Is(err, ErrTermCompile) || !errors.Is(err, ErrNumericSpec) {
+			t.Errorf("%q: Expected %v, got %v", term, ErrNumericSpec, err)
+		}
+	}
+}
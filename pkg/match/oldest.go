@@ -0,0 +1,91 @@
+package match
+
+import "math"
+
+// OldestI is implemented by matchers that can report the earliest timestamp
+// of the state they hold: the asserts of partial matches and the reset lines
+// recorded.  Lines stamped earlier have no bearing on what the matcher will
+// emit, so a replay from the first line at the oldest timestamp rebuilds the
+// state; see Oldest.
+type OldestI interface {
+	// Oldest returns the earliest timestamp held; false if nothing is held.
+	Oldest() (int64, bool)
+}
+
+// Oldest returns the earliest timestamp held by m, or false if m holds
+// nothing.  A matcher that does not implement OldestI may hold any line it
+// was passed, so is reported as holding math.MinInt64.
+func Oldest(m Matcher) (int64, bool) {
+	if o, ok := m.(OldestI); ok {
+		return o.Oldest()
+	}
+	return math.MinInt64, true
+}
+
+// With WithLineWindow, the asserts are stamped with line counts; the oldest of
+// the timestamps saved for them is returned.
+func (r *MatchSeq) Oldest() (int64, bool) {
+	stamp, ok := oldestOf(r.terms, nil, r.lines)
+	if opt, optOk := oldestOf(r.optional, nil, r.lines); optOk && (!ok || opt < stamp) {
+		stamp, ok = opt, true
+	}
+	return stamp, ok
+}
+
+func (r *MatchSet) Oldest() (int64, bool) {
+	return oldestOf(r.terms, nil, nil)
+}
+
+func (r *InverseSeq) Oldest() (int64, bool) {
+	return oldestOf(r.terms, r.resets, nil)
+}
+
+func (r *InverseSet) Oldest() (int64, bool) {
+	return oldestOf(r.terms, r.resets, nil)
+}
+
+// Oldest returns the earliest timestamp held by any partition.
+func (r *KeyedMatcher) Oldest() (int64, bool) {
+	var (
+		stamp int64
+		held  bool
+	)
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		if ts, ok := Oldest(el.Value.(*partT).m); ok && (!held || ts < stamp) {
+			stamp, held = ts, true
+		}
+	}
+	return stamp, held
+}
+
+func (d *DualClock) Oldest() (int64, bool) {
+	return Oldest(d.m)
+}
+
+// Earliest assert of the terms, or reset stamp; reset stamps are in order.
+// With lines, the asserts are stamped with line counts, and the timestamps
+// saved for them are compared instead; an assert past the bound is expired.
+func oldestOf(terms []termT, resets []resetT, lines *lineRingT) (stamp int64, ok bool) {
+	stamp = math.MaxInt64
+	for _, term := range terms {
+		for _, e := range term.asserts {
+			ts := e.Timestamp
+			if lines != nil {
+				var held bool
+				if ts, held = lines.lookup(ts); !held {
+					continue
+				}
+			}
+			stamp, ok = min(stamp, ts), true
+		}
+	}
+	for _, reset := range resets {
+		if len(reset.resets) > 0 {
+			stamp, ok = min(stamp, reset.resets[0]), true
+		}
+	}
+	if !ok {
+		stamp = 0
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package match
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestOldest(t *testing.T) {
+	seq, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	inv, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("ok"), Window: 10, Slide: -5, Absolute: true}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+
+	if _, ok := Oldest(seq); ok {
+		t.Errorf("Expected nothing held")
+	}
+
+	seq.Scan(sl.ResetLine(3, "alpha"))
+	if ts, ok := Oldest(seq); !ok || ts != 3 {
+		t.Errorf("Expected 3, got %d %v", ts, ok)
+	}
+	if hits := seq.Scan(sl.ResetLine(4, "beta")); hits.Cnt != 1 {
+		t.Fatalf("Expected 1 hit, got %d", hits.Cnt)
+	}
+	if ts, ok := Oldest(seq); ok {
+		t.Errorf("Expected nothing held once fired, got %d", ts)
+	}
+
+	// Reset lines are held too; the window slides back over this one.
+	inv.Scan(sl.ResetLine(1, "ok"))
+	inv.Scan(sl.ResetLine(2, "alpha"))
+	if ts, ok := Oldest(inv); !ok || ts != 1 {
+		t.Errorf("Expected 1, got %d %v", ts, ok)
+	}
+
+	// Matchers that cannot tell hold everything.
+	if ts, ok := Oldest(&clockRecT{Matcher: seq}); !ok || ts != math.MinInt64 {
+		t.Errorf("Expected %d, got %d %v", int64(math.MinInt64), ts, ok)
+	}
+}
+
+func TestOldestLineWindow(t *testing.T) {
+	lseq, err := NewMatchSeqOpts(2, makeTermsA("alpha", "beta"), WithLineWindow())
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	sl := NewScanLine()
+
+	lseq.Scan(sl.ResetLine(1000, "noise"))
+	lseq.Scan(sl.ResetLine(2000, "alpha"))
+	lseq.Scan(sl.ResetLine(3000, "noise"))
+
+	// The timestamp of the assert, not its line count.
+	if ts, ok := Oldest(lseq); !ok || ts != 2000 {
+		t.Errorf("Expected 2000, got %d %v", ts, ok)
+	}
+
+	// Out of the window after two more lines.
+	lseq.Scan(sl.ResetLine(4000, "noise"))
+	lseq.Scan(sl.ResetLine(5000, "noise"))
+	if ts, ok := Oldest(lseq); ok {
+		t.Errorf("Expected nothing held, got %d", ts)
+	}
+}
+
+func TestOldestKeyed(t *testing.T) {
+	keyFn := func(e *ScanLine) string {
+		key, _, _ := strings.Cut(e.Line, " ")
+		return key
+	}
+	km, err := NewKeyedMatcher(keyFn, func() Matcher {
+		m, _ := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+		return m
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	km.Scan(sl.ResetLine(1, "a alpha"))
+	km.Scan(sl.ResetLine(2, "b alpha"))
+	if ts, ok := km.Oldest(); !ok || ts != 1 {
+		t.Errorf("Expected 1, got %d %v", ts, ok)
+	}
+
+	km.Scan(sl.ResetLine(3, "a beta"))
+	if ts, ok := km.Oldest(); !ok || ts != 2 {
+		t.Errorf("Expected 2 once key a fired, got %d %v", ts, ok)
+	}
+}
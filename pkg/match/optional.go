@@ -0,0 +1,133 @@
+package match
+
+import (
+	"errors"
+	"slices"
+)
+
+var ErrOptional = errors.New("optional term out of range")
+
+type pickT struct {
+	after int
+	entry LogEntry
+}
+
+// Split the sequence terms into required and optional terms.  The first and
+// last terms anchor the sequence and cannot be optional, nor can terms with a
+// count.  For each optional term, after is the number of required anchors
+// preceding it, including dupes.
+func splitOptional(seqTerms []TermT, pos []int) (required []TermT, optional []termT, after []int, err error) {
+	if len(pos) == 0 {
+		return seqTerms, nil, nil, nil
+	}
+
+	for _, p := range pos {
+		if p <= 0 || p >= len(seqTerms)-1 || seqTerms[p].Count > 1 {
+			return nil, nil, nil, ErrOptional
+		}
+	}
+
+	required = make([]TermT, 0, len(seqTerms)-len(pos))
+
+	for i, term := range seqTerms {
+		if !slices.Contains(pos, i) {
+			required = append(required, term)
+			continue
+		}
+
+		m, err := term.NewMatcher()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		optional = append(optional, termT{matcher: m})
+		after = append(after, countAnchors(required))
+	}
+
+	return required, optional, after, nil
+}
+
+// True if a and b are the same log line.
+func sameEntry(a, b LogEntry) bool {
+	return a.Timestamp == b.Timestamp && a.Line == b.Line
+}
+
+// Record optional matches; only useful while a sequence is in progress.
+func (r *MatchSeq) scanOptional(e *ScanLine) {
+	if r.nActive == 0 {
+		return
+	}
+	for i := range r.optional {
+		if r.optional[i].matcher(e) {
+			r.optional[i].push(e.Entry())
+		}
+	}
+}
+
+// Weave optional matches into the required frame logs[base:].  Each optional
+// term takes its first match strictly between its neighbours in the frame,
+// including preceding optional picks; picked and earlier matches are consumed.
+func (r *MatchSeq) weave(logs []LogEntry, base int) []LogEntry {
+	if len(r.optional) == 0 {
+		return logs
+	}
+
+	frame := logs[base:]
+	r.picks = r.picks[:0]
+
+	for i := range r.optional {
+		var (
+			after = r.optAfter[i]
+			lo    = frame[after-1]
+			hi    = frame[after]
+		)
+
+		if n := len(r.picks); n > 0 && r.picks[n-1].after == after {
+			lo = r.picks[n-1].entry
+		}
+
+		pick := -1
+		for j, a := range r.optional[i].asserts {
+			if a.Timestamp < lo.Timestamp || sameEntry(a, lo) || (r.strict && a.Timestamp == lo.Timestamp) {
+				continue
+			}
+			if a.Timestamp > hi.Timestamp || sameEntry(a, hi) || (r.strict && a.Timestamp == hi.Timestamp) {
+				break
+			}
+			pick = j
+			break
+		}
+
+		if pick < 0 {
+			continue
+		}
+
+		r.picks = append(r.picks, pickT{after: after, entry: r.optional[i].asserts[pick]})
+		shiftLeft(r.optional, i, pick+1)
+	}
+
+	if len(r.picks) == 0 {
+		return logs
+	}
+
+	r.frame = append(r.frame[:0], frame...)
+	logs = logs[:base]
+
+	var k int
+	for i, entry := range r.frame {
+		for ; k < len(r.picks) && r.picks[k].after == i; k++ {
+			logs = append(logs, r.picks[k].entry)
+		}
+		logs = append(logs, entry)
+	}
+
+	return logs
+}
+
+// Drop optional matches older than deadline.
+func (r *MatchSeq) gcOptional(deadline int64) {
+	for i := range r.optional {
+		if cnt := countBefore(r.optional[i].asserts, deadline); cnt > 0 {
+			shiftLeft(r.optional, i, cnt)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+var ErrOption = errors.New("option not supported by matcher")
+
+type OptT func(*optsT)
+
+type optsT struct {
+	set         optT // Options given; see check.
+	precedence  PrecedenceT
+	between     map[int][]TermT
+	refire      RefireT
+	absence     AbsenceT
+	heartbeat   *TermT
+	maxKeys     int
+	keyTTL      int64
+	maxGap      []int64
+	minGap      []int64
+	strict      bool
+	optional    []int
+	explain     int
+	maxBuffered int
+	regexDb     RegexDbFactoryT
+	lineWindow  bool
+	maxLines    int64
+	sample      int
+	rateLimit   float64
+	burst       int
+	id          string
+	labels      map[string]string
+	resets      []ResetT
+	ordered     []int
+	quorum      int
+	gcEvery     int64
+	gcPolicy    GCPolicyT
+	gcSize      int
+	shards      int
+	shardBatch  int
+}
+
+// optT is a set of options, by the With function that sets each.
+type optT uint64
+
+const (
+	optPrecedence optT = 1 << iota
+	optBetween
+	optRefire
+	optAbsence
+	optHeartbeat
+	optMaxKeys
+	optKeyTTL
+	optMaxGap
+	optMinGap
+	optStrictOrder
+	optOptional
+	optExplain
+	optMaxBuffered
+	optRegexDb
+	optLineWindow
+	optMaxLines
+	optSample
+	optRateLimit
+	optID
+	optLabels
+	optResets
+	optGCInterval
+	optGCPolicy
+	optGCSize
+	optOrdered
+	optQuorum
+	optShards
+	optShardBatch
+)
+
+var optNames = [...]string{
+	"WithPrecedence",
+	"WithBetween",
+	"WithRefire",
+	"WithAbsence",
+	"WithHeartbeat",
+	"WithMaxKeys",
+	"WithKeyTTL",
+	"WithMaxGap",
+	"WithMinGap",
+	"WithStrictOrder",
+	"WithOptional",
+	"WithExplain",
+	"WithMaxBuffered",
+	"WithRegexDb",
+	"WithLineWindow",
+	"WithMaxLines",
+	"WithSample",
+	"WithRateLimit",
+	"WithID",
+	"WithLabels",
+	"WithResets",
+	"WithGCInterval",
+	"WithGCPolicy",
+	"WithGCSize",
+	"WithOrdered",
+	"WithQuorum",
+	"WithShards",
+	"WithShardBatch",
+}
+
+// Options of the matchers that embed statsT.
+const optsStats = optID | optLabels | optGCInterval | optGCPolicy | optGCSize
+
+func parseOpts(opts []OptT) optsT {
+	var o optsT
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Fail on the first option given that is not in accept; a constructor
+// declares the options it applies, rather than silently ignore the rest.
+func (o optsT) check(accept optT) error {
+	if extra := o.set &^ accept; extra != 0 {
+		return fmt.Errorf("%w: %s", ErrOption, optNames[bits.TrailingZeros64(uint64(extra))])
+	}
+	return nil
+}
+
+// PrecedenceT determines how a line that matches both a reset term
+// and a sequence term is counted.
+type PrecedenceT int
+
+const (
+	PrecedenceBoth  PrecedenceT = iota // Line counts as both a reset and a term; the default.
+	PrecedenceReset                    // Line counts only as a reset.
+	PrecedenceTerm                     // Line counts only as a term.
+)
+
+// WithPrecedence controls how InverseSeq counts a line that matches
+// both a reset term and an active sequence term.
+func WithPrecedence(p PrecedenceT) OptT {
+	return func(o *optsT) {
+		o.set |= optPrecedence
+		o.precedence = p
+	}
+}
+
+// WithBetween adds negative terms to the gap preceding sequence term pos.
+// A line matching any of the terms strictly between the matches of terms
+// pos-1 and pos invalidates that partial match; the earlier terms remain
+// eligible for a later match.  Applies to InverseSeq only; pos indexes the
+// sequence terms as supplied and must be in the range [1, len(terms)).
+func WithBetween(pos int, terms ...TermT) OptT {
+	return func(o *optsT) {
+		o.set |= optBetween
+		if o.between == nil {
+			o.between = make(map[int][]TermT)
+		}
+		o.between[pos] = append(o.between[pos], terms...)
+	}
+}
+
+// RefireT determines how a threshold matcher behaves once it has fired.
+type RefireT int
+
+const (
+	RefireTumbling RefireT = iota // Contributing entries are consumed on fire; the default.
+	RefireRolling                 // Only the oldest entry is dropped on fire.
+)
+
+// WithRefire controls whether MatchCount tumbles or rolls after a fire.
+func WithRefire(rf RefireT) OptT {
+	return func(o *optsT) {
+		o.set |= optRefire
+		o.refire = rf
+	}
+}
+
+// AbsenceT determines where MatchAbsence anchors its window.
+type AbsenceT int
+
+const (
+	AbsenceStart     AbsenceT = iota // Window starts at the first line scanned; the default.
+	AbsencePrevious                  // Window starts at the previous occurrence of the term.
+	AbsenceHeartbeat                 // Window starts at a heartbeat term; see WithHeartbeat.
+)
+
+// WithAbsence selects the anchor of the MatchAbsence window.
+func WithAbsence(a AbsenceT) OptT {
+	return func(o *optsT) {
+		o.set |= optAbsence
+		o.absence = a
+	}
+}
+
+// WithHeartbeat anchors the MatchAbsence window at each match of term.
+func WithHeartbeat(term TermT) OptT {
+	return func(o *optsT) {
+		o.set |= optHeartbeat
+		o.absence = AbsenceHeartbeat
+		o.heartbeat = &term
+	}
+}
+
+// WithMaxKeys bounds the number of partitions held by KeyedMatcher.  When
+// the bound is reached, the least recently used partition is evicted.  Zero,
+// the default, is unbounded.
+func WithMaxKeys(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optMaxKeys
+		o.maxKeys = n
+	}
+}
+
+// WithKeyTTL evicts KeyedMatcher partitions that have not seen a line for
+// longer than ttl.  Zero, the default, disables expiry.
+func WithKeyTTL(ttl int64) OptT {
+	return func(o *optsT) {
+		o.set |= optKeyTTL
+		o.keyTTL = ttl
+	}
+}
+
+// WithMaxGap bounds the time between consecutive terms of MatchSeq.  Gap i
+// applies between sequence terms i and i+1, as supplied, including dupes.
+// A zero gap is unbounded; missing trailing gaps are zero.
+func WithMaxGap(gaps ...int64) OptT {
+	return func(o *optsT) {
+		o.set |= optMaxGap
+		o.maxGap = gaps
+	}
+}
+
+// WithMinGap sets the minimum time between consecutive terms of MatchSeq.
+// Gaps are indexed as with WithMaxGap; a zero or missing gap has no minimum.
+func WithMinGap(gaps ...int64) OptT {
+	return func(o *optsT) {
+		o.set |= optMinGap
+		o.minGap = gaps
+	}
+}
+
+// WithStrictOrder requires MatchSeq terms to have strictly increasing
+// timestamps.  By default, equal timestamps are considered in order.
+func WithStrictOrder(strict bool) OptT {
+	return func(o *optsT) {
+		o.set |= optStrictOrder
+		o.strict = strict
+	}
+}
+
+// WithOptional marks MatchSeq terms, by position as supplied, as optional.
+// An optional term is included in a hit if it matched between its
+// neighbours; the first and last terms cannot be optional.
+func WithOptional(pos ...int) OptT {
+	return func(o *optsT) {
+		o.set |= optOptional
+		o.optional = append(o.optional, pos...)
+	}
+}
+
+// WithLineWindow measures the MatchSeq window, and any gaps, in lines scanned
+// rather than nanoseconds; a window of 100 matches a sequence spanning up to
+// 100 lines after its first term.
+func WithLineWindow() OptT {
+	return func(o *optsT) {
+		o.set |= optLineWindow
+		o.lineWindow = true
+	}
+}
+
+// WithMaxLines bounds a MatchSeq or MatchSet hit to span at most n lines
+// scanned by the matcher, as well as the time window.  Zero, the default, is
+// unbounded.  Ignored with WithLineWindow.
+func WithMaxLines(n int64) OptT {
+	return func(o *optsT) {
+		o.set |= optMaxLines
+		o.maxLines = n
+	}
+}
+
+// WithSample makes MatchSingle emit only the first of every n hits.  Values
+// below two emit every hit.
+func WithSample(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optSample
+		o.sample = n
+	}
+}
+
+// WithRateLimit bounds the hits emitted by MatchSingle to rate per second of
+// log time, with bursts of up to burst hits, by token bucket.  A burst below
+// one is one.  Applied after WithSample.
+func WithRateLimit(rate float64, burst int) OptT {
+	return func(o *optsT) {
+		o.set |= optRateLimit
+		o.rateLimit = rate
+		o.burst = max(burst, 1)
+	}
+}
+
+// WithID names the matcher, for example after the rule it implements.  The ID
+// is recorded on every hit under PropID, in Stats, and in warning logs.
+func WithID(id string) OptT {
+	return func(o *optsT) {
+		o.set |= optID
+		o.id = id
+	}
+}
+
+// WithLabels attaches labels to the matcher, recorded as with WithID under
+// PropLabels.  Treat the map as immutable once passed.
+func WithLabels(labels map[string]string) OptT {
+	return func(o *optsT) {
+		o.set |= optLabels
+		o.labels = labels
+	}
+}
+
+// WithResets adds reset terms to InverseSeq and InverseSet, after any passed
+// to the constructor.
+func WithResets(resets ...ResetT) OptT {
+	return func(o *optsT) {
+		o.set |= optResets
+		o.resets = append(o.resets, resets...)
+	}
+}
+
+// WithGCInterval limits the garbage collection run by Eval on MatchSeq,
+// MatchSet, MatchCount and MatchRate to at most once per interval.  Scans
+// still collect as needed, so hits are unaffected; entries past the window
+// may be held for up to the interval longer.  Sets the GCInterval policy
+// unless another is given; see GCPolicyT.
+func WithGCInterval(interval int64) OptT {
+	return func(o *optsT) {
+		o.set |= optGCInterval
+		o.gcEvery = interval
+		if o.gcPolicy == GCInline {
+			o.gcPolicy = GCInterval
+		}
+	}
+}
+
+// WithGCPolicy sets when deferred garbage collection runs; see GCPolicyT.
+func WithGCPolicy(p GCPolicyT) OptT {
+	return func(o *optsT) {
+		o.set |= optGCPolicy
+		o.gcPolicy = p
+	}
+}
+
+// WithGCSize defers garbage collection until more than n entries are held;
+// for KeyedMatcher, partitions.  Sets the GCSize policy.
+func WithGCSize(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optGCSize
+		o.gcSize = n
+		o.gcPolicy = GCSize
+	}
+}
+
+// WithOrdered requires the InverseSet terms at the given positions, as
+// supplied, to match in the order listed; the remaining terms may match in
+// any order.  Equal timestamps are considered in order.  Ordered terms must
+// be distinct and cannot have a count.
+func WithOrdered(pos ...int) OptT {
+	return func(o *optsT) {
+		o.set |= optOrdered
+		o.ordered = append(o.ordered, pos...)
+	}
+}
+
+// WithQuorum fires a MatchSet when any m of its terms match within the
+// window, rather than all of them.  Duplicate terms count once, when their
+// count is reached.  The terms that made up a hit are recorded under
+// PropQuorum.  An m of the number of terms is a plain set.
+func WithQuorum(m int) OptT {
+	return func(o *optsT) {
+		o.set |= optQuorum
+		o.quorum = m
+	}
+}
+
+// WithShards sets the number of ParallelMatcher workers.  Defaults to
+// GOMAXPROCS.
+func WithShards(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optShards
+		o.shards = n
+	}
+}
+
+// WithShardBatch sets the number of lines ParallelMatcher gathers before
+// dispatching them to the workers.  Defaults to DefShardBatch.
+func WithShardBatch(n int) OptT {
+	return func(o *optsT) {
+		o.set |= optShardBatch
+		o.shardBatch = n
+	}
+}
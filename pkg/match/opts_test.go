@@ -0,0 +1,115 @@
+package match
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOptsUnsupported(t *testing.T) {
+	var (
+		terms  = makeTermsA("alpha", "beta")
+		resets = []ResetT{{Term: makeRaw("reset")}}
+		keyFn  = func(*ScanLine) string { return "k" }
+		single = func() Matcher {
+			m, _ := NewMatchSingle(makeRaw("alpha"))
+			return m
+		}
+		cb = func(Hits) {}
+	)
+
+	cases := map[string]struct {
+		name string
+		ctor func() error
+	}{
+		"SetMaxGap": {
+			name: "WithMaxGap",
+			ctor: func() error { _, err := NewMatchSetOpts(10, terms, WithMaxGap(5)); return err },
+		},
+		"SetOptional": {
+			name: "WithOptional",
+			ctor: func() error { _, err := NewMatchSetOpts(10, terms, WithOptional(1)); return err },
+		},
+		"SeqQuorum": {
+			name: "WithQuorum",
+			ctor: func() error { _, err := NewMatchSeqOpts(10, terms, WithQuorum(1)); return err },
+		},
+		"InverseSetPrecedence": {
+			name: "WithPrecedence",
+			ctor: func() error {
+				_, err := NewInverseSet(10, terms, resets, WithPrecedence(PrecedenceReset))
+				return err
+			},
+		},
+		"SingleResets": {
+			name: "WithResets",
+			ctor: func() error { _, err := NewMatchSingleOpts(makeRaw("alpha"), WithResets(resets...)); return err },
+		},
+		"KeyedShards": {
+			name: "WithShards",
+			ctor: func() error { _, err := NewKeyedMatcher(keyFn, single, WithShards(2)); return err },
+		},
+		"ParallelUnkeyed": {
+			// Without a key, there is no KeyedMatcher to take WithMaxKeys.
+			name: "WithMaxKeys",
+			ctor: func() error { _, err := NewParallel(nil, single, cb, WithMaxKeys(2)); return err },
+		},
+		"MultiID": {
+			name: "WithID",
+			ctor: func() error { _, err := NewMultiMatcher(WithID("rule")); return err },
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.ctor()
+			if !errors.Is(err, ErrOption) {
+				t.Fatalf("Expected ErrOption, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.name) {
+				t.Errorf("Expected %s named, got %v", tc.name, err)
+			}
+		})
+	}
+}
+
+func TestOptsSupported(t *testing.T) {
+	var (
+		terms = makeTermsA("alpha", "beta")
+		ident = []OptT{WithID("rule"), WithLabels(map[string]string{"team": "infra"}), WithGCPolicy(GCManual)}
+	)
+
+	if _, err := NewMatchSeqOpts(10, terms, append(ident, WithMaxGap(5), WithOptional(), WithExplain(4))...); err != nil {
+		t.Errorf("Seq: Expected nil error, got %v", err)
+	}
+	if _, err := NewMatchSetOpts(10, terms, append(ident, WithQuorum(1), WithMaxBuffered(4))...); err != nil {
+		t.Errorf("Set: Expected nil error, got %v", err)
+	}
+
+	p, err := NewParallel(KeyFn(func(*ScanLine) string { return "k" }), func() Matcher {
+		m, _ := NewMatchSingle(makeRaw("alpha"))
+		return m
+	}, func(Hits) {}, WithShards(1), WithMaxKeys(2), WithID("rule"))
+	if err != nil {
+		t.Fatalf("Parallel: Expected nil error, got %v", err)
+	}
+	p.Close()
+}
+
+func TestOptsNames(t *testing.T) {
+	var all optT
+	for _, opt := range []OptT{
+		WithPrecedence(0), WithBetween(1), WithRefire(0), WithAbsence(0), WithHeartbeat(TermT{}),
+		WithMaxKeys(0), WithKeyTTL(0), WithMaxGap(), WithMinGap(), WithStrictOrder(false),
+		WithOptional(), WithExplain(0), WithMaxBuffered(0), WithRegexDb(nil), WithLineWindow(),
+		WithMaxLines(0), WithSample(0), WithRateLimit(0, 0), WithID(""), WithLabels(nil),
+		WithResets(), WithGCInterval(0), WithGCPolicy(0), WithGCSize(0), WithOrdered(),
+		WithQuorum(0), WithShards(0), WithShardBatch(0),
+	} {
+		all |= parseOpts([]OptT{opt}).set
+	}
+
+	if want := optT(1)<<len(optNames) - 1; all != want {
+		t.Errorf("Expected every option named, got %b of %b", all, want)
+	}
+}
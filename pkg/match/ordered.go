@@ -0,0 +1,48 @@
+package match
+
+import (
+	"errors"
+)
+
+var ErrOrdered = errors.New("ordered term out of range")
+
+// Map the WithOrdered positions, as supplied, to set term indices.  Each
+// ordered term must be distinct and without dupes or a count.
+func buildOrdered(setTerms []TermT, pos []int, dupeMap map[int]int) ([]int, error) {
+	if len(pos) == 0 {
+		return nil, nil
+	}
+
+	var (
+		indices = termIndices(setTerms)
+		order   = make([]int, 0, len(pos))
+		seen    = make(map[int]bool, len(pos))
+	)
+	for _, p := range pos {
+		if p < 0 || p >= len(setTerms) {
+			return nil, ErrOrdered
+		}
+		idx := indices[p]
+		if seen[idx] || dupeMap[idx] > 0 {
+			return nil, ErrOrdered
+		}
+		seen[idx] = true
+		order = append(order, idx)
+	}
+
+	return order, nil
+}
+
+// Assumes we are hot; returns the first ordered term whose oldest assert
+// precedes that of the term before it, else -1.  Every later assert of the
+// earlier term is later still, so the offending assert cannot be part of
+// any frame.  Equal timestamps are in order.
+func (r *InverseSet) checkOrder() int {
+	for i := 1; i < len(r.ordered); i++ {
+		prev, cur := r.ordered[i-1], r.ordered[i]
+		if r.terms[cur].asserts[0].Timestamp < r.terms[prev].asserts[0].Timestamp {
+			return cur
+		}
+	}
+	return -1
+}
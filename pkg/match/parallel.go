@@ -0,0 +1,369 @@
+package match
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"runtime"
+	"slices"
+)
+
+var ErrParallelArgs = errors.New("parallel matcher requires a factory")
+
+const (
+	DefShardBatch = 1024
+	parallelDepth = 2 // Batches in flight per shard.
+)
+
+// ParallelMatcher fans a stream out across worker goroutines, each running
+// its own clone of a matcher, for CPU bound rules.
+//
+// With a key function, lines are sharded by correlation key: each shard holds
+// a KeyedMatcher over the factory, and every line of a key goes to the same
+// shard, so the result is that of a single KeyedMatcher.  Without one, lines
+// are dealt round robin, which is only correct for stateless matchers such as
+// MatchSingle.
+//
+// Lines are gathered into batches of WithShardBatch, and every shard walks
+// every batch, scanning its own lines.  Keys are assigned to shards by a
+// fixed hash.  Hits are reported in the order of the lines that fired them;
+// hits fired together, by the evaluation at the end of a batch or by Eval,
+// are merged in order of fire stamp then key, so the output depends on
+// neither scheduling nor the number of shards.  Time driven matchers are evaluated at the last
+// timestamp of each batch rather than at every line, so they may fire up to a
+// batch later, by clock, than under Scan.  WithMaxKeys and WithKeyTTL apply to
+// each shard.
+//
+// Hits are passed to the callback on the goroutine calling Scan, Flush or
+// Eval; the matcher itself is not safe for concurrent use.  Close stops the
+// workers.
+type ParallelMatcher struct {
+	keyFn  KeyFn
+	cb     ParallelHitFuncT
+	shards []*shardT
+	size   int
+	batch  parallelBatchT
+	queued int // Batches dispatched and not yet merged.
+	rr     int
+	merge  []seqHitsT
+	line   ScanLine // For the key function.
+	closed bool
+}
+
+// ParallelHitFuncT receives merged hits, in order.
+type ParallelHitFuncT func(hits Hits)
+
+type parallelBatchT struct {
+	entries []LogEntry
+	owner   []int32 // Shard of each entry; -1 for none.
+	clock   int64
+}
+
+type shardT struct {
+	m    Matcher
+	in   chan parallelBatchT
+	out  chan []seqHitsT
+	line ScanLine
+}
+
+type seqHitsT struct {
+	seq   int // Twice the index in the batch; odd for the evaluation at its end.
+	shard int
+	hits  Hits
+}
+
+// NewParallel starts the workers of a parallel matcher over factory.  keyFn
+// may be nil for round robin; opts are passed through to each KeyedMatcher.
+func NewParallel(keyFn KeyFn, factory func() Matcher, cb ParallelHitFuncT, opts ...OptT) (*ParallelMatcher, error) {
+	if factory == nil || cb == nil {
+		return nil, ErrParallelArgs
+	}
+
+	// Without a key there is no KeyedMatcher to pass the rest through to.
+	accept := optShards | optShardBatch
+	if keyFn != nil {
+		accept |= optsKeyed
+	}
+
+	o := parseOpts(opts)
+	if err := o.check(accept); err != nil {
+		return nil, err
+	}
+
+	n := o.shards
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	size := o.shardBatch
+	if size <= 0 {
+		size = DefShardBatch
+	}
+
+	p := &ParallelMatcher{
+		keyFn: keyFn,
+		cb:    cb,
+		size:  size,
+		batch: newBatch(size),
+	}
+
+	for range n {
+		m := p.clone(factory, o)
+
+		sh := &shardT{
+			m:   m,
+			in:  make(chan parallelBatchT, parallelDepth),
+			out: make(chan []seqHitsT, parallelDepth),
+		}
+		p.shards = append(p.shards, sh)
+		go sh.run(len(p.shards) - 1)
+	}
+
+	return p, nil
+}
+
+func (p *ParallelMatcher) clone(factory func() Matcher, o optsT) Matcher {
+	if p.keyFn == nil {
+		return factory()
+	}
+	return newKeyed(p.keyFn, factory, o)
+}
+
+// Scan queues a copy of the entry.  Once a batch is full it is dispatched,
+// and hits from earlier batches may be reported.  The line must not view a
+// borrowed buffer.
+func (p *ParallelMatcher) Scan(e LogEntry) {
+	owner := p.owner(e)
+
+	p.batch.entries = append(p.batch.entries, e)
+	p.batch.owner = append(p.batch.owner, owner)
+	p.batch.clock = max(p.batch.clock, e.Timestamp)
+
+	if len(p.batch.entries) >= p.size {
+		p.dispatch()
+	}
+}
+
+// Flush dispatches the pending batch and reports every outstanding hit.
+func (p *ParallelMatcher) Flush() {
+	if len(p.batch.entries) > 0 {
+		p.dispatch()
+	}
+	for p.queued > 0 {
+		p.collect()
+	}
+}
+
+// Eval flushes, then evaluates every shard at clock.
+func (p *ParallelMatcher) Eval(clock int64) {
+	p.Flush()
+
+	p.merge = p.merge[:0]
+	for i, sh := range p.shards {
+		if hits := sh.m.Eval(clock); hits.Cnt > 0 {
+			p.merge = append(p.merge, seqHitsT{shard: i, hits: hits})
+		}
+	}
+	if hits := mergeHits(p.merge); hits.Cnt > 0 {
+		p.cb(hits)
+	}
+	clear(p.merge)
+}
+
+// GarbageCollect flushes, then collects every shard at clock.
+func (p *ParallelMatcher) GarbageCollect(clock int64) {
+	p.Flush()
+	for _, sh := range p.shards {
+		sh.m.GarbageCollect(clock)
+	}
+}
+
+// Stats flushes, then sums the counters of the shards, lines scanned
+// included, as each shard scans only its own lines.
+func (p *ParallelMatcher) Stats() StatsT {
+	p.Flush()
+	var s StatsT
+	for _, sh := range p.shards {
+		o := statsOf(sh.m)
+		s.Scanned += o.Scanned
+		s.add(o)
+	}
+	return s
+}
+
+// Close flushes and stops the workers.
+func (p *ParallelMatcher) Close() {
+	if p.closed {
+		return
+	}
+	p.Flush()
+	for _, sh := range p.shards {
+		close(sh.in)
+	}
+	p.closed = true
+}
+
+// Shard of an entry; -1 if keyed and the key is empty.
+func (p *ParallelMatcher) owner(e LogEntry) int32 {
+	if p.keyFn == nil {
+		p.rr = (p.rr + 1) % len(p.shards)
+		return int32(p.rr)
+	}
+
+	key := p.keyFn(p.line.Reset(e))
+	if key == "" {
+		return -1
+	}
+	return int32(hashKey(key) % uint64(len(p.shards)))
+}
+
+// FNV-1a; unlike maphash, the same in every process, so that a key lands on
+// the same shard from run to run.
+func hashKey(key string) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime
+	}
+	return h
+}
+
+// Send the batch to every shard, first merging the oldest if the shards
+// are full.
+func (p *ParallelMatcher) dispatch() {
+	if p.queued >= parallelDepth {
+		p.collect()
+	}
+
+	for _, sh := range p.shards {
+		sh.in <- p.batch
+	}
+	p.queued += 1
+
+	// The batch is shared read only by the shards; start a new one.
+	p.batch = newBatch(p.size)
+}
+
+func newBatch(size int) parallelBatchT {
+	return parallelBatchT{
+		entries: make([]LogEntry, 0, size),
+		owner:   make([]int32, 0, size),
+		clock:   math.MinInt64,
+	}
+}
+
+// Merge the results of the oldest batch from every shard.
+func (p *ParallelMatcher) collect() {
+	p.merge = p.merge[:0]
+	for _, sh := range p.shards {
+		p.merge = append(p.merge, <-sh.out...)
+	}
+	p.queued -= 1
+
+	slices.SortStableFunc(p.merge, func(a, b seqHitsT) int {
+		if a.seq != b.seq {
+			return a.seq - b.seq
+		}
+		return a.shard - b.shard
+	})
+
+	for run := p.merge; len(run) > 0; {
+		n := 1
+		for n < len(run) && run[n].seq == run[0].seq {
+			n += 1
+		}
+		if hits := mergeHits(run[:n]); hits.Cnt > 0 {
+			p.cb(hits)
+		}
+		run = run[n:]
+	}
+	clear(p.merge)
+}
+
+// Merge hits fired at the same point of the stream, by any shard, into one,
+// ordered by fire stamp, then key, then shard.  A hit takes the end of its
+// window as its own fire stamp, bounded by that of its group; hits evaluated
+// together fire at one clock, but each was settled once its window closed.
+func mergeHits(res []seqHitsT) Hits {
+	if len(res) == 1 && res[0].hits.Cnt == 1 {
+		return res[0].hits
+	}
+
+	type oneT struct {
+		shard int
+		key   string
+		hits  Hits
+	}
+
+	var all []oneT
+	for _, r := range res {
+		// Split the props by hit in one pass.
+		props := make([]map[PropKey]any, r.hits.Cnt)
+		for k, v := range r.hits.Props {
+			if props[k.Idx] == nil {
+				props[k.Idx] = make(map[PropKey]any)
+			}
+			props[k.Idx][PropKey{Key: k.Key}] = v
+		}
+
+		var i int
+		for hit := range r.hits.Iter() {
+			key, _ := props[i][PropKey{Key: PropKeyed}].(string)
+			all = append(all, oneT{
+				shard: r.shard,
+				key:   key,
+				hits: Hits{
+					Cnt:       1,
+					Logs:      hit.Logs,
+					Props:     props[i],
+					FireStamp: min(r.hits.FireStamp, hit.Window[1]),
+				},
+			})
+			i += 1
+		}
+	}
+
+	slices.SortStableFunc(all, func(a, b oneT) int {
+		return cmp.Or(
+			cmp.Compare(a.hits.FireStamp, b.hits.FireStamp),
+			cmp.Compare(a.key, b.key),
+			cmp.Compare(a.shard, b.shard),
+		)
+	})
+
+	var out Hits
+	for _, o := range all {
+		out.Append(o.hits)
+	}
+
+	// The clock at which the hits fired, as reported by the shards.
+	for _, r := range res {
+		out.FireStamp = max(out.FireStamp, r.hits.FireStamp)
+	}
+	return out
+}
+
+func (sh *shardT) run(idx int) {
+	for b := range sh.in {
+		var res []seqHitsT
+
+		for i := range b.entries {
+			if b.owner[i] != int32(idx) {
+				continue
+			}
+			if hits := sh.m.Scan(sh.line.Reset(b.entries[i])); hits.Cnt > 0 {
+				res = append(res, seqHitsT{seq: 2 * i, shard: idx, hits: hits})
+			}
+		}
+
+		if hits := sh.m.Eval(b.clock); hits.Cnt > 0 {
+			res = append(res, seqHitsT{seq: 2*len(b.entries) + 1, shard: idx, hits: hits})
+		}
+
+		sh.out <- res
+	}
+}
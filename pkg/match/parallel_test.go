@@ -0,0 +1,321 @@
+package match
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Lines for n pods, interleaved, each starting and failing in turn.
+func parallelLines(n, pods int) []LogEntry {
+	out := make([]LogEntry, 0, n)
+	for i := range n {
+		var (
+			pod  = i % pods
+			verb = "start"
+		)
+		if (i/pods)%2 == 1 {
+			verb = "fail"
+		}
+		out = append(out, LogEntry{Timestamp: int64(i + 1), Line: fmt.Sprintf("pod=p%d %s", pod, verb)})
+	}
+	return out
+}
+
+func parallelSeq(tb testing.TB) func() Matcher {
+	return func() Matcher {
+		m, err := NewMatchSeq(int64(1000), makeTermsA("start", "fail")...)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return m
+	}
+}
+
+// Render hits as the stamps and key of each, in order.
+func renderHits(out *[]string) func(Hits) {
+	return func(hits Hits) {
+		for hit := range hits.Iter() {
+			var stamps []string
+			for _, l := range hit.Logs {
+				stamps = append(stamps, fmt.Sprint(l.Timestamp))
+			}
+			*out = append(*out, fmt.Sprintf("%v:%s", hit.Props[PropKeyed], strings.Join(stamps, ",")))
+		}
+	}
+}
+
+func TestParallelKeyed(t *testing.T) {
+	var (
+		lines   = parallelLines(500, 7)
+		factory = parallelSeq(t)
+		want    []string
+		cb      = renderHits(&want)
+	)
+
+	keyFn, err := KeyRegex(`pod=(\w+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := NewScanLine()
+	for _, e := range lines {
+		cb(km.Scan(line.Reset(e)))
+	}
+	if len(want) == 0 {
+		t.Fatal("Expected hits")
+	}
+
+	for _, shards := range []int{1, 3, 8} {
+		t.Run(fmt.Sprint(shards), func(t *testing.T) {
+			var got []string
+
+			pm, err := NewParallel(keyFn, factory, renderHits(&got), WithShards(shards), WithShardBatch(16))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pm.Close()
+
+			for _, e := range lines {
+				pm.Scan(e)
+			}
+			pm.Flush()
+
+			if !slices.Equal(got, want) {
+				t.Errorf("Expected %d hits in order, got %d:\n%v\n%v", len(want), len(got), want, got)
+			}
+			if s := pm.Stats(); s.Scanned != uint64(len(lines)) {
+				t.Errorf("Expected %d scanned, got %d", len(lines), s.Scanned)
+			}
+		})
+	}
+}
+
+func TestParallelRoundRobin(t *testing.T) {
+	var (
+		got     []int64
+		factory = func() Matcher {
+			m, err := NewMatchSingle(makeRaw("fail"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return m
+		}
+	)
+
+	pm, err := NewParallel(nil, factory, func(hits Hits) {
+		for hit := range hits.Iter() {
+			got = append(got, hit.Logs[0].Timestamp)
+		}
+	}, WithShards(4), WithShardBatch(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := parallelLines(100, 3)
+	for _, e := range lines {
+		pm.Scan(e)
+	}
+	pm.Close()
+
+	var want []int64
+	for _, e := range lines {
+		if strings.HasSuffix(e.Line, "fail") {
+			want = append(want, e.Timestamp)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestParallelTimed(t *testing.T) {
+	var (
+		got     []string
+		factory = func() Matcher {
+			m, err := NewInverseSeq(10, makeTermsA("start", "fail"), []ResetT{{Term: makeRaw("ok"), Window: 5}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return m
+		}
+	)
+
+	keyFn, _ := KeyRegex(`pod=(\w+)`)
+	pm, err := NewParallel(keyFn, factory, renderHits(&got), WithShards(2), WithShardBatch(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pm.Close()
+
+	pm.Scan(LogEntry{Timestamp: 1, Line: "pod=a start"})
+	pm.Scan(LogEntry{Timestamp: 2, Line: "pod=a fail"})
+	pm.Scan(LogEntry{Timestamp: 3, Line: "pod=b start"})
+	pm.Flush()
+	if len(got) != 0 {
+		t.Errorf("Expected no hits before the reset window, got %v", got)
+	}
+
+	pm.Eval(20)
+	if !slices.Equal(got, []string{"a:1,2"}) {
+		t.Errorf("Expected the held hit, got %v", got)
+	}
+}
+
+func TestParallelEvalOrder(t *testing.T) {
+	factory := func() Matcher {
+		m, err := NewInverseSeq(10, makeTermsA("start", "fail"), []ResetT{{Term: makeRaw("ok"), Window: 5}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	keyFn, _ := KeyRegex(`pod=(\w+)`)
+
+	want := []string{"e:1,2", "c:3,4", "g:3,4", "a:5,6", "b:27,28", "d:29,30"}
+
+	for _, shards := range []int{1, 2, 5} {
+		t.Run(fmt.Sprint(shards), func(t *testing.T) {
+			var got []string
+
+			pm, err := NewParallel(keyFn, factory, renderHits(&got), WithShards(shards), WithShardBatch(100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pm.Close()
+
+			// Keys e, c, g and a are held by the reset window and fire together
+			// at the first Eval, in the order their windows closed; g closes
+			// with c, and follows it by key.  b and d fire at the second.
+			for i, pods := range [][]string{{"e"}, {"g", "c"}, {"a"}, {"b"}, {"d"}} {
+				ts := int64(2*i + 1)
+				if i > 2 {
+					ts += 20
+				}
+				for _, pod := range pods {
+					pm.Scan(LogEntry{Timestamp: ts, Line: "pod=" + pod + " start"})
+				}
+				for _, pod := range pods {
+					pm.Scan(LogEntry{Timestamp: ts + 1, Line: "pod=" + pod + " fail"})
+				}
+				if i == 2 {
+					pm.Eval(20)
+				}
+			}
+			pm.Eval(50)
+
+			if !slices.Equal(got, want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestParallelMergeHits(t *testing.T) {
+	var (
+		keyed = func(keys ...string) map[PropKey]any {
+			props := make(map[PropKey]any)
+			for i, key := range keys {
+				props[PropKey{Idx: i, Key: PropKeyed}] = key
+			}
+			return props
+		}
+		res = []seqHitsT{
+			{shard: 0, hits: Hits{Cnt: 2, Logs: []LogEntry{{Timestamp: 1}, {Timestamp: 9}}, Props: keyed("a", "b"), FireStamp: 10}},
+			{shard: 1, hits: Hits{Cnt: 1, Logs: []LogEntry{{Timestamp: 5}}, Props: keyed("c"), FireStamp: 10}},
+		}
+	)
+
+	hits := mergeHits(res)
+
+	var got []string
+	for hit := range hits.Iter() {
+		got = append(got, fmt.Sprintf("%v:%d", hit.Props[PropKeyed], hit.Logs[0].Timestamp))
+	}
+	if want := []string{"a:1", "c:5", "b:9"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if hits.FireStamp != 10 {
+		t.Errorf("Expected fire stamp 10, got %d", hits.FireStamp)
+	}
+}
+
+func TestParallelArgs(t *testing.T) {
+	if _, err := NewParallel(nil, nil, func(Hits) {}); err != ErrParallelArgs {
+		t.Errorf("Expected ErrParallelArgs, got %v", err)
+	}
+}
+
+// A rule with costly terms, for which sharding pays.
+func benchFactory(b *testing.B) func() Matcher {
+	return func() Matcher {
+		m, err := NewMatchSeq(int64(1000),
+			TermT{Type: TermRegex, Value: `pod=\w+ (start|begin) id=\d+`},
+			TermT{Type: TermJqJson, Value: `.level == "error" and .msg == "fail"`},
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return m
+	}
+}
+
+func benchLines(n int) []LogEntry {
+	out := make([]LogEntry, n)
+	for i := range out {
+		line := fmt.Sprintf(`{"pod":"p%d","level":"info","msg":"served id=%d"}`, i%64, i)
+		if i%3 == 0 {
+			line = fmt.Sprintf(`{"pod":"p%d","level":"error","msg":"fail"}`, i%64)
+		}
+		out[i] = LogEntry{Timestamp: int64(i + 1), Line: line}
+	}
+	return out
+}
+
+func BenchmarkKeyedSerial(b *testing.B) {
+	var (
+		lines = benchLines(4096)
+		line  = NewScanLine()
+	)
+	keyFn, _ := KeyRegex(`"pod":"(\w+)"`)
+
+	km, err := NewKeyedMatcher(keyFn, benchFactory(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := lines[i%len(lines)]
+		e.Timestamp = int64(i + 1)
+		km.Scan(line.Reset(e))
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	lines := benchLines(4096)
+	keyFn, _ := KeyRegex(`"pod":"(\w+)"`)
+
+	for _, shards := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			pm, err := NewParallel(keyFn, benchFactory(b), func(Hits) {}, WithShards(shards))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer pm.Close()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e := lines[i%len(lines)]
+				e.Timestamp = int64(i + 1)
+				pm.Scan(e)
+			}
+			pm.Flush()
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package match
+
+type PausePolicyT int
+
+const (
+	PauseDrop  PausePolicyT = iota // Discard hits emitted while paused.
+	PauseQueue                     // Queue hits emitted while paused; flush on Resume.
+)
+
+// DefPauseQueue is the number of hits MatchPause queues while paused, by
+// default.
+const DefPauseQueue = 1024
+
+// MatchPause wraps a Matcher so that firing can be temporarily suspended.
+//
+// While paused, entries are still scanned and garbage collected by the
+// wrapped matcher so its state stays current; only the emitted hits are
+// suppressed.  Depending on the policy, suppressed hits are either dropped
+// or queued and returned on Resume.  The queue holds at most DefPauseQueue
+// hits, or the bound given to NewMatchPauseQueue; past it, the oldest hits
+// are dropped and counted in StatsT.Evicted.
+
+type MatchPause struct {
+	m        Matcher
+	policy   PausePolicyT
+	paused   bool
+	queued   Hits
+	maxQueue int
+	nEvicted uint64
+}
+
+func NewMatchPause(m Matcher, policy PausePolicyT) *MatchPause {
+	return &MatchPause{m: m, policy: policy, maxQueue: DefPauseQueue}
+}
+
+// NewMatchPauseQueue returns a MatchPause with the PauseQueue policy that
+// queues at most maxQueue hits while paused; zero or less is DefPauseQueue.
+func NewMatchPauseQueue(m Matcher, maxQueue int) *MatchPause {
+	if maxQueue <= 0 {
+		maxQueue = DefPauseQueue
+	}
+	return &MatchPause{m: m, policy: PauseQueue, maxQueue: maxQueue}
+}
+
+func (r *MatchPause) Pause() {
+	r.paused = true
+}
+
+// Resume re-enables firing.  If the policy is PauseQueue, the hits
+// suppressed while paused are returned; otherwise Resume returns no hits.
+func (r *MatchPause) Resume() (hits Hits) {
+	r.paused = false
+	hits, r.queued = r.queued, Hits{}
+	return
+}
+
+func (r *MatchPause) Paused() bool {
+	return r.paused
+}
+
+func (r *MatchPause) Scan(e *ScanLine) Hits {
+	return r.filter(r.m.Scan(e))
+}
+
+func (r *MatchPause) Eval(clock int64) Hits {
+	return r.filter(r.m.Eval(clock))
+}
+
+func (r *MatchPause) GarbageCollect(clock int64) {
+	r.m.GarbageCollect(clock)
+}
+
+// Stats returns the counters of the wrapped matcher; Evicted includes the
+// hits dropped from the queue.
+func (r *MatchPause) Stats() StatsT {
+	s := statsOf(r.m)
+	s.Evicted += r.nEvicted
+	return s
+}
+
+func (r *MatchPause) filter(hits Hits) Hits {
+	if !r.paused || hits.Cnt == 0 {
+		return hits
+	}
+
+	if r.policy == PauseQueue {
+		r.queued.Append(hits)
+		if n := r.queued.Cnt - r.maxQueue; n > 0 {
+			r.queued = r.queued.Tail(r.maxQueue)
+			r.nEvicted += uint64(n)
+		}
+	}
+
+	return Hits{}
+}
@@ -0,0 +1,141 @@
+package match
+
+import "testing"
+
+func TestMatchPause(t *testing.T) {
+
+	cases := map[string]struct {
+		policy PausePolicyT
+		resume func(*testing.T, int, Hits)
+	}{
+		"Drop": {
+			policy: PauseDrop,
+			resume: checkNoFire,
+		},
+		"Queue": {
+			policy: PauseQueue,
+			resume: matchStampsN(2, 1, 2, 3, 4),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			seq, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+			if err != nil {
+				t.Fatalf("Expected err == nil, got %v", err)
+			}
+
+			var (
+				sm = NewMatchPause(seq, tc.policy)
+				sl = NewScanLine()
+			)
+
+			sm.Pause()
+
+			// State is maintained while paused; the sequences still complete.
+			checkNoFire(t, 1, sm.Scan(sl.ResetLine(1, "alpha")))
+			checkNoFire(t, 2, sm.Scan(sl.ResetLine(2, "beta")))
+			checkNoFire(t, 3, sm.Scan(sl.ResetLine(3, "alpha")))
+			checkNoFire(t, 4, sm.Scan(sl.ResetLine(4, "beta")))
+
+			// A partial match started while paused completes after resume.
+			checkNoFire(t, 5, sm.Scan(sl.ResetLine(5, "alpha")))
+
+			if !sm.Paused() {
+				t.Fatalf("Expected paused")
+			}
+
+			tc.resume(t, 6, sm.Resume())
+
+			matchStamps(5, 6)(t, 7, sm.Scan(sl.ResetLine(6, "beta")))
+
+			// Nothing left in the queue.
+			checkNoFire(t, 8, sm.Resume())
+		})
+	}
+}
+
+func TestMatchPauseEval(t *testing.T) {
+	resets := []ResetT{{Term: makeRaw("reset"), Window: 10, Absolute: true}}
+
+	seq, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), resets)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var (
+		sm = NewMatchPause(seq, PauseQueue)
+		sl = NewScanLine()
+	)
+
+	sm.Scan(sl.ResetLine(1, "alpha"))
+	sm.Scan(sl.ResetLine(2, "beta"))
+
+	sm.Pause()
+	checkNoFire(t, 1, sm.Eval(20))
+	sm.GarbageCollect(20)
+
+	matchStamps(1, 2)(t, 2, sm.Resume())
+}
+
+func TestMatchPauseQueueLimit(t *testing.T) {
+	m, err := NewMatchSingle(makeRaw("alpha"))
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var (
+		sm = NewMatchPauseQueue(m, 2)
+		sl = NewScanLine()
+	)
+
+	sm.Pause()
+	for i := range 5 {
+		checkNoFire(t, i+1, sm.Scan(sl.ResetLine(int64(i+1), "alpha")))
+	}
+
+	if s := sm.Stats(); s.Evicted != 3 || s.Hits != 5 {
+		t.Errorf("Expected 3 evicted of 5 hits, got %d of %d", s.Evicted, s.Hits)
+	}
+
+	// The oldest hits were dropped.
+	hits := sm.Resume()
+	if hits.Cnt != 2 || hits.Logs[0].Timestamp != 4 || hits.Logs[1].Timestamp != 5 {
+		t.Errorf("Expected hits at 4 and 5, got %v", hits.Logs)
+	}
+
+	if d := NewMatchPauseQueue(m, 0); d.maxQueue != DefPauseQueue {
+		t.Errorf("Expected default bound %d, got %d", DefPauseQueue, d.maxQueue)
+	}
+}
+
+func TestHitsAppend(t *testing.T) {
+	var h Hits
+
+	h.Append(Hits{})
+	if h.Cnt != 0 || h.Props != nil {
+		t.Fatalf("Expected empty hits, got %v", h)
+	}
+
+	h.Append(Hits{
+		Cnt:   1,
+		Logs:  makeTestLogs(2),
+		Props: map[PropKey]any{{Idx: 0, Key: "a"}: 1},
+	})
+	h.Append(Hits{
+		Cnt:   2,
+		Logs:  makeTestLogs(4),
+		Props: map[PropKey]any{{Idx: 1, Key: "b"}: 2},
+	})
+
+	if h.Cnt != 3 || len(h.Logs) != 6 {
+		t.Fatalf("Expected 3 hits with 6 logs, got %v hits with %v logs", h.Cnt, len(h.Logs))
+	}
+
+	if v := h.IndexProps(0)["a"]; v != 1 {
+		t.Errorf("Expected prop a on index 0, got %v", v)
+	}
+	if v := h.IndexProps(2)["b"]; v != 2 {
+		t.Errorf("Expected prop b on index 2, got %v", v)
+	}
+}
@@ -0,0 +1,108 @@
+package match
+
+// PendingI is implemented by matchers that can report the partial matches
+// they hold; what a rule is waiting for.
+type PendingI interface {
+	Pending() []PendingT
+}
+
+// PendingT is a frame of asserts the matcher is waiting to complete.  Only the
+// frame evaluated next is reported; later asserts of a term queue behind it.
+//
+// A frame that lacks terms waits on Missing.  A complete frame waits on the
+// reset windows in Resets to close before it fires.
+type PendingT struct {
+	Logs     []LogEntry      // Asserts of the frame, in term order, dupes included.
+	Missing  []int           // Terms yet to match, as TraceT.Term; nil if complete.
+	Start    int64           // Timestamp of the earliest assert.
+	Deadline int64           // Last timestamp at which the frame may complete; Start plus the window.
+	Resets   []PendingResetT // Reset windows still open on a complete frame.
+}
+
+// PendingResetT is a reset window holding back a complete frame.  A reset line
+// stamped within the window, inclusive, drops the frame.
+type PendingResetT struct {
+	Reset int   // Reset term, as TraceT.Reset.
+	Start int64 // Window start.
+	Stop  int64 // Window stop.
+	Until int64 // Clock after which the window is closed, as TraceT.Until.
+}
+
+// Pending returns the frame at the head of the sequence, if any term has
+// matched.  Terms are missing from the first inactive term on.
+func (r *InverseSeq) Pending() []PendingT {
+	if len(r.terms[0].asserts) == 0 {
+		return nil
+	}
+
+	var p PendingT
+	for i, term := range r.terms {
+		cnt := min(len(term.asserts), r.dupeMap[i]+1)
+		if i >= r.nActive {
+			p.Missing = append(p.Missing, i)
+		}
+		p.Logs = append(p.Logs, term.asserts[:cnt]...)
+	}
+
+	p.Start = r.terms[0].asserts[0].Timestamp
+	p.Deadline = p.Start + r.window
+
+	if p.Missing == nil {
+		p.Resets = pendingResets(r.resets, gatherAnchors(r.terms, r.dupeMap), r.clock)
+	}
+	return []PendingT{p}
+}
+
+// Pending returns the frame of the earliest asserts of each term, if any term
+// has matched.  Terms are missing until matched as many times as they repeat.
+func (r *InverseSet) Pending() []PendingT {
+	var (
+		p    PendingT
+		seen bool
+	)
+
+	for i, term := range r.terms {
+		var (
+			want = r.dupeMap[i] + 1
+			cnt  = min(len(term.asserts), want)
+		)
+		if cnt < want {
+			p.Missing = append(p.Missing, i)
+		}
+		for _, e := range term.asserts[:cnt] {
+			if !seen || e.Timestamp < p.Start {
+				p.Start, seen = e.Timestamp, true
+			}
+		}
+		p.Logs = append(p.Logs, term.asserts[:cnt]...)
+	}
+
+	if !seen {
+		return nil
+	}
+
+	p.Deadline = p.Start + r.window
+
+	if p.Missing == nil {
+		p.Resets = pendingResets(r.resets, r.sortedAnchors(), r.clock)
+	}
+	return []PendingT{p}
+}
+
+// Reset windows on the anchors that have yet to close at clock; see evalResets.
+func pendingResets(resets []resetT, anchors []anchorT, clock int64) (out []PendingResetT) {
+	for i, reset := range resets {
+		if reset.corr != nil {
+			if _, ok := reset.corr.entryValue(*anchors[reset.anchor].entry); !ok {
+				continue
+			}
+		}
+
+		start, stop := reset.calcWindowA(anchors)
+		if stop < clock {
+			continue
+		}
+		out = append(out, PendingResetT{Reset: i, Start: start, Stop: stop, Until: stop + 1})
+	}
+	return
+}
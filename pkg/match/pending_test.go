@@ -0,0 +1,87 @@
+package match
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPendingInverseSeq(t *testing.T) {
+	m, err := NewInverseSeq(10, makeTermsA("start", "fail"), []ResetT{{Term: makeRaw("ok"), Window: 5, Absolute: true}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var _ PendingI = m
+	sl := NewScanLine()
+
+	if p := m.Pending(); p != nil {
+		t.Fatalf("Expected nothing pending, got %+v", p)
+	}
+
+	m.Scan(sl.ResetLine(1, "start"))
+	want := []PendingT{{
+		Logs:     []LogEntry{{Timestamp: 1, Line: "start"}},
+		Missing:  []int{1},
+		Start:    1,
+		Deadline: 11,
+	}}
+	if p := m.Pending(); !reflect.DeepEqual(p, want) {
+		t.Errorf("Expected %+v, got %+v", want, p)
+	}
+
+	// Complete; held on the reset window.
+	if hits := m.Scan(sl.ResetLine(3, "fail")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+	want = []PendingT{{
+		Logs:     []LogEntry{{Timestamp: 1, Line: "start"}, {Timestamp: 3, Line: "fail"}},
+		Start:    1,
+		Deadline: 11,
+		Resets:   []PendingResetT{{Reset: 0, Start: 1, Stop: 6, Until: 7}},
+	}}
+	if p := m.Pending(); !reflect.DeepEqual(p, want) {
+		t.Errorf("Expected %+v, got %+v", want, p)
+	}
+
+	if hits := m.Eval(7); hits.Cnt != 1 {
+		t.Fatalf("Expected 1 hit, got %v", hits.Cnt)
+	}
+	if p := m.Pending(); p != nil {
+		t.Errorf("Expected nothing pending, got %+v", p)
+	}
+}
+
+func TestPendingInverseSet(t *testing.T) {
+	m, err := NewInverseSet(10, makeTermsA("alpha", "beta", "beta"), []ResetT{{Term: makeRaw("ok")}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var _ PendingI = m
+	sl := NewScanLine()
+
+	m.Scan(sl.ResetLine(2, "beta"))
+	m.Scan(sl.ResetLine(3, "alpha"))
+
+	want := []PendingT{{
+		Logs:     []LogEntry{{Timestamp: 3, Line: "alpha"}, {Timestamp: 2, Line: "beta"}},
+		Missing:  []int{1},
+		Start:    2,
+		Deadline: 12,
+	}}
+	if p := m.Pending(); !reflect.DeepEqual(p, want) {
+		t.Errorf("Expected %+v, got %+v", want, p)
+	}
+
+	// Complete; the reset window spans the frame, so is closed once scanned past.
+	m.Scan(sl.ResetLine(4, "beta"))
+	want = []PendingT{{
+		Logs:     []LogEntry{{Timestamp: 3, Line: "alpha"}, {Timestamp: 2, Line: "beta"}, {Timestamp: 4, Line: "beta"}},
+		Start:    2,
+		Deadline: 12,
+		Resets:   []PendingResetT{{Reset: 0, Start: 2, Stop: 4, Until: 5}},
+	}}
+	if p := m.Pending(); !reflect.DeepEqual(p, want) {
+		t.Errorf("Expected %+v, got %+v", want, p)
+	}
+}
@@ -0,0 +1,40 @@
+package match
+
+import (
+	"errors"
+)
+
+var ErrQuorum = errors.New("quorum out of range")
+
+// PropQuorum is the Props key holding the positions, as supplied, of the
+// terms that made up a WithQuorum hit, as an ascending []int.
+const PropQuorum = "quorum"
+
+// Whether the hot terms make a frame.
+func (r *MatchSet) full() bool {
+	if r.quorum == 0 {
+		return r.hotMask.FirstN(len(r.terms))
+	}
+	return r.hotMask.Count() >= r.quorum
+}
+
+// Positions of the hot terms, as supplied.
+func (r *MatchSet) quorumTerms() []int {
+	pos := make([]int, 0, len(r.indices))
+	for p, idx := range r.indices {
+		if r.hotMask.IsSet(idx) {
+			pos = append(pos, p)
+		}
+	}
+	return pos
+}
+
+// QuorumOf returns the positions of the terms that made up hit i of a
+// WithQuorum set.
+func QuorumOf(h Hits, i int) ([]int, bool) {
+	if i < 0 || i >= h.Cnt || h.Props == nil {
+		return nil, false
+	}
+	pos, ok := h.Props[PropKey{Idx: i, Key: PropQuorum}].([]int)
+	return pos, ok
+}
@@ -0,0 +1,84 @@
+package match
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetQuorum(t *testing.T) {
+	sm, err := NewMatchSetOpts(10, makeTermsA("oom", "restart", "probe", "evict"), WithQuorum(2))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+
+	if hits := sm.Scan(sl.ResetLine(1, "probe")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits)
+	}
+
+	hits := sm.Scan(sl.ResetLine(2, "oom"))
+	matchStamps(2, 1)(t, 1, hits)
+	if pos, ok := QuorumOf(hits, 0); !ok || !slices.Equal(pos, []int{0, 2}) {
+		t.Errorf("Expected quorum of terms [0 2], got %v", pos)
+	}
+
+	// A lone term is held for a later frame, until it leaves the window.
+	if hits := sm.Scan(sl.ResetLine(3, "evict")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits)
+	}
+	hits = sm.Scan(sl.ResetLine(5, "restart"))
+	matchStamps(5, 3)(t, 2, hits)
+	if pos, _ := QuorumOf(hits, 0); !slices.Equal(pos, []int{1, 3}) {
+		t.Errorf("Expected quorum of terms [1 3], got %v", pos)
+	}
+
+	sm.Scan(sl.ResetLine(10, "oom"))
+	if hits := sm.Scan(sl.ResetLine(30, "probe")); hits.Cnt != 0 {
+		t.Errorf("Expected no hits outside the window, got %v", hits)
+	}
+}
+
+func TestSetQuorumDupes(t *testing.T) {
+	terms := []TermT{makeRaw("oom"), makeRaw("oom"), makeRaw("restart"), makeRaw("probe")}
+
+	sm, err := NewMatchSetOpts(10, terms, WithQuorum(2))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	sm.Scan(sl.ResetLine(1, "oom"))
+	if hits := sm.Scan(sl.ResetLine(2, "probe")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits short of the dupe count, got %v", hits)
+	}
+
+	hits := sm.Scan(sl.ResetLine(3, "oom"))
+	matchStamps(1, 3, 2)(t, 1, hits)
+	if pos, _ := QuorumOf(hits, 0); !slices.Equal(pos, []int{0, 1, 3}) {
+		t.Errorf("Expected quorum of terms [0 1 3], got %v", pos)
+	}
+}
+
+func TestSetQuorumAll(t *testing.T) {
+	sm, err := NewMatchSetOpts(10, makeTermsA("alpha", "beta"), WithQuorum(2))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	sm.Scan(sl.ResetLine(1, "alpha"))
+	hits := sm.Scan(sl.ResetLine(2, "beta"))
+	matchStamps(1, 2)(t, 1, hits)
+	if _, ok := QuorumOf(hits, 0); ok {
+		t.Errorf("Expected no quorum prop on a full set")
+	}
+}
+
+func TestSetQuorumFail(t *testing.T) {
+	for _, m := range []int{-1, 3} {
+		if _, err := NewMatchSetOpts(10, makeTermsA("alpha", "beta"), WithQuorum(m)); err != ErrQuorum {
+			t.Errorf("Quorum %d: expected %v, got %v", m, ErrQuorum, err)
+		}
+	}
+}
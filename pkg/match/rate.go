@@ -0,0 +1,224 @@
+package match
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrRate = errors.New("rate must be positive")
+
+// PropRate is the Props key holding the measured rate of a burst, in events
+// per second, as a float64.
+const PropRate = "rate"
+
+// Rate is measured over buckets of at least one second of nanosecond
+// timestamps.
+const rateBucket = int64(time.Second)
+
+// MatchRate fires when a term matches at a rate of at least rate events per
+// second, sustained for sustain nanoseconds.
+//
+// Time is divided into buckets of the fewest whole seconds that hold a whole
+// number of events at the rate, so that 2.5 events per second is measured as
+// five events in two seconds; a burst is a run of consecutive buckets that each
+// reach the rate.  The matcher fires as soon as the burst
+// spans sustain, emitting a single hit with the first and last entries of the
+// burst and the rate measured across it in Props.  It does not fire again
+// until the burst is broken by a bucket that falls short of the rate.
+//
+// The matcher is edge triggered; a fire requires a matching event.
+
+type MatchRate struct {
+	matcher MatchFunc
+	clock   int64
+	width   int64   // Bucket width, in nanoseconds.
+	need    float64 // Events per bucket at the rate; a whole number.
+	nNeed   int64
+
+	// Current bucket
+	bucket int64
+	bCnt   int
+	bFirst LogEntry
+
+	// Current burst; consecutive buckets that reached the rate, excluding the current bucket.
+	runStart int64
+	runCnt   int
+	runFirst LogEntry
+	inRun    bool
+	fired    bool
+
+	statsT
+	lateT
+}
+
+func NewMatchRate(rate float64, sustain int64, term TermT, opts ...OptT) (*MatchRate, error) {
+	if rate <= 0 {
+		return nil, ErrRate
+	}
+
+	m, err := term.NewMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	o := parseOpts(opts)
+	if err := o.check(optsStats); err != nil {
+		return nil, err
+	}
+
+	width, need := rateWidth(rate)
+
+	return &MatchRate{
+		matcher: m,
+		width:   width,
+		need:    need,
+		nNeed:   max(1, (sustain+width-1)/width),
+		statsT:  newStats(o),
+	}, nil
+}
+
+// Bound on the events per bucket searched for a whole second bucket.
+const maxRateNeed = 1 << 16
+
+// Size the bucket to hold a whole number of events at the rate.  A threshold
+// rounded up from a fraction, such as 3 for 2.5 events per second, is never
+// reached by a stream that alternates 2 and 3 events per second.  A rate with
+// no whole second bucket gets a bucket of ceil(rate) events, to the nanosecond.
+func rateWidth(rate float64) (width int64, need float64) {
+	for k := 1.0; k <= maxRateNeed; k++ {
+		if secs := k / rate; secs >= 1 && math.Abs(secs-math.Round(secs)) < 1e-9 {
+			return int64(math.Round(secs)) * rateBucket, k
+		}
+	}
+	need = math.Ceil(rate)
+	return int64(math.Ceil(need * float64(rateBucket) / rate)), need
+}
+
+func (r *MatchRate) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
+	if e.Timestamp < r.clock {
+		if e = r.late(&r.identT, "MatchRate", e, r.clock); e == nil {
+			return
+		}
+	}
+	r.clock = e.Timestamp
+
+	if !r.matcher(e) {
+		return
+	}
+	r.nMatched += 1
+
+	bucket := floorDiv(e.Timestamp, r.width)
+	if bucket != r.bucket || r.bCnt == 0 {
+		r.roll(bucket)
+		r.bucket = bucket
+		r.bFirst = e.Entry()
+	}
+	r.bCnt += 1
+
+	if r.fired || float64(r.bCnt) < r.need {
+		return
+	}
+
+	var (
+		first = r.bFirst
+		cnt   = r.bCnt
+		nSpan = int64(1)
+	)
+
+	if r.inRun {
+		first = r.runFirst
+		cnt += r.runCnt
+		nSpan += bucket - r.runStart
+	}
+
+	if nSpan < r.nNeed {
+		return
+	}
+
+	r.fired = true
+
+	r.nHits += 1
+	hits.Cnt = 1
+	hits.FireStamp = e.Timestamp
+	hits.Logs = []LogEntry{first, e.Entry()}
+	hits.Props = map[PropKey]any{
+		{Idx: 0, Key: PropRate}: float64(cnt) * float64(rateBucket) / float64(nSpan*r.width),
+	}
+	r.tag(&hits)
+	return
+}
+
+// Close out the current bucket on moving to bucket.  The burst continues only
+// if the current bucket reached the rate and bucket directly follows it.
+func (r *MatchRate) roll(bucket int64) {
+	if r.bCnt == 0 {
+		return
+	}
+
+	if float64(r.bCnt) >= r.need {
+		if !r.inRun {
+			r.inRun = true
+			r.runStart = r.bucket
+			r.runFirst = r.bFirst
+			r.runCnt = 0
+		}
+		r.runCnt += r.bCnt
+	} else {
+		r.breakRun()
+	}
+
+	if bucket != r.bucket+1 {
+		r.breakRun()
+	}
+
+	r.bCnt = 0
+}
+
+func (r *MatchRate) breakRun() {
+	r.inRun = false
+	r.fired = false
+	r.runCnt = 0
+	r.runFirst = LogEntry{}
+}
+
+// Rate is edge triggered; the clock alone cannot cause a fire.  Advances the
+// clock and drops a burst the clock has moved past.
+func (r *MatchRate) Eval(clock int64) (hits Hits) {
+	if clock <= r.clock {
+		return
+	}
+	r.clock = clock
+	if !r.gcDue(clock, r.bCnt) {
+		return
+	}
+	r.GarbageCollect(clock)
+	return
+}
+
+// Drop the burst if the clock has moved past the bucket following the current.
+func (r *MatchRate) GarbageCollect(clock int64) {
+	r.gcClock = clock
+	if r.bCnt == 0 || floorDiv(clock, r.width) <= r.bucket+1 {
+		return
+	}
+	r.breakRun()
+	r.bCnt = 0
+	r.bFirst = LogEntry{}
+}
+
+func (r *MatchRate) edgeTriggered() {}
+
+// Stats returns the matcher counters.
+func (r *MatchRate) Stats() StatsT {
+	return r.stats(nil, &r.lateT)
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q -= 1
+	}
+	return q
+}
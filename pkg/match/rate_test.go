@@ -0,0 +1,277 @@
+package match
+
+import (
+	"testing"
+	"time"
+)
+
+func checkRate(rate float64, stamps ...int64) func(*testing.T, int, Hits) {
+	return func(t *testing.T, step int, hits Hits) {
+		t.Helper()
+		matchStamps(stamps...)(t, step, hits)
+		if v, ok := hits.Props[PropKey{Idx: 0, Key: PropRate}]; !ok || v.(float64) != rate {
+			t.Errorf("Step %v: Expected rate %v, got %v", step, rate, v)
+		}
+	}
+}
+
+func NewCasesRate() casesT {
+	const (
+		s  = int64(time.Second)
+		ms = int64(time.Millisecond)
+	)
+
+	return casesT{
+		"Sustained": {
+			// 2/s for 3s
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: 100 * ms, line: "alpha"},
+				{stamp: 200 * ms, line: "alpha"},
+				{stamp: s + 100*ms, line: "alpha"},
+				{stamp: s + 150*ms, line: "beta"},
+				{stamp: s + 200*ms, line: "alpha"},
+				{stamp: 2*s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 200*ms, line: "alpha", cb: checkRate(2, 100*ms, 2*s+200*ms)},
+				{stamp: 2*s + 300*ms, line: "alpha"}, // No refire within burst
+				{stamp: 3*s + 100*ms, line: "alpha"},
+				{stamp: 3*s + 200*ms, line: "alpha"},
+			},
+		},
+		"Rate": {
+			// Surplus in a bucket counts toward the measured rate.
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: 100 * ms, line: "alpha"},
+				{stamp: 200 * ms, line: "alpha"},
+				{stamp: 300 * ms, line: "alpha"},
+				{stamp: 400 * ms, line: "alpha"},
+				{stamp: s + 100*ms, line: "alpha"},
+				{stamp: s + 200*ms, line: "alpha"},
+				{stamp: 2*s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 200*ms, line: "alpha", cb: checkRate(8.0/3, 100*ms, 2*s+200*ms)},
+			},
+		},
+		"Spike": {
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: 100 * ms, line: "alpha"},
+				{stamp: 200 * ms, line: "alpha"},
+				{stamp: 300 * ms, line: "alpha"},
+				{stamp: 400 * ms, line: "alpha"},
+				{stamp: 500 * ms, line: "alpha"},
+				{stamp: s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 100*ms, line: "alpha"},
+			},
+		},
+		"ShortBucket": {
+			// Bucket 1 falls short; the burst restarts at bucket 2.
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: 100 * ms, line: "alpha"},
+				{stamp: 200 * ms, line: "alpha"},
+				{stamp: s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 200*ms, line: "alpha"},
+				{stamp: 3*s + 100*ms, line: "alpha"},
+				{stamp: 3*s + 200*ms, line: "alpha"},
+				{stamp: 4*s + 100*ms, line: "alpha"},
+				{stamp: 4*s + 200*ms, line: "alpha", cb: checkRate(2, 2*s+100*ms, 4*s+200*ms)},
+			},
+		},
+		"Gap": {
+			// Empty bucket 1 breaks the burst.
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: 100 * ms, line: "alpha"},
+				{stamp: 200 * ms, line: "alpha"},
+				{stamp: 2*s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 200*ms, line: "alpha"},
+				{stamp: 3*s + 100*ms, line: "alpha"},
+				{stamp: 3*s + 200*ms, line: "alpha"},
+			},
+		},
+		"RefireAfterBreak": {
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: 100 * ms, line: "alpha"},
+				{stamp: 200 * ms, line: "alpha"},
+				{stamp: s + 100*ms, line: "alpha"},
+				{stamp: s + 200*ms, line: "alpha"},
+				{stamp: 2*s + 100*ms, line: "alpha"},
+				{stamp: 2*s + 200*ms, line: "alpha", cb: checkRate(2, 100*ms, 2*s+200*ms)},
+				{postF: garbageCollect(4 * s)},
+				{stamp: 4*s + 100*ms, line: "alpha"},
+				{stamp: 4*s + 200*ms, line: "alpha"},
+				{stamp: 5*s + 100*ms, line: "alpha"},
+				{stamp: 5*s + 200*ms, line: "alpha"},
+				{stamp: 6*s + 100*ms, line: "alpha"},
+				{stamp: 6*s + 200*ms, line: "alpha", cb: checkRate(2, 4*s+100*ms, 6*s+200*ms)},
+			},
+		},
+		"OutOfOrder": {
+			terms: []string{"alpha"},
+			steps: []stepT{
+				{stamp: s, line: "alpha"},
+				{stamp: 100 * ms, line: "alpha"},
+				{postF: checkEval(10*s, checkNoFire)},
+			},
+		},
+	}
+}
+
+func TestRate(t *testing.T) {
+	defer disableLogs()()
+
+	NewCasesRate().run(t, func(tc caseT) (Matcher, error) {
+		return NewMatchRate(2, 3*int64(time.Second), makeTerms(tc.terms)[0])
+	})
+}
+
+func TestRateFractional(t *testing.T) {
+	const s = int64(time.Second)
+
+	cases := map[string]struct {
+		stamps []int64
+		fire   int64 // Stamp of the firing line; zero for none.
+	}{
+		"Sustained": {
+			// One every 5s, in each of three 5s buckets.
+			stamps: []int64{s, 6 * s, 11 * s},
+			fire:   11 * s,
+		},
+		"Burst": {
+			// Three within a second are a burst of one bucket, not 15s of it.
+			stamps: []int64{s, 2 * s, 3 * s},
+		},
+		"Slow": {
+			// Bucket [10s, 15s) is empty.
+			stamps: []int64{s, 8 * s, 16 * s, 21 * s},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewMatchRate(0.2, 15*s, makeRaw("alpha"))
+			if err != nil {
+				t.Fatalf("Expected nil error, got: %v", err)
+			}
+
+			var fired int64
+			sl := NewScanLine()
+			for _, stamp := range tc.stamps {
+				hits := m.Scan(sl.ResetLine(stamp, "alpha"))
+				if hits.Cnt == 0 {
+					continue
+				}
+				fired = stamp
+				if v := hits.Props[PropKey{Idx: 0, Key: PropRate}]; v != 0.2 {
+					t.Errorf("Expected rate 0.2, got %v", v)
+				}
+			}
+			if fired != tc.fire {
+				t.Errorf("Expected fire at %v, got %v", tc.fire, fired)
+			}
+		})
+	}
+}
+
+func TestRateFractionalAbove(t *testing.T) {
+	const s = int64(time.Second)
+
+	// Alternate 2 and 3 events per second; 2.5 on average.
+	alternate := func(secs int, lo, hi int) (stamps []int64) {
+		for i := range secs {
+			n := lo
+			if i%2 == 1 {
+				n = hi
+			}
+			for j := range n {
+				stamps = append(stamps, int64(i)*s+int64(j)*s/int64(n))
+			}
+		}
+		return
+	}
+
+	cases := map[string]struct {
+		stamps []int64
+		fire   bool
+	}{
+		"Alternate": {stamps: alternate(8, 2, 3), fire: true},
+		"Short":     {stamps: alternate(8, 2, 2)},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewMatchRate(2.5, 6*s, makeRaw("alpha"))
+			if err != nil {
+				t.Fatalf("Expected nil error, got: %v", err)
+			}
+
+			var fired bool
+			sl := NewScanLine()
+			for _, stamp := range tc.stamps {
+				hits := m.Scan(sl.ResetLine(stamp, "alpha"))
+				if hits.Cnt == 0 {
+					continue
+				}
+				fired = true
+				if v := hits.Props[PropKey{Idx: 0, Key: PropRate}]; v != 2.5 {
+					t.Errorf("Expected rate 2.5, got %v", v)
+				}
+			}
+			if fired != tc.fire {
+				t.Errorf("Expected fire %v, got %v", tc.fire, fired)
+			}
+		})
+	}
+}
+
+func TestRateWidth(t *testing.T) {
+	const s = int64(time.Second)
+
+	cases := map[float64]struct {
+		width int64
+		need  float64
+	}{
+		5:         {s, 5},
+		2.5:       {2 * s, 5},
+		0.2:       {5 * s, 1},
+		0.3:       {10 * s, 3},
+		1.0 / 3.0: {3 * s, 1},
+	}
+
+	for rate, exp := range cases {
+		if width, need := rateWidth(rate); width != exp.width || need != exp.need {
+			t.Errorf("%v: Expected %v/%v, got %v/%v", rate, exp.need, exp.width, need, width)
+		}
+	}
+}
+
+func TestRateInitFail(t *testing.T) {
+
+	cases := map[string]struct {
+		err  error
+		rate float64
+		term TermT
+	}{
+		"EmptyTerm": {
+			err:  ErrTermEmpty,
+			rate: 1,
+			term: TermT{Type: TermRaw, Value: ""},
+		},
+		"ZeroRate": {
+			err:  ErrRate,
+			term: makeRaw("alpha"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewMatchRate(tc.rate, int64(time.Second), tc.term)
+			if err != tc.err {
+				t.Fatalf("Expected err == %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
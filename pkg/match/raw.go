@@ -0,0 +1,101 @@
+package match
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Raw match with options.  Plain raw terms use strings.Contains directly;
+// this path is only taken when options are set.
+
+func makeRawOptMatch(s string, opts TermOptT) MatchFunc {
+	var (
+		noCase = opts&TermOptNoCase != 0
+		word   = opts&TermOptWord != 0
+		index  = strings.Index
+	)
+
+	if noCase {
+		index = indexFold
+	}
+
+	return func(e *ScanLine) bool {
+		line := e.Line
+		for off := 0; off <= len(line); {
+			i := index(line[off:], s)
+			if i < 0 {
+				return false
+			}
+			start := off + i
+			end := start + len(s)
+			if !word || wordBounded(line, start, end) {
+				return true
+			}
+			// Step past the first rune of the match and retry.
+			_, sz := utf8.DecodeRuneInString(line[start:])
+			off = start + sz
+		}
+		return false
+	}
+}
+
+// Case insensitive strings.Index.  The match in s is assumed to have the same
+// byte length as substr, which holds for all but a handful of runes whose case
+// variants differ in encoded length.
+func indexFold(s, substr string) int {
+	n := len(substr)
+	if n == 0 {
+		return 0
+	}
+
+	c := substr[0]
+	isASCII := c < utf8.RuneSelf
+	lo, up := c, c
+	if isASCII {
+		lo, up = toLowerASCII(c), toUpperASCII(c)
+	}
+
+	for i := 0; i+n <= len(s); i++ {
+		if isASCII && s[i] != lo && s[i] != up {
+			continue
+		}
+		if strings.EqualFold(s[i:i+n], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func toLowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func toUpperASCII(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - ('a' - 'A')
+	}
+	return c
+}
+
+// True if the match s[start:end] is not adjoined by a word character.
+func wordBounded(s string, start, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(s[:start]); isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(s) {
+		if r, _ := utf8.DecodeRuneInString(s[end:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
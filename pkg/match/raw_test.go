@@ -0,0 +1,77 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestRawOptions(t *testing.T) {
+
+	tests := map[string]struct {
+		term  string
+		opts  TermOptT
+		line  string
+		match bool
+	}{
+		"NoCase":            {term: "error", opts: TermOptNoCase, line: "FATAL ERROR here", match: true},
+		"NoCaseMixed":       {term: "OOMKilled", opts: TermOptNoCase, line: "reason: oomkilled", match: true},
+		"NoCaseMiss":        {term: "error", opts: TermOptNoCase, line: "FATAL ERR", match: false},
+		"NoCaseUnicode":     {term: "ÉCHEC", opts: TermOptNoCase, line: "statut: échec", match: true},
+		"Word":              {term: "fail", opts: TermOptWord, line: "job fail: exit 1", match: true},
+		"WordEdges":         {term: "fail", opts: TermOptWord, line: "fail", match: true},
+		"WordPrefix":        {term: "fail", opts: TermOptWord, line: "job failed", match: false},
+		"WordSuffix":        {term: "fail", opts: TermOptWord, line: "nofail", match: false},
+		"WordUnderscore":    {term: "fail", opts: TermOptWord, line: "fail_fast", match: false},
+		"WordSecondHit":     {term: "fail", opts: TermOptWord, line: "failed then fail.", match: true},
+		"WordPunctTerm":     {term: "[warn]", opts: TermOptWord, line: "x[warn]y", match: false},
+		"WordNoCase":        {term: "Fail", opts: TermOptWord | TermOptNoCase, line: "FAILED; FAIL", match: true},
+		"WordNoCaseMiss":    {term: "Fail", opts: TermOptWord | TermOptNoCase, line: "FAILED", match: false},
+		"WordUnicodeLetter": {term: "fail", opts: TermOptWord, line: "éfail", match: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := TermT{Type: TermRaw, Value: tc.term, Options: tc.opts}.NewMatcher()
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+			if got := m(NewScanLine().ResetLine(1, tc.line)); got != tc.match {
+				t.Errorf("Expected %v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestRawOptionsBadType(t *testing.T) {
+	_, err := TermT{Type: TermRegex, Value: "a", Options: TermOptNoCase}.NewMatcher()
+	if err != ErrTermOptions {
+		t.Errorf("Expected %v, got %v", ErrTermOptions, err)
+	}
+}
+
+func BenchmarkRawNoCase(b *testing.B) {
+	m, err := TermT{Type: TermRaw, Value: "shrubbery", Options: TermOptNoCase}.NewMatcher()
+	if err != nil {
+		b.Fatalf("Expected nil error, got %v", err)
+	}
+
+	sl := NewScanLine().ResetLine(1, "2025-01-01 level=info the knights who say ni demand a SHRUBBERY")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		m(sl)
+	}
+}
+
+func BenchmarkRegexNoCase(b *testing.B) {
+	m, err := TermT{Type: TermRegex, Value: "(?i)shrubbery"}.NewMatcher()
+	if err != nil {
+		b.Fatalf("Expected nil error, got %v", err)
+	}
+
+	sl := NewScanLine().ResetLine(1, "2025-01-01 level=info the knights who say ni demand a SHRUBBERY")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		m(sl)
+	}
+}
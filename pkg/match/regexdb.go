@@ -0,0 +1,114 @@
+package match
+
+import (
+	"regexp"
+)
+
+// RegexDbI matches a line against a set of regular expressions at once, and
+// reports the index of every pattern that matches.  It lets MultiMatcher
+// dispatch regex terms that have no required literal, rather than send them
+// every line.
+//
+// A backend that compiles all patterns into a single automaton, such as
+// Hyperscan, may be supplied through WithRegexDb; it must accept the Go
+// regexp syntax of the terms or fail the build.
+type RegexDbI interface {
+	Match(line string, emit func(idx int))
+}
+
+// RegexDbFactoryT compiles pats into a RegexDbI.
+type RegexDbFactoryT func(pats []string) (RegexDbI, error)
+
+// WithRegexDb sets the backend MultiMatcher uses to prefilter regex terms
+// that have no required literal.  NewRegexDb is a pure Go backend.
+func WithRegexDb(factory RegexDbFactoryT) OptT {
+	return func(o *optsT) {
+		o.set |= optRegexDb
+		o.regexDb = factory
+	}
+}
+
+type regexDbT struct {
+	exps   []*regexp.Regexp
+	ac     *acT    // Literals required by the patterns that have them.
+	always []int32 // Patterns without required literals; run on every line.
+
+	// Per line candidate marks; gen avoids clearing between lines.
+	marks []uint32
+	gen   uint32
+	cands []int32
+}
+
+// NewRegexDb is the default backend.  Each pattern is reduced to a set of
+// literals of which one must appear in a matching line, and the literals of all
+// patterns are compiled into a single Aho-Corasick automaton.  A line is scanned
+// once by the automaton, and only the patterns whose literals it contains, or
+// that have none, are run.  The backend is not safe for concurrent use.
+func NewRegexDb(pats []string) (RegexDbI, error) {
+	var (
+		db   = &regexDbT{exps: make([]*regexp.Regexp, 0, len(pats)), marks: make([]uint32, len(pats))}
+		lits []string
+		ids  []int32
+	)
+
+	for i, pat := range pats {
+		exp, err := regexp.Compile(pat)
+		if err != nil {
+			return nil, err
+		}
+		db.exps = append(db.exps, exp)
+
+		set := requiredLiterals(pat)
+		if set == nil {
+			db.always = append(db.always, int32(i))
+			continue
+		}
+		for _, lit := range set {
+			lits = append(lits, lit)
+			ids = append(ids, int32(i))
+		}
+	}
+
+	if len(lits) > 0 {
+		db.ac = newAC(lits, ids)
+	}
+
+	return db, nil
+}
+
+func (db *regexDbT) Match(line string, emit func(idx int)) {
+	if db.ac == nil {
+		db.matchEach(line, emit)
+		return
+	}
+
+	db.gen += 1
+	if db.gen == 0 {
+		// Wrapped; clear stale marks.
+		clear(db.marks)
+		db.gen = 1
+	}
+
+	db.cands = append(db.cands[:0], db.always...)
+	db.ac.scan(line, func(id int32) {
+		if db.marks[id] != db.gen {
+			db.marks[id] = db.gen
+			db.cands = append(db.cands, id)
+		}
+	})
+
+	for _, id := range db.cands {
+		if db.exps[id].MatchString(line) {
+			emit(int(id))
+		}
+	}
+}
+
+// Run every pattern in turn.
+func (db *regexDbT) matchEach(line string, emit func(idx int)) {
+	for i, exp := range db.exps {
+		if exp.MatchString(line) {
+			emit(i)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRegexDbCandidates(t *testing.T) {
+
+	mm, _ := NewMultiMatcher(WithRegexDb(NewRegexDb))
+	for _, rule := range makeMultiRules() {
+		mm.Add(rule.build(t), rule.all()...)
+	}
+
+	cases := map[string][]int{
+		"nothing here":  {4},
+		"alpha":         {0, 4},
+		"code 123":      {3, 4},
+		"zeta-9 delta":  {1, 4},
+		"alphabeta 456": {0, 2, 3, 4},
+	}
+
+	for line, exp := range cases {
+		got := mm.Candidates(line)
+		slices.Sort(got)
+		if !slices.Equal(got, exp) {
+			t.Errorf("%q: Expected %v, got %v", line, exp, got)
+		}
+	}
+}
+
+func TestRegexDbScan(t *testing.T) {
+
+	var (
+		mm, _ = NewMultiMatcher(WithRegexDb(NewRegexDb))
+		got   []int
+		m, _  = NewMatchSingle(TermT{Type: TermRegex, Value: `\d{3}`})
+	)
+
+	idx := mm.Add(m, TermT{Type: TermRegex, Value: `\d{3}`})
+
+	for i, line := range []string{"no digits", "code 404", "12"} {
+		mm.Scan(NewScanLine().ResetLine(int64(i+1), line), func(i int, hits Hits) {
+			got = append(got, i)
+		})
+	}
+
+	if !slices.Equal(got, []int{idx}) {
+		t.Errorf("Expected %v, got %v", []int{idx}, got)
+	}
+
+	if s := m.Stats(); s.Scanned != 1 {
+		t.Errorf("Expected 1 line dispatched, got %d", s.Scanned)
+	}
+}
+
+func TestRegexDbFallback(t *testing.T) {
+
+	var (
+		nCalls  int
+		factory = func(pats []string) (RegexDbI, error) {
+			nCalls += 1
+			return nil, errors.New("unsupported")
+		}
+		mm, _ = NewMultiMatcher(WithRegexDb(factory))
+	)
+
+	for _, rule := range makeMultiRules() {
+		mm.Add(rule.build(t), rule.all()...)
+	}
+
+	// Falls back to dispatching every line, and stays so across a rebuild.
+	for i, exp := range [][]int{{3, 4}, {3, 4, 5}} {
+		got := mm.Candidates("nothing here")
+		slices.Sort(got)
+		if !slices.Equal(got, exp) {
+			t.Errorf("Pass %d: Expected %v, got %v", i, exp, got)
+		}
+		m, _ := NewMatchSingle(makeRaw("nothing"))
+		mm.Add(m, makeRaw("nothing"))
+	}
+
+	if nCalls != 1 {
+		t.Errorf("Expected 1 factory call, got %d", nCalls)
+	}
+}
+
+func TestRegexDbBadPattern(t *testing.T) {
+	if _, err := NewRegexDb([]string{`ok`, `(`}); err == nil {
+		t.Errorf("Expected err != nil")
+	}
+}
+
+func TestRegexDbFlags(t *testing.T) {
+	db, err := NewRegexDb([]string{`(?i)alpha`, `beta$`, `^\d+$`})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	cases := map[string][]int{
+		"ALPHA":      {0},
+		"Alpha beta": {0, 1},
+		"BETA":       nil,
+		"beta gamma": nil,
+		"12345":      {2},
+		"12a45":      nil,
+	}
+
+	for line, exp := range cases {
+		var got []int
+		db.Match(line, func(idx int) { got = append(got, idx) })
+		slices.Sort(got)
+		if !slices.Equal(got, exp) {
+			t.Errorf("%q: Expected %v, got %v", line, exp, got)
+		}
+	}
+}
+
+func makeRegexDbBench(b *testing.B) *regexDbT {
+	pats := make([]string, 0, 100)
+	for i := range 50 {
+		pats = append(pats, fmt.Sprintf(`[a-z]+-%d\s+(fail|err)\w*`, i), fmt.Sprintf(`(?:timeout|refused)\s+%d`, i))
+	}
+	db, err := NewRegexDb(pats)
+	if err != nil {
+		b.Fatalf("Expected nil error, got: %v", err)
+	}
+	return db.(*regexDbT)
+}
+
+const regexDbBenchLine = "2025-01-01T00:00:00Z INFO worker-77 completed request in 12ms"
+
+func BenchmarkRegexDbMiss(b *testing.B) {
+	db := makeRegexDbBench(b)
+	b.ResetTimer()
+	for range b.N {
+		db.Match(regexDbBenchLine, func(int) {})
+	}
+}
+
+func BenchmarkRegexDbMissEach(b *testing.B) {
+	db := makeRegexDbBench(b)
+	b.ResetTimer()
+	for range b.N {
+		db.matchEach(regexDbBenchLine, func(int) {})
+	}
+}
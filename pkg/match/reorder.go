@@ -0,0 +1,121 @@
+package match
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Reorderer buffers entries for up to maxSkew and releases them to the wrapped
+// matcher in timestamp order, so that modest clock skew between sources does
+// not cause events to be dropped as out of order.  Entries with equal
+// timestamps are released in arrival order.
+//
+// An entry is released once an entry at least maxSkew newer has been scanned,
+// or once Eval is called with a clock at least maxSkew past it.  The wrapped
+// matcher therefore runs maxSkew behind; Eval and GarbageCollect are forwarded
+// with the clock delayed accordingly.  An entry older than the most recently
+// released entry can no longer be ordered and is dropped; see Stats.
+
+type Reorderer struct {
+	m       Matcher
+	maxSkew int64
+	newest  int64
+	mark    int64 // Timestamp of the most recently released entry.
+	seq     uint64
+	buf     reorderHeapT
+	sl      *ScanLine
+	stats   ReorderStatsT
+}
+
+// ReorderStatsT counts entries through a Reorderer.
+type ReorderStatsT struct {
+	Buffered int    // Entries currently held.
+	Released uint64 // Entries passed to the wrapped matcher.
+	Dropped  uint64 // Entries older than the release mark.
+}
+
+func NewReorderer(maxSkew int64, m Matcher) *Reorderer {
+	return &Reorderer{
+		m:       m,
+		maxSkew: maxSkew,
+		newest:  math.MinInt64,
+		mark:    math.MinInt64,
+		sl:      NewScanLine(),
+	}
+}
+
+func (r *Reorderer) Scan(e *ScanLine) (hits Hits) {
+	if e.Timestamp < r.mark {
+		r.stats.Dropped += 1
+		return
+	}
+
+	heap.Push(&r.buf, reorderT{entry: e.Entry(), seq: r.seq})
+	r.seq += 1
+	r.newest = max(r.newest, e.Timestamp)
+
+	return r.release(r.newest - r.maxSkew)
+}
+
+func (r *Reorderer) Eval(clock int64) Hits {
+	deadline := clock - r.maxSkew
+	hits := r.release(deadline)
+	hits.Append(r.m.Eval(deadline))
+	return hits
+}
+
+func (r *Reorderer) GarbageCollect(clock int64) {
+	r.m.GarbageCollect(clock - r.maxSkew)
+}
+
+// Flush releases all buffered entries regardless of skew.
+func (r *Reorderer) Flush() Hits {
+	return r.release(math.MaxInt64)
+}
+
+// Stats returns the entry counters.
+func (r *Reorderer) Stats() ReorderStatsT {
+	s := r.stats
+	s.Buffered = r.buf.Len()
+	return s
+}
+
+// Release buffered entries at or before deadline, oldest first.
+func (r *Reorderer) release(deadline int64) (hits Hits) {
+	for r.buf.Len() > 0 && r.buf[0].entry.Timestamp <= deadline {
+		item := heap.Pop(&r.buf).(reorderT)
+		r.mark = item.entry.Timestamp
+		r.stats.Released += 1
+		hits.Append(r.m.Scan(r.sl.Reset(item.entry)))
+	}
+	return
+}
+
+type reorderT struct {
+	entry LogEntry
+	seq   uint64
+}
+
+type reorderHeapT []reorderT
+
+func (h reorderHeapT) Len() int { return len(h) }
+
+func (h reorderHeapT) Less(i, j int) bool {
+	if h[i].entry.Timestamp != h[j].entry.Timestamp {
+		return h[i].entry.Timestamp < h[j].entry.Timestamp
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h reorderHeapT) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *reorderHeapT) Push(x any) { *h = append(*h, x.(reorderT)) }
+
+func (h *reorderHeapT) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = reorderT{}
+	*h = old[:n-1]
+	return x
+}
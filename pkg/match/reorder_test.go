@@ -0,0 +1,87 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestReorderer(t *testing.T) {
+	defer disableLogs()()
+
+	seq, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		r  = NewReorderer(3, seq)
+		sl = NewScanLine()
+	)
+
+	// beta arrives ahead of alpha; held for the skew.
+	if hits := r.Scan(sl.ResetLine(5, "beta")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+	if hits := r.Scan(sl.ResetLine(4, "alpha")); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+	if s := r.Stats(); s.Buffered != 2 || s.Released != 0 {
+		t.Fatalf("Expected 2 buffered, got %+v", s)
+	}
+
+	// Releases alpha at 4 and beta at 5, in order.
+	hits := r.Scan(sl.ResetLine(8, "noop"))
+	matchStamps(4, 5)(t, 3, hits)
+
+	// Older than the release mark; dropped.
+	r.Scan(sl.ResetLine(4, "alpha"))
+
+	if s := r.Stats(); s.Buffered != 1 || s.Released != 2 || s.Dropped != 1 {
+		t.Errorf("Expected 1 buffered, 2 released, 1 dropped, got %+v", s)
+	}
+}
+
+func TestReordererEqualStamps(t *testing.T) {
+	seq, err := NewMatchSeqOpts(10, makeTermsA("alpha", "beta"), WithStrictOrder(true))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		r  = NewReorderer(2, seq)
+		sl = NewScanLine()
+	)
+
+	// Equal stamps release in arrival order; strict order rejects beta at 1.
+	r.Scan(sl.ResetLine(1, "beta"))
+	r.Scan(sl.ResetLine(1, "alpha"))
+	r.Scan(sl.ResetLine(2, "beta"))
+
+	hits := r.Flush()
+	matchStamps(1, 2)(t, 1, hits)
+}
+
+func TestReordererEval(t *testing.T) {
+
+	absence, err := NewMatchAbsence(5, makeRaw("alpha"), WithAbsence(AbsencePrevious))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	var (
+		r  = NewReorderer(3, absence)
+		sl = NewScanLine()
+	)
+
+	r.Scan(sl.ResetLine(1, "alpha"))
+
+	// Eval releases alpha; the wrapped clock runs 3 behind.
+	if hits := r.Eval(9); hits.Cnt != 0 {
+		t.Fatalf("Expected no hits, got %v", hits.Cnt)
+	}
+	if s := r.Stats(); s.Released != 1 {
+		t.Fatalf("Expected 1 released, got %+v", s)
+	}
+
+	hits := r.Eval(10)
+	checkFireStamp(7, matchStamps(1))(t, 2, hits)
+}
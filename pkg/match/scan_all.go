@@ -0,0 +1,117 @@
+package match
+
+import (
+	"iter"
+	"math"
+)
+
+// ScanAll returns an iterator that lazily scans entries through m, yielding
+// each non-empty Hits as it is produced.
+//
+// The entries are treated as a complete stream.  Once the last entry has been
+// scanned, m is flushed with an Eval at the end of time so that matches waiting
+// on reset windows are resolved; any hits produced by the flush are yielded last.
+// As a consequence, m should not be used for further scanning after a full iteration.
+// Breaking out of the loop early stops the scan and skips the flush.
+//
+// Every matcher of this package has a ScanAll method as well, so that callers
+// may write:
+//
+//	for hits := range m.ScanAll(entries) {
+//		...
+//	}
+
+func ScanAll(m Matcher, entries []LogEntry) iter.Seq[Hits] {
+	return func(yield func(Hits) bool) {
+		sl := NewScanLine()
+
+		for _, e := range entries {
+			if hits := m.Scan(sl.Reset(e)); hits.Cnt > 0 {
+				if !yield(hits) {
+					return
+				}
+			}
+		}
+
+		if hits := m.Eval(math.MaxInt64); hits.Cnt > 0 {
+			yield(hits)
+		}
+	}
+}
+
+// ScanAll scans entries through the matcher; see ScanAll.
+func (r *MatchSingle) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchSeq) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchSet) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *InverseSeq) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *InverseSet) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchCount) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchRate) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchAbsence) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchFallingEdge) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *KeyedMatcher) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchChain) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchNamed) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchCooldown) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchLimit) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchPause) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *MatchSuppress) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (r *Reorderer) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(r, entries)
+}
+
+func (d *DualClock) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(d, entries)
+}
+
+func (t *Ticker) ScanAll(entries []LogEntry) iter.Seq[Hits] {
+	return ScanAll(t, entries)
+}
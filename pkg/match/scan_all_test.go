@@ -0,0 +1,177 @@
+package match
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+)
+
+func makeEntries(lines ...string) []LogEntry {
+	entries := make([]LogEntry, 0, len(lines))
+	for i, line := range lines {
+		entries = append(entries, LogEntry{Line: line, Timestamp: int64(i + 1)})
+	}
+	return entries
+}
+
+func TestScanAll(t *testing.T) {
+	entries := makeEntries("alpha", "beta", "noop", "alpha", "alpha", "beta", "beta")
+
+	manual, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var expected []Hits
+	sl := NewScanLine()
+	for _, e := range entries {
+		if hits := manual.Scan(sl.Reset(e)); hits.Cnt > 0 {
+			expected = append(expected, hits)
+		}
+	}
+
+	sm, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var got []Hits
+	for hits := range sm.ScanAll(entries) {
+		got = append(got, hits)
+	}
+
+	if len(got) != len(expected) || len(got) != 3 {
+		t.Fatalf("Expected %v hits, got %v", len(expected), len(got))
+	}
+
+	for i := range got {
+		if !reflect.DeepEqual(got[i].Logs, expected[i].Logs) {
+			t.Errorf("Hit %v: expected %v, got %v", i, expected[i].Logs, got[i].Logs)
+		}
+	}
+}
+
+func TestScanAllBreak(t *testing.T) {
+	entries := makeEntries("alpha", "beta", "alpha", "beta", "alpha", "beta")
+
+	sm, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var cnt int
+	for hits := range sm.ScanAll(entries) {
+		cnt += 1
+		if hits.Logs[0].Timestamp != 1 {
+			t.Errorf("Expected first hit at stamp 1, got %v", hits.Logs[0].Timestamp)
+		}
+		break
+	}
+
+	if cnt != 1 {
+		t.Fatalf("Expected 1 iteration, got %v", cnt)
+	}
+
+	// Iteration stopped after the second entry; the rest were never scanned.
+	if sm.clock != 2 {
+		t.Errorf("Expected clock == 2, got %v", sm.clock)
+	}
+}
+
+func TestScanAllEvalFlush(t *testing.T) {
+	// The hit is delayed by an absolute reset window that extends past the last entry.
+	resets := []ResetT{{Term: makeRaw("reset"), Window: 100, Absolute: true}}
+
+	sm, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), resets)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var got []Hits
+	for hits := range ScanAll(sm, makeEntries("alpha", "beta", "noop")) {
+		got = append(got, hits)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 flushed hit, got %v", len(got))
+	}
+	matchStamps(1, 2)(t, 1, got[0])
+}
+
+func TestHitsAll(t *testing.T) {
+	h := Hits{Cnt: 3, Logs: makeTestLogs(6)}
+
+	var cnt int
+	for logs := range h.All() {
+		if len(logs) != 2 {
+			t.Errorf("Expected 2 logs, got %d", len(logs))
+		}
+		cnt += 1
+	}
+	if cnt != 3 {
+		t.Errorf("Expected 3 groups, got %d", cnt)
+	}
+
+	for range h.All() {
+		cnt += 1
+		break
+	}
+	if cnt != 4 {
+		t.Errorf("Expected break after 1 group, got %d", cnt-3)
+	}
+}
+
+func TestScanAllIngestTime(t *testing.T) {
+
+	factory, err := format.NewRegexFactory(
+		`event=(\S+)`,
+		format.WithTimeFormat(time.RFC3339),
+		format.WithIngestTime(`ingest=(\S+)`, format.WithTimeFormat(time.RFC3339)),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	// Events are 2s apart, but ingestion lagged by a minute.
+	var (
+		parser  = factory.New()
+		entries []LogEntry
+		lines   = []string{
+			`event=2024-01-01T00:00:01Z ingest=2024-01-01T00:00:01Z alpha`,
+			`event=2024-01-01T00:00:03Z ingest=2024-01-01T00:01:03Z beta`,
+		}
+	)
+
+	for _, line := range lines {
+		e, err := parser.ReadEntry([]byte(line))
+		if err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	sm, err := NewMatchSeq(int64(5*time.Second), makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var hits Hits
+	for h := range ScanAll(sm, entries) {
+		hits.Append(h)
+	}
+
+	if hits.Cnt != 1 {
+		t.Fatalf("Expected 1 hit windowed on event time, got %d", hits.Cnt)
+	}
+
+	var (
+		last = hits.Logs[1]
+		lag  = time.Duration(last.IngestTime - last.Timestamp)
+	)
+
+	if lag != time.Minute {
+		t.Errorf("Expected ingest lag of 1m, got %v", lag)
+	}
+}
@@ -2,6 +2,9 @@ package match
 
 import (
 	"encoding/json"
+	"maps"
+	"strings"
+	"unsafe"
 
 	"github.com/goccy/go-yaml"
 )
@@ -17,19 +20,36 @@ const (
 // ScanLine is a wrapper around LogEntry that provides caching for decoded JSON and YAML data.
 // It is a replacement for the memoized struct that was previously used in the Matcher implementation,
 // and is designed to be used across multiple matchers without needing to duplicate the memoization logic in each matcher.
-// The cache is simple: it stores the type of the last decode (JSON or YAML), the decoded value, and any error that occurred during decoding.
-// Matchers that switch between JSON and YAML decoding on the same line will cause cache invalidation.
-// In general, matchers should stick to one decode type per line for best performance.
+// The cache is the shared parse context of a line: pass the same ScanLine to every matcher, as MultiMatcher
+// and KeyedMatcher do, and the jq, numeric, logfmt and CIDR terms of all of them decode the line once.
+// The cache stores the most recent decode (JSON or YAML), the decoded value, and any error that occurred during decoding,
+// and keeps the previous decode of the other type aside, so terms that mix JSON and YAML on the same line each decode once.
 
 type ScanLine struct {
 	LogEntry
-	cache *cacheT // Allocate lazily only if needed; TODO: Consider making this a weak ptr.
+	gen      uint64  // Incremented on every Reset; lets stateful terms detect repeat evaluation of a line.
+	cache    *cacheT // Allocate lazily only if needed; TODO: Consider making this a weak ptr.
+	borrowed bool    // Line views a caller buffer; see ResetBytes.
+
+	// Props extracted by the last term evaluated on the line; see Entry.
+	extract map[string]any
 }
 
 type cacheT struct {
 	ty  decodeT
 	ptr any
 	err error
+
+	// Previous decode of the other type; swapped back in on demand.
+	alt struct {
+		ty  decodeT
+		ptr any
+		err error
+	}
+
+	// Logfmt is cached independently; it does not compete with JSON or YAML.
+	logfmt   map[string]string
+	isLogfmt bool
 }
 
 func NewScanLine() *ScanLine {
@@ -42,49 +62,155 @@ func (s *ScanLine) _maybeClear(line string) {
 	switch {
 	case s.cache == nil:
 		// Fall through;  nothing to clear
-	case s.LogEntry.Line != line:
-		// Clear the cache if the line has changed
-		s.cache.ty = decodeNone
-		s.cache.ptr = nil
-		s.cache.err = nil
+	case s.borrowed, s.LogEntry.Line != line:
+		// Clear the cache if the line has changed.  A borrowed line cannot
+		// be compared; its buffer may have been overwritten in place.
+		s._clear()
 	}
 }
 
+func (s *ScanLine) _clear() {
+	s.cache.ty = decodeNone
+	s.cache.ptr = nil
+	s.cache.err = nil
+	s.cache.alt.ty = decodeNone
+	s.cache.alt.ptr = nil
+	s.cache.alt.err = nil
+	s.cache.logfmt = nil
+	s.cache.isLogfmt = false
+}
+
 func (s *ScanLine) Reset(e LogEntry) *ScanLine {
 	s._maybeClear(e.Line)
 	s.LogEntry = e
+	s.borrowed = false
+	s.extract = nil
+	s.gen += 1
+	return s
+}
+
+// ResetBytes resets the line to view buf without copying, so that lines read
+// from a buffer may be scanned without an allocation each.  The line is
+// borrowed; buf must not be modified until the next reset.  Matchers copy the
+// line only for entries they retain, via Entry.
+func (s *ScanLine) ResetBytes(ts int64, buf []byte) *ScanLine {
+	s.borrowed = true
+	s._maybeClear("")
+	s.LogEntry = LogEntry{Line: unsafe.String(unsafe.SliceData(buf), len(buf)), Timestamp: ts}
+	s.extract = nil
+	s.gen += 1
 	return s
 }
 
+// Entry returns the entry for retention beyond the scan.  A borrowed line is
+// copied once, on first call, and shared by subsequent calls.  The cache is
+// dropped along with the borrow, as it may view the buffer.
+//
+// Props extracted by the last term evaluated are merged into the returned
+// entry only; the line is shared, so they do not carry over to the entries
+// retained for other terms or matchers.
+func (s *ScanLine) Entry() LogEntry {
+	if s.borrowed {
+		s.Line = strings.Clone(s.Line)
+		s.borrowed = false
+		if s.cache != nil {
+			s._clear()
+		}
+	}
+	if len(s.extract) == 0 {
+		return s.LogEntry
+	}
+
+	e := s.LogEntry
+	e.Props = make(map[string]any, len(s.Props)+len(s.extract))
+	maps.Copy(e.Props, s.Props)
+	maps.Copy(e.Props, s.extract)
+	return e
+}
+
+// Copy v if it may view a borrowed line.
+func (s *ScanLine) own(v string) string {
+	if s.borrowed {
+		return strings.Clone(v)
+	}
+	return v
+}
+
 func (s *ScanLine) ResetLine(ts int64, line string) *ScanLine {
 	return s.Reset(LogEntry{Line: line, Timestamp: ts})
 }
 
+// Merge props into those extracted by the current term.
+func (s *ScanLine) extractProps(props map[string]any) {
+	if len(props) == 0 {
+		return
+	}
+
+	m := make(map[string]any, len(s.extract)+len(props))
+	maps.Copy(m, s.extract)
+	maps.Copy(m, props)
+	s.extract = m
+}
+
+// DecodeLogfmt returns the key=value pairs of the line.  Parsing is lenient;
+// malformed input yields whatever pairs could be recovered.
+func (s *ScanLine) DecodeLogfmt() map[string]string {
+	if s.cache == nil {
+		s.cache = &cacheT{}
+	}
+	if !s.cache.isLogfmt {
+		s.cache.logfmt = parseLogfmt(s.Line)
+		s.cache.isLogfmt = true
+	}
+	return s.cache.logfmt
+}
+
 func (s *ScanLine) DecodeJson() (any, error) {
-	if s.cache != nil && s.cache.ty == decodeJson {
+	if s._cached(decodeJson) {
 		return s.cache.ptr, s.cache.err
 	}
-	return s._decode(decodeJson, json.Unmarshal)
+	// The decoder does not retain its input; view the line rather than copy it.
+	return s._decode(decodeJson, unsafe.Slice(unsafe.StringData(s.Line), len(s.Line)), json.Unmarshal)
 }
 
 func (s *ScanLine) DecodeYaml() (any, error) {
-	if s.cache != nil && s.cache.ty == decodeYaml {
+	if s._cached(decodeYaml) {
 		return s.cache.ptr, s.cache.err
 	}
-	return s._decode(decodeYaml, yaml.Unmarshal)
+	return s._decode(decodeYaml, []byte(s.Line), yaml.Unmarshal)
+}
+
+// True if the cache holds a decode of type ty, swapping it in from the alternate if need be.
+func (s *ScanLine) _cached(ty decodeT) bool {
+	switch {
+	case s.cache == nil:
+		return false
+	case s.cache.ty == ty:
+		return true
+	case s.cache.alt.ty == ty:
+		c := s.cache
+		c.ty, c.alt.ty = c.alt.ty, c.ty
+		c.ptr, c.alt.ptr = c.alt.ptr, c.ptr
+		c.err, c.alt.err = c.alt.err, c.err
+		return true
+	}
+	return false
 }
 
-func (s *ScanLine) _decode(ty decodeT, unmarshal func([]byte, interface{}) error) (any, error) {
+func (s *ScanLine) _decode(ty decodeT, data []byte, unmarshal func([]byte, interface{}) error) (any, error) {
 
 	if s.cache == nil {
 		s.cache = &cacheT{ty: ty}
 	} else {
-		s.cache.ty = ty
-		s.cache.err = nil
+		// Set the current decode aside.
+		c := s.cache
+		c.alt.ty, c.alt.ptr, c.alt.err = c.ty, c.ptr, c.err
+		c.ty = ty
+		c.err = nil
 	}
 
 	var dany any
-	if err := unmarshal([]byte(s.Line), &dany); err != nil {
+	if err := unmarshal(data, &dany); err != nil {
 		s.cache.ptr = nil
 		s.cache.err = err
 		return nil, err
@@ -223,3 +223,63 @@ func TestNoDecodeMeansNoCache(t *testing.T) {
 		t.Errorf("expected cache to be nil if no decode attempted")
 	}
 }
+
+func TestDecodeAlternate(t *testing.T) {
+	sl := NewScanLine().ResetLine(0, `{"foo": "bar"}`)
+
+	j1, err := sl.DecodeJson()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := sl.DecodeYaml(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The JSON decode is kept aside; no second decode.
+	j2, _ := sl.DecodeJson()
+	if reflect.ValueOf(j1).UnsafePointer() != reflect.ValueOf(j2).UnsafePointer() {
+		t.Errorf("expected cached JSON document to be reused")
+	}
+	if sl.cache.ty != decodeJson || sl.cache.alt.ty != decodeYaml {
+		t.Errorf("expected JSON current and YAML alternate, got %v and %v", sl.cache.ty, sl.cache.alt.ty)
+	}
+
+	// Reset clears both.
+	sl.ResetLine(1, `{"foo": "baz"}`)
+	if sl.cache.ty != decodeNone || sl.cache.alt.ty != decodeNone {
+		t.Errorf("expected cache cleared after reset")
+	}
+}
+
+func TestDecodeSharedMulti(t *testing.T) {
+	var (
+		mm, _ = NewMultiMatcher()
+		sl    = NewScanLine().ResetLine(1, `{"level": "error", "code": 503}`)
+		terms = []TermT{
+			{Type: TermJqJson, Value: `.level == "error"`},
+			{Type: TermJqYaml, Value: `.level != "info"`},
+			{Type: TermNumeric, Value: `code >= 500`},
+		}
+		fired int
+	)
+
+	for _, term := range terms {
+		m, err := NewMatchSingle(term)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		mm.Add(m, term)
+	}
+
+	doc, _ := sl.DecodeJson()
+	mm.Scan(sl, func(int, Hits) { fired += 1 })
+
+	if fired != len(terms) {
+		t.Errorf("expected %d fired, got %d", len(terms), fired)
+	}
+
+	// Every matcher shared the decode made before the scan.
+	if cur, _ := sl.DecodeJson(); reflect.ValueOf(cur).UnsafePointer() != reflect.ValueOf(doc).UnsafePointer() {
+		t.Errorf("expected the JSON document to be decoded once")
+	}
+}
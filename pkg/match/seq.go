@@ -16,6 +16,27 @@ import (
 // that if two matches in a sequence have the same timestamp, it will be considered a match.
 // This is done to account for imprecise clocks; a clock with low resolution might emit
 // two events with the same timestamp when in real time they are sequential.
+//
+// WithMaxGap bounds the time between consecutive terms in addition to the
+// window.  When a step exceeds its gap, the earlier entry is stale; it is
+// dropped and the sequence is rebuilt from any later matches of that term.
+// WithMinGap is the complement; an entry that arrives too soon after the
+// previous term does not consume its slot.  WithStrictOrder disables the
+// equal timestamp allowance above; it is a minimum gap of one on every step.
+//
+// WithOptional marks terms that may be skipped.  The state machine runs on
+// the required terms alone; when it fires, the first match of each optional
+// term found between its required neighbours is included in the hit.  Hits
+// therefore vary in size.  Gap options index the required terms only.
+//
+// WithMaxLines additionally bounds a hit to span at most that many lines
+// scanned, on top of the time window.
+//
+// WithLineWindow measures the window, and any gaps, in lines scanned rather
+// than time, for logs with coarse or broken timestamps.  The matcher then
+// keeps its own clock of lines; entries are never late, and Eval and
+// GarbageCollect, which are given a time, do nothing.  Hits carry the real
+// timestamps; Coverage and traces are in lines.
 
 type MatchSeq struct {
 	clock   int64
@@ -23,45 +44,197 @@ type MatchSeq struct {
 	nActive int
 	terms   []termT
 	dupeMap map[int]int
+	maxGap  []int64
+	minGap  []int64
+
+	optional []termT
+	optAfter []int
+	strict   bool
+	picks    []pickT
+	frame    []LogEntry
+	lines    *lineRingT // WithLineWindow
+	limit    *lineRingT // WithMaxLines
+
+	statsT
+	lateT
+	explainT
+	budgetT
 }
 
 func NewMatchSeq(window int64, seqTerms ...TermT) (*MatchSeq, error) {
+	return NewMatchSeqOpts(window, seqTerms)
+}
+
+// NewMatchSeqOpts is NewMatchSeq with options.
+func NewMatchSeqOpts(window int64, seqTerms []TermT, opts ...OptT) (*MatchSeq, error) {
+
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optExplain | optMaxBuffered | optMaxGap | optMinGap | optOptional | optStrictOrder | optLineWindow | optMaxLines); err != nil {
+		return nil, err
+	}
+
+	seqTerms, optional, optAfter, err := splitOptional(seqTerms, o.optional)
+	if err != nil {
+		return nil, err
+	}
 
 	terms, dupeMap, err := buildSeqTerms(seqTerms...)
 	if err != nil {
 		return nil, err
 	}
 
+	nAnchors := countAnchors(seqTerms)
+
+	if err := validateGaps(o.maxGap, nAnchors); err != nil {
+		return nil, err
+	}
+	if err := validateGaps(o.minGap, nAnchors); err != nil {
+		return nil, err
+	}
+
+	minGap := o.minGap
+	if o.strict {
+		minGap = strictGaps(minGap, nAnchors)
+	}
+
+	lines, limit := lineRings(window, o)
+
 	return &MatchSeq{
-		window:  window,
-		terms:   terms,
-		dupeMap: dupeMap,
+		lines:    lines,
+		limit:    limit,
+		window:   window,
+		terms:    terms,
+		dupeMap:  dupeMap,
+		maxGap:   o.maxGap,
+		minGap:   minGap,
+		optional: optional,
+		optAfter: optAfter,
+		strict:   o.strict,
+		explainT: explainT{limit: o.explain},
+		budgetT:  budgetT{maxBuffered: o.maxBuffered},
+		statsT:   newStats(o),
 	}, nil
 }
 
 func (r *MatchSeq) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
+	if e = r.admit(e); e == nil {
+		return
+	}
+	defer r.trim()
 
-	if e.Timestamp < r.clock {
-		log.Warn().
-			Str("line", e.Line).
-			Int64("stamp", e.Timestamp).
-			Int64("clock", r.clock).
-			Msg("MatchSeq: Out of order event.")
+	if !r.advance(e) {
 		return
 	}
+
+	hits.Cnt = 1
+	hits.FireStamp = e.Timestamp
+	hits.Logs = r.fire(e, make([]LogEntry, 0, r.GroupSize()))
+	r.restoreLines(&hits)
+	r.tag(&hits)
+	return
+}
+
+// ScanInto is an allocation free alternative to Scan.  On fire, the hit is written
+// into dst, reusing the storage of dst.Logs, and true is returned.  The contents of
+// dst are only valid until the next call to ScanInto with the same dst.
+//
+// Because the sequence has a fixed arity, dst.Logs must have a capacity of at least
+// GroupSize().  If it does not, the event is rejected without being scanned and
+// false is returned.  A matcher with WithID or WithLabels allocates Props on fire.
+func (r *MatchSeq) ScanInto(e *ScanLine, dst *Hits) bool {
+	if sz := r.GroupSize(); cap(dst.Logs) < sz {
+		r.log(log.Warn()).
+			Int("cap", cap(dst.Logs)).
+			Int("need", sz).
+			Msg("MatchSeq: Destination too small.")
+		return false
+	}
+
+	r.nScanned += 1
+	if e = r.admit(e); e == nil {
+		return false
+	}
+	defer r.trim()
+
+	if !r.advance(e) {
+		return false
+	}
+
+	dst.Cnt = 1
+	dst.FireStamp = e.Timestamp
+	dst.Logs = r.fire(e, dst.Logs[:0])
+	dst.Props = nil
+	dst.Groups = nil
+	r.restoreLines(dst)
+	r.tag(dst)
+	return true
+}
+
+// GroupSize returns the maximum number of log entries in each hit, including
+// dupes and optional terms.
+func (r *MatchSeq) GroupSize() int {
+	return len(r.terms) + r.dupeMap[-1] + len(r.optional)
+}
+
+// Evict over the WithMaxBuffered bound once the event is done.  A full frame
+// must be fired before trimming, as eviction may break the frame.
+func (r *MatchSeq) trim() {
+	if n := r.trimTerms(r.terms, r.dupeMap) + r.trimTerms(r.optional, nil); n > 0 {
+		r.nEvicted += uint64(n)
+		r.repair()
+	}
+}
+
+// Apply the late policy; returns nil if the event is dropped.  With a window
+// in lines, returns the event stamped with its line count.
+func (r *MatchSeq) admit(e *ScanLine) *ScanLine {
+	switch {
+	case r.lines != nil:
+		return r.lines.stamp(e)
+	case e.Timestamp < r.clock:
+		if e = r.late(&r.identT, "MatchSeq", e, r.clock); e == nil {
+			return nil
+		}
+	}
+	if r.limit != nil {
+		return r.limit.number(e)
+	}
+	return e
+}
+
+// Restore the fields of a hit replaced by line counts.
+func (r *MatchSeq) restoreLines(hits *Hits) {
+	switch {
+	case r.lines != nil:
+		r.lines.unstamp(hits)
+	case r.limit != nil:
+		r.limit.unnumber(hits)
+	}
+}
+
+// Advance the state machine on event; return true on a full frame.
+func (r *MatchSeq) advance(e *ScanLine) bool {
 	r.clock = e.Timestamp
 
 	r.maybeGC(e.Timestamp)
+	r.gcLines()
+
+	r.scanOptional(e)
 
 	for i := range r.nActive {
 		if r.terms[i].matcher(e) {
-			r.terms[i].asserts = append(r.terms[i].asserts, e.LogEntry)
+			r.terms[i].assert(e.Entry())
 		}
 	}
 
 	if !r.terms[r.nActive].matcher(e) {
 		// No match on active term; NOOP.
-		return
+		return false
+	}
+
+	if r.hasGaps() {
+		return r.advanceGaps(e)
 	}
 
 	// We have matched the active term; check if there are dupes before advancing.
@@ -69,25 +242,34 @@ func (r *MatchSeq) Scan(e *ScanLine) (hits Hits) {
 
 	if len(r.terms[r.nActive].asserts) < dupeCnt {
 		// Not enough dupes yet; append current for later.
-		r.terms[r.nActive].asserts = append(r.terms[r.nActive].asserts, e.LogEntry)
-		return
+		r.terms[r.nActive].assert(e.Entry())
+		return false
 	}
 
 	// We matched the active term, but not the all terms yet.
 	// Advance the active term and append the current event.
 	if r.nActive+1 < len(r.terms) {
-		r.terms[r.nActive].asserts = append(r.terms[r.nActive].asserts, e.LogEntry)
+		r.terms[r.nActive].assert(e.Entry())
 		r.nActive += 1
-		return
+		return false
 	}
 
-	// We have a full frame; fire and prune.
-	hits.Cnt = 1
-	hits.Logs = make([]LogEntry, 0, len(r.terms)+r.dupeMap[-1])
+	// We have a full frame.
+	r.terms[r.nActive].matched += 1
+	return true
+}
+
+// Append the full frame to logs and prune.
+func (r *MatchSeq) fire(e *ScanLine, logs []LogEntry) []LogEntry {
+
+	var (
+		base    = len(logs)
+		dupeCnt = r.dupeMap[r.nActive]
+	)
 
 	for i := range len(r.terms) - 1 {
 		hitCnt := r.dupeMap[i] + 1
-		hits.Logs = append(hits.Logs, r.terms[i].asserts[:hitCnt]...)
+		logs = append(logs, r.terms[i].asserts[:hitCnt]...)
 
 		// Only remove the first item; leave remaining dupes for next match.
 		shiftLeft(r.terms, i, hitCnt)
@@ -95,22 +277,27 @@ func (r *MatchSeq) Scan(e *ScanLine) (hits Hits) {
 
 	// Append any dupes for the final term
 	if dupeCnt > 0 {
-		hits.Logs = append(hits.Logs, r.terms[r.nActive].asserts[0:dupeCnt]...)
+		logs = append(logs, r.terms[r.nActive].asserts[0:dupeCnt]...)
 
 		// Remove all items
 		shiftLeft(r.terms, r.nActive, dupeCnt)
 	}
 
 	// And the final event that triggered this hit
-	hits.Logs = append(hits.Logs, e.LogEntry)
+	logs = append(logs, e.Entry())
+
+	// Include optional terms before the prune below discards them.
+	logs = r.weave(logs, base)
+	r.nHits += 1
+	r.trace(TraceT{Kind: TraceFire, Clock: e.Timestamp, Term: -1, Reset: -1})
 
 	// Update active so the miniGC can cleanup up correctly
 	r.nActive += 1
 
 	// Fixup state
-	r.miniGC()
+	r.repair()
 
-	return
+	return logs
 }
 
 func (r *MatchSeq) maybeGC(clock int64) {
@@ -118,31 +305,62 @@ func (r *MatchSeq) maybeGC(clock int64) {
 		return
 	}
 
-	r.GarbageCollect(clock)
+	r.gc(clock)
 }
 
 // Remove all terms that are older than the window.
 func (r *MatchSeq) GarbageCollect(clock int64) {
+	if r.lines == nil {
+		r.gc(clock)
+	}
+}
+
+// Remove all asserts numbered before the WithMaxLines bound.
+func (r *MatchSeq) gcLines() {
+	if r.limit == nil {
+		return
+	}
+
 	var (
-		cnt      int
-		m        = r.terms[0].asserts
-		deadline = clock - r.window
+		oldest = r.limit.oldest()
+		dirty  bool
 	)
 
-	// Find the first term that is not older than the window.
-	for _, term := range m {
+	for i, term := range r.terms {
+		if cnt := countBeforeLine(term.asserts, oldest); cnt > 0 {
+			r.trace(TraceT{Kind: TraceExpire, Clock: r.clock, Term: i, Reset: -1, Stamp: term.asserts[0].Timestamp, Count: cnt})
+			shiftLeft(r.terms, i, cnt)
+			dirty = true
+		}
+	}
 
-		if term.Timestamp >= deadline {
-			break
+	for i, term := range r.optional {
+		if cnt := countBeforeLine(term.asserts, oldest); cnt > 0 {
+			shiftLeft(r.optional, i, cnt)
 		}
-		cnt += 1
 	}
 
+	if dirty {
+		r.repair()
+	}
+}
+
+func (r *MatchSeq) gc(clock int64) {
+	var (
+		m        = r.terms[0].asserts
+		deadline = clock - r.window
+		cnt      = countBefore(m, deadline)
+	)
+
+	r.gcClock = clock
+
 	if cnt > 0 {
+		r.trace(TraceT{Kind: TraceExpire, Clock: clock, Term: 0, Reset: -1, Stamp: m[0].Timestamp, Count: cnt})
 		shiftLeft(r.terms, 0, cnt)
 	}
 
-	r.miniGC()
+	r.gcOptional(deadline)
+	r.repair()
 }
 
 func (r *MatchSeq) miniGC() {
@@ -175,21 +393,7 @@ func (r *MatchSeq) miniGC() {
 			continue
 		}
 
-		var (
-			cnt int
-			m   = r.terms[i].asserts
-		)
-	TERMLOOP:
-		for _, term := range m {
-			switch {
-			case term.Timestamp < zeroMatch:
-			default:
-				break TERMLOOP
-			}
-			cnt += 1
-		}
-
-		if cnt > 0 {
+		if cnt := countBefore(r.terms[i].asserts, zeroMatch); cnt > 0 {
 			shiftLeft(r.terms, i, cnt)
 		}
 
@@ -205,21 +409,33 @@ func (r *MatchSeq) miniGC() {
 
 func (r *MatchSeq) reset() {
 	for i := range r.terms {
-		m := r.terms[i].asserts
-		if cap(m) <= capThreshold {
-			r.terms[i].asserts = m[:0]
-		} else {
-			r.terms[i].asserts = nil
-		}
+		resetTerm(r.terms, i)
+	}
+	for i := range r.optional {
+		resetTerm(r.optional, i)
 	}
 	r.nActive = 0
 }
 
-// Because match sequence is edge triggered, there won't be hits.
+// Because match sequence is edge triggered, there won't be hits.  Advances the
+// clock and drops asserts that have left the window.
 func (r *MatchSeq) Eval(clock int64) (h Hits) {
+	if clock <= r.clock || r.lines != nil {
+		return
+	}
+	r.clock = clock
+	if !r.gcDue(clock, heldAsserts(r.terms)) {
+		return
+	}
+	r.maybeGC(clock)
 	return
 }
 
+// Coverage returns the range from the oldest retained assert to the clock.
+func (r *MatchSeq) Coverage() (oldest, newest int64) {
+	return calcCoverage(r.clock, r.terms, nil)
+}
+
 func buildSeqTerms(seqTerms ...TermT) ([]termT, map[int]int, error) {
 
 	if len(seqTerms) == 0 {
@@ -235,29 +451,39 @@ func buildSeqTerms(seqTerms ...TermT) ([]termT, map[int]int, error) {
 		terms    = make([]termT, 0, nTerms)
 	)
 
+	// A term with a count is treated as that many dupes.
 	for _, term := range seqTerms {
 
+		var (
+			dupes = term.occurs() - 1
+			key   = term.uncounted()
+		)
+
 		switch {
 		case i == -1: // First time
 			fallthrough
-		case term != lastTerm:
+		case key != lastTerm:
 			m, err := term.NewMatcher()
 			if err != nil {
 				return nil, nil, err
 			}
 			i += 1
 			terms = append(terms, termT{matcher: m})
-			lastTerm = term
-		case dupeMap == nil: // dupe
-			dupeMap = make(map[int]int)
-			fallthrough
-		default:
-			dupeMap[i]++
-			dupeSum++
+			lastTerm = key
+		default: // dupe
+			dupes += 1
+		}
+
+		if dupes > 0 {
+			if dupeMap == nil {
+				dupeMap = make(map[int]int)
+			}
+			dupeMap[i] += dupes
+			dupeSum += dupes
 		}
 	}
 
-	if len(terms) > maxTerms {
+	if len(terms) > MaxTerms {
 		return nil, nil, ErrTooManyTerms
 	}
 
@@ -275,3 +501,106 @@ func buildSeqTerms(seqTerms ...TermT) ([]termT, map[int]int, error) {
 
 	return terms, dupeMap, nil
 }
+
+func (r *MatchSeq) edgeTriggered() {}
+
+// Stats returns the matcher counters.
+func (r *MatchSeq) Stats() StatsT {
+	s := r.stats(r.terms, &r.lateT)
+	for _, o := range r.optional {
+		s.Buffered += len(o.asserts)
+	}
+	return s
+}
+
+// Advance with gap constraints.  The event is appended to the active term
+// tentatively; if it arrived too soon, or repairing the chain rolls back past
+// the active term, the event is discarded.
+func (r *MatchSeq) advanceGaps(e *ScanLine) bool {
+	k := r.nActive
+	r.terms[k].assert(e.Entry())
+
+	r.fixGaps()
+
+	switch {
+	case r.nActive != k:
+		return false
+	case len(r.terms[k].asserts) <= r.dupeMap[k]:
+		// Not enough dupes yet.
+		return false
+	case k+1 < len(r.terms):
+		r.nActive += 1
+		return false
+	}
+
+	// Full frame; fire appends the triggering event itself.
+	m := r.terms[k].asserts
+	r.terms[k].asserts = m[:len(m)-1]
+	return true
+}
+
+func (r *MatchSeq) repair() {
+	r.miniGC()
+	if r.hasGaps() {
+		r.fixGaps()
+	}
+}
+
+func (r *MatchSeq) hasGaps() bool {
+	return r.maxGap != nil || r.minGap != nil
+}
+
+// Drop stale asserts until the chain satisfies the gap constraints.
+// Each pass removes an assert, so this terminates.
+func (r *MatchSeq) fixGaps() {
+	for {
+		drop := r.staleAnchor()
+		if !drop.ValidTerm() {
+			return
+		}
+
+		shiftAnchor(r.terms, drop)
+		r.miniGC()
+
+		// Terms past the (partial) active term were recorded against a
+		// chain that no longer exists.
+		for i := r.nActive + 1; i < len(r.terms); i++ {
+			resetTerm(r.terms, i)
+		}
+	}
+}
+
+// Walk the chain, including any partial dupes of the active term, and return
+// the anchor to drop on the first constraint violation.
+func (r *MatchSeq) staleAnchor() anchorT {
+	var (
+		pos  int
+		prev anchorT
+		last = min(r.nActive, len(r.terms)-1)
+	)
+
+	for i := 0; i <= last; i++ {
+		m := r.terms[i].asserts
+		for j := range min(r.dupeMap[i]+1, len(m)) {
+			cur := anchorT{clock: m[j].Timestamp, term: i, offset: j}
+
+			if pos > 0 {
+				switch {
+				case cur.clock < prev.clock:
+					// Out of order after a rollback; the current entry is stale.
+					return cur
+				case !minGapOK(r.minGap, pos-1, cur.clock-prev.clock):
+					// Too soon; a later entry may yet fill the slot.
+					return cur
+				case !gapOK(r.maxGap, pos-1, cur.clock-prev.clock):
+					return prev
+				}
+			}
+
+			prev = cur
+			pos += 1
+		}
+	}
+
+	return anchorT{term: -1}
+}
@@ -392,6 +392,313 @@ func NewCasesSeqDupes() casesT {
 	}
 }
 
+func NewCasesSeqMaxGap() casesT {
+	// B within 2 of A, C within 30 of B.
+	gaps := []OptT{WithMaxGap(2, 30)}
+
+	return casesT{
+		"WithinGaps": {
+			window: 100,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 3, line: "beta"},
+				{stamp: 33, line: "gamma", cb: matchStamps(1, 3, 33)},
+			},
+		},
+		"FirstGapStale": {
+			window: 100,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 4, line: "alpha"},
+				{stamp: 5, line: "beta"}, // alpha at 1 is stale
+				{stamp: 6, line: "gamma", cb: matchStamps(4, 5, 6)},
+			},
+		},
+		"FirstGapNoRecovery": {
+			window: 100,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 4, line: "beta"},
+				{stamp: 5, line: "gamma"},
+				{postF: checkActive(0)},
+			},
+		},
+		"SecondGapRebuild": {
+			// gamma is too late for beta at 2; rebuild from alpha 9 and beta 10.
+			window: 100,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 2, line: "beta"},
+				{stamp: 9, line: "alpha"},
+				{stamp: 10, line: "beta"},
+				{stamp: 35, line: "gamma", cb: matchStamps(9, 10, 35)},
+			},
+		},
+		"SecondGapRollback": {
+			window: 100,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 2, line: "beta"},
+				{stamp: 40, line: "gamma"},
+				{postF: checkActive(1)}, // alpha remains until a beta proves it stale
+				{stamp: 50, line: "alpha"},
+				{stamp: 51, line: "beta"},
+				{stamp: 52, line: "gamma", cb: matchStamps(50, 51, 52)},
+			},
+		},
+		"Unbounded": {
+			// A zero gap is unbounded; the window still applies.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   []OptT{WithMaxGap(0, 1)},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 8, line: "beta"},
+				{stamp: 9, line: "gamma", cb: matchStamps(1, 8, 9)},
+				{stamp: 20, line: "alpha"},
+				{stamp: 29, line: "beta"},
+				{stamp: 31, line: "gamma"},
+			},
+		},
+		"Dupes": {
+			window: 100,
+			terms:  []string{"alpha", "alpha", "beta"},
+			opts:   []OptT{WithMaxGap(5, 5)},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 10, line: "alpha"}, // alpha at 1 is stale
+				{stamp: 12, line: "alpha"},
+				{stamp: 14, line: "beta", cb: matchStamps(10, 12, 14)},
+			},
+		},
+	}
+}
+
+func NewCasesSeqMinGap() casesT {
+	// B at least 5 after A.
+	gaps := []OptT{WithMinGap(5)}
+
+	return casesT{
+		"AfterGap": {
+			window: 100,
+			terms:  []string{"alpha", "beta"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 6, line: "beta", cb: matchStamps(1, 6)},
+			},
+		},
+		"TooSoon": {
+			// Early betas do not consume the slot.
+			window: 100,
+			terms:  []string{"alpha", "beta"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 2, line: "beta"},
+				{stamp: 5, line: "beta"},
+				{postF: checkActive(1)},
+				{stamp: 7, line: "beta", cb: matchStamps(1, 7)},
+			},
+		},
+		"ThreeTerms": {
+			window: 100,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   []OptT{WithMinGap(0, 5)},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 1, line: "beta"},
+				{stamp: 3, line: "gamma"},
+				{stamp: 6, line: "gamma", cb: matchStamps(1, 1, 6)},
+			},
+		},
+		"MinAndMax": {
+			// beta between 5 and 10 after alpha.
+			window: 100,
+			terms:  []string{"alpha", "beta"},
+			opts:   []OptT{WithMinGap(5), WithMaxGap(10)},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 8, line: "alpha"},
+				{stamp: 12, line: "beta"}, // Stale alpha at 1; too soon for alpha at 8
+				{postF: checkActive(1)},
+				{stamp: 14, line: "beta", cb: matchStamps(8, 14)},
+			},
+		},
+		"Dupes": {
+			window: 100,
+			terms:  []string{"alpha", "alpha"},
+			opts:   gaps,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 3, line: "alpha"},
+				{stamp: 6, line: "alpha", cb: matchStamps(1, 6)},
+			},
+		},
+	}
+}
+
+func NewCasesSeqOptional() casesT {
+	optB := []OptT{WithOptional(1)}
+
+	return casesT{
+		"Present": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   optB,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(1, 2, 3)},
+			},
+		},
+		"Absent": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   optB,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "gamma", cb: matchStamps(1, 2)},
+			},
+		},
+		"BeforeAnchor": {
+			// Optional match before the first term is not included.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   optB,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(1, 2, 3)},
+				{line: "beta"},
+				{line: "alpha"},
+				{line: "gamma", cb: matchStamps(5, 6)},
+			},
+		},
+		"FirstMatch": {
+			// The first match is taken; later ones are consumed.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   optB,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "beta"},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(1, 2, 4)},
+				{line: "alpha"},
+				{line: "gamma", cb: matchStamps(5, 6)},
+			},
+		},
+		"Overlap": {
+			// Optional matches are assigned to the frame they fall in.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   optB,
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "alpha"},
+				{line: "gamma", cb: matchStamps(1, 3)},
+				{line: "beta"},
+				{line: "gamma", cb: matchStamps(2, 4, 5)},
+			},
+		},
+		"Adjacent": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma", "delta"},
+			opts:   []OptT{WithOptional(1, 2)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "gamma"},
+				{line: "beta"},
+				{line: "gamma"},
+				{line: "delta", cb: matchStamps(1, 3, 4, 5)},
+			},
+		},
+		"Between": {
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma", "delta"},
+			opts:   []OptT{WithOptional(2)},
+			steps: []stepT{
+				{line: "alpha"},
+				{line: "gamma"},
+				{line: "beta"},
+				{line: "delta", cb: matchStamps(1, 3, 4)},
+			},
+		},
+		"Window": {
+			window: 5,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   optB,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 2, line: "beta"},
+				{stamp: 8, line: "alpha"},
+				{stamp: 9, line: "gamma", cb: matchStamps(8, 9)},
+			},
+		},
+	}
+}
+
+func NewCasesSeqStrict() casesT {
+	strict := []OptT{WithStrictOrder(true)}
+
+	return casesT{
+		"EqualStampsDefault": {
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 1, line: "beta", cb: matchStamps(1, 1)},
+			},
+		},
+		"EqualStamps": {
+			window: 10,
+			terms:  []string{"alpha", "beta"},
+			opts:   strict,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 1, line: "beta"},
+				{stamp: 2, line: "beta", cb: matchStamps(1, 2)},
+			},
+		},
+		"Dupes": {
+			window: 10,
+			terms:  []string{"alpha", "alpha", "beta"},
+			opts:   strict,
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 1, line: "alpha"},
+				{stamp: 2, line: "alpha"},
+				{stamp: 2, line: "beta"},
+				{stamp: 3, line: "beta", cb: matchStamps(1, 2, 3)},
+			},
+		},
+		"WithMinGap": {
+			// The larger of the two applies.
+			window: 10,
+			terms:  []string{"alpha", "beta", "gamma"},
+			opts:   []OptT{WithStrictOrder(true), WithMinGap(3)},
+			steps: []stepT{
+				{stamp: 1, line: "alpha"},
+				{stamp: 3, line: "beta"},
+				{stamp: 4, line: "beta"},
+				{stamp: 4, line: "gamma"},
+				{stamp: 5, line: "gamma", cb: matchStamps(1, 4, 5)},
+			},
+		},
+	}
+}
+
 func TestSeq(t *testing.T) {
 
 	cases := map[string]struct {
@@ -406,13 +713,25 @@ func TestSeq(t *testing.T) {
 		"Dupes": {
 			cases: NewCasesSeqDupes(),
 		},
+		"MaxGap": {
+			cases: NewCasesSeqMaxGap(),
+		},
+		"MinGap": {
+			cases: NewCasesSeqMinGap(),
+		},
+		"Strict": {
+			cases: NewCasesSeqStrict(),
+		},
+		"Optional": {
+			cases: NewCasesSeqOptional(),
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 
 			tc.cases.run(t, func(tc caseT) (Matcher, error) {
-				return NewMatchSeq(tc.window, makeTerms(tc.terms)...)
+				return NewMatchSeqOpts(tc.window, makeTerms(tc.terms), tc.opts...)
 			})
 
 		})
@@ -425,6 +744,7 @@ func TestSeqInitFail(t *testing.T) {
 		err    error
 		window int64
 		terms  []TermT
+		opts   []OptT
 	}{
 		"NoTerms": {
 			err:    ErrNoTerms,
@@ -440,25 +760,60 @@ func TestSeqInitFail(t *testing.T) {
 		"AlmostTooManyTerms": {
 			err:    nil,
 			window: 10,
-			terms:  makeTermsN(maxTerms),
+			terms:  makeTermsN(MaxTerms),
 		},
 
 		"DupeShouldNotPushOverMax": {
 			err:    nil,
 			window: 10,
-			terms:  makeDupesN(maxTerms * 2),
+			terms:  makeDupesN(MaxTerms * 2),
 		},
 
 		"TooManyTerms": {
 			err:    ErrTooManyTerms,
 			window: 10,
-			terms:  makeTermsN(maxTerms + 1),
+			terms:  makeTermsN(MaxTerms + 1),
+		},
+
+		"TooManyGaps": {
+			err:    ErrGapRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithMaxGap(1, 2)},
+		},
+
+		"TooManyMinGaps": {
+			err:    ErrGapRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithMinGap(1, 2)},
+		},
+
+		"OptionalFirst": {
+			err:    ErrOptional,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta", "gamma"),
+			opts:   []OptT{WithOptional(0)},
+		},
+
+		"OptionalLast": {
+			err:    ErrOptional,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta", "gamma"),
+			opts:   []OptT{WithOptional(2)},
+		},
+
+		"NegativeGap": {
+			err:    ErrGapRange,
+			window: 10,
+			terms:  makeTermsA("alpha", "beta"),
+			opts:   []OptT{WithMaxGap(-1)},
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			_, err := NewMatchSeq(tc.window, tc.terms...)
+			_, err := NewMatchSeqOpts(tc.window, tc.terms, tc.opts...)
 			if err != tc.err {
 				t.Fatalf("Expected err == %v, got %v", tc.err, err)
 			}
@@ -466,6 +821,52 @@ func TestSeqInitFail(t *testing.T) {
 	}
 }
 
+func TestSeqScanInto(t *testing.T) {
+	defer disableLogs()()
+
+	sm, err := NewMatchSeq(10, makeTermsA("alpha", "beta", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	if sm.GroupSize() != 3 {
+		t.Fatalf("Expected group size 3, got %d", sm.GroupSize())
+	}
+
+	// Too small; rejected without scanning.
+	small := Hits{Logs: make([]LogEntry, 0, 2)}
+	if sm.ScanInto(NewScanLine().ResetLine(1, "alpha"), &small) {
+		t.Fatalf("Expected reject on small dst")
+	}
+
+	var (
+		dst   = Hits{Logs: make([]LogEntry, 0, sm.GroupSize())}
+		store = &dst.Logs[:1][0]
+		lines = []string{"alpha", "beta", "beta", "alpha", "beta", "beta"}
+		fires int
+	)
+
+	for i, line := range lines {
+		if !sm.ScanInto(NewScanLine().ResetLine(int64(i+1), line), &dst) {
+			continue
+		}
+		fires += 1
+		if dst.Cnt != 1 || len(dst.Logs) != 3 {
+			t.Fatalf("Expected 1 hit of 3 logs, got %d of %d", dst.Cnt, len(dst.Logs))
+		}
+		if &dst.Logs[0] != store {
+			t.Fatalf("Expected dst storage to be reused")
+		}
+		if dst.Logs[0].Timestamp != int64(i-1) || dst.Logs[2].Timestamp != int64(i+1) {
+			t.Fatalf("Unexpected stamps: %v", dst.Logs)
+		}
+	}
+
+	if fires != 2 {
+		t.Fatalf("Expected 2 fires, got %d", fires)
+	}
+}
+
 // ----------
 
 func BenchmarkSequenceMisses(b *testing.B) {
@@ -506,6 +907,29 @@ func BenchmarkSequenceHitSequence(b *testing.B) {
 	}
 }
 
+func BenchmarkSequenceHitSequenceInto(b *testing.B) {
+	sm, err := NewMatchSeq(int64(time.Second), makeTermsA("frank", "burns")...)
+	if err != nil {
+		b.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	ts := time.Now().UnixNano()
+	ev1 := NewScanLine().ResetLine(ts, "Let's be frank")
+	ev2 := NewScanLine().ResetLine(ts, "Mr burns I am")
+	dst := Hits{Logs: make([]LogEntry, 0, sm.GroupSize())}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ev1.Timestamp = ts
+		ev2.Timestamp = ts + 1
+		ts += 2
+		sm.ScanInto(ev1, &dst)
+		if !sm.ScanInto(ev2, &dst) || dst.Cnt != 1 {
+			b.FailNow()
+		}
+	}
+}
+
 func BenchmarkSequenceHitOverlap(b *testing.B) {
 	sm, err := NewMatchSeq(int64(time.Second), makeTermsA("frank", "burns")...)
 	if err != nil {
@@ -555,3 +979,169 @@ func BenchmarkSeqRunawayMatch(b *testing.B) {
 		sm.Scan(ev1)
 	}
 }
+
+// A term with a count behaves as that many dupes.
+func TestSeqCount(t *testing.T) {
+
+	cases := map[string]struct {
+		counted  []TermT
+		repeated []TermT
+	}{
+		"Leading": {
+			counted:  []TermT{{Type: TermRaw, Value: "alpha", Count: 3}, makeRaw("beta")},
+			repeated: makeTermsA("alpha", "alpha", "alpha", "beta"),
+		},
+		"Trailing": {
+			counted:  []TermT{makeRaw("alpha"), {Type: TermRaw, Value: "beta", Count: 2}},
+			repeated: makeTermsA("alpha", "beta", "beta"),
+		},
+		"WithDupe": {
+			counted:  []TermT{{Type: TermRaw, Value: "alpha", Count: 2}, makeRaw("alpha"), makeRaw("beta")},
+			repeated: makeTermsA("alpha", "alpha", "alpha", "beta"),
+		},
+		"One": {
+			counted:  []TermT{{Type: TermRaw, Value: "alpha", Count: 1}, makeRaw("beta")},
+			repeated: makeTermsA("alpha", "beta"),
+		},
+	}
+
+	lines := []string{"alpha", "beta", "alpha", "alpha", "beta", "alpha", "alpha", "alpha", "alpha", "beta", "beta"}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			counted, err := NewMatchSeq(10, tc.counted...)
+			if err != nil {
+				t.Fatalf("Expected nil error, got: %v", err)
+			}
+			repeated, err := NewMatchSeq(10, tc.repeated...)
+			if err != nil {
+				t.Fatalf("Expected nil error, got: %v", err)
+			}
+
+			if counted.GroupSize() != repeated.GroupSize() {
+				t.Fatalf("Expected group size %d, got %d", repeated.GroupSize(), counted.GroupSize())
+			}
+
+			sl := NewScanLine()
+			for i, line := range lines {
+				sl.ResetLine(int64(i+1), line)
+				var (
+					want = repeated.Scan(sl)
+					got  = counted.Scan(sl)
+				)
+				if want.Cnt != got.Cnt || len(want.Logs) != len(got.Logs) {
+					t.Fatalf("Step %d: expected %d hits of %d logs, got %d of %d", i, want.Cnt, len(want.Logs), got.Cnt, len(got.Logs))
+				}
+			}
+		})
+	}
+}
+
+func TestSeqCountInit(t *testing.T) {
+	_, err := NewMatchSeq(10, TermT{Type: TermRaw, Value: "alpha", Count: -1})
+	if err != ErrTermCount {
+		t.Errorf("Expected ErrTermCount, got %v", err)
+	}
+
+	// Counts do not push over the maximum number of terms.
+	terms := makeTermsN(MaxTerms)
+	terms[0].Count = MaxTerms
+	if _, err := NewMatchSeq(10, terms...); err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+
+	// Gaps index anchors, so account for counts.
+	counted := []TermT{{Type: TermRaw, Value: "alpha", Count: 3}, makeRaw("beta")}
+	if _, err := NewMatchSeqOpts(10, counted, WithMaxGap(1, 1, 1)); err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+}
+
+func TestSeqLineWindow(t *testing.T) {
+
+	sm, err := NewMatchSeqOpts(3, makeTermsA("alpha", "beta"), WithLineWindow())
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	// Timestamps are coarse and out of order; only the line count matters.
+	type stepT struct {
+		stamp int64
+		line  string
+		fire  bool
+	}
+
+	steps := []stepT{
+		{50, "alpha", false},
+		{50, "noop", false},
+		{40, "noop", false},
+		{40, "noop", false},
+		{90, "beta", false}, // Four lines after alpha.
+		{10, "alpha", false},
+		{10, "noop", false},
+		{5, "noop", false},
+		{20, "beta", true}, // Three lines after alpha.
+	}
+
+	sl := NewScanLine()
+	for i, step := range steps {
+		hits := sm.Scan(sl.ResetLine(step.stamp, step.line))
+		if (hits.Cnt > 0) != step.fire {
+			t.Fatalf("Step %d: expected fire %v, got %v", i, step.fire, hits)
+		}
+		if !step.fire {
+			continue
+		}
+		if hits.FireStamp != 20 || hits.Logs[0].Timestamp != 10 || hits.Logs[1].Timestamp != 20 {
+			t.Errorf("Expected real timestamps, got %v", hits)
+		}
+	}
+
+	// Time does not move a line window.
+	sm.Scan(sl.ResetLine(1, "alpha"))
+	sm.Eval(1e9)
+	sm.GarbageCollect(1e9)
+	if hits := sm.Scan(sl.ResetLine(2, "beta")); hits.Cnt != 1 {
+		t.Errorf("Expected a hit after eval, got %v", hits)
+	}
+}
+
+func TestSeqMaxLines(t *testing.T) {
+
+	sm, err := NewMatchSeqOpts(10, makeTermsA("alpha", "beta"), WithMaxLines(2))
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	type stepT struct {
+		stamp int64
+		line  string
+		fire  bool
+	}
+
+	steps := []stepT{
+		{1, "alpha", false},
+		{1, "noop", false},
+		{1, "noop", false},
+		{2, "beta", false}, // In time, but three lines after alpha.
+		{3, "alpha", false},
+		{3, "noop", false},
+		{4, "beta", true}, // Two lines after alpha.
+		{5, "alpha", false},
+		{20, "beta", false}, // Next line, but out of time.
+	}
+
+	sl := NewScanLine()
+	for i, step := range steps {
+		sl.ResetLine(step.stamp, step.line)
+		sl.LineNo = int64(100 + i)
+
+		hits := sm.Scan(sl)
+		if (hits.Cnt > 0) != step.fire {
+			t.Fatalf("Step %d: expected fire %v, got %v", i, step.fire, hits)
+		}
+		if step.fire && (hits.Logs[0].LineNo != 104 || hits.Logs[1].LineNo != 106) {
+			t.Errorf("Expected source line numbers, got %v", hits.Logs)
+		}
+	}
+}
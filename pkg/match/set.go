@@ -2,8 +2,6 @@ package match
 
 import (
 	"math"
-
-	"github.com/rs/zerolog/log"
 )
 
 const disableGC int64 = math.MaxInt64
@@ -15,42 +13,83 @@ type MatchSet struct {
 	terms   []termT
 	hotMask bitMaskT
 	dupeMap map[int]int
+	limit   *lineRingT // WithMaxLines
+	quorum  int        // WithQuorum; zero requires every term.
+	indices []int      // Term index of each term as supplied; WithQuorum only.
+
+	statsT
+	lateT
+	budgetT
 }
 
 func NewMatchSet(window int64, setTerms ...TermT) (*MatchSet, error) {
+	return NewMatchSetOpts(window, setTerms)
+}
+
+func NewMatchSetOpts(window int64, setTerms []TermT, opts ...OptT) (*MatchSet, error) {
+
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optMaxBuffered | optMaxLines | optQuorum); err != nil {
+		return nil, err
+	}
 
 	terms, dupeMap, err := buildSetTerms(setTerms...)
 	if err != nil {
 		return nil, err
 	}
 
+	var limit *lineRingT
+	if o.maxLines > 0 {
+		limit = newLineRing(o.maxLines)
+	}
+
+	var indices []int
+	if o.quorum != 0 {
+		if o.quorum < 0 || o.quorum > len(terms) {
+			return nil, ErrQuorum
+		}
+		if o.quorum < len(terms) {
+			indices = termIndices(setTerms)
+		} else {
+			o.quorum = 0 // Every term; a plain set.
+		}
+	}
+
 	return &MatchSet{
+		limit:   limit,
+		quorum:  o.quorum,
+		indices: indices,
 		terms:   terms,
 		window:  window,
 		gcMark:  disableGC,
 		dupeMap: dupeMap, // 8 bytes overhead if nil, same as a bitmask
+		budgetT: budgetT{maxBuffered: o.maxBuffered},
+		statsT:  newStats(o),
 	}, nil
 }
 
 func (r *MatchSet) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
 	if e.Timestamp < r.clock {
-		log.Warn().
-			Str("line", e.Line).
-			Int64("stamp", e.Timestamp).
-			Int64("clock", r.clock).
-			Msg("MatchSet: Out of order event.")
-		return
+		if e = r.late(&r.identT, "MatchSet", e, r.clock); e == nil {
+			return
+		}
 	}
 	r.clock = e.Timestamp
 
+	if r.limit != nil {
+		e = r.limit.number(e)
+	}
+
 	r.maybeGC(e.Timestamp)
+	r.gcLines()
 
 	// For a set, must scan all terms.
 	// Cannot short circuit like a sequence.
 	for i, term := range r.terms {
 		if term.matcher(e) {
 			// Append the match to the assert list
-			r.terms[i].asserts = append(r.terms[i].asserts, e.LogEntry)
+			r.terms[i].assert(e.Entry())
 
 			if dupeCnt := r.dupeMap[i]; len(r.terms[i].asserts) > dupeCnt {
 				r.hotMask.Set(i)
@@ -63,14 +102,26 @@ func (r *MatchSet) Scan(e *ScanLine) (hits Hits) {
 		}
 	}
 
-	if !r.hotMask.FirstN(len(r.terms)) {
+	// Evict over the WithMaxBuffered bound.  Eviction leaves a term at or
+	// above its dupe count; the hot mask holds.
+	r.nEvicted += uint64(r.trimTerms(r.terms, r.dupeMap))
+
+	if !r.full() {
 		return // no match
 	}
 
 	// We have a full frame; fire and prune.
+	r.nHits += 1
 	hits.Cnt = 1
+	hits.FireStamp = e.Timestamp
 	hits.Logs = make([]LogEntry, 0, len(r.terms)) // Not quite if dupes are present
 
+	if r.quorum > 0 {
+		hits.Props = map[PropKey]any{
+			{Idx: 0, Key: PropQuorum}: r.quorumTerms(),
+		}
+	}
+
 	r.gcMark = disableGC
 	for i, term := range r.terms {
 
@@ -80,17 +131,20 @@ func (r *MatchSet) Scan(e *ScanLine) (hits Hits) {
 		)
 
 		m := term.asserts
-		hits.Logs = append(hits.Logs, m[0:hitCnt]...)
-		if len(m) == hitCnt && cap(m) <= capThreshold {
-			m = m[:0]
-		} else {
-			m = m[hitCnt:]
+		if !r.hotMask.IsSet(i) {
+			// Short of quorum; the term is held for a later frame.
+			if len(m) > 0 && m[0].Timestamp < r.gcMark {
+				r.gcMark = m[0].Timestamp
+			}
+			continue
 		}
-		r.terms[i].asserts = m
 
-		if len(m) == 0 {
+		hits.Logs = append(hits.Logs, m[0:hitCnt]...)
+		if shiftLeft(r.terms, i, hitCnt) == 0 {
 			r.hotMask.Clr(i)
 		} else {
+			m = r.terms[i].asserts
+
 			// Clear the hot mask if there's a dupeCnt and we're under it
 			if len(m) <= dupeCnt {
 				r.hotMask.Clr(i)
@@ -103,6 +157,11 @@ func (r *MatchSet) Scan(e *ScanLine) (hits Hits) {
 		}
 	}
 
+	if r.limit != nil {
+		r.limit.unnumber(&hits)
+	}
+
+	r.tag(&hits)
 	return
 }
 
@@ -119,20 +178,33 @@ func (r *MatchSet) GarbageCollect(clock int64) {
 
 	deadline := clock - r.window
 
-	r.gcMark = disableGC
+	r.gcClock = clock
+	r.prune(func(asserts []LogEntry) int {
+		return countBefore(asserts, deadline)
+	})
+}
 
-	for i, term := range r.terms {
+// Remove all asserts numbered before the WithMaxLines bound.
+func (r *MatchSet) gcLines() {
+	if r.limit == nil || r.gcMark == disableGC {
+		return
+	}
 
-		var cnt int
+	oldest := r.limit.oldest()
+	r.prune(func(asserts []LogEntry) int {
+		return countBeforeLine(asserts, oldest)
+	})
+}
 
-		for _, assert := range term.asserts {
-			if assert.Timestamp >= deadline {
-				break
-			}
-			cnt += 1
-		}
+// Remove the leading asserts of each term counted by before, and recompute
+// the hot mask and gc mark.
+func (r *MatchSet) prune(before func([]LogEntry) int) {
 
-		if cnt > 0 {
+	r.gcMark = disableGC
+
+	for i, term := range r.terms {
+
+		if cnt := before(term.asserts); cnt > 0 {
 			shiftLeft(r.terms, i, cnt)
 		}
 
@@ -154,11 +226,43 @@ func (r *MatchSet) GarbageCollect(clock int64) {
 	}
 }
 
-// Because match sequence is edge triggered, there won't be hits.  But can GC.
+// Set is edge triggered; the clock alone cannot cause a fire.  Advances the
+// clock and drops asserts that have left the window.
 func (r *MatchSet) Eval(clock int64) (h Hits) {
+	if clock <= r.clock {
+		return
+	}
+	r.clock = clock
+	if !r.gcDue(clock, heldAsserts(r.terms)) {
+		return
+	}
+	r.maybeGC(clock)
 	return
 }
 
+// Coverage returns the range from the oldest retained assert to the clock.
+func (r *MatchSet) Coverage() (oldest, newest int64) {
+	return calcCoverage(r.clock, r.terms, nil)
+}
+
+// Index of the term built by buildSetTerms for each term as supplied.
+func termIndices(setTerms []TermT) []int {
+	var (
+		uniqs   = make(map[TermT]int, len(setTerms))
+		indices = make([]int, len(setTerms))
+	)
+	for i, term := range setTerms {
+		key := term.uncounted()
+		idx, ok := uniqs[key]
+		if !ok {
+			idx = len(uniqs)
+			uniqs[key] = idx
+		}
+		indices[i] = idx
+	}
+	return indices
+}
+
 func buildSetTerms(setTerms ...TermT) ([]termT, map[int]int, error) {
 
 	if len(setTerms) == 0 {
@@ -175,26 +279,39 @@ func buildSetTerms(setTerms ...TermT) ([]termT, map[int]int, error) {
 	)
 
 	// O(n) on nTerms
+	// A term with a count is treated as that many dupes.
 	for _, term := range setTerms {
 
-		if idx, ok := uniqs[term]; ok {
-			if dupeMap == nil {
-				dupeMap = make(map[int]int)
-			}
-			dupeMap[idx]++
-			dupeSum++
+		var (
+			idx   int
+			dupes = term.occurs() - 1
+			key   = term.uncounted()
+		)
+
+		if j, ok := uniqs[key]; ok {
+			idx = j
+			dupes += 1
 		} else {
 			m, err := term.NewMatcher()
 			if err != nil {
 				return nil, nil, err
 			}
 			terms = append(terms, termT{matcher: m})
-			uniqs[term] = i
+			uniqs[key] = i
+			idx = i
 			i += 1
 		}
+
+		if dupes > 0 {
+			if dupeMap == nil {
+				dupeMap = make(map[int]int)
+			}
+			dupeMap[idx] += dupes
+			dupeSum += dupes
+		}
 	}
 
-	if len(terms) > maxTerms {
+	if len(terms) > MaxTerms {
 		return nil, nil, ErrTooManyTerms
 	}
 
@@ -212,3 +329,10 @@ func buildSetTerms(setTerms ...TermT) ([]termT, map[int]int, error) {
 
 	return terms, dupeMap, nil
 }
+
+func (r *MatchSet) edgeTriggered() {}
+
+// Stats returns the matcher counters.
+func (r *MatchSet) Stats() StatsT {
+	return r.stats(r.terms, &r.lateT)
+}
@@ -1,6 +1,7 @@
 package match
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -244,19 +245,19 @@ func TestSetInitFail(t *testing.T) {
 		"AlmostTooManyTerms": {
 			err:    nil,
 			window: 10,
-			terms:  makeTermsN(maxTerms),
+			terms:  makeTermsN(MaxTerms),
 		},
 
 		"DupeShouldNotPushOverMax": {
 			err:    nil,
 			window: 10,
-			terms:  makeDupesN(maxTerms * 2),
+			terms:  makeDupesN(MaxTerms * 2),
 		},
 
 		"TooManyTerms": {
 			err:    ErrTooManyTerms,
 			window: 10,
-			terms:  makeTermsN(maxTerms + 1),
+			terms:  makeTermsN(MaxTerms + 1),
 		},
 	}
 
@@ -269,3 +270,104 @@ func TestSetInitFail(t *testing.T) {
 		})
 	}
 }
+
+func TestSetCount(t *testing.T) {
+	sm, err := NewMatchSet(10, TermT{Type: TermRaw, Value: "alpha", Count: 2}, makeRaw("beta"), makeRaw("alpha"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	steps := []struct {
+		line string
+		cnt  int
+	}{
+		{"beta", 0},
+		{"alpha", 0},
+		{"alpha", 0},
+		{"alpha", 1},
+	}
+
+	for i, step := range steps {
+		hits := sm.Scan(sl.ResetLine(int64(i+1), step.line))
+		if hits.Cnt != step.cnt {
+			t.Fatalf("Step %d: expected %d hits, got %d", i, step.cnt, hits.Cnt)
+		}
+	}
+}
+
+func TestSetMaxLines(t *testing.T) {
+
+	sm, err := NewMatchSetOpts(10, makeTermsA("alpha", "beta"), WithMaxLines(2))
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	type stepT struct {
+		stamp int64
+		line  string
+		fire  bool
+	}
+
+	steps := []stepT{
+		{1, "beta", false},
+		{1, "noop", false},
+		{1, "noop", false},
+		{2, "alpha", false}, // In time, but three lines after beta.
+		{3, "noop", false},
+		{4, "beta", true}, // Two lines after alpha.
+	}
+
+	sl := NewScanLine()
+	for i, step := range steps {
+		sl.ResetLine(step.stamp, step.line)
+		sl.LineNo = int64(100 + i)
+
+		hits := sm.Scan(sl)
+		if (hits.Cnt > 0) != step.fire {
+			t.Fatalf("Step %d: expected fire %v, got %v", i, step.fire, hits)
+		}
+		if step.fire && (hits.Logs[0].LineNo != 103 || hits.Logs[1].LineNo != 105) {
+			t.Errorf("Expected source line numbers, got %v", hits.Logs)
+		}
+	}
+}
+
+func TestSetWide(t *testing.T) {
+	const n = 100
+
+	terms := make([]TermT, n)
+	for i := range n {
+		terms[i] = makeRaw(fmt.Sprintf("<%d>", i))
+	}
+
+	sm, err := NewMatchSet(1000, terms...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i := n - 1; i > 0; i-- {
+		if hits := sm.Scan(sl.ResetLine(int64(n-i), fmt.Sprintf("<%d>", i))); hits.Cnt != 0 {
+			t.Fatalf("Expected no fire on term %d, got %v", i, hits)
+		}
+	}
+
+	// The hot mask spans words through a checkpoint.
+	data, err := sm.MarshalState()
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	restored, _ := NewMatchSet(1000, terms...)
+	if err := restored.RestoreState(data); err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	hits := restored.Scan(sl.ResetLine(n, "<0>"))
+	if hits.Cnt != 1 || len(hits.Logs) != n {
+		t.Fatalf("Expected 1 hit of %d logs, got %d of %d", n, hits.Cnt, len(hits.Logs))
+	}
+	if !restored.hotMask.Zeros() {
+		t.Errorf("Expected hot mask cleared after fire")
+	}
+}
@@ -4,32 +4,134 @@ import (
 	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
 )
 
+// PropSuppressed is the number of hits suppressed by WithSample or
+// WithRateLimit since the previous hit emitted, set on a hit that follows any.
+const PropSuppressed = "suppressed"
+
+// MatchSingle fires on every line matching its term.  As a feeder for high
+// frequency terms, WithSample and WithRateLimit thin the hits it emits; the
+// rate limit is a token bucket on log time.
+
 type MatchSingle struct {
 	matcher MatchFunc
+	sample  int
+	nSeen   int
+	nSince  uint64 // Suppressed since the last hit emitted.
+	bucket  *tokenBucketT
+
+	statsT
+}
+
+type tokenBucketT struct {
+	rate   float64 // Tokens per nanosecond.
+	burst  float64
+	tokens float64
+	last   int64
+	primed bool
 }
 
 func NewMatchSingle(term TermT) (*MatchSingle, error) {
+	return NewMatchSingleOpts(term)
+}
+
+// NewMatchSingleOpts is NewMatchSingle with options.
+func NewMatchSingleOpts(term TermT, opts ...OptT) (*MatchSingle, error) {
 	m, err := term.NewMatcher()
 	if err != nil {
 		return nil, err
 	}
 
-	return &MatchSingle{matcher: m}, nil
+	o := parseOpts(opts)
+	if err := o.check(optsStats | optSample | optRateLimit); err != nil {
+		return nil, err
+	}
+
+	var bucket *tokenBucketT
+	if o.rateLimit > 0 {
+		bucket = &tokenBucketT{
+			rate:   o.rateLimit / 1e9,
+			burst:  float64(o.burst),
+			tokens: float64(o.burst),
+		}
+	}
+
+	return &MatchSingle{
+		matcher: m,
+		sample:  o.sample,
+		bucket:  bucket,
+		statsT:  newStats(o),
+	}, nil
 }
 
 func (r *MatchSingle) Scan(e *ScanLine) (hits Hits) {
+	r.nScanned += 1
 
-	if r.matcher(e) {
-		hits.Cnt = 1
-		hits.Logs = []entry.LogEntry{e.LogEntry}
+	if !r.matcher(e) {
+		return
 	}
+	r.nMatched += 1
+
+	if !r.admit(e.Timestamp) {
+		r.nSupp += 1
+		r.nSince += 1
+		return
+	}
+
+	r.nHits += 1
+	hits.Cnt = 1
+	hits.FireStamp = e.Timestamp
+	hits.Logs = []entry.LogEntry{e.Entry()}
 
+	if r.nSince > 0 {
+		hits.Props = map[PropKey]any{{Idx: 0, Key: PropSuppressed}: r.nSince}
+		r.nSince = 0
+	}
+
+	r.tag(&hits)
 	return
 }
 
+// Apply sampling, then the rate limit; true if the hit is emitted.
+func (r *MatchSingle) admit(ts int64) bool {
+	if r.sample > 1 {
+		seen := r.nSeen
+		r.nSeen = (r.nSeen + 1) % r.sample
+		if seen != 0 {
+			return false
+		}
+	}
+
+	return r.bucket == nil || r.bucket.take(ts)
+}
+
+// Refill for the log time elapsed since the last take, then take a token.
+func (b *tokenBucketT) take(ts int64) bool {
+	switch {
+	case !b.primed:
+		b.primed, b.last = true, ts
+	case ts > b.last:
+		b.tokens = min(b.burst, b.tokens+float64(ts-b.last)*b.rate)
+		b.last = ts
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens -= 1
+	return true
+}
+
+// Single fires only on Scan; Eval has nothing to do.
 func (r *MatchSingle) Eval(clock int64) (hits Hits) {
 	return
 }
 
 func (r *MatchSingle) GarbageCollect(clock int64) {
 }
+
+func (r *MatchSingle) edgeTriggered() {}
+
+// Stats returns the matcher counters.
+func (r *MatchSingle) Stats() StatsT {
+	return r.stats(nil, nil)
+}
@@ -110,3 +110,71 @@ func BenchmarkSingleHit(b *testing.B) {
 		sm.Scan(ev1)
 	}
 }
+
+func TestSingleSample(t *testing.T) {
+
+	sm, err := NewMatchSingleOpts(makeRaw("alpha"), WithSample(3))
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var (
+		sl    = NewScanLine()
+		fired []int64
+	)
+
+	for i := range int64(7) {
+		sm.Scan(sl.ResetLine(i+1, "beta"))
+		if hits := sm.Scan(sl.ResetLine(i+1, "alpha")); hits.Cnt > 0 {
+			fired = append(fired, hits.FireStamp)
+			if i > 0 && hits.Props[PropKey{Idx: 0, Key: PropSuppressed}] != uint64(2) {
+				t.Errorf("Expected 2 suppressed on hit, got %v", hits.Props)
+			}
+		}
+	}
+
+	if len(fired) != 3 || fired[0] != 1 || fired[1] != 4 || fired[2] != 7 {
+		t.Errorf("Expected hits at 1, 4 and 7, got %v", fired)
+	}
+
+	if s := sm.Stats(); s.Hits != 3 || s.Suppressed != 4 || s.Matched[0] != 7 {
+		t.Errorf("Expected 3 hits, 4 suppressed of 7, got %+v", s)
+	}
+}
+
+func TestSingleRateLimit(t *testing.T) {
+
+	const sec = int64(1e9)
+
+	// One per second, bursts of two.
+	sm, err := NewMatchSingleOpts(makeRaw("alpha"), WithRateLimit(1, 2))
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	tests := []struct {
+		stamp int64
+		fire  bool
+	}{
+		{10 * sec, true},
+		{10 * sec, true},
+		{10 * sec, false}, // Burst spent.
+		{10*sec + sec/2, false},
+		{11 * sec, true}, // One token back.
+		{11 * sec, false},
+		{20 * sec, true}, // Refilled to the burst only.
+		{20 * sec, true},
+		{20 * sec, false},
+	}
+
+	sl := NewScanLine()
+	for i, tc := range tests {
+		if hits := sm.Scan(sl.ResetLine(tc.stamp, "alpha")); (hits.Cnt > 0) != tc.fire {
+			t.Errorf("Step %d: expected fire %v, got %v", i, tc.fire, hits)
+		}
+	}
+
+	if s := sm.Stats(); s.Suppressed != 4 {
+		t.Errorf("Expected 4 suppressed, got %d", s.Suppressed)
+	}
+}
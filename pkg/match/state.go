@@ -0,0 +1,222 @@
+package match
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Bump on any incompatible change to stateT.
+const stateVersion = 2
+
+var (
+	ErrStateVersion  = errors.New("unsupported state version")
+	ErrStateMismatch = errors.New("state does not match matcher")
+)
+
+const (
+	stateKindSeq        = "seq"
+	stateKindSet        = "set"
+	stateKindInverseSeq = "inverseSeq"
+	stateKindInverseSet = "inverseSet"
+)
+
+// StateI is implemented by matchers that support checkpoint and restore.
+type StateI interface {
+	MarshalState() ([]byte, error)
+	RestoreState([]byte) error
+}
+
+// stateT is the checkpoint format shared by the windowed matchers.  Only dynamic
+// state is captured; configuration (terms, window, resets) must be supplied by
+// constructing an identical matcher before calling RestoreState.
+type stateT struct {
+	Version  int          `json:"v"`
+	Kind     string       `json:"k"`
+	Clock    int64        `json:"clock"`
+	GcMark   int64        `json:"gc,omitempty"`
+	NActive  int          `json:"active,omitempty"`
+	HotMask  uint64       `json:"hot,omitempty"`
+	HotHi    []uint64     `json:"hotHi,omitempty"` // Hot mask past the first 64 terms.
+	Asserts  [][]LogEntry `json:"asserts"`
+	Optional [][]LogEntry `json:"optional,omitempty"` // Asserts of optional terms; MatchSeq only.
+	Resets   [][]int64    `json:"resets,omitempty"`
+	Keys     [][]string   `json:"keys,omitempty"`  // Correlation values, parallel to Resets.
+	Lines    *lineStateT  `json:"lines,omitempty"` // Line counter of a window bounded in lines.
+}
+
+func marshalState(kind string, s stateT, terms []termT, resets []resetT) ([]byte, error) {
+	s.Version = stateVersion
+	s.Kind = kind
+
+	s.Asserts = make([][]LogEntry, len(terms))
+	for i, term := range terms {
+		s.Asserts[i] = term.asserts
+	}
+
+	if len(resets) > 0 {
+		s.Resets = make([][]int64, len(resets))
+		for i, reset := range resets {
+			s.Resets[i] = reset.resets
+			if reset.corr != nil {
+				if s.Keys == nil {
+					s.Keys = make([][]string, len(resets))
+				}
+				s.Keys[i] = reset.corr.keys
+			}
+		}
+	}
+
+	return json.Marshal(s)
+}
+
+// Decode and validate state against the matcher shape.  Does not modify the matcher.
+func unmarshalState(kind string, data []byte, terms []termT, resets []resetT) (s stateT, err error) {
+	if err = json.Unmarshal(data, &s); err != nil {
+		return
+	}
+
+	switch {
+	case s.Version != stateVersion:
+		err = fmt.Errorf("%w: %d", ErrStateVersion, s.Version)
+	case s.Kind != kind:
+		err = fmt.Errorf("%w: kind %q, expected %q", ErrStateMismatch, s.Kind, kind)
+	case len(s.Asserts) != len(terms):
+		err = fmt.Errorf("%w: %d terms, expected %d", ErrStateMismatch, len(s.Asserts), len(terms))
+	case len(s.Resets) != len(resets):
+		err = fmt.Errorf("%w: %d resets, expected %d", ErrStateMismatch, len(s.Resets), len(resets))
+	case s.NActive < 0 || s.NActive > len(terms):
+		err = fmt.Errorf("%w: active %d", ErrStateMismatch, s.NActive)
+	}
+
+	for i := range resets {
+		if err != nil || resets[i].corr == nil {
+			continue
+		}
+		if i >= len(s.Keys) || len(s.Keys[i]) != len(s.Resets[i]) {
+			err = fmt.Errorf("%w: reset %d correlation", ErrStateMismatch, i)
+		}
+	}
+
+	return
+}
+
+func restoreAsserts(s stateT, terms []termT, resets []resetT) {
+	for i := range terms {
+		terms[i].asserts = slices.Clip(s.Asserts[i])
+	}
+	for i := range resets {
+		resets[i].resets = slices.Clip(s.Resets[i])
+		if c := resets[i].corr; c != nil {
+			c.keys = slices.Clip(s.Keys[i])
+		}
+	}
+}
+
+// MarshalState captures the dynamic state of the matcher.
+func (r *MatchSeq) MarshalState() ([]byte, error) {
+	s := stateT{
+		Clock:   r.clock,
+		NActive: r.nActive,
+		Lines:   cmp.Or(r.lines, r.limit).state(),
+	}
+	for _, term := range r.optional {
+		s.Optional = append(s.Optional, term.asserts)
+	}
+	return marshalState(stateKindSeq, s, r.terms, nil)
+}
+
+// RestoreState restores state captured by MarshalState on an identically configured matcher.
+func (r *MatchSeq) RestoreState(data []byte) error {
+	s, err := unmarshalState(stateKindSeq, data, r.terms, nil)
+	if err != nil {
+		return err
+	}
+	if len(s.Optional) != len(r.optional) {
+		return fmt.Errorf("%w: %d optional terms, expected %d", ErrStateMismatch, len(s.Optional), len(r.optional))
+	}
+	if err = cmp.Or(r.lines, r.limit).restore(s.Lines); err != nil {
+		return err
+	}
+	restoreAsserts(s, r.terms, nil)
+	for i := range r.optional {
+		r.optional[i].asserts = slices.Clip(s.Optional[i])
+	}
+	r.clock = s.Clock
+	r.nActive = s.NActive
+	return nil
+}
+
+// MarshalState captures the dynamic state of the matcher.
+func (r *MatchSet) MarshalState() ([]byte, error) {
+	return marshalState(stateKindSet, stateT{
+		Clock:   r.clock,
+		GcMark:  r.gcMark,
+		HotMask: r.hotMask.lo,
+		HotHi:   r.hotMask.hi,
+		Lines:   r.limit.state(),
+	}, r.terms, nil)
+}
+
+// RestoreState restores state captured by MarshalState on an identically configured matcher.
+func (r *MatchSet) RestoreState(data []byte) error {
+	s, err := unmarshalState(stateKindSet, data, r.terms, nil)
+	if err != nil {
+		return err
+	}
+	if err = r.limit.restore(s.Lines); err != nil {
+		return err
+	}
+	restoreAsserts(s, r.terms, nil)
+	r.clock = s.Clock
+	r.gcMark = s.GcMark
+	r.hotMask = makeBitMask(s.HotMask, s.HotHi)
+	return nil
+}
+
+// MarshalState captures the dynamic state of the matcher.
+func (r *InverseSeq) MarshalState() ([]byte, error) {
+	return marshalState(stateKindInverseSeq, stateT{
+		Clock:   r.clock,
+		GcMark:  r.gcMark,
+		NActive: r.nActive,
+	}, r.terms, r.resets)
+}
+
+// RestoreState restores state captured by MarshalState on an identically configured matcher.
+func (r *InverseSeq) RestoreState(data []byte) error {
+	s, err := unmarshalState(stateKindInverseSeq, data, r.terms, r.resets)
+	if err != nil {
+		return err
+	}
+	restoreAsserts(s, r.terms, r.resets)
+	r.clock = s.Clock
+	r.gcMark = s.GcMark
+	r.nActive = s.NActive
+	return nil
+}
+
+// MarshalState captures the dynamic state of the matcher.
+func (r *InverseSet) MarshalState() ([]byte, error) {
+	return marshalState(stateKindInverseSet, stateT{
+		Clock:   r.clock,
+		GcMark:  r.gcMark,
+		HotMask: r.hotMask.lo,
+		HotHi:   r.hotMask.hi,
+	}, r.terms, r.resets)
+}
+
+// RestoreState restores state captured by MarshalState on an identically configured matcher.
+func (r *InverseSet) RestoreState(data []byte) error {
+	s, err := unmarshalState(stateKindInverseSet, data, r.terms, r.resets)
+	if err != nil {
+		return err
+	}
+	restoreAsserts(s, r.terms, r.resets)
+	r.clock = s.Clock
+	r.gcMark = s.GcMark
+	r.hotMask = makeBitMask(s.HotMask, s.HotHi)
+	return nil
+}
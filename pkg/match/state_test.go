@@ -0,0 +1,134 @@
+package match
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+
+	var (
+		terms  = makeTermsA("alpha", "beta", "beta")
+		resets = []ResetT{{Term: makeRaw("boom"), Window: 5}}
+		lines  = []string{"alpha", "beta", "alpha", "boom", "beta", "noop", "beta", "alpha", "beta", "beta"}
+	)
+
+	factories := map[string]func() (Matcher, error){
+		"Seq":        func() (Matcher, error) { return NewMatchSeq(10, terms...) },
+		"Set":        func() (Matcher, error) { return NewMatchSet(10, terms...) },
+		"InverseSeq": func() (Matcher, error) { return NewInverseSeq(10, terms, resets) },
+		"InverseSet": func() (Matcher, error) { return NewInverseSet(10, terms, resets) },
+		"SeqLines":   func() (Matcher, error) { return NewMatchSeqOpts(4, terms, WithLineWindow()) },
+		"SeqMaxLine": func() (Matcher, error) { return NewMatchSeqOpts(10, terms, WithMaxLines(4)) },
+		"SetMaxLine": func() (Matcher, error) { return NewMatchSetOpts(10, terms, WithMaxLines(4)) },
+		"SeqOptional": func() (Matcher, error) {
+			return NewMatchSeqOpts(10, makeTermsA("alpha", "boom", "beta"), WithOptional(1))
+		},
+	}
+
+	scan := func(sm Matcher, start, stop int, hits *Hits) {
+		for i := start; i < stop; i++ {
+			hits.Append(sm.Scan(NewScanLine().ResetLine(int64(i+1), lines[i])))
+		}
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+
+			// Checkpoint at every position; compare against an uninterrupted run.
+			for cut := range len(lines) + 1 {
+
+				ref, err := factory()
+				if err != nil {
+					t.Fatalf("Expected err == nil, got %v", err)
+				}
+
+				var expHits Hits
+				scan(ref, 0, len(lines), &expHits)
+				expHits.Append(ref.Eval(100))
+
+				first, _ := factory()
+				var gotHits Hits
+				scan(first, 0, cut, &gotHits)
+
+				data, err := first.(StateI).MarshalState()
+				if err != nil {
+					t.Fatalf("Expected err == nil, got %v", err)
+				}
+
+				second, _ := factory()
+				if err := second.(StateI).RestoreState(data); err != nil {
+					t.Fatalf("Expected err == nil, got %v", err)
+				}
+
+				scan(second, cut, len(lines), &gotHits)
+				gotHits.Append(second.Eval(100))
+
+				if gotHits.Cnt != expHits.Cnt || !reflect.DeepEqual(gotHits.Logs, expHits.Logs) {
+					t.Errorf("Cut %d: Expected %d hits %v, got %d hits %v", cut, expHits.Cnt, expHits.Logs, gotHits.Cnt, gotHits.Logs)
+				}
+			}
+		})
+	}
+}
+
+func TestStateRestoreFail(t *testing.T) {
+
+	seq, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+	seq.Scan(NewScanLine().ResetLine(1, "alpha"))
+
+	good, err := seq.MarshalState()
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	set, _ := NewMatchSet(10, makeTermsA("alpha", "beta")...)
+	seq3, _ := NewMatchSeq(10, makeTermsA("alpha", "beta", "gamma")...)
+	inv, _ := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("boom")}})
+	lim, _ := NewMatchSeqOpts(10, makeTermsA("alpha", "beta"), WithMaxLines(2))
+	opt, _ := NewMatchSeqOpts(10, makeTermsA("alpha", "boom", "beta"), WithOptional(1))
+
+	cases := map[string]struct {
+		sm   StateI
+		data []byte
+		err  error
+	}{
+		"Version": {
+			sm:   seq,
+			data: []byte(strings.Replace(string(good), `"v":2`, `"v":99`, 1)),
+			err:  ErrStateVersion,
+		},
+		"OldVersion": {
+			// Version 1 did not capture optional terms.
+			sm:   seq,
+			data: []byte(strings.Replace(string(good), `"v":2`, `"v":1`, 1)),
+			err:  ErrStateVersion,
+		},
+		"Optional":  {sm: opt, data: good, err: ErrStateMismatch},
+		"Kind":      {sm: set, data: good, err: ErrStateMismatch},
+		"TermCount": {sm: seq3, data: good, err: ErrStateMismatch},
+		"Lines":     {sm: lim, data: good, err: ErrStateMismatch},
+		"Resets": {
+			sm:   inv,
+			data: []byte(strings.Replace(string(good), `"k":"seq"`, `"k":"inverseSeq"`, 1)),
+			err:  ErrStateMismatch,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := tc.sm.RestoreState(tc.data); !errors.Is(err, tc.err) {
+				t.Errorf("Expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	if err := seq.RestoreState([]byte("{bad")); err == nil {
+		t.Errorf("Expected error on malformed state")
+	}
+}
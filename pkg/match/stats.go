@@ -0,0 +1,107 @@
+package match
+
+import (
+	"slices"
+)
+
+// StatsT reports counters for a matcher, to help debug rules that never fire.
+type StatsT struct {
+	Scanned    uint64   // Lines scanned.
+	Matched    []uint64 // Lines matched per term, in term order; dupes share a term.
+	Hits       uint64   // Hits emitted.
+	Resets     uint64   // Reset lines recorded.
+	Buffered   int      // Entries currently held.
+	LastGC     int64    // Clock of the most recent garbage collection; zero if none.
+	Dropped    uint64   // Out of order entries dropped.
+	Evicted    uint64   // Entries evicted over the WithMaxBuffered bound, or hits over a MatchPause queue.
+	Suppressed uint64   // Hits suppressed by WithSample or WithRateLimit.
+
+	ID     string            // Set by WithID.
+	Labels map[string]string // Set by WithLabels.
+}
+
+// StatsI is implemented by matchers that report counters.
+type StatsI interface {
+	Stats() StatsT
+}
+
+// Embedded by matchers to keep counters.  Matched counts are kept on the
+// terms of multi term matchers, and dropped entries by lateT.
+type statsT struct {
+	nScanned uint64
+	nMatched uint64 // Single term matchers only.
+	nHits    uint64
+	nResets  uint64
+	nEvicted uint64
+	nSupp    uint64
+
+	gcT
+	identT
+}
+
+func newStats(o optsT) statsT {
+	return statsT{gcT: newGC(o), identT: newIdent(o)}
+}
+
+// Build the stats; terms is nil for single term matchers.
+func (s *statsT) stats(terms []termT, l *lateT) StatsT {
+	out := StatsT{
+		Scanned:    s.nScanned,
+		Matched:    []uint64{s.nMatched},
+		Hits:       s.nHits,
+		Resets:     s.nResets,
+		Evicted:    s.nEvicted,
+		LastGC:     s.gcClock,
+		Suppressed: s.nSupp,
+		ID:         s.id,
+		Labels:     s.labels,
+	}
+
+	if terms != nil {
+		out.Matched = make([]uint64, len(terms))
+	}
+	for i, term := range terms {
+		out.Matched[i] = term.matched
+		out.Buffered += len(term.asserts)
+	}
+
+	if l != nil {
+		out.Dropped = l.nDropped
+	}
+
+	return out
+}
+
+// Append e to the term asserts, counting the match.
+func (t *termT) assert(e LogEntry) {
+	t.push(e)
+	t.matched += 1
+}
+
+// Sum the counters of o into s, other than Scanned.  Matched is taken from
+// the first o, and summed thereafter where the shapes agree.
+func (s *StatsT) add(o StatsT) {
+	s.Hits += o.Hits
+	s.Resets += o.Resets
+	s.Buffered += o.Buffered
+	s.Dropped += o.Dropped
+	s.Evicted += o.Evicted
+	s.Suppressed += o.Suppressed
+	s.LastGC = max(s.LastGC, o.LastGC)
+
+	if s.Matched == nil {
+		s.Matched = slices.Clone(o.Matched)
+	} else if len(s.Matched) == len(o.Matched) {
+		for i, n := range o.Matched {
+			s.Matched[i] += n
+		}
+	}
+}
+
+// Stats of m; zero if m does not report them.
+func statsOf(m Matcher) StatsT {
+	if sm, ok := m.(StatsI); ok {
+		return sm.Stats()
+	}
+	return StatsT{}
+}
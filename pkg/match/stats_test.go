@@ -0,0 +1,148 @@
+package match
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStatsSeq(t *testing.T) {
+	sm, err := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i, line := range []string{"alpha", "gamma", "alpha", "beta", "alpha"} {
+		sm.Scan(sl.ResetLine(int64(i+10), line))
+	}
+
+	// Out of order; dropped.
+	sm.Scan(sl.ResetLine(1, "alpha"))
+	sm.GarbageCollect(30)
+
+	s := sm.Stats()
+	if s.Scanned != 6 || s.Hits != 1 || s.Dropped != 1 || s.LastGC != 30 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+	if !slices.Equal(s.Matched, []uint64{3, 1}) {
+		t.Errorf("Expected matched [3 1], got %v", s.Matched)
+	}
+	if s.Buffered != 0 {
+		t.Errorf("Expected nothing buffered after GC, got %d", s.Buffered)
+	}
+}
+
+func TestStatsInverseSeq(t *testing.T) {
+	iseq, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset")}})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i, line := range []string{"alpha", "reset", "beta", "alpha"} {
+		iseq.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	s := iseq.Stats()
+	if s.Scanned != 4 || s.Resets != 1 || s.Hits != 0 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+	if !slices.Equal(s.Matched, []uint64{2, 1}) {
+		t.Errorf("Expected matched [2 1], got %v", s.Matched)
+	}
+}
+
+func TestStatsSingle(t *testing.T) {
+	cnt, err := NewMatchCount(10, 2, makeRaw("alpha"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i, line := range []string{"alpha", "beta", "alpha"} {
+		cnt.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	s := cnt.Stats()
+	if s.Scanned != 3 || s.Hits != 1 || !slices.Equal(s.Matched, []uint64{2}) {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+}
+
+func TestStatsWrapped(t *testing.T) {
+	m, err := NewMatchSingle(makeRaw("alpha"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	lm, err := NewMatchLimit(2, NewMatchNamed("rule", m))
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	lm.Scan(sl.ResetLine(1, "alpha"))
+	lm.Scan(sl.ResetLine(2, "beta"))
+
+	if s := lm.Stats(); s.Scanned != 2 || s.Hits != 1 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+
+	// Once done, only the hits emitted are reported.
+	lm.Scan(sl.ResetLine(3, "alpha"))
+	if s := lm.Stats(); s.Scanned != 0 || s.Hits != 2 {
+		t.Errorf("Unexpected stats once done: %+v", s)
+	}
+}
+
+func TestStatsKeyed(t *testing.T) {
+	keyFn, err := KeyRegex(`pod=(\S+)`)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	km, err := NewKeyedMatcher(keyFn, func() Matcher {
+		m, _ := NewMatchSeq(10, makeTermsA("alpha", "beta")...)
+		return m
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := NewScanLine()
+	for i, line := range []string{"alpha pod=a", "alpha pod=b", "beta pod=a", "beta"} {
+		km.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	s := km.Stats()
+	if s.Scanned != 4 || s.Hits != 1 || s.Buffered != 1 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+	if !slices.Equal(s.Matched, []uint64{2, 1}) {
+		t.Errorf("Expected matched [2 1], got %v", s.Matched)
+	}
+}
+
+func TestStatsMulti(t *testing.T) {
+	var (
+		mm, _ = NewMultiMatcher()
+		sl    = NewScanLine()
+	)
+
+	for _, term := range []string{"alpha", "beta"} {
+		m, err := NewMatchSingle(makeRaw(term))
+		if err != nil {
+			t.Fatalf("Expected nil error, got: %v", err)
+		}
+		mm.Add(m, makeRaw(term))
+	}
+
+	for i, line := range []string{"alpha", "beta", "gamma"} {
+		mm.Scan(sl.ResetLine(int64(i+1), line), func(int, Hits) {})
+	}
+
+	s := mm.Stats()
+	if s.Scanned != 3 || s.Hits != 2 || s.Matched != nil {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+}
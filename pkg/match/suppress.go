@@ -0,0 +1,206 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrSuppressWindow = errors.New("invalid suppression window")
+
+const oneDay = 24 * time.Hour
+
+// SuppressWindowT is a recurring window of the day, such as a nightly backup,
+// from Start after midnight for Duration.  A window may run past midnight; it
+// belongs to the day it starts on.
+type SuppressWindowT struct {
+	Start    time.Duration
+	Duration time.Duration
+	Days     []time.Weekday // Days the window starts on; empty for every day.
+	Loc      *time.Location // Zone of the time of day; nil for UTC.
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseSuppressWindow parses a window of the form "02:00-03:00", optionally
+// led by the days it starts on, such as "sat,sun 22:00-02:00" or
+// "mon-fri 12:00-12:30", in the zone loc.  A nil loc is UTC.
+func ParseSuppressWindow(spec string, loc *time.Location) (SuppressWindowT, error) {
+
+	w := SuppressWindowT{Loc: loc}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+	case 2:
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return w, err
+		}
+		w.Days = days
+		fields = fields[1:]
+	default:
+		return w, fmt.Errorf("%w: %q", ErrSuppressWindow, spec)
+	}
+
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return w, fmt.Errorf("%w: %q", ErrSuppressWindow, spec)
+	}
+
+	start, err := parseTimeOfDay(from)
+	if err != nil {
+		return w, err
+	}
+	stop, err := parseTimeOfDay(to)
+	if err != nil {
+		return w, err
+	}
+
+	if stop <= start {
+		stop += oneDay
+	}
+
+	w.Start, w.Duration = start, stop-start
+	return w, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Duration(t.Hour())*time.Hour +
+				time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: time of day %q", ErrSuppressWindow, s)
+}
+
+func parseDays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, ok1 := weekdays[from]
+		last, ok2 := weekdays[to]
+		switch {
+		case !ok1:
+			return nil, fmt.Errorf("%w: day %q", ErrSuppressWindow, from)
+		case !isRange:
+			days = append(days, first)
+			continue
+		case !ok2:
+			return nil, fmt.Errorf("%w: day %q", ErrSuppressWindow, to)
+		}
+
+		// Ranges may wrap the week, as in "fri-mon".
+		for d := first; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// Contains reports whether the timestamp ts falls within the window.
+func (w SuppressWindowT) Contains(ts int64) bool {
+	loc := w.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var (
+		t   = time.Unix(0, ts).In(loc)
+		tod = time.Duration(t.Hour())*time.Hour +
+			time.Duration(t.Minute())*time.Minute +
+			time.Duration(t.Second())*time.Second +
+			time.Duration(t.Nanosecond())
+	)
+
+	// Either in a window that started today, or in one that started
+	// yesterday and runs past midnight.
+	switch {
+	case tod >= w.Start && tod < w.Start+w.Duration:
+		return w.onDay(t.Weekday())
+	case tod+oneDay < w.Start+w.Duration:
+		return w.onDay((t.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func (w SuppressWindowT) onDay(d time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, d)
+}
+
+// MatchSuppress wraps a Matcher to drop hits whose anchor, the first entry of
+// the hit, falls within any of a set of recurring windows, such as planned
+// maintenance.  The wrapped matcher runs unchanged; suppression applies only
+// to what is emitted.
+
+type MatchSuppress struct {
+	m          Matcher
+	windows    []SuppressWindowT
+	suppressed uint64
+}
+
+func NewMatchSuppress(m Matcher, windows ...SuppressWindowT) *MatchSuppress {
+	return &MatchSuppress{m: m, windows: windows}
+}
+
+func (r *MatchSuppress) Scan(e *ScanLine) Hits {
+	return r.filter(r.m.Scan(e))
+}
+
+func (r *MatchSuppress) Eval(clock int64) Hits {
+	return r.filter(r.m.Eval(clock))
+}
+
+func (r *MatchSuppress) GarbageCollect(clock int64) {
+	r.m.GarbageCollect(clock)
+}
+
+// Suppressed returns the number of hits suppressed so far.
+func (r *MatchSuppress) Suppressed() uint64 {
+	return r.suppressed
+}
+
+// Stats returns the counters of the wrapped matcher; Hits includes those suppressed.
+func (r *MatchSuppress) Stats() StatsT {
+	return statsOf(r.m)
+}
+
+func (r *MatchSuppress) filter(h Hits) Hits {
+	if h.Cnt == 0 || len(r.windows) == 0 {
+		return h
+	}
+
+	out := h.Filter(func(logs []LogEntry) bool {
+		return len(logs) == 0 || !r.suppress(logs[0].Timestamp)
+	})
+
+	r.suppressed += uint64(h.Cnt - out.Cnt)
+	return out
+}
+
+func (r *MatchSuppress) suppress(ts int64) bool {
+	for _, w := range r.windows {
+		if w.Contains(ts) {
+			return true
+		}
+	}
+	return false
+}
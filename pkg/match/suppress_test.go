@@ -0,0 +1,119 @@
+package match
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSuppressWindow(t *testing.T) {
+
+	tests := map[string]struct {
+		spec  string
+		start time.Duration
+		dur   time.Duration
+		days  []time.Weekday
+		err   error
+	}{
+		"daily":    {spec: "02:00-03:00", start: 2 * time.Hour, dur: time.Hour},
+		"seconds":  {spec: "02:00:30-02:01", start: 2*time.Hour + 30*time.Second, dur: 30 * time.Second},
+		"midnight": {spec: "23:30-00:30", start: 23*time.Hour + 30*time.Minute, dur: time.Hour},
+		"days":     {spec: "Sat,sun 01:00-02:00", start: time.Hour, dur: time.Hour, days: []time.Weekday{time.Saturday, time.Sunday}},
+		"range":    {spec: "fri-mon 01:00-02:00", start: time.Hour, dur: time.Hour, days: []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+		"noRange":  {spec: "02:00", err: ErrSuppressWindow},
+		"badTime":  {spec: "2am-3am", err: ErrSuppressWindow},
+		"badDay":   {spec: "someday 02:00-03:00", err: ErrSuppressWindow},
+		"extra":    {spec: "mon 02:00-03:00 utc", err: ErrSuppressWindow},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w, err := ParseSuppressWindow(tc.spec, nil)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected err %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if w.Start != tc.start || w.Duration != tc.dur {
+				t.Errorf("Expected %v for %v, got %v for %v", tc.start, tc.dur, w.Start, w.Duration)
+			}
+			if len(w.Days) != len(tc.days) {
+				t.Fatalf("Expected days %v, got %v", tc.days, w.Days)
+			}
+			for i := range w.Days {
+				if w.Days[i] != tc.days[i] {
+					t.Errorf("Expected days %v, got %v", tc.days, w.Days)
+				}
+			}
+		})
+	}
+}
+
+func TestSuppressWindowContains(t *testing.T) {
+
+	est := time.FixedZone("EST", -5*3600)
+
+	// Saturday night into Sunday, in EST.
+	w, err := ParseSuppressWindow("sat 23:00-01:00", est)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2024, 3, 9, 23, 30, 0, 0, est), true},      // Saturday
+		{time.Date(2024, 3, 10, 0, 59, 59, 0, est), true},     // Past midnight
+		{time.Date(2024, 3, 10, 1, 0, 0, 0, est), false},      // End is exclusive
+		{time.Date(2024, 3, 10, 4, 30, 0, 0, time.UTC), true}, // Same instant as 23:30 EST
+		{time.Date(2024, 3, 10, 23, 30, 0, 0, est), false},    // Sunday
+		{time.Date(2024, 3, 11, 0, 30, 0, 0, est), false},     // Monday morning
+		{time.Date(2024, 3, 9, 22, 59, 0, 0, est), false},     // Before start
+	}
+
+	for _, tc := range tests {
+		if got := w.Contains(tc.at.UnixNano()); got != tc.want {
+			t.Errorf("%v: expected %v, got %v", tc.at, tc.want, got)
+		}
+	}
+}
+
+func TestMatchSuppress(t *testing.T) {
+
+	sm, err := NewMatchSeq(int64(2*time.Hour), makeTermsA("alpha", "beta")...)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	w, err := ParseSuppressWindow("02:00-03:00", nil)
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var (
+		r    = NewMatchSuppress(sm, w)
+		sl   = NewScanLine()
+		base = time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+		at   = func(h, m int) int64 {
+			return base.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute).UnixNano()
+		}
+	)
+
+	// Anchored in the window; suppressed even though it fires after.
+	r.Scan(sl.ResetLine(at(2, 50), "alpha"))
+	if hits := r.Scan(sl.ResetLine(at(3, 10), "beta")); hits.Cnt != 0 {
+		t.Errorf("Expected suppressed hit, got %v", hits)
+	}
+
+	// Anchored after the window.
+	r.Scan(sl.ResetLine(at(3, 20), "alpha"))
+	if hits := r.Scan(sl.ResetLine(at(3, 30), "beta")); hits.Cnt != 1 {
+		t.Errorf("Expected 1 hit, got %v", hits)
+	}
+
+	if r.Suppressed() != 1 {
+		t.Errorf("Expected 1 suppressed, got %d", r.Suppressed())
+	}
+}
@@ -0,0 +1,120 @@
+package match
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const DefTermCacheSize = 4096
+
+// TermCacheT holds compiled MatchFuncs keyed by TermT, so that matchers built
+// from the same terms share one compiled regex or jq program.  A compiled
+// term is safe to share; terms that keep state between lines, such as
+// TermJqJsonDiff, are always compiled afresh.  Terms that fail to compile are
+// not cached.  Safe for concurrent use.
+type TermCacheT struct {
+	mu      sync.RWMutex
+	maxSz   int
+	entries map[TermT]MatchFunc
+
+	nHits    atomic.Uint64
+	nMisses  atomic.Uint64
+	nEvicted atomic.Uint64
+}
+
+// TermCacheStatsT reports the counters of a TermCacheT.
+type TermCacheStatsT struct {
+	Hits    uint64 // Lookups served from the cache.
+	Misses  uint64 // Lookups that compiled the term.
+	Evicted uint64 // Entries evicted over the size bound.
+	Entries int    // Entries currently held.
+}
+
+// NewTermCache returns a cache of up to maxSz compiled terms; zero or less is
+// unbounded.  Over the bound, an arbitrary entry is evicted.
+func NewTermCache(maxSz int) *TermCacheT {
+	return &TermCacheT{
+		maxSz:   maxSz,
+		entries: make(map[TermT]MatchFunc),
+	}
+}
+
+// The cache consulted by TermT.NewMatcher; nil disables caching.
+var termCache atomic.Pointer[TermCacheT]
+
+func init() {
+	termCache.Store(NewTermCache(DefTermCacheSize))
+}
+
+// SetTermCache replaces the cache used by TermT.NewMatcher, returning the
+// previous one.  A nil cache disables caching.
+func SetTermCache(c *TermCacheT) *TermCacheT {
+	return termCache.Swap(c)
+}
+
+// TermCache returns the cache used by TermT.NewMatcher; nil if disabled.
+func TermCache() *TermCacheT {
+	return termCache.Load()
+}
+
+// Get returns the compiled term, compiling it on a miss.
+func (c *TermCacheT) Get(tt TermT) (MatchFunc, error) {
+	if tt.Type == TermJqJsonDiff {
+		return tt.compile()
+	}
+
+	c.mu.RLock()
+	m, ok := c.entries[tt]
+	c.mu.RUnlock()
+
+	if ok {
+		c.nHits.Add(1)
+		return m, nil
+	}
+
+	c.nMisses.Add(1)
+
+	// Compile outside the lock; a racing compile of the same term loses.
+	m, err := tt.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prev, ok := c.entries[tt]; ok {
+		return prev, nil
+	}
+
+	if c.maxSz > 0 && len(c.entries) >= c.maxSz {
+		for k := range c.entries {
+			delete(c.entries, k)
+			c.nEvicted.Add(1)
+			break
+		}
+	}
+
+	c.entries[tt] = m
+	return m, nil
+}
+
+// Purge drops all entries; counters are kept.
+func (c *TermCacheT) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+func (c *TermCacheT) Stats() TermCacheStatsT {
+	c.mu.RLock()
+	n := len(c.entries)
+	c.mu.RUnlock()
+
+	return TermCacheStatsT{
+		Hits:    c.nHits.Load(),
+		Misses:  c.nMisses.Load(),
+		Evicted: c.nEvicted.Load(),
+		Entries: n,
+	}
+}
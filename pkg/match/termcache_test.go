@@ -0,0 +1,90 @@
+package match
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTermCache(t *testing.T) {
+	var (
+		c  = NewTermCache(2)
+		sl = NewScanLine()
+		re = TermT{Type: TermRegex, Value: `err(or)?`}
+	)
+
+	m1, err := c.Get(re)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	m2, _ := c.Get(re)
+	if !m1(sl.ResetLine(1, "an error")) || !m2(sl.ResetLine(2, "err")) {
+		t.Errorf("Expected cached matcher to match")
+	}
+
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 1 || s.Entries != 1 {
+		t.Errorf("Expected 1 hit, 1 miss, 1 entry; got %+v", s)
+	}
+
+	// Stateful terms are not cached.
+	c.Get(TermT{Type: TermJqJsonDiff, Value: ".a"})
+	if s := c.Stats(); s.Entries != 1 || s.Misses != 1 {
+		t.Errorf("Expected diff term uncached, got %+v", s)
+	}
+
+	// Errors are not cached.
+	if _, err := c.Get(TermT{Type: TermRegex, Value: "("}); !errors.Is(err, ErrTermCompile) {
+		t.Errorf("Expected %v, got %v", ErrTermCompile, err)
+	}
+	if s := c.Stats(); s.Entries != 1 {
+		t.Errorf("Expected failed term uncached, got %+v", s)
+	}
+
+	// Over the bound.
+	c.Get(makeRaw("alpha"))
+	c.Get(makeRaw("beta"))
+	if s := c.Stats(); s.Entries != 2 || s.Evicted != 1 {
+		t.Errorf("Expected 2 entries, 1 evicted; got %+v", s)
+	}
+
+	c.Purge()
+	if s := c.Stats(); s.Entries != 0 || s.Evicted != 1 {
+		t.Errorf("Expected empty cache, got %+v", s)
+	}
+}
+
+func TestTermCacheShared(t *testing.T) {
+	c := NewTermCache(0)
+	defer SetTermCache(SetTermCache(c))
+
+	terms := []TermT{
+		{Type: TermRegex, Value: `alpha\d`},
+		{Type: TermJqJson, Value: `select(.level == "error")`},
+	}
+
+	for range 3 {
+		if _, err := NewMatchSeq(10, terms...); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+
+	if s := c.Stats(); s.Misses != 2 || s.Hits != 4 || s.Entries != 2 {
+		t.Errorf("Expected 2 misses, 4 hits, 2 entries; got %+v", s)
+	}
+}
+
+func TestTermCacheDisabled(t *testing.T) {
+	prev := SetTermCache(nil)
+	defer SetTermCache(prev)
+
+	if TermCache() != nil {
+		t.Fatalf("Expected cache disabled")
+	}
+
+	before := prev.Stats()
+	if _, err := NewMatchSingle(makeRaw("alpha")); err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+	if after := prev.Stats(); after != before {
+		t.Errorf("Expected cache bypassed, got %+v", after)
+	}
+}
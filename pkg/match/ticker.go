@@ -0,0 +1,122 @@
+package match
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker drives a Matcher from the wall clock for live streams.
+//
+// Matchers only advance their clock on a scanned entry or an explicit Eval,
+// so a hit waiting on a reset window is held for as long as the stream is
+// silent.  Ticker evaluates the wrapped matcher at the current time every
+// interval, sending any hits to out.  Entries must then be scanned through
+// the Ticker, which serializes access to the matcher; hits from Scan are
+// returned to the caller as usual.
+//
+// An entry stamped earlier than a tick's clock is late to the matcher; use
+// WithTickerLag to allow for clock skew and delivery delay.
+
+type Ticker struct {
+	mux  sync.Mutex
+	m    Matcher
+	out  chan<- Hits
+	lag  time.Duration
+	now  func() time.Time
+	stop chan struct{}
+	done chan struct{}
+}
+
+type TickerOptT func(*Ticker)
+
+// WithTickerLag evaluates each tick at the current time less lag.
+func WithTickerLag(lag time.Duration) TickerOptT {
+	return func(t *Ticker) {
+		t.lag = lag
+	}
+}
+
+// WithTickerNow replaces time.Now as the source of the current time.
+func WithTickerNow(now func() time.Time) TickerOptT {
+	return func(t *Ticker) {
+		t.now = now
+	}
+}
+
+// NewTicker starts evaluating m every interval; call Stop to end.
+func NewTicker(m Matcher, interval time.Duration, out chan<- Hits, opts ...TickerOptT) *Ticker {
+	t := &Ticker{
+		m:    m,
+		out:  out,
+		now:  time.Now,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	go t.run(time.NewTicker(interval))
+	return t
+}
+
+func (t *Ticker) run(ticker *time.Ticker) {
+	defer close(t.done)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+
+		hits := t.Eval(t.now().Add(-t.lag).UnixNano())
+		if hits.Cnt == 0 {
+			continue
+		}
+
+		select {
+		case t.out <- hits:
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// Stop ends the ticks and waits for the driver to exit.  Hits from a tick
+// still waiting to be received on out are discarded.
+func (t *Ticker) Stop() {
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+	<-t.done
+}
+
+func (t *Ticker) Scan(e *ScanLine) Hits {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.m.Scan(e)
+}
+
+func (t *Ticker) Eval(clock int64) Hits {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.m.Eval(clock)
+}
+
+func (t *Ticker) GarbageCollect(clock int64) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.m.GarbageCollect(clock)
+}
+
+// Stats returns the counters of the wrapped matcher.
+func (t *Ticker) Stats() StatsT {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return statsOf(t.m)
+}
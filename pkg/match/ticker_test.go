@@ -0,0 +1,81 @@
+package match
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTicker(t *testing.T) {
+
+	iseq, err := NewInverseSeq(10, makeTermsA("alpha", "beta"), []ResetT{{Term: makeRaw("reset"), Window: 5, Absolute: true, Anchor: 1}})
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	var (
+		now  atomic.Int64
+		out  = make(chan Hits, 1)
+		sl   = NewScanLine()
+		tick = NewTicker(iseq, time.Millisecond, out,
+			WithTickerLag(10),
+			WithTickerNow(func() time.Time { return time.Unix(0, now.Load()) }),
+		)
+	)
+	defer tick.Stop()
+
+	now.Store(100)
+
+	if hits := tick.Scan(sl.ResetLine(100, "alpha")); hits.Cnt != 0 {
+		t.Errorf("Expected no hits on scan, got %v", hits)
+	}
+	if hits := tick.Scan(sl.ResetLine(101, "beta")); hits.Cnt != 0 {
+		t.Errorf("Expected no hits on scan, got %v", hits)
+	}
+
+	// The reset window closes at 106; within the lag nothing fires.
+	now.Store(115)
+	select {
+	case hits := <-out:
+		t.Fatalf("Expected no hits before the window closes, got %v", hits)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Silence past the window fires without another entry.
+	now.Store(117)
+	select {
+	case hits := <-out:
+		if hits.Cnt != 1 {
+			t.Errorf("Expected 1 hit, got %v", hits)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a hit from the ticker")
+	}
+}
+
+func TestTickerStop(t *testing.T) {
+
+	iseq, err := NewInverseSeq(10, makeTermsA("alpha"), []ResetT{{Term: makeRaw("reset"), Window: 5, Absolute: true}})
+	if err != nil {
+		t.Fatalf("Expected err == nil, got %v", err)
+	}
+
+	// Nobody receives; Stop must not hang on the blocked send.
+	tick := NewTicker(iseq, time.Millisecond, make(chan Hits))
+	tick.Scan(NewScanLine().ResetLine(1, "alpha"))
+
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		tick.Stop()
+		tick.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Stop to return")
+	}
+}
@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrRuleDupe = errors.New("rule already registered")
+	ErrRuleId   = errors.New("rule label is required")
+)
+
+const labelRule = "rule"
+
+// Collector exposes the counters of registered matchers as a prometheus.Collector,
+// labelled by rule.  Counters are read from the matcher Stats at scrape time, so
+// the scan path pays only for a mutex and, if sampled, the latency histogram.
+//
+// Matchers are not safe for concurrent use, so a registered matcher must only
+// be driven through the wrapper returned by Wrap; the wrapper serializes scans
+// with scrapes.
+
+type Collector struct {
+	mu      sync.Mutex
+	rules   map[string]*Matcher
+	sample  int
+	latency *prometheus.HistogramVec
+
+	scanned  *prometheus.Desc
+	matched  *prometheus.Desc
+	hits     *prometheus.Desc
+	resets   *prometheus.Desc
+	dropped  *prometheus.Desc
+	buffered *prometheus.Desc
+}
+
+func NewCollector(opts ...OptT) *Collector {
+	o := parseOpts(opts)
+
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, "", name),
+			help,
+			append([]string{labelRule}, labels...),
+			nil,
+		)
+	}
+
+	return &Collector{
+		rules:  make(map[string]*Matcher),
+		sample: o.sample,
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "scan_seconds",
+			Help:      "Latency of a matcher scan.",
+			Buckets:   o.buckets,
+		}, []string{labelRule}),
+		scanned:  desc("scanned_total", "Lines scanned."),
+		matched:  desc("matched_total", "Lines matched per term.", "term"),
+		hits:     desc("hits_total", "Hits emitted."),
+		resets:   desc("resets_total", "Reset lines recorded."),
+		dropped:  desc("dropped_total", "Out of order lines dropped."),
+		buffered: desc("buffered", "Entries currently held."),
+	}
+}
+
+// Wrap registers m under the rule label and returns the matcher to drive in its stead.
+// An empty rule defaults to the matcher's WithID.
+func (c *Collector) Wrap(rule string, m match.Matcher) (*Matcher, error) {
+	if sm, ok := m.(match.StatsI); ok && rule == "" {
+		rule = sm.Stats().ID
+	}
+	if rule == "" {
+		return nil, ErrRuleId
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.rules[rule]; ok {
+		return nil, ErrRuleDupe
+	}
+
+	w := &Matcher{
+		m:       m,
+		sample:  c.sample,
+		latency: c.latency.WithLabelValues(rule),
+	}
+	c.rules[rule] = w
+	return w, nil
+}
+
+// Remove unregisters the rule and drops its series.
+func (c *Collector) Remove(rule string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.rules[rule]; !ok {
+		return
+	}
+	delete(c.rules, rule)
+	c.latency.DeleteLabelValues(rule)
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.scanned
+	ch <- c.matched
+	ch <- c.hits
+	ch <- c.resets
+	ch <- c.dropped
+	ch <- c.buffered
+	c.latency.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for rule, w := range c.rules {
+		s, ok := w.stats()
+		if !ok {
+			continue
+		}
+
+		counter := func(desc *prometheus.Desc, v uint64, labels ...string) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(v), append([]string{rule}, labels...)...)
+		}
+
+		counter(c.scanned, s.Scanned)
+		counter(c.hits, s.Hits)
+		counter(c.resets, s.Resets)
+		counter(c.dropped, s.Dropped)
+		for i, n := range s.Matched {
+			counter(c.matched, n, strconv.Itoa(i))
+		}
+		ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(s.Buffered), rule)
+	}
+
+	c.latency.Collect(ch)
+}
+
+// Matcher wraps a registered matcher, timing its scans and guarding it
+// against concurrent scrapes.
+
+type Matcher struct {
+	mu      sync.Mutex
+	m       match.Matcher
+	sample  int
+	nScan   int
+	latency prometheus.Observer
+}
+
+func (r *Matcher) Scan(e *match.ScanLine) match.Hits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nScan += 1; r.nScan < r.sample {
+		return r.m.Scan(e)
+	}
+	r.nScan = 0
+
+	start := time.Now()
+	hits := r.m.Scan(e)
+	r.latency.Observe(time.Since(start).Seconds())
+	return hits
+}
+
+func (r *Matcher) Eval(clock int64) match.Hits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.m.Eval(clock)
+}
+
+func (r *Matcher) GarbageCollect(clock int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m.GarbageCollect(clock)
+}
+
+// Stats returns the counters of the wrapped matcher.
+func (r *Matcher) Stats() match.StatsT {
+	s, _ := r.stats()
+	return s
+}
+
+func (r *Matcher) stats() (match.StatsT, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sm, ok := r.m.(match.StatsI)
+	if !ok {
+		return match.StatsT{}, false
+	}
+	return sm.Stats(), true
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCollector(t *testing.T) {
+	sm, err := match.NewMatchSeq(10, match.TermT{Type: match.TermRaw, Value: "alpha"}, match.TermT{Type: match.TermRaw, Value: "beta"})
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	c := NewCollector()
+	m, err := c.Wrap("crash", sm)
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	sl := match.NewScanLine()
+	for i, line := range []string{"alpha", "gamma", "beta"} {
+		m.Scan(sl.ResetLine(int64(i+1), line))
+	}
+
+	exp := `
+# HELP logmatch_hits_total Hits emitted.
+# TYPE logmatch_hits_total counter
+logmatch_hits_total{rule="crash"} 1
+# HELP logmatch_matched_total Lines matched per term.
+# TYPE logmatch_matched_total counter
+logmatch_matched_total{rule="crash",term="0"} 1
+logmatch_matched_total{rule="crash",term="1"} 1
+# HELP logmatch_scanned_total Lines scanned.
+# TYPE logmatch_scanned_total counter
+logmatch_scanned_total{rule="crash"} 3
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(exp), "logmatch_hits_total", "logmatch_matched_total", "logmatch_scanned_total"); err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(c, "logmatch_scan_seconds"); n != 1 {
+		t.Errorf("Expected 1 latency series, got %d", n)
+	}
+
+	// Registers cleanly.
+	if err := prometheus.NewPedanticRegistry().Register(c); err != nil {
+		t.Errorf("Expected nil error, got: %v", err)
+	}
+
+	c.Remove("crash")
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("Expected no series after remove, got %d", n)
+	}
+}
+
+func TestCollectorWrapFail(t *testing.T) {
+	var (
+		c     = NewCollector()
+		sm, _ = match.NewMatchSingle(match.TermT{Type: match.TermRaw, Value: "alpha"})
+	)
+
+	if _, err := c.Wrap("", sm); err != ErrRuleId {
+		t.Errorf("Expected %v, got %v", ErrRuleId, err)
+	}
+	if _, err := c.Wrap("a", sm); err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+	if _, err := c.Wrap("a", sm); err != ErrRuleDupe {
+		t.Errorf("Expected %v, got %v", ErrRuleDupe, err)
+	}
+}
+
+func TestCollectorWrapID(t *testing.T) {
+	var (
+		c     = NewCollector()
+		sm, _ = match.NewMatchSingleOpts(match.TermT{Type: match.TermRaw, Value: "alpha"}, match.WithID("rule-1"))
+	)
+
+	if _, err := c.Wrap("", sm); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if _, err := c.Wrap("rule-1", sm); err != ErrRuleDupe {
+		t.Errorf("Expected %v, got %v", ErrRuleDupe, err)
+	}
+}
+
+func TestSample(t *testing.T) {
+	var (
+		c     = NewCollector(WithSample(3))
+		sm, _ = match.NewMatchSingle(match.TermT{Type: match.TermRaw, Value: "alpha"})
+		sl    = match.NewScanLine()
+	)
+
+	m, _ := c.Wrap("a", sm)
+	for i := range 7 {
+		m.Scan(sl.ResetLine(int64(i+1), "alpha"))
+	}
+
+	if s := m.Stats(); s.Scanned != 7 {
+		t.Errorf("Expected 7 scanned, got %d", s.Scanned)
+	}
+
+	var (
+		h   = c.latency.WithLabelValues("a").(prometheus.Metric)
+		out dto.Metric
+	)
+	if err := h.Write(&out); err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	if n := out.GetHistogram().GetSampleCount(); n != 2 {
+		t.Errorf("Expected 2 samples, got %d", n)
+	}
+}
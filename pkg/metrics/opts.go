@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defNamespace = "logmatch"
+
+type OptT func(*optsT)
+
+type optsT struct {
+	namespace string
+	buckets   []float64
+	sample    int
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		namespace: defNamespace,
+		buckets:   prometheus.ExponentialBuckets(100e-9, 4, 10), // 100ns to ~26ms
+		sample:    1,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithNamespace sets the metric namespace; defaults to "logmatch".
+func WithNamespace(ns string) OptT {
+	return func(o *optsT) {
+		o.namespace = ns
+	}
+}
+
+// WithBuckets sets the scan latency histogram buckets, in seconds.
+func WithBuckets(buckets []float64) OptT {
+	return func(o *optsT) {
+		o.buckets = buckets
+	}
+}
+
+// WithSample times one in every n scans, to reduce the cost of the latency
+// histogram on hot rules.  Counters are unaffected.  Values below one are ignored.
+func WithSample(n int) OptT {
+	return func(o *optsT) {
+		if n > 0 {
+			o.sample = n
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TermCacheCollector exposes the counters of a match.TermCacheT.
+
+type TermCacheCollector struct {
+	c *match.TermCacheT
+
+	hits    *prometheus.Desc
+	misses  *prometheus.Desc
+	evicted *prometheus.Desc
+	entries *prometheus.Desc
+}
+
+// NewTermCacheCollector collects c; a nil c collects the match.TermCache at
+// the time of each scrape.
+func NewTermCacheCollector(c *match.TermCacheT, opts ...OptT) *TermCacheCollector {
+	o := parseOpts(opts)
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "term_cache", name), help, nil, nil)
+	}
+
+	return &TermCacheCollector{
+		c:       c,
+		hits:    desc("hits_total", "Compiled terms served from the cache."),
+		misses:  desc("misses_total", "Terms compiled on a cache miss."),
+		evicted: desc("evicted_total", "Compiled terms evicted over the cache bound."),
+		entries: desc("entries", "Compiled terms held."),
+	}
+}
+
+func (r *TermCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.hits
+	ch <- r.misses
+	ch <- r.evicted
+	ch <- r.entries
+}
+
+func (r *TermCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c := r.c
+	if c == nil {
+		if c = match.TermCache(); c == nil {
+			return
+		}
+	}
+
+	s := c.Stats()
+	ch <- prometheus.MustNewConstMetric(r.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(r.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(r.evicted, prometheus.CounterValue, float64(s.Evicted))
+	ch <- prometheus.MustNewConstMetric(r.entries, prometheus.GaugeValue, float64(s.Entries))
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTermCacheCollector(t *testing.T) {
+	var (
+		tc   = match.NewTermCache(0)
+		term = match.TermT{Type: match.TermRegex, Value: `alpha\d`}
+	)
+
+	tc.Get(term)
+	tc.Get(term)
+
+	exp := `
+# HELP logmatch_term_cache_entries Compiled terms held.
+# TYPE logmatch_term_cache_entries gauge
+logmatch_term_cache_entries 1
+# HELP logmatch_term_cache_evicted_total Compiled terms evicted over the cache bound.
+# TYPE logmatch_term_cache_evicted_total counter
+logmatch_term_cache_evicted_total 0
+# HELP logmatch_term_cache_hits_total Compiled terms served from the cache.
+# TYPE logmatch_term_cache_hits_total counter
+logmatch_term_cache_hits_total 1
+# HELP logmatch_term_cache_misses_total Terms compiled on a cache miss.
+# TYPE logmatch_term_cache_misses_total counter
+logmatch_term_cache_misses_total 1
+`
+	if err := testutil.CollectAndCompare(NewTermCacheCollector(tc), strings.NewReader(exp)); err != nil {
+		t.Error(err)
+	}
+
+	// Disabled default cache; nothing collected.
+	defer match.SetTermCache(match.SetTermCache(nil))
+	if n := testutil.CollectAndCount(NewTermCacheCollector(nil)); n != 0 {
+		t.Errorf("Expected no series, got %d", n)
+	}
+}
@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scanner"
+)
+
+var ErrScheme = errors.New("no opener for scheme")
+
+const sampleSize = 64 << 10
+
+// Open the archive at location, a path or a URL, decompressing it.
+func (r *Replayer) open(ctx context.Context, location string) (io.ReadCloser, error) {
+	var (
+		rc  io.ReadCloser
+		err error
+	)
+
+	u, uerr := url.Parse(location)
+	switch {
+	case uerr != nil || u.Scheme == "" || len(u.Scheme) == 1: // A path; or a Windows drive.
+		rc, err = os.Open(location)
+	case r.o.openers[u.Scheme] != nil:
+		rc, err = r.o.openers[u.Scheme](ctx, location)
+	case u.Scheme == "http" || u.Scheme == "https":
+		rc, err = openHTTP(ctx, location)
+	case u.Scheme == "file":
+		rc, err = os.Open(u.Path)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrScheme, u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(rc)
+}
+
+func openHTTP(ctx context.Context, location string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("get %s: %s", location, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+type readCloserT struct {
+	io.Reader
+	io.Closer
+}
+
+// Decode rc if compressed; see scanner.Decompress.
+func decompress(rc io.ReadCloser) (io.ReadCloser, error) {
+	rdr, err := scanner.Decompress(rc)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return readCloserT{Reader: rdr, Closer: rc}, nil
+}
+
+// Detect the format from a sample at the head of rdr, without consuming it.
+func detect(rdr io.Reader) (format.FactoryI, io.Reader, error) {
+	br := bufio.NewReaderSize(rdr, sampleSize)
+
+	sample, err := br.Peek(sampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+
+	res, err := format.DetectSample(bytes.NewReader(sample))
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Factory, br, nil
+}
@@ -0,0 +1,74 @@
+package replay
+
+import (
+	"context"
+	"io"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+)
+
+// OpenerFuncT opens the archive at a location, such as an object store key.
+type OpenerFuncT func(ctx context.Context, location string) (io.ReadCloser, error)
+
+type OptT func(*optsT)
+
+type optsT struct {
+	factory format.FactoryI
+	tick    int64
+	drain   int64
+	speed   float64
+	openers map[string]OpenerFuncT
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithFactory parses the archive with factory rather than detecting its format.
+func WithFactory(factory format.FactoryI) OptT {
+	return func(o *optsT) {
+		o.factory = factory
+	}
+}
+
+// WithTick evaluates the rules each time event time crosses a multiple of
+// tick nanoseconds, as a live pipeline evaluating on a ticker would.  Across a
+// gap in the archive, only the last tick before the next entry is evaluated.
+func WithTick(tick int64) OptT {
+	return func(o *optsT) {
+		o.tick = max(tick, 0)
+	}
+}
+
+// WithDrain evaluates the rules at drain nanoseconds past the last entry once
+// the archive ends, so that hits waiting on the clock fire.
+func WithDrain(drain int64) OptT {
+	return func(o *optsT) {
+		o.drain = max(drain, 0)
+	}
+}
+
+// WithSpeed paces the replay at speed times event time; 60 replays an hour in
+// a minute.  By default the replay runs as fast as it can.
+func WithSpeed(speed float64) OptT {
+	return func(o *optsT) {
+		o.speed = max(speed, 0)
+	}
+}
+
+// WithOpener opens locations with the given URL scheme, such as "s3", with fn.
+// Plain paths and http or https URLs are opened without one.
+func WithOpener(scheme string, fn OpenerFuncT) OptT {
+	return func(o *optsT) {
+		if o.openers == nil {
+			o.openers = make(map[string]OpenerFuncT)
+		}
+		o.openers[scheme] = fn
+	}
+}
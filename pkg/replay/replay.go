@@ -0,0 +1,213 @@
+// Package replay runs rules over archived logs for back-testing.
+//
+// Windows and resets work in event time, the timestamps of the entries, so a
+// replay behaves as the live pipeline would have, only faster.
+package replay
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/rules"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+	"github.com/prequel-dev/prequel-logmatch/pkg/stream"
+)
+
+// HitT is a set of hits of a rule, annotated with where the replay was when
+// they fired.
+type HitT struct {
+	Rule      string
+	Hits      match.Hits
+	EventTime int64         // Event time the hits fired at.
+	Position  int64         // Line number of the last entry scanned; zero before the first.
+	Elapsed   time.Duration // Wall time since the replay started.
+}
+
+// HitFuncT receives hits as they fire.  Return true to stop the replay.
+type HitFuncT func(HitT) bool
+
+// StatsT summarizes a replay.
+type StatsT struct {
+	Entries uint64
+	Hits    uint64 // Hit sets passed to the callback.
+	Start   int64  // Event time of the first entry.
+	End     int64  // Event time of the last entry.
+	Elapsed time.Duration
+}
+
+// Replayer replays archives through a rule set.  Each replay starts from
+// fresh matchers.
+type Replayer struct {
+	rules []rules.RuleT
+	o     optsT
+}
+
+// New compiles the rule document, as rules.Compile.
+func New(doc []byte, opts ...OptT) (*Replayer, error) {
+	rs, err := rules.Compile(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{rules: rs, o: parseOpts(opts)}, nil
+}
+
+// RunFile replays the archive at location: a path, an http or https URL, or
+// a URL with a scheme given to WithOpener.  A compressed archive is decoded,
+// as by scanner.Decompress, and the format detected unless set with
+// WithFactory.
+func (r *Replayer) RunFile(ctx context.Context, location string, cb HitFuncT) (StatsT, error) {
+	rc, err := r.open(ctx, location)
+	if err != nil {
+		return StatsT{}, err
+	}
+	defer rc.Close()
+
+	var rdr io.Reader = rc
+
+	factory := r.o.factory
+	if factory == nil {
+		if factory, rdr, err = detect(rc); err != nil {
+			return StatsT{}, err
+		}
+	}
+
+	return r.Run(ctx, stream.NewReaderSource(rdr, factory, stream.WithName(location)), cb)
+}
+
+// Run replays the entries of src until it ends, ctx is done, or cb requests a
+// stop.
+func (r *Replayer) Run(ctx context.Context, src stream.SourceI, cb HitFuncT) (StatsT, error) {
+	ids := make([]string, 0, len(r.rules))
+	matchers := make([]match.Matcher, 0, len(r.rules))
+	for _, rule := range r.rules {
+		m, err := rule.New()
+		if err != nil {
+			return StatsT{}, err
+		}
+		ids = append(ids, rule.Id)
+		matchers = append(matchers, m)
+	}
+
+	sc, err := scan.New(nil, matchers)
+	if err != nil {
+		return StatsT{}, err
+	}
+
+	run := runT{
+		ids:   ids,
+		sc:    sc,
+		cb:    cb,
+		o:     r.o,
+		start: time.Now(),
+	}
+	err = run.loop(ctx, src)
+	run.stats.Elapsed = time.Since(run.start)
+	return run.stats, err
+}
+
+type runT struct {
+	ids      []string
+	sc       *scan.Scanner
+	cb       HitFuncT
+	o        optsT
+	start    time.Time
+	position int64
+	nextTick int64
+	stats    StatsT
+}
+
+func (r *runT) loop(ctx context.Context, src stream.SourceI) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		e, err := src.Next()
+		switch {
+		case err == io.EOF:
+			return r.drain()
+		case err != nil:
+			return err
+		}
+
+		if err := r.pace(ctx, e.Timestamp); err != nil {
+			return err
+		}
+
+		if r.stats.Entries == 0 {
+			r.stats.Start = e.Timestamp
+		}
+		r.stats.Entries += 1
+		r.stats.End = max(r.stats.End, e.Timestamp)
+
+		if r.tick(e.Timestamp) {
+			return nil
+		}
+
+		r.position = e.LineNo
+		if r.sc.ScanEntry(e, r.emit) {
+			return nil
+		}
+	}
+}
+
+// Evaluate the last tick boundary at or before stamp that has not been.
+func (r *runT) tick(stamp int64) bool {
+	if r.o.tick == 0 {
+		return false
+	}
+
+	boundary := stamp - stamp%r.o.tick
+	if r.nextTick == 0 {
+		r.nextTick = boundary + r.o.tick
+		return false
+	}
+	if boundary < r.nextTick {
+		return false
+	}
+
+	r.nextTick = boundary + r.o.tick
+	return r.sc.Eval(boundary, r.emit)
+}
+
+func (r *runT) drain() error {
+	if r.o.drain > 0 && r.stats.Entries > 0 {
+		r.sc.Eval(r.stats.End+r.o.drain, r.emit)
+	}
+	return nil
+}
+
+// Sleep until stamp is due at the WithSpeed pace.
+func (r *runT) pace(ctx context.Context, stamp int64) error {
+	if r.o.speed == 0 || r.stats.Entries == 0 {
+		return nil
+	}
+
+	due := time.Duration(float64(stamp-r.stats.Start) / r.o.speed)
+	wait := due - time.Since(r.start)
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *runT) emit(h scan.HitT) bool {
+	r.stats.Hits += 1
+	return r.cb(HitT{
+		Rule:      r.ids[h.Idx],
+		Hits:      h.Hits,
+		EventTime: h.Hits.FireStamp,
+		Position:  r.position,
+		Elapsed:   time.Since(r.start),
+	})
+}
@@ -0,0 +1,208 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const doc = `
+rules:
+  - id: pair
+    window: 10s
+    terms:
+      - alpha
+      - beta
+  - id: unrecovered
+    window: 1s
+    terms:
+      - alpha
+    resets:
+      - term: {raw: recovered}
+        window: 5s
+        absolute: true
+`
+
+const archive = `2024-01-01T00:00:01Z alpha
+2024-01-01T00:00:02Z beta
+2024-01-01T00:00:03Z recovered
+2024-01-01T00:00:30Z alpha
+`
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+
+func sec(n int64) int64 {
+	return base + n*int64(time.Second)
+}
+
+func replay(t *testing.T, r *Replayer, location string) ([]HitT, StatsT) {
+	t.Helper()
+	var hits []HitT
+	stats, err := r.RunFile(context.Background(), location, func(h HitT) bool {
+		hits = append(hits, h)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	return hits, stats
+}
+
+func write(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestReplay(t *testing.T) {
+	r, err := New([]byte(doc))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	hits, stats := replay(t, r, write(t, "app.log", []byte(archive)))
+
+	// The second alpha is pending on its reset window when the archive ends.
+	if len(hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %+v", hits)
+	}
+	if h := hits[0]; h.Rule != "pair" || h.EventTime != sec(2) || h.Position != 2 {
+		t.Errorf("Expected pair at 2s, line 2; got %+v", h)
+	}
+
+	exp := StatsT{Entries: 4, Hits: 1, Start: sec(1), End: sec(30)}
+	stats.Elapsed = 0
+	if stats != exp {
+		t.Errorf("Expected %+v, got %+v", exp, stats)
+	}
+
+	// Each run starts fresh.
+	if again, _ := replay(t, r, write(t, "app.log", []byte(archive))); len(again) != 1 {
+		t.Errorf("Expected 1 hit on a second run, got %d", len(again))
+	}
+}
+
+func TestReplayDrainTick(t *testing.T) {
+	path := write(t, "app.log.gz", gzipped(archive))
+
+	// Drain fires the pending alpha, 5s after it.
+	r, _ := New([]byte(doc), WithDrain(int64(10*time.Second)))
+	hits, _ := replay(t, r, path)
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %+v", hits)
+	}
+	if h := hits[1]; h.Rule != "unrecovered" || h.EventTime != sec(40) || h.Position != 4 {
+		t.Errorf("Expected unrecovered at 40s, line 4; got %+v", h)
+	}
+
+	// Ticks do not change the outcome here.
+	r, _ = New([]byte(doc), WithTick(int64(20*time.Second)), WithDrain(int64(10*time.Second)))
+	hits, _ = replay(t, r, path)
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %+v", hits)
+	}
+}
+
+func TestReplayTickFires(t *testing.T) {
+	const gap = `2024-01-01T00:00:01Z alpha
+2024-01-01T00:00:25Z gamma
+`
+	path := write(t, "gap.log", []byte(gap))
+
+	// Without ticks, the reset window is found clear on the next entry.
+	r, _ := New([]byte(doc))
+	hits, _ := replay(t, r, path)
+	if len(hits) != 1 || hits[0].EventTime != sec(25) {
+		t.Fatalf("Expected a hit at 25s, got %+v", hits)
+	}
+
+	// With ticks, at the last boundary before it.
+	r, _ = New([]byte(doc), WithTick(int64(10*time.Second)))
+	hits, _ = replay(t, r, path)
+	if len(hits) != 1 || hits[0].EventTime != sec(20) || hits[0].Position != 1 {
+		t.Fatalf("Expected a hit at 20s, line 1; got %+v", hits)
+	}
+}
+
+func TestReplayOpeners(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/app.log.gz" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(gzipped(archive))
+	}))
+	defer srv.Close()
+
+	r, _ := New([]byte(doc))
+	if hits, _ := replay(t, r, srv.URL+"/app.log.gz"); len(hits) != 1 {
+		t.Errorf("Expected 1 hit over http, got %d", len(hits))
+	}
+	if _, err := r.RunFile(context.Background(), srv.URL+"/missing", nil); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	var got string
+	r, _ = New([]byte(doc), WithOpener("s3", func(ctx context.Context, location string) (io.ReadCloser, error) {
+		got = location
+		return io.NopCloser(strings.NewReader(archive)), nil
+	}))
+	if hits, _ := replay(t, r, "s3://bucket/incident/app.log"); len(hits) != 1 || got != "s3://bucket/incident/app.log" {
+		t.Errorf("Expected 1 hit from the opener, got %d from %q", len(hits), got)
+	}
+
+	if _, err := r.RunFile(context.Background(), "gs://bucket/app.log", nil); !errors.Is(err, ErrScheme) {
+		t.Errorf("Expected %v, got %v", ErrScheme, err)
+	}
+}
+
+func TestReplaySpeedStop(t *testing.T) {
+	const paced = `2024-01-01T00:00:00Z alpha
+2024-01-01T00:00:01Z beta
+2024-01-01T00:00:01Z alpha
+2024-01-01T00:00:01Z beta
+`
+	path := write(t, "paced.log", []byte(paced))
+
+	// One second of event time at 20x is 50ms.
+	r, _ := New([]byte(doc), WithSpeed(20))
+	var n int
+	stats, err := r.RunFile(context.Background(), path, func(h HitT) bool {
+		n++
+		return true
+	})
+	switch {
+	case err != nil:
+		t.Fatalf("Expected nil error, got %v", err)
+	case n != 1 || stats.Entries != 2:
+		t.Errorf("Expected a stop on the first hit, got %d hits and %+v", n, stats)
+	case stats.Elapsed < 50*time.Millisecond:
+		t.Errorf("Expected a paced replay, took %v", stats.Elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.RunFile(ctx, path, func(HitT) bool { return false }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
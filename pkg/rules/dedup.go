@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"sync"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+// FamilyFuncT maps a rule id to its family.
+type FamilyFuncT func(rule string) string
+
+// Families maps the id of each rule to the Family of its definition, or to
+// the id itself where the definition names none.
+func Families(rs []RuleT) FamilyFuncT {
+	fams := make(map[string]string, len(rs))
+	for _, rule := range rs {
+		if rule.Def.Family != "" {
+			fams[rule.Id] = rule.Def.Family
+		}
+	}
+
+	return func(rule string) string {
+		if f, ok := fams[rule]; ok {
+			return f
+		}
+		return rule
+	}
+}
+
+// Dedup suppresses duplicate hits from versions of a rule run side by side,
+// such as during a rollout.  Hits are duplicates if their rules are of the same
+// family, and they share the match window and the anchor line, being the first
+// log of the hit.
+//
+// A duplicate is suppressed if it ends within horizon of the latest hit seen;
+// the horizon bounds the hits remembered.  The clock is driven by the end of
+// the hit windows, so a version that fires much later than another is not
+// deduplicated.
+type Dedup struct {
+	mux     sync.Mutex
+	horizon int64
+	family  FamilyFuncT
+	cb      HitFuncT
+	seen    map[dedupKeyT]int64 // End of the window of the first hit.
+	clock   int64
+}
+
+type dedupKeyT struct {
+	family     string
+	start, end int64
+	stamp      int64
+	line       string
+}
+
+// NewDedup passes the hits that are not duplicates on to cb.  A nil family
+// treats every rule as its own family.
+func NewDedup(horizon int64, family FamilyFuncT, cb HitFuncT) *Dedup {
+	if family == nil {
+		family = func(rule string) string { return rule }
+	}
+	return &Dedup{
+		horizon: horizon,
+		family:  family,
+		cb:      cb,
+		seen:    make(map[dedupKeyT]int64),
+	}
+}
+
+// Hits is a HitFuncT; pass it to the Manager, or wherever hits are emitted.
+func (d *Dedup) Hits(rule string, hits match.Hits) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	var (
+		i   int
+		fam = d.family(rule)
+	)
+
+	// Filter visits every hit in order.
+	hits = hits.Filter(func(logs []match.LogEntry) bool {
+		meta, _ := match.MetaOf(hits, i)
+		i++
+
+		key := dedupKeyT{family: fam, start: meta.Start, end: meta.End}
+		if len(logs) > 0 {
+			key.stamp, key.line = logs[0].Timestamp, logs[0].Line
+		}
+
+		d.expire(meta.End)
+		if _, dupe := d.seen[key]; dupe {
+			return false
+		}
+		d.seen[key] = meta.End
+		return true
+	})
+
+	if hits.Cnt > 0 {
+		d.cb(rule, hits)
+	}
+}
+
+// Forget hits ending beyond the horizon of the clock.
+func (d *Dedup) expire(stamp int64) {
+	if stamp <= d.clock {
+		return
+	}
+
+	d.clock = stamp
+	for key, end := range d.seen {
+		if end < d.clock-d.horizon {
+			delete(d.seen, key)
+		}
+	}
+}
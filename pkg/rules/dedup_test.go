@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+const dedupDoc = `
+rules:
+  - id: crashloop-v1
+    family: crashloop
+    window: 10
+    terms: [start, fail]
+  - id: crashloop-v2
+    family: crashloop
+    window: 10
+    terms: [start, regex: "fail|panic"]
+  - id: other
+    window: 10
+    terms: [start, fail]
+`
+
+func TestDedup(t *testing.T) {
+	rs, err := Compile([]byte(dedupDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		got   []string
+		line  = match.NewScanLine()
+		dedup = NewDedup(100, Families(rs), func(rule string, hits match.Hits) {
+			for hit := range hits.Iter() {
+				got = append(got, rule+":"+hit.Logs[1].Line)
+			}
+		})
+		mgr = NewManager(dedup.Hits)
+	)
+	if err := mgr.Replace(rs); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, l := range []string{"start", "fail", "start", "panic"} {
+		mgr.Scan(line.ResetLine(int64(i+1), l))
+	}
+
+	want := []string{"crashloop-v1:fail", "other:fail", "crashloop-v2:panic"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDedupHorizon(t *testing.T) {
+	hit := func(stamp int64) match.Hits {
+		return match.Hits{Cnt: 1, Logs: []match.LogEntry{{Timestamp: stamp, Line: "x"}}, FireStamp: stamp}
+	}
+
+	var got []int64
+	dedup := NewDedup(10, nil, func(rule string, hits match.Hits) {
+		got = append(got, hits.Logs[0].Timestamp)
+	})
+
+	dedup.Hits("a", hit(1))
+	dedup.Hits("a", hit(1))  // Duplicate.
+	dedup.Hits("b", hit(1))  // Another family.
+	dedup.Hits("a", hit(11)) // Within the horizon of 1.
+	dedup.Hits("a", hit(1))  // Still remembered.
+	dedup.Hits("a", hit(12)) // Beyond; 1 is forgotten.
+	dedup.Hits("a", hit(1))
+
+	if want := []int64{1, 1, 11, 12, 1}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
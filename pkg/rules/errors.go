@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoRules   = errors.New("no rules")
+	ErrRuleId    = errors.New("missing rule id")
+	ErrRuleDupe  = errors.New("duplicate rule id")
+	ErrOrder     = errors.New("unknown order")
+	ErrTermSpec  = errors.New("term must specify exactly one type")
+	ErrKeySpec   = errors.New("key must specify exactly one of regex or jq")
+	ErrDuration  = errors.New("invalid duration")
+	ErrParseRule = errors.New("fail parse rules")
+)
+
+// ErrorT is a validation error with the location of the offending field.
+type ErrorT struct {
+	Path   string // YAML path, e.g. $.rules[0].terms[1]
+	Line   int    // 1 based; zero if unknown
+	Column int
+	Err    error
+}
+
+func (e *ErrorT) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s [%d:%d]: %v", e.Path, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ErrorT) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,301 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/itchyny/gojq"
+)
+
+// SeverityT ranks a diagnostic.  An error fails Compile; a warning compiles
+// but is likely not what was meant.
+type SeverityT int
+
+const (
+	SevWarning SeverityT = iota
+	SevError
+)
+
+func (s SeverityT) String() string {
+	if s == SevError {
+		return "error"
+	}
+	return "warning"
+}
+
+// CheckT names the check that raised a diagnostic.
+type CheckT string
+
+const (
+	CheckCompile     CheckT = "compile"        // Compile fails on the rule.
+	CheckWindow      CheckT = "window"         // Window of zero or less.
+	CheckAnchor      CheckT = "anchor"         // Reset anchor beyond the sequence.
+	CheckResetWindow CheckT = "reset-window"   // Reset window that cannot deny.
+	CheckAlwaysMatch CheckT = "always-match"   // Term that matches every line.
+	CheckTooManyTerm CheckT = "too-many-terms" // Terms, with repeats, beyond MaxTerms.
+	CheckJqBool      CheckT = "jq-bool"        // Jq program that never yields a boolean.
+)
+
+// DiagnosticT is a likely mistake in a rule, with the location of the
+// offending field.
+type DiagnosticT struct {
+	Rule     string // Rule id.
+	Path     string // YAML path, e.g. $.rules[0].resets[1].window
+	Line     int    // 1 based; zero if unknown
+	Column   int
+	Severity SeverityT
+	Check    CheckT
+	Msg      string
+}
+
+func (d DiagnosticT) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s [%d:%d]: %s: %s (%s)", d.Path, d.Line, d.Column, d.Severity, d.Msg, d.Check)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", d.Path, d.Severity, d.Msg, d.Check)
+}
+
+// Lint parses a rule document and inspects each rule for likely mistakes.
+// Rules that fail to compile are reported as diagnostics too, so a document
+// may be linted whether or not it compiles.  Only a document that fails to
+// parse returns an error.  Diagnostics are in document order.
+func Lint(data []byte) ([]DiagnosticT, error) {
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseRule, err)
+	}
+
+	var doc DocT
+	if err := yaml.UnmarshalWithOptions(data, &doc, yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseRule, err)
+	}
+
+	l := linterT{compilerT: compilerT{file: file}}
+	for i, def := range doc.Rules {
+		l.lintRule(fmt.Sprintf("$.rules[%d]", i), def)
+	}
+	return l.diags, nil
+}
+
+type linterT struct {
+	compilerT
+	rule  string
+	diags []DiagnosticT
+}
+
+func (l *linterT) report(path string, sev SeverityT, check CheckT, format string, args ...any) {
+	d := DiagnosticT{
+		Rule:     l.rule,
+		Path:     path,
+		Severity: sev,
+		Check:    check,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+	d.Line, d.Column = l.position(path)
+	l.diags = append(l.diags, d)
+}
+
+func (l *linterT) lintRule(path string, def RuleDefT) {
+	l.rule = def.Id
+
+	// Anchor and term limits are checked below, at the offending field.
+	if _, _, err := l.compileRule(path, def); err != nil && !errors.Is(err, match.ErrAnchorRange) && !errors.Is(err, match.ErrTooManyTerms) {
+		var e *ErrorT
+		if errors.As(err, &e) {
+			l.report(e.Path, SevError, CheckCompile, "%v", e.Err)
+		} else {
+			l.report(path, SevError, CheckCompile, "%v", err)
+		}
+	}
+
+	var (
+		window   = int64(def.Window)
+		terms    = make([]match.TermT, 0, len(def.Terms))
+		nAnchors int
+	)
+
+	for j, td := range def.Terms {
+		term, err := td.Term()
+		if err != nil {
+			continue
+		}
+		terms = append(terms, term)
+		nAnchors += max(term.Count, 1)
+		l.lintTerm(fmt.Sprintf("%s.terms[%d]", path, j), term)
+	}
+
+	switch {
+	case window < 0:
+		l.report(path+".window", SevError, CheckWindow, "negative window; the rule can never fire")
+	case window == 0 && nAnchors > 1:
+		l.report(path+".window", SevWarning, CheckWindow, "window of zero; every term must match at the same timestamp")
+	}
+
+	if n := distinctTerms(terms, def.Order == OrderSet); n > match.MaxTerms {
+		l.report(path+".terms", SevError, CheckTooManyTerm, "%d distinct terms, beyond the limit of %d", n, match.MaxTerms)
+	} else if nAnchors > match.MaxTerms {
+		l.report(path+".terms", SevWarning, CheckTooManyTerm, "terms repeat to %d lines a match, beyond the limit of %d distinct terms", nAnchors, match.MaxTerms)
+	}
+
+	for k, rd := range def.Resets {
+		rpath := fmt.Sprintf("%s.resets[%d]", path, k)
+		if term, err := rd.Term.Term(); err == nil {
+			l.lintTerm(rpath+".term", term)
+		}
+		l.lintReset(rpath, rd, window, nAnchors)
+	}
+}
+
+func (l *linterT) lintReset(path string, rd ResetDefT, window int64, nAnchors int) {
+	var (
+		width = int64(rd.Window)
+		slide = int64(rd.Slide)
+	)
+
+	switch {
+	case rd.Anchor < 0, rd.Anchor >= nAnchors:
+		l.report(path+".anchor", SevError, CheckAnchor, "anchor %d beyond the %d terms of the match", rd.Anchor, nAnchors)
+		return
+	case rd.AnchorEnd < 0, rd.AnchorEnd >= nAnchors:
+		l.report(path+".anchorEnd", SevError, CheckAnchor, "anchor end %d beyond the %d terms of the match", rd.AnchorEnd, nAnchors)
+		return
+	case rd.AnchorEnd > 0 && rd.AnchorEnd <= rd.Anchor:
+		l.report(path+".anchorEnd", SevError, CheckAnchor, "anchor end %d does not follow anchor %d", rd.AnchorEnd, rd.Anchor)
+		return
+	}
+
+	// A range spans anchor plus slide to the end plus the window; the end
+	// follows the anchor by at most the rule window.
+	if rd.AnchorEnd > 0 {
+		if slide > max(width, 0)+max(window, 0) {
+			l.report(path+".slide", SevWarning, CheckResetWindow, "slide past the end of the anchor range; the reset can never deny a match")
+		}
+		return
+	}
+
+	// Otherwise the window is clamped to an instant; see match.ResetT.
+	if !rd.Absolute {
+		width += max(window, 0)
+	}
+	if width <= 0 {
+		l.report(path+".window", SevWarning, CheckResetWindow, "reset window shrinks to an instant; it only denies lines stamped exactly at the anchor")
+	}
+}
+
+func (l *linterT) lintTerm(path string, term match.TermT) {
+	switch term.Type {
+	case match.TermRegex:
+		if regexAlways(term.Value) {
+			l.report(path, SevWarning, CheckAlwaysMatch, "regex %q matches every line", term.Value)
+		}
+	case match.TermJqJson, match.TermJqYaml:
+		query, err := gojq.Parse(term.Value)
+		if err != nil {
+			return
+		}
+		switch {
+		case jqLiteral(query):
+			l.report(path, SevWarning, CheckAlwaysMatch, "jq program %q is a constant", term.Value)
+		case jqNeverBool(query):
+			l.report(path, SevWarning, CheckJqBool, "jq program %q never yields a boolean; any result but null or false matches", term.Value)
+		}
+	}
+}
+
+// Number of distinct terms the matcher holds; repeats fold into one term,
+// consecutive repeats only for a sequence.
+func distinctTerms(terms []match.TermT, set bool) (n int) {
+	for i, term := range terms {
+		switch {
+		case set && slices.ContainsFunc(terms[:i], func(t match.TermT) bool { return sameTerm(t, term) }):
+		case !set && i > 0 && sameTerm(terms[i-1], term):
+		default:
+			n++
+		}
+	}
+	return
+}
+
+func sameTerm(a, b match.TermT) bool {
+	a.Count, b.Count = 0, 0
+	return a == b
+}
+
+// Lines of assorted shape; a regex matching all of them likely matches any.
+var regexSamples = []string{"", " ", "x", "0", "The quick brown fox", `{"level":"info","msg":"ok"}`, "-- 12:00:00 --"}
+
+func regexAlways(expr string) bool {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	for _, s := range regexSamples {
+		if !re.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// A program whose every result is a constant.
+func jqLiteral(q *gojq.Query) bool {
+	if q.Op != 0 || q.Term == nil || len(q.Term.SuffixList) > 0 || len(q.FuncDefs) > 0 {
+		return false
+	}
+	switch q.Term.Type {
+	case gojq.TermTypeTrue, gojq.TermTypeNumber, gojq.TermTypeString:
+		return true
+	case gojq.TermTypeQuery:
+		return jqLiteral(q.Term.Query)
+	}
+	return false
+}
+
+// Builtins that never return a boolean.
+var jqNonBool = map[string]bool{
+	"length": true, "utf8bytelength": true, "keys": true, "keys_unsorted": true,
+	"tostring": true, "tonumber": true, "tojson": true, "type": true,
+	"ascii_downcase": true, "ascii_upcase": true, "ltrimstr": true, "rtrimstr": true,
+	"split": true, "join": true, "to_entries": true, "from_entries": true,
+	"floor": true, "ceil": true, "round": true, "sqrt": true, "fabs": true,
+	"now": true, "todate": true, "fromdate": true, "ascii": true, "explode": true, "implode": true,
+}
+
+// Whether the results of q are known never to be booleans.  Unknown shapes,
+// such as paths into the input, may be booleans at run time.  Objects are
+// not flagged, as they match by design, extracting their keys as props.
+func jqNeverBool(q *gojq.Query) bool {
+	switch q.Op {
+	case gojq.OpPipe:
+		return jqNeverBool(q.Right)
+	case gojq.OpComma, gojq.OpAlt:
+		return jqNeverBool(q.Left) && jqNeverBool(q.Right)
+	case gojq.OpAdd, gojq.OpSub, gojq.OpMul, gojq.OpDiv, gojq.OpMod:
+		return true
+	case 0:
+	default:
+		return false
+	}
+
+	t := q.Term
+	if t == nil || len(t.SuffixList) > 0 {
+		return false
+	}
+
+	switch t.Type {
+	case gojq.TermTypeNumber, gojq.TermTypeString, gojq.TermTypeArray,
+		gojq.TermTypeFormat, gojq.TermTypeUnary:
+		return true
+	case gojq.TermTypeFunc:
+		return jqNonBool[t.Func.Name]
+	case gojq.TermTypeQuery:
+		return jqNeverBool(t.Query)
+	}
+	return false
+}
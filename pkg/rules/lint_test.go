@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const lintDoc = `
+rules:
+  - id: clean
+    window: 10s
+    terms:
+      - "Back-off"
+      - regex: "exit code [1-9]"
+      - jq: '.level == "error"'
+      - jq: '{pod: .pod}'
+    resets:
+      - term: {raw: Started}
+        window: 5s
+  - id: sloppy
+    window: 0
+    terms:
+      - regex: ".*"
+      - jq: ".msg | length"
+      - jq: "true"
+    resets:
+      - term: {regex: "x?"}
+        window: -1s
+        absolute: true
+      - term: {raw: ok}
+        anchor: 3
+      - term: {raw: ok}
+        anchor: 0
+        anchorEnd: 2
+        slide: 30s
+        window: 1s
+  - id: broken
+    window: -5s
+    order: sideways
+    terms:
+      - raw: a
+        count: 2000
+`
+
+func TestLint(t *testing.T) {
+	diags, err := Lint([]byte(lintDoc))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	type diagT struct {
+		rule  string
+		path  string
+		sev   SeverityT
+		check CheckT
+	}
+
+	var got []diagT
+	for _, d := range diags {
+		got = append(got, diagT{d.Rule, d.Path, d.Severity, d.Check})
+		if d.Line == 0 || d.Msg == "" {
+			t.Errorf("Expected a position and message, got %v", d)
+		}
+	}
+
+	want := []diagT{
+		{"sloppy", "$.rules[1].terms[0]", SevWarning, CheckAlwaysMatch},
+		{"sloppy", "$.rules[1].terms[1]", SevWarning, CheckJqBool},
+		{"sloppy", "$.rules[1].terms[2]", SevWarning, CheckAlwaysMatch},
+		{"sloppy", "$.rules[1].window", SevWarning, CheckWindow},
+		{"sloppy", "$.rules[1].resets[0].term", SevWarning, CheckAlwaysMatch},
+		{"sloppy", "$.rules[1].resets[0].window", SevWarning, CheckResetWindow},
+		{"sloppy", "$.rules[1].resets[1].anchor", SevError, CheckAnchor},
+		{"sloppy", "$.rules[1].resets[2].slide", SevWarning, CheckResetWindow},
+		{"broken", "$.rules[2].order", SevError, CheckCompile},
+		{"broken", "$.rules[2].window", SevError, CheckWindow},
+		{"broken", "$.rules[2].terms", SevWarning, CheckTooManyTerm},
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected:\n%v\ngot:\n%v", want, got)
+		for _, d := range diags {
+			t.Log(d)
+		}
+	}
+}
+
+func TestLintCompileAgrees(t *testing.T) {
+	diags, err := Lint([]byte(doc))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	for _, d := range diags {
+		if d.Severity == SevError {
+			t.Errorf("Expected no errors on a document that compiles, got %v", d)
+		}
+	}
+
+	if _, err := Lint([]byte("rules: [")); !errors.Is(err, ErrParseRule) {
+		t.Errorf("Expected %v, got %v", ErrParseRule, err)
+	}
+}
+
+func TestLintTerms(t *testing.T) {
+	for expr, always := range map[string]bool{
+		".*":     true,
+		"^":      true,
+		"(a|)":   true,
+		"[a-z]*": true,
+		"^$":     false,
+		`\b`:     false,
+		"error":  false,
+	} {
+		if regexAlways(expr) != always {
+			t.Errorf("%q: expected always match %v", expr, always)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		doc  string
+		want []CheckT
+	}{
+		{"path", `.level`, nil},
+		{"compare", `.a == 1, .b > 2`, nil},
+		{"func", `.a | tostring`, []CheckT{CheckJqBool}},
+		{"arith", `.a + 1`, []CheckT{CheckJqBool}},
+		{"alt", `.a // "none"`, nil},
+		{"string", `"x"`, []CheckT{CheckAlwaysMatch}},
+		{"object", `{a: .a}`, nil},
+		{"select", `select(.a)`, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			diags, err := Lint([]byte("rules:\n  - id: a\n    window: 1s\n    terms:\n      - jq: '" + tc.doc + "'\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []CheckT
+			for _, d := range diags {
+				got = append(got, d.Check)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := DiagnosticT{Path: "$.rules[0].window", Line: 3, Column: 5, Severity: SevWarning, Check: CheckWindow, Msg: "zero"}
+	if s := d.String(); !strings.Contains(s, "[3:5]") || !strings.Contains(s, "warning") || !strings.Contains(s, "(window)") {
+		t.Errorf("Unexpected %q", s)
+	}
+}
@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+// HitFuncT receives the hits of the rule named.
+type HitFuncT func(rule string, hits match.Hits)
+
+// Manager owns a set of named rules and routes every scanned line to each of
+// them.  Rules may be replaced, added or removed while lines are scanned; a
+// rule whose definition is unchanged across a replace keeps its matcher, and
+// so any partial match in flight.
+//
+// A rule that is removed, or replaced by a new definition, is drained: it is
+// evaluated as at the end of a stream, so that hits it holds are emitted
+// rather than lost.  Drained hits are passed to cb, as are those of Scan and
+// Eval, under the lock; cb must not call back into the manager.
+type Manager struct {
+	mux   sync.Mutex
+	rules []managedT
+	cb    HitFuncT
+}
+
+type managedT struct {
+	id  string
+	m   match.Matcher
+	def *RuleDefT // Nil if built by hand; such a rule is never kept.
+}
+
+func NewManager(cb HitFuncT) *Manager {
+	return &Manager{cb: cb}
+}
+
+// Scan passes the line to every rule, in order.
+func (r *Manager) Scan(e *match.ScanLine) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, rule := range r.rules {
+		r.emit(rule.id, rule.m.Scan(e))
+	}
+}
+
+// Eval evaluates every rule at clock.
+func (r *Manager) Eval(clock int64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, rule := range r.rules {
+		r.emit(rule.id, rule.m.Eval(clock))
+	}
+}
+
+// GarbageCollect collects every rule at clock.
+func (r *Manager) GarbageCollect(clock int64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, rule := range r.rules {
+		rule.m.GarbageCollect(clock)
+	}
+}
+
+// Load compiles a rule document and replaces the rules with it.  Should the
+// document fail to compile, the rules are left as they were.
+func (r *Manager) Load(data []byte) error {
+	rs, err := Compile(data)
+	if err != nil {
+		return err
+	}
+	return r.Replace(rs)
+}
+
+// Replace swaps the rules for rs, in order, in one step.  A rule of rs with
+// the Id and Def of a current rule keeps the current matcher, and its state;
+// the matcher of rs is then unused.  Every other current rule is drained.
+func (r *Manager) Replace(rs []RuleT) error {
+	ids := make(map[string]struct{}, len(rs))
+	for _, rule := range rs {
+		if _, dupe := ids[rule.Id]; dupe {
+			return fmt.Errorf("%w: %q", ErrRuleDupe, rule.Id)
+		}
+		ids[rule.Id] = struct{}{}
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	var (
+		next = make([]managedT, 0, len(rs))
+		kept = make(map[int]struct{}, len(rs))
+	)
+
+	for _, rule := range rs {
+		nr := managed(rule)
+		if i := r.find(rule.Id); i >= 0 && sameDef(r.rules[i].def, nr.def) {
+			nr.m = r.rules[i].m
+			kept[i] = struct{}{}
+		}
+		next = append(next, nr)
+	}
+
+	for i, rule := range r.rules {
+		if _, ok := kept[i]; !ok {
+			r.drain(rule)
+		}
+	}
+
+	r.rules = next
+	return nil
+}
+
+// Add appends a rule; it fails with ErrRuleDupe should the Id be in use.
+func (r *Manager) Add(rule RuleT) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.find(rule.Id) >= 0 {
+		return fmt.Errorf("%w: %q", ErrRuleDupe, rule.Id)
+	}
+	r.rules = append(r.rules, managed(rule))
+	return nil
+}
+
+// Remove drains and drops the rule named; returns false if there is none.
+func (r *Manager) Remove(id string) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	i := r.find(id)
+	if i < 0 {
+		return false
+	}
+
+	r.drain(r.rules[i])
+	r.rules = slices.Delete(r.rules, i, i+1)
+	return true
+}
+
+// Rules returns the ids of the rules, in the order lines are routed.
+func (r *Manager) Rules() []string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	ids := make([]string, 0, len(r.rules))
+	for _, rule := range r.rules {
+		ids = append(ids, rule.id)
+	}
+	return ids
+}
+
+func managed(rule RuleT) managedT {
+	nr := managedT{id: rule.Id, m: rule.Matcher}
+	if rule.Def.Id != "" {
+		def := rule.Def
+		nr.def = &def
+	}
+	return nr
+}
+
+func sameDef(a, b *RuleDefT) bool {
+	return a != nil && b != nil && reflect.DeepEqual(*a, *b)
+}
+
+func (r *Manager) find(id string) int {
+	return slices.IndexFunc(r.rules, func(rule managedT) bool { return rule.id == id })
+}
+
+// No further lines reach the rule; evaluate as at the end of a stream.
+func (r *Manager) drain(rule managedT) {
+	r.emit(rule.id, rule.m.Eval(math.MaxInt64))
+}
+
+func (r *Manager) emit(id string, hits match.Hits) {
+	if hits.Cnt > 0 && r.cb != nil {
+		r.cb(id, hits)
+	}
+}
@@ -0,0 +1,139 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+const managerDoc = `
+rules:
+  - id: crashloop
+    window: 10
+    terms: [start, fail]
+  - id: unrecovered
+    window: 10
+    terms: [alpha]
+    resets:
+      - term: {raw: recovered}
+        window: 5
+        absolute: true
+`
+
+// Same crashloop; unrecovered waits longer for recovery.
+const managerDocEdit = `
+rules:
+  - id: crashloop
+    window: 10
+    terms: [start, fail]
+  - id: unrecovered
+    window: 10
+    terms: [alpha]
+    resets:
+      - term: {raw: recovered}
+        window: 50
+        absolute: true
+  - id: single
+    terms: [beta]
+`
+
+func TestManager(t *testing.T) {
+	var (
+		got  []string
+		line = match.NewScanLine()
+		mgr  = NewManager(func(rule string, hits match.Hits) {
+			for range hits.Cnt {
+				got = append(got, rule)
+			}
+		})
+	)
+
+	if err := mgr.Load([]byte(managerDoc)); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	mgr.Scan(line.ResetLine(1, "start"))
+	mgr.Scan(line.ResetLine(2, "alpha"))
+
+	// The changed rule is drained; its held match fires.
+	if err := mgr.Load([]byte(managerDocEdit)); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if want := []string{"unrecovered"}; !slices.Equal(got, want) {
+		t.Fatalf("Expected %v drained, got %v", want, got)
+	}
+
+	// The unchanged rule kept its partial match.
+	got = nil
+	mgr.Scan(line.ResetLine(3, "fail"))
+	mgr.Scan(line.ResetLine(4, "beta"))
+	if want := []string{"crashloop", "single"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if want := []string{"crashloop", "unrecovered", "single"}; !slices.Equal(mgr.Rules(), want) {
+		t.Errorf("Expected rules %v, got %v", want, mgr.Rules())
+	}
+
+	// A bad document leaves the rules as they were.
+	if err := mgr.Load([]byte("rules: []")); !errors.Is(err, ErrNoRules) {
+		t.Errorf("Expected %v, got %v", ErrNoRules, err)
+	}
+	if len(mgr.Rules()) != 3 {
+		t.Errorf("Expected rules kept, got %v", mgr.Rules())
+	}
+}
+
+func TestManagerAddRemove(t *testing.T) {
+	var (
+		got  []string
+		line = match.NewScanLine()
+		mgr  = NewManager(func(rule string, hits match.Hits) { got = append(got, rule) })
+	)
+
+	rs, err := Compile([]byte(managerDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range rs {
+		if err := mgr.Add(rule); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+	if err := mgr.Add(rs[0]); !errors.Is(err, ErrRuleDupe) {
+		t.Errorf("Expected %v, got %v", ErrRuleDupe, err)
+	}
+
+	mgr.Scan(line.ResetLine(1, "alpha"))
+	if !mgr.Remove("unrecovered") {
+		t.Fatal("Expected the rule removed")
+	}
+	if mgr.Remove("unrecovered") {
+		t.Error("Expected nothing left to remove")
+	}
+	if want := []string{"unrecovered"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v drained, got %v", want, got)
+	}
+
+	// A rule built by hand has no definition, so is replaced even if equal.
+	m, err := match.NewMatchSingle(match.TermT{Type: match.TermRaw, Value: "start"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hand := RuleT{Id: "crashloop", Matcher: m}
+
+	if err := mgr.Replace([]RuleT{hand, hand}); !errors.Is(err, ErrRuleDupe) {
+		t.Errorf("Expected %v, got %v", ErrRuleDupe, err)
+	}
+	if err := mgr.Replace([]RuleT{hand}); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	got = nil
+	mgr.Scan(line.ResetLine(2, "start"))
+	if want := []string{"crashloop"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,414 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+const (
+	OrderSeq = "seq"
+	OrderSet = "set"
+)
+
+// DocT is a rule document.  JSON documents are accepted as well, being a subset of YAML.
+//
+//	rules:
+//	  - id: crashloop
+//	    family: crashloop       # optional; versions of a rule share a family, see Dedup
+//	    window: 30s
+//	    order: seq              # seq (default) or set
+//	    maxBuffered: 1000       # optional; bound on asserts held per term
+//	    terms:
+//	      - "Back-off"          # a bare string is a raw term
+//	      - regex: "exit code [1-9]"
+//	      - raw: "Discarding"
+//	        count: 5            # at least 5 in a row
+//	      - raw: "OOMKilled"
+//	        minSeverity: warn   # optional; lines of at least this severity
+//	    resets:
+//	      - term: {raw: "Started"}
+//	        window: 10s
+//	        slide: -1s
+//	        anchor: 1
+//	        anchorEnd: 2        # optional; window spans anchors 1 through 2
+//	        absolute: true
+//	        correlate: req_id   # optional; reset must share this field with the match
+//	    key:                    # optional; match per correlation key
+//	      - regex: "pod=(\\S+)"
+//	      - jq: ".tenant"       # parts are joined, as match.KeyComposite
+type DocT struct {
+	Rules []RuleDefT `yaml:"rules"`
+}
+
+type RuleDefT struct {
+	Id          string      `yaml:"id"`
+	Family      string      `yaml:"family"`
+	Window      DurationT   `yaml:"window"`
+	Order       string      `yaml:"order"`
+	MaxBuffered int         `yaml:"maxBuffered"`
+	Terms       []TermDefT  `yaml:"terms"`
+	Resets      []ResetDefT `yaml:"resets"`
+	Key         []KeyDefT   `yaml:"key"`
+}
+
+// KeyDefT is a part of a correlation key; a map with exactly one of regex, as
+// match.KeyRegex, or jq, as match.KeyJq.
+type KeyDefT struct {
+	Regex  string `yaml:"regex"`
+	JqJson string `yaml:"jq"`
+}
+
+func (k KeyDefT) KeyFn() (match.KeyFn, error) {
+	switch {
+	case (k.Regex == "") == (k.JqJson == ""):
+		return nil, ErrKeySpec
+	case k.Regex != "":
+		return match.KeyRegex(k.Regex)
+	default:
+		return match.KeyJq(k.JqJson)
+	}
+}
+
+type ResetDefT struct {
+	Term      TermDefT  `yaml:"term"`
+	Window    DurationT `yaml:"window"`
+	Slide     DurationT `yaml:"slide"`
+	Anchor    int       `yaml:"anchor"`
+	AnchorEnd int       `yaml:"anchorEnd"`
+	Absolute  bool      `yaml:"absolute"`
+	Correlate string    `yaml:"correlate"`
+}
+
+// TermDefT is either a bare string (raw term), or a map with exactly one term type.
+type TermDefT struct {
+	Raw      string `yaml:"raw"`
+	Regex    string `yaml:"regex"`
+	JqJson   string `yaml:"jq"`
+	JqYaml   string `yaml:"jqYaml"`
+	JqDiff   string `yaml:"jqDiff"`
+	Fuzzy    string `yaml:"fuzzy"`
+	Logfmt   string `yaml:"logfmt"`
+	Numeric  string `yaml:"numeric"`
+	CIDR     string `yaml:"cidr"`
+	Distance int    `yaml:"distance"`
+	NoCase   bool   `yaml:"nocase"`
+	Word     bool   `yaml:"word"`
+	Count    int    `yaml:"count"`
+
+	// MinSeverity names the least severity of a matching line, for example
+	// "warn" or "error"; see entry.ParseSeverity.
+	MinSeverity string `yaml:"minSeverity"`
+}
+
+func (t *TermDefT) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*t = TermDefT{Raw: s}
+		return nil
+	}
+
+	type plain TermDefT
+	return unmarshal((*plain)(t))
+}
+
+func (t TermDefT) Term() (match.TermT, error) {
+	var (
+		cnt  int
+		term match.TermT
+	)
+
+	for _, c := range []struct {
+		ty  match.TermTypeT
+		val string
+	}{
+		{match.TermRaw, t.Raw},
+		{match.TermRegex, t.Regex},
+		{match.TermJqJson, t.JqJson},
+		{match.TermJqYaml, t.JqYaml},
+		{match.TermJqJsonDiff, t.JqDiff},
+		{match.TermFuzzy, t.Fuzzy},
+		{match.TermLogfmt, t.Logfmt},
+		{match.TermNumeric, t.Numeric},
+		{match.TermCIDR, t.CIDR},
+	} {
+		if c.val != "" {
+			cnt += 1
+			term = match.TermT{Type: c.ty, Value: c.val}
+		}
+	}
+
+	if cnt != 1 {
+		return term, ErrTermSpec
+	}
+
+	term.Distance = t.Distance
+	term.Count = t.Count
+	if t.MinSeverity != "" {
+		sev, err := entry.ParseSeverity(t.MinSeverity)
+		if err != nil {
+			return term, fmt.Errorf("%w: %q", err, t.MinSeverity)
+		}
+		term.MinSeverity = sev
+	}
+	if t.NoCase {
+		term.Options |= match.TermOptNoCase
+	}
+	if t.Word {
+		term.Options |= match.TermOptWord
+	}
+	return term, nil
+}
+
+// DurationT accepts either a Go duration string ("10s") or integer nanoseconds.
+type DurationT int64
+
+func (d *DurationT) UnmarshalYAML(unmarshal func(any) error) error {
+	var n int64
+	if err := unmarshal(&n); err == nil {
+		*d = DurationT(n)
+		return nil
+	}
+
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return fmt.Errorf("%w: %w", ErrDuration, err)
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDuration, err)
+	}
+	*d = DurationT(v)
+	return nil
+}
+
+// RuleT is a compiled rule.
+type RuleT struct {
+	Id      string
+	Matcher match.Matcher
+
+	// New builds another matcher for the rule, with state independent of
+	// Matcher; for running the rule over many partitions or keys.
+	New func() (match.Matcher, error)
+
+	// Def is the definition the rule compiled from; see Manager.Replace.
+	Def RuleDefT
+}
+
+// Compile parses a YAML or JSON rule document and builds a matcher per rule.
+//
+// Rules without resets compile to MatchSeq or MatchSet; rules with resets compile
+// to InverseSeq or InverseSet.  Rules with a key run within a KeyedMatcher.  Validation errors are returned as *ErrorT, carrying
+// the path and line of the offending field.
+func Compile(data []byte) ([]RuleT, error) {
+
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseRule, err)
+	}
+
+	var doc DocT
+	if err := yaml.UnmarshalWithOptions(data, &doc, yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseRule, err)
+	}
+
+	c := compilerT{file: file}
+	return c.compile(doc)
+}
+
+type compilerT struct {
+	file *ast.File
+}
+
+func (c compilerT) compile(doc DocT) ([]RuleT, error) {
+
+	if len(doc.Rules) == 0 {
+		return nil, c.errorf("$", ErrNoRules)
+	}
+
+	var (
+		out = make([]RuleT, 0, len(doc.Rules))
+		ids = make(map[string]struct{}, len(doc.Rules))
+	)
+
+	for i, def := range doc.Rules {
+		path := fmt.Sprintf("$.rules[%d]", i)
+
+		switch _, dupe := ids[def.Id]; {
+		case def.Id == "":
+			return nil, c.errorf(path, ErrRuleId)
+		case dupe:
+			return nil, c.errorf(path+".id", fmt.Errorf("%w: %q", ErrRuleDupe, def.Id))
+		}
+		ids[def.Id] = struct{}{}
+
+		m, factory, err := c.compileRule(path, def)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, RuleT{Id: def.Id, Matcher: m, New: factory, Def: def})
+	}
+
+	return out, nil
+}
+
+func (c compilerT) compileRule(path string, def RuleDefT) (match.Matcher, func() (match.Matcher, error), error) {
+
+	terms := make([]match.TermT, 0, len(def.Terms))
+	for j, td := range def.Terms {
+		term, err := c.compileTerm(fmt.Sprintf("%s.terms[%d]", path, j), td)
+		if err != nil {
+			return nil, nil, err
+		}
+		terms = append(terms, term)
+	}
+
+	resets := make([]match.ResetT, 0, len(def.Resets))
+	for k, rd := range def.Resets {
+		term, err := c.compileTerm(fmt.Sprintf("%s.resets[%d].term", path, k), rd.Term)
+		if err != nil {
+			return nil, nil, err
+		}
+		resets = append(resets, match.ResetT{
+			Term:      term,
+			Window:    int64(rd.Window),
+			Slide:     int64(rd.Slide),
+			Anchor:    rd.Anchor,
+			AnchorEnd: rd.AnchorEnd,
+			Absolute:  rd.Absolute,
+			Correlate: rd.Correlate,
+		})
+	}
+
+	switch def.Order {
+	case "", OrderSeq, OrderSet:
+	default:
+		return nil, nil, c.errorf(path+".order", fmt.Errorf("%w: %q", ErrOrder, def.Order))
+	}
+
+	keyFn, err := c.compileKey(path+".key", def.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := builderT{
+		keyFn:  keyFn,
+		set:    def.Order == OrderSet,
+		window: int64(def.Window),
+		terms:  terms,
+		resets: resets,
+		opts:   []match.OptT{match.WithMaxBuffered(def.MaxBuffered)},
+	}
+
+	m, err := b.build()
+	if err != nil {
+		if len(terms) == 0 {
+			path += ".terms"
+		}
+		return nil, nil, c.errorf(path, err)
+	}
+
+	return m, b.build, nil
+}
+
+// The compiled pieces of a rule, from which RuleT.New builds matchers.
+type builderT struct {
+	keyFn  match.KeyFn
+	set    bool
+	window int64
+	terms  []match.TermT
+	resets []match.ResetT
+	opts   []match.OptT
+}
+
+func (b builderT) build() (match.Matcher, error) {
+	if b.keyFn == nil {
+		return b.buildOne()
+	}
+
+	// Fail here, rather than on the first line of a key.
+	if _, err := b.buildOne(); err != nil {
+		return nil, err
+	}
+	return match.NewKeyedMatcher(b.keyFn, func() match.Matcher {
+		m, err := b.buildOne()
+		if err != nil {
+			return nil
+		}
+		return m
+	})
+}
+
+func (b builderT) buildOne() (match.Matcher, error) {
+	switch {
+	case b.set && len(b.resets) > 0:
+		return match.NewInverseSet(b.window, b.terms, b.resets, b.opts...)
+	case b.set:
+		return match.NewMatchSetOpts(b.window, b.terms, b.opts...)
+	case len(b.resets) > 0:
+		return match.NewInverseSeq(b.window, b.terms, b.resets, b.opts...)
+	default:
+		return match.NewMatchSeqOpts(b.window, b.terms, b.opts...)
+	}
+}
+
+// Join the parts of the key; nil if none.
+func (c compilerT) compileKey(path string, defs []KeyDefT) (match.KeyFn, error) {
+	fns := make([]match.KeyFn, 0, len(defs))
+	for i, kd := range defs {
+		fn, err := kd.KeyFn()
+		if err != nil {
+			return nil, c.errorf(fmt.Sprintf("%s[%d]", path, i), err)
+		}
+		fns = append(fns, fn)
+	}
+
+	switch len(fns) {
+	case 0:
+		return nil, nil
+	case 1:
+		return fns[0], nil
+	}
+	return match.KeyComposite(fns...), nil
+}
+
+// Validate the term eagerly so that the error points at the term itself.
+func (c compilerT) compileTerm(path string, td TermDefT) (match.TermT, error) {
+	term, err := td.Term()
+	if err != nil {
+		return term, c.errorf(path, err)
+	}
+	if _, err := term.NewMatcher(); err != nil {
+		return term, c.errorf(path, err)
+	}
+	return term, nil
+}
+
+func (c compilerT) errorf(path string, err error) error {
+	e := &ErrorT{Path: path, Err: err}
+	e.Line, e.Column = c.position(path)
+	return e
+}
+
+// Line and column of the node at path; zero if unknown.
+func (c compilerT) position(path string) (line, column int) {
+	p, err := yaml.PathString(path)
+	if err != nil {
+		return
+	}
+
+	if node, err := p.FilterFile(c.file); err == nil && node != nil {
+		if tk := node.GetToken(); tk != nil {
+			line, column = tk.Position.Line, tk.Position.Column
+		}
+	}
+	return
+}
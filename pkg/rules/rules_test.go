@@ -0,0 +1,360 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+const doc = `
+rules:
+  - id: crashloop
+    window: 10s
+    terms:
+      - "Back-off"
+      - regex: "exit code [1-9]"
+  - id: either
+    window: 5s
+    order: set
+    terms:
+      - alpha
+      - beta
+  - id: unrecovered
+    window: 10s
+    terms:
+      - alpha
+    resets:
+      - term: {raw: recovered}
+        window: 5s
+        absolute: true
+  - id: drift
+    window: 1000
+    terms:
+      - fuzzy: "disk full"
+        distance: 1
+      - logfmt: "level=error svc=api"
+      - raw: oomkilled
+        nocase: true
+        word: true
+      - numeric: "status >= 500"
+      - cidr: "client.ip in 10.0.0.0/8"
+      - raw: "Discarding message"
+        count: 5
+`
+
+func TestCompile(t *testing.T) {
+
+	rules, err := Compile([]byte(doc))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if len(rules) != 4 {
+		t.Fatalf("Expected 4 rules, got %d", len(rules))
+	}
+
+	exp := map[string]string{
+		"crashloop":   "MatchSeq",
+		"either":      "MatchSet",
+		"unrecovered": "InverseSeq",
+		"drift":       "MatchSeq",
+	}
+
+	for _, rule := range rules {
+		if want, got := exp[rule.Id], typeName(rule.Matcher); want != got {
+			t.Errorf("Rule %q: Expected %s, got %s", rule.Id, want, got)
+		}
+	}
+
+	// Window is honored; 10s.
+	var (
+		sm   = rules[0].Matcher
+		sec  = int64(time.Second)
+		hits match.Hits
+	)
+	sm.Scan(match.NewScanLine().ResetLine(1*sec, "Back-off restarting"))
+	hits = sm.Scan(match.NewScanLine().ResetLine(5*sec, "exit code 2"))
+	if hits.Cnt != 1 {
+		t.Errorf("Expected hit, got %d", hits.Cnt)
+	}
+
+	// New builds a matcher of the same kind with state of its own.
+	m, err := rules[0].New()
+	if err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+	if m == sm || typeName(m) != "MatchSeq" {
+		t.Fatalf("Expected a fresh MatchSeq, got %T", m)
+	}
+	if hits = m.Scan(match.NewScanLine().ResetLine(6*sec, "exit code 2")); hits.Cnt != 0 {
+		t.Errorf("Expected no hit, got %d", hits.Cnt)
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case *match.MatchSeq:
+		return "MatchSeq"
+	case *match.MatchSet:
+		return "MatchSet"
+	case *match.InverseSeq:
+		return "InverseSeq"
+	case *match.InverseSet:
+		return "InverseSet"
+	}
+	return "unknown"
+}
+
+func TestCompileJson(t *testing.T) {
+	rules, err := Compile([]byte(`{"rules":[{"id":"j","window":"1s","order":"set","terms":["a",{"jq":".level == \"error\""}],"resets":[{"term":"b"}]}]}`))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if _, ok := rules[0].Matcher.(*match.InverseSet); !ok {
+		t.Errorf("Expected InverseSet, got %T", rules[0].Matcher)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+
+	cases := map[string]struct {
+		doc  string
+		err  error
+		path string
+		line int
+	}{
+		"NoRules": {
+			doc:  "rules: []\n",
+			err:  ErrNoRules,
+			path: "$",
+		},
+		"MissingId": {
+			doc:  "rules:\n  - window: 1s\n    terms: [a]\n",
+			err:  ErrRuleId,
+			path: "$.rules[0]",
+			line: 2,
+		},
+		"DupeId": {
+			doc:  "rules:\n  - id: a\n    terms: [a]\n  - id: a\n    terms: [b]\n",
+			err:  ErrRuleDupe,
+			path: "$.rules[1].id",
+			line: 4,
+		},
+		"BadOrder": {
+			doc:  "rules:\n  - id: a\n    order: tree\n    terms: [a]\n",
+			err:  ErrOrder,
+			path: "$.rules[0].order",
+			line: 3,
+		},
+		"BadRegex": {
+			doc:  "rules:\n  - id: a\n    terms:\n      - alpha\n      - regex: \"(\"\n",
+			err:  match.ErrTermCompile,
+			path: "$.rules[0].terms[1]",
+			line: 5,
+		},
+		"TwoTypes": {
+			doc:  "rules:\n  - id: a\n    terms:\n      - {raw: a, regex: b}\n",
+			err:  ErrTermSpec,
+			path: "$.rules[0].terms[0]",
+			line: 4,
+		},
+		"NoTerms": {
+			doc:  "rules:\n  - id: a\n    window: 1s\n",
+			err:  match.ErrNoTerms,
+			path: "$.rules[0].terms",
+		},
+		"ResetAnchor": {
+			doc:  "rules:\n  - id: a\n    terms: [a]\n    resets:\n      - term: b\n        anchor: 3\n",
+			err:  match.ErrAnchorRange,
+			path: "$.rules[0]",
+			line: 2,
+		},
+		"ResetAnchorEnd": {
+			doc:  "rules:\n  - id: a\n    terms: [a, b]\n    resets:\n      - term: c\n        anchor: 1\n        anchorEnd: 1\n",
+			err:  match.ErrAnchorRange,
+			path: "$.rules[0]",
+			line: 2,
+		},
+		"ResetCorrelate": {
+			doc:  "rules:\n  - id: a\n    terms: [a]\n    resets:\n      - term: c\n        correlate: \".\"\n",
+			err:  match.ErrCorrelate,
+			path: "$.rules[0]",
+			line: 2,
+		},
+		"KeySpec": {
+			doc:  "rules:\n  - id: a\n    terms: [a]\n    key:\n      - {regex: a, jq: .b}\n",
+			err:  ErrKeySpec,
+			path: "$.rules[0].key[0]",
+			line: 5,
+		},
+		"KeyRegex": {
+			doc:  "rules:\n  - id: a\n    terms: [a]\n    key:\n      - regex: \"(\"\n",
+			err:  match.ErrTermCompile,
+			path: "$.rules[0].key[0]",
+			line: 5,
+		},
+		"ResetTerm": {
+			doc:  "rules:\n  - id: a\n    terms: [a]\n    resets:\n      - term: {regex: \"[\"}\n",
+			err:  match.ErrTermCompile,
+			path: "$.rules[0].resets[0].term",
+			line: 5,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Compile([]byte(tc.doc))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected %v, got %v", tc.err, err)
+			}
+
+			var e *ErrorT
+			if !errors.As(err, &e) {
+				t.Fatalf("Expected *ErrorT, got %T", err)
+			}
+
+			if e.Path != tc.path {
+				t.Errorf("Expected path %q, got %q", tc.path, e.Path)
+			}
+
+			if tc.line != 0 && e.Line != tc.line {
+				t.Errorf("Expected line %d, got %d (%v)", tc.line, e.Line, err)
+			}
+		})
+	}
+}
+
+func TestCompileDecodeErrors(t *testing.T) {
+
+	cases := map[string]string{
+		"Syntax":       "rules: [\n",
+		"UnknownField": "rules:\n  - id: a\n    terms: [a]\n    bogus: 1\n",
+		"BadDuration":  "rules:\n  - id: a\n    window: soon\n    terms: [a]\n",
+	}
+
+	for name, doc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Compile([]byte(doc)); !errors.Is(err, ErrParseRule) {
+				t.Errorf("Expected %v, got %v", ErrParseRule, err)
+			}
+		})
+	}
+}
+
+func TestCompileCount(t *testing.T) {
+	rules, err := Compile([]byte("rules:\n  - id: c\n    window: 10\n    terms:\n      - raw: discard\n        count: 2\n      - overloaded\n"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var (
+		m    = rules[0].Matcher
+		sl   = match.NewScanLine()
+		hits match.Hits
+	)
+	for i, line := range []string{"discard", "overloaded", "discard", "overloaded"} {
+		hits = m.Scan(sl.ResetLine(int64(i+1), line))
+	}
+	if hits.Cnt != 1 || len(hits.Logs) != 3 {
+		t.Errorf("Expected 1 hit of 3 logs, got %d of %d", hits.Cnt, len(hits.Logs))
+	}
+}
+
+func TestCompileKey(t *testing.T) {
+	rules, err := Compile([]byte("rules:\n  - id: k\n    window: 10\n    terms: [start, fail]\n    key:\n      - regex: \"pod=(\\\\w+)\"\n      - jq: .ns\n"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var (
+		sl   = match.NewScanLine()
+		cnt  int
+		keys []any
+	)
+	for _, m := range []match.Matcher{rules[0].Matcher, newMatcher(t, rules[0])} {
+		if _, ok := m.(*match.KeyedMatcher); !ok {
+			t.Fatalf("Expected KeyedMatcher, got %T", m)
+		}
+
+		// Unkeyed, the start of a and the fail of b would match.
+		for i, line := range []string{
+			`{"ns":"x","msg":"pod=a start"}`,
+			`{"ns":"y","msg":"pod=b start"}`,
+			`{"ns":"x","msg":"pod=b fail"}`,
+			`{"ns":"y","msg":"pod=b fail"}`,
+		} {
+			hits := m.Scan(sl.ResetLine(int64(i+1), line))
+			cnt += hits.Cnt
+			for hit := range hits.Iter() {
+				keys = append(keys, hit.Props[match.PropKeyed])
+			}
+		}
+	}
+
+	if want := []any{`"b"|"y"`, `"b"|"y"`}; cnt != 2 || !slices.Equal(keys, want) {
+		t.Errorf("Expected keys %v, got %d hits %v", want, cnt, keys)
+	}
+}
+
+func newMatcher(t *testing.T, rule RuleT) match.Matcher {
+	t.Helper()
+	m, err := rule.New()
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	return m
+}
+
+func TestCompileMaxBuffered(t *testing.T) {
+	rules, err := Compile([]byte("rules:\n  - id: b\n    window: 1000\n    maxBuffered: 2\n    terms: [frank, burns]\n"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var (
+		m  = rules[0].Matcher
+		sl = match.NewScanLine()
+	)
+	for i := range 10 {
+		m.Scan(sl.ResetLine(int64(i+1), "frank"))
+	}
+	if s := m.(match.StatsI).Stats(); s.Evicted != 8 {
+		t.Errorf("Expected 8 evicted, got %d", s.Evicted)
+	}
+}
+
+func TestCompileSeverity(t *testing.T) {
+	rules, err := Compile([]byte("rules:\n  - id: s\n    window: 10\n    terms:\n      - raw: disk\n        minSeverity: error\n      - raw: retry\n        minSeverity: warn\n"))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var (
+		m    = rules[0].Matcher
+		sl   = match.NewScanLine()
+		hits match.Hits
+	)
+	for i, e := range []match.LogEntry{
+		{Line: "disk full", Severity: entry.SevWarn},
+		{Line: "retry", Severity: entry.SevWarn},
+		{Line: "disk full", Severity: entry.SevError},
+		{Line: "retry"}, // Unknown severity.
+		{Line: "retry", Severity: entry.SevWarn},
+	} {
+		e.Timestamp = int64(i + 1)
+		hits.Append(m.Scan(sl.Reset(e)))
+	}
+	if hits.Cnt != 1 || hits.Logs[0].Timestamp != 3 || hits.Logs[1].Timestamp != 5 {
+		t.Errorf("Expected a hit on lines 3 and 5, got %+v", hits.Logs)
+	}
+
+	_, err = Compile([]byte("rules:\n  - id: s\n    terms:\n      - raw: disk\n        minSeverity: loud\n"))
+	var e *ErrorT
+	if !errors.Is(err, entry.ErrSeverity) || !errors.As(err, &e) || e.Path != "$.rules[0].terms[0]" {
+		t.Errorf("Expected %v at the term, got %v", entry.ErrSeverity, err)
+	}
+}
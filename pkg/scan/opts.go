@@ -0,0 +1,98 @@
+package scan
+
+import (
+	"regexp"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MaxRecordSize = pool.MaxRecordSize
+	defBufSize    = 64 << 10
+)
+
+// ErrFuncT is called on a line that cannot be processed.  Return nil to skip
+// the line and continue, or an error to abort the scan.
+type ErrFuncT func([]byte, error) error
+
+type OptT func(*optsT)
+
+type optsT struct {
+	maxSz int
+	flush bool
+	fold  bool
+	cont  *regexp.Regexp
+	errF  ErrFuncT
+}
+
+func defaultErrFunc(line []byte, err error) error {
+	// Tolerate badly formed lines
+	log.Debug().
+		Err(err).
+		Int("size", len(line)).
+		Msg("Fail line.  Continue...")
+	return nil
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		maxSz: MaxRecordSize,
+		flush: true,
+		errF:  defaultErrFunc,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithMaxSize sets the largest line accepted.  Longer lines are passed to the
+// error function with ErrLineTooLong and skipped.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz <= 0 || maxSz > MaxRecordSize {
+			maxSz = MaxRecordSize
+		}
+		o.maxSz = maxSz
+	}
+}
+
+// WithFlush controls whether the matchers are evaluated at end of stream.  When
+// enabled (the default), EOF is treated as the end of time and pending hits
+// waiting on a reset window are emitted.  Disable for readers that may be resumed.
+func WithFlush(flush bool) OptT {
+	return func(o *optsT) {
+		o.flush = flush
+	}
+}
+
+func WithErrFunc(errF ErrFuncT) OptT {
+	return func(o *optsT) {
+		o.errF = errF
+	}
+}
+
+// WithFold joins lines that fail to parse onto the preceding entry, separated
+// by a newline, so that a multi-line record such as a stack trace is scanned
+// as one entry.  An entry is held until the next entry begins, or until EOF or
+// Flush.  Lines that would grow an entry past the maximum size are passed to
+// the error function with ErrLineTooLong.
+func WithFold(fold bool) OptT {
+	return func(o *optsT) {
+		o.fold = fold
+	}
+}
+
+// WithContinuation also joins lines matching exp onto the preceding entry,
+// whether or not they parse; for example `^\s` or `^Caused by: `.  Implies
+// WithFold.
+func WithContinuation(exp *regexp.Regexp) OptT {
+	return func(o *optsT) {
+		o.cont = exp
+		o.fold = exp != nil || o.fold
+	}
+}
@@ -0,0 +1,334 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scanner"
+)
+
+var (
+	ErrNoMatchers  = errors.New("no matchers")
+	ErrLineTooLong = errors.New("line too long")
+)
+
+// StreamHeartbeat is the Stream of the synthetic entries scanned by Heartbeat.
+const StreamHeartbeat = "logmatch.heartbeat"
+
+type LogEntry = match.LogEntry
+
+// HitT is a set of hits emitted by the matcher at index Idx.
+type HitT struct {
+	Idx  int
+	Hits match.Hits
+}
+
+// HitFuncT receives hits as they are emitted.  Return true to stop the scan.
+type HitFuncT func(HitT) bool
+
+// Scanner feeds lines through a parser and into one or more matchers.
+//
+// Lines are split on '\n', with an optional trailing '\r' removed.  A final line
+// without a terminating newline is processed at EOF.  Lines longer than the
+// configured maximum are reported to the error function and skipped, as are
+// lines that fail to parse unless folded with WithFold.
+
+type Scanner struct {
+	parser   format.ParserI
+	matchers []match.Matcher
+	sl       *match.ScanLine
+	clock    int64
+	o        optsT
+
+	// Folding state
+	held    bool
+	pending LogEntry
+	folded  []byte
+
+	// Set once a heartbeat has been scanned; hits are then filtered.
+	beats bool
+}
+
+func New(parser format.ParserI, matchers []match.Matcher, opts ...OptT) (*Scanner, error) {
+	if len(matchers) == 0 {
+		return nil, ErrNoMatchers
+	}
+
+	return &Scanner{
+		parser:   parser,
+		matchers: matchers,
+		sl:       match.NewScanLine(),
+		o:        parseOpts(opts),
+	}, nil
+}
+
+// Feed parses a single line and scans it through each matcher.
+// Returns true if cb requested a stop.
+func (s *Scanner) Feed(line []byte, cb HitFuncT) (bool, error) {
+	if s.held && s.o.cont != nil && s.o.cont.Match(line) {
+		return false, s.fold(line)
+	}
+
+	e, err := s.parser.ReadEntry(line)
+	switch {
+	case errors.Is(err, format.ErrPartial):
+		// Held by the parser until the line is complete.
+		return false, nil
+	case err != nil && s.held:
+		return false, s.fold(line)
+	case err != nil:
+		return false, s.o.errF(line, err)
+	case !s.o.fold:
+		return s.ScanEntry(e, cb), nil
+	}
+
+	stop := s.Flush(cb)
+	s.held, s.pending = true, e
+	return stop, nil
+}
+
+// Flush scans the entry held for folding, if any.
+// Returns true if cb requested a stop.
+func (s *Scanner) Flush(cb HitFuncT) bool {
+	if !s.held {
+		return false
+	}
+
+	if len(s.folded) > 0 {
+		s.pending.Line = string(s.folded)
+		s.folded = s.folded[:0]
+	}
+
+	e := s.pending
+	s.held, s.pending = false, LogEntry{}
+	return s.ScanEntry(e, cb)
+}
+
+// Join a continuation line onto the held entry.
+func (s *Scanner) fold(line []byte) error {
+	if len(s.folded) == 0 {
+		s.folded = append(s.folded, s.pending.Line...)
+	}
+
+	if len(s.folded)+1+len(line) > s.o.maxSz {
+		return s.o.errF(line, ErrLineTooLong)
+	}
+
+	s.folded = append(s.folded, '\n')
+	s.folded = append(s.folded, line...)
+	return nil
+}
+
+// ScanEntry scans an already parsed entry through each matcher.
+// Returns true if cb requested a stop.
+func (s *Scanner) ScanEntry(e LogEntry, cb HitFuncT) bool {
+	s.sl.Reset(e)
+	s.clock = max(s.clock, e.Timestamp)
+
+	for i, m := range s.matchers {
+		if s.emit(i, m.Scan(s.sl), cb) {
+			return true
+		}
+	}
+	return false
+}
+
+// Eval evaluates each matcher at clock, emitting any pending hits.
+// Returns true if cb requested a stop.
+func (s *Scanner) Eval(clock int64, cb HitFuncT) bool {
+	for i, m := range s.matchers {
+		if s.emit(i, m.Eval(clock), cb) {
+			return true
+		}
+	}
+	return false
+}
+
+// Heartbeat scans a synthetic entry at clock, so that matchers waiting on the
+// clock, such as inverse matchers with absolute reset windows, are driven
+// forward on a quiet stream.  The entry has an empty line and Stream set to
+// StreamHeartbeat; hits built on one are dropped, so heartbeats never appear
+// in Hits.Logs.  Any entry held for folding is flushed first.  A clock at or
+// behind the greatest timestamp scanned is ignored.  The clock should track
+// the timestamps of the stream; a clock ahead of them makes the lines that
+// follow late.  Returns true if cb requested a stop.
+func (s *Scanner) Heartbeat(clock int64, cb HitFuncT) bool {
+	if s.Flush(cb) {
+		return true
+	}
+	if clock <= s.clock {
+		return false
+	}
+
+	s.beats = true
+	return s.ScanEntry(LogEntry{Timestamp: clock, Stream: StreamHeartbeat}, cb)
+}
+
+// Pass the hits of matcher i to cb, less any built on a heartbeat.
+func (s *Scanner) emit(i int, hits match.Hits, cb HitFuncT) bool {
+	if s.beats && hits.Cnt > 0 {
+		hits = hits.Filter(func(logs []LogEntry) bool {
+			return !slices.ContainsFunc(logs, func(e LogEntry) bool {
+				return e.Stream == StreamHeartbeat
+			})
+		})
+	}
+
+	if hits.Cnt == 0 {
+		return false
+	}
+	return cb(HitT{Idx: i, Hits: hits})
+}
+
+// Clock returns the greatest timestamp scanned.
+func (s *Scanner) Clock() int64 {
+	return s.clock
+}
+
+// Run reads rdr until EOF, an error, or cb requests a stop.  A gzip or zstd
+// stream is decompressed; see scanner.Decompress.
+func (s *Scanner) Run(rdr io.Reader, cb HitFuncT) error {
+
+	var (
+		bufSz = min(defBufSize, s.o.maxSz)
+		br    = bufio.NewReaderSize(rdr, bufSz)
+	)
+
+	dr, err := scanner.Decompress(br)
+	if err != nil {
+		return err
+	}
+	if dr != io.Reader(br) {
+		br = bufio.NewReaderSize(dr, bufSz)
+	}
+
+	lr := lineReader{rdr: br, maxSz: s.o.maxSz}
+
+	for {
+		line, rerr := lr.next()
+
+		switch {
+		case errors.Is(rerr, ErrLineTooLong):
+			if err := s.o.errF(line, rerr); err != nil {
+				return err
+			}
+			continue
+		case rerr == io.EOF && line == nil:
+			if s.Flush(cb) {
+				return nil
+			}
+			if s.o.flush {
+				s.Eval(math.MaxInt64, cb)
+			}
+			return nil
+		case rerr != nil && rerr != io.EOF:
+			return rerr
+		}
+
+		stop, err := s.Feed(line, cb)
+		switch {
+		case err != nil:
+			return err
+		case stop:
+			return nil
+		}
+	}
+}
+
+// RunChan runs the scanner in a goroutine, emitting hits on the returned channel.
+// The hit channel is closed when the scan completes.  The error channel receives
+// at most one value, and is closed after the hit channel.
+func (s *Scanner) RunChan(ctx context.Context, rdr io.Reader) (<-chan HitT, <-chan error) {
+
+	var (
+		hitC = make(chan HitT)
+		errC = make(chan error, 1)
+	)
+
+	go func() {
+		defer close(errC)
+
+		err := s.Run(rdr, func(h HitT) bool {
+			select {
+			case hitC <- h:
+				return false
+			case <-ctx.Done():
+				return true
+			}
+		})
+
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		close(hitC)
+
+		if err != nil {
+			errC <- err
+		}
+	}()
+
+	return hitC, errC
+}
+
+// lineReader splits lines, tolerating lines larger than the bufio buffer up to maxSz.
+type lineReader struct {
+	rdr   *bufio.Reader
+	maxSz int
+	buf   []byte
+}
+
+// Returns the next line without the line terminator.  At EOF, a trailing partial
+// line is returned with a nil error; the following call returns io.EOF.
+// An overlong line is consumed and returned truncated with ErrLineTooLong.
+func (lr *lineReader) next() ([]byte, error) {
+
+	lr.buf = lr.buf[:0]
+	tooLong := false
+
+	for {
+		frag, err := lr.rdr.ReadSlice('\n')
+
+		// Allow room for a "\r\n" terminator; beyond that, stop buffering.
+		if !tooLong {
+			if len(lr.buf)+len(frag) > lr.maxSz+2 {
+				tooLong = true
+				n := max(0, lr.maxSz-len(lr.buf))
+				lr.buf = append(lr.buf[:min(len(lr.buf), lr.maxSz)], frag[:n]...)
+			} else {
+				lr.buf = append(lr.buf, frag...)
+			}
+		}
+
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case nil:
+			// Fall through; complete line
+		case io.EOF:
+			if len(lr.buf) == 0 && !tooLong {
+				return nil, io.EOF
+			}
+		default:
+			return nil, err
+		}
+
+		if tooLong {
+			return lr.buf, ErrLineTooLong
+		}
+
+		line := bytes.TrimSuffix(lr.buf, []byte{'\n'})
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if len(line) > lr.maxSz {
+			return line[:lr.maxSz], ErrLineTooLong
+		}
+		return line, nil
+	}
+}
@@ -0,0 +1,331 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+// Lines are of the form "<stamp> <text>".
+func newParser(t *testing.T) format.ParserI {
+	t.Helper()
+	factory, err := format.NewRegexFactory(`^(\d+) `, func(m []byte) (int64, error) {
+		return strconv.ParseInt(string(m), 10, 64)
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	return factory.New()
+}
+
+func newSeq(t *testing.T, terms ...string) match.Matcher {
+	t.Helper()
+	tt := make([]match.TermT, 0, len(terms))
+	for _, term := range terms {
+		tt = append(tt, match.TermT{Type: match.TermRaw, Value: term})
+	}
+	sm, err := match.NewMatchSeq(10, tt...)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	return sm
+}
+
+func collect(hits *[]HitT) HitFuncT {
+	return func(h HitT) bool {
+		*hits = append(*hits, h)
+		return false
+	}
+}
+
+func TestScannerRun(t *testing.T) {
+
+	s, err := New(newParser(t), []match.Matcher{
+		newSeq(t, "alpha", "beta"),
+		newSeq(t, "gamma"),
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	// CRLF terminators, a bad line, and a partial final line without a newline.
+	data := "1 alpha\r\n2 gamma\nbogus\n3 noop\n4 beta"
+
+	var hits []HitT
+	if err := s.Run(strings.NewReader(data), collect(&hits)); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %d", len(hits))
+	}
+
+	if hits[0].Idx != 1 || hits[0].Hits.Logs[0].Line != "2 gamma" {
+		t.Errorf("Expected gamma hit on matcher 1, got %+v", hits[0])
+	}
+
+	if hits[1].Idx != 0 || hits[1].Hits.Logs[0].Line != "1 alpha" || hits[1].Hits.Logs[1].Line != "4 beta" {
+		t.Errorf("Expected alpha/beta hit on matcher 0, got %+v", hits[1])
+	}
+
+	if s.Clock() != 4 {
+		t.Errorf("Expected clock 4, got %d", s.Clock())
+	}
+}
+
+func TestScannerLargeLine(t *testing.T) {
+
+	var (
+		big  = "2 alpha " + strings.Repeat("x", 100<<10) // Larger than the read buffer
+		huge = "3 alpha " + strings.Repeat("x", 300<<10) // Larger than max
+		data = "1 noop\n" + big + "\n" + huge + "\n4 beta\n"
+		errs []error
+	)
+
+	s, err := New(newParser(t), []match.Matcher{newSeq(t, "alpha", "beta")},
+		WithMaxSize(200<<10),
+		WithErrFunc(func(line []byte, err error) error {
+			if len(line) > 200<<10 {
+				t.Errorf("Expected line truncated to max, got %d", len(line))
+			}
+			errs = append(errs, err)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var hits []HitT
+	if err := s.Run(strings.NewReader(data), collect(&hits)); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], ErrLineTooLong) {
+		t.Errorf("Expected single ErrLineTooLong, got %v", errs)
+	}
+
+	if len(hits) != 1 || hits[0].Hits.Logs[0].Line != big {
+		t.Errorf("Expected hit on the large line, got %d hits", len(hits))
+	}
+}
+
+func TestScannerFold(t *testing.T) {
+
+	s, err := New(newParser(t), []match.Matcher{newSeq(t, "Exception", "done")},
+		WithContinuation(regexp.MustCompile(`^\d+ \.\.\. `)),
+		WithMaxSize(120),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	data := strings.Join([]string{
+		"bogus before any entry",
+		"1 java.lang.NullPointerException: oops",
+		"\tat Foo.bar(Foo.java:10)",
+		"\tat Foo.main(Foo.java:3)",
+		"12 ... more",
+		"2 done",
+		"\tat " + strings.Repeat("x", 110), // Would grow the entry past max
+	}, "\n")
+
+	var hits []HitT
+	if err := s.Run(strings.NewReader(data), collect(&hits)); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if len(hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(hits))
+	}
+
+	exp := "1 java.lang.NullPointerException: oops\n\tat Foo.bar(Foo.java:10)\n\tat Foo.main(Foo.java:3)\n12 ... more"
+	if logs := hits[0].Hits.Logs; logs[0].Line != exp || logs[0].Timestamp != 1 || logs[1].Line != "2 done" {
+		t.Errorf("Expected folded trace, got %q", logs)
+	}
+
+	if s.Clock() != 2 {
+		t.Errorf("Expected clock 2, got %d", s.Clock())
+	}
+}
+
+func TestScannerFlush(t *testing.T) {
+
+	newInverse := func() match.Matcher {
+		sm, err := match.NewInverseSeq(10,
+			[]match.TermT{{Type: match.TermRaw, Value: "alpha"}},
+			[]match.ResetT{{Term: match.TermT{Type: match.TermRaw, Value: "boom"}, Window: 5}},
+		)
+		if err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+		return sm
+	}
+
+	cases := map[string]struct {
+		opts []OptT
+		cnt  int
+	}{
+		"Default": {cnt: 1},
+		"NoFlush": {opts: []OptT{WithFlush(false)}, cnt: 0},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := New(newParser(t), []match.Matcher{newInverse()}, tc.opts...)
+			if err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			// Reset window is still open at EOF.
+			var hits []HitT
+			if err := s.Run(strings.NewReader("1 alpha\n2 noop\n"), collect(&hits)); err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+
+			if len(hits) != tc.cnt {
+				t.Errorf("Expected %d hits, got %d", tc.cnt, len(hits))
+			}
+		})
+	}
+}
+
+func TestScannerStop(t *testing.T) {
+
+	s, err := New(newParser(t), []match.Matcher{newSeq(t, "alpha")})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	cnt := 0
+	err = s.Run(strings.NewReader("1 alpha\n2 alpha\n3 alpha\n"), func(HitT) bool {
+		cnt += 1
+		return true
+	})
+
+	if err != nil || cnt != 1 {
+		t.Errorf("Expected stop after 1 hit, got %d, %v", cnt, err)
+	}
+}
+
+func TestScannerErrFunc(t *testing.T) {
+
+	errAbort := errors.New("abort")
+
+	s, err := New(newParser(t), []match.Matcher{newSeq(t, "alpha")},
+		WithErrFunc(func([]byte, error) error { return errAbort }),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if err := s.Run(strings.NewReader("bogus\n1 alpha\n"), collect(new([]HitT))); err != errAbort {
+		t.Errorf("Expected %v, got %v", errAbort, err)
+	}
+}
+
+func TestScannerRunChan(t *testing.T) {
+
+	s, err := New(newParser(t), []match.Matcher{newSeq(t, "alpha")})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	hitC, errC := s.RunChan(context.Background(), strings.NewReader("1 alpha\n2 beta\n3 alpha\n"))
+
+	cnt := 0
+	for range hitC {
+		cnt += 1
+	}
+
+	if err := <-errC; err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+
+	if cnt != 2 {
+		t.Errorf("Expected 2 hits, got %d", cnt)
+	}
+}
+
+func TestScannerRunChanCancel(t *testing.T) {
+
+	s, err := New(newParser(t), []match.Matcher{newSeq(t, "alpha")})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	hitC, errC := s.RunChan(ctx, strings.NewReader("1 alpha\n2 alpha\n"))
+	for range hitC {
+	}
+
+	if err := <-errC; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestScannerNoMatchers(t *testing.T) {
+	if _, err := New(newParser(t), nil); err != ErrNoMatchers {
+		t.Errorf("Expected %v, got %v", ErrNoMatchers, err)
+	}
+}
+
+func TestScannerHeartbeat(t *testing.T) {
+	// Fires once the reset window after beta has passed.
+	iseq, err := match.NewInverseSeq(10,
+		[]match.TermT{{Type: match.TermRaw, Value: "alpha"}, {Type: match.TermRaw, Value: "beta"}},
+		[]match.ResetT{{Term: match.TermT{Type: match.TermRaw, Value: "cancel"}, Window: 5, Absolute: true, Anchor: 1}},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	// A term matching the empty heartbeat line.
+	set, err := match.NewMatchSet(10,
+		match.TermT{Type: match.TermRaw, Value: "alpha"},
+		match.TermT{Type: match.TermRegex, Value: "^$"},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	s, err := New(newParser(t), []match.Matcher{iseq, set})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	var hits []HitT
+	for _, line := range []string{"1 alpha", "2 beta"} {
+		if _, err := s.Feed([]byte(line), collect(&hits)); err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+	}
+
+	// Behind the clock; ignored.
+	s.Heartbeat(2, collect(&hits))
+	s.Heartbeat(4, collect(&hits))
+	if len(hits) != 0 {
+		t.Fatalf("Expected no hits inside the reset window, got %+v", hits)
+	}
+
+	s.Heartbeat(20, collect(&hits))
+	if len(hits) != 1 || hits[0].Idx != 0 {
+		t.Fatalf("Expected 1 hit from the inverse sequence, got %+v", hits)
+	}
+	for _, e := range hits[0].Hits.Logs {
+		if e.Stream == StreamHeartbeat {
+			t.Errorf("Expected no heartbeat in hit logs, got %+v", hits[0].Hits.Logs)
+		}
+	}
+	if s.Clock() != 20 {
+		t.Errorf("Expected clock 20, got %d", s.Clock())
+	}
+}
@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	ErrNoDecoder  = errors.New("no decoder for compressed stream")
+	ErrCompressed = errors.New("compressed stream cannot be scanned in reverse")
+)
+
+// Compression formats detected by Decompress.
+const (
+	CodecGzip = "gzip"
+	CodecZstd = "zstd"
+)
+
+// DecoderFuncT wraps a compressed stream in a reader of its content.
+type DecoderFuncT func(io.Reader) (io.Reader, error)
+
+type codecT struct {
+	name   string
+	magic  []byte
+	decode DecoderFuncT
+}
+
+var codecs = struct {
+	mux  sync.RWMutex
+	list []codecT
+	peek int
+}{
+	list: []codecT{
+		{CodecGzip, []byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{CodecZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}, decodeZstd},
+	},
+	peek: 4,
+}
+
+// RegisterDecoder sets the decoder of the named compression format, adding the
+// format if it is not known.  Streams that begin with magic are decoded with
+// fn.  A nil magic keeps the magic of a known format; a nil fn leaves the
+// format detected, but failing with ErrNoDecoder.
+func RegisterDecoder(name string, magic []byte, fn DecoderFuncT) {
+	codecs.mux.Lock()
+	defer codecs.mux.Unlock()
+
+	i := slices.IndexFunc(codecs.list, func(c codecT) bool { return c.name == name })
+	switch {
+	case i < 0:
+		codecs.list = append(codecs.list, codecT{name: name, magic: magic, decode: fn})
+	case magic != nil:
+		codecs.list[i] = codecT{name: name, magic: magic, decode: fn}
+	default:
+		codecs.list[i].decode = fn
+	}
+	codecs.peek = max(codecs.peek, len(magic))
+}
+
+// Decompress returns a reader of the content of rdr, decoding it if it begins
+// with the magic of a known compression format: gzip or zstd.  Concatenated
+// gzip members or zstd frames, as left by some log rotators, are read as one
+// stream.  Offsets of lines read
+// from the returned reader are relative to the decompressed content.
+func Decompress(rdr io.Reader) (io.Reader, error) {
+	codecs.mux.RLock()
+	list, peek := codecs.list, codecs.peek
+	codecs.mux.RUnlock()
+
+	br, ok := rdr.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(rdr)
+	}
+
+	head, err := br.Peek(peek)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if c, ok := match(list, head); ok {
+		if c.decode == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoDecoder, c.name)
+		}
+		return c.decode(br)
+	}
+	return br, nil
+}
+
+// Decode zstd synchronously; the decoder then holds no goroutines, and needs
+// no Close once the stream is dropped.
+func decodeZstd(r io.Reader) (io.Reader, error) {
+	return zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+}
+
+// Fail with ErrCompressed if src begins with the magic of a known format.
+func checkCompressed(src io.ReaderAt) error {
+	codecs.mux.RLock()
+	list, peek := codecs.list, codecs.peek
+	codecs.mux.RUnlock()
+
+	head := make([]byte, peek)
+	n, err := src.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if c, ok := match(list, head[:n]); ok {
+		return fmt.Errorf("%w: %s", ErrCompressed, c.name)
+	}
+	return nil
+}
+
+func match(list []codecT, head []byte) (codecT, bool) {
+	for _, c := range list {
+		if len(c.magic) > 0 && bytes.HasPrefix(head, c.magic) {
+			return c, true
+		}
+	}
+	return codecT{}, false
+}
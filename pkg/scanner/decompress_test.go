@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, parts ...string) []byte {
+	t.Helper()
+
+	// Each part is a separate member, as left by appending rotators.
+	var buf bytes.Buffer
+	for _, part := range parts {
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func scanLines(rdr io.Reader) ([]LogEntry, error) {
+	var (
+		logs   []LogEntry
+		parseF = func(line []byte) (LogEntry, error) {
+			return LogEntry{Timestamp: int64(line[0] - '0'), Line: string(line)}, nil
+		}
+		scanF = func(entry LogEntry) bool {
+			logs = append(logs, entry)
+			return false
+		}
+	)
+
+	err := ScanForward(rdr, parseF, scanF, WithPosition(true))
+	return logs, err
+}
+
+func TestForwardGzip(t *testing.T) {
+	data := gzipped(t, "1 alpha\n2 beta\n", "3 gamma\n")
+
+	logs, err := scanLines(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	exp := []struct {
+		line   string
+		offset int64
+	}{{"1 alpha", 0}, {"2 beta", 8}, {"3 gamma", 15}}
+
+	if len(logs) != len(exp) {
+		t.Fatalf("Expected %d entries, got %d", len(exp), len(logs))
+	}
+	for i, e := range logs {
+		if e.Line != exp[i].line || e.Offset != exp[i].offset {
+			t.Errorf("Entry %d: expected %q at %d, got %q at %d", i, exp[i].line, exp[i].offset, e.Line, e.Offset)
+		}
+	}
+}
+
+func TestForwardPlain(t *testing.T) {
+	logs, err := scanLines(strings.NewReader("1 alpha\n2 beta\n"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if len(logs) != 2 || logs[1].Offset != 8 {
+		t.Errorf("Expected 2 entries, got %v", logs)
+	}
+}
+
+func TestForwardZstd(t *testing.T) {
+	// Two frames of 50 lines each, from the zstd command line tool.
+	f, err := os.Open("testdata/rotated.log.zst")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer f.Close()
+
+	logs, err := scanLines(f)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if len(logs) != 100 {
+		t.Fatalf("Expected 100 entries, got %d", len(logs))
+	}
+
+	const lineSz = int64(len("0 request 0000 ok\n"))
+	for i, e := range logs {
+		if exp := fmt.Sprintf("%d request %04d ok", i%10, i); e.Line != exp || e.Offset != int64(i)*lineSz {
+			t.Errorf("Entry %d: expected %q at %d, got %q at %d", i, exp, int64(i)*lineSz, e.Line, e.Offset)
+		}
+	}
+}
+
+func TestNoDecoder(t *testing.T) {
+	RegisterDecoder(CodecZstd, nil, nil)
+	defer RegisterDecoder(CodecZstd, nil, decodeZstd)
+
+	data := []byte{0x28, 0xb5, 0x2f, 0xfd, '1', '\n'}
+	if _, err := scanLines(bytes.NewReader(data)); !errors.Is(err, ErrNoDecoder) {
+		t.Fatalf("Expected ErrNoDecoder, got %v", err)
+	}
+}
+
+func TestReverseCompressed(t *testing.T) {
+	var (
+		data   = gzipped(t, "1 alpha\n")
+		parseF = func(line []byte) (LogEntry, error) { return LogEntry{Line: string(line)}, nil }
+		scanF  = func(LogEntry) bool { return false }
+	)
+
+	if err := ScanReverse(bytes.NewReader(data), parseF, scanF); !errors.Is(err, ErrCompressed) {
+		t.Errorf("Expected ErrCompressed, got %v", err)
+	}
+}
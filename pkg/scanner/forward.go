@@ -2,13 +2,22 @@ package scanner
 
 import (
 	"bufio"
+	"errors"
 	"io"
 
 	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
 )
 
+// ScanForward scans the lines of rdr in order.  A gzip or zstd stream is
+// decompressed; see Decompress.
 func ScanForward(rdr io.Reader, parseF ParseFuncT, scanF ScanFuncT, opts ...ScanOptT) error {
 
+	rdr, err := Decompress(rdr)
+	if err != nil {
+		return err
+	}
+
 	var (
 		buf     []byte
 		o       = parseOpts(opts)
@@ -29,10 +38,33 @@ func ScanForward(rdr io.Reader, parseF ParseFuncT, scanF ScanFuncT, opts ...Scan
 	// if it encounters a line that is > o.maxSz.
 	scanner.Buffer(buf, o.maxSz)
 
+	var (
+		start, end int64
+		lineNo     int64
+	)
+
+	if o.pos {
+		// Track the offset of each line as it is split.
+		scanner.Split(func(data []byte, atEOF bool) (adv int, tok []byte, err error) {
+			adv, tok, err = bufio.ScanLines(data, atEOF)
+			if tok != nil {
+				start = end
+			}
+			end += int64(adv)
+			return
+		})
+	}
+
 LOOP:
 	for scanner.Scan() {
 
+		lineNo += 1
+
 		entry, parseErr := parseF(scanner.Bytes())
+		if errors.Is(parseErr, format.ErrPartial) {
+			// Held by the parser until the line is complete.
+			continue
+		}
 		if parseErr != nil {
 			if err := errF(scanner.Bytes(), parseErr); err != nil {
 				return err
@@ -44,6 +76,10 @@ LOOP:
 			break LOOP
 		}
 
+		if o.pos {
+			entry.Offset, entry.LineNo = start, lineNo
+		}
+
 		if scanF(entry) {
 			break LOOP
 		}
@@ -223,3 +223,68 @@ func TestForwardScanErrFuncExecutes(t *testing.T) {
 		t.Errorf("Error function was not called")
 	}
 }
+
+func TestForwardPosition(t *testing.T) {
+	var (
+		logs   []LogEntry
+		rdr    = strings.NewReader("1 alpha\n2 beta\r\n\n4 gamma")
+		parseF = func(line []byte) (LogEntry, error) {
+			if len(line) == 0 {
+				return LogEntry{}, io.ErrUnexpectedEOF
+			}
+			return LogEntry{Timestamp: int64(line[0] - '0'), Line: string(line)}, nil
+		}
+		scanF = func(entry LogEntry) bool {
+			logs = append(logs, entry)
+			return false
+		}
+		errF = func([]byte, error) error { return nil }
+	)
+
+	if err := ScanForward(rdr, parseF, scanF, WithPosition(true), WithErrFunc(errF)); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	exp := [][2]int64{{0, 1}, {8, 2}, {17, 4}}
+	if len(logs) != len(exp) {
+		t.Fatalf("Expected %d entries, got %d", len(exp), len(logs))
+	}
+	for i, e := range logs {
+		if e.Offset != exp[i][0] || e.LineNo != exp[i][1] {
+			t.Errorf("Entry %d: expected offset %d line %d, got %d %d", i, exp[i][0], exp[i][1], e.Offset, e.LineNo)
+		}
+	}
+}
+
+func TestForwardDockerPartial(t *testing.T) {
+	var (
+		logs []LogEntry
+		f    = format.NewDockerFactory().New()
+		rdr  = strings.NewReader(`{"log":"first ","stream":"stdout","time":"2024-02-13T15:12:44.1Z"}
+{"log":"line\n","stream":"stdout","time":"2024-02-13T15:12:44.2Z"}
+{"log":"second\n","stream":"stdout","time":"2024-02-13T15:12:44.3Z"}
+`)
+		scanF = func(entry LogEntry) bool {
+			logs = append(logs, entry)
+			return false
+		}
+		errF = func(line []byte, err error) error {
+			t.Errorf("Unexpected error %v on %q", err, line)
+			return nil
+		}
+	)
+
+	if err := ScanForward(rdr, f.ReadEntry, scanF, WithErrFunc(errF), WithFold(true)); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	exp := []string{"first line", "second"}
+	if len(logs) != len(exp) {
+		t.Fatalf("Expected %d entries, got %d", len(exp), len(logs))
+	}
+	for i, e := range logs {
+		if e.Line != exp[i] {
+			t.Errorf("Entry %d: expected %q, got %q", i, exp[i], e.Line)
+		}
+	}
+}
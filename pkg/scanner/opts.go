@@ -27,6 +27,7 @@ type scanOpt struct {
 	start int64
 	stop  int64
 	mark  int64
+	pos   bool
 	errF  ErrFuncT
 }
 
@@ -114,6 +115,15 @@ func WithMark(mark int64) ScanOptT {
 	}
 }
 
+// WithPosition sets the Offset and LineNo of each entry scanned forward,
+// relative to the start of the reader.  A folded entry is located by its
+// first line.
+func WithPosition(pos bool) ScanOptT {
+	return func(o *scanOpt) {
+		o.pos = pos
+	}
+}
+
 func WithErrFunc(errF ErrFuncT) ScanOptT {
 	return func(o *scanOpt) {
 		o.errF = errF
@@ -7,8 +7,14 @@ import (
 	"github.com/icza/backscanner"
 )
 
+// ScanReverse scans the lines of src from the end back.  Compressed sources
+// cannot be read backwards, and fail with ErrCompressed.
 func ScanReverse(src io.ReaderAt, parseF ParseFuncT, scanF ScanFuncT, opts ...ScanOptT) error {
 
+	if err := checkCompressed(src); err != nil {
+		return err
+	}
+
 	var (
 		err   error
 		o     = parseOpts(opts)
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ConnStream is a StreamI over a byte stream, each message framed with
+// wire.WriteFrame.
+type ConnStream struct {
+	rdr   *bufio.Reader
+	wr    io.Writer
+	maxSz int
+}
+
+// NewConnStream frames a Match stream over rw.
+func NewConnStream(rw io.ReadWriter, opts ...OptT) *ConnStream {
+	o := parseOpts(opts)
+	return &ConnStream{rdr: bufio.NewReader(rw), wr: rw, maxSz: o.maxSz}
+}
+
+func (c *ConnStream) Recv() (wire.RequestT, error) {
+	b, err := wire.ReadFrame(c.rdr, c.maxSz)
+	if err != nil {
+		return wire.RequestT{}, err
+	}
+	return wire.UnmarshalRequest(b)
+}
+
+func (c *ConnStream) Send(resp wire.ResponseT) error {
+	b, err := wire.MarshalResponse(resp)
+	if err != nil {
+		return err
+	}
+	return wire.WriteFrame(c.wr, b)
+}
+
+// Listen accepts connections on ln and serves a Match stream on each, until
+// ctx is done or accept fails.  When ctx is done the listener and every open
+// connection are closed, and Listen returns ctx.Err() once the sessions have
+// ended.  Session failures are logged and do not stop the listener.
+func Listen(ctx context.Context, ln net.Listener, opts ...OptT) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+
+			err := Serve(ctx, NewConnStream(conn, opts...))
+			if err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				log.Warn().Err(err).Str("remote", conn.RemoteAddr().String()).Msg("Match session failed")
+			}
+		}()
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire"
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire/wirepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPC is the Match service of wire.proto; each stream is a session run by
+// Serve.  Lines, as proto3 strings, must be valid UTF-8 over gRPC.
+type GRPC struct {
+	wirepb.UnimplementedMatchServer
+}
+
+// RegisterGRPC registers the Match service on s.
+func RegisterGRPC(s grpc.ServiceRegistrar) {
+	wirepb.RegisterMatchServer(s, GRPC{})
+}
+
+func (GRPC) Stream(s wirepb.Match_StreamServer) error {
+	return Serve(s.Context(), grpcStreamT{s})
+}
+
+// Adapts a generated stream to StreamI.  Messages convert by way of their
+// encoding, so the session sees exactly what the wire codec decodes.
+type grpcStreamT struct {
+	s wirepb.Match_StreamServer
+}
+
+func (g grpcStreamT) Recv() (wire.RequestT, error) {
+	req, err := g.s.Recv()
+	if err != nil {
+		return wire.RequestT{}, err
+	}
+
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return wire.RequestT{}, err
+	}
+	return wire.UnmarshalRequest(b)
+}
+
+func (g grpcStreamT) Send(resp wire.ResponseT) error {
+	b, err := wire.MarshalResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	var msg wirepb.Response
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	return g.s.Send(&msg)
+}
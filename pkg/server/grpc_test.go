@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire/wirepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPC(t *testing.T) {
+	var (
+		ln  = bufconn.Listen(1 << 20)
+		srv = grpc.NewServer()
+	)
+	RegisterGRPC(srv)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := wirepb.NewMatchClient(conn).Stream(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	for _, req := range []*wirepb.Request{
+		{Msg: &wirepb.Request_Entry{Entry: &wirepb.LogEntry{Line: "alpha", Timestamp: 1}}}, // No rules yet.
+		{Msg: &wirepb.Request_Rules{Rules: []byte(doc)}},
+		{Msg: &wirepb.Request_Entry{Entry: &wirepb.LogEntry{Line: "alpha", Timestamp: 2}}},
+		{Msg: &wirepb.Request_Entry{Entry: &wirepb.LogEntry{Line: "beta", Timestamp: 3}}},
+		{Msg: &wirepb.Request_Eval{Eval: 20}},
+	} {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	var resps []*wirepb.Response
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		resps = append(resps, resp)
+	}
+
+	if len(resps) != 3 {
+		t.Fatalf("Expected 3 responses, got %v", resps)
+	}
+	if resps[0].Error != ErrNoRules.Error() {
+		t.Errorf("Expected %q, got %v", ErrNoRules, resps[0])
+	}
+
+	pair := resps[1]
+	switch {
+	case pair.Rule != "pair" || pair.Hits.GetCnt() != 1:
+		t.Errorf("Expected a pair hit, got %v", pair)
+	case len(pair.Hits.Logs) != 2 || pair.Hits.Logs[1].Line != "beta":
+		t.Errorf("Expected alpha and beta, got %v", pair.Hits.Logs)
+	}
+	if r := resps[2]; r.Rule != "unrecovered" || r.Hits.GetFireStamp() != 20 {
+		t.Errorf("Expected an unrecovered hit at 20, got %v", r)
+	}
+}
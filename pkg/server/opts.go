@@ -0,0 +1,37 @@
+package server
+
+import (
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+)
+
+const (
+	MaxFrameSize = pool.MaxRecordSize
+)
+
+type OptT func(*optsT)
+
+type optsT struct {
+	maxSz int
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		maxSz: MaxFrameSize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithMaxSize bounds the size of a framed message read from a connection.
+// Defaults to MaxFrameSize.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz > 0 {
+			o.maxSz = maxSz
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package server runs compiled matchers on behalf of remote clients, so that
+// pipelines not written in Go can use the library as a sidecar.
+//
+// A client opens a Match stream, as declared in pkg/wire/wire.proto, pushes a
+// rule document, then pushes log entries and eval clocks.  Hits are streamed
+// back as they fire, tagged with the id of the rule.  The stream is transport
+// agnostic: RegisterGRPC serves it as the gRPC service of wire.proto, and
+// Listen serves the same messages framed over a plain socket.
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/rules"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire"
+)
+
+var (
+	ErrNoRules = errors.New("no rules loaded")
+)
+
+// StreamI is the server side of a Match stream.  Recv returns io.EOF once the
+// client has finished sending.
+type StreamI interface {
+	Recv() (wire.RequestT, error)
+	Send(wire.ResponseT) error
+}
+
+// Serve runs a match session on s until the client finishes, the stream
+// fails, or ctx is done.  A clean finish returns nil.
+//
+// A rules request compiles the document with rules.Compile and replaces any
+// rules already loaded; the state of the old matchers is discarded.  A request
+// that fails, such as a bad rule document or an entry sent before any rules,
+// is answered with a response carrying only Error, and the session continues.
+func Serve(ctx context.Context, s StreamI) error {
+	var sess sessionT
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req, err := s.Recv()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if err := sess.handle(s, req); err != nil {
+			return err
+		}
+	}
+}
+
+type sessionT struct {
+	ids     []string
+	scanner *scan.Scanner
+}
+
+// Handle a single request; returns an error only if the stream failed.
+func (r *sessionT) handle(s StreamI, req wire.RequestT) error {
+
+	if req.Rules != nil {
+		if err := r.load(req.Rules); err != nil {
+			return s.Send(wire.ResponseT{Error: err.Error()})
+		}
+		return nil
+	}
+
+	if r.scanner == nil {
+		return s.Send(wire.ResponseT{Error: ErrNoRules.Error()})
+	}
+
+	var serr error
+	cb := func(h scan.HitT) bool {
+		serr = s.Send(wire.ResponseT{Rule: r.ids[h.Idx], Hits: h.Hits})
+		return serr != nil
+	}
+
+	if req.Entry != nil {
+		r.scanner.ScanEntry(*req.Entry, cb)
+	} else {
+		r.scanner.Eval(req.Eval, cb)
+	}
+
+	return serr
+}
+
+func (r *sessionT) load(doc []byte) error {
+	rs, err := rules.Compile(doc)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ids      = make([]string, 0, len(rs))
+		matchers = make([]match.Matcher, 0, len(rs))
+	)
+	for _, rule := range rs {
+		ids = append(ids, rule.Id)
+		matchers = append(matchers, rule.Matcher)
+	}
+
+	// Entries arrive parsed; the scanner needs no parser.
+	sc, err := scan.New(nil, matchers)
+	if err != nil {
+		return err
+	}
+
+	r.ids, r.scanner = ids, sc
+	return nil
+}
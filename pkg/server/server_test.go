@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/rules"
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire"
+)
+
+const doc = `
+rules:
+  - id: pair
+    window: 10
+    terms:
+      - alpha
+      - beta
+  - id: unrecovered
+    window: 5
+    terms:
+      - alpha
+    resets:
+      - term: {raw: recovered}
+        window: 5
+        absolute: true
+`
+
+type streamT struct {
+	reqs  []wire.RequestT
+	resps []wire.ResponseT
+}
+
+func (s *streamT) Recv() (wire.RequestT, error) {
+	if len(s.reqs) == 0 {
+		return wire.RequestT{}, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *streamT) Send(resp wire.ResponseT) error {
+	s.resps = append(s.resps, resp)
+	return nil
+}
+
+func entry(line string, stamp int64) wire.RequestT {
+	return wire.RequestT{Entry: &wire.LogEntry{Line: line, Timestamp: stamp}}
+}
+
+func TestServe(t *testing.T) {
+	s := &streamT{reqs: []wire.RequestT{
+		entry("alpha", 1), // No rules yet.
+		{Rules: []byte(doc)},
+		entry("alpha", 2),
+		entry("beta", 3),
+		{Eval: 20},
+	}}
+
+	if err := Serve(context.Background(), s); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	if len(s.resps) != 3 {
+		t.Fatalf("Expected 3 responses, got %+v", s.resps)
+	}
+	if s.resps[0].Error != ErrNoRules.Error() {
+		t.Errorf("Expected %q, got %+v", ErrNoRules, s.resps[0])
+	}
+
+	exp := []struct {
+		rule  string
+		stamp int64
+		lines int
+	}{
+		{"pair", 3, 2},
+		{"unrecovered", 20, 1},
+	}
+	for i, e := range exp {
+		resp := s.resps[i+1]
+		switch {
+		case resp.Rule != e.rule:
+			t.Errorf("%d: Expected rule %q, got %q", i, e.rule, resp.Rule)
+		case resp.Hits.FireStamp != e.stamp:
+			t.Errorf("%d: Expected stamp %d, got %d", i, e.stamp, resp.Hits.FireStamp)
+		case len(resp.Hits.Logs) != e.lines:
+			t.Errorf("%d: Expected %d lines, got %d", i, e.lines, len(resp.Hits.Logs))
+		}
+	}
+}
+
+func TestServeBadRules(t *testing.T) {
+	s := &streamT{reqs: []wire.RequestT{
+		{Rules: []byte(doc)},
+		{Rules: []byte("rules: []")},
+		entry("alpha", 1),
+		entry("beta", 2),
+	}}
+
+	if err := Serve(context.Background(), s); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	// The failed load leaves the old rules in place.
+	if len(s.resps) != 2 {
+		t.Fatalf("Expected 2 responses, got %+v", s.resps)
+	}
+	if s.resps[0].Error == "" {
+		t.Errorf("Expected an error for %v, got %+v", rules.ErrNoRules, s.resps[0])
+	}
+	if s.resps[1].Rule != "pair" {
+		t.Errorf("Expected pair, got %+v", s.resps[1])
+	}
+}
+
+func TestServeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &streamT{reqs: []wire.RequestT{{Rules: []byte(doc)}}}
+	if err := Serve(ctx, s); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Listen(ctx, ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer conn.Close()
+
+	for _, req := range []wire.RequestT{
+		{Rules: []byte(doc)},
+		entry("alpha", 1),
+		entry("beta", 2),
+	} {
+		b, err := wire.MarshalRequest(req)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		if err := wire.WriteFrame(conn, b); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+
+	b, err := wire.ReadFrame(bufio.NewReader(conn), MaxFrameSize)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	resp, err := wire.UnmarshalResponse(b)
+	switch {
+	case err != nil:
+		t.Fatalf("Expected nil error, got %v", err)
+	case resp.Rule != "pair" || resp.Hits.Cnt != 1:
+		t.Errorf("Expected a pair hit, got %+v", resp)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	DefBatchSize = 1
+	DefRetries   = 3
+	DefBackoff   = 500 * time.Millisecond
+	DefTimeout   = 10 * time.Second
+
+	maxBackoff = 30 * time.Second
+)
+
+type OptT func(*optsT)
+
+type optsT struct {
+	client   *http.Client
+	batchSz  int
+	interval time.Duration
+	retries  int
+	backoff  time.Duration
+	tmpl     string
+	header   http.Header
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		client:  &http.Client{Timeout: DefTimeout},
+		batchSz: DefBatchSize,
+		retries: DefRetries,
+		backoff: DefBackoff,
+		header:  http.Header{"Content-Type": {"application/json"}},
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithClient posts with client rather than a default client with DefTimeout.
+func WithClient(client *http.Client) OptT {
+	return func(o *optsT) {
+		if client != nil {
+			o.client = client
+		}
+	}
+}
+
+// WithBatch holds hits until n are pending, posting them together.  With an
+// interval, Run also posts whatever is pending on each tick.
+func WithBatch(n int, interval time.Duration) OptT {
+	return func(o *optsT) {
+		if n > 0 {
+			o.batchSz = n
+		}
+		o.interval = interval
+	}
+}
+
+// WithRetries retries a failed post up to n times, backing off exponentially
+// from backoff.  Defaults to DefRetries and DefBackoff.
+func WithRetries(n int, backoff time.Duration) OptT {
+	return func(o *optsT) {
+		o.retries = max(n, 0)
+		if backoff > 0 {
+			o.backoff = backoff
+		}
+	}
+}
+
+// WithTemplate renders the body with a text/template rather than as a JSON
+// array of hits.  The template is executed with a PayloadT; the function
+// json encodes its argument.
+func WithTemplate(tmpl string) OptT {
+	return func(o *optsT) {
+		o.tmpl = tmpl
+	}
+}
+
+// WithHeader sets a request header; for example an authorization token, or
+// the content type of a templated body.
+func WithHeader(key, value string) OptT {
+	return func(o *optsT) {
+		o.header.Set(key, value)
+	}
+}
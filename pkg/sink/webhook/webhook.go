@@ -0,0 +1,234 @@
+// Package webhook posts hits to an HTTP endpoint, so that a rule firing can
+// raise an alert without any plumbing.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+var (
+	ErrURL    = errors.New("webhook url must be http or https")
+	ErrStatus = errors.New("webhook post failed")
+)
+
+// PayloadT is the data a WithTemplate template is executed with.
+type PayloadT struct {
+	Hits []match.Hit
+}
+
+// Sink posts hits to a URL.
+//
+// By default the body is a JSON array of hits in the stable hit schema, one
+// post per hit.  WithBatch gathers several hits into a post, and WithTemplate
+// renders the body from a template instead.
+//
+// A post that fails on the network, or with a 5xx or 429 status, is retried
+// with exponential backoff, honouring Retry-After in seconds.  Any other
+// status fails at once with ErrStatus.  The hits of a post that fails are
+// dropped, and counted in Stats.
+type Sink struct {
+	url  string
+	tmpl *template.Template
+	o    optsT
+
+	mu      sync.Mutex
+	pending []match.Hit
+	sent    uint64
+	failed  uint64
+}
+
+// StatsT counts the hits handled by a sink.
+type StatsT struct {
+	Sent    uint64 // Hits posted.
+	Failed  uint64 // Hits dropped on a failed post.
+	Pending int    // Hits held for the batch.
+}
+
+func New(rawURL string, opts ...OptT) (*Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrURL
+	}
+
+	s := &Sink{url: rawURL, o: parseOpts(opts)}
+
+	if s.o.tmpl != "" {
+		funcs := template.FuncMap{"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		}}
+		if s.tmpl, err = template.New("webhook").Funcs(funcs).Parse(s.o.tmpl); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Add queues the hits of rule, posting once the batch is full.  Hits without
+// a rule name are given rule.  Returns the error of the post, if any.
+func (s *Sink) Add(ctx context.Context, rule string, hits match.Hits) error {
+	s.mu.Lock()
+	for hit := range hits.Iter() {
+		if hit.Rule == "" {
+			hit.Rule = rule
+		}
+		s.pending = append(s.pending, hit)
+	}
+
+	if len(s.pending) < s.o.batchSz {
+		s.mu.Unlock()
+		return nil
+	}
+
+	batch := s.take()
+	s.mu.Unlock()
+
+	return s.post(ctx, batch)
+}
+
+// Flush posts any pending hits.
+func (s *Sink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	batch := s.take()
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return s.post(ctx, batch)
+}
+
+// Run flushes on each tick of the WithBatch interval until ctx is done, then
+// flushes once more without ctx, so that pending hits are not lost.  Post
+// failures are counted in Stats.  Returns at once without an interval.
+func (s *Sink) Run(ctx context.Context) {
+	if s.o.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(ctx)
+		case <-ctx.Done():
+			s.Flush(context.WithoutCancel(ctx))
+			return
+		}
+	}
+}
+
+// Stats returns the sink counters.
+func (s *Sink) Stats() StatsT {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return StatsT{Sent: s.sent, Failed: s.failed, Pending: len(s.pending)}
+}
+
+// Must hold the lock.
+func (s *Sink) take() []match.Hit {
+	batch := s.pending
+	s.pending = nil
+	return batch
+}
+
+func (s *Sink) post(ctx context.Context, batch []match.Hit) error {
+	err := s.postBatch(ctx, batch)
+
+	s.mu.Lock()
+	if err != nil {
+		s.failed += uint64(len(batch))
+	} else {
+		s.sent += uint64(len(batch))
+	}
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *Sink) postBatch(ctx context.Context, batch []match.Hit) error {
+	body, err := s.render(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.o.backoff
+	for attempt := 0; ; attempt++ {
+		wait, err := s.try(ctx, body)
+		if err == nil || wait < 0 || attempt == s.o.retries {
+			return err
+		}
+
+		wait = max(wait, backoff)
+		backoff = min(2*backoff, maxBackoff)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// Post body once.  On failure returns the least wait before a retry, or -1 if
+// the failure is not worth a retry.
+func (s *Sink) try(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header = s.o.header.Clone()
+
+	resp, err := s.o.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, err
+		}
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		var wait time.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			wait = min(time.Duration(secs)*time.Second, maxBackoff)
+		}
+		return wait, fmt.Errorf("%w: %s", ErrStatus, resp.Status)
+	}
+	return -1, fmt.Errorf("%w: %s", ErrStatus, resp.Status)
+}
+
+func (s *Sink) render(batch []match.Hit) ([]byte, error) {
+	if s.tmpl == nil {
+		return json.Marshal(batch)
+	}
+
+	var buf bytes.Buffer
+	if err := s.tmpl.Execute(&buf, PayloadT{Hits: batch}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
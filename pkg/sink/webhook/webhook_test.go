@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+type serverT struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	statuses []int // Served in turn, then 200.
+}
+
+func newServer(t *testing.T, statuses ...int) *serverT {
+	s := &serverT{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		s.headers = append(s.headers, r.Header)
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *serverT) posts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.bodies...)
+}
+
+func makeHits(lines ...string) match.Hits {
+	var h match.Hits
+	for i, line := range lines {
+		h.Cnt += 1
+		h.Logs = append(h.Logs, match.LogEntry{Line: line, Timestamp: int64(i + 1)})
+	}
+	return h
+}
+
+func TestSink(t *testing.T) {
+	srv := newServer(t)
+
+	s, err := New(srv.URL, WithHeader("Authorization", "Bearer x"))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	if err := s.Add(context.Background(), "oom", makeHits("alpha", "beta")); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	posts := srv.posts()
+	if len(posts) != 1 {
+		t.Fatalf("Expected 1 post, got %d", len(posts))
+	}
+
+	var hits []struct {
+		Rule string `json:"rule"`
+		Logs []struct {
+			Line string `json:"line"`
+		} `json:"logs"`
+	}
+	if err := json.Unmarshal([]byte(posts[0]), &hits); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if len(hits) != 2 || hits[0].Rule != "oom" || hits[1].Logs[0].Line != "beta" {
+		t.Errorf("Expected 2 oom hits, got %+v", hits)
+	}
+
+	h := srv.headers[0]
+	if h.Get("Authorization") != "Bearer x" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected headers, got %v", h)
+	}
+	if st := s.Stats(); st != (StatsT{Sent: 2}) {
+		t.Errorf("Expected stats, got %+v", st)
+	}
+}
+
+func TestSinkBatch(t *testing.T) {
+	srv := newServer(t)
+
+	s, err := New(srv.URL, WithBatch(3, 0))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+	s.Add(ctx, "r1", makeHits("alpha"))
+	s.Add(ctx, "r2", makeHits("beta"))
+	if n := len(srv.posts()); n != 0 {
+		t.Fatalf("Expected no post, got %d", n)
+	}
+	if st := s.Stats(); st.Pending != 2 {
+		t.Errorf("Expected 2 pending, got %+v", st)
+	}
+
+	s.Add(ctx, "r3", makeHits("gamma", "delta"))
+	s.Add(ctx, "r4", makeHits("epsilon"))
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	posts := srv.posts()
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 posts, got %d", len(posts))
+	}
+
+	var sizes []int
+	for _, p := range posts {
+		var hits []any
+		json.Unmarshal([]byte(p), &hits)
+		sizes = append(sizes, len(hits))
+	}
+	if sizes[0] != 4 || sizes[1] != 1 {
+		t.Errorf("Expected batches of 4 and 1, got %v", sizes)
+	}
+
+	// Nothing pending; nothing posted.
+	if err := s.Flush(ctx); err != nil || len(srv.posts()) != 2 {
+		t.Errorf("Expected no post, got %v", err)
+	}
+}
+
+func TestSinkRetry(t *testing.T) {
+	srv := newServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	s, err := New(srv.URL, WithRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := s.Add(context.Background(), "r", makeHits("alpha")); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if n := len(srv.posts()); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// Out of retries.
+	srv = newServer(t, 500, 500, 500)
+	s, _ = New(srv.URL, WithRetries(1, time.Millisecond))
+	if err := s.Add(context.Background(), "r", makeHits("alpha")); !errors.Is(err, ErrStatus) {
+		t.Errorf("Expected %v, got %v", ErrStatus, err)
+	}
+	if n := len(srv.posts()); n != 2 {
+		t.Errorf("Expected 2 attempts, got %d", n)
+	}
+
+	// Client errors are not retried.
+	srv = newServer(t, http.StatusBadRequest)
+	s, _ = New(srv.URL, WithRetries(3, time.Millisecond))
+	if err := s.Add(context.Background(), "r", makeHits("alpha")); !errors.Is(err, ErrStatus) {
+		t.Errorf("Expected %v, got %v", ErrStatus, err)
+	}
+	if n := len(srv.posts()); n != 1 {
+		t.Errorf("Expected 1 attempt, got %d", n)
+	}
+	if st := s.Stats(); st != (StatsT{Failed: 1}) {
+		t.Errorf("Expected stats, got %+v", st)
+	}
+}
+
+func TestSinkTemplate(t *testing.T) {
+	srv := newServer(t)
+
+	tmpl := `{"text":{{json (printf "%d hits" (len .Hits))}},"rules":[{{range $i, $h := .Hits}}{{if $i}},{{end}}{{json $h.Rule}}{{end}}]}`
+	s, err := New(srv.URL, WithTemplate(tmpl), WithBatch(2, 0))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := s.Add(context.Background(), "oom", makeHits("alpha", "beta")); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	exp := `{"text":"2 hits","rules":["oom","oom"]}`
+	if posts := srv.posts(); len(posts) != 1 || posts[0] != exp {
+		t.Errorf("Expected %s, got %v", exp, posts)
+	}
+}
+
+func TestSinkRun(t *testing.T) {
+	srv := newServer(t)
+
+	s, err := New(srv.URL, WithBatch(10, time.Hour))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { s.Run(ctx); close(done) }()
+
+	s.Add(ctx, "r", makeHits("alpha"))
+	cancel()
+	<-done
+
+	if n := len(srv.posts()); n != 1 {
+		t.Errorf("Expected the final flush, got %d posts", n)
+	}
+}
+
+func TestSinkFail(t *testing.T) {
+	if _, err := New("ftp://example.com"); !errors.Is(err, ErrURL) {
+		t.Errorf("Expected %v, got %v", ErrURL, err)
+	}
+	if _, err := New("http://example.com", WithTemplate("{{")); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
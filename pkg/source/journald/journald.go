@@ -0,0 +1,279 @@
+// Package journald reads systemd journal entries as log entries.
+//
+// The journal is read through journalctl's JSON export, one object per line,
+// which needs neither cgo nor libsystemd.  A Source satisfies stream.SourceI.
+package journald
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+var ErrNoTimestamp = errors.New("journal entry has no __REALTIME_TIMESTAMP")
+
+// Journal fields read into an entry.
+const (
+	FieldMessage   = "MESSAGE"
+	FieldRealtime  = "__REALTIME_TIMESTAMP"
+	FieldCursor    = "__CURSOR"
+	FieldUnit      = "_SYSTEMD_UNIT"
+	FieldHostname  = "_HOSTNAME"
+	FieldIdent     = "SYSLOG_IDENTIFIER"
+	FieldPriority  = "PRIORITY"
+	FieldTransport = "_TRANSPORT"
+)
+
+// Fields copied into the labels of each entry, under their journal names.
+var labelFields = []string{FieldUnit, FieldHostname, FieldIdent, FieldPriority, FieldTransport}
+
+type LogEntry = match.LogEntry
+
+// Source yields the entries of a journal.
+//
+// The timestamp of an entry is __REALTIME_TIMESTAMP, the time the journal
+// received it.  The line is MESSAGE, or with WithFieldLine the JSON object of
+// every field.  Binary field values, exported as arrays of bytes, are decoded
+// to strings; a field that occurs more than once becomes an array of strings
+// in the field line, and its first value elsewhere.  The severity is that of
+// PRIORITY, a syslog severity code.
+type Source struct {
+	scanner *bufio.Scanner
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	cursor  string
+	lineNo  int64
+	o       optsT
+}
+
+// Open runs journalctl with the options given, reading its output.  The
+// process ends with ctx or Close; the caller must Close the source.
+func Open(ctx context.Context, opts ...OptT) (*Source, error) {
+	o := parseOpts(opts)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cmd := exec.CommandContext(ctx, o.command, args(o)...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := newSource(stdout, o)
+	s.cmd, s.cancel = cmd, cancel
+	return s, nil
+}
+
+// NewReaderSource reads journal entries in the JSON export of journalctl from
+// rdr; for example a saved export.  Options for the command are ignored.
+func NewReaderSource(rdr io.Reader, opts ...OptT) *Source {
+	return newSource(rdr, parseOpts(opts))
+}
+
+func newSource(rdr io.Reader, o optsT) *Source {
+	scanner := bufio.NewScanner(rdr)
+	scanner.Buffer(make([]byte, 0, min(o.maxSz, 64<<10)), o.maxSz)
+	return &Source{scanner: scanner, o: o}
+}
+
+func args(o optsT) []string {
+	args := []string{"--output=json", "--no-pager", "--quiet"}
+
+	switch {
+	case o.cursor != "":
+		args = append(args, "--after-cursor="+o.cursor)
+	case !o.since.IsZero():
+		args = append(args, "--since=@"+strconv.FormatInt(o.since.Unix(), 10))
+	}
+	if o.follow {
+		args = append(args, "--follow")
+	}
+	if o.directory != "" {
+		args = append(args, "--directory="+o.directory)
+	}
+	for _, unit := range o.units {
+		args = append(args, "--unit="+unit)
+	}
+	return args
+}
+
+// Next returns the next entry, or io.EOF at the end of the journal.
+func (s *Source) Next() (LogEntry, error) {
+	for s.scanner.Scan() {
+		data := s.scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		s.lineNo += 1
+
+		e, cursor, err := s.entry(data)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("journal record %d: %w", s.lineNo, err)
+		}
+		s.cursor = cursor
+		return e, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return LogEntry{}, err
+	}
+	if s.cmd != nil {
+		if err := s.wait(); err != nil {
+			return LogEntry{}, err
+		}
+	}
+	return LogEntry{}, io.EOF
+}
+
+// Cursor returns the cursor of the last entry returned, for WithCursor.
+func (s *Source) Cursor() string {
+	return s.cursor
+}
+
+// Close ends journalctl for a source from Open.
+func (s *Source) Close() error {
+	if s.cmd == nil {
+		return nil
+	}
+	s.cancel()
+	s.wait()
+	return nil
+}
+
+func (s *Source) wait() error {
+	cmd := s.cmd
+	s.cmd = nil
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s: %w", s.o.command, err)
+	}
+	return nil
+}
+
+func (s *Source) entry(data []byte) (LogEntry, string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return LogEntry{}, "", err
+	}
+
+	fields := make(map[string][]string, len(raw))
+	for k, v := range raw {
+		vals, err := decodeField(v)
+		if err != nil {
+			return LogEntry{}, "", fmt.Errorf("field %s: %w", k, err)
+		}
+		fields[k] = vals
+	}
+
+	first := func(k string) string {
+		if v := fields[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	usec, err := strconv.ParseInt(first(FieldRealtime), 10, 64)
+	if err != nil {
+		return LogEntry{}, "", ErrNoTimestamp
+	}
+
+	e := LogEntry{
+		Timestamp: time.UnixMicro(usec).UnixNano(),
+		Line:      first(FieldMessage),
+		LineNo:    s.lineNo,
+	}
+
+	if s.o.fieldLine {
+		obj := make(map[string]any, len(fields))
+		for k, v := range fields {
+			if len(v) == 1 {
+				obj[k] = v[0]
+			} else {
+				obj[k] = v
+			}
+		}
+		line, err := json.Marshal(obj)
+		if err != nil {
+			return LogEntry{}, "", err
+		}
+		e.Line = string(line)
+	}
+
+	if pri, err := strconv.Atoi(first(FieldPriority)); err == nil {
+		e.Severity = entry.SyslogSeverity(pri)
+	}
+
+	for _, k := range labelFields {
+		if v := first(k); v != "" {
+			if e.Labels == nil {
+				e.Labels = make(map[string]string, len(labelFields))
+			}
+			e.Labels[k] = v
+		}
+	}
+
+	return e, first(FieldCursor), nil
+}
+
+// A field is exported as a string, an array of bytes if it is not valid
+// UTF-8, null if too large, or an array of either if it repeats.
+func decodeField(raw json.RawMessage) ([]string, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		if s, ok := bytesOf(v); ok {
+			return []string{s}, nil
+		}
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				out = append(out, item)
+			case []any:
+				if s, ok := bytesOf(item); ok {
+					out = append(out, s)
+					continue
+				}
+				return nil, errors.New("bad field value")
+			default:
+				return nil, errors.New("bad field value")
+			}
+		}
+		return out, nil
+	}
+	return nil, errors.New("bad field value")
+}
+
+// An array of byte values, as a string; false if not one.
+func bytesOf(v []any) (string, bool) {
+	b := make([]byte, 0, len(v))
+	for _, item := range v {
+		n, ok := item.(float64)
+		if !ok || n < 0 || n > 255 || n != float64(byte(n)) {
+			return "", false
+		}
+		b = append(b, byte(n))
+	}
+	return string(b), true
+}
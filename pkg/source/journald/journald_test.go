@@ -0,0 +1,161 @@
+package journald
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+const export = `{"__CURSOR":"s=1","__REALTIME_TIMESTAMP":"1700000000000001","MESSAGE":"Started sshd","_SYSTEMD_UNIT":"sshd.service","_HOSTNAME":"node-1","PRIORITY":"6"}
+
+{"__CURSOR":"s=2","__REALTIME_TIMESTAMP":"1700000000000002","MESSAGE":[104,105,255],"_SYSTEMD_UNIT":"sshd.service","TAG":["a","b"],"BIG":null}
+{"__CURSOR":"s=3","__REALTIME_TIMESTAMP":"1700000000000003","MESSAGE":"Failed password for root","_SYSTEMD_UNIT":"sshd.service","PRIORITY":"3"}
+`
+
+func drain(t *testing.T, src *Source) []LogEntry {
+	t.Helper()
+	var out []LogEntry
+	for {
+		e, err := src.Next()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		out = append(out, e)
+	}
+}
+
+func TestReaderSource(t *testing.T) {
+	src := NewReaderSource(strings.NewReader(export))
+	entries := drain(t, src)
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	exp := LogEntry{
+		Line:      "Started sshd",
+		Timestamp: time.UnixMicro(1700000000000001).UnixNano(),
+		LineNo:    1,
+		Labels:    map[string]string{FieldUnit: "sshd.service", FieldHostname: "node-1", FieldPriority: "6"},
+		Severity:  entry.SevInfo,
+	}
+	if !reflect.DeepEqual(entries[0], exp) {
+		t.Errorf("Expected %+v, got %+v", exp, entries[0])
+	}
+	if entries[1].Line != "hi\xff" {
+		t.Errorf("Expected binary message, got %q", entries[1].Line)
+	}
+	if src.Cursor() != "s=3" {
+		t.Errorf("Expected cursor s=3, got %q", src.Cursor())
+	}
+}
+
+func TestFieldLine(t *testing.T) {
+	entries := drain(t, NewReaderSource(strings.NewReader(export), WithFieldLine()))
+
+	m, err := match.NewMatchSingle(match.TermT{
+		Type:  match.TermJqJson,
+		Value: `._SYSTEMD_UNIT == "sshd.service" and .PRIORITY == "3" and (.MESSAGE | test("Failed"))`,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	var hits int
+	for _, e := range entries {
+		hits += m.Scan(match.NewScanLine().Reset(e)).Cnt
+	}
+	if hits != 1 {
+		t.Errorf("Expected 1 hit, got %d", hits)
+	}
+
+	if !strings.Contains(entries[1].Line, `"TAG":["a","b"]`) {
+		t.Errorf("Expected repeated field as an array, got %s", entries[1].Line)
+	}
+}
+
+func TestReaderSourceFail(t *testing.T) {
+	bad := map[string]string{
+		"NotJson":     "nope\n",
+		"NoTimestamp": `{"MESSAGE":"x"}` + "\n",
+		"BadValue":    `{"__REALTIME_TIMESTAMP":"1","MESSAGE":{"a":1}}` + "\n",
+	}
+	for name, data := range bad {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewReaderSource(strings.NewReader(data)).Next(); err == nil || err == io.EOF {
+				t.Errorf("Expected error, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewReaderSource(strings.NewReader(`{"MESSAGE":"x"}`)).Next(); !errors.Is(err, ErrNoTimestamp) {
+		t.Errorf("Expected %v, got %v", ErrNoTimestamp, err)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	o := parseOpts([]OptT{WithUnits("a.service", "b.service"), WithFollow(), WithSince(time.Unix(100, 0)), WithDirectory("/var/log/journal")})
+	exp := []string{"--output=json", "--no-pager", "--quiet", "--since=@100", "--follow", "--directory=/var/log/journal", "--unit=a.service", "--unit=b.service"}
+	if got := args(o); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %v, got %v", exp, got)
+	}
+
+	// The cursor wins over since.
+	o = parseOpts([]OptT{WithSince(time.Unix(100, 0)), WithCursor("s=9")})
+	if got := args(o); got[3] != "--after-cursor=s=9" || len(got) != 4 {
+		t.Errorf("Expected cursor, got %v", got)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		data = filepath.Join(dir, "export.json")
+		cmd  = filepath.Join(dir, "journalctl")
+	)
+	if err := os.WriteFile(data, []byte(export), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cmd, []byte("#!/bin/sh\ncat "+data+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Open(context.Background(), WithCommand(cmd))
+	if err != nil {
+		t.Skipf("Cannot run command: %v", err)
+	}
+	defer src.Close()
+
+	if entries := drain(t, src); len(entries) != 3 {
+		t.Errorf("Expected 3 entries, got %d", len(entries))
+	}
+
+	// A failing command is reported at the end of its output.
+	if err := os.WriteFile(cmd, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src, err = Open(context.Background(), WithCommand(cmd))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer src.Close()
+	if _, err := src.Next(); err == nil || err == io.EOF {
+		t.Errorf("Expected exit error, got %v", err)
+	}
+
+	if _, err := Open(context.Background(), WithCommand(filepath.Join(dir, "missing"))); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
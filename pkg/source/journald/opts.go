@@ -0,0 +1,101 @@
+package journald
+
+import (
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+)
+
+const (
+	DefCommand    = "journalctl"
+	MaxRecordSize = pool.MaxRecordSize
+)
+
+type OptT func(*optsT)
+
+type optsT struct {
+	command   string
+	units     []string
+	follow    bool
+	cursor    string
+	since     time.Time
+	directory string
+	fieldLine bool
+	maxSz     int
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		command: DefCommand,
+		maxSz:   MaxRecordSize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithUnits reads only the entries of the given systemd units.
+func WithUnits(units ...string) OptT {
+	return func(o *optsT) {
+		o.units = append(o.units, units...)
+	}
+}
+
+// WithFollow waits for new entries once the journal is read, rather than
+// ending with io.EOF.
+func WithFollow() OptT {
+	return func(o *optsT) {
+		o.follow = true
+	}
+}
+
+// WithCursor resumes after the entry at cursor; see Source.Cursor.
+func WithCursor(cursor string) OptT {
+	return func(o *optsT) {
+		o.cursor = cursor
+	}
+}
+
+// WithSince reads entries from t onwards.  Ignored if a cursor is set.
+func WithSince(t time.Time) OptT {
+	return func(o *optsT) {
+		o.since = t
+	}
+}
+
+// WithDirectory reads the journal files in dir rather than the system journal.
+func WithDirectory(dir string) OptT {
+	return func(o *optsT) {
+		o.directory = dir
+	}
+}
+
+// WithFieldLine sets the line of each entry to the JSON object of all its
+// fields, rather than MESSAGE, so that jq terms can match any field; for
+// example '._SYSTEMD_UNIT == "sshd.service" and (.MESSAGE | test("Failed"))'.
+func WithFieldLine() OptT {
+	return func(o *optsT) {
+		o.fieldLine = true
+	}
+}
+
+// WithCommand sets the journalctl binary.  Defaults to DefCommand on PATH.
+func WithCommand(command string) OptT {
+	return func(o *optsT) {
+		o.command = command
+	}
+}
+
+// WithMaxSize sets the largest journal record accepted.  A longer record
+// aborts the source with bufio.ErrTooLong.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz <= 0 || maxSz > MaxRecordSize {
+			maxSz = MaxRecordSize
+		}
+		o.maxSz = maxSz
+	}
+}
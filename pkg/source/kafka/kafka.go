@@ -0,0 +1,393 @@
+// Package kafka runs a rule set over records consumed from Kafka topics and
+// publishes the hits to an output topic.
+//
+// The package is independent of any Kafka client; ConsumerI and ProducerI are
+// implemented by a thin adapter over the client of choice.
+package kafka
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/rules"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+)
+
+var ErrNoConsumer = errors.New("kafka bridge requires a consumer and a producer")
+
+// Labels set on each entry built from a record.
+const (
+	LabelTopic     = "kafka.topic"
+	LabelPartition = "kafka.partition"
+	LabelKey       = "kafka.key"
+)
+
+type LogEntry = match.LogEntry
+
+// RecordT is a Kafka record, as consumed or produced.
+type RecordT struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Timestamp int64 // Nanoseconds since the epoch.
+}
+
+// ConsumerI polls records from the subscribed topics.  Records of a partition
+// must be returned in offset order.
+type ConsumerI interface {
+	// Poll blocks until records are available, or ctx is done.
+	Poll(ctx context.Context) ([]RecordT, error)
+
+	// Commit records offset as the next to consume from the partition.
+	Commit(ctx context.Context, topic string, partition int32, offset int64) error
+}
+
+// ProducerI publishes a record; it returns once the record is acknowledged.
+type ProducerI interface {
+	Produce(ctx context.Context, rec RecordT) error
+}
+
+// KeyRecord is a match.KeyFn on the key of the record an entry came from.
+func KeyRecord(e *match.ScanLine) string {
+	return e.Labels[LabelKey]
+}
+
+// Bridge consumes records, scans them through a rule set, and publishes each
+// hit to the output topic as JSON in the stable hit schema, keyed by the key of
+// the record that completed it.
+//
+// Each partition has matchers of its own, since records are only ordered within
+// a partition.  WithKey further splits each partition by a correlation key.
+// The matchers are evaluated every WithEvalInterval, so that rules waiting on
+// time, such as those with resets, fire on a quiet partition.  Evaluation runs
+// on the ingest clock, translated to record time as match.DualClock does; a
+// backlog consumed at speed does not fire them early.
+//
+// A partition is committed through the records that no longer bear on a
+// match: those stamped before the oldest line held by its matchers, as
+// match.Oldest.  Commits follow the hits published, and every evaluation.  A
+// restart therefore replays the records that partial matches were built from,
+// rebuilding them, and no hit is lost.  A hit may be published twice, should
+// the records that completed it be replayed.
+type Bridge struct {
+	consumer ConsumerI
+	producer ProducerI
+	topic    string
+	rules    []rules.RuleT
+	parts    map[partKeyT]*partT
+	o        optsT
+	err      error // Failure to build a keyed matcher; see keyed.
+}
+
+type partKeyT struct {
+	topic     string
+	partition int32
+}
+
+type partT struct {
+	ids      []string
+	matchers []match.Matcher
+	scanner  *scan.Scanner
+	parser   format.ParserI
+	labels   map[string]string
+
+	scanned   []recordStampT // Records scanned since the last commit, in offset order.
+	next      int64          // Offset following the last record consumed.
+	committed int64          // Offset last committed, or the first consumed.
+}
+
+type recordStampT struct {
+	offset int64
+	stamp  int64
+}
+
+// New compiles the rule document, as rules.Compile, for a bridge publishing
+// hits to topic.
+func New(consumer ConsumerI, producer ProducerI, topic string, doc []byte, opts ...OptT) (*Bridge, error) {
+	if consumer == nil || producer == nil {
+		return nil, ErrNoConsumer
+	}
+
+	rs, err := rules.Compile(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bridge{
+		consumer: consumer,
+		producer: producer,
+		topic:    topic,
+		rules:    rs,
+		parts:    make(map[partKeyT]*partT),
+		o:        parseOpts(opts),
+	}, nil
+}
+
+// Run consumes until ctx is done, or the consumer, producer or error function
+// fails.  Polls are cut short by each evaluation due.
+func (b *Bridge) Run(ctx context.Context) error {
+	next := time.Now().Add(b.o.interval)
+
+	for {
+		pctx, cancel := context.WithDeadline(ctx, next)
+		recs, err := b.consumer.Poll(pctx)
+		due := pctx.Err() != nil
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && !due:
+			return err
+		}
+
+		for _, rec := range recs {
+			if err := b.Process(ctx, rec); err != nil {
+				return err
+			}
+		}
+
+		if now := time.Now(); !now.Before(next) {
+			if err := b.Eval(ctx, now.UnixNano()); err != nil {
+				return err
+			}
+			next = now.Add(b.o.interval)
+		}
+	}
+}
+
+// Process scans a single record, publishing any hits and then committing the
+// partition.
+func (b *Bridge) Process(ctx context.Context, rec RecordT) error {
+	p, err := b.part(rec)
+	if err != nil {
+		return err
+	}
+	p.next = rec.Offset + 1
+
+	e, err := p.entry(rec)
+	if err != nil {
+		return b.o.errF(rec, err)
+	}
+	if e.IngestTime == 0 {
+		e.IngestTime = time.Now().UnixNano()
+	}
+	p.scanned = append(p.scanned, recordStampT{offset: rec.Offset, stamp: e.Timestamp})
+
+	var out []match.Hit
+	p.scanner.ScanEntry(e, p.collect(&out))
+
+	if b.err != nil {
+		return b.err
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	if err := b.publish(ctx, out); err != nil {
+		return err
+	}
+	return b.commit(ctx, rec.Topic, rec.Partition, p)
+}
+
+// Eval evaluates every partition at clock, an ingest time in nanoseconds since
+// the epoch, publishing any hits; then commits every partition.  Run calls Eval
+// every WithEvalInterval.
+func (b *Bridge) Eval(ctx context.Context, clock int64) error {
+	keys := slices.SortedFunc(maps.Keys(b.parts), func(a, b partKeyT) int {
+		return cmp.Or(cmp.Compare(a.topic, b.topic), cmp.Compare(a.partition, b.partition))
+	})
+
+	for _, pk := range keys {
+		var (
+			p   = b.parts[pk]
+			out []match.Hit
+		)
+		p.scanner.Eval(clock, p.collect(&out))
+
+		if err := b.publish(ctx, out); err != nil {
+			return err
+		}
+		if err := b.commit(ctx, pk.topic, pk.partition, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish hits in order, each once acknowledged.
+func (b *Bridge) publish(ctx context.Context, out []match.Hit) error {
+	for _, hit := range out {
+		value, err := json.Marshal(hit)
+		if err != nil {
+			return err
+		}
+
+		var key []byte
+		if n := len(hit.Logs); n > 0 {
+			if k, ok := hit.Logs[n-1].Labels[LabelKey]; ok {
+				key = []byte(k)
+			}
+		}
+
+		if err := b.producer.Produce(ctx, RecordT{Topic: b.topic, Key: key, Value: value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit the partition through the records stamped before the oldest line its
+// matchers hold, if that moves the offset on.
+func (b *Bridge) commit(ctx context.Context, topic string, partition int32, p *partT) error {
+	var (
+		oldest int64
+		held   bool
+	)
+	for _, m := range p.matchers {
+		if ts, ok := match.Oldest(m); ok && (!held || ts < oldest) {
+			oldest, held = ts, true
+		}
+	}
+
+	var i int
+	for i < len(p.scanned) && (!held || p.scanned[i].stamp < oldest) {
+		i++
+	}
+	p.scanned = slices.Delete(p.scanned, 0, i)
+
+	offset := p.next
+	if len(p.scanned) > 0 {
+		offset = p.scanned[0].offset
+	}
+	if offset <= p.committed {
+		return nil
+	}
+
+	if err := b.consumer.Commit(ctx, topic, partition, offset); err != nil {
+		return err
+	}
+	p.committed = offset
+	return nil
+}
+
+// Partitions returns the number of partitions seen.
+func (b *Bridge) Partitions() int {
+	return len(b.parts)
+}
+
+func (b *Bridge) part(rec RecordT) (*partT, error) {
+	pk := partKeyT{topic: rec.Topic, partition: rec.Partition}
+	if p, ok := b.parts[pk]; ok {
+		return p, nil
+	}
+
+	var (
+		ids      = make([]string, 0, len(b.rules))
+		matchers = make([]match.Matcher, 0, len(b.rules))
+	)
+	for _, rule := range b.rules {
+		var (
+			m   match.Matcher
+			err error
+		)
+		if b.o.keyFn == nil {
+			m, err = rule.New()
+		} else {
+			m, err = match.NewKeyedMatcher(b.o.keyFn, b.keyed(rule), b.o.keyOpts...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, rule.Id)
+		matchers = append(matchers, match.NewDualClock(m))
+	}
+
+	// Entries are built here; the scanner needs no parser.
+	sc, err := scan.New(nil, matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &partT{
+		ids:       ids,
+		matchers:  matchers,
+		scanner:   sc,
+		committed: rec.Offset,
+		labels: map[string]string{
+			LabelTopic:     rec.Topic,
+			LabelPartition: strconv.Itoa(int(rec.Partition)),
+		},
+	}
+	if b.o.factory != nil {
+		p.parser = b.o.factory.New()
+	}
+
+	b.parts[pk] = p
+	return p, nil
+}
+
+// Factory of the keyed partitions of rule, which are built while scanning; a
+// failure is held for Process to return.
+func (b *Bridge) keyed(rule rules.RuleT) func() match.Matcher {
+	return func() match.Matcher {
+		m, err := rule.New()
+		if err != nil {
+			b.err = err
+			return nil
+		}
+		return m
+	}
+}
+
+// Gather the hits of the partition, tagged with their rule.
+func (p *partT) collect(out *[]match.Hit) scan.HitFuncT {
+	return func(h scan.HitT) bool {
+		for hit := range h.Hits.Iter() {
+			if hit.Rule == "" {
+				hit.Rule = p.ids[h.Idx]
+			}
+			*out = append(*out, hit)
+		}
+		return false
+	}
+}
+
+func (p *partT) entry(rec RecordT) (LogEntry, error) {
+	var e LogEntry
+
+	if p.parser == nil {
+		e = LogEntry{Line: string(rec.Value), Timestamp: rec.Timestamp}
+	} else {
+		var err error
+		if e, err = p.parser.ReadEntry(rec.Value); err != nil {
+			return e, err
+		}
+	}
+
+	e.Offset = rec.Offset
+
+	// Entries without a record key share the partition's map.
+	switch {
+	case rec.Key == nil && e.Labels == nil:
+		e.Labels = p.labels
+	default:
+		labels := maps.Clone(p.labels)
+		maps.Copy(labels, e.Labels)
+		if rec.Key != nil {
+			labels[LabelKey] = string(rec.Key)
+		}
+		e.Labels = labels
+	}
+
+	return e, nil
+}
@@ -0,0 +1,273 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+)
+
+const doc = `
+rules:
+  - id: pair
+    window: 10
+    terms:
+      - alpha
+      - beta
+`
+
+var errDone = errors.New("done")
+
+type commitT struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+type fakeT struct {
+	batches [][]RecordT
+	waits   int // Polls that wait on ctx once the batches are done.
+	commits []commitT
+	out     []RecordT
+}
+
+func (f *fakeT) Poll(ctx context.Context) ([]RecordT, error) {
+	if len(f.batches) == 0 {
+		if f.waits == 0 {
+			return nil, errDone
+		}
+		f.waits--
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	b := f.batches[0]
+	f.batches = f.batches[1:]
+	return b, nil
+}
+
+func (f *fakeT) Commit(ctx context.Context, topic string, partition int32, offset int64) error {
+	f.commits = append(f.commits, commitT{topic, partition, offset})
+	return nil
+}
+
+func (f *fakeT) Produce(ctx context.Context, rec RecordT) error {
+	f.out = append(f.out, rec)
+	return nil
+}
+
+func rec(partition int32, offset int64, key, value string) RecordT {
+	r := RecordT{Topic: "logs", Partition: partition, Offset: offset, Value: []byte(value), Timestamp: offset}
+	if key != "" {
+		r.Key = []byte(key)
+	}
+	return r
+}
+
+func run(t *testing.T, f *fakeT, opts ...OptT) *Bridge {
+	t.Helper()
+	return runDoc(t, f, doc, opts...)
+}
+
+func runDoc(t *testing.T, f *fakeT, doc string, opts ...OptT) *Bridge {
+	t.Helper()
+	b, err := New(f, f, "hits", []byte(doc), opts...)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := b.Run(context.Background()); !errors.Is(err, errDone) {
+		t.Fatalf("Expected %v, got %v", errDone, err)
+	}
+	return b
+}
+
+func TestBridgePartitions(t *testing.T) {
+	f := &fakeT{batches: [][]RecordT{
+		{rec(0, 1, "", "alpha"), rec(1, 2, "", "beta")}, // Split across partitions.
+		{rec(0, 3, "", "gamma"), rec(0, 4, "", "beta")},
+	}}
+
+	b := run(t, f)
+
+	if b.Partitions() != 2 {
+		t.Errorf("Expected 2 partitions, got %d", b.Partitions())
+	}
+	if len(f.out) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(f.out))
+	}
+	if exp := []commitT{{"logs", 0, 5}}; len(f.commits) != 1 || f.commits[0] != exp[0] {
+		t.Errorf("Expected commits %v, got %v", exp, f.commits)
+	}
+
+	var hit struct {
+		Rule string `json:"rule"`
+		Logs []struct {
+			Line   string            `json:"line"`
+			Offset int64             `json:"offset"`
+			Labels map[string]string `json:"labels"`
+		} `json:"logs"`
+	}
+	if err := json.Unmarshal(f.out[0].Value, &hit); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	switch {
+	case f.out[0].Topic != "hits":
+		t.Errorf("Expected topic hits, got %q", f.out[0].Topic)
+	case hit.Rule != "pair":
+		t.Errorf("Expected rule pair, got %q", hit.Rule)
+	case len(hit.Logs) != 2 || hit.Logs[0].Offset != 1 || hit.Logs[1].Offset != 4:
+		t.Errorf("Expected offsets 1 and 4, got %+v", hit.Logs)
+	case hit.Logs[1].Labels[LabelPartition] != "0" || hit.Logs[1].Labels[LabelTopic] != "logs":
+		t.Errorf("Expected partition labels, got %v", hit.Logs[1].Labels)
+	}
+}
+
+func TestBridgeKeyed(t *testing.T) {
+	f := &fakeT{batches: [][]RecordT{
+		{rec(0, 1, "a", "alpha"), rec(0, 2, "b", "beta")},
+		{rec(0, 3, "a", "beta")},
+	}}
+
+	run(t, f, WithKey(KeyRecord))
+
+	if len(f.out) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(f.out))
+	}
+	if key := string(f.out[0].Key); key != "a" {
+		t.Errorf("Expected key a, got %q", key)
+	}
+	if len(f.commits) != 1 || f.commits[0].offset != 4 {
+		t.Errorf("Expected commit at 4, got %v", f.commits)
+	}
+}
+
+func TestBridgeParser(t *testing.T) {
+	var (
+		base  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		entry = func(offset int64, line string, ts time.Time) RecordT {
+			v := `{"log":"` + line + `","time":"` + ts.Format(time.RFC3339Nano) + `"}`
+			return rec(0, offset, "", v)
+		}
+	)
+
+	f := &fakeT{batches: [][]RecordT{{
+		entry(1, "alpha", base),
+		rec(0, 2, "", "not json"),
+		entry(3, "beta", base.Add(time.Nanosecond)), // Within the window by payload time.
+	}}}
+
+	var bad int
+	run(t, f, WithParser(format.NewJsonFactory()), WithErrFunc(func(RecordT, error) error {
+		bad++
+		return nil
+	}))
+
+	if bad != 1 {
+		t.Errorf("Expected 1 bad record, got %d", bad)
+	}
+	if len(f.out) != 1 {
+		t.Errorf("Expected 1 hit, got %d", len(f.out))
+	}
+
+	// Stop on a bad record.
+	f = &fakeT{batches: [][]RecordT{{rec(0, 1, "", "not json")}}}
+	b, _ := New(f, f, "hits", []byte(doc), WithParser(format.NewJsonFactory()), WithErrFunc(func(_ RecordT, err error) error {
+		return err
+	}))
+	if err := b.Run(context.Background()); err == nil || errors.Is(err, errDone) {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+func TestBridgeFail(t *testing.T) {
+	if _, err := New(nil, nil, "hits", []byte(doc)); !errors.Is(err, ErrNoConsumer) {
+		t.Errorf("Expected %v, got %v", ErrNoConsumer, err)
+	}
+	f := &fakeT{}
+	if _, err := New(f, f, "hits", []byte("rules: []")); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestBridgeCommitHeld(t *testing.T) {
+	const doc = `
+rules:
+  - id: pair
+    window: 10
+    terms: [alpha, beta]
+  - id: other
+    window: 10
+    terms: [delta, epsilon]
+`
+	f := &fakeT{batches: [][]RecordT{
+		{rec(0, 10, "", "gamma"), rec(0, 11, "", "delta"), rec(0, 12, "", "alpha"), rec(0, 13, "", "beta")},
+		{rec(0, 14, "", "epsilon")},
+	}}
+
+	runDoc(t, f, doc)
+
+	// The pair hit commits up to the delta still held by the other rule.
+	exp := []commitT{{"logs", 0, 11}, {"logs", 0, 15}}
+	if len(f.out) != 2 || !slices.Equal(f.commits, exp) {
+		t.Errorf("Expected 2 hits and commits %v, got %d and %v", exp, len(f.out), f.commits)
+	}
+}
+
+const docReset = `
+rules:
+  - id: unrecovered
+    window: 1m
+    terms: [alpha]
+    resets:
+      - term: recovered
+        window: 1m
+        absolute: true
+`
+
+func TestBridgeEval(t *testing.T) {
+	f := &fakeT{}
+	b, err := New(f, f, "hits", []byte(docReset))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+	for _, r := range []RecordT{rec(0, 1, "", "alpha"), rec(1, 7, "", "gamma")} {
+		if err := b.Process(ctx, r); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+
+	// The quiet partition is committed; the other holds alpha.
+	if err := b.Eval(ctx, time.Now().UnixNano()); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp := []commitT{{"logs", 1, 8}}; len(f.out) != 0 || !slices.Equal(f.commits, exp) {
+		t.Errorf("Expected no hits and commits %v, got %d and %v", exp, len(f.out), f.commits)
+	}
+
+	// No recovery once the reset window has passed on the ingest clock.
+	if err := b.Eval(ctx, time.Now().Add(2*time.Minute).UnixNano()); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp := []commitT{{"logs", 1, 8}, {"logs", 0, 2}}; len(f.out) != 1 || !slices.Equal(f.commits, exp) {
+		t.Errorf("Expected 1 hit and commits %v, got %d and %v", exp, len(f.out), f.commits)
+	}
+}
+
+func TestBridgeRunEval(t *testing.T) {
+	f := &fakeT{
+		batches: [][]RecordT{{rec(0, 1, "", "alpha")}},
+		waits:   5,
+	}
+
+	runDoc(t, f, strings.ReplaceAll(docReset, "1m", "1ms"), WithEvalInterval(time.Millisecond))
+
+	if exp := []commitT{{"logs", 0, 2}}; len(f.out) != 1 || !slices.Equal(f.commits, exp) {
+		t.Errorf("Expected 1 hit and commits %v, got %d and %v", exp, len(f.out), f.commits)
+	}
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+// DefaultEvalInterval is how often Run evaluates the matchers by default.
+const DefaultEvalInterval = time.Second
+
+// ErrFuncT is called with a record whose value fails to parse.  Return nil to
+// skip the record, or an error to stop Run.
+type ErrFuncT func(RecordT, error) error
+
+type OptT func(*optsT)
+
+type optsT struct {
+	topic    string
+	factory  format.FactoryI
+	keyFn    match.KeyFn
+	keyOpts  []match.OptT
+	errF     ErrFuncT
+	interval time.Duration
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		errF:     func(RecordT, error) error { return nil },
+		interval: DefaultEvalInterval,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithParser parses record values with factory, taking the timestamp from the
+// payload.  By default the value is the line and the record timestamp is used.
+func WithParser(factory format.FactoryI) OptT {
+	return func(o *optsT) {
+		o.factory = factory
+	}
+}
+
+// WithKey runs each rule per correlation key within a partition, as with
+// match.NewKeyedMatcher; opts are passed through.  KeyRecord keys on the
+// record key.
+func WithKey(keyFn match.KeyFn, opts ...match.OptT) OptT {
+	return func(o *optsT) {
+		o.keyFn = keyFn
+		o.keyOpts = opts
+	}
+}
+
+// WithErrFunc sets the handler for records that fail to parse.  By default
+// they are skipped.
+func WithErrFunc(errF ErrFuncT) OptT {
+	return func(o *optsT) {
+		if errF != nil {
+			o.errF = errF
+		}
+	}
+}
+
+// WithEvalInterval sets how often Run evaluates the matchers, and commits
+// every partition.  Defaults to DefaultEvalInterval.
+func WithEvalInterval(d time.Duration) OptT {
+	return func(o *optsT) {
+		if d > 0 {
+			o.interval = d
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package object
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrURL    = errors.New("store url must be http or https")
+	ErrStatus = errors.New("store request failed")
+)
+
+// HTTPStore reads a bucket through the S3 REST API, which MinIO and the GCS
+// XML API also serve.
+//
+// The bucket is addressed by its URL, path style such as
+// http://minio:9000/logs or virtual host style such as
+// https://logs.s3.amazonaws.com.  Objects are listed with ListObjectsV2 and
+// read with ranged GETs.  Requests are unsigned unless WithSigner is given, so
+// a private bucket needs a signer, for example one computing AWS Signature
+// Version 4, or a proxy that signs on its behalf.
+type HTTPStore struct {
+	base *url.URL
+	o    storeOptsT
+}
+
+func NewHTTPStore(bucket string, opts ...StoreOptT) (*HTTPStore, error) {
+	u, err := url.Parse(bucket)
+	switch {
+	case err != nil:
+		return nil, errors.Join(ErrURL, err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, ErrURL
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return &HTTPStore{base: u, o: parseStoreOpts(opts)}, nil
+}
+
+type listResultT struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+		ETag string `xml:"ETag"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List the objects under prefix, following continuation tokens.
+func (h *HTTPStore) List(ctx context.Context, prefix string) ([]ObjectT, error) {
+	var (
+		objs  []ObjectT
+		token string
+	)
+
+	for {
+		u := *h.base
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = q.Encode()
+
+		resp, err := h.do(ctx, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: list %s: %s", ErrStatus, prefix, resp.Status)
+		}
+
+		var res listResultT
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", prefix, err)
+		}
+
+		for _, c := range res.Contents {
+			objs = append(objs, ObjectT{Key: c.Key, Size: c.Size, ETag: c.ETag})
+		}
+
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return objs, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// Open reads obj from offset with a ranged GET.  A server that ignores the
+// range is read from the start, discarding offset bytes.
+func (h *HTTPStore) Open(ctx context.Context, obj ObjectT, offset int64) (io.ReadCloser, error) {
+	hdr := make(http.Header)
+	if offset > 0 {
+		hdr.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	if obj.ETag != "" {
+		hdr.Set("If-Match", obj.ETag)
+	}
+
+	resp, err := h.do(ctx, h.objectURL(obj.Key), hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusOK:
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+		}
+		return resp.Body, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	case http.StatusPreconditionFailed:
+		resp.Body.Close()
+		return nil, ErrChanged
+	}
+
+	resp.Body.Close()
+	return nil, fmt.Errorf("%w: get %s: %s", ErrStatus, obj.Key, resp.Status)
+}
+
+// Escape each segment of key, keeping the separators.
+func (h *HTTPStore) objectURL(key string) string {
+	segs := strings.Split(key, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+
+	u := *h.base
+	u.RawPath = h.base.EscapedPath() + "/" + strings.Join(segs, "/")
+	u.Path = h.base.Path + "/" + key
+	return u.String()
+}
+
+func (h *HTTPStore) do(ctx context.Context, location string, hdr http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+	if h.o.signer != nil {
+		if err := h.o.signer(req); err != nil {
+			return nil, err
+		}
+	}
+	return h.o.client.Do(req)
+}
@@ -0,0 +1,411 @@
+// Package object streams log objects from an object store, such as S3, GCS
+// or MinIO, into the scanner without downloading them first.
+//
+// A Source reads every object under a prefix in key order.  Its position is a
+// CheckpointT, the key and byte offset after the last entry returned, from
+// which a later Source resumes with a ranged read.
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scanner"
+)
+
+var ErrChanged = errors.New("object changed since checkpoint")
+
+// An object with nothing left to read.
+var errEmpty = errors.New("empty object")
+
+// LabelObject is the label under which each entry records its object key.
+const LabelObject = "object"
+
+const sampleSize = 64 << 10
+
+type LogEntry = match.LogEntry
+
+// ObjectT describes a stored object.
+type ObjectT struct {
+	Key  string
+	Size int64
+	ETag string
+}
+
+// StoreI is an object store.
+//
+// List returns the objects under prefix, in any order.  Open reads obj from
+// the byte offset given; if obj has an ETag, Open fails with ErrChanged should
+// the stored object no longer match it.  An offset at or past the end of the
+// object reads nothing.
+type StoreI interface {
+	List(ctx context.Context, prefix string) ([]ObjectT, error)
+	Open(ctx context.Context, obj ObjectT, offset int64) (io.ReadCloser, error)
+}
+
+// CheckpointT is the position after an entry.
+//
+// Offset is in the content of the object, after decompression.  A compressed
+// object cannot be read from an offset, so it is read again from its start,
+// discarding Offset bytes.
+type CheckpointT struct {
+	Key        string `json:"key"`
+	ETag       string `json:"etag,omitempty"`
+	Offset     int64  `json:"offset"`
+	LineNo     int64  `json:"line"`
+	Compressed bool   `json:"compressed,omitempty"`
+}
+
+// Source yields the entries of the objects under a prefix.
+//
+// Objects are read in key order, as rotated logs are usually named, and are
+// decompressed as with scanner.Decompress.  Should a read fail part way, the
+// object is opened again from the last line read, up to WithRetries times.
+type Source struct {
+	ctx    context.Context
+	store  StoreI
+	prefix string
+	o      optsT
+
+	objs   []ObjectT
+	listed bool
+
+	// The object being read.
+	obj        ObjectT
+	rc         io.ReadCloser
+	scanner    *bufio.Scanner
+	parser     format.ParserI
+	factory    format.FactoryI
+	labels     map[string]string
+	pos        int64 // Offset after the last line read.
+	lineNo     int64
+	compressed bool
+	tries      int
+	rerr       error // Failed read of the object.
+
+	cp CheckpointT
+}
+
+// New returns a source of the objects in store under prefix.  Reads are
+// bound to ctx; the caller must Close the source.
+func New(ctx context.Context, store StoreI, prefix string, opts ...OptT) *Source {
+	o := parseOpts(opts)
+	return &Source{
+		ctx:    ctx,
+		store:  store,
+		prefix: prefix,
+		o:      o,
+		cp:     o.checkpoint,
+	}
+}
+
+// Next returns the next entry, or io.EOF once every object is read.
+func (s *Source) Next() (LogEntry, error) {
+	for {
+		if s.rc == nil {
+			if err := s.advance(); err != nil {
+				return LogEntry{}, err
+			}
+		}
+
+		if s.scanner.Scan() {
+			s.lineNo += 1
+			s.tries = 0
+
+			entry, err := s.parser.ReadEntry(s.scanner.Bytes())
+			switch {
+			case errors.Is(err, format.ErrPartial):
+				// Held by the parser until the line is complete.
+				continue
+			case err != nil:
+				if err = s.o.errF(s.scanner.Bytes(), err); err != nil {
+					return LogEntry{}, err
+				}
+				continue
+			}
+
+			entry.LineNo = s.lineNo
+			s.label(&entry)
+			s.cp = CheckpointT{
+				Key:        s.obj.Key,
+				ETag:       s.obj.ETag,
+				Offset:     s.pos,
+				LineNo:     s.lineNo,
+				Compressed: s.compressed,
+			}
+			return entry, nil
+		}
+
+		err := s.scanner.Err()
+		if err == nil {
+			s.closeObject()
+			continue
+		}
+		if !s.retryable(err) {
+			return LogEntry{}, fmt.Errorf("object %s: %w", s.obj.Key, err)
+		}
+		if err := s.reopen(); err != nil {
+			return LogEntry{}, err
+		}
+	}
+}
+
+// Checkpoint returns the position after the last entry returned, for
+// WithCheckpoint.
+func (s *Source) Checkpoint() CheckpointT {
+	return s.cp
+}
+
+// Close closes the object being read.
+func (s *Source) Close() error {
+	if s.rc == nil {
+		return nil
+	}
+	err := s.rc.Close()
+	s.rc = nil
+	return err
+}
+
+// Open the next object, or return io.EOF.
+func (s *Source) advance() error {
+	if !s.listed {
+		if err := s.list(); err != nil {
+			return err
+		}
+	}
+
+	for len(s.objs) > 0 {
+		obj := s.objs[0]
+		s.objs = s.objs[1:]
+
+		s.obj, s.factory, s.tries = obj, s.o.factory, 0
+		s.pos, s.lineNo, s.compressed = 0, 0, false
+
+		if obj.Key == s.o.checkpoint.Key {
+			cp := s.o.checkpoint
+			if cp.ETag != "" {
+				s.obj.ETag = cp.ETag
+			}
+			s.pos, s.lineNo, s.compressed = cp.Offset, cp.LineNo, cp.Compressed
+		}
+
+		// Read to the end at the checkpoint.
+		if !s.compressed && s.pos > 0 && s.pos >= obj.Size {
+			continue
+		}
+
+		switch err := s.openRetry(); err {
+		case nil:
+			s.parser = s.factory.New()
+			return nil
+		case errEmpty:
+			continue
+		default:
+			return err
+		}
+	}
+
+	return io.EOF
+}
+
+// List the objects in key order, from the checkpoint on.
+func (s *Source) list() error {
+	objs, err := s.store.List(s.ctx, s.prefix)
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(objs, func(a, b ObjectT) int { return strings.Compare(a.Key, b.Key) })
+
+	from := s.o.checkpoint.Key
+	s.objs = slices.DeleteFunc(objs, func(obj ObjectT) bool {
+		return obj.Key < from || (s.o.filter != nil && !s.o.filter(obj))
+	})
+	s.listed = true
+	return nil
+}
+
+// Open the current object at pos, retrying failed reads.
+func (s *Source) openRetry() error {
+	for {
+		err := s.open()
+		if err == nil || err == errEmpty || !s.retryable(err) {
+			return err
+		}
+		if err := s.backoff(); err != nil {
+			return err
+		}
+	}
+}
+
+// Open the current object at pos.
+func (s *Source) open() error {
+	start := s.pos
+	if s.compressed {
+		start = 0
+	}
+
+	rc, err := s.store.Open(s.ctx, s.obj, start)
+	if err != nil {
+		return fmt.Errorf("object %s: %w", s.obj.Key, err)
+	}
+
+	rdr, err := s.decode(rc, start)
+	if err == nil && s.factory == nil {
+		rdr, err = s.detect(rdr)
+	}
+	if err != nil {
+		rc.Close()
+		if err == errEmpty {
+			return err
+		}
+		return fmt.Errorf("object %s: %w", s.obj.Key, err)
+	}
+
+	s.rc, s.rerr = rc, nil
+	s.scanner = bufio.NewScanner(&readerT{rdr: rdr, err: &s.rerr})
+	s.scanner.Buffer(make([]byte, 0, min(s.o.maxSz, 64<<10)), s.o.maxSz)
+	s.scanner.Split(s.split)
+	s.labels = map[string]string{LabelObject: s.obj.Key}
+	return nil
+}
+
+// Decompress an object read from its start, then skip to pos.
+func (s *Source) decode(rc io.Reader, start int64) (io.Reader, error) {
+	if start > 0 {
+		return rc, nil
+	}
+
+	br := bufio.NewReader(rc)
+	rdr, err := scanner.Decompress(br)
+	if err != nil {
+		return nil, err
+	}
+	s.compressed = rdr != io.Reader(br)
+
+	if s.pos > 0 {
+		if _, err := io.CopyN(io.Discard, rdr, s.pos); err != nil {
+			return nil, err
+		}
+	}
+	return rdr, nil
+}
+
+// Detect the format from a sample of the object, without consuming it.  An
+// object with nothing left to read fails with errEmpty.
+func (s *Source) detect(rdr io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(rdr, sampleSize)
+
+	sample, err := br.Peek(sampleSize)
+	switch {
+	case err != nil && err != io.EOF && err != bufio.ErrBufferFull:
+		return nil, err
+	case len(sample) == 0:
+		return nil, errEmpty
+	}
+
+	res, err := format.DetectSample(bytes.NewReader(sample))
+	if err != nil {
+		return nil, err
+	}
+	s.factory = res.Factory
+	return br, nil
+}
+
+// Split lines, tracking the offset after each.  The scanner splits what is
+// left on any read error, so a line cut short by a failed read is dropped to
+// be read again.
+func (s *Source) split(data []byte, atEOF bool) (int, []byte, error) {
+	if s.rerr != nil {
+		atEOF = false
+	}
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if advance == 0 && token == nil && s.rerr != nil {
+		return 0, nil, s.rerr
+	}
+	s.pos += int64(advance)
+	return advance, token, err
+}
+
+// Errors that a second read would only repeat are not retried.
+func (s *Source) retryable(err error) bool {
+	switch {
+	case s.tries >= s.o.retries:
+		return false
+	case errors.Is(err, bufio.ErrTooLong),
+		errors.Is(err, ErrChanged),
+		errors.Is(err, format.ErrFormatDetect),
+		errors.Is(err, scanner.ErrNoDecoder):
+		return false
+	case s.ctx.Err() != nil:
+		return false
+	}
+	return true
+}
+
+// Wait before the next attempt, doubling the wait on each.
+func (s *Source) backoff() error {
+	wait := s.o.backoff << s.tries
+	s.tries += 1
+
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// Open the current object again after a failed read, from the last line
+// read.  Lines held by the parser are kept.
+func (s *Source) reopen() error {
+	s.closeObject()
+
+	if err := s.backoff(); err != nil {
+		return err
+	}
+
+	// The format is known, so the object is not empty again.
+	return s.openRetry()
+}
+
+func (s *Source) closeObject() {
+	s.rc.Close()
+	s.rc = nil
+}
+
+// Records the first error other than io.EOF.
+type readerT struct {
+	rdr io.Reader
+	err *error
+}
+
+func (r *readerT) Read(p []byte) (int, error) {
+	n, err := r.rdr.Read(p)
+	if err != nil && err != io.EOF && *r.err == nil {
+		*r.err = err
+	}
+	return n, err
+}
+
+// Entries without labels share the object's map; those with labels of their
+// own get a copy.
+func (s *Source) label(entry *LogEntry) {
+	if entry.Labels == nil {
+		entry.Labels = s.labels
+		return
+	}
+	labels := maps.Clone(entry.Labels)
+	labels[LabelObject] = s.obj.Key
+	entry.Labels = labels
+}
@@ -0,0 +1,298 @@
+package object
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+)
+
+func lines(from, to int) string {
+	var sb strings.Builder
+	for i := from; i < to; i++ {
+		fmt.Fprintf(&sb, "2024-01-01T00:00:%02dZ line %d\n", i, i)
+	}
+	return sb.String()
+}
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+// bucketT serves objects through the subset of the S3 API HTTPStore uses,
+// one key per list page.
+type bucketT struct {
+	mux     sync.Mutex
+	objs    map[string][]byte
+	cut     map[string]int // Truncate the next GET of a key after n bytes.
+	ranges  []string
+	listing int
+}
+
+func (b *bucketT) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if r.URL.Query().Get("list-type") == "2" {
+		b.list(w, r)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	data, ok := b.objs[key]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := fmt.Sprintf("%q", strconv.Itoa(len(data)))
+	if m := r.Header.Get("If-Match"); m != "" && m != etag {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		b.ranges = append(b.ranges, key+":"+rng)
+		off, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+		if off >= len(data) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		data, status = data[off:], http.StatusPartialContent
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if n, ok := b.cut[key]; ok {
+		delete(b.cut, key)
+		data = data[:n]
+	}
+	w.Write(data)
+}
+
+func (b *bucketT) list(w http.ResponseWriter, r *http.Request) {
+	b.listing += 1
+
+	var keys []string
+	for key := range b.objs {
+		if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+
+	var res listResultT
+	if start < len(keys) {
+		key := keys[start]
+		res.Contents = append(res.Contents, struct {
+			Key  string `xml:"Key"`
+			Size int64  `xml:"Size"`
+			ETag string `xml:"ETag"`
+		}{key, int64(len(b.objs[key])), fmt.Sprintf("%q", strconv.Itoa(len(b.objs[key])))})
+	}
+	if start+1 < len(keys) {
+		res.IsTruncated = true
+		res.NextContinuationToken = strconv.Itoa(start + 1)
+	}
+	xml.NewEncoder(w).Encode(res)
+}
+
+func newBucket(t *testing.T) (*bucketT, *HTTPStore) {
+	b := &bucketT{
+		objs: map[string][]byte{
+			"logs/app.log.1.gz": gzipped(lines(0, 4)),
+			"logs/app.log.2":    []byte(lines(4, 8)),
+			"other/app.log":     []byte(lines(8, 10)),
+		},
+		cut: make(map[string]int),
+	}
+
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+
+	store, err := NewHTTPStore(srv.URL + "/bucket/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, store
+}
+
+func readAll(t *testing.T, src *Source, n int) []LogEntry {
+	t.Helper()
+
+	var out []LogEntry
+	for n < 0 || len(out) < n {
+		entry, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestSource(t *testing.T) {
+	b, store := newBucket(t)
+
+	src := New(context.Background(), store, "logs/", WithRetries(0, 0))
+	defer src.Close()
+
+	entries := readAll(t, src, -1)
+	if len(entries) != 8 {
+		t.Fatalf("Expected 8 entries, got %d", len(entries))
+	}
+
+	for i, e := range entries {
+		if exp := fmt.Sprintf("line %d", i); e.Line != exp {
+			t.Errorf("Entry %d: expected %q, got %q", i, exp, e.Line)
+		}
+	}
+
+	if e := entries[5]; e.Labels[LabelObject] != "logs/app.log.2" || e.LineNo != 2 {
+		t.Errorf("Unexpected label %v or line %d", e.Labels, e.LineNo)
+	}
+	if b.listing != 2 {
+		t.Errorf("Expected 2 list pages, got %d", b.listing)
+	}
+	if cp := src.Checkpoint(); cp.Key != "logs/app.log.2" || cp.Offset != int64(len(b.objs[cp.Key])) {
+		t.Errorf("Unexpected checkpoint %+v", cp)
+	}
+}
+
+func TestSourceResume(t *testing.T) {
+	tests := map[string]int{
+		"compressed": 2,
+		"boundary":   4,
+		"plain":      6,
+		"end":        8,
+	}
+
+	for name, n := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, store := newBucket(t)
+
+			src := New(context.Background(), store, "logs/")
+			readAll(t, src, n)
+			cp := src.Checkpoint()
+			src.Close()
+
+			src = New(context.Background(), store, "logs/", WithCheckpoint(cp))
+			defer src.Close()
+
+			entries := readAll(t, src, -1)
+			if len(entries) != 8-n {
+				t.Fatalf("Expected %d entries, got %d", 8-n, len(entries))
+			}
+			for i, e := range entries {
+				if exp := fmt.Sprintf("line %d", n+i); e.Line != exp {
+					t.Errorf("Entry %d: expected %q, got %q", i, exp, e.Line)
+				}
+				if exp := int64((n+i)%4 + 1); e.LineNo != exp {
+					t.Errorf("Entry %d: expected line number %d, got %d", i, exp, e.LineNo)
+				}
+			}
+
+			if name == "plain" && (len(b.ranges) != 1 || !strings.HasPrefix(b.ranges[0], "logs/app.log.2:bytes=")) {
+				t.Errorf("Expected a ranged read, got %v", b.ranges)
+			}
+		})
+	}
+}
+
+func TestSourceRetry(t *testing.T) {
+	b, store := newBucket(t)
+
+	// Cut both objects part way through a line.
+	b.cut["logs/app.log.2"] = 40
+	b.cut["logs/app.log.1.gz"] = 30
+
+	// With the format given, the head is not sampled, so the cuts fall while
+	// scanning.
+	factory, err := format.NewLayout(time.RFC3339)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := New(context.Background(), store, "logs/", WithFactory(factory), WithRetries(1, 0))
+	defer src.Close()
+
+	entries := readAll(t, src, -1)
+	if len(entries) != 8 {
+		t.Fatalf("Expected 8 entries, got %d", len(entries))
+	}
+	for i, e := range entries {
+		if exp := fmt.Sprintf("line %d", i); e.Line != exp {
+			t.Errorf("Entry %d: expected %q, got %q", i, exp, e.Line)
+		}
+	}
+	if len(b.ranges) != 1 {
+		t.Errorf("Expected the plain object resumed with a range, got %v", b.ranges)
+	} else if b.ranges[0] != "logs/app.log.2:bytes=28-" {
+		t.Errorf("Expected a range from the second line, got %v", b.ranges[0])
+	}
+}
+
+func TestSourceNoRetry(t *testing.T) {
+	b, store := newBucket(t)
+	b.cut["logs/app.log.2"] = 40
+
+	src := New(context.Background(), store, "logs/app.log.2", WithRetries(0, 0))
+	defer src.Close()
+
+	var err error
+	for err == nil {
+		_, err = src.Next()
+	}
+	if err == io.EOF {
+		t.Errorf("Expected a read error, got EOF")
+	}
+}
+
+func TestSourceChanged(t *testing.T) {
+	_, store := newBucket(t)
+
+	cp := CheckpointT{Key: "logs/app.log.2", ETag: `"stale"`, Offset: 27, LineNo: 1}
+	src := New(context.Background(), store, "logs/", WithCheckpoint(cp))
+	defer src.Close()
+
+	if _, err := src.Next(); !errors.Is(err, ErrChanged) {
+		t.Errorf("Expected ErrChanged, got %v", err)
+	}
+}
+
+func TestHTTPStoreURL(t *testing.T) {
+	if _, err := NewHTTPStore("s3://bucket"); !errors.Is(err, ErrURL) {
+		t.Errorf("Expected ErrURL, got %v", err)
+	}
+
+	store, err := NewHTTPStore("http://minio:9000/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := store.objectURL("logs/a b?.log"); u != "http://minio:9000/bucket/logs/a%20b%3F.log" {
+		t.Errorf("Unexpected url %s", u)
+	}
+}
@@ -0,0 +1,150 @@
+package object
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MaxRecordSize = pool.MaxRecordSize
+	DefRetries    = 3
+	DefBackoff    = 500 * time.Millisecond
+)
+
+// ErrFuncT is called on a line that cannot be parsed.  Return nil to skip the
+// line and continue, or an error to abort the source.
+type ErrFuncT func([]byte, error) error
+
+type OptT func(*optsT)
+
+type optsT struct {
+	factory    format.FactoryI
+	checkpoint CheckpointT
+	filter     func(ObjectT) bool
+	retries    int
+	backoff    time.Duration
+	maxSz      int
+	errF       ErrFuncT
+}
+
+func defaultErrFunc(line []byte, err error) error {
+	// Tolerate badly formed lines
+	log.Debug().
+		Err(err).
+		Int("size", len(line)).
+		Msg("Fail line.  Continue...")
+	return nil
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		retries: DefRetries,
+		backoff: DefBackoff,
+		maxSz:   MaxRecordSize,
+		errF:    defaultErrFunc,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithFactory parses every object with factory.  By default the format of
+// each object is detected from a sample of its head.
+func WithFactory(factory format.FactoryI) OptT {
+	return func(o *optsT) {
+		o.factory = factory
+	}
+}
+
+// WithCheckpoint resumes at cp, from Source.Checkpoint.  Objects with keys
+// before cp.Key are skipped.
+func WithCheckpoint(cp CheckpointT) OptT {
+	return func(o *optsT) {
+		o.checkpoint = cp
+	}
+}
+
+// WithFilter reads only the objects for which fn returns true; for example
+// to skip manifests stored alongside the logs.
+func WithFilter(fn func(ObjectT) bool) OptT {
+	return func(o *optsT) {
+		o.filter = fn
+	}
+}
+
+// WithRetries sets the number of times an object is opened again after a
+// failed read, waiting backoff, doubled on each attempt.  Defaults to
+// DefRetries and DefBackoff.
+func WithRetries(n int, backoff time.Duration) OptT {
+	return func(o *optsT) {
+		o.retries = max(n, 0)
+		o.backoff = backoff
+	}
+}
+
+// WithMaxSize sets the largest line accepted.  A longer line aborts the source
+// with bufio.ErrTooLong.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz <= 0 || maxSz > MaxRecordSize {
+			maxSz = MaxRecordSize
+		}
+		o.maxSz = maxSz
+	}
+}
+
+func WithErrFunc(errF ErrFuncT) OptT {
+	return func(o *optsT) {
+		o.errF = errF
+	}
+}
+
+// ----------
+
+// SignerFuncT prepares a request to the store; for example adding an
+// authorization header.
+type SignerFuncT func(*http.Request) error
+
+type StoreOptT func(*storeOptsT)
+
+type storeOptsT struct {
+	client *http.Client
+	signer SignerFuncT
+}
+
+func parseStoreOpts(opts []StoreOptT) storeOptsT {
+	o := storeOptsT{
+		client: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithClient sets the HTTP client of the store.  Defaults to http.DefaultClient.
+func WithClient(client *http.Client) StoreOptT {
+	return func(o *storeOptsT) {
+		if client != nil {
+			o.client = client
+		}
+	}
+}
+
+// WithSigner calls fn on every request before it is sent.  Without a signer,
+// the bucket must allow anonymous reads.
+func WithSigner(fn SignerFuncT) StoreOptT {
+	return func(o *storeOptsT) {
+		o.signer = fn
+	}
+}
@@ -0,0 +1,154 @@
+// Package otel converts OpenTelemetry log records into log entries, so that
+// rules can run inside an OTel Collector style pipeline.
+//
+// Records are decoded straight from OTLP protobuf (ExportLogsServiceRequest or
+// LogsData, which share a layout) with DecodeLogs, or built by the caller as a
+// RecordT, for example from a pdata plog.LogRecord, and converted with Convert.
+package otel
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+)
+
+// Labels set from the fields of a record, alongside its attributes.
+const (
+	LabelSeverity       = "severity"
+	LabelSeverityNumber = "severity_number"
+	LabelTraceID        = "trace_id"
+	LabelSpanID         = "span_id"
+	LabelScope          = "otel.scope.name"
+)
+
+type LogEntry = entry.LogEntry
+
+// RecordT is a log record, with the attributes of its resource and scope.
+type RecordT struct {
+	Time           int64 // time_unix_nano; zero if unknown.
+	ObservedTime   int64 // observed_time_unix_nano.
+	SeverityNumber int32
+	SeverityText   string
+	Body           any // string, bool, int64, float64, []byte, []any or map[string]any.
+	Attributes     map[string]any
+	TraceID        []byte
+	SpanID         []byte
+
+	Resource map[string]any // Resource attributes.
+	Scope    string         // Instrumentation scope name.
+}
+
+// Convert builds an entry from a record.
+//
+// The timestamp is the record time, or the observed time if the record has
+// none; the observed time is also kept as the ingest time.  A string body is
+// the line; any other body is encoded as JSON.  Resource attributes, then
+// record attributes, become labels, so that a record attribute wins over a
+// resource attribute of the same name.  Values that are not strings are
+// formatted; arrays and maps as JSON.  Severity, trace and span ids, and the
+// scope name are labels too.  A missing severity text is derived from the
+// severity number.  The severity number is kept as the entry Severity, or
+// where unspecified, the severity text is parsed.
+func Convert(rec RecordT) LogEntry {
+	e := LogEntry{
+		Line:       line(rec.Body),
+		Timestamp:  rec.Time,
+		IngestTime: rec.ObservedTime,
+	}
+	if e.Timestamp == 0 {
+		e.Timestamp = rec.ObservedTime
+	}
+
+	labels := make(map[string]string, len(rec.Resource)+len(rec.Attributes)+5)
+	for k, v := range rec.Resource {
+		labels[k] = format(v)
+	}
+	for k, v := range rec.Attributes {
+		labels[k] = format(v)
+	}
+
+	sev := rec.SeverityText
+	if sev == "" {
+		sev = SeverityText(rec.SeverityNumber)
+	}
+	if sev != "" {
+		labels[LabelSeverity] = sev
+	}
+	if rec.SeverityNumber != 0 {
+		labels[LabelSeverityNumber] = strconv.Itoa(int(rec.SeverityNumber))
+	}
+	if s := entry.SeverityT(rec.SeverityNumber); s.Level() != entry.SevUnknown {
+		e.Severity = s
+	} else {
+		e.Severity, _ = entry.ParseSeverity(rec.SeverityText)
+	}
+	if len(rec.TraceID) > 0 {
+		labels[LabelTraceID] = hex.EncodeToString(rec.TraceID)
+	}
+	if len(rec.SpanID) > 0 {
+		labels[LabelSpanID] = hex.EncodeToString(rec.SpanID)
+	}
+	if rec.Scope != "" {
+		labels[LabelScope] = rec.Scope
+	}
+
+	if len(labels) > 0 {
+		e.Labels = labels
+	}
+	return e
+}
+
+// SeverityText returns the short name of the range a severity number falls
+// in, per the OpenTelemetry log data model; empty if unspecified.
+func SeverityText(n int32) string {
+	switch {
+	case n <= 0 || n > 24:
+		return ""
+	case n <= 4:
+		return "TRACE"
+	case n <= 8:
+		return "DEBUG"
+	case n <= 12:
+		return "INFO"
+	case n <= 16:
+		return "WARN"
+	case n <= 20:
+		return "ERROR"
+	}
+	return "FATAL"
+}
+
+func line(body any) string {
+	if s, ok := body.(string); ok {
+		return s
+	}
+	if body == nil {
+		return ""
+	}
+	return format(body)
+}
+
+func format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return hex.EncodeToString(v)
+	case nil:
+		return ""
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,197 @@
+package otel
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Minimal OTLP encoders for the tests.
+
+func msg(num protowire.Number, sub []byte) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, sub)
+}
+
+func str(num protowire.Number, s string) []byte {
+	return msg(num, []byte(s))
+}
+
+func varint(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func fixed(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func cat(parts ...[]byte) (out []byte) {
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return
+}
+
+func kv(key string, val []byte) []byte {
+	return cat(str(kvKey, key), msg(kvValue, val))
+}
+
+func record(ts uint64, body string, attrs ...[]byte) []byte {
+	b := cat(fixed(recordTime, ts), msg(recordBody, str(anyString, body)))
+	for _, a := range attrs {
+		b = append(b, msg(recordAttributes, a)...)
+	}
+	return b
+}
+
+func logs(resource []byte, scope string, records ...[]byte) []byte {
+	sl := msg(scopeLogsScope, str(scopeName, scope))
+	for _, r := range records {
+		sl = append(sl, msg(scopeLogsLogRecords, r)...)
+	}
+	rl := cat(msg(resourceLogsResource, resource), msg(resourceLogsScopeLogs, sl))
+	return msg(logsResourceLogs, rl)
+}
+
+func TestDecodeLogs(t *testing.T) {
+	var (
+		resource = msg(resourceAttributes, kv("service.name", str(anyString, "api")))
+		body     = msg(anyKvList, msg(listValues, kv("msg", str(anyString, "oom"))))
+		rec      = cat(
+			fixed(recordTime, 0),
+			fixed(recordObservedTime, 20),
+			varint(recordSeverityNumber, 17),
+			msg(recordBody, body),
+			msg(recordAttributes, kv("code", varint(anyInt, 137))),
+			msg(recordAttributes, kv("retry", varint(anyBool, 1))),
+			msg(recordAttributes, kv("load", fixed(anyDouble, math.Float64bits(0.5)))),
+			msg(recordAttributes, kv("tags", msg(anyArray, cat(msg(listValues, str(anyString, "a")), msg(listValues, varint(anyInt, 2)))))),
+			msg(recordAttributes, kv("service.name", str(anyString, "api-override"))),
+			str(recordTraceID, "\x01\x02"),
+			str(recordSpanID, "\xff"),
+		)
+	)
+
+	recs, err := DecodeLogs(cat(
+		logs(resource, "app", record(10, "alpha"), rec),
+		logs(nil, "", record(30, "beta")),
+	))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(recs))
+	}
+
+	if recs[0].Scope != "app" || recs[0].Resource["service.name"] != "api" || recs[2].Resource != nil {
+		t.Errorf("Expected resource and scope per batch, got %+v / %+v", recs[0], recs[2])
+	}
+
+	e := Convert(recs[1])
+	exp := LogEntry{
+		Line:       `{"msg":"oom"}`,
+		Timestamp:  20, // Observed time; the record has none.
+		IngestTime: 20,
+		Labels: map[string]string{
+			"service.name":      "api-override",
+			"code":              "137",
+			"retry":             "true",
+			"load":              "0.5",
+			"tags":              `["a",2]`,
+			LabelSeverity:       "ERROR",
+			LabelSeverityNumber: "17",
+			LabelTraceID:        "0102",
+			LabelSpanID:         "ff",
+			LabelScope:          "app",
+		},
+		Severity: entry.SevError,
+	}
+	if !reflect.DeepEqual(e, exp) {
+		t.Errorf("Expected %+v, got %+v", exp, e)
+	}
+}
+
+func TestDecodeLogsFail(t *testing.T) {
+	bad := map[string][]byte{
+		"Truncated": {0x0a, 0x05, 0x01},
+		"WrongType": varint(logsResourceLogs, 1),
+		"BadTime":   logs(nil, "", varint(recordTime, 1)),
+	}
+	for name, data := range bad {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeLogs(data); !errors.Is(err, ErrOTLP) {
+				t.Errorf("Expected %v, got %v", ErrOTLP, err)
+			}
+		})
+	}
+}
+
+func TestSeverityText(t *testing.T) {
+	exp := map[int32]string{0: "", 1: "TRACE", 5: "DEBUG", 9: "INFO", 13: "WARN", 20: "ERROR", 24: "FATAL", 25: ""}
+	for n, want := range exp {
+		if got := SeverityText(n); got != want {
+			t.Errorf("%d: Expected %q, got %q", n, want, got)
+		}
+	}
+
+	// Severity text on the record is kept as is.
+	if e := Convert(RecordT{SeverityNumber: 9, SeverityText: "notice"}); e.Labels[LabelSeverity] != "notice" {
+		t.Errorf("Expected notice, got %v", e.Labels)
+	}
+
+	// The number is the entry severity; without one, the text is parsed.
+	if e := Convert(RecordT{SeverityNumber: 14, SeverityText: "notice"}); e.Severity != entry.SevWarn+1 {
+		t.Errorf("Expected severity %d, got %d", entry.SevWarn+1, e.Severity)
+	}
+	if e := Convert(RecordT{SeverityText: "Warning"}); e.Severity != entry.SevWarn {
+		t.Errorf("Expected severity %d, got %d", entry.SevWarn, e.Severity)
+	}
+}
+
+func TestProcessor(t *testing.T) {
+	const doc = `
+rules:
+  - id: pair
+    window: 10
+    terms:
+      - alpha
+      - beta
+`
+	type hitT struct {
+		rule string
+		hits match.Hits
+	}
+	var got []hitT
+
+	p, err := NewProcessor([]byte(doc), func(rule string, hits match.Hits) {
+		got = append(got, hitT{rule, hits})
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	// The beta resource is encoded first, but logged after alpha.
+	data := cat(
+		logs(nil, "", record(5, "beta")),
+		logs(nil, "", record(1, "alpha")),
+	)
+	if err := p.ConsumeOTLP(data); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	if len(got) != 1 || got[0].rule != "pair" || got[0].hits.FireStamp != 5 {
+		t.Errorf("Expected a pair hit at 5, got %+v", got)
+	}
+
+	if err := p.ConsumeOTLP([]byte{0xff}); !errors.Is(err, ErrOTLP) {
+		t.Errorf("Expected %v, got %v", ErrOTLP, err)
+	}
+}
@@ -0,0 +1,309 @@
+package otel
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var ErrOTLP = errors.New("malformed OTLP message")
+
+// Field numbers, per opentelemetry/proto/logs/v1/logs.proto and common.proto.
+const (
+	logsResourceLogs = 1
+
+	resourceLogsResource  = 1
+	resourceLogsScopeLogs = 2
+
+	resourceAttributes = 1
+
+	scopeLogsScope      = 1
+	scopeLogsLogRecords = 2
+
+	scopeName = 1
+
+	recordTime           = 1
+	recordSeverityNumber = 2
+	recordSeverityText   = 3
+	recordBody           = 5
+	recordAttributes     = 6
+	recordTraceID        = 9
+	recordSpanID         = 10
+	recordObservedTime   = 11
+
+	kvKey   = 1
+	kvValue = 2
+
+	anyString = 1
+	anyBool   = 2
+	anyInt    = 3
+	anyDouble = 4
+	anyArray  = 5
+	anyKvList = 6
+	anyBytes  = 7
+
+	listValues = 1
+)
+
+// DecodeLogs decodes an OTLP ExportLogsServiceRequest, or LogsData, into its
+// records.  Records are returned in the order they appear in the message.
+func DecodeLogs(b []byte) ([]RecordT, error) {
+	var recs []RecordT
+
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v fieldT) error {
+		if num != logsResourceLogs {
+			return nil
+		}
+		if typ != protowire.BytesType {
+			return ErrOTLP
+		}
+		var err error
+		recs, err = decodeResourceLogs(v.raw, recs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+// Resource attributes precede the scope logs in a conforming encoder, but the
+// wire allows any order; records are completed once the message is read.
+func decodeResourceLogs(b []byte, recs []RecordT) ([]RecordT, error) {
+	var (
+		resource map[string]any
+		first    = len(recs)
+	)
+
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v fieldT) (err error) {
+		switch num {
+		case resourceLogsResource:
+			resource, err = decodeAttributes(v.raw, resourceAttributes)
+		case resourceLogsScopeLogs:
+			recs, err = decodeScopeLogs(v.raw, recs)
+		}
+		return err
+	})
+
+	for i := first; i < len(recs); i++ {
+		recs[i].Resource = resource
+	}
+	return recs, err
+}
+
+func decodeScopeLogs(b []byte, recs []RecordT) ([]RecordT, error) {
+	var (
+		scope string
+		first = len(recs)
+	)
+
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v fieldT) (err error) {
+		switch num {
+		case scopeLogsScope:
+			err = walk(v.raw, func(num protowire.Number, typ protowire.Type, v fieldT) (err error) {
+				if num == scopeName {
+					scope, err = v.str(typ)
+				}
+				return
+			})
+		case scopeLogsLogRecords:
+			var rec RecordT
+			if rec, err = decodeRecord(v.raw); err == nil {
+				recs = append(recs, rec)
+			}
+		}
+		return err
+	})
+
+	for i := first; i < len(recs); i++ {
+		recs[i].Scope = scope
+	}
+	return recs, err
+}
+
+func decodeRecord(b []byte) (rec RecordT, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v fieldT) (err error) {
+		switch num {
+		case recordTime:
+			rec.Time, err = v.fixed(typ)
+		case recordObservedTime:
+			rec.ObservedTime, err = v.fixed(typ)
+		case recordSeverityNumber:
+			var n int64
+			n, err = v.int(typ)
+			rec.SeverityNumber = int32(n)
+		case recordSeverityText:
+			rec.SeverityText, err = v.str(typ)
+		case recordBody:
+			rec.Body, err = decodeAny(v.raw)
+		case recordAttributes:
+			var k string
+			var val any
+			if k, val, err = decodeKeyValue(v.raw); err == nil {
+				if rec.Attributes == nil {
+					rec.Attributes = make(map[string]any)
+				}
+				rec.Attributes[k] = val
+			}
+		case recordTraceID:
+			rec.TraceID, err = v.bytes(typ)
+		case recordSpanID:
+			rec.SpanID, err = v.bytes(typ)
+		}
+		return err
+	})
+	return
+}
+
+// Decode the repeated KeyValue field num of the message in b.
+func decodeAttributes(b []byte, num protowire.Number) (map[string]any, error) {
+	var attrs map[string]any
+
+	err := walk(b, func(n protowire.Number, typ protowire.Type, v fieldT) error {
+		if n != num {
+			return nil
+		}
+		k, val, err := decodeKeyValue(v.raw)
+		if err != nil {
+			return err
+		}
+		if attrs == nil {
+			attrs = make(map[string]any)
+		}
+		attrs[k] = val
+		return nil
+	})
+	return attrs, err
+}
+
+func decodeKeyValue(b []byte) (k string, val any, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v fieldT) (err error) {
+		switch num {
+		case kvKey:
+			k, err = v.str(typ)
+		case kvValue:
+			val, err = decodeAny(v.raw)
+		}
+		return err
+	})
+	return
+}
+
+// Decode an AnyValue into string, bool, int64, float64, []byte, []any or
+// map[string]any; nil if unset.
+func decodeAny(b []byte) (val any, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v fieldT) (err error) {
+		switch num {
+		case anyString:
+			val, err = v.str(typ)
+		case anyBool:
+			var n int64
+			n, err = v.int(typ)
+			val = n != 0
+		case anyInt:
+			val, err = v.int(typ)
+		case anyDouble:
+			var n int64
+			n, err = v.fixed(typ)
+			val = math.Float64frombits(uint64(n))
+		case anyBytes:
+			val, err = v.bytes(typ)
+		case anyArray:
+			var list []any
+			err = walk(v.raw, func(num protowire.Number, typ protowire.Type, v fieldT) error {
+				if num != listValues {
+					return nil
+				}
+				item, err := decodeAny(v.raw)
+				list = append(list, item)
+				return err
+			})
+			if list == nil {
+				list = []any{}
+			}
+			val = list
+		case anyKvList:
+			var kv map[string]any
+			kv, err = decodeAttributes(v.raw, listValues)
+			if kv == nil {
+				kv = map[string]any{}
+			}
+			val = kv
+		}
+		return err
+	})
+	return
+}
+
+// fieldT is a decoded field value; raw holds the bytes of a length delimited
+// field, and n the value of a varint or fixed field.
+type fieldT struct {
+	raw []byte
+	n   uint64
+}
+
+func (f fieldT) int(typ protowire.Type) (int64, error) {
+	if typ != protowire.VarintType {
+		return 0, ErrOTLP
+	}
+	return int64(f.n), nil
+}
+
+func (f fieldT) fixed(typ protowire.Type) (int64, error) {
+	if typ != protowire.Fixed64Type {
+		return 0, ErrOTLP
+	}
+	return int64(f.n), nil
+}
+
+func (f fieldT) str(typ protowire.Type) (string, error) {
+	if typ != protowire.BytesType {
+		return "", ErrOTLP
+	}
+	return string(f.raw), nil
+}
+
+func (f fieldT) bytes(typ protowire.Type) ([]byte, error) {
+	if typ != protowire.BytesType {
+		return nil, ErrOTLP
+	}
+	return append([]byte{}, f.raw...), nil
+}
+
+// Call fn on each field of the message in b; unknown fields are passed too,
+// for fn to ignore.
+func walk(b []byte, fn func(protowire.Number, protowire.Type, fieldT) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %w", ErrOTLP, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var f fieldT
+		switch typ {
+		case protowire.VarintType:
+			f.n, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.n, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.raw, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %w", ErrOTLP, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, f); err != nil {
+			if errors.Is(err, ErrOTLP) {
+				return err
+			}
+			return fmt.Errorf("%w: field %d: %w", ErrOTLP, num, err)
+		}
+	}
+	return nil
+}
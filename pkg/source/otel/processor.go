@@ -0,0 +1,88 @@
+package otel
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/rules"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+)
+
+// HitFuncT receives the hits of the rule with id rule.
+type HitFuncT func(rule string, hits match.Hits)
+
+// Processor shows how rules run in a collector style pipeline: the pipeline
+// hands each batch of logs to ConsumeOTLP, or ConsumeRecords once converted
+// from pdata, before passing the batch on unchanged, and calls Eval on a timer
+// so that rules waiting on the clock fire while the pipeline is quiet.
+//
+// A batch is ordered by timestamp before it is scanned, since the records of
+// different resources interleave.  Batches should advance in time; a record
+// older than the newest already scanned is late to the matchers.
+type Processor struct {
+	ids     []string
+	scanner *scan.Scanner
+	cb      HitFuncT
+}
+
+// NewProcessor compiles the rule document, as rules.Compile.
+func NewProcessor(doc []byte, cb HitFuncT) (*Processor, error) {
+	rs, err := rules.Compile(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ids      = make([]string, 0, len(rs))
+		matchers = make([]match.Matcher, 0, len(rs))
+	)
+	for _, rule := range rs {
+		ids = append(ids, rule.Id)
+		matchers = append(matchers, rule.Matcher)
+	}
+
+	// Entries are converted here; the scanner needs no parser.
+	sc, err := scan.New(nil, matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Processor{ids: ids, scanner: sc, cb: cb}, nil
+}
+
+// ConsumeOTLP decodes and scans an OTLP logs message; see DecodeLogs.
+func (p *Processor) ConsumeOTLP(data []byte) error {
+	recs, err := DecodeLogs(data)
+	if err != nil {
+		return err
+	}
+	p.ConsumeRecords(recs)
+	return nil
+}
+
+// ConsumeRecords converts and scans a batch of records.
+func (p *Processor) ConsumeRecords(recs []RecordT) {
+	entries := make([]LogEntry, 0, len(recs))
+	for _, rec := range recs {
+		entries = append(entries, Convert(rec))
+	}
+
+	slices.SortStableFunc(entries, func(a, b LogEntry) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+
+	for _, e := range entries {
+		p.scanner.ScanEntry(e, p.emit)
+	}
+}
+
+// Eval evaluates the rules at clock.
+func (p *Processor) Eval(clock int64) {
+	p.scanner.Eval(clock, p.emit)
+}
+
+func (p *Processor) emit(h scan.HitT) bool {
+	p.cb(p.ids[h.Idx], h.Hits)
+	return false
+}
@@ -0,0 +1,59 @@
+package syslog
+
+import (
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+)
+
+const (
+	DefQueueSize = 1024
+	DefMaxSize   = 64 << 10
+)
+
+type OptT func(*optsT)
+
+type optsT struct {
+	queueSz int
+	maxSz   int
+	factory format.FactoryI
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		queueSz: DefQueueSize,
+		maxSz:   DefMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithQueueSize bounds the entries held between the listeners and the sink.
+// Defaults to DefQueueSize.
+func WithQueueSize(n int) OptT {
+	return func(o *optsT) {
+		if n > 0 {
+			o.queueSz = n
+		}
+	}
+}
+
+// WithMaxSize bounds the size of a message.  Defaults to DefMaxSize.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz > 0 {
+			o.maxSz = maxSz
+		}
+	}
+}
+
+// WithFactory parses messages with factory, rather than detecting RFC 5424
+// or RFC 3164 per message; for example an RFC 3164 factory with the location
+// of the senders.
+func WithFactory(factory format.FactoryI) OptT {
+	return func(o *optsT) {
+		o.factory = factory
+	}
+}
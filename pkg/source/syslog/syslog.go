@@ -0,0 +1,329 @@
+// Package syslog receives syslog messages over UDP and TCP and feeds them, as
+// entries, into matchers.
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNoListener = errors.New("no listener")
+	ErrFrame      = errors.New("bad syslog frame")
+)
+
+// Labels set on each entry.
+const (
+	LabelPeer      = "peer"      // Address of the sender, without the port.
+	LabelTransport = "transport" // "udp" or "tcp".
+)
+
+type LogEntry = match.LogEntry
+
+// SinkFuncT receives each entry; it is called from a single goroutine.
+type SinkFuncT func(LogEntry)
+
+// ScanSink feeds entries through sc, passing hits to cb.  Entries of different
+// peers interleave, so an entry may be late to the matchers.
+func ScanSink(sc *scan.Scanner, cb scan.HitFuncT) SinkFuncT {
+	return func(e LogEntry) {
+		sc.ScanEntry(e, cb)
+	}
+}
+
+// StatsT counts the messages a server has handled.
+type StatsT struct {
+	Received uint64 // Messages read, including those dropped.
+	Dropped  uint64 // Messages dropped; a full queue on UDP, or over size.
+	Unparsed uint64 // Messages that failed to parse; see Server.
+}
+
+// Server reads syslog messages and passes them to a sink.
+//
+// Each message is parsed as RFC 5424, falling back to RFC 3164, unless set
+// otherwise with WithFactory.  A message that fails to parse is kept whole as
+// the line, stamped with the time it was received.  The receive time is also
+// the ingest time of every entry, and entries are labelled with the peer and
+// transport.
+//
+// TCP streams may be framed by octet counting or by newlines (RFC 6587); the
+// framing is detected per message.
+//
+// Messages are queued for the sink, bounded by WithQueueSize.  When the queue
+// is full a TCP reader waits, so that flow control pushes back on the sender;
+// UDP has no such mechanism, and the message is dropped.
+type Server struct {
+	sink  SinkFuncT
+	queue chan LogEntry
+	o     optsT
+
+	received atomic.Uint64
+	dropped  atomic.Uint64
+	unparsed atomic.Uint64
+}
+
+func New(sink SinkFuncT, opts ...OptT) *Server {
+	o := parseOpts(opts)
+	return &Server{
+		sink:  sink,
+		queue: make(chan LogEntry, o.queueSz),
+		o:     o,
+	}
+}
+
+// Stats returns the message counters.
+func (s *Server) Stats() StatsT {
+	return StatsT{
+		Received: s.received.Load(),
+		Dropped:  s.dropped.Load(),
+		Unparsed: s.unparsed.Load(),
+	}
+}
+
+// Serve reads datagrams from pc and accepts streams on ln; either may be nil.
+// It runs until ctx is done, returning ctx.Err(), or until a listener fails.
+// On return pc, ln and every open stream are closed, and the queue has been
+// drained into the sink.
+func (s *Server) Serve(ctx context.Context, pc net.PacketConn, ln net.Listener) error {
+	if pc == nil && ln == nil {
+		return ErrNoListener
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		readers sync.WaitGroup
+		done    = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		for e := range s.queue {
+			s.sink(e)
+		}
+	}()
+
+	if pc != nil {
+		stop := context.AfterFunc(ctx, func() { pc.Close() })
+		defer stop()
+
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			if err := s.readUDP(ctx, pc); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	if ln != nil {
+		stop := context.AfterFunc(ctx, func() { ln.Close() })
+		defer stop()
+
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			if err := s.acceptTCP(ctx, ln, &readers); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	readers.Wait()
+	close(s.queue)
+	<-done
+
+	return context.Cause(ctx)
+}
+
+func (s *Server) readUDP(ctx context.Context, pc net.PacketConn) error {
+	var (
+		parser = s.parser()
+		buf    = make([]byte, s.o.maxSz+1)
+	)
+
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		s.received.Add(1)
+		if n > s.o.maxSz {
+			s.dropped.Add(1)
+			continue
+		}
+
+		e := s.entry(parser, bytes.TrimRight(buf[:n], "\r\n"), addr, "udp")
+
+		select {
+		case s.queue <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+func (s *Server) acceptTCP(ctx context.Context, ln net.Listener, wg *sync.WaitGroup) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+
+			if err := s.readTCP(ctx, conn); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Str("peer", conn.RemoteAddr().String()).Msg("Syslog stream failed")
+			}
+		}()
+	}
+}
+
+func (s *Server) readTCP(ctx context.Context, conn net.Conn) error {
+	var (
+		parser = s.parser()
+		rdr    = bufio.NewReaderSize(conn, s.o.maxSz+1)
+	)
+
+	for {
+		msg, err := s.readFrame(rdr)
+		switch {
+		case err == errTooLong:
+			s.received.Add(1)
+			s.dropped.Add(1)
+			continue
+		case err != nil:
+			if errors.Is(err, net.ErrClosed) || err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		s.received.Add(1)
+		e := s.entry(parser, msg, conn.RemoteAddr(), "tcp")
+
+		select {
+		case s.queue <- e:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+var errTooLong = errors.New("message too long")
+
+// Read an octet counted frame, 'LEN SP MSG', or a newline terminated one.
+// An over long newline frame is skipped with errTooLong; an over long counted
+// frame cannot be skipped safely, and fails.
+func (s *Server) readFrame(rdr *bufio.Reader) ([]byte, error) {
+	c, err := rdr.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if c[0] >= '1' && c[0] <= '9' {
+		hdr, err := rdr.ReadSlice(' ')
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFrame, err)
+		}
+		n, err := strconv.Atoi(string(hdr[:len(hdr)-1]))
+		if err != nil || n > s.o.maxSz {
+			return nil, fmt.Errorf("%w: length %q", ErrFrame, hdr[:len(hdr)-1])
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(rdr, msg); err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(msg, "\r\n"), nil
+	}
+
+	line, err := rdr.ReadSlice('\n')
+	switch {
+	case err == bufio.ErrBufferFull:
+		for err == bufio.ErrBufferFull {
+			_, err = rdr.ReadSlice('\n')
+		}
+		return nil, errTooLong
+	case err != nil && len(line) == 0:
+		return nil, err
+	}
+
+	// ReadSlice reuses the buffer; the entry is built before the next read.
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func (s *Server) entry(parser format.ParserI, msg []byte, addr net.Addr, transport string) LogEntry {
+	now := time.Now().UnixNano()
+
+	e, err := parser.ReadEntry(msg)
+	if err != nil {
+		s.unparsed.Add(1)
+		e = LogEntry{Line: string(msg), Timestamp: now}
+	}
+	e.IngestTime = now
+
+	peer := addr.String()
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	e.Labels = map[string]string{LabelPeer: peer, LabelTransport: transport}
+	return e
+}
+
+func (s *Server) parser() format.ParserI {
+	if s.o.factory != nil {
+		return s.o.factory.New()
+	}
+	return autoParserT{
+		rfc5424: format.NewRfc5424Factory().New(),
+		rfc3164: format.NewRfc3164Factory().New(),
+	}
+}
+
+// Parse RFC 5424, falling back to RFC 3164.
+type autoParserT struct {
+	rfc5424 format.ParserI
+	rfc3164 format.ParserI
+}
+
+// The reader cannot be rewound; only RFC 5424 is tried.
+func (p autoParserT) ReadTimestamp(rdr io.Reader) (int64, error) {
+	return p.rfc5424.ReadTimestamp(rdr)
+}
+
+func (p autoParserT) ReadEntry(line []byte) (LogEntry, error) {
+	if e, err := p.rfc5424.ReadEntry(line); err == nil {
+		return e, nil
+	}
+	return p.rfc3164.ReadEntry(line)
+}
@@ -0,0 +1,234 @@
+package syslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+)
+
+const (
+	msg5424 = "<165>1 2003-10-11T22:14:15.003Z host app - ID47 - disk full"
+	msg3164 = "<34>Oct 11 22:14:15 host su: 'su root' failed"
+)
+
+type runT struct {
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func serve(t *testing.T, s *Server, pc net.PacketConn, ln net.Listener) runT {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := runT{cancel: cancel, done: make(chan error, 1)}
+	go func() { r.done <- s.Serve(ctx, pc, ln) }()
+	return r
+}
+
+func (r runT) stop(t *testing.T) {
+	t.Helper()
+	r.cancel()
+	if err := <-r.done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func collect(t *testing.T, ch <-chan LogEntry, n int) []LogEntry {
+	t.Helper()
+	out := make([]LogEntry, 0, n)
+	for range n {
+		select {
+		case e := <-ch:
+			out = append(out, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d entries, got %d", n, len(out))
+		}
+	}
+	return out
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServerUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+
+	ch := make(chan LogEntry, 8)
+	s := New(func(e LogEntry) { ch <- e })
+	r := serve(t, s, pc, nil)
+	defer r.stop(t)
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer conn.Close()
+
+	for _, msg := range []string{msg5424 + "\n", msg3164, "garbage"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+
+	entries := collect(t, ch, 3)
+
+	exp := time.Date(2003, 10, 11, 22, 14, 15, 3e6, time.UTC).UnixNano()
+	if e := entries[0]; e.Line != "disk full" || e.Timestamp != exp {
+		t.Errorf("Expected 5424 entry, got %+v", e)
+	}
+	if e := entries[1]; e.Line != "su: 'su root' failed" {
+		t.Errorf("Expected 3164 entry, got %+v", e)
+	}
+	if e := entries[2]; e.Line != "garbage" || e.Timestamp != e.IngestTime {
+		t.Errorf("Expected raw entry at receive time, got %+v", e)
+	}
+
+	for _, e := range entries {
+		if e.Labels[LabelPeer] != "127.0.0.1" || e.Labels[LabelTransport] != "udp" || e.IngestTime == 0 {
+			t.Errorf("Expected peer labels, got %+v", e)
+		}
+	}
+
+	if st := s.Stats(); st != (StatsT{Received: 3, Unparsed: 1}) {
+		t.Errorf("Expected stats, got %+v", st)
+	}
+}
+
+func TestServerTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+
+	ch := make(chan LogEntry, 8)
+	s := New(func(e LogEntry) { ch <- e }, WithMaxSize(128))
+	r := serve(t, s, nil, ln)
+	defer r.stop(t)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer conn.Close()
+
+	stream := fmt.Sprintf("%d %s", len(msg5424), msg5424) + // Octet counted.
+		msg3164 + "\r\n" + // Newline framed.
+		strings.Repeat("x", 200) + "\n" + // Over size; skipped.
+		"<13>Oct 11 22:14:16 host tail\n"
+	if _, err := conn.Write([]byte(stream)); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	entries := collect(t, ch, 3)
+	for i, exp := range []string{"disk full", "su: 'su root' failed", "tail"} {
+		if entries[i].Line != exp || entries[i].Labels[LabelTransport] != "tcp" {
+			t.Errorf("%d: Expected %q over tcp, got %+v", i, exp, entries[i])
+		}
+	}
+
+	if st := s.Stats(); st != (StatsT{Received: 4, Dropped: 1}) {
+		t.Errorf("Expected stats, got %+v", st)
+	}
+}
+
+func TestServerBackPressure(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+
+	var (
+		gate = make(chan struct{})
+		ch   = make(chan LogEntry, 8)
+		s    = New(func(e LogEntry) { <-gate; ch <- e }, WithQueueSize(1))
+	)
+	r := serve(t, s, pc, nil)
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer conn.Close()
+
+	// One held by the sink, one queued; the rest are dropped.
+	if _, err := conn.Write([]byte("first")); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	waitFor(t, func() bool { return s.Stats().Received == 1 && len(s.queue) == 0 })
+	for range 4 {
+		if _, err := conn.Write([]byte("next")); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+	waitFor(t, func() bool { return s.Stats().Received == 5 })
+
+	close(gate)
+	collect(t, ch, 2)
+	r.stop(t)
+
+	if st := s.Stats(); st.Dropped != 3 {
+		t.Errorf("Expected 3 dropped, got %+v", st)
+	}
+}
+
+func TestScanSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+
+	m, err := match.NewMatchSeq(int64(time.Second), match.TermT{Type: match.TermRaw, Value: "alpha"}, match.TermT{Type: match.TermRaw, Value: "beta"})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	sc, err := scan.New(nil, []match.Matcher{m})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	hits := make(chan scan.HitT, 1)
+	s := New(ScanSink(sc, func(h scan.HitT) bool { hits <- h; return false }))
+	r := serve(t, s, nil, ln)
+	defer r.stop(t)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("<13>Oct 11 22:14:15 host alpha\n<13>Oct 11 22:14:15 host beta\n")); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	select {
+	case h := <-hits:
+		if len(h.Hits.Logs) != 2 || h.Hits.Logs[1].Labels[LabelPeer] != "127.0.0.1" {
+			t.Errorf("Expected a labelled hit, got %+v", h.Hits)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a hit")
+	}
+}
+
+func TestServerFail(t *testing.T) {
+	if err := New(func(LogEntry) {}).Serve(context.Background(), nil, nil); !errors.Is(err, ErrNoListener) {
+		t.Errorf("Expected %v, got %v", ErrNoListener, err)
+	}
+}
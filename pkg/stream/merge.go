@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"container/heap"
+	"io"
+	"math"
+)
+
+// MergeT merges several sources into one stream ordered by timestamp.  Ties
+// go to the source listed first, so the merge is deterministic.
+//
+// Each source is assumed to be in order.  The watermark of a source is the
+// timestamp of the latest entry read from it; the merge emits an entry only
+// once every open source has a watermark at or past it, so entries from
+// different sources interleave correctly.  An entry older than one already
+// emitted, from a source that is itself out of order, is emitted as is and
+// counted by Late.
+type MergeT struct {
+	srcs  []SourceI
+	marks []int64
+	heap  mergeHeapT
+	clock int64
+	late  int
+	init  bool
+	err   error
+}
+
+type mergeItemT struct {
+	entry LogEntry
+	src   int
+}
+
+func NewMerge(srcs ...SourceI) *MergeT {
+	marks := make([]int64, len(srcs))
+	for i := range marks {
+		marks[i] = math.MinInt64
+	}
+
+	return &MergeT{
+		srcs:  srcs,
+		marks: marks,
+		heap:  make(mergeHeapT, 0, len(srcs)),
+		clock: math.MinInt64,
+	}
+}
+
+// Next returns the oldest pending entry and the index of its source, or
+// io.EOF once every source is exhausted.  An error from a source stops the
+// merge and is returned by this and every later call.
+func (m *MergeT) Next() (LogEntry, int, error) {
+
+	if m.err != nil {
+		return LogEntry{}, -1, m.err
+	}
+
+	if !m.init {
+		m.init = true
+		for i := range m.srcs {
+			if err := m.pull(i); err != nil {
+				m.err = err
+				return LogEntry{}, -1, err
+			}
+		}
+	}
+
+	if len(m.heap) == 0 {
+		return LogEntry{}, -1, io.EOF
+	}
+
+	item := heap.Pop(&m.heap).(mergeItemT)
+
+	if item.entry.Timestamp < m.clock {
+		m.late += 1
+	} else {
+		m.clock = item.entry.Timestamp
+	}
+
+	// Refill from the same source before the entry is released.
+	if err := m.pull(item.src); err != nil {
+		m.err = err
+		return LogEntry{}, -1, err
+	}
+
+	return item.entry, item.src, nil
+}
+
+// Run emits every entry in order until the sources are exhausted, a source
+// fails, or cb returns true to stop.
+func (m *MergeT) Run(cb func(entry LogEntry, src int) bool) error {
+	for {
+		entry, src, err := m.Next()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		case cb(entry, src):
+			return nil
+		}
+	}
+}
+
+// Watermark returns the timestamp of the latest entry read from source idx,
+// math.MinInt64 before the first, and math.MaxInt64 once it is exhausted.
+func (m *MergeT) Watermark(idx int) int64 {
+	return m.marks[idx]
+}
+
+// Low returns the lowest watermark of the open sources; no entry older than
+// this is emitted, barring late entries.  It is math.MaxInt64 once every source
+// is exhausted.
+func (m *MergeT) Low() int64 {
+	low := int64(math.MaxInt64)
+	for _, mark := range m.marks {
+		low = min(low, mark)
+	}
+	return low
+}
+
+// Late returns the number of entries emitted behind the merged clock.
+func (m *MergeT) Late() int {
+	return m.late
+}
+
+// Read the next entry of source idx onto the heap.
+func (m *MergeT) pull(idx int) error {
+	entry, err := m.srcs[idx].Next()
+	switch {
+	case err == io.EOF:
+		m.marks[idx] = math.MaxInt64
+		return nil
+	case err != nil:
+		return err
+	}
+
+	m.marks[idx] = max(m.marks[idx], entry.Timestamp)
+	heap.Push(&m.heap, mergeItemT{entry: entry, src: idx})
+	return nil
+}
+
+type mergeHeapT []mergeItemT
+
+func (h mergeHeapT) Len() int { return len(h) }
+
+func (h mergeHeapT) Less(i, j int) bool {
+	if h[i].entry.Timestamp != h[j].entry.Timestamp {
+		return h[i].entry.Timestamp < h[j].entry.Timestamp
+	}
+	return h[i].src < h[j].src
+}
+
+func (h mergeHeapT) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeapT) Push(x any) { *h = append(*h, x.(mergeItemT)) }
+
+func (h *mergeHeapT) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = mergeItemT{}
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+)
+
+// Lines are of the form "<stamp> <text>", with the stamp in nanoseconds.
+func newSource(t *testing.T, data string, opts ...OptT) *ReaderSourceT {
+	t.Helper()
+	factory, err := format.NewEpochFactory(format.EpochNanos)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	return NewReaderSource(strings.NewReader(data), factory, opts...)
+}
+
+type errSourceT struct {
+	err error
+}
+
+func (s errSourceT) Next() (LogEntry, error) {
+	return LogEntry{}, s.err
+}
+
+func TestMerge(t *testing.T) {
+
+	m := NewMerge(
+		newSource(t, "1 a1\n4 a4\n4 a4b\n9 a9\n"),
+		newSource(t, "2 b2\n4 b4\n5 b5\n"),
+		newSource(t, ""),
+		newSource(t, "3 c3\n10 c10"),
+	)
+
+	if m.Low() != math.MinInt64 {
+		t.Errorf("Expected unset low watermark got %d", m.Low())
+	}
+
+	var (
+		lines []string
+		srcs  []int
+	)
+
+	err := m.Run(func(entry LogEntry, src int) bool {
+		lines = append(lines, entry.Line)
+		srcs = append(srcs, src)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	want := "a1 b2 c3 a4 a4b b4 b5 a9 c10"
+	if got := strings.Join(lines, " "); got != want {
+		t.Errorf("Expected %q got %q", want, got)
+	}
+	if srcs[5] != 1 || srcs[8] != 3 {
+		t.Errorf("Unexpected sources %v", srcs)
+	}
+
+	if m.Low() != math.MaxInt64 || m.Watermark(0) != math.MaxInt64 {
+		t.Errorf("Expected exhausted watermarks got %d, %d", m.Low(), m.Watermark(0))
+	}
+	if m.Late() != 0 {
+		t.Errorf("Expected no late entries got %d", m.Late())
+	}
+
+	if _, _, err := m.Next(); err != io.EOF {
+		t.Errorf("Expected EOF got %v", err)
+	}
+}
+
+func TestMergeWatermark(t *testing.T) {
+
+	m := NewMerge(
+		newSource(t, "1 a1\n5 a5\n"),
+		newSource(t, "2 b2\n3 b3\n"),
+	)
+
+	// Both sources have been read ahead by one entry.
+	if entry, _, err := m.Next(); err != nil || entry.Line != "a1" {
+		t.Fatalf("Expected a1 got %q, %v", entry.Line, err)
+	}
+	if m.Watermark(0) != 5 || m.Watermark(1) != 2 || m.Low() != 2 {
+		t.Errorf("Expected watermarks 5, 2, 2 got %d, %d, %d", m.Watermark(0), m.Watermark(1), m.Low())
+	}
+}
+
+func TestMergeLate(t *testing.T) {
+
+	m := NewMerge(
+		newSource(t, "5 a5\n2 a2\n"),
+		newSource(t, "3 b3\n6 b6\n"),
+	)
+
+	var lines []string
+	if err := m.Run(func(entry LogEntry, _ int) bool {
+		lines = append(lines, entry.Line)
+		return false
+	}); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	want := "b3 a5 a2 b6"
+	if got := strings.Join(lines, " "); got != want {
+		t.Errorf("Expected %q got %q", want, got)
+	}
+	if m.Late() != 1 {
+		t.Errorf("Expected 1 late entry got %d", m.Late())
+	}
+}
+
+func TestMergeError(t *testing.T) {
+
+	errBad := errors.New("bad source")
+
+	m := NewMerge(newSource(t, "1 a1\n"), errSourceT{err: errBad})
+
+	if _, _, err := m.Next(); !errors.Is(err, errBad) {
+		t.Errorf("Expected %v got %v", errBad, err)
+	}
+	if err := m.Run(func(LogEntry, int) bool { return false }); !errors.Is(err, errBad) {
+		t.Errorf("Expected sticky %v got %v", errBad, err)
+	}
+}
+
+// A sequence spanning two services only matches over the merged stream.
+func TestMergeScan(t *testing.T) {
+
+	sm, err := match.NewMatchSeq(10,
+		match.TermT{Type: match.TermRaw, Value: "request sent"},
+		match.TermT{Type: match.TermRaw, Value: "request received"},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	s, err := scan.New(nil, []match.Matcher{sm})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	m := NewMerge(
+		newSource(t, "5 request received\n"),
+		newSource(t, "3 request sent\n"),
+	)
+
+	var hits []scan.HitT
+	cb := func(h scan.HitT) bool {
+		hits = append(hits, h)
+		return false
+	}
+
+	if err := m.Run(func(entry LogEntry, _ int) bool {
+		return s.ScanEntry(entry, cb)
+	}); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	s.Eval(math.MaxInt64, cb)
+
+	if len(hits) != 1 {
+		t.Errorf("Expected 1 hit got %d", len(hits))
+	}
+}
@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+
+	"github.com/rs/zerolog/log"
+)
+
+const MaxRecordSize = pool.MaxRecordSize
+
+// ErrFuncT is called on a line that cannot be parsed.  Return nil to skip the
+// line and continue, or an error to abort the source.
+type ErrFuncT func([]byte, error) error
+
+type OptT func(*optsT)
+
+type optsT struct {
+	name  string
+	maxSz int
+	errF  ErrFuncT
+}
+
+func defaultErrFunc(line []byte, err error) error {
+	// Tolerate badly formed lines
+	log.Debug().
+		Err(err).
+		Int("size", len(line)).
+		Msg("Fail line.  Continue...")
+	return nil
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		maxSz: MaxRecordSize,
+		errF:  defaultErrFunc,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithName labels each entry of the source under LabelSource.
+func WithName(name string) OptT {
+	return func(o *optsT) {
+		o.name = name
+	}
+}
+
+// WithMaxSize sets the largest line accepted.  A longer line aborts the source
+// with bufio.ErrTooLong.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz <= 0 || maxSz > MaxRecordSize {
+			maxSz = MaxRecordSize
+		}
+		o.maxSz = maxSz
+	}
+}
+
+func WithErrFunc(errF ErrFuncT) OptT {
+	return func(o *optsT) {
+		o.errF = errF
+	}
+}
@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"maps"
+	"os"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+)
+
+// LabelSource is the label under which a named source records its name.
+const LabelSource = "source"
+
+type LogEntry = match.LogEntry
+
+// SourceI yields the entries of a single source, such as one file, in the
+// order they were logged.  Next returns io.EOF once the source is exhausted.
+type SourceI interface {
+	Next() (LogEntry, error)
+}
+
+// ReaderSourceT parses the lines of a reader into entries.  Lines are split on
+// '\n', with an optional trailing '\r' removed.  Lines that fail to parse are
+// passed to the error function.
+type ReaderSourceT struct {
+	scanner *bufio.Scanner
+	parser  format.ParserI
+	labels  map[string]string
+	lineNo  int64
+	closer  io.Closer
+	o       optsT
+}
+
+func NewReaderSource(rdr io.Reader, factory format.FactoryI, opts ...OptT) *ReaderSourceT {
+
+	o := parseOpts(opts)
+
+	scanner := bufio.NewScanner(rdr)
+	scanner.Buffer(make([]byte, 0, min(o.maxSz, 64<<10)), o.maxSz)
+
+	var labels map[string]string
+	if o.name != "" {
+		labels = map[string]string{LabelSource: o.name}
+	}
+
+	return &ReaderSourceT{
+		scanner: scanner,
+		parser:  factory.New(),
+		labels:  labels,
+		o:       o,
+	}
+}
+
+// OpenFile opens the file at path as a source named after path, unless named
+// with WithName.  If factory is nil the format is detected from a sample of the
+// file.  The caller must Close the source.
+func OpenFile(path string, factory format.FactoryI, opts ...OptT) (*ReaderSourceT, error) {
+
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if factory == nil {
+		res, err := format.DetectSample(fh)
+		if err == nil {
+			_, err = fh.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			fh.Close()
+			return nil, err
+		}
+		factory = res.Factory
+	}
+
+	opts = append([]OptT{WithName(path)}, opts...)
+
+	src := NewReaderSource(fh, factory, opts...)
+	src.closer = fh
+	return src, nil
+}
+
+// Next returns the next entry, or io.EOF at the end of the reader.
+func (s *ReaderSourceT) Next() (LogEntry, error) {
+
+	for s.scanner.Scan() {
+		s.lineNo += 1
+
+		entry, err := s.parser.ReadEntry(s.scanner.Bytes())
+		switch {
+		case errors.Is(err, format.ErrPartial):
+			// Held by the parser until the line is complete.
+			continue
+		case err != nil:
+			if err = s.o.errF(s.scanner.Bytes(), err); err != nil {
+				return LogEntry{}, err
+			}
+			continue
+		}
+
+		entry.LineNo = s.lineNo
+		s.label(&entry)
+		return entry, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return LogEntry{}, err
+	}
+	return LogEntry{}, io.EOF
+}
+
+// Close closes the underlying file of a source from OpenFile.
+func (s *ReaderSourceT) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// Entries without labels share the source's map; those with labels of their
+// own get a copy.
+func (s *ReaderSourceT) label(entry *LogEntry) {
+	switch {
+	case s.labels == nil:
+	case entry.Labels == nil:
+		entry.Labels = s.labels
+	default:
+		labels := maps.Clone(entry.Labels)
+		labels[LabelSource] = s.o.name
+		entry.Labels = labels
+	}
+}
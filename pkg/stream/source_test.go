@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+)
+
+func TestReaderSource(t *testing.T) {
+
+	var bad []string
+	src := newSource(t, "1 one\r\nbogus\n\n2 two", WithName("svc"), WithErrFunc(func(line []byte, err error) error {
+		bad = append(bad, string(line))
+		return nil
+	}))
+
+	tests := []struct {
+		line   string
+		ts     int64
+		lineNo int64
+	}{
+		{"one", 1, 1},
+		{"two", 2, 4},
+	}
+
+	for _, tc := range tests {
+		entry, err := src.Next()
+		if err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+		if entry.Line != tc.line || entry.Timestamp != tc.ts || entry.LineNo != tc.lineNo {
+			t.Errorf("Expected %q at %d line %d got %q at %d line %d",
+				tc.line, tc.ts, tc.lineNo, entry.Line, entry.Timestamp, entry.LineNo)
+		}
+		if entry.Labels[LabelSource] != "svc" {
+			t.Errorf("Expected source label svc got %v", entry.Labels)
+		}
+	}
+
+	if _, err := src.Next(); err != io.EOF {
+		t.Errorf("Expected EOF got %v", err)
+	}
+	if len(bad) != 2 {
+		t.Errorf("Expected 2 bad lines got %q", bad)
+	}
+}
+
+func TestReaderSourceErrors(t *testing.T) {
+
+	errAbort := errors.New("abort")
+
+	src := newSource(t, "bogus\n1 one\n", WithErrFunc(func([]byte, error) error {
+		return errAbort
+	}))
+	if _, err := src.Next(); !errors.Is(err, errAbort) {
+		t.Errorf("Expected %v got %v", errAbort, err)
+	}
+
+	src = newSource(t, "1 "+strings.Repeat("x", 64)+"\n", WithMaxSize(32))
+	if _, err := src.Next(); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Expected %v got %v", bufio.ErrTooLong, err)
+	}
+}
+
+func TestReaderSourceLabels(t *testing.T) {
+
+	src := NewReaderSource(
+		strings.NewReader("I0102 15:04:05.000000   1 main.go:1] hello\n"),
+		format.NewKlogFactory(),
+		WithName("svc"),
+	)
+
+	entry, err := src.Next()
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	if entry.Labels[LabelSource] != "svc" || entry.Labels[format.LabelSeverity] != "INFO" {
+		t.Errorf("Expected merged labels got %v", entry.Labels)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	data := "2024-02-13T15:12:44.000000000Z first\n2024-02-13T15:12:45.000000000Z second\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	src, err := OpenFile(path, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+	defer src.Close()
+
+	for _, want := range []string{"first", "second"} {
+		entry, err := src.Next()
+		if err != nil {
+			t.Fatalf("Expected nil error got %v", err)
+		}
+		if entry.Line != want || entry.Labels[LabelSource] != path {
+			t.Errorf("Expected %q from %s got %q from %v", want, path, entry.Line, entry.Labels)
+		}
+	}
+
+	if _, err := OpenFile(filepath.Join(t.TempDir(), "missing.log"), nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected %v got %v", os.ErrNotExist, err)
+	}
+}
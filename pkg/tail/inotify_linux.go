@@ -0,0 +1,84 @@
+//go:build linux
+
+package tail
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_MODIFY | unix.IN_CREATE | unix.IN_MOVED_TO |
+	unix.IN_MOVED_FROM | unix.IN_DELETE | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB
+
+// Watch the parent directory so that rename and recreate are observed.
+type inotifyWaiter struct {
+	fd       int
+	interval time.Duration
+	buf      []byte
+}
+
+func newInotifyWaiter(path string, interval time.Duration) (waiterI, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := unix.InotifyAddWatch(fd, filepath.Dir(path), inotifyMask); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	return &inotifyWaiter{
+		fd:       fd,
+		interval: interval,
+		buf:      make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1)),
+	}, nil
+}
+
+// Returns on any event in the directory, or after the interval elapses.
+// Events are coalesced; the tailer re-examines the file on every wake.
+func (w *inotifyWaiter) wait(ctx context.Context) error {
+	const slice = 50 * time.Millisecond
+
+	var (
+		deadline = time.Now().Add(w.interval)
+		fds      = []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		left := time.Until(deadline)
+		if left <= 0 {
+			return nil
+		}
+
+		n, err := unix.Poll(fds, int(min(left, slice).Milliseconds())+1)
+		switch {
+		case err == unix.EINTR:
+			continue
+		case err != nil:
+			return err
+		case n > 0:
+			w.drain()
+			return nil
+		}
+	}
+}
+
+func (w *inotifyWaiter) drain() {
+	for {
+		if n, err := unix.Read(w.fd, w.buf); n <= 0 || err != nil {
+			return
+		}
+	}
+}
+
+func (w *inotifyWaiter) close() error {
+	return unix.Close(w.fd)
+}
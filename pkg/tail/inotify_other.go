@@ -0,0 +1,11 @@
+//go:build !linux
+
+package tail
+
+import (
+	"time"
+)
+
+func newInotifyWaiter(path string, interval time.Duration) (waiterI, error) {
+	return nil, ErrBackendUnsupported
+}
@@ -0,0 +1,88 @@
+package tail
+
+import (
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/internal/pkg/pool"
+)
+
+// BackendT selects how the tailer waits for the file to change.
+type BackendT int
+
+const (
+	BackendPoll    BackendT = iota // Stat the file on an interval.
+	BackendInotify                 // Wake on inotify events; Linux only.
+)
+
+const (
+	defPollInterval = 250 * time.Millisecond
+	MaxRecordSize   = pool.MaxRecordSize
+)
+
+type OptT func(*optsT)
+
+type optsT struct {
+	backend   BackendT
+	interval  time.Duration
+	fromStart bool
+	maxSz     int
+	heartbeat time.Duration
+}
+
+func parseOpts(opts []OptT) optsT {
+	o := optsT{
+		backend:  BackendPoll,
+		interval: defPollInterval,
+		maxSz:    MaxRecordSize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func WithBackend(backend BackendT) OptT {
+	return func(o *optsT) {
+		o.backend = backend
+	}
+}
+
+// WithPollInterval sets the poll interval.  For the inotify backend, this is
+// the upper bound between checks in case an event is missed.
+func WithPollInterval(interval time.Duration) OptT {
+	return func(o *optsT) {
+		if interval > 0 {
+			o.interval = interval
+		}
+	}
+}
+
+// WithFromStart reads the file from the beginning rather than from the end.
+// Files opened after a rotation are always read from the beginning.
+func WithFromStart(fromStart bool) OptT {
+	return func(o *optsT) {
+		o.fromStart = fromStart
+	}
+}
+
+// WithMaxSize sets the largest line accepted; longer lines are skipped.
+func WithMaxSize(maxSz int) OptT {
+	return func(o *optsT) {
+		if maxSz <= 0 || maxSz > MaxRecordSize {
+			maxSz = MaxRecordSize
+		}
+		o.maxSz = maxSz
+	}
+}
+
+// WithHeartbeat has Run scan a heartbeat, stamped with the wall clock, each
+// time the file is quiet for interval; see scan.Scanner.Heartbeat.  Checked
+// after each wait, so the effective interval is rounded up to the poll
+// interval.  Suits logs whose timestamps track the wall clock.
+func WithHeartbeat(interval time.Duration) OptT {
+	return func(o *optsT) {
+		o.heartbeat = interval
+	}
+}
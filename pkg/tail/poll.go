@@ -0,0 +1,31 @@
+package tail
+
+import (
+	"context"
+	"time"
+)
+
+type waiterI interface {
+	wait(ctx context.Context) error
+	close() error
+}
+
+type pollWaiter struct {
+	interval time.Duration
+}
+
+func (w *pollWaiter) wait(ctx context.Context) error {
+	timer := time.NewTimer(w.interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (w *pollWaiter) close() error {
+	return nil
+}
@@ -0,0 +1,297 @@
+package tail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrBackendUnsupported = errors.New("tail backend unsupported on this platform")
+)
+
+const readSize = 32 << 10
+
+// LineFuncT receives each complete line, without the terminator.
+// The slice is only valid for the duration of the call.
+type LineFuncT func([]byte) error
+
+// Tailer follows a file as it grows.
+//
+// When the file shrinks below the current offset it is considered truncated, and
+// reading restarts from the beginning.  When the path refers to a different file
+// than the one open (rename and recreate), the old file is drained, and the new
+// file is read from the beginning.  A trailing partial line is held until its
+// newline arrives, or emitted as is when the file is rotated away.
+
+type Tailer struct {
+	path string
+	o    optsT
+
+	fd      *os.File
+	fi      os.FileInfo
+	offset  int64
+	pending []byte
+	discard bool
+	buf     []byte
+}
+
+func New(path string, opts ...OptT) *Tailer {
+	return &Tailer{
+		path: path,
+		o:    parseOpts(opts),
+	}
+}
+
+// Follow tails the file until ctx is done or lineF returns an error.
+func (t *Tailer) Follow(ctx context.Context, lineF LineFuncT) error {
+	return t.follow(ctx, lineF, nil)
+}
+
+// Tail the file, calling idleF, if set, after each wait for the file to change.
+func (t *Tailer) follow(ctx context.Context, lineF LineFuncT, idleF func() error) error {
+
+	waiter, err := t.newWaiter()
+	if err != nil {
+		return err
+	}
+	defer waiter.close()
+
+	if err := t.open(!t.o.fromStart); err != nil {
+		return err
+	}
+	defer t.closeFile()
+
+	t.buf = make([]byte, readSize)
+
+	for {
+		if err := t.drain(lineF); err != nil {
+			return err
+		}
+
+		if err := waiter.wait(ctx); err != nil {
+			return err
+		}
+
+		if idleF != nil {
+			if err := idleF(); err != nil {
+				return err
+			}
+		}
+
+		if err := t.check(lineF); err != nil {
+			return err
+		}
+	}
+}
+
+// Run tails the file into the scanner, passing hits to cb.  With
+// WithHeartbeat, a heartbeat is scanned at the wall clock each time the file
+// has been quiet for the interval.  Returns nil if cb requests a stop.
+func (t *Tailer) Run(ctx context.Context, s *scan.Scanner, cb scan.HitFuncT) error {
+
+	var (
+		errStop = errors.New("stop")
+		active  = time.Now()
+		idleF   func() error
+	)
+
+	if t.o.heartbeat > 0 {
+		idleF = func() error {
+			now := time.Now()
+			if now.Sub(active) < t.o.heartbeat {
+				return nil
+			}
+			active = now
+			if s.Heartbeat(now.UnixNano(), cb) {
+				return errStop
+			}
+			return nil
+		}
+	}
+
+	err := t.follow(ctx, func(line []byte) error {
+		if idleF != nil {
+			active = time.Now()
+		}
+		stop, err := s.Feed(line, cb)
+		switch {
+		case err != nil:
+			return err
+		case stop:
+			return errStop
+		}
+		return nil
+	}, idleF)
+
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+func (t *Tailer) newWaiter() (waiterI, error) {
+	switch t.o.backend {
+	case BackendInotify:
+		return newInotifyWaiter(t.path, t.o.interval)
+	default:
+		return &pollWaiter{interval: t.o.interval}, nil
+	}
+}
+
+func (t *Tailer) open(atEnd bool) error {
+	fd, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	fi, err := fd.Stat()
+	if err != nil {
+		fd.Close()
+		return err
+	}
+
+	var offset int64
+	if atEnd {
+		if offset, err = fd.Seek(0, io.SeekEnd); err != nil {
+			fd.Close()
+			return err
+		}
+	}
+
+	t.fd = fd
+	t.fi = fi
+	t.offset = offset
+	t.pending = t.pending[:0]
+	t.discard = false
+	return nil
+}
+
+func (t *Tailer) closeFile() {
+	if t.fd != nil {
+		t.fd.Close()
+		t.fd = nil
+	}
+}
+
+// Detect rotation and truncation.
+func (t *Tailer) check(lineF LineFuncT) error {
+
+	fi, err := os.Stat(t.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Mid rotation; wait for the file to be recreated.
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if !os.SameFile(fi, t.fi) {
+		// Rotated; finish the old file, flush any partial line, and switch.
+		if err := t.drain(lineF); err != nil {
+			return err
+		}
+		if err := t.flush(lineF); err != nil {
+			return err
+		}
+		t.closeFile()
+
+		log.Debug().Str("path", t.path).Msg("Tail: File rotated")
+		return t.open(false)
+	}
+
+	if fi.Size() < t.offset {
+		log.Debug().
+			Str("path", t.path).
+			Int64("offset", t.offset).
+			Int64("size", fi.Size()).
+			Msg("Tail: File truncated")
+
+		if _, err := t.fd.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.offset = 0
+		t.pending = t.pending[:0]
+		t.discard = false
+	}
+
+	return nil
+}
+
+// Read to EOF, emitting complete lines.
+func (t *Tailer) drain(lineF LineFuncT) error {
+	for {
+		n, err := t.fd.Read(t.buf)
+		if n > 0 {
+			t.offset += int64(n)
+			if lerr := t.split(t.buf[:n], lineF); lerr != nil {
+				return lerr
+			}
+		}
+
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		case n == 0:
+			return nil
+		}
+	}
+}
+
+func (t *Tailer) split(data []byte, lineF LineFuncT) error {
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			t.hold(data)
+			return nil
+		}
+
+		t.hold(data[:idx])
+		data = data[idx+1:]
+
+		if err := t.flush(lineF); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tailer) hold(data []byte) {
+	if t.discard {
+		return
+	}
+	if len(t.pending)+len(data) > t.o.maxSz {
+		log.Warn().
+			Str("path", t.path).
+			Int("max", t.o.maxSz).
+			Msg("Tail: Line too long; skipped")
+		t.discard = true
+		t.pending = t.pending[:0]
+		return
+	}
+	t.pending = append(t.pending, data...)
+}
+
+func (t *Tailer) flush(lineF LineFuncT) error {
+	var (
+		line    = bytes.TrimSuffix(t.pending, []byte{'\r'})
+		discard = t.discard
+	)
+
+	t.pending = t.pending[:0]
+	t.discard = false
+
+	if discard || len(line) == 0 {
+		return nil
+	}
+	return lineF(line)
+}
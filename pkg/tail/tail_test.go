@@ -0,0 +1,242 @@
+package tail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/format"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/scan"
+)
+
+func writeAppend(path, data string) error {
+	fd, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = fd.WriteString(data)
+	return err
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := writeAppend(path, data); err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+}
+
+func expectLines(t *testing.T, lineC <-chan string, lines ...string) {
+	t.Helper()
+	for _, exp := range lines {
+		select {
+		case got := <-lineC:
+			if got != exp {
+				t.Fatalf("Expected %q, got %q", exp, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timeout waiting for %q", exp)
+		}
+	}
+}
+
+func startFollow(t *testing.T, path string, opts ...OptT) (<-chan string, func()) {
+	t.Helper()
+
+	var (
+		lineC       = make(chan string, 100)
+		errC        = make(chan error, 1)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	go func() {
+		errC <- New(path, opts...).Follow(ctx, func(line []byte) error {
+			lineC <- string(line)
+			return nil
+		})
+	}()
+
+	return lineC, func() {
+		cancel()
+		if err := <-errC; err != context.Canceled {
+			t.Errorf("Expected %v, got %v", context.Canceled, err)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+
+	backends := map[string]BackendT{
+		"Poll":    BackendPoll,
+		"Inotify": BackendInotify,
+	}
+
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			if backend == BackendInotify && runtime.GOOS != "linux" {
+				t.Skip("inotify unsupported")
+			}
+
+			path := filepath.Join(t.TempDir(), "app.log")
+			appendFile(t, path, "old line\n")
+
+			lineC, stop := startFollow(t, path,
+				WithBackend(backend),
+				WithPollInterval(10*time.Millisecond),
+			)
+			defer stop()
+
+			// Default starts at the end; give the tailer a moment to open the file.
+			time.Sleep(50 * time.Millisecond)
+
+			// Growth, including a line split across writes.
+			appendFile(t, path, "one\ntw")
+			time.Sleep(30 * time.Millisecond)
+			appendFile(t, path, "o\r\n")
+			expectLines(t, lineC, "one", "two")
+
+			// Truncation
+			if err := os.Truncate(path, 0); err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+			time.Sleep(50 * time.Millisecond)
+			appendFile(t, path, "three\n")
+			expectLines(t, lineC, "three")
+
+			// Rotation; rename and recreate.  The partial line of the old file is flushed.
+			appendFile(t, path, "partial")
+			time.Sleep(30 * time.Millisecond)
+			if err := os.Rename(path, path+".1"); err != nil {
+				t.Fatalf("Expected nil error got %v", err)
+			}
+			appendFile(t, path, "four\n")
+			expectLines(t, lineC, "partial", "four")
+		})
+	}
+}
+
+func TestTailFromStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\n")
+
+	lineC, stop := startFollow(t, path, WithFromStart(true), WithPollInterval(10*time.Millisecond))
+	defer stop()
+
+	expectLines(t, lineC, "one", "two")
+}
+
+func TestTailLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "0123456789abcdef\nok\n")
+
+	lineC, stop := startFollow(t, path, WithFromStart(true), WithMaxSize(8), WithPollInterval(10*time.Millisecond))
+	defer stop()
+
+	expectLines(t, lineC, "ok")
+}
+
+func TestTailMissing(t *testing.T) {
+	err := New(filepath.Join(t.TempDir(), "missing.log")).Follow(context.Background(), nil)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected not exist error, got %v", err)
+	}
+}
+
+func TestTailRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "1 alpha\n")
+
+	factory, err := format.NewRegexFactory(`^(\d+) `, func(m []byte) (int64, error) {
+		return strconv.ParseInt(string(m), 10, 64)
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	sm, err := match.NewMatchSeq(10,
+		match.TermT{Type: match.TermRaw, Value: "alpha"},
+		match.TermT{Type: match.TermRaw, Value: "beta"},
+	)
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	s, err := scan.New(factory.New(), []match.Matcher{sm})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if err := writeAppend(path, "2 beta\n"); err != nil {
+			t.Errorf("Expected nil error got %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var hits []scan.HitT
+	err = New(path, WithFromStart(true), WithPollInterval(10*time.Millisecond)).Run(ctx, s, func(h scan.HitT) bool {
+		hits = append(hits, h)
+		return true
+	})
+
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if len(hits) != 1 || hits[0].Hits.Logs[1].Line != "2 beta" {
+		t.Errorf("Expected 1 hit, got %+v", hits)
+	}
+}
+
+func TestTailRunHeartbeat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// Stamped with the wall clock; silence follows.
+	appendFile(t, path, strconv.FormatInt(time.Now().UnixNano(), 10)+" alpha\n")
+
+	factory, err := format.NewRegexFactory(`^(\d+) `, func(m []byte) (int64, error) {
+		return strconv.ParseInt(string(m), 10, 64)
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	am, err := match.NewMatchAbsence(int64(30*time.Millisecond), match.TermT{Type: match.TermRaw, Value: "alpha"}, match.WithAbsence(match.AbsencePrevious))
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	s, err := scan.New(factory.New(), []match.Matcher{am})
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var hits []scan.HitT
+	err = New(path,
+		WithFromStart(true),
+		WithPollInterval(5*time.Millisecond),
+		WithHeartbeat(10*time.Millisecond),
+	).Run(ctx, s, func(h scan.HitT) bool {
+		hits = append(hits, h)
+		return true
+	})
+
+	if err != nil {
+		t.Fatalf("Expected nil error got %v", err)
+	}
+
+	if len(hits) != 1 || len(hits[0].Hits.Logs) != 1 || hits[0].Hits.Logs[0].Stream == scan.StreamHeartbeat {
+		t.Errorf("Expected 1 hit on the alpha anchor, got %+v", hits)
+	}
+}
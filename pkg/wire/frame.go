@@ -0,0 +1,59 @@
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var ErrFrameSize = errors.New("frame too large")
+
+// WriteFrame writes b prefixed by its varint encoded length; the framing of
+// protodelim, for transports that do not delimit messages themselves.
+func WriteFrame(w io.Writer, b []byte) error {
+	buf := protowire.AppendVarint(make([]byte, 0, len(b)+binary.MaxVarintLen64), uint64(len(b)))
+	_, err := w.Write(append(buf, b...))
+	return err
+}
+
+// ReadFrame reads a frame written by WriteFrame.  Frames over maxSz bytes fail
+// with ErrFrameSize.  Returns io.EOF only on a clean end between frames.
+func ReadFrame(r *bufio.Reader, maxSz int) ([]byte, error) {
+	var (
+		sz    uint64
+		shift uint
+	)
+	for i := 0; ; i++ {
+		c, err := r.ReadByte()
+		switch {
+		case err == io.EOF && i > 0:
+			return nil, io.ErrUnexpectedEOF
+		case err != nil:
+			return nil, err
+		case i == binary.MaxVarintLen64:
+			return nil, fmt.Errorf("%w: bad length", ErrWire)
+		}
+		sz |= uint64(c&0x7f) << shift
+		shift += 7
+		if c < 0x80 {
+			break
+		}
+	}
+
+	if sz > uint64(maxSz) {
+		return nil, fmt.Errorf("%w: %d > %d", ErrFrameSize, sz, maxSz)
+	}
+
+	b := make([]byte, sz)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
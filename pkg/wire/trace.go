@@ -0,0 +1,231 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var ErrDiverged = errors.New("replay diverged from trace")
+
+// MaxCallSize bounds a Call frame read from a trace; hits may carry many
+// entries, so this is well above the size of a single record.
+const MaxCallSize = 64 << 20
+
+// CallKindT is the matcher method of a recorded call.
+type CallKindT int
+
+const (
+	CallScan CallKindT = iota
+	CallEval
+	CallGC
+)
+
+// CallT is a Call message.  Entry is set for a Scan, and Clock for an Eval or
+// GarbageCollect.
+type CallT struct {
+	Kind  CallKindT
+	Entry LogEntry
+	Clock int64
+	Hits  match.Hits
+}
+
+// MarshalCall encodes a Call message.
+func MarshalCall(c CallT) ([]byte, error) {
+	var b []byte
+
+	switch c.Kind {
+	case CallScan:
+		sub, err := appendLogEntry(nil, c.Entry)
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, callScan, sub)
+	case CallEval, CallGC:
+		// The oneof has presence; a zero clock is still written.
+		num := protowire.Number(callEval)
+		if c.Kind == CallGC {
+			num = callGC
+		}
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(c.Clock))
+	default:
+		return nil, fmt.Errorf("%w: call kind %d", ErrWire, c.Kind)
+	}
+
+	if c.Hits.Cnt > 0 {
+		sub, err := MarshalHits(c.Hits)
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, callHits, sub)
+	}
+	return b, nil
+}
+
+// UnmarshalCall decodes a Call message.  Should more than one field of the
+// oneof be present, the last wins.
+func UnmarshalCall(b []byte) (c CallT, err error) {
+	var seen bool
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		switch num {
+		case callScan:
+			if typ != protowire.BytesType {
+				return ErrWire
+			}
+			c.Kind, c.Clock, seen = CallScan, 0, true
+			c.Entry, err = UnmarshalLogEntry(v.raw)
+		case callEval, callGC:
+			c.Kind, c.Entry, seen = CallEval, LogEntry{}, true
+			if num == callGC {
+				c.Kind = CallGC
+			}
+			c.Clock, err = v.int(typ)
+		case callHits:
+			c.Hits, err = UnmarshalHits(v.raw)
+		}
+		return err
+	})
+	if err == nil && !seen {
+		err = fmt.Errorf("%w: call without a method", ErrWire)
+	}
+	return
+}
+
+// Recorder is a matcher that records every call to the matcher it wraps,
+// with the hits returned, as a trace of Call frames; see Replay.
+//
+// Recording does not affect matching.  Should a write fail, recording stops
+// and the error is returned by Err.  Writes are not buffered, so wrap w in a
+// bufio.Writer as needed, and flush it once done.
+type Recorder struct {
+	m   match.Matcher
+	w   io.Writer
+	err error
+}
+
+func NewRecorder(m match.Matcher, w io.Writer) *Recorder {
+	return &Recorder{m: m, w: w}
+}
+
+func (r *Recorder) Scan(e *match.ScanLine) match.Hits {
+	// Taken before the scan, which may replace the props of the line.  A
+	// borrowed line is encoded before it is reset, so need not be copied.
+	entry := e.LogEntry
+	hits := r.m.Scan(e)
+	r.record(CallT{Kind: CallScan, Entry: entry, Hits: hits})
+	return hits
+}
+
+func (r *Recorder) Eval(clock int64) match.Hits {
+	hits := r.m.Eval(clock)
+	r.record(CallT{Kind: CallEval, Clock: clock, Hits: hits})
+	return hits
+}
+
+func (r *Recorder) GarbageCollect(clock int64) {
+	r.m.GarbageCollect(clock)
+	r.record(CallT{Kind: CallGC, Clock: clock})
+}
+
+// Err returns the first error recording, if any.
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+func (r *Recorder) record(c CallT) {
+	if r.err != nil {
+		return
+	}
+
+	b, err := MarshalCall(c)
+	if err == nil {
+		err = WriteFrame(r.w, b)
+	}
+	r.err = err
+}
+
+// ReplayFuncT receives each replayed call, numbered from zero, with the hits
+// the matcher returned.
+type ReplayFuncT func(idx int, c CallT, hits match.Hits)
+
+// Replay makes the calls of a trace written by Recorder against m, in order,
+// and checks that m returns the hits recorded.  Props compare by their JSON
+// values.  The first mismatch fails
+// with ErrDiverged; fn, if not nil, is called on every call before the check.
+func Replay(r io.Reader, m match.Matcher, fn ReplayFuncT) error {
+	var (
+		rdr  = bufio.NewReader(r)
+		line = match.NewScanLine()
+	)
+
+	for idx := 0; ; idx++ {
+		b, err := ReadFrame(rdr, MaxCallSize)
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return fmt.Errorf("call %d: %w", idx, err)
+		}
+
+		c, err := UnmarshalCall(b)
+		if err != nil {
+			return fmt.Errorf("call %d: %w", idx, err)
+		}
+
+		var hits match.Hits
+		switch c.Kind {
+		case CallScan:
+			hits = m.Scan(line.Reset(c.Entry))
+		case CallEval:
+			hits = m.Eval(c.Clock)
+		case CallGC:
+			m.GarbageCollect(c.Clock)
+		}
+
+		if fn != nil {
+			fn(idx, c, hits)
+		}
+
+		if ok, err := sameHits(c.Hits, hits); err != nil {
+			return fmt.Errorf("call %d: %w", idx, err)
+		} else if !ok {
+			return fmt.Errorf("%w: call %d returned %d hits, recorded %d", ErrDiverged, idx, hits.Cnt, c.Hits.Cnt)
+		}
+	}
+}
+
+func sameHits(a, b match.Hits) (bool, error) {
+	if a.Cnt == 0 || b.Cnt == 0 {
+		return a.Cnt == b.Cnt, nil
+	}
+
+	ea, err := normalHits(a)
+	if err != nil {
+		return false, err
+	}
+	eb, err := normalHits(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ea, eb), nil
+}
+
+// Encode hits with their props decoded to the generic JSON types, so that a
+// struct prop encodes as the map it decodes to.
+func normalHits(h match.Hits) ([]byte, error) {
+	b, err := MarshalHits(h)
+	if err != nil {
+		return nil, err
+	}
+	if h, err = UnmarshalHits(b); err != nil {
+		return nil, err
+	}
+	return MarshalHits(h)
+}
@@ -0,0 +1,169 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire/wirepb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func traceMatcher(t *testing.T, window int64) match.Matcher {
+	t.Helper()
+	m, err := match.NewInverseSeq(window,
+		[]match.TermT{{Value: "start"}, {Value: "fail"}},
+		[]match.ResetT{{Term: match.TermT{Value: "ok"}, Window: 5}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// Drive a session of scans, evals and collections.
+func traceSession(m match.Matcher) (hits int) {
+	line := match.NewScanLine()
+	for i, l := range []string{"start", "fail", "noise", "start", "ok", "fail", "start"} {
+		hits += m.Scan(line.ResetBytes(int64(10*(i+1)), []byte(l))).Cnt
+	}
+	m.GarbageCollect(75)
+	hits += m.Scan(line.ResetLine(80, "fail")).Cnt
+	hits += m.Eval(0).Cnt
+	hits += m.Eval(200).Cnt
+	return
+}
+
+func TestRecordReplay(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		w   = bufio.NewWriter(&buf)
+		rec = NewRecorder(traceMatcher(t, 30), w)
+	)
+
+	want := traceSession(rec)
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want == 0 {
+		t.Fatal("Expected the session to fire")
+	}
+
+	var (
+		calls []CallKindT
+		got   int
+		trace = bytes.Clone(buf.Bytes())
+	)
+
+	err := Replay(bytes.NewReader(trace), traceMatcher(t, 30), func(idx int, c CallT, hits match.Hits) {
+		calls = append(calls, c.Kind)
+		got += hits.Cnt
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if got != want {
+		t.Errorf("Expected %d hits replayed, got %d", want, got)
+	}
+
+	wantCalls := []CallKindT{CallScan, CallScan, CallScan, CallScan, CallScan, CallScan, CallScan, CallGC, CallScan, CallEval, CallEval}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("Expected calls %v, got %v", wantCalls, calls)
+	}
+
+	// A matcher that behaves otherwise diverges.
+	if err := Replay(bytes.NewReader(trace), traceMatcher(t, 5), nil); !errors.Is(err, ErrDiverged) {
+		t.Errorf("Expected %v, got %v", ErrDiverged, err)
+	}
+
+	// As does a truncated trace, though it fails on the frame.
+	if err := Replay(bytes.NewReader(trace[:len(trace)-1]), traceMatcher(t, 30), nil); err == nil {
+		t.Errorf("Expected an error on a truncated trace")
+	}
+}
+
+type failWriter struct{ n int }
+
+func (f *failWriter) Write(b []byte) (int, error) {
+	if f.n == 0 {
+		return 0, errors.New("full")
+	}
+	f.n -= 1
+	return len(b), nil
+}
+
+func TestRecordFail(t *testing.T) {
+	var (
+		plain = traceSession(traceMatcher(t, 30))
+		rec   = NewRecorder(traceMatcher(t, 30), &failWriter{n: 2})
+	)
+
+	if got := traceSession(rec); got != plain {
+		t.Errorf("Expected recording errors not to affect matching; %d hits, got %d", plain, got)
+	}
+	if rec.Err() == nil {
+		t.Errorf("Expected the write error")
+	}
+}
+
+func TestCallRoundTrip(t *testing.T) {
+	calls := []CallT{
+		{Kind: CallScan, Entry: LogEntry{Line: "alpha", Timestamp: 7}},
+		{Kind: CallScan, Entry: LogEntry{Line: "beta", Timestamp: 8}, Hits: match.Hits{Cnt: 1, Logs: []LogEntry{{Line: "beta", Timestamp: 8}}, FireStamp: 8}},
+		{Kind: CallEval},
+		{Kind: CallEval, Clock: 9},
+		{Kind: CallGC, Clock: 10},
+	}
+
+	for _, c := range calls {
+		b, err := MarshalCall(c)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		got, err := UnmarshalCall(b)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("Expected %+v, got %+v", c, got)
+		}
+	}
+
+	if _, err := MarshalCall(CallT{Kind: 7}); !errors.Is(err, ErrWire) {
+		t.Errorf("Expected %v, got %v", ErrWire, err)
+	}
+	if _, err := UnmarshalCall(nil); !errors.Is(err, ErrWire) {
+		t.Errorf("Expected %v on a call without a method, got %v", ErrWire, err)
+	}
+}
+
+// A zero clock must survive the protobuf runtime, as the oneof has presence.
+func TestCallSchema(t *testing.T) {
+	b, err := MarshalCall(CallT{Kind: CallGC})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	var msg wirepb.Call
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if _, ok := msg.Call.(*wirepb.Call_Gc); !ok {
+		t.Errorf("Expected gc set, got %T", msg.Call)
+	}
+
+	out, err := proto.MarshalOptions{Deterministic: true}.Marshal(&msg)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if c, err := UnmarshalCall(out); err != nil || c.Kind != CallGC {
+		t.Errorf("Expected a GC call, got %+v, %v", c, err)
+	}
+}
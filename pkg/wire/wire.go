@@ -0,0 +1,603 @@
+// Package wire encodes matcher inputs and results in the protobuf format
+// defined by wire.proto, so that match workers can be distributed across
+// processes and their results shipped over gRPC or any other transport.
+// Recorder and Replay use the same encoding to capture a matcher session as
+// a trace, and to reproduce it against another matcher.
+//
+// Props, which hold arbitrary values, are carried as JSON; on decode they are
+// the generic JSON types rather than the types originally set.
+//
+// The messages are encoded straight from the match types, without the copy
+// into, or the UTF-8 checks of, the generated types in wirepb.  The field
+// numbers below are checked against the generated descriptors by the tests.
+package wire
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//go:generate protoc --go_out=wirepb --go_opt=paths=source_relative --go-grpc_out=wirepb --go-grpc_opt=paths=source_relative wire.proto
+
+var ErrWire = errors.New("malformed wire message")
+
+type LogEntry = match.LogEntry
+
+// Field numbers, per wire.proto.
+const (
+	spanOffsets = 1
+
+	entryLine       = 1
+	entryStream     = 2
+	entryTimestamp  = 3
+	entryMatches    = 4
+	entryIngestTime = 5
+	entryProps      = 6
+	entryOffset     = 7
+	entryLineNo     = 8
+	entryLabels     = 9
+	entrySeverity   = 10
+
+	mapKey   = 1
+	mapValue = 2
+
+	termType        = 1
+	termValue       = 2
+	termDistance    = 3
+	termOptions     = 4
+	termCount       = 5
+	termMinSeverity = 6
+
+	resetTerm      = 1
+	resetWindow    = 2
+	resetSlide     = 3
+	resetAnchor    = 4
+	resetAbsolute  = 5
+	resetAnchorEnd = 6
+	resetCorrelate = 7
+
+	propIdx   = 1
+	propKey   = 2
+	propValue = 3
+
+	hitsCnt       = 1
+	hitsLogs      = 2
+	hitsProps     = 3
+	hitsFireStamp = 4
+	hitsGroups    = 5
+
+	requestRules = 1
+	requestEntry = 2
+	requestEval  = 3
+
+	responseRule  = 1
+	responseHits  = 2
+	responseError = 3
+
+	callScan = 1
+	callEval = 2
+	callGC   = 3
+	callHits = 4
+)
+
+// RequestT is a Request message.  Exactly one of Rules or Entry is set;
+// otherwise the request is an Eval, which may be zero.
+type RequestT struct {
+	Rules []byte
+	Entry *LogEntry
+	Eval  int64
+}
+
+// ResponseT is a Response message.
+type ResponseT struct {
+	Rule  string
+	Hits  match.Hits
+	Error string
+}
+
+// MarshalLogEntry encodes a LogEntry message.
+func MarshalLogEntry(e LogEntry) ([]byte, error) {
+	return appendLogEntry(nil, e)
+}
+
+// UnmarshalLogEntry decodes a LogEntry message.
+func UnmarshalLogEntry(b []byte) (e LogEntry, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		switch num {
+		case entryLine:
+			e.Line, err = v.str(typ)
+		case entryStream:
+			e.Stream, err = v.str(typ)
+		case entryTimestamp:
+			e.Timestamp, err = v.int(typ)
+		case entryMatches:
+			var span []int64
+			if span, err = unmarshalSpan(v.raw); err == nil {
+				e.Matches = append(e.Matches, intsOf(span))
+			}
+		case entryIngestTime:
+			e.IngestTime, err = v.int(typ)
+		case entryProps:
+			if typ == protowire.BytesType {
+				err = json.Unmarshal(v.raw, &e.Props)
+			}
+		case entryOffset:
+			e.Offset, err = v.int(typ)
+		case entryLineNo:
+			e.LineNo, err = v.int(typ)
+		case entryLabels:
+			var k, val string
+			if k, val, err = unmarshalMapEntry(v.raw); err == nil {
+				if e.Labels == nil {
+					e.Labels = make(map[string]string)
+				}
+				e.Labels[k] = val
+			}
+		case entrySeverity:
+			var n int64
+			n, err = v.int(typ)
+			e.Severity = entry.SeverityT(n)
+		}
+		return err
+	})
+	return
+}
+
+// MarshalTerm encodes a Term message.
+func MarshalTerm(t match.TermT) []byte {
+	return appendTerm(nil, t)
+}
+
+// UnmarshalTerm decodes a Term message.
+func UnmarshalTerm(b []byte) (t match.TermT, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		var n int64
+		switch num {
+		case termType:
+			n, err = v.int(typ)
+			t.Type = match.TermTypeT(n)
+		case termValue:
+			t.Value, err = v.str(typ)
+		case termDistance:
+			n, err = v.int(typ)
+			t.Distance = int(n)
+		case termOptions:
+			n, err = v.int(typ)
+			t.Options = match.TermOptT(n)
+		case termCount:
+			n, err = v.int(typ)
+			t.Count = int(n)
+		case termMinSeverity:
+			n, err = v.int(typ)
+			t.MinSeverity = entry.SeverityT(n)
+		}
+		return err
+	})
+	return
+}
+
+// MarshalReset encodes a Reset message.  A CorrelateKey function cannot be
+// encoded and is dropped.
+func MarshalReset(r match.ResetT) []byte {
+	return appendReset(nil, r)
+}
+
+// UnmarshalReset decodes a Reset message.
+func UnmarshalReset(b []byte) (r match.ResetT, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		var n int64
+		switch num {
+		case resetTerm:
+			r.Term, err = UnmarshalTerm(v.raw)
+		case resetWindow:
+			r.Window, err = v.int(typ)
+		case resetSlide:
+			r.Slide, err = v.int(typ)
+		case resetAnchor:
+			n, err = v.int(typ)
+			r.Anchor = int(n)
+		case resetAbsolute:
+			n, err = v.int(typ)
+			r.Absolute = n != 0
+		case resetAnchorEnd:
+			n, err = v.int(typ)
+			r.AnchorEnd = int(n)
+		case resetCorrelate:
+			r.Correlate, err = v.str(typ)
+		}
+		return err
+	})
+	return
+}
+
+// MarshalHits encodes a Hits message.  Props are ordered by hit, then key.
+func MarshalHits(h match.Hits) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+
+	b = appendInt(b, hitsCnt, int64(h.Cnt))
+
+	for _, e := range h.Logs {
+		var sub []byte
+		if sub, err = appendLogEntry(nil, e); err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, hitsLogs, sub)
+	}
+
+	keys := slices.SortedFunc(maps.Keys(h.Props), func(a, b match.PropKey) int {
+		return cmp.Or(cmp.Compare(a.Idx, b.Idx), cmp.Compare(a.Key, b.Key))
+	})
+	for _, k := range keys {
+		data, err := json.Marshal(h.Props[k])
+		if err != nil {
+			return nil, fmt.Errorf("%w: prop %q: %w", ErrWire, k.Key, err)
+		}
+		var sub []byte
+		sub = appendInt(sub, propIdx, int64(k.Idx))
+		sub = appendString(sub, propKey, k.Key)
+		sub = appendBytes(sub, propValue, data)
+		b = appendMessage(b, hitsProps, sub)
+	}
+
+	b = appendInt(b, hitsFireStamp, h.FireStamp)
+	b = appendPacked(b, hitsGroups, int64sOf(h.Groups))
+	return b, nil
+}
+
+// UnmarshalHits decodes a Hits message.
+func UnmarshalHits(b []byte) (h match.Hits, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		var n int64
+		switch num {
+		case hitsCnt:
+			n, err = v.int(typ)
+			h.Cnt = int(n)
+		case hitsLogs:
+			var e LogEntry
+			if e, err = UnmarshalLogEntry(v.raw); err == nil {
+				h.Logs = append(h.Logs, e)
+			}
+		case hitsProps:
+			var (
+				k   match.PropKey
+				val any
+			)
+			if k, val, err = unmarshalProp(v.raw); err == nil {
+				if h.Props == nil {
+					h.Props = make(map[match.PropKey]any)
+				}
+				h.Props[k] = val
+			}
+		case hitsFireStamp:
+			h.FireStamp, err = v.int(typ)
+		case hitsGroups:
+			var groups []int64
+			if groups, err = v.ints(typ); err == nil {
+				h.Groups = append(h.Groups, intsOf(groups)...)
+			}
+		}
+		return err
+	})
+	return
+}
+
+// MarshalRequest encodes a Request message.
+func MarshalRequest(r RequestT) ([]byte, error) {
+	switch {
+	case r.Rules != nil:
+		b := protowire.AppendTag(nil, requestRules, protowire.BytesType)
+		return protowire.AppendBytes(b, r.Rules), nil
+	case r.Entry != nil:
+		sub, err := appendLogEntry(nil, *r.Entry)
+		if err != nil {
+			return nil, err
+		}
+		return appendMessage(nil, requestEntry, sub), nil
+	}
+
+	// The oneof has presence; a zero clock is still written.
+	b := protowire.AppendTag(nil, requestEval, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(r.Eval)), nil
+}
+
+// UnmarshalRequest decodes a Request message.  Should more than one field of
+// the oneof be present, the last wins.
+func UnmarshalRequest(b []byte) (r RequestT, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		switch num {
+		case requestRules:
+			if typ != protowire.BytesType {
+				return ErrWire
+			}
+			r = RequestT{Rules: append([]byte{}, v.raw...)}
+		case requestEntry:
+			var e LogEntry
+			if e, err = UnmarshalLogEntry(v.raw); err == nil {
+				r = RequestT{Entry: &e}
+			}
+		case requestEval:
+			var n int64
+			if n, err = v.int(typ); err == nil {
+				r = RequestT{Eval: n}
+			}
+		}
+		return err
+	})
+	return
+}
+
+// MarshalResponse encodes a Response message.
+func MarshalResponse(r ResponseT) ([]byte, error) {
+	b := appendString(nil, responseRule, r.Rule)
+
+	if r.Hits.Cnt > 0 {
+		sub, err := MarshalHits(r.Hits)
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, responseHits, sub)
+	}
+
+	return appendString(b, responseError, r.Error), nil
+}
+
+// UnmarshalResponse decodes a Response message.
+func UnmarshalResponse(b []byte) (r ResponseT, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		switch num {
+		case responseRule:
+			r.Rule, err = v.str(typ)
+		case responseHits:
+			r.Hits, err = UnmarshalHits(v.raw)
+		case responseError:
+			r.Error, err = v.str(typ)
+		}
+		return err
+	})
+	return
+}
+
+func appendLogEntry(b []byte, e LogEntry) ([]byte, error) {
+	b = appendString(b, entryLine, e.Line)
+	b = appendString(b, entryStream, e.Stream)
+	b = appendInt(b, entryTimestamp, e.Timestamp)
+
+	for _, m := range e.Matches {
+		b = appendMessage(b, entryMatches, appendPacked(nil, spanOffsets, int64sOf(m)))
+	}
+
+	b = appendInt(b, entryIngestTime, e.IngestTime)
+
+	if len(e.Props) > 0 {
+		data, err := json.Marshal(e.Props)
+		if err != nil {
+			return nil, fmt.Errorf("%w: props: %w", ErrWire, err)
+		}
+		b = appendBytes(b, entryProps, data)
+	}
+
+	b = appendInt(b, entryOffset, e.Offset)
+	b = appendInt(b, entryLineNo, e.LineNo)
+
+	for _, k := range slices.Sorted(maps.Keys(e.Labels)) {
+		var sub []byte
+		sub = appendString(sub, mapKey, k)
+		sub = appendString(sub, mapValue, e.Labels[k])
+		b = appendMessage(b, entryLabels, sub)
+	}
+
+	b = appendInt(b, entrySeverity, int64(e.Severity))
+	return b, nil
+}
+
+func appendTerm(b []byte, t match.TermT) []byte {
+	b = appendInt(b, termType, int64(t.Type))
+	b = appendString(b, termValue, t.Value)
+	b = appendInt(b, termDistance, int64(t.Distance))
+	b = appendInt(b, termOptions, int64(t.Options))
+	b = appendInt(b, termCount, int64(t.Count))
+	b = appendInt(b, termMinSeverity, int64(t.MinSeverity))
+	return b
+}
+
+func appendReset(b []byte, r match.ResetT) []byte {
+	b = appendMessage(b, resetTerm, appendTerm(nil, r.Term))
+	b = appendInt(b, resetWindow, r.Window)
+	b = appendInt(b, resetSlide, r.Slide)
+	b = appendInt(b, resetAnchor, int64(r.Anchor))
+	if r.Absolute {
+		b = appendInt(b, resetAbsolute, 1)
+	}
+	b = appendInt(b, resetAnchorEnd, int64(r.AnchorEnd))
+	b = appendString(b, resetCorrelate, r.Correlate)
+	return b
+}
+
+func unmarshalSpan(b []byte) (span []int64, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		if num == spanOffsets {
+			var vals []int64
+			if vals, err = v.ints(typ); err == nil {
+				span = append(span, vals...)
+			}
+		}
+		return err
+	})
+	return
+}
+
+func unmarshalMapEntry(b []byte) (k, val string, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		switch num {
+		case mapKey:
+			k, err = v.str(typ)
+		case mapValue:
+			val, err = v.str(typ)
+		}
+		return err
+	})
+	return
+}
+
+func unmarshalProp(b []byte) (k match.PropKey, val any, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, v field) error {
+		var n int64
+		switch num {
+		case propIdx:
+			n, err = v.int(typ)
+			k.Idx = int(n)
+		case propKey:
+			k.Key, err = v.str(typ)
+		case propValue:
+			if typ == protowire.BytesType {
+				err = json.Unmarshal(v.raw, &val)
+			}
+		}
+		return err
+	})
+	return
+}
+
+// Scalars are omitted at their zero value, as in proto3.
+
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendMessage(b []byte, num protowire.Number, sub []byte) []byte {
+	return appendBytes(b, num, sub)
+}
+
+func appendPacked(b []byte, num protowire.Number, vals []int64) []byte {
+	if len(vals) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range vals {
+		packed = protowire.AppendVarint(packed, uint64(v))
+	}
+	return appendBytes(b, num, packed)
+}
+
+// field is a decoded field value; raw holds the bytes of a length delimited
+// field, and n the value of a varint.
+type field struct {
+	raw []byte
+	n   uint64
+}
+
+func (f field) int(typ protowire.Type) (int64, error) {
+	if typ != protowire.VarintType {
+		return 0, ErrWire
+	}
+	return int64(f.n), nil
+}
+
+func (f field) str(typ protowire.Type) (string, error) {
+	if typ != protowire.BytesType {
+		return "", ErrWire
+	}
+	return string(f.raw), nil
+}
+
+// Repeated varints, packed or not.
+func (f field) ints(typ protowire.Type) ([]int64, error) {
+	switch typ {
+	case protowire.VarintType:
+		return []int64{int64(f.n)}, nil
+	case protowire.BytesType:
+		var (
+			out []int64
+			b   = f.raw
+		)
+		for len(b) > 0 {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, ErrWire
+			}
+			out = append(out, int64(v))
+			b = b[n:]
+		}
+		return out, nil
+	}
+	return nil, ErrWire
+}
+
+// Call fn on each field of the message in b; unknown fields are passed too,
+// for fn to ignore.
+func walk(b []byte, fn func(protowire.Number, protowire.Type, field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %w", ErrWire, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var f field
+		switch typ {
+		case protowire.VarintType:
+			f.n, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.raw, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %w", ErrWire, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, f); err != nil {
+			if errors.Is(err, ErrWire) {
+				return err
+			}
+			return fmt.Errorf("%w: field %d: %w", ErrWire, num, err)
+		}
+	}
+	return nil
+}
+
+func int64sOf(v []int) []int64 {
+	out := make([]int64, len(v))
+	for i, n := range v {
+		out[i] = int64(n)
+	}
+	return out
+}
+
+func intsOf(v []int64) []int {
+	out := make([]int, len(v))
+	for i, n := range v {
+		out[i] = int(n)
+	}
+	return out
+}
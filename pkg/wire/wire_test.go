@@ -0,0 +1,348 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prequel-dev/prequel-logmatch/pkg/entry"
+	"github.com/prequel-dev/prequel-logmatch/pkg/match"
+	"github.com/prequel-dev/prequel-logmatch/pkg/wire/wirepb"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestLogEntryRoundTrip(t *testing.T) {
+	e := LogEntry{
+		Line:       "pod restarted",
+		Stream:     "stderr",
+		Timestamp:  1700000000000000000,
+		Matches:    [][]int{{0, 3}, {4, 13}},
+		IngestTime: 1700000000000000001,
+		Props:      map[string]any{"code": float64(137), "pod": "api-0"},
+		Offset:     4096,
+		LineNo:     42,
+		Labels:     map[string]string{"host": "node-1", "source": "app.log"},
+	}
+
+	data, err := MarshalLogEntry(e)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	got, err := UnmarshalLogEntry(data)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, e) {
+		t.Errorf("Expected %+v, got %+v", e, got)
+	}
+
+	// Zero value round trips to zero.
+	data, _ = MarshalLogEntry(LogEntry{})
+	if got, err := UnmarshalLogEntry(data); err != nil || !reflect.DeepEqual(got, LogEntry{}) {
+		t.Errorf("Expected zero entry, got %+v %v", got, err)
+	}
+}
+
+func TestTermResetRoundTrip(t *testing.T) {
+	term := match.TermT{Type: match.TermFuzzy, Value: "conection refused", Distance: 2, Count: 3, MinSeverity: entry.SevError}
+	if got, err := UnmarshalTerm(MarshalTerm(term)); err != nil || got != term {
+		t.Errorf("Expected %+v, got %+v %v", term, got, err)
+	}
+
+	reset := match.ResetT{
+		Term:      match.TermT{Type: match.TermRaw, Value: "ok", Options: match.TermOptNoCase},
+		Window:    -10,
+		Slide:     -5,
+		Anchor:    1,
+		Absolute:  true,
+		AnchorEnd: 2,
+		Correlate: "req.id",
+	}
+	if got, err := UnmarshalReset(MarshalReset(reset)); err != nil || !reflect.DeepEqual(got, reset) {
+		t.Errorf("Expected %+v, got %+v %v", reset, got, err)
+	}
+}
+
+func TestHitsRoundTrip(t *testing.T) {
+	h := match.Hits{
+		Cnt: 2,
+		Logs: []LogEntry{
+			{Line: "alpha", Timestamp: 1},
+			{Line: "beta", Timestamp: 2},
+			{Line: "gamma", Timestamp: 3},
+		},
+		Props: map[match.PropKey]any{
+			{Idx: 0, Key: match.PropID}:   "rule-1",
+			{Idx: 1, Key: match.PropRate}: 2.5,
+		},
+		FireStamp: 3,
+		Groups:    []int{2, 1},
+	}
+
+	data, err := MarshalHits(h)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	got, err := UnmarshalHits(data)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Errorf("Expected %+v, got %+v", h, got)
+	}
+
+	// Encoding is deterministic.
+	again, _ := MarshalHits(got)
+	if string(again) != string(data) {
+		t.Errorf("Expected a stable encoding")
+	}
+}
+
+func TestUnmarshalFail(t *testing.T) {
+	bad := map[string][]byte{
+		"Truncated":  {0x0a, 0x05, 'a'},
+		"BadTag":     {0x00},
+		"WrongType":  {0x18, 0x01, 0x0a, 0x01, 'x', 0x1a, 0x00},
+		"BadProps":   {0x32, 0x01, '{'},
+		"LineAsVInt": {0x08, 0x01},
+	}
+
+	for name, data := range bad {
+		t.Run(name, func(t *testing.T) {
+			if _, err := UnmarshalLogEntry(data); !errors.Is(err, ErrWire) {
+				t.Errorf("Expected %v, got %v", ErrWire, err)
+			}
+		})
+	}
+}
+
+// The encoding must agree with wire.proto as read by the protobuf runtime.
+func TestSchema(t *testing.T) {
+	e := LogEntry{
+		Line:      "alpha",
+		Timestamp: 7,
+		Matches:   [][]int{{1, 2}},
+		Labels:    map[string]string{"host": "a"},
+		Severity:  entry.SevWarn,
+	}
+	h := match.Hits{Cnt: 1, Logs: []LogEntry{e}, FireStamp: 7, Groups: []int{1}}
+
+	data, err := MarshalHits(h)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	var msg wirepb.Hits
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	if msg.Cnt != 1 {
+		t.Errorf("Expected cnt 1, got %d", msg.Cnt)
+	}
+	if len(msg.Logs) != 1 {
+		t.Fatalf("Expected 1 log, got %d", len(msg.Logs))
+	}
+	log := msg.Logs[0]
+	if log.Line != "alpha" {
+		t.Errorf("Expected line alpha, got %q", log.Line)
+	}
+	if v := log.Labels["host"]; v != "a" {
+		t.Errorf("Expected label a, got %q", v)
+	}
+	if log.Severity != int32(entry.SevWarn) {
+		t.Errorf("Expected severity %d, got %d", entry.SevWarn, log.Severity)
+	}
+	if len(log.Matches) != 1 || !slices.Equal(log.Matches[0].Offsets, []int64{1, 2}) {
+		t.Errorf("Expected span 1, 2, got %v", log.Matches)
+	}
+
+	// And back, from the runtime's encoding.
+	out, err := proto.MarshalOptions{Deterministic: true}.Marshal(&msg)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	got, err := UnmarshalHits(out)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Errorf("Expected %+v, got %+v", h, got)
+	}
+}
+
+// The field numbers and wire types of the codec must agree with wire.proto,
+// and cover every field.
+func TestFieldNumbers(t *testing.T) {
+	var (
+		vint = protowire.VarintType
+		lend = protowire.BytesType
+	)
+
+	fields := map[string][]struct {
+		name string
+		num  protowire.Number
+		typ  protowire.Type
+	}{
+		"Span": {{"offsets", spanOffsets, lend}},
+		"LogEntry": {
+			{"line", entryLine, lend},
+			{"stream", entryStream, lend},
+			{"timestamp", entryTimestamp, vint},
+			{"matches", entryMatches, lend},
+			{"ingest_time", entryIngestTime, vint},
+			{"props", entryProps, lend},
+			{"offset", entryOffset, vint},
+			{"line_no", entryLineNo, vint},
+			{"labels", entryLabels, lend},
+			{"severity", entrySeverity, vint},
+		},
+		"LogEntry.LabelsEntry": {{"key", mapKey, lend}, {"value", mapValue, lend}},
+		"Term": {
+			{"type", termType, vint},
+			{"value", termValue, lend},
+			{"distance", termDistance, vint},
+			{"options", termOptions, vint},
+			{"count", termCount, vint},
+			{"min_severity", termMinSeverity, vint},
+		},
+		"Reset": {
+			{"term", resetTerm, lend},
+			{"window", resetWindow, vint},
+			{"slide", resetSlide, vint},
+			{"anchor", resetAnchor, vint},
+			{"absolute", resetAbsolute, vint},
+			{"anchor_end", resetAnchorEnd, vint},
+			{"correlate", resetCorrelate, lend},
+		},
+		"Prop": {{"idx", propIdx, vint}, {"key", propKey, lend}, {"value", propValue, lend}},
+		"Hits": {
+			{"cnt", hitsCnt, vint},
+			{"logs", hitsLogs, lend},
+			{"props", hitsProps, lend},
+			{"fire_stamp", hitsFireStamp, vint},
+			{"groups", hitsGroups, lend},
+		},
+		"Request":  {{"rules", requestRules, lend}, {"entry", requestEntry, lend}, {"eval", requestEval, vint}},
+		"Response": {{"rule", responseRule, lend}, {"hits", responseHits, lend}, {"error", responseError, lend}},
+		"Call": {
+			{"scan", callScan, lend},
+			{"eval", callEval, vint},
+			{"gc", callGC, vint},
+			{"hits", callHits, lend},
+		},
+	}
+
+	mds := wirepb.File_wire_proto.Messages()
+	if n := mds.Len() + 1; n != len(fields) {
+		t.Errorf("Expected %d messages, got %d", len(fields), n)
+	}
+
+	for name, want := range fields {
+		md := mds.ByName(protoreflect.Name(name))
+		if parent, nested, ok := strings.Cut(name, "."); ok {
+			md = mds.ByName(protoreflect.Name(parent)).Messages().ByName(protoreflect.Name(nested))
+		}
+		if md == nil {
+			t.Errorf("%s: Expected a message", name)
+			continue
+		}
+
+		if n := md.Fields().Len(); n != len(want) {
+			t.Errorf("%s: Expected %d fields, got %d", name, len(want), n)
+		}
+		for _, w := range want {
+			fd := md.Fields().ByName(protoreflect.Name(w.name))
+			switch {
+			case fd == nil:
+				t.Errorf("%s.%s: Expected a field", name, w.name)
+			case fd.Number() != w.num:
+				t.Errorf("%s.%s: Expected number %d, got %d", name, w.name, w.num, fd.Number())
+			case wireType(fd) != w.typ:
+				t.Errorf("%s.%s: Expected wire type %d, got %v", name, w.name, w.typ, fd.Kind())
+			}
+		}
+	}
+}
+
+// The wire type of a field as the codec writes it; repeated scalars packed.
+func wireType(fd protoreflect.FieldDescriptor) protowire.Type {
+	if fd.IsList() && fd.Kind() != protoreflect.MessageKind {
+		return protowire.BytesType
+	}
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.BoolKind, protoreflect.EnumKind:
+		return protowire.VarintType
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind:
+		return protowire.BytesType
+	}
+	return -1
+}
+
+func TestRequestResponseRoundTrip(t *testing.T) {
+	reqs := []RequestT{
+		{Rules: []byte("rules: []")},
+		{Entry: &LogEntry{Line: "alpha", Timestamp: 1}},
+		{Eval: 42},
+		{}, // Eval at zero.
+	}
+	for i, req := range reqs {
+		data, err := MarshalRequest(req)
+		if err != nil {
+			t.Fatalf("%d: Expected nil error, got %v", i, err)
+		}
+		got, err := UnmarshalRequest(data)
+		if err != nil || !reflect.DeepEqual(got, req) {
+			t.Errorf("%d: Expected %+v, got %+v %v", i, req, got, err)
+		}
+	}
+
+	resps := []ResponseT{
+		{Rule: "r1", Hits: match.Hits{Cnt: 1, Logs: []LogEntry{{Line: "alpha"}}, FireStamp: 1}},
+		{Error: "no rules loaded"},
+	}
+	for i, resp := range resps {
+		data, err := MarshalResponse(resp)
+		if err != nil {
+			t.Fatalf("%d: Expected nil error, got %v", i, err)
+		}
+		got, err := UnmarshalResponse(data)
+		if err != nil || !reflect.DeepEqual(got, resp) {
+			t.Errorf("%d: Expected %+v, got %+v %v", i, resp, got, err)
+		}
+	}
+}
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+	for _, msg := range []string{"", "alpha", strings.Repeat("x", 300)} {
+		if err := WriteFrame(&buf, []byte(msg)); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+
+	rdr := bufio.NewReader(bytes.NewReader(buf.Bytes()))
+	for _, exp := range []string{"", "alpha"} {
+		if b, err := ReadFrame(rdr, 100); err != nil || string(b) != exp {
+			t.Errorf("Expected %q, got %q %v", exp, b, err)
+		}
+	}
+	if _, err := ReadFrame(rdr, 100); !errors.Is(err, ErrFrameSize) {
+		t.Errorf("Expected %v, got %v", ErrFrameSize, err)
+	}
+
+	if _, err := ReadFrame(bufio.NewReader(bytes.NewReader(nil)), 100); err != io.EOF {
+		t.Errorf("Expected %v, got %v", io.EOF, err)
+	}
+	if _, err := ReadFrame(bufio.NewReader(bytes.NewReader([]byte{5, 'a'})), 100); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
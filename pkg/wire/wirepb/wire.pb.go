@@ -0,0 +1,996 @@
+// Canonical wire format for shipping matcher inputs and results between
+// processes.  Encoded and decoded by the wire package; see wire.go.  The
+// generated types, in wirepb, serve gRPC clients and servers.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: wire.proto
+
+package wirepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TermType int32
+
+const (
+	TermType_TERM_RAW          TermType = 0
+	TermType_TERM_REGEX        TermType = 1
+	TermType_TERM_JQ_JSON      TermType = 2
+	TermType_TERM_JQ_YAML      TermType = 3
+	TermType_TERM_JQ_JSON_DIFF TermType = 4
+	TermType_TERM_FUZZY        TermType = 5
+	TermType_TERM_LOGFMT       TermType = 6
+	TermType_TERM_NUMERIC      TermType = 7
+	TermType_TERM_CIDR         TermType = 8
+)
+
+// Enum value maps for TermType.
+var (
+	TermType_name = map[int32]string{
+		0: "TERM_RAW",
+		1: "TERM_REGEX",
+		2: "TERM_JQ_JSON",
+		3: "TERM_JQ_YAML",
+		4: "TERM_JQ_JSON_DIFF",
+		5: "TERM_FUZZY",
+		6: "TERM_LOGFMT",
+		7: "TERM_NUMERIC",
+		8: "TERM_CIDR",
+	}
+	TermType_value = map[string]int32{
+		"TERM_RAW":          0,
+		"TERM_REGEX":        1,
+		"TERM_JQ_JSON":      2,
+		"TERM_JQ_YAML":      3,
+		"TERM_JQ_JSON_DIFF": 4,
+		"TERM_FUZZY":        5,
+		"TERM_LOGFMT":       6,
+		"TERM_NUMERIC":      7,
+		"TERM_CIDR":         8,
+	}
+)
+
+func (x TermType) Enum() *TermType {
+	p := new(TermType)
+	*p = x
+	return p
+}
+
+func (x TermType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TermType) Descriptor() protoreflect.EnumDescriptor {
+	return file_wire_proto_enumTypes[0].Descriptor()
+}
+
+func (TermType) Type() protoreflect.EnumType {
+	return &file_wire_proto_enumTypes[0]
+}
+
+func (x TermType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TermType.Descriptor instead.
+func (TermType) EnumDescriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{0}
+}
+
+type Span struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offsets       []int64                `protobuf:"varint,1,rep,packed,name=offsets,proto3" json:"offsets,omitempty"` // Start and end byte offsets.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Span) Reset() {
+	*x = Span{}
+	mi := &file_wire_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Span) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Span) ProtoMessage() {}
+
+func (x *Span) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Span.ProtoReflect.Descriptor instead.
+func (*Span) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Span) GetOffsets() []int64 {
+	if x != nil {
+		return x.Offsets
+	}
+	return nil
+}
+
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          string                 `protobuf:"bytes,1,opt,name=line,proto3" json:"line,omitempty"`
+	Stream        string                 `protobuf:"bytes,2,opt,name=stream,proto3" json:"stream,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Matches       []*Span                `protobuf:"bytes,4,rep,name=matches,proto3" json:"matches,omitempty"`
+	IngestTime    int64                  `protobuf:"varint,5,opt,name=ingest_time,json=ingestTime,proto3" json:"ingest_time,omitempty"`
+	Props         []byte                 `protobuf:"bytes,6,opt,name=props,proto3" json:"props,omitempty"` // JSON object.
+	Offset        int64                  `protobuf:"varint,7,opt,name=offset,proto3" json:"offset,omitempty"`
+	LineNo        int64                  `protobuf:"varint,8,opt,name=line_no,json=lineNo,proto3" json:"line_no,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,9,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Severity      int32                  `protobuf:"varint,10,opt,name=severity,proto3" json:"severity,omitempty"` // OpenTelemetry severity number; zero if unknown.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_wire_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LogEntry) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+func (x *LogEntry) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *LogEntry) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LogEntry) GetMatches() []*Span {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *LogEntry) GetIngestTime() int64 {
+	if x != nil {
+		return x.IngestTime
+	}
+	return 0
+}
+
+func (x *LogEntry) GetProps() []byte {
+	if x != nil {
+		return x.Props
+	}
+	return nil
+}
+
+func (x *LogEntry) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *LogEntry) GetLineNo() int64 {
+	if x != nil {
+		return x.LineNo
+	}
+	return 0
+}
+
+func (x *LogEntry) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *LogEntry) GetSeverity() int32 {
+	if x != nil {
+		return x.Severity
+	}
+	return 0
+}
+
+type Term struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          TermType               `protobuf:"varint,1,opt,name=type,proto3,enum=logmatch.wire.v1.TermType" json:"type,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Distance      int64                  `protobuf:"varint,3,opt,name=distance,proto3" json:"distance,omitempty"`
+	Options       uint32                 `protobuf:"varint,4,opt,name=options,proto3" json:"options,omitempty"`
+	Count         int64                  `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	MinSeverity   int32                  `protobuf:"varint,6,opt,name=min_severity,json=minSeverity,proto3" json:"min_severity,omitempty"` // Least severity of a line; zero for any.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Term) Reset() {
+	*x = Term{}
+	mi := &file_wire_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Term) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Term) ProtoMessage() {}
+
+func (x *Term) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Term.ProtoReflect.Descriptor instead.
+func (*Term) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Term) GetType() TermType {
+	if x != nil {
+		return x.Type
+	}
+	return TermType_TERM_RAW
+}
+
+func (x *Term) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Term) GetDistance() int64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *Term) GetOptions() uint32 {
+	if x != nil {
+		return x.Options
+	}
+	return 0
+}
+
+func (x *Term) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Term) GetMinSeverity() int32 {
+	if x != nil {
+		return x.MinSeverity
+	}
+	return 0
+}
+
+type Reset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          *Term                  `protobuf:"bytes,1,opt,name=term,proto3" json:"term,omitempty"`
+	Window        int64                  `protobuf:"varint,2,opt,name=window,proto3" json:"window,omitempty"`
+	Slide         int64                  `protobuf:"varint,3,opt,name=slide,proto3" json:"slide,omitempty"`
+	Anchor        uint32                 `protobuf:"varint,4,opt,name=anchor,proto3" json:"anchor,omitempty"`
+	Absolute      bool                   `protobuf:"varint,5,opt,name=absolute,proto3" json:"absolute,omitempty"`
+	AnchorEnd     uint32                 `protobuf:"varint,6,opt,name=anchor_end,json=anchorEnd,proto3" json:"anchor_end,omitempty"`
+	Correlate     string                 `protobuf:"bytes,7,opt,name=correlate,proto3" json:"correlate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reset) Reset() {
+	*x = Reset{}
+	mi := &file_wire_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reset) ProtoMessage() {}
+
+func (x *Reset) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reset.ProtoReflect.Descriptor instead.
+func (*Reset) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Reset) GetTerm() *Term {
+	if x != nil {
+		return x.Term
+	}
+	return nil
+}
+
+func (x *Reset) GetWindow() int64 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+func (x *Reset) GetSlide() int64 {
+	if x != nil {
+		return x.Slide
+	}
+	return 0
+}
+
+func (x *Reset) GetAnchor() uint32 {
+	if x != nil {
+		return x.Anchor
+	}
+	return 0
+}
+
+func (x *Reset) GetAbsolute() bool {
+	if x != nil {
+		return x.Absolute
+	}
+	return false
+}
+
+func (x *Reset) GetAnchorEnd() uint32 {
+	if x != nil {
+		return x.AnchorEnd
+	}
+	return 0
+}
+
+func (x *Reset) GetCorrelate() string {
+	if x != nil {
+		return x.Correlate
+	}
+	return ""
+}
+
+type Prop struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Idx           int64                  `protobuf:"varint,1,opt,name=idx,proto3" json:"idx,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"` // JSON value.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Prop) Reset() {
+	*x = Prop{}
+	mi := &file_wire_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prop) ProtoMessage() {}
+
+func (x *Prop) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prop.ProtoReflect.Descriptor instead.
+func (*Prop) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Prop) GetIdx() int64 {
+	if x != nil {
+		return x.Idx
+	}
+	return 0
+}
+
+func (x *Prop) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Prop) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type Hits struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cnt           int64                  `protobuf:"varint,1,opt,name=cnt,proto3" json:"cnt,omitempty"`
+	Logs          []*LogEntry            `protobuf:"bytes,2,rep,name=logs,proto3" json:"logs,omitempty"`
+	Props         []*Prop                `protobuf:"bytes,3,rep,name=props,proto3" json:"props,omitempty"`
+	FireStamp     int64                  `protobuf:"varint,4,opt,name=fire_stamp,json=fireStamp,proto3" json:"fire_stamp,omitempty"`
+	Groups        []int64                `protobuf:"varint,5,rep,packed,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hits) Reset() {
+	*x = Hits{}
+	mi := &file_wire_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hits) ProtoMessage() {}
+
+func (x *Hits) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hits.ProtoReflect.Descriptor instead.
+func (*Hits) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Hits) GetCnt() int64 {
+	if x != nil {
+		return x.Cnt
+	}
+	return 0
+}
+
+func (x *Hits) GetLogs() []*LogEntry {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *Hits) GetProps() []*Prop {
+	if x != nil {
+		return x.Props
+	}
+	return nil
+}
+
+func (x *Hits) GetFireStamp() int64 {
+	if x != nil {
+		return x.FireStamp
+	}
+	return 0
+}
+
+func (x *Hits) GetGroups() []int64 {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+// A client message on the match stream.
+type Request struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Msg:
+	//
+	//	*Request_Rules
+	//	*Request_Entry
+	//	*Request_Eval
+	Msg           isRequest_Msg `protobuf_oneof:"msg"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_wire_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Request) GetMsg() isRequest_Msg {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+func (x *Request) GetRules() []byte {
+	if x != nil {
+		if x, ok := x.Msg.(*Request_Rules); ok {
+			return x.Rules
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetEntry() *LogEntry {
+	if x != nil {
+		if x, ok := x.Msg.(*Request_Entry); ok {
+			return x.Entry
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetEval() int64 {
+	if x != nil {
+		if x, ok := x.Msg.(*Request_Eval); ok {
+			return x.Eval
+		}
+	}
+	return 0
+}
+
+type isRequest_Msg interface {
+	isRequest_Msg()
+}
+
+type Request_Rules struct {
+	Rules []byte `protobuf:"bytes,1,opt,name=rules,proto3,oneof"` // YAML or JSON rule document; replaces any loaded.
+}
+
+type Request_Entry struct {
+	Entry *LogEntry `protobuf:"bytes,2,opt,name=entry,proto3,oneof"` // Entry to scan.
+}
+
+type Request_Eval struct {
+	Eval int64 `protobuf:"varint,3,opt,name=eval,proto3,oneof"` // Clock to evaluate the matchers at.
+}
+
+func (*Request_Rules) isRequest_Msg() {}
+
+func (*Request_Entry) isRequest_Msg() {}
+
+func (*Request_Eval) isRequest_Msg() {}
+
+// A server message on the match stream; either hits or an error.
+type Response struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"` // Id of the rule that fired.
+	Hits          *Hits                  `protobuf:"bytes,2,opt,name=hits,proto3" json:"hits,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // Failed request; the stream remains open.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_wire_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Response) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Response) GetHits() *Hits {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *Response) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// A matcher call and the hits it returned; a trace is a sequence of these,
+// each framed by its varint length.
+type Call struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Call:
+	//
+	//	*Call_Scan
+	//	*Call_Eval
+	//	*Call_Gc
+	Call          isCall_Call `protobuf_oneof:"call"`
+	Hits          *Hits       `protobuf:"bytes,4,opt,name=hits,proto3" json:"hits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Call) Reset() {
+	*x = Call{}
+	mi := &file_wire_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Call) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Call) ProtoMessage() {}
+
+func (x *Call) ProtoReflect() protoreflect.Message {
+	mi := &file_wire_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Call.ProtoReflect.Descriptor instead.
+func (*Call) Descriptor() ([]byte, []int) {
+	return file_wire_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Call) GetCall() isCall_Call {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+func (x *Call) GetScan() *LogEntry {
+	if x != nil {
+		if x, ok := x.Call.(*Call_Scan); ok {
+			return x.Scan
+		}
+	}
+	return nil
+}
+
+func (x *Call) GetEval() int64 {
+	if x != nil {
+		if x, ok := x.Call.(*Call_Eval); ok {
+			return x.Eval
+		}
+	}
+	return 0
+}
+
+func (x *Call) GetGc() int64 {
+	if x != nil {
+		if x, ok := x.Call.(*Call_Gc); ok {
+			return x.Gc
+		}
+	}
+	return 0
+}
+
+func (x *Call) GetHits() *Hits {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+type isCall_Call interface {
+	isCall_Call()
+}
+
+type Call_Scan struct {
+	Scan *LogEntry `protobuf:"bytes,1,opt,name=scan,proto3,oneof"` // Entry scanned.
+}
+
+type Call_Eval struct {
+	Eval int64 `protobuf:"varint,2,opt,name=eval,proto3,oneof"` // Clock evaluated at.
+}
+
+type Call_Gc struct {
+	Gc int64 `protobuf:"varint,3,opt,name=gc,proto3,oneof"` // Clock collected at.
+}
+
+func (*Call_Scan) isCall_Call() {}
+
+func (*Call_Eval) isCall_Call() {}
+
+func (*Call_Gc) isCall_Call() {}
+
+var File_wire_proto protoreflect.FileDescriptor
+
+const file_wire_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"wire.proto\x12\x10logmatch.wire.v1\" \n" +
+	"\x04Span\x12\x18\n" +
+	"\aoffsets\x18\x01 \x03(\x03R\aoffsets\"\x85\x03\n" +
+	"\bLogEntry\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\tR\x04line\x12\x16\n" +
+	"\x06stream\x18\x02 \x01(\tR\x06stream\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x120\n" +
+	"\amatches\x18\x04 \x03(\v2\x16.logmatch.wire.v1.SpanR\amatches\x12\x1f\n" +
+	"\vingest_time\x18\x05 \x01(\x03R\n" +
+	"ingestTime\x12\x14\n" +
+	"\x05props\x18\x06 \x01(\fR\x05props\x12\x16\n" +
+	"\x06offset\x18\a \x01(\x03R\x06offset\x12\x17\n" +
+	"\aline_no\x18\b \x01(\x03R\x06lineNo\x12>\n" +
+	"\x06labels\x18\t \x03(\v2&.logmatch.wire.v1.LogEntry.LabelsEntryR\x06labels\x12\x1a\n" +
+	"\bseverity\x18\n" +
+	" \x01(\x05R\bseverity\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbb\x01\n" +
+	"\x04Term\x12.\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1a.logmatch.wire.v1.TermTypeR\x04type\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
+	"\bdistance\x18\x03 \x01(\x03R\bdistance\x12\x18\n" +
+	"\aoptions\x18\x04 \x01(\rR\aoptions\x12\x14\n" +
+	"\x05count\x18\x05 \x01(\x03R\x05count\x12!\n" +
+	"\fmin_severity\x18\x06 \x01(\x05R\vminSeverity\"\xd2\x01\n" +
+	"\x05Reset\x12*\n" +
+	"\x04term\x18\x01 \x01(\v2\x16.logmatch.wire.v1.TermR\x04term\x12\x16\n" +
+	"\x06window\x18\x02 \x01(\x03R\x06window\x12\x14\n" +
+	"\x05slide\x18\x03 \x01(\x03R\x05slide\x12\x16\n" +
+	"\x06anchor\x18\x04 \x01(\rR\x06anchor\x12\x1a\n" +
+	"\babsolute\x18\x05 \x01(\bR\babsolute\x12\x1d\n" +
+	"\n" +
+	"anchor_end\x18\x06 \x01(\rR\tanchorEnd\x12\x1c\n" +
+	"\tcorrelate\x18\a \x01(\tR\tcorrelate\"@\n" +
+	"\x04Prop\x12\x10\n" +
+	"\x03idx\x18\x01 \x01(\x03R\x03idx\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\"\xad\x01\n" +
+	"\x04Hits\x12\x10\n" +
+	"\x03cnt\x18\x01 \x01(\x03R\x03cnt\x12.\n" +
+	"\x04logs\x18\x02 \x03(\v2\x1a.logmatch.wire.v1.LogEntryR\x04logs\x12,\n" +
+	"\x05props\x18\x03 \x03(\v2\x16.logmatch.wire.v1.PropR\x05props\x12\x1d\n" +
+	"\n" +
+	"fire_stamp\x18\x04 \x01(\x03R\tfireStamp\x12\x16\n" +
+	"\x06groups\x18\x05 \x03(\x03R\x06groups\"r\n" +
+	"\aRequest\x12\x16\n" +
+	"\x05rules\x18\x01 \x01(\fH\x00R\x05rules\x122\n" +
+	"\x05entry\x18\x02 \x01(\v2\x1a.logmatch.wire.v1.LogEntryH\x00R\x05entry\x12\x14\n" +
+	"\x04eval\x18\x03 \x01(\x03H\x00R\x04evalB\x05\n" +
+	"\x03msg\"`\n" +
+	"\bResponse\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12*\n" +
+	"\x04hits\x18\x02 \x01(\v2\x16.logmatch.wire.v1.HitsR\x04hits\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x94\x01\n" +
+	"\x04Call\x120\n" +
+	"\x04scan\x18\x01 \x01(\v2\x1a.logmatch.wire.v1.LogEntryH\x00R\x04scan\x12\x14\n" +
+	"\x04eval\x18\x02 \x01(\x03H\x00R\x04eval\x12\x10\n" +
+	"\x02gc\x18\x03 \x01(\x03H\x00R\x02gc\x12*\n" +
+	"\x04hits\x18\x04 \x01(\v2\x16.logmatch.wire.v1.HitsR\x04hitsB\x06\n" +
+	"\x04call*\xa5\x01\n" +
+	"\bTermType\x12\f\n" +
+	"\bTERM_RAW\x10\x00\x12\x0e\n" +
+	"\n" +
+	"TERM_REGEX\x10\x01\x12\x10\n" +
+	"\fTERM_JQ_JSON\x10\x02\x12\x10\n" +
+	"\fTERM_JQ_YAML\x10\x03\x12\x15\n" +
+	"\x11TERM_JQ_JSON_DIFF\x10\x04\x12\x0e\n" +
+	"\n" +
+	"TERM_FUZZY\x10\x05\x12\x0f\n" +
+	"\vTERM_LOGFMT\x10\x06\x12\x10\n" +
+	"\fTERM_NUMERIC\x10\a\x12\r\n" +
+	"\tTERM_CIDR\x10\b2L\n" +
+	"\x05Match\x12C\n" +
+	"\x06Stream\x12\x19.logmatch.wire.v1.Request\x1a\x1a.logmatch.wire.v1.Response(\x010\x01B9Z7github.com/prequel-dev/prequel-logmatch/pkg/wire/wirepbb\x06proto3"
+
+var (
+	file_wire_proto_rawDescOnce sync.Once
+	file_wire_proto_rawDescData []byte
+)
+
+func file_wire_proto_rawDescGZIP() []byte {
+	file_wire_proto_rawDescOnce.Do(func() {
+		file_wire_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wire_proto_rawDesc), len(file_wire_proto_rawDesc)))
+	})
+	return file_wire_proto_rawDescData
+}
+
+var file_wire_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_wire_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_wire_proto_goTypes = []any{
+	(TermType)(0),    // 0: logmatch.wire.v1.TermType
+	(*Span)(nil),     // 1: logmatch.wire.v1.Span
+	(*LogEntry)(nil), // 2: logmatch.wire.v1.LogEntry
+	(*Term)(nil),     // 3: logmatch.wire.v1.Term
+	(*Reset)(nil),    // 4: logmatch.wire.v1.Reset
+	(*Prop)(nil),     // 5: logmatch.wire.v1.Prop
+	(*Hits)(nil),     // 6: logmatch.wire.v1.Hits
+	(*Request)(nil),  // 7: logmatch.wire.v1.Request
+	(*Response)(nil), // 8: logmatch.wire.v1.Response
+	(*Call)(nil),     // 9: logmatch.wire.v1.Call
+	nil,              // 10: logmatch.wire.v1.LogEntry.LabelsEntry
+}
+var file_wire_proto_depIdxs = []int32{
+	1,  // 0: logmatch.wire.v1.LogEntry.matches:type_name -> logmatch.wire.v1.Span
+	10, // 1: logmatch.wire.v1.LogEntry.labels:type_name -> logmatch.wire.v1.LogEntry.LabelsEntry
+	0,  // 2: logmatch.wire.v1.Term.type:type_name -> logmatch.wire.v1.TermType
+	3,  // 3: logmatch.wire.v1.Reset.term:type_name -> logmatch.wire.v1.Term
+	2,  // 4: logmatch.wire.v1.Hits.logs:type_name -> logmatch.wire.v1.LogEntry
+	5,  // 5: logmatch.wire.v1.Hits.props:type_name -> logmatch.wire.v1.Prop
+	2,  // 6: logmatch.wire.v1.Request.entry:type_name -> logmatch.wire.v1.LogEntry
+	6,  // 7: logmatch.wire.v1.Response.hits:type_name -> logmatch.wire.v1.Hits
+	2,  // 8: logmatch.wire.v1.Call.scan:type_name -> logmatch.wire.v1.LogEntry
+	6,  // 9: logmatch.wire.v1.Call.hits:type_name -> logmatch.wire.v1.Hits
+	7,  // 10: logmatch.wire.v1.Match.Stream:input_type -> logmatch.wire.v1.Request
+	8,  // 11: logmatch.wire.v1.Match.Stream:output_type -> logmatch.wire.v1.Response
+	11, // [11:12] is the sub-list for method output_type
+	10, // [10:11] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_wire_proto_init() }
+func file_wire_proto_init() {
+	if File_wire_proto != nil {
+		return
+	}
+	file_wire_proto_msgTypes[6].OneofWrappers = []any{
+		(*Request_Rules)(nil),
+		(*Request_Entry)(nil),
+		(*Request_Eval)(nil),
+	}
+	file_wire_proto_msgTypes[8].OneofWrappers = []any{
+		(*Call_Scan)(nil),
+		(*Call_Eval)(nil),
+		(*Call_Gc)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wire_proto_rawDesc), len(file_wire_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wire_proto_goTypes,
+		DependencyIndexes: file_wire_proto_depIdxs,
+		EnumInfos:         file_wire_proto_enumTypes,
+		MessageInfos:      file_wire_proto_msgTypes,
+	}.Build()
+	File_wire_proto = out.File
+	file_wire_proto_goTypes = nil
+	file_wire_proto_depIdxs = nil
+}
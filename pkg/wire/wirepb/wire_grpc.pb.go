@@ -0,0 +1,125 @@
+// Canonical wire format for shipping matcher inputs and results between
+// processes.  Encoded and decoded by the wire package; see wire.go.  The
+// generated types, in wirepb, serve gRPC clients and servers.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: wire.proto
+
+package wirepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Match_Stream_FullMethodName = "/logmatch.wire.v1.Match/Stream"
+)
+
+// MatchClient is the client API for Match service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Match runs the rules last pushed by the client against the entries that
+// follow, streaming back hits as they fire.
+type MatchClient interface {
+	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Request, Response], error)
+}
+
+type matchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMatchClient(cc grpc.ClientConnInterface) MatchClient {
+	return &matchClient{cc}
+}
+
+func (c *matchClient) Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Request, Response], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Match_ServiceDesc.Streams[0], Match_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Request, Response]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Match_StreamClient = grpc.BidiStreamingClient[Request, Response]
+
+// MatchServer is the server API for Match service.
+// All implementations must embed UnimplementedMatchServer
+// for forward compatibility.
+//
+// Match runs the rules last pushed by the client against the entries that
+// follow, streaming back hits as they fire.
+type MatchServer interface {
+	Stream(grpc.BidiStreamingServer[Request, Response]) error
+	mustEmbedUnimplementedMatchServer()
+}
+
+// UnimplementedMatchServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMatchServer struct{}
+
+func (UnimplementedMatchServer) Stream(grpc.BidiStreamingServer[Request, Response]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedMatchServer) mustEmbedUnimplementedMatchServer() {}
+func (UnimplementedMatchServer) testEmbeddedByValue()               {}
+
+// UnsafeMatchServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MatchServer will
+// result in compilation errors.
+type UnsafeMatchServer interface {
+	mustEmbedUnimplementedMatchServer()
+}
+
+func RegisterMatchServer(s grpc.ServiceRegistrar, srv MatchServer) {
+	// If the following call pancis, it indicates UnimplementedMatchServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Match_ServiceDesc, srv)
+}
+
+func _Match_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MatchServer).Stream(&grpc.GenericServerStream[Request, Response]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Match_StreamServer = grpc.BidiStreamingServer[Request, Response]
+
+// Match_ServiceDesc is the grpc.ServiceDesc for Match service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Match_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logmatch.wire.v1.Match",
+	HandlerType: (*MatchServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Match_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "wire.proto",
+}